@@ -3,77 +3,53 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
+	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"insightful-intel/internal/database"
+	"insightful-intel/internal/infra"
 	"insightful-intel/internal/interactor"
 	"insightful-intel/internal/repositories"
 	"insightful-intel/internal/server"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
-	// Create context that listens for the interrupt signal from the OS.
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
-	// Listen for the interrupt signal.
-	<-ctx.Done()
-
-	log.Println("shutting down gracefully, press Ctrl+C again to force")
-	stop() // Allow Ctrl+C to force shutdown
-
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := apiServer.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
-	}
-
-	log.Println("Server exiting")
-
-	// Notify the main goroutine that the shutdown is complete
-	done <- true
-}
-
 func main() {
-	// Initialize database
-	db := database.New()
+	infra.SetupLogger()
 
-	// Run database migrations
-	if err := runMigrations(db); err != nil {
-		panic(fmt.Sprintf("failed to run migrations: %v", err))
+	// Initialize database, unless the records are kept in memory
+	var db database.Service
+	if database.Driver() != database.DriverMemory {
+		db = database.New()
+
+		// Run database migrations
+		if err := runMigrations(db); err != nil {
+			panic(fmt.Sprintf("failed to run migrations: %v", err))
+		}
 	}
 
 	// Initialize repository factory
 	repoFactory := repositories.NewRepositoryFactory(db)
 
 	dynamicPipelineInteractor := interactor.NewDynamicPipelineInteractor(repoFactory)
-	server := server.NewServer(repoFactory, dynamicPipelineInteractor)
-
-	// Create a done channel to signal when the shutdown is complete
-	done := make(chan bool, 1)
+	apiServer := server.NewServer(db, repoFactory, dynamicPipelineInteractor)
 
-	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	// Serve until SIGINT or SIGTERM, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// Once shutting down, a second Ctrl+C forces the exit
+	context.AfterFunc(ctx, stop)
 
-	err := server.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		panic(fmt.Sprintf("http server error: %s", err))
+	if err := apiServer.Run(ctx); err != nil {
+		slog.Error("server stopped with error", slog.Any("error", err))
+		os.Exit(1)
 	}
-
-	// Wait for the graceful shutdown to complete
-	<-done
-	log.Println("Graceful shutdown complete.")
+	slog.Info("graceful shutdown complete")
 }
 
 // runMigrations executes database migrations
 func runMigrations(db database.Service) error {
 	migrationService := database.NewMigrationService(db.GetDB())
-	migrations := database.GetInitialMigrations()
-	return migrationService.RunMigrations(migrations)
+	return migrationService.RunMigrations(migrationService.Migrations())
 }
@@ -2,34 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"time"
 
 	"insightful-intel/internal/database"
+	"insightful-intel/internal/domain"
 	"insightful-intel/internal/infra"
 	"insightful-intel/internal/interactor"
+	"insightful-intel/internal/module"
 	"insightful-intel/internal/repositories"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-// contextKey is a custom type for context keys
-type contextKey string
-
-const executionIDKey contextKey = "executionID"
-
 var (
 	maxDepth       int
 	skipDuplicates bool
-)
+	delayMs        int
 
-// GetExecutionID retrieves the execution ID from the context
-func GetExecutionID(ctx context.Context) (string, bool) {
-	executionID, ok := ctx.Value(executionIDKey).(string)
-	return executionID, ok
-}
+	migrateUp     bool
+	migrateDown   int
+	migrateStatus bool
+)
 
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
@@ -38,54 +37,148 @@ var rootCmd = &cobra.Command{
 	Long:  "A CLI tool for running dynamic pipeline searches across multiple domains",
 }
 
+// seedArgs accepts a single argument that is a valid seed query
+func seedArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	if _, err := module.ValidateSeed(args[0]); err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	return nil
+}
+
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Use:   "run [query]",
 	Short: "Run dynamic pipeline search",
 	Long: `Run a dynamic pipeline search with the specified query across multiple domains.
 The search will explore related entities across ONAPI, SCJ, DGII, PGR, and Google Docking.`,
-	Args: cobra.ExactArgs(1),
+	Args: seedArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if delayMs < 0 {
+			return fmt.Errorf("--delay-ms must not be negative, got %d", delayMs)
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		query := args[0]
+		query, _ := module.ValidateSeed(args[0])
 
 		// Generate a unique execution ID
 		executionID := uuid.New()
 
-		log.Printf("Starting CLI execution with ID: %s", executionID.String())
-
-		db := database.New()
+		var db database.Service
+		if database.Driver() != database.DriverMemory {
+			db = database.New()
+		}
 
 		repositoryFactory := repositories.NewRepositoryFactory(db)
 		dynamicPipelineInteractor := interactor.NewDynamicPipelineInteractor(repositoryFactory)
 
 		// Create context with execution ID
 		ctx := infra.SetExecutionID(context.Background(), executionID.String())
+		logger := infra.Logger(ctx)
 
-		log.Printf("Executing dynamic pipeline [%s] with query: %s, max depth: %d, skip duplicates: %v",
-			executionID.String(), query, maxDepth, skipDuplicates)
+		config := interactor.DefaultPipelineConfig(ctx, query, maxDepth, skipDuplicates)
+		config.DelayBetweenSteps = domain.Seconds(time.Duration(delayMs) * time.Millisecond)
 
-		dynamicResult, err := dynamicPipelineInteractor.ExecuteDynamicPipeline(ctx, query, maxDepth, skipDuplicates)
+		logger.Info("executing dynamic pipeline",
+			slog.String("query", query),
+			slog.Int("max_depth", maxDepth),
+			slog.Bool("skip_duplicates", skipDuplicates),
+			slog.Duration("delay_between_steps", config.DelayBetweenSteps.Duration()),
+		)
+
+		dynamicResult, err := dynamicPipelineInteractor.ExecuteDynamicPipelineWithConfig(ctx, config)
 		if err != nil {
-			log.Fatalf("[%s] failed to execute dynamic pipeline: %v", executionID.String(), err)
+			logger.Error("failed to execute dynamic pipeline", slog.Any("error", err))
+			closeDatabase(db)
+			os.Exit(1)
 		}
 
 		fmt.Println(dynamicResult)
 
-		log.Printf("[%s] Dynamic pipeline execution completed", executionID.String())
+		logger.Info("dynamic pipeline execution completed")
+
+		closeDatabase(db)
 	},
 }
 
-func main() {
-	// Initialize database
-	db := database.New()
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Run, roll back or list database migrations",
+	Long: `Run the pending database migrations with --up, roll back the last N executed
+migrations with --down N, or list the applied and pending migrations with --status.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if database.Driver() == database.DriverMemory {
+			return errors.New("nothing to migrate: DB_DRIVER=memory keeps the records in memory")
+		}
+		if cmd.Flags().Changed("down") && migrateDown < 1 {
+			return fmt.Errorf("--down must roll back at least 1 migration, got %d", migrateDown)
+		}
 
-	log.Println("Running migrations")
+		db := database.New()
+		defer closeDatabase(db)
 
-	if err := runMigrations(db); err != nil {
-		log.Fatalf("failed to run migrations: %v", err)
+		migrationService := database.NewMigrationService(db.GetDB())
+		return migrate(cmd.OutOrStdout(), migrationService, migrationService.Migrations(), migrateDown, migrateStatus)
+	},
+}
+
+// migrate rolls back the last down migrations when down is positive, writes
+// the status of the migrations to out when status is set, and otherwise runs
+// the pending migrations
+func migrate(out io.Writer, migrationService *database.MigrationService, migrations []database.Migration, down int, status bool) error {
+	switch {
+	case down > 0:
+		return migrationService.RollbackMigrations(migrations, down)
+	case status:
+		statuses, err := migrationService.Status(migrations)
+		if err != nil {
+			return err
+		}
+		for _, migration := range statuses {
+			state := "pending"
+			if migration.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(out, "%-8s %3d  %s\n", state, migration.Version, migration.Name)
+		}
+		return nil
+	default:
+		return migrationService.RunMigrations(migrations)
 	}
+}
 
-	log.Println("Migrations completed")
+// closeDatabase closes the database the command opened, if any
+func closeDatabase(db database.Service) {
+	if db == nil {
+		return
+	}
+	if err := db.Close(); err != nil {
+		slog.Error("failed to close database", slog.Any("error", err))
+	}
+}
+
+func main() {
+	infra.SetupLogger()
+
+	// Initialize database, unless the records are kept in memory or the
+	// migrations are run by the migrate command
+	if database.Driver() != database.DriverMemory && !invokesMigrate(os.Args[1:]) {
+		db := database.New()
+
+		slog.Info("running migrations")
+
+		if err := runMigrations(db); err != nil {
+			slog.Error("failed to run migrations", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		slog.Info("migrations completed")
+	}
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -100,15 +193,30 @@ func init() {
 	// Add flags for run command
 	runCmd.Flags().IntVarP(&maxDepth, "max-depth", "d", 5, "Maximum depth for pipeline execution")
 	runCmd.Flags().BoolVarP(&skipDuplicates, "skip-duplicates", "s", true, "Skip duplicate searches")
+	runCmd.Flags().IntVar(&delayMs, "delay-ms", int(domain.DefaultDelayBetweenSteps.Duration().Milliseconds()), "Pause after each step, in milliseconds")
 
 	// Set description for flags
 	runCmd.Flags().Lookup("max-depth").Usage = "Maximum depth to traverse in the pipeline (default: 5)"
 	runCmd.Flags().Lookup("skip-duplicates").Usage = "Skip searching duplicate keywords across domains (default: true)"
+
+	// Add migrate command to root
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().BoolVar(&migrateUp, "up", false, "Run the pending migrations")
+	migrateCmd.Flags().IntVar(&migrateDown, "down", 0, "Roll back the last N executed migrations")
+	migrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "List the applied and pending migrations")
+	migrateCmd.MarkFlagsMutuallyExclusive("up", "down", "status")
+	migrateCmd.MarkFlagsOneRequired("up", "down", "status")
+}
+
+// invokesMigrate reports whether the command line runs the migrate command
+func invokesMigrate(args []string) bool {
+	cmd, _, err := rootCmd.Find(args)
+	return err == nil && cmd == migrateCmd
 }
 
 // runMigrations executes database migrations
 func runMigrations(db database.Service) error {
 	migrationService := database.NewMigrationService(db.GetDB())
-	migrations := database.GetInitialMigrations()
-	return migrationService.RunMigrations(migrations)
+	return migrationService.RunMigrations(migrationService.Migrations())
 }
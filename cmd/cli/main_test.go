@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"insightful-intel/internal/database"
+	"insightful-intel/internal/domain"
+)
+
+func TestSeedArgs(t *testing.T) {
+	for name, args := range map[string][]string{
+		"missing":    {},
+		"extra":      {"Novasco", "SRL"},
+		"empty":      {""},
+		"whitespace": {"  \t "},
+		"oversized":  {strings.Repeat("a", domain.DefaultMaxSeedLength+1)},
+	} {
+		if err := seedArgs(runCmd, args); err == nil {
+			t.Errorf("%s: expected the arguments %q to be rejected", name, args)
+		}
+	}
+
+	if err := seedArgs(runCmd, []string{"  Novasco  "}); err != nil {
+		t.Errorf("expected a padded seed to be accepted, got %v", err)
+	}
+}
+
+// newMigrationService returns a migration service over an in-memory SQLite
+// database
+func newMigrationService(t *testing.T) (*database.MigrationService, *sql.DB) {
+	t.Helper()
+
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return database.NewMigrationService(db), db
+}
+
+// executedVersions returns the versions recorded in the migrations table
+func executedVersions(t *testing.T, db *sql.DB) []int {
+	t.Helper()
+
+	rows, err := db.Query(`SELECT version FROM migrations ORDER BY version`)
+	if err != nil {
+		t.Fatalf("failed to query migrations: %v", err)
+	}
+	defer rows.Close()
+
+	var versions []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatalf("failed to scan migration: %v", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// tableExists reports whether the SQLite database has the table
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatalf("failed to look up table %s: %v", name, err)
+	}
+	return count > 0
+}
+
+func TestMigrateUpThenDown(t *testing.T) {
+	migrationService, db := newMigrationService(t)
+	migrations := migrationService.Migrations()
+
+	if err := migrate(&bytes.Buffer{}, migrationService, migrations, 0, false); err != nil {
+		t.Fatalf("migrate up returned error: %v", err)
+	}
+	if versions := executedVersions(t, db); len(versions) != len(migrations) {
+		t.Fatalf("expected %d executed migrations, got %v", len(migrations), versions)
+	}
+	if !tableExists(t, db, "dynamic_pipeline_results") {
+		t.Fatalf("expected the repository tables to be created")
+	}
+
+	var status bytes.Buffer
+	if err := migrate(&status, migrationService, migrations, 0, true); err != nil {
+		t.Fatalf("migrate status returned error: %v", err)
+	}
+	if !strings.Contains(status.String(), "applied") || strings.Contains(status.String(), "pending") {
+		t.Errorf("expected every migration to be applied, got:\n%s", status.String())
+	}
+
+	if err := migrate(&bytes.Buffer{}, migrationService, migrations, len(migrations), false); err != nil {
+		t.Fatalf("migrate down returned error: %v", err)
+	}
+	if versions := executedVersions(t, db); len(versions) != 0 {
+		t.Errorf("expected no executed migrations, got %v", versions)
+	}
+	if tableExists(t, db, "dynamic_pipeline_results") {
+		t.Errorf("expected the repository tables to be dropped")
+	}
+
+	status.Reset()
+	migrate(&status, migrationService, migrations, 0, true)
+	if strings.Contains(status.String(), "applied") || !strings.Contains(status.String(), "pending") {
+		t.Errorf("expected every migration to be pending, got:\n%s", status.String())
+	}
+}
+
+func TestMigrateDownRollsBackTheLastMigrations(t *testing.T) {
+	migrationService, db := newMigrationService(t)
+	migrations := []database.Migration{
+		{Version: 1, Name: "create_first", UpSQL: `CREATE TABLE first (id INTEGER)`, DownSQL: `DROP TABLE first`},
+		{Version: 2, Name: "create_second", UpSQL: `CREATE TABLE second (id INTEGER)`, DownSQL: `DROP TABLE second`},
+		{Version: 3, Name: "create_third", UpSQL: `CREATE TABLE third (id INTEGER)`, DownSQL: `DROP TABLE third`},
+	}
+
+	if err := migrate(&bytes.Buffer{}, migrationService, migrations, 0, false); err != nil {
+		t.Fatalf("migrate up returned error: %v", err)
+	}
+	if err := migrate(&bytes.Buffer{}, migrationService, migrations, 2, false); err != nil {
+		t.Fatalf("migrate down returned error: %v", err)
+	}
+
+	if versions := executedVersions(t, db); len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("expected only the first migration to remain, got %v", versions)
+	}
+	if !tableExists(t, db, "first") || tableExists(t, db, "second") || tableExists(t, db, "third") {
+		t.Errorf("expected only the last two tables to be dropped")
+	}
+
+	var status bytes.Buffer
+	migrate(&status, migrationService, migrations, 0, true)
+	want := "applied    1  create_first\npending    2  create_second\npending    3  create_third\n"
+	if status.String() != want {
+		t.Errorf("expected status:\n%s\ngot:\n%s", want, status.String())
+	}
+
+	// Rolling back more migrations than were executed stops at none
+	if err := migrate(&bytes.Buffer{}, migrationService, migrations, 5, false); err != nil {
+		t.Fatalf("migrate down returned error: %v", err)
+	}
+	if versions := executedVersions(t, db); len(versions) != 0 {
+		t.Errorf("expected no executed migrations, got %v", versions)
+	}
+}
@@ -174,7 +174,7 @@ export function createPipelineStream(
     }
   });
 
-  eventSource.addEventListener('sumary', (event) => {
+  eventSource.addEventListener('summary', (event) => {
     try {
       const data = JSON.parse(event.data);
       onStep(data);
@@ -0,0 +1,126 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrBudgetExhausted is returned for requests made after the budget in their
+// context has been spent
+var ErrBudgetExhausted = errors.New("outbound budget exhausted")
+
+// Budget caps the outbound requests and bytes of a pipeline run. A zero limit
+// means unlimited.
+type Budget struct {
+	MaxRequests int64
+	MaxBytes    int64
+
+	requests atomic.Int64
+	bytes    atomic.Int64
+}
+
+type budgetKey struct{}
+
+// NewBudget creates a budget with the given limits
+func NewBudget(maxRequests, maxBytes int64) *Budget {
+	return &Budget{
+		MaxRequests: maxRequests,
+		MaxBytes:    maxBytes,
+	}
+}
+
+// WithBudget returns a context whose outbound requests are counted against the budget
+func WithBudget(ctx context.Context, budget *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// BudgetFromContext returns the budget of the context, or nil when it has none
+func BudgetFromContext(ctx context.Context) *Budget {
+	budget, _ := ctx.Value(budgetKey{}).(*Budget)
+	return budget
+}
+
+// Record adds requests and bytes to the usage of the budget
+func (b *Budget) Record(requests, bytes int64) {
+	if b == nil {
+		return
+	}
+	b.requests.Add(requests)
+	b.bytes.Add(bytes)
+}
+
+// Requests returns the number of outbound requests made so far
+func (b *Budget) Requests() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.requests.Load()
+}
+
+// Bytes returns the number of bytes sent and received so far
+func (b *Budget) Bytes() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.bytes.Load()
+}
+
+// Exhausted reports whether either limit has been reached
+func (b *Budget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	if b.MaxRequests > 0 && b.Requests() >= b.MaxRequests {
+		return true
+	}
+	if b.MaxBytes > 0 && b.Bytes() >= b.MaxBytes {
+		return true
+	}
+	return false
+}
+
+// budgetTransport counts the requests and bytes of every request whose context
+// carries a budget, and refuses new requests once it is exhausted
+type budgetTransport struct {
+	base http.RoundTripper
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	budget := BudgetFromContext(req.Context())
+	if budget == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	if budget.Exhausted() {
+		return nil, ErrBudgetExhausted
+	}
+
+	sent := int64(len(req.URL.String()))
+	if req.ContentLength > 0 {
+		sent += req.ContentLength
+	}
+	budget.Record(1, sent)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &countingBody{ReadCloser: resp.Body, budget: budget}
+	return resp, nil
+}
+
+// countingBody records the bytes read from a response body
+type countingBody struct {
+	io.ReadCloser
+	budget *Budget
+}
+
+func (c *countingBody) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.budget.Record(0, int64(n))
+	return n, err
+}
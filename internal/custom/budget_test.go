@@ -0,0 +1,44 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBudgetTransportCountsAndRefuses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer srv.Close()
+
+	client := NewClient()
+	budget := NewBudget(1, 0)
+	ctx := WithBudget(context.Background(), budget)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		t.Fatalf("first request returned error: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if budget.Requests() != 1 {
+		t.Errorf("expected 1 request, got %d", budget.Requests())
+	}
+	if want := int64(len(srv.URL) + 10); budget.Bytes() != want {
+		t.Errorf("expected %d bytes, got %d", want, budget.Bytes())
+	}
+	if !budget.Exhausted() {
+		t.Fatal("expected the budget to be exhausted")
+	}
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Client.Do(req); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("expected ErrBudgetExhausted, got %v", err)
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	neturl "net/url"
 	"time"
@@ -15,6 +14,8 @@ import (
 type Client struct {
 	RequestParams RequestParams
 	Client        *http.Client
+	// UserAgents provides the User-Agent of the requests that do not set one
+	UserAgents *UserAgentPool
 }
 
 type RequestParams struct {
@@ -26,24 +27,22 @@ type RequestParams struct {
 	Timeout  time.Duration
 }
 
-func NewClient() *Client {
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout:   10 * time.Second, // Connection timeout
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		TLSHandshakeTimeout: 5 * time.Second, // TLS handshake timeout
-	}
+// NewClient creates a client whose requests go through the proxy set by
+// OSINT_PROXY_URL or HTTP_PROXY, and rotate the user agents set by
+// USER_AGENTS, unless options set others
+func NewClient(options ...ClientOption) *Client {
+	o := newClientOptions(options)
 
 	return &Client{
 		Client: &http.Client{
 			Timeout:   30 * time.Second,
-			Transport: transport,
+			Transport: &budgetTransport{base: &rateLimitTransport{base: newTransport(o)}},
 		},
+		UserAgents: o.userAgents,
 	}
 }
 
-func (s *Client) Do() (*http.Response, error) {
+func (s *Client) Do(ctx context.Context) (*http.Response, error) {
 	if s.RequestParams.Endpoint == "" {
 		return nil, fmt.Errorf("endpoint is required")
 	}
@@ -69,8 +68,15 @@ func (s *Client) Do() (*http.Response, error) {
 		body = bytes.NewBufferString(s.RequestParams.Body)
 	}
 
+	// Set timeout if specified
+	if s.RequestParams.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.RequestParams.Timeout)
+		defer cancel()
+	}
+
 	// Create HTTP request
-	req, err := http.NewRequest(s.RequestParams.Method, url, body)
+	req, err := http.NewRequestWithContext(ctx, s.RequestParams.Method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -79,62 +85,76 @@ func (s *Client) Do() (*http.Response, error) {
 	for key, value := range s.RequestParams.Headers {
 		req.Header.Set(key, value)
 	}
+	if req.Header.Get("User-Agent") == "" && s.UserAgents != nil {
+		req.Header.Set("User-Agent", s.UserAgents.Next())
+	}
 
-	// Set timeout if specified
-	ctx := req.Context()
-	if s.RequestParams.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, s.RequestParams.Timeout)
-		defer cancel()
-		req = req.WithContext(ctx)
+	// Only request the encodings the response can be decompressed from. Without
+	// any, the transport requests and decompresses gzip itself.
+	if encoding := req.Header.Get("Accept-Encoding"); encoding != "" {
+		if encoding = acceptEncoding(encoding); encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		} else {
+			req.Header.Del("Accept-Encoding")
+		}
 	}
 
 	// Execute request
-	return s.Client.Do(req)
+	response, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers read the body as is, so compressed bodies are decompressed here
+	if err := decodeResponse(response); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	return response, nil
 }
 
 // Helper methods for common HTTP operations
-func (s *Client) Get(endpoint string, params map[string]string, headers map[string]string) (*http.Response, error) {
+func (s *Client) Get(ctx context.Context, endpoint string, params map[string]string, headers map[string]string) (*http.Response, error) {
 	s.RequestParams = RequestParams{
 		Method:   "GET",
 		Endpoint: endpoint,
 		Params:   params,
 		Headers:  headers,
 	}
-	return s.Do()
+	return s.Do(ctx)
 }
 
-func (s *Client) Post(endpoint string, body string, headers map[string]string) (*http.Response, error) {
+func (s *Client) Post(ctx context.Context, endpoint string, body string, headers map[string]string) (*http.Response, error) {
 	s.RequestParams = RequestParams{
 		Method:   "POST",
 		Endpoint: endpoint,
 		Body:     body,
 		Headers:  headers,
 	}
-	return s.Do()
+	return s.Do(ctx)
 }
 
-func (s *Client) Put(endpoint string, body string, headers map[string]string) (*http.Response, error) {
+func (s *Client) Put(ctx context.Context, endpoint string, body string, headers map[string]string) (*http.Response, error) {
 	s.RequestParams = RequestParams{
 		Method:   "PUT",
 		Endpoint: endpoint,
 		Body:     body,
 		Headers:  headers,
 	}
-	return s.Do()
+	return s.Do(ctx)
 }
 
-func (s *Client) Delete(endpoint string, headers map[string]string) (*http.Response, error) {
+func (s *Client) Delete(ctx context.Context, endpoint string, headers map[string]string) (*http.Response, error) {
 	s.RequestParams = RequestParams{
 		Method:   "DELETE",
 		Endpoint: endpoint,
 		Headers:  headers,
 	}
-	return s.Do()
+	return s.Do(ctx)
 }
 
 // JSON helper methods
-func (s *Client) PostJSON(endpoint string, data interface{}, headers map[string]string) (*http.Response, error) {
+func (s *Client) PostJSON(ctx context.Context, endpoint string, data interface{}, headers map[string]string) (*http.Response, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
@@ -145,10 +165,10 @@ func (s *Client) PostJSON(endpoint string, data interface{}, headers map[string]
 	}
 	headers["Content-Type"] = "application/json"
 
-	return s.Post(endpoint, string(jsonData), headers)
+	return s.Post(ctx, endpoint, string(jsonData), headers)
 }
 
-func (s *Client) PutJSON(endpoint string, data interface{}, headers map[string]string) (*http.Response, error) {
+func (s *Client) PutJSON(ctx context.Context, endpoint string, data interface{}, headers map[string]string) (*http.Response, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
@@ -159,7 +179,7 @@ func (s *Client) PutJSON(endpoint string, data interface{}, headers map[string]s
 	}
 	headers["Content-Type"] = "application/json"
 
-	return s.Put(endpoint, string(jsonData), headers)
+	return s.Put(ctx, endpoint, string(jsonData), headers)
 }
 
 // Set timeout for the client
@@ -171,3 +191,24 @@ func (s *Client) SetTimeout(timeout time.Duration) {
 func (s *Client) SetClient(client *http.Client) {
 	s.Client = client
 }
+
+// NewContextTransport returns a transport that attaches ctx to every request,
+// counts it against the budget of ctx and waits on its request limiter, for
+// HTTP clients built by third-party libraries rather than by NewClient.
+// Requests go through the proxy set in the environment, as those of NewClient
+// do.
+func NewContextTransport(ctx context.Context) http.RoundTripper {
+	return &contextTransport{
+		ctx:  ctx,
+		base: &budgetTransport{base: &rateLimitTransport{base: contextBaseTransport()}},
+	}
+}
+
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
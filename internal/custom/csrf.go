@@ -0,0 +1,255 @@
+package custom
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultCSRFTokenTTL is how long an anti-forgery token is reused when the
+// configuration sets no TTL
+const DefaultCSRFTokenTTL = 10 * time.Minute
+
+// ErrCSRFTokenNotFound is returned when the pre-flight page holds no token
+var ErrCSRFTokenNotFound = errors.New("anti-forgery token not found")
+
+// CSRFConfig describes how a search page hands out the anti-forgery token its
+// search requests must carry
+type CSRFConfig struct {
+	// PageURL is fetched before the search to obtain the token
+	PageURL string
+	// InputName is the name of the hidden input, or of the meta tag, holding
+	// the token. It is used when Pattern is nil.
+	InputName string
+	// Pattern extracts the token from the page body as its first submatch
+	Pattern *regexp.Regexp
+	// Field is the form field and Header the header the token is sent in.
+	// Either may be empty, and Field defaults to InputName.
+	Field  string
+	Header string
+	// TTL is how long a token is reused, DefaultCSRFTokenTTL when zero
+	TTL time.Duration
+}
+
+// CSRFSession performs the searches of a page protected by an anti-forgery
+// token: the token is fetched with a pre-flight GET, cached for the session and
+// fetched again when the page rejects it. The session keeps the cookies the
+// token is bound to.
+type CSRFSession struct {
+	Config CSRFConfig
+
+	client  *Client
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// NewCSRFSession creates a session sending its requests with client, giving
+// the client a cookie jar when it has none
+func NewCSRFSession(client *Client, config CSRFConfig) *CSRFSession {
+	if client.Client.Jar == nil {
+		jar, _ := cookiejar.New(nil)
+		client.Client.Jar = jar
+	}
+	if config.Field == "" {
+		config.Field = config.InputName
+	}
+	if config.TTL <= 0 {
+		config.TTL = DefaultCSRFTokenTTL
+	}
+
+	return &CSRFSession{
+		Config: config,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Token returns the cached token, fetching a new one when it has expired
+func (s *CSRFSession) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.now().Before(s.expires) {
+		return s.token, nil
+	}
+
+	token, err := s.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expires = token, s.now().Add(s.Config.TTL)
+	return token, nil
+}
+
+// Invalidate drops the cached token, so the next request fetches a new one
+func (s *CSRFSession) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// Client returns the client the session sends its requests with. Connectors
+// send their other requests with a copy of it, so all of them share the
+// cookies the token is bound to.
+func (s *CSRFSession) Client() *Client {
+	return s.client
+}
+
+// PostForm posts form to endpoint with the token of the session. A request
+// rejected as forbidden is retried once with a fresh token.
+func (s *CSRFSession) PostForm(ctx context.Context, endpoint string, form url.Values, headers map[string]string) (*http.Response, error) {
+	return s.withToken(ctx, func(token string) (*http.Response, error) {
+		values := make(url.Values, len(form)+1)
+		for key, value := range form {
+			values[key] = value
+		}
+		if s.Config.Field != "" {
+			values.Set(s.Config.Field, token)
+		}
+
+		requestHeaders := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+		for key, value := range headers {
+			requestHeaders[key] = value
+		}
+		if s.Config.Header != "" {
+			requestHeaders[s.Config.Header] = token
+		}
+
+		client := *s.client
+		return client.Post(ctx, endpoint, values.Encode(), requestHeaders)
+	})
+}
+
+// Get sends a GET to endpoint with the token of the session as a query
+// parameter and a header, for the search APIs queried with GETs. A request
+// rejected as forbidden is retried once with a fresh token.
+func (s *CSRFSession) Get(ctx context.Context, endpoint string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	return s.withToken(ctx, func(token string) (*http.Response, error) {
+		requestParams := make(map[string]string, len(params)+1)
+		for key, value := range params {
+			requestParams[key] = value
+		}
+		if s.Config.Field != "" {
+			requestParams[s.Config.Field] = token
+		}
+
+		requestHeaders := make(map[string]string, len(headers)+1)
+		for key, value := range headers {
+			requestHeaders[key] = value
+		}
+		if s.Config.Header != "" {
+			requestHeaders[s.Config.Header] = token
+		}
+
+		client := *s.client
+		return client.Get(ctx, endpoint, requestParams, requestHeaders)
+	})
+}
+
+// withToken sends a request with the token of the session, fetching a fresh
+// token and sending it again once when the page rejects the token
+func (s *CSRFSession) withToken(ctx context.Context, send func(token string) (*http.Response, error)) (*http.Response, error) {
+	response, err := s.send(ctx, send)
+	if err != nil || !rejectsToken(response.StatusCode) {
+		return response, err
+	}
+
+	response.Body.Close()
+	s.Invalidate()
+	return s.send(ctx, send)
+}
+
+func (s *CSRFSession) send(ctx context.Context, send func(token string) (*http.Response, error)) (*http.Response, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return send(token)
+}
+
+// fetchToken loads the page and extracts its token
+func (s *CSRFSession) fetchToken(ctx context.Context) (string, error) {
+	// Each request sends a copy of the client, whose request parameters are
+	// not shared with the concurrent searches
+	client := *s.client
+	response, err := client.Get(ctx, s.Config.PageURL, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch anti-forgery token: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch anti-forgery token: unexpected status code: %d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	token := s.extractToken(body)
+	if token == "" {
+		return "", ErrCSRFTokenNotFound
+	}
+	return token, nil
+}
+
+func (s *CSRFSession) extractToken(body []byte) string {
+	if s.Config.Pattern != nil {
+		match := s.Config.Pattern.FindSubmatch(body)
+		if len(match) < 2 {
+			return ""
+		}
+		return string(match[1])
+	}
+
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var token string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if token != "" {
+			return
+		}
+		if n.Type == html.ElementNode && (n.Data == "input" || n.Data == "meta") {
+			attrs := make(map[string]string, len(n.Attr))
+			for _, attr := range n.Attr {
+				attrs[attr.Key] = attr.Val
+			}
+			if attrs["name"] == s.Config.InputName {
+				token = attrs["value"]
+				if n.Data == "meta" {
+					token = attrs["content"]
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+
+	return token
+}
+
+// rejectsToken reports whether the status is the one pages answer a missing
+// or expired token with
+func rejectsToken(status int) bool {
+	// 419 is the status Laravel answers expired tokens with
+	return status == http.StatusForbidden || status == 419
+}
@@ -0,0 +1,184 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// csrfServer issues a token bound to a cookie on GET /form and accepts POST
+// /search only with the current token of the cookie
+type csrfServer struct {
+	mu     sync.Mutex
+	issued map[string]string
+	gets   int
+	posts  int
+}
+
+func newCSRFServer(t *testing.T) (*csrfServer, *httptest.Server) {
+	t.Helper()
+
+	state := &csrfServer{issued: make(map[string]string)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/form":
+			state.gets++
+			session := fmt.Sprintf("session-%d", state.gets)
+			token := fmt.Sprintf("token-%d", state.gets)
+			state.issued[session] = token
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session})
+			fmt.Fprintf(w, `<html><head><meta name="csrf-token" content="%s"></head><body><form>
+				<input type="hidden" name="__RequestVerificationToken" value="%s" />
+			</form></body></html>`, token, token)
+		case "/search":
+			state.posts++
+			cookie, err := r.Cookie("session")
+			r.ParseForm()
+			if err != nil || r.PostForm.Get("__RequestVerificationToken") != state.issued[cookie.Value] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, "results for %s", r.PostForm.Get("q"))
+		case "/list":
+			cookie, err := r.Cookie("session")
+			if err != nil || r.Header.Get("X-CSRF-Token") != state.issued[cookie.Value] || r.URL.Query().Get("__RequestVerificationToken") != state.issued[cookie.Value] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, "results for %s", r.URL.Query().Get("q"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return state, srv
+}
+
+func readBody(t *testing.T, response *http.Response) string {
+	t.Helper()
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+	return string(body)
+}
+
+func TestCSRFSessionPostsWithPreflightToken(t *testing.T) {
+	state, srv := newCSRFServer(t)
+
+	session := NewCSRFSession(NewClient(), CSRFConfig{
+		PageURL:   srv.URL + "/form",
+		InputName: "__RequestVerificationToken",
+	})
+
+	for _, query := range []string{"novasco", "acme"} {
+		response, err := session.PostForm(context.Background(), srv.URL+"/search", url.Values{"q": {query}}, nil)
+		if err != nil {
+			t.Fatalf("PostForm returned error: %v", err)
+		}
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", response.StatusCode)
+		}
+		if body := readBody(t, response); body != "results for "+query {
+			t.Errorf("unexpected body %q", body)
+		}
+	}
+
+	if state.gets != 1 || state.posts != 2 {
+		t.Errorf("expected one pre-flight reused by both searches, got %d GETs and %d POSTs", state.gets, state.posts)
+	}
+}
+
+func TestCSRFSessionGetsWithToken(t *testing.T) {
+	state, srv := newCSRFServer(t)
+
+	session := NewCSRFSession(NewClient(), CSRFConfig{
+		PageURL:   srv.URL + "/form",
+		InputName: "__RequestVerificationToken",
+		Header:    "X-CSRF-Token",
+	})
+
+	response, err := session.Get(context.Background(), srv.URL+"/list", map[string]string{"q": "novasco"}, nil)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", response.StatusCode)
+	}
+	if body := readBody(t, response); body != "results for novasco" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if state.gets != 1 {
+		t.Errorf("expected one pre-flight, got %d", state.gets)
+	}
+}
+
+func TestCSRFSessionRefetchesRejectedToken(t *testing.T) {
+	state, srv := newCSRFServer(t)
+
+	session := NewCSRFSession(NewClient(), CSRFConfig{
+		PageURL: srv.URL + "/form",
+		Pattern: regexp.MustCompile(`<meta name="csrf-token" content="([^"]+)">`),
+		Field:   "__RequestVerificationToken",
+	})
+	if _, err := session.Token(context.Background()); err != nil {
+		t.Fatalf("Token returned error: %v", err)
+	}
+
+	// The server expires the token
+	state.mu.Lock()
+	state.issued["session-1"] = "expired"
+	state.mu.Unlock()
+
+	response, err := session.PostForm(context.Background(), srv.URL+"/search", url.Values{"q": {"novasco"}}, nil)
+	if err != nil {
+		t.Fatalf("PostForm returned error: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected the search to succeed with a fresh token, got %d", response.StatusCode)
+	}
+	response.Body.Close()
+
+	if state.gets != 2 {
+		t.Errorf("expected a second pre-flight, got %d", state.gets)
+	}
+}
+
+func TestCSRFProtectedSearchRejectsTokenlessRequest(t *testing.T) {
+	_, srv := newCSRFServer(t)
+
+	response, err := NewClient().Post(context.Background(), srv.URL+"/search", url.Values{"q": {"novasco"}}.Encode(), map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+	})
+	if err != nil {
+		t.Fatalf("Post returned error: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a tokenless search to be rejected, got %d", response.StatusCode)
+	}
+}
+
+func TestCSRFSessionTokenNotFound(t *testing.T) {
+	_, srv := newCSRFServer(t)
+
+	session := NewCSRFSession(NewClient(), CSRFConfig{
+		PageURL:   srv.URL + "/form",
+		InputName: "_token",
+	})
+	if _, err := session.Token(context.Background()); !errors.Is(err, ErrCSRFTokenNotFound) {
+		t.Errorf("expected ErrCSRFTokenNotFound, got %v", err)
+	}
+}
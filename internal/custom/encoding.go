@@ -0,0 +1,92 @@
+package custom
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// supportedEncodings are the content codings responses are decompressed from.
+// Brotli and zstd are not among them, so they are never requested.
+var supportedEncodings = map[string]bool{
+	"gzip":     true,
+	"x-gzip":   true,
+	"deflate":  true,
+	"identity": true,
+}
+
+// acceptEncoding drops from an Accept-Encoding header the codings responses
+// cannot be decompressed from
+func acceptEncoding(header string) string {
+	var kept []string
+	for _, coding := range strings.Split(header, ",") {
+		coding = strings.TrimSpace(coding)
+		name, _, _ := strings.Cut(coding, ";")
+		if supportedEncodings[strings.ToLower(strings.TrimSpace(name))] {
+			kept = append(kept, coding)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// decodedBody reads a decompressed response body, closing the original one
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
+
+// decodeResponse replaces the body of a compressed response with its
+// decompressed bytes, so callers read the document whatever the encoding the
+// server chose
+func decodeResponse(response *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return nil
+	}
+
+	// Empty bodies, such as the ones of HEAD requests, have nothing to decompress
+	buffered := bufio.NewReader(response.Body)
+	if _, err := buffered.Peek(1); err == io.EOF {
+		return nil
+	}
+
+	var reader io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("failed to decompress gzip response: %w", err)
+		}
+		reader = gz
+	case "deflate":
+		// Deflate is meant to be zlib wrapped, but some servers send raw deflate
+		header, _ := buffered.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(buffered)
+			if err != nil {
+				return fmt.Errorf("failed to decompress deflate response: %w", err)
+			}
+			reader = zr
+		} else {
+			reader = flate.NewReader(buffered)
+		}
+	default:
+		return fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	response.Body = &decodedBody{Reader: reader, body: response.Body}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+	return nil
+}
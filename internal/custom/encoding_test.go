@@ -0,0 +1,119 @@
+package custom
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const encodedDocument = `{"data":[{"texto":"NOVASCO"}]}`
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		writer, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	writer.Close()
+	return buf.Bytes()
+}
+
+func TestClientDecompressesResponses(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			var accepted string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accepted = r.Header.Get("Accept-Encoding")
+				contentEncoding := encoding
+				if encoding == "raw-deflate" {
+					contentEncoding = "deflate"
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", contentEncoding)
+				w.Write(compress(t, encoding, []byte(encodedDocument)))
+			}))
+			defer srv.Close()
+
+			response, err := NewClient().Get(context.Background(), srv.URL, nil, map[string]string{
+				"Accept-Encoding": "gzip, deflate, br",
+			})
+			if err != nil {
+				t.Fatalf("Get returned error: %v", err)
+			}
+			defer response.Body.Close()
+
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			var document struct {
+				Data []struct {
+					Texto string `json:"texto"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(body, &document); err != nil {
+				t.Fatalf("expected a decompressed JSON body, got %q: %v", body, err)
+			}
+			if len(document.Data) != 1 || document.Data[0].Texto != "NOVASCO" {
+				t.Errorf("unexpected document %+v", document)
+			}
+
+			if accepted != "gzip, deflate" {
+				t.Errorf("expected brotli to be dropped from Accept-Encoding, got %q", accepted)
+			}
+			if response.Header.Get("Content-Encoding") != "" {
+				t.Errorf("expected the Content-Encoding of the decompressed body to be removed")
+			}
+		})
+	}
+}
+
+func TestClientTransparentGzipWithoutAcceptEncoding(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("expected the transport to request gzip, got %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compress(t, "gzip", []byte(encodedDocument)))
+	}))
+	defer srv.Close()
+
+	// Only brotli was requested, which is dropped
+	response, err := NewClient().Get(context.Background(), srv.URL, nil, map[string]string{"Accept-Encoding": "br"})
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if body := readBody(t, response); body != encodedDocument {
+		t.Errorf("expected the decompressed document, got %q", body)
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	tests := map[string]string{
+		"gzip, deflate, br":        "gzip, deflate",
+		"br, zstd":                 "",
+		"gzip;q=1.0, identity;q=0": "gzip;q=1.0, identity;q=0",
+	}
+	for header, want := range tests {
+		if got := acceptEncoding(header); got != want {
+			t.Errorf("acceptEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package custom
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// ClientOption configures the clients built by NewClient
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	proxyURL   string
+	userAgents *UserAgentPool
+}
+
+// WithProxy routes the requests of the client through the proxy at proxyURL,
+// an http, https, socks5 or socks5h URL, instead of the proxy set in the
+// environment. An empty URL sends the requests directly.
+func WithProxy(proxyURL string) ClientOption {
+	return func(o *clientOptions) {
+		o.proxyURL = proxyURL
+	}
+}
+
+// WithUserAgentPool sets the pool the client takes the User-Agent of its
+// requests from, instead of the one set in the environment
+func WithUserAgentPool(pool *UserAgentPool) ClientOption {
+	return func(o *clientOptions) {
+		o.userAgents = pool
+	}
+}
+
+// ProxyFromEnv returns the proxy outbound requests are routed through:
+// OSINT_PROXY_URL, or HTTP_PROXY when it is not set
+func ProxyFromEnv() string {
+	if proxyURL := os.Getenv("OSINT_PROXY_URL"); proxyURL != "" {
+		return proxyURL
+	}
+	return os.Getenv("HTTP_PROXY")
+}
+
+// newClientOptions applies the options over the proxy and user agents set in
+// the environment
+func newClientOptions(options []ClientOption) clientOptions {
+	o := clientOptions{proxyURL: ProxyFromEnv()}
+	for _, option := range options {
+		option(&o)
+	}
+	if o.userAgents == nil {
+		o.userAgents = UserAgentPoolFromEnv()
+	}
+	return o
+}
+
+// configureProxy routes the requests of transport through the proxy at
+// proxyURL, dialing through dialer for SOCKS proxies. A proxy URL that cannot be
+// used fails every request rather than letting them leave unproxied.
+func configureProxy(transport *http.Transport, dialer *net.Dialer, proxyURL string) {
+	if proxyURL == "" {
+		return
+	}
+
+	u, err := neturl.Parse(proxyURL)
+	if err == nil && u.Host == "" {
+		err = fmt.Errorf("missing host")
+	}
+	if err != nil {
+		failProxy(transport, fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err))
+		return
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		// Loopback and NO_PROXY hosts are still reached directly
+		proxyFunc := (&httpproxy.Config{
+			HTTPProxy:  proxyURL,
+			HTTPSProxy: proxyURL,
+			NoProxy:    os.Getenv("NO_PROXY"),
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*neturl.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	case "socks5", "socks5h":
+		socks, err := proxy.FromURL(u, dialer)
+		if err != nil {
+			failProxy(transport, fmt.Errorf("invalid SOCKS proxy %q: %w", u.Redacted(), err))
+			return
+		}
+		contextDialer, ok := socks.(proxy.ContextDialer)
+		if !ok {
+			failProxy(transport, fmt.Errorf("SOCKS proxy %q cannot dial with a context", u.Redacted()))
+			return
+		}
+		transport.Proxy = nil
+		transport.DialContext = contextDialer.DialContext
+	default:
+		failProxy(transport, fmt.Errorf("unsupported proxy scheme %q", u.Scheme))
+	}
+}
+
+// failProxy makes every request of transport fail with err
+func failProxy(transport *http.Transport, err error) {
+	transport.Proxy = func(*http.Request) (*neturl.URL, error) {
+		return nil, err
+	}
+	transport.DialContext = func(context.Context, string, string) (net.Conn, error) {
+		return nil, err
+	}
+}
+
+// newTransport builds the transport of the clients, keeping the connection and
+// TLS handshake timeouts whatever the proxy
+func newTransport(o clientOptions) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second, // Connection timeout
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 5 * time.Second, // TLS handshake timeout
+	}
+	configureProxy(transport, dialer, o.proxyURL)
+	return transport
+}
+
+// contextBaseTransport is the transport NewContextTransport wraps, shared so
+// the clients of third-party libraries reuse their connections
+var contextBaseTransport = sync.OnceValue(func() http.RoundTripper {
+	proxyURL := ProxyFromEnv()
+	if proxyURL == "" {
+		return http.DefaultTransport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport.DialContext = dialer.DialContext
+	configureProxy(transport, dialer, proxyURL)
+	return transport
+})
@@ -0,0 +1,191 @@
+package custom
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRecordingProxy starts an HTTP proxy answering every request itself and
+// recording the URLs it was asked for
+func newRecordingProxy(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.String())
+		mu.Unlock()
+		fmt.Fprint(w, "proxied")
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func TestClientWithProxy(t *testing.T) {
+	proxy, requested := newRecordingProxy(t)
+
+	client := NewClient(WithProxy(proxy.URL))
+	response, err := client.Get(context.Background(), "http://consultas.example.gob.do/rnc", map[string]string{"q": "novasco"}, nil)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if body := readBody(t, response); body != "proxied" {
+		t.Errorf("expected the proxy to answer, got %q", body)
+	}
+	if got := requested(); len(got) != 1 || got[0] != "http://consultas.example.gob.do/rnc?q=novasco" {
+		t.Errorf("expected the request to traverse the proxy, got %q", got)
+	}
+	if client.Client.Timeout != 30*time.Second {
+		t.Errorf("expected the client timeout to be kept, got %v", client.Client.Timeout)
+	}
+}
+
+func TestClientProxyFromEnv(t *testing.T) {
+	proxy, requested := newRecordingProxy(t)
+	t.Setenv("HTTP_PROXY", "http://unused.invalid:3128")
+	t.Setenv("OSINT_PROXY_URL", proxy.URL)
+
+	response, err := NewCustomClient().HTTP.Get(context.Background(), "http://dgii.example.gob.do/", nil, nil)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	readBody(t, response)
+
+	if got := requested(); len(got) != 1 || got[0] != "http://dgii.example.gob.do/" {
+		t.Errorf("expected OSINT_PROXY_URL to win over HTTP_PROXY, got %q", got)
+	}
+}
+
+func TestClientWithSOCKS5Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "direct")
+	}))
+	t.Cleanup(target.Close)
+
+	socks, dialed := newSOCKS5Proxy(t)
+
+	client := NewClient(WithProxy("socks5://" + socks))
+	response, err := client.Get(context.Background(), target.URL, nil, nil)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+
+	if body := readBody(t, response); body != "direct" {
+		t.Errorf("expected the target to answer through the tunnel, got %q", body)
+	}
+	if got := dialed(); len(got) != 1 || got[0] != target.Listener.Addr().String() {
+		t.Errorf("expected the SOCKS proxy to connect to the target, got %q", got)
+	}
+}
+
+func TestClientWithInvalidProxyFailsClosed(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected no request to leave without the proxy")
+	}))
+	t.Cleanup(target.Close)
+
+	for _, proxyURL := range []string{"ftp://proxy.example:21", "://bad"} {
+		if _, err := NewClient(WithProxy(proxyURL)).Get(context.Background(), target.URL, nil, nil); err == nil {
+			t.Errorf("expected %q to fail the request", proxyURL)
+		}
+	}
+}
+
+// newSOCKS5Proxy starts a SOCKS5 proxy without authentication tunnelling
+// CONNECT requests, recording the addresses it connected to
+func newSOCKS5Proxy(t *testing.T) (string, func() []string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	var dialed []string
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				// Greeting: version, methods; answered with no authentication
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+
+				// Request: version, command, reserved, address type, address, port
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				var host string
+				switch request[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				if _, err := io.ReadFull(conn, port); err != nil {
+					return
+				}
+				address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+				upstream, err := net.Dial("tcp", address)
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+
+				mu.Lock()
+				dialed = append(dialed, address)
+				mu.Unlock()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), dialed...)
+	}
+}
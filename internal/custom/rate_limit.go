@@ -0,0 +1,44 @@
+package custom
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestLimiter spaces the outbound requests sent to each host
+type RequestLimiter interface {
+	// Wait blocks until a request to host is allowed, or returns an error
+	// when ctx is done first
+	Wait(ctx context.Context, host string) error
+}
+
+type requestLimiterKey struct{}
+
+// WithRequestLimiter returns a context whose outbound requests each wait on
+// limiter before being sent
+func WithRequestLimiter(ctx context.Context, limiter RequestLimiter) context.Context {
+	return context.WithValue(ctx, requestLimiterKey{}, limiter)
+}
+
+// RequestLimiterFromContext returns the request limiter of the context, or nil
+// when it has none
+func RequestLimiterFromContext(ctx context.Context) RequestLimiter {
+	limiter, _ := ctx.Value(requestLimiterKey{}).(RequestLimiter)
+	return limiter
+}
+
+// rateLimitTransport waits on the request limiter of the request context before
+// sending each request, so a search sending several requests to a source takes
+// a token for each of them
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if limiter := RequestLimiterFromContext(req.Context()); limiter != nil {
+		if err := limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,56 @@
+package custom
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// hostLimiter records the hosts it is asked to wait for, refusing them once
+// err is set
+type hostLimiter struct {
+	hosts []string
+	err   error
+}
+
+func (l *hostLimiter) Wait(ctx context.Context, host string) error {
+	l.hosts = append(l.hosts, host)
+	return l.err
+}
+
+func TestRateLimitTransportWaitsPerRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	limiter := &hostLimiter{}
+	ctx := WithRequestLimiter(context.Background(), limiter)
+
+	client := NewClient()
+	for range 3 {
+		response, err := client.Get(ctx, srv.URL, nil, nil)
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		response.Body.Close()
+	}
+
+	if len(limiter.hosts) != 3 {
+		t.Fatalf("expected a wait per request, got %d", len(limiter.hosts))
+	}
+	for _, host := range limiter.hosts {
+		if host != target.Hostname() {
+			t.Errorf("expected to wait for %s, got %s", target.Hostname(), host)
+		}
+	}
+
+	limiter.err = context.DeadlineExceeded
+	if _, err := client.Get(ctx, srv.URL, nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the limiter error, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package custom
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header carrying the signature of a request body, as
+// "t=<unix seconds>,sha256=<hex HMAC>"
+const SignatureHeader = "X-Signature"
+
+// DefaultSignatureTolerance is how far the timestamp of a signature may be from
+// the current time when the verification sets no tolerance
+const DefaultSignatureTolerance = 5 * time.Minute
+
+var (
+	// ErrSignatureMissing is returned when a request carries no signature
+	ErrSignatureMissing = errors.New("missing request signature")
+	// ErrSignatureMalformed is returned when the signature header cannot be parsed
+	ErrSignatureMalformed = errors.New("malformed request signature")
+	// ErrSignatureMismatch is returned when the signature does not match the body
+	ErrSignatureMismatch = errors.New("request signature does not match")
+	// ErrSignatureStale is returned when the signature timestamp is outside the
+	// tolerance, as it is when a captured request is replayed later
+	ErrSignatureStale = errors.New("request signature timestamp outside tolerance")
+)
+
+// Sign returns the signature header of a body sent at timestamp. The HMAC
+// covers the timestamp as well as the body, so the timestamp cannot be
+// replaced without the secret.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,sha256=%s", unix, hex.EncodeToString(signatureMAC(secret, unix, body)))
+}
+
+// VerifySignature checks that header signs body with secret and that its
+// timestamp is within tolerance of now, DefaultSignatureTolerance when zero
+func VerifySignature(secret []byte, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	if header == "" {
+		return ErrSignatureMissing
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultSignatureTolerance
+	}
+
+	var unix, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "sha256":
+			signature = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return ErrSignatureMalformed
+	}
+	mac, err := hex.DecodeString(signature)
+	if err != nil || len(mac) == 0 {
+		return ErrSignatureMalformed
+	}
+
+	if !hmac.Equal(mac, signatureMAC(secret, unix, body)) {
+		return ErrSignatureMismatch
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrSignatureStale
+	}
+	return nil
+}
+
+// signatureMAC is the HMAC-SHA256 of the timestamp and body, joined by a dot
+func signatureMAC(secret []byte, unix string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,50 @@
+package custom
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("shared-secret")
+	body := []byte(`{"query":"novasco"}`)
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name   string
+		header string
+		body   []byte
+		want   error
+	}{
+		{"valid", Sign(secret, now.Add(-time.Minute), body), body, nil},
+		{"tampered body", Sign(secret, now, body), []byte(`{"query":"acme"}`), ErrSignatureMismatch},
+		{"wrong secret", Sign([]byte("other"), now, body), body, ErrSignatureMismatch},
+		{"replayed", Sign(secret, now.Add(-10*time.Minute), body), body, ErrSignatureStale},
+		{"from the future", Sign(secret, now.Add(10*time.Minute), body), body, ErrSignatureStale},
+		{"missing", "", body, ErrSignatureMissing},
+		{"malformed", "sha256=abc", body, ErrSignatureMalformed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifySignature(secret, test.header, test.body, now, 5*time.Minute)
+			if !errors.Is(err, test.want) {
+				t.Errorf("expected %v, got %v", test.want, err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureRejectsReplacedTimestamp(t *testing.T) {
+	secret := []byte("shared-secret")
+	body := []byte(`{}`)
+	now := time.Unix(1_700_000_000, 0)
+
+	// A replayed request with a fresh timestamp no longer matches its HMAC
+	stale := Sign(secret, now.Add(-time.Hour), body)
+	refreshed := "t=1700000000," + stale[len("t=1699996400,"):]
+	if err := VerifySignature(secret, refreshed, body, now, 0); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("expected a refreshed timestamp to be rejected, got %v", err)
+	}
+}
@@ -5,8 +5,11 @@ import "fmt"
 type CustomClient struct {
 	Client    *CustomClient
 	connected *CustomClient
+	// HTTP is the client the searches make their requests with
+	HTTP      *Client
 	Stored    func(any) error
 	Retrieved func() (any, error)
+	Searched  func(string) (any, error)
 	// Deleted func() error
 	// Updated func(any) error
 	// Created func(any) error
@@ -19,9 +22,11 @@ type StuffInterface interface {
 	Search(query string) (any, error)
 }
 
-func NewCustomClient() *CustomClient {
+// NewCustomClient creates a client making its requests through the proxy set
+// in the environment or by the options
+func NewCustomClient(options ...ClientOption) *CustomClient {
 	return &CustomClient{
-		Client: NewCustomClient(),
+		HTTP: NewClient(options...),
 	}
 }
 
@@ -44,10 +49,10 @@ func (s *CustomClient) Connect(conn *CustomClient) {
 // }
 
 func (s *CustomClient) Search(query string) (any, error) {
-	if s.Search == nil {
+	if s.Searched == nil {
 		return nil, fmt.Errorf("search function is not set")
 	}
-	return s.Search(query)
+	return s.Searched(query)
 }
 
 type CustomPathMap struct {
@@ -0,0 +1,84 @@
+package custom
+
+import (
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultUserAgents are the desktop browsers requests present themselves as
+// when USER_AGENTS is not set
+var DefaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:142.0) Gecko/20100101 Firefox/142.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.6; rv:142.0) Gecko/20100101 Firefox/142.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.6 Safari/605.1.15",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
+}
+
+// UserAgentRotation is the order a UserAgentPool hands out its user agents in
+type UserAgentRotation string
+
+const (
+	UserAgentRandom     UserAgentRotation = "random"
+	UserAgentRoundRobin UserAgentRotation = "round-robin"
+)
+
+// UserAgentPool hands out a user agent per request, so the sources do not see
+// every request coming from the same browser
+type UserAgentPool struct {
+	agents   []string
+	rotation UserAgentRotation
+
+	mu   sync.Mutex
+	next int
+	// intN picks the index of a random user agent
+	intN func(n int) int
+}
+
+// NewUserAgentPool creates a pool rotating through agents, or through
+// DefaultUserAgents when agents is empty. Any rotation other than round-robin
+// picks them at random.
+func NewUserAgentPool(agents []string, rotation UserAgentRotation) *UserAgentPool {
+	if len(agents) == 0 {
+		agents = DefaultUserAgents
+	}
+	if rotation != UserAgentRoundRobin {
+		rotation = UserAgentRandom
+	}
+
+	return &UserAgentPool{
+		agents:   append([]string(nil), agents...),
+		rotation: rotation,
+		intN:     rand.IntN,
+	}
+}
+
+// UserAgentPoolFromEnv creates the pool set by USER_AGENTS, a list of user
+// agents separated by "|", rotated as USER_AGENT_ROTATION says (random by
+// default)
+func UserAgentPoolFromEnv() *UserAgentPool {
+	var agents []string
+	for _, agent := range strings.Split(os.Getenv("USER_AGENTS"), "|") {
+		if agent = strings.TrimSpace(agent); agent != "" {
+			agents = append(agents, agent)
+		}
+	}
+	return NewUserAgentPool(agents, UserAgentRotation(os.Getenv("USER_AGENT_ROTATION")))
+}
+
+// Next returns the user agent of the next request
+func (p *UserAgentPool) Next() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rotation == UserAgentRoundRobin {
+		agent := p.agents[p.next]
+		p.next = (p.next + 1) % len(p.agents)
+		return agent
+	}
+	return p.agents[p.intN(len(p.agents))]
+}
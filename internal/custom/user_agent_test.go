@@ -0,0 +1,100 @@
+package custom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newUserAgentServer starts a server recording the User-Agent of every request
+func newUserAgentServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		agents = append(agents, r.Header.Get("User-Agent"))
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), agents...)
+	}
+}
+
+func TestClientRotatesUserAgents(t *testing.T) {
+	srv, agents := newUserAgentServer(t)
+
+	pool := NewUserAgentPool([]string{"agent-a", "agent-b", "agent-c"}, UserAgentRoundRobin)
+	client := NewClient(WithUserAgentPool(pool))
+	for range 4 {
+		response, err := client.Get(context.Background(), srv.URL, nil, nil)
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		response.Body.Close()
+	}
+
+	got := agents()
+	expected := []string{"agent-a", "agent-b", "agent-c", "agent-a"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d requests, got %q", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("request %d: expected User-Agent %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestClientKeepsExplicitUserAgent(t *testing.T) {
+	srv, agents := newUserAgentServer(t)
+
+	client := NewClient(WithUserAgentPool(NewUserAgentPool([]string{"pooled"}, UserAgentRoundRobin)))
+	response, err := client.Get(context.Background(), srv.URL, nil, map[string]string{"User-Agent": "explicit"})
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	response.Body.Close()
+
+	if got := agents(); len(got) != 1 || got[0] != "explicit" {
+		t.Errorf("expected the caller's User-Agent to be kept, got %q", got)
+	}
+}
+
+func TestUserAgentPoolRandom(t *testing.T) {
+	pool := NewUserAgentPool([]string{"agent-a", "agent-b"}, "")
+	picks := []int{1, 0, 1}
+	pool.intN = func(n int) int {
+		pick := picks[0]
+		picks = picks[1:]
+		return pick
+	}
+
+	for _, want := range []string{"agent-b", "agent-a", "agent-b"} {
+		if got := pool.Next(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestUserAgentPoolFromEnv(t *testing.T) {
+	t.Setenv("USER_AGENTS", "agent-a | agent-b||")
+	t.Setenv("USER_AGENT_ROTATION", "round-robin")
+
+	pool := UserAgentPoolFromEnv()
+	if first, second := pool.Next(), pool.Next(); first != "agent-a" || second != "agent-b" {
+		t.Errorf("expected the agents of USER_AGENTS in order, got %q and %q", first, second)
+	}
+
+	t.Setenv("USER_AGENTS", "")
+	if agent := UserAgentPoolFromEnv().Next(); agent == "" {
+		t.Error("expected a default user agent without USER_AGENTS")
+	}
+}
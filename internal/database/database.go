@@ -11,6 +11,7 @@ import (
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/joho/godotenv/autoload"
+	_ "modernc.org/sqlite"
 )
 
 // Service represents a service that interacts with a database.
@@ -28,7 +29,8 @@ type Service interface {
 }
 
 type service struct {
-	db *sql.DB
+	db   *sql.DB
+	name string
 }
 
 var (
@@ -40,29 +42,115 @@ var (
 	dbInstance *service
 )
 
+// DefaultSQLitePath is the SQLite file used when SQLITE_PATH is not set
+const DefaultSQLitePath = "insightful_intel.db"
+
+// New opens the database of the driver set by DB_DRIVER: MySQL by default, or
+// SQLite
 func New() Service {
 	// Reuse Connection
 	if dbInstance != nil {
 		return dbInstance
 	}
 
-	// Opening a driver typically will not attempt to connect to the database.
-	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", username, password, host, port, dbname))
+	var db *sql.DB
+	var err error
+	name := dbname
+	switch driver := Driver(); driver {
+	case DriverMySQL:
+		// Opening a driver typically will not attempt to connect to the database.
+		db, err = sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", username, password, host, port, dbname))
+		if err == nil {
+			configurePool(db)
+		}
+	case DriverSQLite:
+		name = os.Getenv("SQLITE_PATH")
+		if name == "" {
+			name = DefaultSQLitePath
+		}
+		db, err = OpenSQLite(name)
+	default:
+		err = fmt.Errorf("unsupported DB_DRIVER %q, expected %q or %q", driver, DriverMySQL, DriverSQLite)
+	}
 	if err != nil {
 		// This will not be a connection error, but a DSN parse error or
 		// another initialization error.
 		log.Fatal(err)
 	}
-	db.SetConnMaxLifetime(0)
-	db.SetMaxIdleConns(50)
-	db.SetMaxOpenConns(50)
 
 	dbInstance = &service{
-		db: db,
+		db:   db,
+		name: name,
 	}
 	return dbInstance
 }
 
+// OpenSQLite opens the SQLite database at path, ":memory:" for one that lives
+// as long as the returned handle, with foreign keys enforced like in MySQL.
+// SQLite allows a single writer, so the statements share one connection
+// instead of failing on each other's locks.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if path != ":memory:" {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	return db, nil
+}
+
+// Defaults of the connection pool, used when BLUEPRINT_DB_MAX_OPEN_CONNS,
+// BLUEPRINT_DB_MAX_IDLE_CONNS or BLUEPRINT_DB_CONN_MAX_LIFETIME are not set.
+// Connections are recycled before MySQL's wait_timeout closes them.
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 25
+	DefaultConnMaxLifetime = 5 * time.Minute
+)
+
+// configurePool bounds the connections db keeps open and how long it reuses
+// them, as set by the environment
+func configurePool(db *sql.DB) {
+	maxOpen := intFromEnv("BLUEPRINT_DB_MAX_OPEN_CONNS", DefaultMaxOpenConns)
+	maxIdle := min(intFromEnv("BLUEPRINT_DB_MAX_IDLE_CONNS", DefaultMaxIdleConns), maxOpen)
+
+	lifetime := DefaultConnMaxLifetime
+	if value := os.Getenv("BLUEPRINT_DB_CONN_MAX_LIFETIME"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("invalid BLUEPRINT_DB_CONN_MAX_LIFETIME %q, using default %s", value, DefaultConnMaxLifetime)
+		} else {
+			lifetime = parsed
+		}
+	}
+
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(lifetime)
+}
+
+// intFromEnv reads a positive integer from the environment variable key,
+// falling back to fallback when it is not set or not valid
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("invalid %s %q, using default %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
 // Health checks the health of the database connection by pinging the database.
 // It returns a map with keys indicating various health statistics.
 func (s *service) Health() map[string]string {
@@ -117,8 +205,12 @@ func (s *service) Health() map[string]string {
 // It logs a message indicating the disconnection from the specific database.
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
+// The next call to New opens a new connection.
 func (s *service) Close() error {
-	log.Printf("Disconnected from database: %s", dbname)
+	log.Printf("Disconnected from database: %s", s.name)
+	if dbInstance == s {
+		dbInstance = nil
+	}
 	return s.db.Close()
 }
 
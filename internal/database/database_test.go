@@ -94,3 +94,20 @@ func TestClose(t *testing.T) {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
+
+func TestNewConfiguresPool(t *testing.T) {
+	t.Setenv("BLUEPRINT_DB_MAX_OPEN_CONNS", "7")
+	t.Setenv("BLUEPRINT_DB_MAX_IDLE_CONNS", "3")
+	t.Setenv("BLUEPRINT_DB_CONN_MAX_LIFETIME", "1m")
+
+	// Drop the shared connection so New applies the settings above
+	if dbInstance != nil {
+		dbInstance.Close()
+	}
+	srv := New()
+	defer srv.Close()
+
+	if got := srv.GetDB().Stats().MaxOpenConnections; got != 7 {
+		t.Errorf("expected at most 7 open connections, got %d", got)
+	}
+}
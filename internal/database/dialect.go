@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// Dialect is the SQL flavour of a database. The repositories write their
+// statements for MySQL and Rewrite adapts them to the database they run on.
+type Dialect string
+
+// Dialects of the supported drivers
+const (
+	DialectMySQL  Dialect = "mysql"
+	DialectSQLite Dialect = "sqlite"
+)
+
+// DialectOf returns the dialect of an open database, MySQL unless it was
+// opened with the SQLite driver
+func DialectOf(db *sql.DB) Dialect {
+	if db == nil {
+		return DialectMySQL
+	}
+	if _, ok := db.Driver().(*sqlite.Driver); ok {
+		return DialectSQLite
+	}
+	return DialectMySQL
+}
+
+// sqliteFunctions replaces the MySQL functions SQLite does not know
+var sqliteFunctions = strings.NewReplacer("NOW()", "CURRENT_TIMESTAMP")
+
+// sqliteConvert matches the MySQL charset conversions, which SQLite does as
+// casts to TEXT
+var sqliteConvert = regexp.MustCompile(`CONVERT\((\w+) USING \w+\)`)
+
+// Rewrite adapts a MySQL statement and its arguments to the dialect. Both
+// dialects take ? placeholders, so only the MySQL functions and the times are
+// rewritten for SQLite: times are bound in the text format CURRENT_TIMESTAMP
+// stores, so they compare with the stored timestamps and read back the same.
+func (d Dialect) Rewrite(query string, args []any) (string, []any) {
+	if d != DialectSQLite {
+		return query, args
+	}
+
+	query = sqliteFunctions.Replace(query)
+	query = sqliteConvert.ReplaceAllString(query, "CAST($1 AS TEXT)")
+
+	var rewritten []any
+	for i, arg := range args {
+		t, ok := arg.(time.Time)
+		if !ok {
+			continue
+		}
+		if rewritten == nil {
+			rewritten = append([]any(nil), args...)
+		}
+		rewritten[i] = t.UTC().Format(time.DateTime)
+	}
+	if rewritten != nil {
+		args = rewritten
+	}
+
+	return query, args
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"os"
+	"strings"
+)
+
+// Storage drivers selectable with DB_DRIVER
+const (
+	// DriverMySQL stores the records in the MySQL database configured by the
+	// BLUEPRINT_DB_* variables
+	DriverMySQL = "mysql"
+	// DriverSQLite stores the records in the SQLite file at SQLITE_PATH, for
+	// single analyst use without a database server
+	DriverSQLite = "sqlite"
+	// DriverMemory keeps the records in memory for as long as the process
+	// runs, with no database to connect to
+	DriverMemory = "memory"
+)
+
+// Driver returns the storage driver set by DB_DRIVER, DriverMySQL when unset
+func Driver() string {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER")))
+	if driver == "" {
+		return DriverMySQL
+	}
+	return driver
+}
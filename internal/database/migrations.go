@@ -4,13 +4,16 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io/fs"
 	"log"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
-//go:embed schema.sql
-var schemaFS embed.FS
+//go:embed migrations
+var migrationsFS embed.FS
 
 // Migration represents a database migration
 type Migration struct {
@@ -22,13 +25,15 @@ type Migration struct {
 
 // MigrationService handles database migrations
 type MigrationService struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
 // NewMigrationService creates a new migration service
 func NewMigrationService(db *sql.DB) *MigrationService {
 	return &MigrationService{
-		db: db,
+		db:      db,
+		dialect: DialectOf(db),
 	}
 }
 
@@ -43,11 +48,29 @@ func (m *MigrationService) CreateMigrationsTable() error {
 			INDEX idx_version (version)
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
 	`
+	if m.dialect == DialectSQLite {
+		query = `
+			CREATE TABLE IF NOT EXISTS migrations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				version INTEGER NOT NULL UNIQUE,
+				name VARCHAR(255) NOT NULL,
+				executed_at TEXT DEFAULT CURRENT_TIMESTAMP
+			)
+		`
+	}
 
 	_, err := m.db.Exec(query)
 	return err
 }
 
+// Migrations returns the migrations of the dialect of the database
+func (m *MigrationService) Migrations() []Migration {
+	if m.dialect == DialectSQLite {
+		return GetSQLiteMigrations()
+	}
+	return GetInitialMigrations()
+}
+
 // GetExecutedMigrations returns a list of executed migration versions
 func (m *MigrationService) GetExecutedMigrations() (map[int]bool, error) {
 	query := `SELECT version FROM migrations ORDER BY version`
@@ -108,9 +131,15 @@ func (m *MigrationService) RollbackMigration(migration Migration) error {
 	}
 	defer tx.Rollback()
 
-	// Execute the rollback SQL
-	if _, err := tx.Exec(migration.DownSQL); err != nil {
-		return fmt.Errorf("failed to rollback migration %d (%s): %w", migration.Version, migration.Name, err)
+	// Execute the rollback SQL statements
+	for _, stmt := range strings.Split(migration.DownSQL, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to rollback migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
 	}
 
 	// Remove the migration record
@@ -156,50 +185,190 @@ func (m *MigrationService) RunMigrations(migrations []Migration) error {
 	return nil
 }
 
-// getEmbeddedSchemaSQL returns the embedded schema.sql content
-func getEmbeddedSchemaSQL() (string, error) {
-	content, err := schemaFS.ReadFile("schema.sql")
+// RollbackMigrations rolls back the last n executed migrations, newest first
+func (m *MigrationService) RollbackMigrations(migrations []Migration, n int) error {
+	if err := m.CreateMigrationsTable(); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	executed, err := m.GetExecutedMigrations()
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to get executed migrations: %w", err)
+	}
+
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	versions := make([]int, 0, len(executed))
+	for version := range executed {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	for _, version := range versions[:min(n, len(versions))] {
+		migration, ok := byVersion[version]
+		if !ok {
+			return fmt.Errorf("no migration to roll back executed version %d", version)
+		}
+		log.Printf("Rolling back migration %d: %s", migration.Version, migration.Name)
+		if err := m.RollbackMigration(migration); err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+		log.Printf("Migration %d rolled back successfully", migration.Version)
+	}
+
+	return nil
+}
+
+// MigrationStatus is a migration and whether it has been executed
+type MigrationStatus struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// Status reports the migrations by version and whether each has been executed
+func (m *MigrationService) Status(migrations []Migration) ([]MigrationStatus, error) {
+	if err := m.CreateMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	executed, err := m.GetExecutedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executed migrations: %w", err)
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status = append(status, MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: executed[migration.Version],
+		})
 	}
-	return string(content), nil
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Version < status[j].Version
+	})
+
+	return status, nil
 }
 
-// GetInitialMigrations returns the initial set of migrations
+// GetSQLiteMigrations returns the migrations of SQLite databases, from
+// migrations/sqlite. They start from the current schema, so the MySQL
+// migrations up to it have no SQLite counterpart.
+func GetSQLiteMigrations() []Migration {
+	return embeddedMigrations("migrations/sqlite")
+}
+
+// GetInitialMigrations returns the migrations of MySQL databases, from
+// migrations/mysql
 func GetInitialMigrations() []Migration {
-	// Load schema from embedded file
-	schemaSQL, err := getEmbeddedSchemaSQL()
+	return embeddedMigrations("migrations/mysql")
+}
+
+// embeddedMigrations returns the migrations embedded in dir
+func embeddedMigrations(dir string) []Migration {
+	sub, err := fs.Sub(migrationsFS, dir)
+	if err == nil {
+		var migrations []Migration
+		if migrations, err = ParseMigrations(sub); err == nil {
+			return migrations
+		}
+	}
+	log.Printf("Warning: Could not load embedded migrations from %s: %v", dir, err)
+	return []Migration{}
+}
+
+// Markers of the sections of a migration file
+const (
+	migrateUpMarker   = "-- +migrate Up"
+	migrateDownMarker = "-- +migrate Down"
+)
+
+// migrationFileName matches the name of a migration file, its version
+// followed by its name
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// ParseMigrations parses the .sql files at the root of fsys into migrations
+// sorted by version. Each file is named by its version and name, like
+// 002_add_indexes.sql, and holds the statements that apply the migration
+// after a "-- +migrate Up" line and those that roll it back after a
+// "-- +migrate Down" line.
+func ParseMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
 	if err != nil {
-		log.Printf("Warning: Could not load embedded schema, using fallback schema: %v", err)
-		// Fallback to embedded schema
-		return []Migration{}
+		return nil, err
 	}
 
-	// Clean up the SQL content
-	cleanSQL := cleanSQLContent(schemaSQL)
-
-	return []Migration{
-		{
-			Version: 1,
-			Name:    "create_repository_tables",
-			UpSQL:   cleanSQL,
-			DownSQL: getRepositorySchemaRollbackSQL(),
-		},
-	}
-}
-
-// getRepositorySchemaRollbackSQL returns the SQL for rolling back repository tables
-func getRepositorySchemaRollbackSQL() string {
-	return `
-		DROP TABLE IF EXISTS dynamic_pipeline_steps;
-		DROP TABLE IF EXISTS dynamic_pipeline_results;
-		DROP TABLE IF EXISTS google_docking_results;
-		DROP TABLE IF EXISTS pgr_news;
-		DROP TABLE IF EXISTS dgii_registers;
-		DROP TABLE IF EXISTS scj_cases;
-		DROP TABLE IF EXISTS onapi_entities;
-		DROP TABLE IF EXISTS domain_search_results;
-	`
+	migrations := make([]Migration, 0, len(paths))
+	files := make(map[int]string, len(paths))
+	for _, path := range paths {
+		migration, err := parseMigrationFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := files[migration.Version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %d", other, path, migration.Version)
+		}
+		files[migration.Version] = path
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseMigrationFile parses the migration file at path
+func parseMigrationFile(fsys fs.FS, path string) (Migration, error) {
+	match := migrationFileName.FindStringSubmatch(path)
+	if match == nil {
+		return Migration{}, fmt.Errorf("migration %s is not named <version>_<name>.sql", path)
+	}
+	version, err := strconv.Atoi(match[1])
+	if err != nil || version < 1 {
+		return Migration{}, fmt.Errorf("migration %s has an invalid version", path)
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	var up, down []string
+	var section *[]string
+	for _, line := range strings.Split(string(content), "\n") {
+		switch strings.TrimSpace(line) {
+		case migrateUpMarker:
+			section = &up
+		case migrateDownMarker:
+			section = &down
+		default:
+			// Lines before the first marker are the header of the file
+			if section != nil {
+				*section = append(*section, line)
+			}
+		}
+	}
+
+	migration := Migration{
+		Version: version,
+		Name:    match[2],
+		UpSQL:   cleanSQLContent(strings.Join(up, "\n")),
+		DownSQL: cleanSQLContent(strings.Join(down, "\n")),
+	}
+	if migration.UpSQL == "" {
+		return Migration{}, fmt.Errorf("migration %s has no statements after %q", path, migrateUpMarker)
+	}
+	if down == nil {
+		return Migration{}, fmt.Errorf("migration %s has no %q section", path, migrateDownMarker)
+	}
+
+	return migration, nil
 }
 
 // cleanSQLContent cleans up SQL content by removing comments and empty lines
@@ -1,5 +1,6 @@
 -- Database schema for Insightful Intel repositories
--- This file contains all the necessary table definitions for the repository layer
+-- This file contains the table definitions the repository layer started
+-- from; the migrations that follow it change them
 -- grant all privileges on insightful_intel.* to root@'%' with grant option;
 -- grant all privileges on insightful_intel.* to root@'localhost' with grant option;
 -- grant all privileges on insightful_intel.* to root@'127.0.0.1' with grant option;
@@ -16,6 +17,8 @@
 
 -- SET GLOBAL sort_buffer_size = 256000000;
 
+-- +migrate Up
+
 -- Dynamic pipeline results table
 CREATE TABLE IF NOT EXISTS  dynamic_pipeline_results (
     id CHAR(36) PRIMARY KEY,
@@ -205,3 +208,12 @@ CREATE TABLE IF NOT EXISTS  google_docking_results (
     INDEX idx_rank (search_rank)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- +migrate Down
+DROP TABLE IF EXISTS dynamic_pipeline_steps;
+DROP TABLE IF EXISTS dynamic_pipeline_results;
+DROP TABLE IF EXISTS google_docking_results;
+DROP TABLE IF EXISTS pgr_news;
+DROP TABLE IF EXISTS dgii_registers;
+DROP TABLE IF EXISTS scj_cases;
+DROP TABLE IF EXISTS onapi_entities;
+DROP TABLE IF EXISTS domain_search_results;
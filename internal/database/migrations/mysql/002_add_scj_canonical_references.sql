@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE scj_cases
+    ADD COLUMN tribunal_canonico VARCHAR(100) AFTER desc_tribunal,
+    ADD COLUMN materia_canonica VARCHAR(100) AFTER desc_materia,
+    ADD INDEX idx_tribunal_canonico (tribunal_canonico),
+    ADD INDEX idx_materia_canonica (materia_canonica);
+
+-- +migrate Down
+ALTER TABLE scj_cases
+    DROP INDEX idx_materia_canonica,
+    DROP INDEX idx_tribunal_canonico,
+    DROP COLUMN materia_canonica,
+    DROP COLUMN tribunal_canonico;
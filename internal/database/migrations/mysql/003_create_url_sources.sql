@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS url_sources (
+    id CHAR(36) PRIMARY KEY,
+    url TEXT NOT NULL,
+    record_table VARCHAR(50) NOT NULL,
+    record_id CHAR(36) NOT NULL,
+    domain_type VARCHAR(50) NOT NULL,
+    domain_search_result_id CHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_url (url(255)),
+    INDEX idx_record (record_table, record_id),
+    FOREIGN KEY (domain_search_result_id) REFERENCES domain_search_results(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS url_sources;
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE dynamic_pipeline_results
+    ADD COLUMN stop_reason VARCHAR(50) NOT NULL DEFAULT '' AFTER max_depth_reached;
+
+-- +migrate Down
+ALTER TABLE dynamic_pipeline_results
+    DROP COLUMN stop_reason;
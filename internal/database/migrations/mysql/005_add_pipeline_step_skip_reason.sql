@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE dynamic_pipeline_steps
+    ADD COLUMN skip_reason VARCHAR(50) NOT NULL DEFAULT '' AFTER error_message;
+
+-- +migrate Down
+ALTER TABLE dynamic_pipeline_steps
+    DROP COLUMN skip_reason;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS step_keywords (
+    id CHAR(36) PRIMARY KEY,
+    step_id CHAR(36) NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    keyword TEXT NOT NULL,
+    normalized_keyword VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_step_category (step_id, category),
+    INDEX idx_category_keyword (category, normalized_keyword),
+    FOREIGN KEY (step_id) REFERENCES dynamic_pipeline_steps(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS step_keywords;
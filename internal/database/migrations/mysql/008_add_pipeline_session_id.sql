@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE dynamic_pipeline_results
+    ADD COLUMN session_id CHAR(36) NULL AFTER id,
+    ADD INDEX idx_session_id (session_id),
+    ADD CONSTRAINT fk_pipeline_session FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE SET NULL;
+
+-- +migrate Down
+ALTER TABLE dynamic_pipeline_results
+    DROP FOREIGN KEY fk_pipeline_session,
+    DROP INDEX idx_session_id,
+    DROP COLUMN session_id;
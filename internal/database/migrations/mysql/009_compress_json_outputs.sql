@@ -0,0 +1,21 @@
+-- Compacted outputs are gzipped, so the JSON columns become binary
+
+-- +migrate Up
+ALTER TABLE domain_search_results
+    MODIFY COLUMN keywords_per_category LONGBLOB,
+    MODIFY COLUMN output LONGBLOB,
+    ADD COLUMN compressed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE dynamic_pipeline_steps
+    MODIFY COLUMN keywords_per_category LONGBLOB,
+    MODIFY COLUMN output LONGBLOB,
+    ADD COLUMN compressed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +migrate Down
+ALTER TABLE domain_search_results
+    DROP COLUMN compressed,
+    MODIFY COLUMN keywords_per_category JSON,
+    MODIFY COLUMN output JSON;
+ALTER TABLE dynamic_pipeline_steps
+    DROP COLUMN compressed,
+    MODIFY COLUMN keywords_per_category JSON,
+    MODIFY COLUMN output JSON;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE dynamic_pipeline_steps
+    ADD COLUMN parent_step_id CHAR(36) NULL AFTER pipeline_id,
+    ADD INDEX idx_parent_step_id (parent_step_id);
+
+-- +migrate Down
+ALTER TABLE dynamic_pipeline_steps
+    DROP INDEX idx_parent_step_id,
+    DROP COLUMN parent_step_id;
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS pipeline_step_queue (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    pipeline_id CHAR(36) NOT NULL,
+    depth INT NOT NULL,
+    priority INT NOT NULL,
+    step JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_pipeline_order (pipeline_id, depth, priority),
+    FOREIGN KEY (pipeline_id) REFERENCES dynamic_pipeline_results(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_step_queue;
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE pgr_news
+    ADD COLUMN published_at TIMESTAMP NULL AFTER title;
+
+-- +migrate Down
+ALTER TABLE pgr_news
+    DROP COLUMN published_at;
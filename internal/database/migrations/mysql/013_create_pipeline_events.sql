@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS pipeline_events (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    pipeline_id CHAR(36) NOT NULL,
+    step_id CHAR(36) NULL,
+    type VARCHAR(50) NOT NULL,
+    message TEXT,
+    data JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_pipeline_events (pipeline_id, seq),
+    FOREIGN KEY (pipeline_id) REFERENCES dynamic_pipeline_results(id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS pipeline_events;
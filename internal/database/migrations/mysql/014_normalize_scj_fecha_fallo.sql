@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE scj_cases
+    CHANGE COLUMN fecha_fallo fecha_fallo_raw VARCHAR(100);
+ALTER TABLE scj_cases
+    ADD COLUMN fecha_fallo DATETIME NULL AFTER fecha_fallo_raw,
+    ADD INDEX idx_fecha_fallo (fecha_fallo);
+
+-- +migrate Down
+ALTER TABLE scj_cases
+    DROP INDEX idx_fecha_fallo,
+    DROP COLUMN fecha_fallo;
+ALTER TABLE scj_cases
+    CHANGE COLUMN fecha_fallo_raw fecha_fallo VARCHAR(100);
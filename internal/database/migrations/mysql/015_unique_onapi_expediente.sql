@@ -0,0 +1,14 @@
+-- +migrate Up
+DELETE older FROM onapi_entities older
+    JOIN onapi_entities newer
+        ON older.serie_expediente = newer.serie_expediente
+        AND older.numero_expediente = newer.numero_expediente
+        AND (older.updated_at < newer.updated_at OR (older.updated_at = newer.updated_at AND older.id < newer.id));
+ALTER TABLE onapi_entities
+    DROP INDEX idx_serie_numero,
+    ADD UNIQUE INDEX uniq_serie_numero (serie_expediente, numero_expediente);
+
+-- +migrate Down
+ALTER TABLE onapi_entities
+    DROP INDEX uniq_serie_numero,
+    ADD INDEX idx_serie_numero (serie_expediente, numero_expediente);
@@ -0,0 +1,13 @@
+-- +migrate Up
+DELETE older FROM dgii_registers older
+    JOIN dgii_registers newer
+        ON older.rnc = newer.rnc
+        AND (older.updated_at < newer.updated_at OR (older.updated_at = newer.updated_at AND older.id < newer.id));
+ALTER TABLE dgii_registers
+    DROP INDEX idx_rnc,
+    ADD UNIQUE INDEX uniq_rnc (rnc);
+
+-- +migrate Down
+ALTER TABLE dgii_registers
+    DROP INDEX uniq_rnc,
+    ADD INDEX idx_rnc (rnc);
@@ -0,0 +1,14 @@
+-- Searches match the unaccented text of the searched fields, so
+-- "Jose" finds "José"
+
+-- +migrate Up
+ALTER TABLE onapi_entities
+    ADD COLUMN search_ascii TEXT NULL AFTER domicilio;
+ALTER TABLE scj_cases
+    ADD COLUMN search_ascii TEXT NULL AFTER involucrados;
+
+-- +migrate Down
+ALTER TABLE scj_cases
+    DROP COLUMN search_ascii;
+ALTER TABLE onapi_entities
+    DROP COLUMN search_ascii;
@@ -0,0 +1,40 @@
+-- Indexes for the lookups and orderings the repositories run on every
+-- search: the results of a domain by date, PGR news by URL, and the newest
+-- records of each domain table. The other columns searched by exact value
+-- already have one: dynamic_pipeline_steps (pipeline_id), dgii_registers
+-- (rnc), onapi_entities (serie_expediente, numero_expediente) and
+-- google_docking_results (relevance).
+--
+-- Searches match LIKE '%...%', which no B-tree index can serve since the
+-- pattern does not fix a prefix; the prefix indexes on TEXT columns, like
+-- pgr_news (url(255)), serve exact and prefix matches only.
+
+-- +migrate Up
+ALTER TABLE domain_search_results
+    ADD INDEX idx_domain_type_created_at (domain_type, created_at);
+ALTER TABLE pgr_news
+    ADD INDEX idx_url (url(255)),
+    ADD INDEX idx_created_at (created_at);
+ALTER TABLE onapi_entities
+    ADD INDEX idx_created_at (created_at);
+ALTER TABLE scj_cases
+    ADD INDEX idx_created_at (created_at);
+ALTER TABLE dgii_registers
+    ADD INDEX idx_created_at (created_at);
+ALTER TABLE google_docking_results
+    ADD INDEX idx_created_at (created_at);
+
+-- +migrate Down
+ALTER TABLE google_docking_results
+    DROP INDEX idx_created_at;
+ALTER TABLE dgii_registers
+    DROP INDEX idx_created_at;
+ALTER TABLE scj_cases
+    DROP INDEX idx_created_at;
+ALTER TABLE onapi_entities
+    DROP INDEX idx_created_at;
+ALTER TABLE pgr_news
+    DROP INDEX idx_created_at,
+    DROP INDEX idx_url;
+ALTER TABLE domain_search_results
+    DROP INDEX idx_domain_type_created_at;
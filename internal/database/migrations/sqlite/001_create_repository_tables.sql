@@ -0,0 +1,253 @@
+-- SQLite schema for Insightful Intel repositories
+-- This file holds the tables of the MySQL migrations up to version 17, in
+-- the types SQLite accepts. Timestamps are TEXT in the format
+-- CURRENT_TIMESTAMP stores, so they read back as MySQL returns them.
+-- Index names are global in SQLite, so they are prefixed with their table.
+
+-- +migrate Up
+
+-- Dynamic pipeline results table
+CREATE TABLE IF NOT EXISTS dynamic_pipeline_results (
+    id CHAR(36) PRIMARY KEY,
+    session_id CHAR(36) NULL REFERENCES sessions(id) ON DELETE SET NULL,
+    total_steps INTEGER DEFAULT 0,
+    successful_steps INTEGER DEFAULT 0,
+    failed_steps INTEGER DEFAULT 0,
+    max_depth_reached INTEGER DEFAULT 0,
+    stop_reason VARCHAR(50) NOT NULL DEFAULT '',
+    config TEXT,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_dynamic_pipeline_results_session_id ON dynamic_pipeline_results (session_id);
+CREATE INDEX IF NOT EXISTS idx_dynamic_pipeline_results_created_at ON dynamic_pipeline_results (created_at);
+
+-- Dynamic pipeline steps table
+CREATE TABLE IF NOT EXISTS dynamic_pipeline_steps (
+    id CHAR(36) PRIMARY KEY,
+    pipeline_id CHAR(36) NOT NULL REFERENCES dynamic_pipeline_results(id) ON DELETE CASCADE,
+    parent_step_id CHAR(36) NULL,
+    domain_type VARCHAR(50) NOT NULL,
+    search_parameter VARCHAR(255),
+    category VARCHAR(50),
+    keywords TEXT,
+    success BOOLEAN DEFAULT FALSE,
+    error_message TEXT,
+    skip_reason VARCHAR(50) NOT NULL DEFAULT '',
+    output BLOB,
+    keywords_per_category BLOB,
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    depth INTEGER DEFAULT 0,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_dynamic_pipeline_steps_pipeline_id ON dynamic_pipeline_steps (pipeline_id);
+CREATE INDEX IF NOT EXISTS idx_dynamic_pipeline_steps_parent_step_id ON dynamic_pipeline_steps (parent_step_id);
+CREATE INDEX IF NOT EXISTS idx_dynamic_pipeline_steps_domain_type ON dynamic_pipeline_steps (domain_type);
+
+-- Domain search results table
+CREATE TABLE IF NOT EXISTS domain_search_results (
+    id CHAR(36) PRIMARY KEY,
+    success BOOLEAN DEFAULT FALSE,
+    error_message TEXT,
+    domain_type VARCHAR(50) NOT NULL,
+    search_parameter VARCHAR(255),
+    keywords_per_category BLOB,
+    output BLOB,
+    compressed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    pipeline_steps_id CHAR(36) REFERENCES dynamic_pipeline_steps(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_domain_search_results_domain_type ON domain_search_results (domain_type);
+CREATE INDEX IF NOT EXISTS idx_domain_search_results_created_at ON domain_search_results (created_at);
+CREATE INDEX IF NOT EXISTS idx_domain_search_results_pipeline_steps_id ON domain_search_results (pipeline_steps_id);
+
+-- ONAPI entities table
+CREATE TABLE IF NOT EXISTS onapi_entities (
+    id CHAR(36) PRIMARY KEY,
+    domain_search_result_id CHAR(36) REFERENCES domain_search_results(id) ON DELETE CASCADE,
+    serie_expediente INTEGER NOT NULL,
+    numero_expediente INTEGER NOT NULL,
+    certificado VARCHAR(255),
+    tipo VARCHAR(100),
+    subtipo VARCHAR(100),
+    texto TEXT,
+    clases TEXT,
+    aplicado_a_proteger TEXT,
+    expedicion VARCHAR(100),
+    vencimiento VARCHAR(100),
+    en_tramite BOOLEAN DEFAULT FALSE,
+    titular VARCHAR(255),
+    gestor VARCHAR(255),
+    domicilio TEXT,
+    search_ascii TEXT NULL,
+    status VARCHAR(100),
+    tipo_signo VARCHAR(100),
+    imagenes TEXT,
+    lista_clases TEXT,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_onapi_entities_domain_search_result_id ON onapi_entities (domain_search_result_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_onapi_entities_serie_numero ON onapi_entities (serie_expediente, numero_expediente);
+
+-- SCJ cases table
+CREATE TABLE IF NOT EXISTS scj_cases (
+    id CHAR(36) PRIMARY KEY,
+    domain_search_result_id CHAR(36) REFERENCES domain_search_results(id) ON DELETE CASCADE,
+    linea INTEGER,
+    agno_cabecera INTEGER,
+    mes_cabecera INTEGER,
+    url_cabecera TEXT,
+    url_cuerpo TEXT,
+    id_expediente INTEGER NOT NULL,
+    no_expediente VARCHAR(255),
+    no_sentencia VARCHAR(255),
+    no_unico VARCHAR(255),
+    no_interno VARCHAR(255),
+    id_tribunal VARCHAR(100),
+    desc_tribunal TEXT,
+    tribunal_canonico VARCHAR(100),
+    id_materia VARCHAR(100),
+    desc_materia TEXT,
+    materia_canonica VARCHAR(100),
+    fecha_fallo_raw VARCHAR(100),
+    fecha_fallo TEXT NULL,
+    involucrados TEXT,
+    search_ascii TEXT NULL,
+    guid_blob VARCHAR(255),
+    tipo_documento_adjunto VARCHAR(100),
+    total_filas INTEGER,
+    url_blob TEXT,
+    extension VARCHAR(10),
+    origen INTEGER,
+    activo BOOLEAN DEFAULT TRUE,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_scj_cases_domain_search_result_id ON scj_cases (domain_search_result_id);
+CREATE INDEX IF NOT EXISTS idx_scj_cases_no_expediente ON scj_cases (no_expediente);
+CREATE INDEX IF NOT EXISTS idx_scj_cases_tribunal_canonico ON scj_cases (tribunal_canonico);
+CREATE INDEX IF NOT EXISTS idx_scj_cases_materia_canonica ON scj_cases (materia_canonica);
+CREATE INDEX IF NOT EXISTS idx_scj_cases_fecha_fallo ON scj_cases (fecha_fallo);
+
+-- DGII registers table
+CREATE TABLE IF NOT EXISTS dgii_registers (
+    id CHAR(36) PRIMARY KEY,
+    domain_search_result_id CHAR(36) REFERENCES domain_search_results(id) ON DELETE CASCADE,
+    rnc VARCHAR(20) NOT NULL,
+    razon_social VARCHAR(255),
+    nombre_comercial VARCHAR(255),
+    categoria VARCHAR(100),
+    regimen_pagos VARCHAR(100),
+    facturador_electronico VARCHAR(100),
+    licencia_comercial VARCHAR(100),
+    estado VARCHAR(100),
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_dgii_registers_domain_search_result_id ON dgii_registers (domain_search_result_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_dgii_registers_rnc ON dgii_registers (rnc);
+
+-- PGR news table
+CREATE TABLE IF NOT EXISTS pgr_news (
+    id CHAR(36) PRIMARY KEY,
+    domain_search_result_id CHAR(36) REFERENCES domain_search_results(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title TEXT,
+    published_at TEXT NULL,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pgr_news_domain_search_result_id ON pgr_news (domain_search_result_id);
+
+-- Google Docking results table
+CREATE TABLE IF NOT EXISTS google_docking_results (
+    id CHAR(36) PRIMARY KEY,
+    domain_search_result_id CHAR(36) REFERENCES domain_search_results(id) ON DELETE CASCADE,
+    search_parameter VARCHAR(255),
+    url TEXT NOT NULL,
+    title TEXT,
+    description TEXT,
+    relevance DECIMAL(3,2) DEFAULT 0.00,
+    search_rank INTEGER DEFAULT 0,
+    keywords TEXT,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_google_docking_results_domain_search_result_id ON google_docking_results (domain_search_result_id);
+CREATE INDEX IF NOT EXISTS idx_google_docking_results_relevance ON google_docking_results (relevance);
+
+-- URL sources table
+CREATE TABLE IF NOT EXISTS url_sources (
+    id CHAR(36) PRIMARY KEY,
+    url TEXT NOT NULL,
+    record_table VARCHAR(50) NOT NULL,
+    record_id CHAR(36) NOT NULL,
+    domain_type VARCHAR(50) NOT NULL,
+    domain_search_result_id CHAR(36) REFERENCES domain_search_results(id) ON DELETE CASCADE,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_url_sources_url ON url_sources (url);
+CREATE INDEX IF NOT EXISTS idx_url_sources_record ON url_sources (record_table, record_id);
+
+-- Step keywords table
+CREATE TABLE IF NOT EXISTS step_keywords (
+    id CHAR(36) PRIMARY KEY,
+    step_id CHAR(36) NOT NULL REFERENCES dynamic_pipeline_steps(id) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    keyword TEXT NOT NULL,
+    normalized_keyword VARCHAR(255) NOT NULL,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_step_keywords_step_category ON step_keywords (step_id, category);
+CREATE INDEX IF NOT EXISTS idx_step_keywords_category_keyword ON step_keywords (category, normalized_keyword);
+
+-- Sessions table
+CREATE TABLE IF NOT EXISTS sessions (
+    id CHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_created_at ON sessions (created_at);
+
+-- Pipeline step queue table
+CREATE TABLE IF NOT EXISTS pipeline_step_queue (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    pipeline_id CHAR(36) NOT NULL REFERENCES dynamic_pipeline_results(id) ON DELETE CASCADE,
+    depth INTEGER NOT NULL,
+    priority INTEGER NOT NULL,
+    step TEXT NOT NULL,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pipeline_step_queue_order ON pipeline_step_queue (pipeline_id, depth, priority);
+
+-- Pipeline events table
+CREATE TABLE IF NOT EXISTS pipeline_events (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    pipeline_id CHAR(36) NOT NULL REFERENCES dynamic_pipeline_results(id) ON DELETE CASCADE,
+    step_id CHAR(36) NULL,
+    type VARCHAR(50) NOT NULL,
+    message TEXT,
+    data TEXT,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_pipeline_events_pipeline ON pipeline_events (pipeline_id, seq);
+
+-- +migrate Down
+DROP TABLE IF EXISTS url_sources;
+DROP TABLE IF EXISTS pipeline_events;
+DROP TABLE IF EXISTS pipeline_step_queue;
+DROP TABLE IF EXISTS step_keywords;
+DROP TABLE IF EXISTS dynamic_pipeline_steps;
+DROP TABLE IF EXISTS dynamic_pipeline_results;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS google_docking_results;
+DROP TABLE IF EXISTS pgr_news;
+DROP TABLE IF EXISTS dgii_registers;
+DROP TABLE IF EXISTS scj_cases;
+DROP TABLE IF EXISTS onapi_entities;
+DROP TABLE IF EXISTS domain_search_results;
@@ -0,0 +1,20 @@
+-- The indexes of the MySQL migration 018_add_lookup_indexes. SQLite has no
+-- prefix indexes, so pgr_news (url) indexes the whole URL.
+
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_domain_search_results_domain_type_created_at ON domain_search_results (domain_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_pgr_news_url ON pgr_news (url);
+CREATE INDEX IF NOT EXISTS idx_pgr_news_created_at ON pgr_news (created_at);
+CREATE INDEX IF NOT EXISTS idx_onapi_entities_created_at ON onapi_entities (created_at);
+CREATE INDEX IF NOT EXISTS idx_scj_cases_created_at ON scj_cases (created_at);
+CREATE INDEX IF NOT EXISTS idx_dgii_registers_created_at ON dgii_registers (created_at);
+CREATE INDEX IF NOT EXISTS idx_google_docking_results_created_at ON google_docking_results (created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_google_docking_results_created_at;
+DROP INDEX IF EXISTS idx_dgii_registers_created_at;
+DROP INDEX IF EXISTS idx_scj_cases_created_at;
+DROP INDEX IF EXISTS idx_onapi_entities_created_at;
+DROP INDEX IF EXISTS idx_pgr_news_created_at;
+DROP INDEX IF EXISTS idx_pgr_news_url;
+DROP INDEX IF EXISTS idx_domain_search_results_domain_type_created_at;
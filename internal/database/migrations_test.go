@@ -0,0 +1,242 @@
+package database
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrationsAreVersionedInOrder(t *testing.T) {
+	for name, migrations := range map[string][]Migration{
+		"mysql":  GetInitialMigrations(),
+		"sqlite": GetSQLiteMigrations(),
+	} {
+		if len(migrations) == 0 {
+			t.Fatalf("%s: expected embedded migrations", name)
+		}
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Errorf("%s: expected version %d at position %d, got %d", name, i+1, i, migration.Version)
+			}
+			if migration.UpSQL == "" || migration.DownSQL == "" {
+				t.Errorf("%s: expected migration %d to apply and roll back", name, migration.Version)
+			}
+		}
+
+		// Databases already migrated recorded version 1 under this name
+		if migrations[0].Name != "create_repository_tables" {
+			t.Errorf("%s: expected version 1 to keep its name, got %q", name, migrations[0].Name)
+		}
+	}
+}
+
+func TestMySQLMigrationsDropOnlyTheirOwnTables(t *testing.T) {
+	tableName := regexp.MustCompile(`(?i)(?:CREATE TABLE IF NOT EXISTS|DROP TABLE IF EXISTS)\s+(\w+)`)
+	tables := func(sql, statement string) map[string]bool {
+		names := make(map[string]bool)
+		for _, match := range tableName.FindAllStringSubmatch(sql, -1) {
+			if strings.HasPrefix(strings.ToUpper(match[0]), statement) {
+				names[match[1]] = true
+			}
+		}
+		return names
+	}
+
+	// Each migration rolls back its own tables, the later ones before it
+	for _, migration := range GetInitialMigrations() {
+		created := tables(migration.UpSQL, "CREATE")
+		for name := range tables(migration.DownSQL, "DROP") {
+			if !created[name] {
+				t.Errorf("migration %d drops %s, which it does not create", migration.Version, name)
+			}
+		}
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	migrations, err := ParseMigrations(fstest.MapFS{
+		"002_add_indexes.sql": {Data: []byte("-- +migrate Up\nCREATE INDEX idx_name ON things (name);\n\n-- +migrate Down\nDROP INDEX idx_name;\n")},
+		"001_init.sql":        {Data: []byte("-- Things\n\n-- +migrate Up\nCREATE TABLE things (\n    name TEXT\n);\n-- +migrate Down\nDROP TABLE things;\n")},
+		"README.md":           {Data: []byte("not a migration")},
+	})
+	if err != nil {
+		t.Fatalf("ParseMigrations returned error: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "init", UpSQL: "CREATE TABLE things (\nname TEXT\n);", DownSQL: "DROP TABLE things;"},
+		{Version: 2, Name: "add_indexes", UpSQL: "CREATE INDEX idx_name ON things (name);", DownSQL: "DROP INDEX idx_name;"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("expected %d migrations, got %+v", len(want), migrations)
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("expected migration %+v, got %+v", want[i], migrations[i])
+		}
+	}
+}
+
+func TestParseMigrationsRejectsInvalidFiles(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"unversioned": {"init.sql": {Data: []byte("-- +migrate Up\nSELECT 1;\n-- +migrate Down\n")}},
+		"version 0":   {"000_init.sql": {Data: []byte("-- +migrate Up\nSELECT 1;\n-- +migrate Down\n")}},
+		"no up":       {"001_init.sql": {Data: []byte("SELECT 1;\n-- +migrate Down\n")}},
+		"no down":     {"001_init.sql": {Data: []byte("-- +migrate Up\nSELECT 1;\n")}},
+		"duplicate version": {
+			"001_init.sql": {Data: []byte("-- +migrate Up\nSELECT 1;\n-- +migrate Down\n")},
+			"01_other.sql": {Data: []byte("-- +migrate Up\nSELECT 1;\n-- +migrate Down\n")},
+		},
+	} {
+		if _, err := ParseMigrations(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRunMigrationsAppliesNewFilesIncrementally(t *testing.T) {
+	db, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"001_init.sql": {Data: []byte("-- +migrate Up\nCREATE TABLE things (name TEXT);\nINSERT INTO things (name) VALUES ('first');\n-- +migrate Down\nDROP TABLE things;\n")},
+	}
+	migrationService := NewMigrationService(db)
+
+	migrations, err := ParseMigrations(fsys)
+	if err != nil {
+		t.Fatalf("ParseMigrations returned error: %v", err)
+	}
+	if err := migrationService.RunMigrations(migrations); err != nil {
+		t.Fatalf("RunMigrations returned error: %v", err)
+	}
+
+	// A second file is applied alone to the migrated database
+	fsys["002_add_kind.sql"] = &fstest.MapFile{Data: []byte("-- +migrate Up\nALTER TABLE things ADD COLUMN kind TEXT;\n-- +migrate Down\nALTER TABLE things DROP COLUMN kind;\n")}
+	if migrations, err = ParseMigrations(fsys); err != nil {
+		t.Fatalf("ParseMigrations returned error: %v", err)
+	}
+	if err := migrationService.RunMigrations(migrations); err != nil {
+		t.Fatalf("RunMigrations returned error: %v", err)
+	}
+
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM things WHERE kind IS NULL`).Scan(&rows); err != nil {
+		t.Fatalf("expected the second migration to add the column: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("expected the first migration to run once, got %d rows", rows)
+	}
+
+	executed, err := migrationService.GetExecutedMigrations()
+	if err != nil {
+		t.Fatalf("GetExecutedMigrations returned error: %v", err)
+	}
+	if len(executed) != 2 || !executed[1] || !executed[2] {
+		t.Errorf("expected versions 1 and 2 to be recorded, got %v", executed)
+	}
+}
+
+func TestMySQLLookupIndexes(t *testing.T) {
+	db := New().GetDB()
+	migrationService := NewMigrationService(db)
+	if err := migrationService.RunMigrations(GetInitialMigrations()); err != nil {
+		t.Fatalf("RunMigrations returned error: %v", err)
+	}
+
+	for query, index := range map[string]string{
+		`SELECT id FROM pgr_news WHERE url = 'https://pgr.gob.do/noticia'`:                      "idx_url",
+		`SELECT id FROM dgii_registers WHERE rnc = '101010101'`:                                 "uniq_rnc",
+		`SELECT id FROM dynamic_pipeline_steps WHERE pipeline_id = 'pipeline'`:                  "idx_pipeline_id",
+		`SELECT id FROM google_docking_results WHERE relevance = 1`:                             "idx_relevance",
+		`SELECT id FROM onapi_entities WHERE serie_expediente = 2020 AND numero_expediente = 1`: "uniq_serie_numero",
+	} {
+		var keys []string
+		for _, row := range explain(t, db, query) {
+			keys = append(keys, row["key"])
+		}
+		if !strings.Contains(strings.Join(keys, ","), index) {
+			t.Errorf("expected %s to use %s, got keys %v", query, index, keys)
+		}
+	}
+}
+
+func TestSQLiteLookupIndexes(t *testing.T) {
+	db, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	defer db.Close()
+
+	migrationService := NewMigrationService(db)
+	migrations := GetSQLiteMigrations()
+	if err := migrationService.RunMigrations(migrations); err != nil {
+		t.Fatalf("RunMigrations returned error: %v", err)
+	}
+
+	// The index migration rolls back and applies again cleanly
+	if err := migrationService.RollbackMigrations(migrations, 1); err != nil {
+		t.Fatalf("RollbackMigrations returned error: %v", err)
+	}
+	if err := migrationService.RunMigrations(migrations); err != nil {
+		t.Fatalf("RunMigrations returned error: %v", err)
+	}
+
+	for query, index := range map[string]string{
+		`SELECT id FROM pgr_news WHERE url = 'https://pgr.gob.do/noticia'`:                                 "idx_pgr_news_url",
+		`SELECT id FROM domain_search_results WHERE domain_type = 'PGR' ORDER BY created_at DESC LIMIT 10`: "idx_domain_search_results_domain_type_created_at",
+		`SELECT id FROM dgii_registers ORDER BY created_at DESC LIMIT 10`:                                  "idx_dgii_registers_created_at",
+	} {
+		var plan []string
+		for _, row := range explain(t, db, query) {
+			plan = append(plan, row["detail"])
+		}
+		if !strings.Contains(strings.Join(plan, "\n"), "INDEX "+index) {
+			t.Errorf("expected %s to use %s, got plan %v", query, index, plan)
+		}
+	}
+}
+
+// explain returns the rows of the plan of query by column
+func explain(t *testing.T, db *sql.DB, query string) []map[string]string {
+	t.Helper()
+
+	statement := "EXPLAIN " + query
+	if DialectOf(db) == DialectSQLite {
+		statement = "EXPLAIN QUERY PLAN " + query
+	}
+	rows, err := db.Query(statement)
+	if err != nil {
+		t.Fatalf("failed to explain %s: %v", query, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("failed to read the columns of %s: %v", query, err)
+	}
+
+	var plan []map[string]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatalf("failed to scan the plan of %s: %v", query, err)
+		}
+
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			row[column] = values[i].String
+		}
+		plan = append(plan, row)
+	}
+	return plan
+}
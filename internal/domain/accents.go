@@ -0,0 +1,16 @@
+package domain
+
+import "strings"
+
+var accentFolder = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u", "â", "a", "ê", "e", "ô", "o", "ç", "c",
+	"Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U", "Ñ", "N",
+	"À", "A", "È", "E", "Ì", "I", "Ò", "O", "Ù", "U", "Â", "A", "Ê", "E", "Ô", "O", "Ç", "C",
+)
+
+// FoldAccents replaces the accented letters of s with their unaccented form,
+// keeping their case, so "José Peña" becomes "Jose Pena"
+func FoldAccents(s string) string {
+	return accentFolder.Replace(s)
+}
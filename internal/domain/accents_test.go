@@ -0,0 +1,19 @@
+package domain
+
+import "testing"
+
+func TestFoldAccents(t *testing.T) {
+	tests := map[string]string{
+		"José Peña":         "Jose Pena",
+		"JOSÉ PEÑA":         "JOSE PENA",
+		"Compañía Agrícola": "Compania Agricola",
+		"Güiro, Ñame":       "Guiro, Name",
+		"NOVASCO SRL":       "NOVASCO SRL",
+	}
+
+	for in, want := range tests {
+		if got := FoldAccents(in); got != want {
+			t.Errorf("FoldAccents(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+// DefaultAdaptiveDepthWindow is the number of steps in a row a branch may go
+// without finding a new entity before adaptive depth stops it
+const DefaultAdaptiveDepthWindow = 2
+
+// DefaultAdaptiveDepthExtension is the number of levels past MaxDepth adaptive
+// depth lets a productive branch go
+const DefaultAdaptiveDepthExtension = 2
+
+// DepthWindow returns the number of barren steps that stop a branch,
+// DefaultAdaptiveDepthWindow when none is set
+func (c DynamicPipelineConfig) DepthWindow() int {
+	if c.AdaptiveDepthWindow <= 0 {
+		return DefaultAdaptiveDepthWindow
+	}
+	return c.AdaptiveDepthWindow
+}
+
+// DepthExtension returns the number of levels a productive branch may go past
+// MaxDepth, DefaultAdaptiveDepthExtension when none is set
+func (c DynamicPipelineConfig) DepthExtension() int {
+	if c.AdaptiveDepthExtension <= 0 {
+		return DefaultAdaptiveDepthExtension
+	}
+	return c.AdaptiveDepthExtension
+}
+
+// BranchYield decides which completed steps of a pipeline have their keywords
+// searched. Without adaptive depth, the steps above MaxDepth do. With it, each
+// branch, the chain of steps leading from an initial step to a step, counts the
+// steps in a row that found no entity the run had not found before: a branch
+// stops once that count reaches the window, and goes past MaxDepth while its
+// steps keep finding new entities.
+type BranchYield struct {
+	config DynamicPipelineConfig
+	found  map[string]bool
+	barren map[ID]int
+}
+
+// NewBranchYield creates the yield tracker of a pipeline run
+func NewBranchYield(config DynamicPipelineConfig) *BranchYield {
+	return &BranchYield{
+		config: config,
+		found:  make(map[string]bool),
+		barren: make(map[ID]int),
+	}
+}
+
+// Expand records the keywords found by a completed step and reports whether
+// the steps generated from them should be queued. Steps must be expanded after
+// the step that found their keyword.
+func (b *BranchYield) Expand(step DynamicPipelineStep) bool {
+	if !b.config.AdaptiveDepth {
+		return step.Depth < b.config.MaxDepth
+	}
+
+	barren := 0
+	if !b.record(step) {
+		barren = b.barren[step.ParentStepID] + 1
+	}
+	b.barren[step.ID] = barren
+
+	if barren >= b.config.DepthWindow() {
+		return false
+	}
+	if step.Depth < b.config.MaxDepth {
+		return true
+	}
+	return barren == 0 && step.Depth < b.config.MaxDepth+b.config.DepthExtension()
+}
+
+// record marks the keywords of a step as found, reporting whether any of them
+// had not been found before
+func (b *BranchYield) record(step DynamicPipelineStep) bool {
+	found := false
+	for category, keywords := range step.KeywordsPerCategory {
+		for _, keyword := range keywords {
+			key := string(category) + "|" + NormalizeKeyword(keyword)
+			if NormalizeKeyword(keyword) == "" || b.found[key] {
+				continue
+			}
+			b.found[key] = true
+			found = true
+		}
+	}
+	return found
+}
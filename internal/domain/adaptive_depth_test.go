@@ -0,0 +1,77 @@
+package domain
+
+import "testing"
+
+// branchStep is a step of a branch finding the given company names
+func branchStep(parent DynamicPipelineStep, names ...string) DynamicPipelineStep {
+	step := DynamicPipelineStep{
+		ID:                  NewID(),
+		ParentStepID:        parent.ID,
+		Depth:               parent.Depth + 1,
+		KeywordsPerCategory: map[KeywordCategory][]string{KeywordCategoryCompanyName: names},
+	}
+	if parent.ID == (ID{}) {
+		step.Depth = 0
+	}
+	return step
+}
+
+func TestBranchYieldWithoutAdaptiveDepth(t *testing.T) {
+	branches := NewBranchYield(DynamicPipelineConfig{MaxDepth: 2})
+
+	for depth, want := range []bool{true, true, false} {
+		if got := branches.Expand(DynamicPipelineStep{ID: NewID(), Depth: depth}); got != want {
+			t.Errorf("depth %d: expected Expand to be %v", depth, want)
+		}
+	}
+}
+
+func TestBranchYieldStopsBarrenBranches(t *testing.T) {
+	branches := NewBranchYield(DynamicPipelineConfig{MaxDepth: 5, AdaptiveDepth: true, AdaptiveDepthWindow: 2})
+
+	root := branchStep(DynamicPipelineStep{}, "NOVASCO", "ECHO")
+	if !branches.Expand(root) {
+		t.Fatal("expected the root step to be expanded")
+	}
+
+	// One barren step is tolerated, the second one in a row stops the branch
+	first := branchStep(root, "echo")
+	if !branches.Expand(first) {
+		t.Error("expected the first barren step to be expanded")
+	}
+	if second := branchStep(first, "Novasco"); branches.Expand(second) {
+		t.Errorf("expected the branch to stop at depth %d, before MaxDepth", second.Depth)
+	}
+
+	// A new entity resets the count
+	revived := branchStep(first, "NOVASCO CARIBE")
+	if !branches.Expand(revived) {
+		t.Error("expected a step finding a new entity to be expanded")
+	}
+	if !branches.Expand(branchStep(revived)) {
+		t.Error("expected the count to restart after a new entity")
+	}
+}
+
+func TestBranchYieldExtendsProductiveBranches(t *testing.T) {
+	branches := NewBranchYield(DynamicPipelineConfig{MaxDepth: 1, AdaptiveDepth: true, AdaptiveDepthExtension: 2})
+
+	step := branchStep(DynamicPipelineStep{}, "CHAIN 0")
+	for depth := 0; depth < 3; depth++ {
+		if !branches.Expand(step) {
+			t.Fatalf("expected the productive step at depth %d to be expanded", depth)
+		}
+		step = branchStep(step, "CHAIN "+string(rune('1'+depth)))
+	}
+	if branches.Expand(step) {
+		t.Errorf("expected the extension to end the branch at depth %d", step.Depth)
+	}
+
+	// Past MaxDepth only steps finding new entities go on
+	barren := NewBranchYield(DynamicPipelineConfig{MaxDepth: 1, AdaptiveDepth: true, AdaptiveDepthWindow: 3})
+	root := branchStep(DynamicPipelineStep{}, "NOVASCO")
+	barren.Expand(root)
+	if barren.Expand(branchStep(root, "NOVASCO")) {
+		t.Error("expected a barren step at MaxDepth not to be expanded")
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// CamaraRecord is a company registration from the mercantile registry of the
+// chambers of commerce
+type CamaraRecord struct {
+	ID                   ID             `json:"id"`
+	DomainSearchResultID ID             `json:"domain_search_result_id"`
+	RegistroMercantil    string         `json:"registro_mercantil"`
+	RNC                  string         `json:"rnc"`
+	RazonSocial          string         `json:"razon_social"`
+	NombreComercial      string         `json:"nombre_comercial"`
+	Camara               string         `json:"camara"`
+	Estado               string         `json:"estado"`
+	FechaConstitucion    string         `json:"fecha_constitucion"`
+	Domicilio            string         `json:"domicilio"`
+	Gerentes             []CamaraPerson `json:"gerentes"`
+	Socios               []CamaraPerson `json:"socios"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
+
+// CamaraPerson is an officer or shareholder listed in a mercantile registration
+type CamaraPerson struct {
+	Nombre    string `json:"nombre"`
+	Cedula    string `json:"cedula"`
+	Cargo     string `json:"cargo"`
+	Domicilio string `json:"domicilio"`
+}
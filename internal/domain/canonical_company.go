@@ -0,0 +1,300 @@
+package domain
+
+import (
+	"insightful-intel/internal/textmatch"
+	"slices"
+	"strings"
+)
+
+// DefaultCompanySimilarity is the name similarity above which two company names
+// found in different domains are considered the same company
+const DefaultCompanySimilarity = 0.85
+
+// CanonicalCompany is a company known under several names across domains
+type CanonicalCompany struct {
+	Name    string       `json:"name"`
+	RNC     string       `json:"rnc,omitempty"`
+	Aliases []string     `json:"aliases"`
+	Sources []DomainType `json:"sources"`
+}
+
+// Key identifies the company regardless of the alias it was found by
+func (c *CanonicalCompany) Key() string {
+	return "company:" + normalizeCompanyName(c.Name)
+}
+
+// CompanyCanonicalizer unifies the names under which the same company appears
+// in DGII (RazonSocial, NombreComercial) and ONAPI (Texto), matching records by
+// RNC or by name similarity
+type CompanyCanonicalizer struct {
+	Threshold float64
+
+	companies []*CanonicalCompany
+	byRNC     map[string]*CanonicalCompany
+	byAlias   map[string]*CanonicalCompany
+
+	// step is the step whose output is being added, if any, and references the
+	// steps each company took its aliases from
+	step       *DynamicPipelineStep
+	references map[*CanonicalCompany][]EntityReference
+}
+
+// NewCompanyCanonicalizer creates a canonicalizer with the given similarity
+// threshold, using DefaultCompanySimilarity when it is not positive
+func NewCompanyCanonicalizer(threshold float64) *CompanyCanonicalizer {
+	if threshold <= 0 {
+		threshold = DefaultCompanySimilarity
+	}
+	return &CompanyCanonicalizer{
+		Threshold:  threshold,
+		byRNC:      make(map[string]*CanonicalCompany),
+		byAlias:    make(map[string]*CanonicalCompany),
+		references: make(map[*CanonicalCompany][]EntityReference),
+	}
+}
+
+// AddRegister merges the names of a DGII register into its canonical company
+func (c *CompanyCanonicalizer) AddRegister(register Register) *CanonicalCompany {
+	return c.add(DomainTypeDGII, register.RNC, register.RazonSocial, register.NombreComercial)
+}
+
+// AddEntity merges the name of an ONAPI entity into its canonical company
+func (c *CompanyCanonicalizer) AddEntity(entity Entity) *CanonicalCompany {
+	return c.add(DomainTypeONAPI, "", entity.Texto)
+}
+
+// AddFinding merges a manual company name or RNC into its canonical company.
+// Findings of other categories are not companies, so they return nil.
+func (c *CompanyCanonicalizer) AddFinding(finding ManualFinding) *CanonicalCompany {
+	switch finding.Category {
+	case KeywordCategoryCompanyName:
+		return c.add(DomainTypeManual, "", finding.Name)
+	case KeywordCategoryContributorID:
+		return c.add(DomainTypeManual, finding.Name)
+	}
+	return nil
+}
+
+// AddOutput merges the companies found in the output of a domain search,
+// returning the canonical companies it touched
+func (c *CompanyCanonicalizer) AddOutput(output any) []*CanonicalCompany {
+	var touched []*CanonicalCompany
+
+	switch records := output.(type) {
+	case []Register:
+		for _, register := range records {
+			if company := c.AddRegister(register); company != nil {
+				touched = append(touched, company)
+			}
+		}
+	case []Entity:
+		for _, entity := range records {
+			if company := c.AddEntity(entity); company != nil {
+				touched = append(touched, company)
+			}
+		}
+	case []ManualFinding:
+		for _, finding := range records {
+			if company := c.AddFinding(finding); company != nil {
+				touched = append(touched, company)
+			}
+		}
+	}
+
+	return touched
+}
+
+// AddStep merges the companies in the output of a step like AddOutput, and
+// records the step as the provenance of the aliases it contributed
+func (c *CompanyCanonicalizer) AddStep(step DynamicPipelineStep, output any) []*CanonicalCompany {
+	c.step = &step
+	defer func() { c.step = nil }()
+	return c.AddOutput(output)
+}
+
+// Resolve returns the canonical company of a name, or nil when the name does
+// not match any known company
+func (c *CompanyCanonicalizer) Resolve(name string) *CanonicalCompany {
+	normalized := normalizeCompanyName(name)
+	if normalized == "" {
+		return nil
+	}
+
+	if company, ok := c.byAlias[normalized]; ok {
+		return company
+	}
+
+	var best *CanonicalCompany
+	bestScore := 0.0
+	for _, company := range c.companies {
+		for _, alias := range company.Aliases {
+			score := companyNameSimilarity(normalized, normalizeCompanyName(alias))
+			if score >= c.Threshold && score > bestScore {
+				best, bestScore = company, score
+			}
+		}
+	}
+
+	return best
+}
+
+// Companies returns the canonical companies found so far
+func (c *CompanyCanonicalizer) Companies() []CanonicalCompany {
+	companies := make([]CanonicalCompany, 0, len(c.companies))
+	for _, company := range c.companies {
+		companies = append(companies, *company)
+	}
+	return companies
+}
+
+func (c *CompanyCanonicalizer) add(source DomainType, rnc string, names ...string) *CanonicalCompany {
+	rnc = strings.ReplaceAll(strings.TrimSpace(rnc), "-", "")
+
+	var company *CanonicalCompany
+	if rnc != "" {
+		company = c.byRNC[rnc]
+	}
+	for _, name := range names {
+		if company != nil {
+			break
+		}
+		company = c.Resolve(name)
+	}
+
+	if company == nil {
+		first := firstNonEmpty(names...)
+		if first == "" {
+			return nil
+		}
+		company = &CanonicalCompany{Name: strings.TrimSpace(first)}
+		c.companies = append(c.companies, company)
+	}
+
+	if rnc != "" && company.RNC == "" {
+		company.RNC = rnc
+		c.byRNC[rnc] = company
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		normalized := normalizeCompanyName(name)
+		if normalized == "" {
+			continue
+		}
+		if _, known := c.byAlias[normalized]; !known {
+			c.byAlias[normalized] = company
+		}
+		if !slices.ContainsFunc(company.Aliases, func(alias string) bool { return strings.EqualFold(alias, name) }) {
+			company.Aliases = append(company.Aliases, name)
+		}
+	}
+
+	if !slices.Contains(company.Sources, source) {
+		company.Sources = append(company.Sources, source)
+	}
+
+	if c.step != nil {
+		c.reference(company, rnc, names)
+	}
+
+	return company
+}
+
+// reference records the current step as the provenance of the names, or of the
+// RNC when the record named no company
+func (c *CompanyCanonicalizer) reference(company *CanonicalCompany, rnc string, names []string) {
+	aliases := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); normalizeCompanyName(name) != "" {
+			aliases = append(aliases, name)
+		}
+	}
+	if len(aliases) == 0 && rnc != "" {
+		aliases = append(aliases, rnc)
+	}
+
+	for _, alias := range aliases {
+		reference := EntityReference{
+			Alias:           alias,
+			DomainType:      c.step.DomainType,
+			StepID:          c.step.ID,
+			SearchParameter: c.step.SearchParameter,
+			Manual:          c.step.DomainType == DomainTypeManual,
+		}
+		// Records of the same step often repeat a name
+		if !slices.ContainsFunc(c.references[company], func(known EntityReference) bool {
+			return known.StepID == reference.StepID && strings.EqualFold(known.Alias, reference.Alias)
+		}) {
+			c.references[company] = append(c.references[company], reference)
+		}
+	}
+}
+
+// companyLegalForms are the legal forms ignored when comparing names, as
+// normalizeScjText leaves them
+var companyLegalForms = [][]string{
+	{"s", "r", "l"}, {"srl"}, {"s", "a", "s"}, {"sas"}, {"s", "a"}, {"sa"},
+	{"e", "i", "r", "l"}, {"eirl"}, {"c", "por", "a"}, {"c", "x", "a"}, {"n", "v"}, {"nv"},
+}
+
+// CanonicalizeCompanyName returns the form company names are compared and
+// searched in: upper case, without accents, punctuation or legal forms, and
+// with single spaces, so "Compañía Novasco, S.R.L." and "COMPANIA NOVASCO SRL"
+// are the same keyword. A name that is only a legal form is kept as it is.
+func CanonicalizeCompanyName(name string) string {
+	return strings.ToUpper(normalizeCompanyName(name))
+}
+
+// IsCompanyLegalForm reports whether text is a legal form alone, such as the
+// "S.R.L." split off a list of names
+func IsCompanyLegalForm(text string) bool {
+	words := strings.Fields(normalizeScjText(text))
+	return len(words) > 0 && legalFormAt(words, 0) == len(words)
+}
+
+// normalizeCompanyName lowercases and folds the name and drops its legal
+// forms, wherever they are in the name
+func normalizeCompanyName(name string) string {
+	words := strings.Fields(normalizeScjText(name))
+
+	kept := make([]string, 0, len(words))
+	for i := 0; i < len(words); {
+		if n := legalFormAt(words, i); n > 0 {
+			i += n
+			continue
+		}
+		kept = append(kept, words[i])
+		i++
+	}
+
+	if len(kept) == 0 {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(kept, " ")
+}
+
+// legalFormAt returns the number of words of the legal form starting at
+// words[i], zero when none does
+func legalFormAt(words []string, i int) int {
+	for _, form := range companyLegalForms {
+		if i+len(form) <= len(words) && slices.Equal(words[i:i+len(form)], form) {
+			return len(form)
+		}
+	}
+	return 0
+}
+
+// companyNameSimilarity returns the edit distance similarity of two normalized
+// names, from 0 (different) to 1 (equal)
+func companyNameSimilarity(a, b string) float64 {
+	return textmatch.Similarity(a, b)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompanyCanonicalizerMergesDgiiAndOnapiNames(t *testing.T) {
+	companies := NewCompanyCanonicalizer(0)
+
+	fromOnapi := companies.AddEntity(Entity{Texto: "NOVASCO REAL ESTATE"})
+	fromDgii := companies.AddRegister(Register{
+		RNC:             "1-30-00000-1",
+		RazonSocial:     "Novasco Real Estate, S.R.L.",
+		NombreComercial: "Novasco Inmobiliaria",
+	})
+	byRNC := companies.AddRegister(Register{RNC: "130000001", RazonSocial: "NOVASCO INMOBILIARIA SRL"})
+	other := companies.AddEntity(Entity{Texto: "Constructora Bisonó"})
+
+	if fromOnapi != fromDgii || fromDgii != byRNC {
+		t.Fatal("expected the DGII and ONAPI names to merge into one company")
+	}
+	if other == fromOnapi {
+		t.Fatal("expected an unrelated company to stay separate")
+	}
+
+	if fromOnapi.Name != "NOVASCO REAL ESTATE" || fromOnapi.RNC != "130000001" {
+		t.Errorf("unexpected canonical company: %+v", fromOnapi)
+	}
+	wantAliases := []string{"NOVASCO REAL ESTATE", "Novasco Real Estate, S.R.L.", "Novasco Inmobiliaria", "NOVASCO INMOBILIARIA SRL"}
+	if !slices.Equal(fromOnapi.Aliases, wantAliases) {
+		t.Errorf("expected aliases %v, got %v", wantAliases, fromOnapi.Aliases)
+	}
+	if !slices.Equal(fromOnapi.Sources, []DomainType{DomainTypeONAPI, DomainTypeDGII}) {
+		t.Errorf("unexpected sources: %v", fromOnapi.Sources)
+	}
+
+	if companies.Resolve("novasco real estate s a") != fromOnapi {
+		t.Error("expected a name differing only in legal form to resolve to the company")
+	}
+	if companies.Resolve("Banco Popular") != nil {
+		t.Error("expected an unknown name not to resolve")
+	}
+	if len(companies.Companies()) != 2 {
+		t.Errorf("expected 2 companies, got %d", len(companies.Companies()))
+	}
+}
+
+func TestCanonicalizeCompanyName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Compañía Novasco, S.R.L.", "COMPANIA NOVASCO"},
+		{"COMPANIA NOVASCO SRL", "COMPANIA NOVASCO"},
+		{"  novasco   real estate  ", "NOVASCO REAL ESTATE"},
+		{"Novasco, S. A.", "NOVASCO"},
+		{"S.A. Novasco", "NOVASCO"},
+		{"Novasco S.A.S. Inversiones", "NOVASCO INVERSIONES"},
+		{"Inversiones Caribe N.V.", "INVERSIONES CARIBE"},
+		{"Construcciones Peña, C. por A.", "CONSTRUCCIONES PENA"},
+		{"S.R.L.", "S R L"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CanonicalizeCompanyName(tt.name); got != tt.want {
+			t.Errorf("CanonicalizeCompanyName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsCompanyLegalForm(t *testing.T) {
+	for _, text := range []string{"S.R.L.", "s. a.", "N.V.", "EIRL"} {
+		if !IsCompanyLegalForm(text) {
+			t.Errorf("expected %q to be a legal form", text)
+		}
+	}
+	for _, text := range []string{"Novasco S.R.L.", "SA Novasco", ""} {
+		if IsCompanyLegalForm(text) {
+			t.Errorf("expected %q not to be a legal form alone", text)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package domain
+
+// CompanyLink is a company in which a person appears, together with the record
+// that links them
+type CompanyLink struct {
+	CompanyName          string     `json:"company_name"`
+	RNC                  string     `json:"rnc,omitempty"`
+	Role                 string     `json:"role"`
+	Source               DomainType `json:"source"`
+	Reference            string     `json:"reference"`
+	DomainSearchResultID ID         `json:"domain_search_result_id"`
+}
+
+const (
+	CompanyRoleTitular = "titular"
+	CompanyRoleGestor  = "gestor"
+	CompanyRoleGerente = "gerente"
+	CompanyRoleSocio   = "socio"
+)
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"reflect"
+	"slices"
+)
+
+// DefaultConfidenceWeights is the weighting used when a pipeline sets none
+var DefaultConfidenceWeights = ConfidenceWeights{
+	Corroboration: 0.4,
+	Alerts:        0.4,
+	Coverage:      0.2,
+}
+
+// AlertDomainTypes are the domains whose records are alerts about the target
+// rather than a footprint: sanctions list matches, prosecutor news and the
+// dorks run with fraud keywords
+var AlertDomainTypes = []DomainType{
+	DomainTypeSanctions,
+	DomainTypePGR,
+	DomainTypeGoogleDorking,
+	DomainTypeFileType,
+}
+
+// ConfidenceWeights weighs the signals aggregated into the overall confidence.
+// Only their proportions matter.
+type ConfidenceWeights struct {
+	Corroboration float64 `json:"corroboration"`
+	Alerts        float64 `json:"alerts"`
+	Coverage      float64 `json:"coverage"`
+}
+
+// PipelineConfidence is the headline signal of a pipeline: how confident the
+// run is that the target has a meaningful footprint or risk
+type PipelineConfidence struct {
+	// OverallConfidence aggregates the signals below, from 0 to 1
+	OverallConfidence float64 `json:"overall_confidence"`
+	// CorroboratingSources counts the domains other than the alert ones that
+	// returned records, the manual findings counting as one more source
+	CorroboratingSources int `json:"corroborating_sources"`
+	// AlertMatches counts the records returned by the alert domains
+	AlertMatches int `json:"alert_matches"`
+	// SourceCoverage is the fraction of the searched domains that answered
+	SourceCoverage float64 `json:"source_coverage"`
+}
+
+// ScoreConfidence aggregates the completed steps of a pipeline into its
+// confidence. Corroboration and alerts saturate, so each additional source or
+// match raises the score by less than the one before.
+func ScoreConfidence(steps []DynamicPipelineStep, weights ConfidenceWeights) PipelineConfidence {
+	if weights == (ConfidenceWeights{}) {
+		weights = DefaultConfidenceWeights
+	}
+
+	searched := make(map[DomainType]bool)
+	answered := make(map[DomainType]bool)
+	corroborating := make(map[DomainType]bool)
+	alerts := 0
+
+	for _, step := range steps {
+		// Manual findings were not searched, so they corroborate the target
+		// without counting towards the coverage
+		if step.DomainType == DomainTypeManual {
+			if step.Success && countRecords(step.Output) > 0 {
+				corroborating[DomainTypeManual] = true
+			}
+			continue
+		}
+		if step.SkipReason != "" || !IsValidDomainType(step.DomainType) {
+			continue
+		}
+		searched[step.DomainType] = true
+		if !step.Success {
+			continue
+		}
+		answered[step.DomainType] = true
+
+		records := countRecords(step.Output)
+		if slices.Contains(AlertDomainTypes, step.DomainType) {
+			alerts += records
+		} else if records > 0 {
+			corroborating[step.DomainType] = true
+		}
+	}
+
+	confidence := PipelineConfidence{
+		CorroboratingSources: len(corroborating),
+		AlertMatches:         alerts,
+	}
+	if len(searched) > 0 {
+		confidence.SourceCoverage = float64(len(answered)) / float64(len(searched))
+	}
+
+	total := weights.Corroboration + weights.Alerts + weights.Coverage
+	if total <= 0 {
+		return confidence
+	}
+	confidence.OverallConfidence = (weights.Corroboration*saturate(confidence.CorroboratingSources) +
+		weights.Alerts*saturate(confidence.AlertMatches) +
+		weights.Coverage*confidence.SourceCoverage) / total
+
+	return confidence
+}
+
+// saturate maps a count to [0, 1), reaching one half at two
+func saturate(count int) float64 {
+	return float64(count) / float64(count+2)
+}
+
+// countRecords returns the number of records in a step output
+func countRecords(output any) int {
+	value := reflect.ValueOf(output)
+	if value.Kind() != reflect.Slice {
+		return 0
+	}
+	return value.Len()
+}
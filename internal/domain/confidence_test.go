@@ -0,0 +1,60 @@
+package domain
+
+import "testing"
+
+func confidenceSteps(sources []DomainType, alerts int) []DynamicPipelineStep {
+	steps := []DynamicPipelineStep{}
+	for _, domainType := range sources {
+		steps = append(steps, DynamicPipelineStep{DomainType: domainType, Success: true, Output: []Entity{{Texto: "NOVASCO"}}})
+	}
+	steps = append(steps, DynamicPipelineStep{DomainType: DomainTypeSanctions, Success: true, Output: make([]SanctionMatch, alerts)})
+	return steps
+}
+
+func TestScoreConfidenceIncreasesWithCorroboration(t *testing.T) {
+	previous := -1.0
+	sources := []DomainType{DomainTypeONAPI, DomainTypeDGII, DomainTypeSCJ, DomainTypeCamara}
+	for n := 0; n <= len(sources); n++ {
+		confidence := ScoreConfidence(confidenceSteps(sources[:n], 0), ConfidenceWeights{})
+		if confidence.CorroboratingSources != n {
+			t.Errorf("expected %d corroborating sources, got %d", n, confidence.CorroboratingSources)
+		}
+		if confidence.OverallConfidence <= previous {
+			t.Errorf("expected %d sources to score above %f, got %f", n, previous, confidence.OverallConfidence)
+		}
+		previous = confidence.OverallConfidence
+	}
+}
+
+func TestScoreConfidenceIncreasesWithAlertMatches(t *testing.T) {
+	previous := -1.0
+	for alerts := 0; alerts <= 4; alerts++ {
+		confidence := ScoreConfidence(confidenceSteps([]DomainType{DomainTypeONAPI}, alerts), ConfidenceWeights{})
+		if confidence.AlertMatches != alerts {
+			t.Errorf("expected %d alert matches, got %d", alerts, confidence.AlertMatches)
+		}
+		if confidence.OverallConfidence <= previous {
+			t.Errorf("expected %d alerts to score above %f, got %f", alerts, previous, confidence.OverallConfidence)
+		}
+		if confidence.OverallConfidence >= 1 {
+			t.Errorf("expected the score to stay below 1, got %f", confidence.OverallConfidence)
+		}
+		previous = confidence.OverallConfidence
+	}
+}
+
+func TestScoreConfidenceWeightsAndCoverage(t *testing.T) {
+	steps := append(confidenceSteps([]DomainType{DomainTypeONAPI}, 0),
+		DynamicPipelineStep{DomainType: DomainTypeDGII, Success: false},
+		DynamicPipelineStep{DomainType: DomainTypeSCJ, SkipReason: SkipReasonSourceUnavailable},
+		DynamicPipelineStep{DomainType: "SUMMARY", Success: true},
+	)
+
+	confidence := ScoreConfidence(steps, ConfidenceWeights{Coverage: 1})
+	if confidence.SourceCoverage != 2.0/3 {
+		t.Errorf("expected 2 of 3 searched domains to have answered, got %f", confidence.SourceCoverage)
+	}
+	if confidence.OverallConfidence != confidence.SourceCoverage {
+		t.Errorf("expected a coverage-only weighting to score the coverage, got %f", confidence.OverallConfidence)
+	}
+}
@@ -1,6 +1,10 @@
 package domain
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 type KeywordCategory string
 
@@ -24,7 +28,23 @@ type DomainConnector[T any] interface {
 	GetSearchableKeywordCategories() []KeywordCategory
 	GetFoundKeywordCategories() []KeywordCategory
 	GetDomainType() DomainType
-	Search(query string) ([]T, error)
+	Search(ctx context.Context, query string) ([]T, error)
+}
+
+// PersistenceFilter is implemented by connectors deciding themselves which of
+// their records are worth storing
+type PersistenceFilter[T any] interface {
+	ShouldPersist(data T) bool
+}
+
+// ShouldPersist reports whether a record found by a connector should be
+// stored: by the ShouldPersist of the connector when it has one, or else when
+// the record passes its ValidateData
+func ShouldPersist[T any](connector DomainConnector[T], data T) bool {
+	if filter, ok := connector.(PersistenceFilter[T]); ok {
+		return filter.ShouldPersist(data)
+	}
+	return connector.ValidateData(data) == nil
 }
 
 // Extended interface that includes domain connector methods
@@ -50,21 +70,75 @@ func ProcessBatchDomainData[T any](connector DomainConnector[T], data []T) ([]T,
 	return results, nil
 }
 
-// GetCategoryByKeywords extracts keywords by category from domain data using a connector
+// GetCategoryByKeywords extracts keywords by category from domain data using a connector.
+// Company names are canonicalized, so each company is a single keyword whatever
+// the form of its name in the data, and a keyword repeated across the data is
+// kept once.
 func GetCategoryByKeywords[T any](connector DomainConnector[T], data []T) map[KeywordCategory][]string {
-	result := map[KeywordCategory][]string{}
+	result := NewKeywordAggregator()
 
 	for _, d := range data {
 		for _, rcv := range connector.GetFoundKeywordCategories() {
-			if result[rcv] == nil {
-				result[rcv] = []string{}
+			keywords := connector.GetDataByCategory(d, rcv)
+			if rcv == KeywordCategoryCompanyName {
+				canonical := make([]string, 0, len(keywords))
+				for _, keyword := range keywords {
+					canonical = append(canonical, CanonicalizeCompanyName(keyword))
+				}
+				keywords = canonical
 			}
+			result.Add(rcv, keywords...)
+		}
+	}
+
+	return result.KeywordsPerCategory()
+}
+
+// KeywordAggregator gathers keywords per category, keeping each keyword once
+// per category, compared case-insensitively once trimmed, in the order first
+// seen
+type KeywordAggregator struct {
+	keywords map[KeywordCategory][]string
+	seen     map[KeywordCategory]map[string]bool
+}
+
+// NewKeywordAggregator creates an empty aggregator
+func NewKeywordAggregator() *KeywordAggregator {
+	return &KeywordAggregator{
+		keywords: map[KeywordCategory][]string{},
+		seen:     map[KeywordCategory]map[string]bool{},
+	}
+}
 
-			result[rcv] = append(result[rcv], connector.GetDataByCategory(d, rcv)...)
+// Add adds the keywords of a category not added yet. The category is kept even
+// when none of its keywords is.
+func (a *KeywordAggregator) Add(category KeywordCategory, keywords ...string) {
+	if a.keywords[category] == nil {
+		a.keywords[category] = []string{}
+		a.seen[category] = map[string]bool{}
+	}
+
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		key := strings.ToLower(keyword)
+		if keyword == "" || a.seen[category][key] {
+			continue
 		}
+		a.seen[category][key] = true
+		a.keywords[category] = append(a.keywords[category], keyword)
+	}
+}
+
+// AddAll adds the keywords of every category
+func (a *KeywordAggregator) AddAll(keywordsPerCategory map[KeywordCategory][]string) {
+	for category, keywords := range keywordsPerCategory {
+		a.Add(category, keywords...)
 	}
+}
 
-	return result
+// KeywordsPerCategory returns the keywords added per category
+func (a *KeywordAggregator) KeywordsPerCategory() map[KeywordCategory][]string {
+	return a.keywords
 }
 
 // DomainType represents the different domain types available
@@ -76,10 +150,18 @@ const (
 	DomainTypeSCJ           DomainType = "SCJ"
 	DomainTypeDGII          DomainType = "DGII"
 	DomainTypePGR           DomainType = "PGR"
+	DomainTypeCamara        DomainType = "CAMARA"
+	DomainTypeJCE           DomainType = "JCE"
+	DomainTypeSanctions     DomainType = "SANCTIONS"
+	DomainTypeSIB           DomainType = "SIB"
 	DomainTypeGoogleDorking DomainType = "GOOGLE_DOCKING"
 	DomainTypeSocialMedia   DomainType = "SOCIAL_MEDIA"
 	DomainTypeXSocialMedia  DomainType = "X_SOCIAL_MEDIA"
 	DomainTypeFileType      DomainType = "FILE_TYPE"
+	// DomainTypeManual tags the findings entered by an analyst rather than
+	// scraped from a source. It is not searchable, so it is left out of
+	// AllDomainTypes.
+	DomainTypeManual DomainType = "MANUAL"
 )
 
 // AllDomainTypes returns a list of all available domain types (excluding ERROR)
@@ -89,6 +171,10 @@ func AllDomainTypes() []DomainType {
 		DomainTypeSCJ,
 		DomainTypeDGII,
 		DomainTypePGR,
+		DomainTypeCamara,
+		DomainTypeJCE,
+		DomainTypeSanctions,
+		DomainTypeSIB,
 		DomainTypeGoogleDorking,
 		DomainTypeSocialMedia,
 		DomainTypeXSocialMedia,
@@ -105,12 +191,25 @@ func DefaultDomainTypes() []DomainType {
 	}
 }
 
+// AuthoritativeDomainTypes returns the official registries whose records are
+// trusted to prove that an entity exists
+func AuthoritativeDomainTypes() []DomainType {
+	return []DomainType{
+		DomainTypeONAPI,
+		DomainTypeDGII,
+	}
+}
+
 // StringToDomainType maps string identifiers (typically from URL parameters) to DomainType
 var StringToDomainType = map[string]DomainType{
 	"onapi":          DomainTypeONAPI,
 	"scj":            DomainTypeSCJ,
 	"dgii":           DomainTypeDGII,
 	"pgr":            DomainTypePGR,
+	"camara":         DomainTypeCamara,
+	"jce":            DomainTypeJCE,
+	"sanctions":      DomainTypeSanctions,
+	"sib":            DomainTypeSIB,
 	"docking":        DomainTypeGoogleDorking,
 	"social_media":   DomainTypeSocialMedia,
 	"x_social_media": DomainTypeXSocialMedia,
@@ -123,6 +222,10 @@ var DomainTypeToString = map[DomainType]string{
 	DomainTypeSCJ:           "scj",
 	DomainTypeDGII:          "dgii",
 	DomainTypePGR:           "pgr",
+	DomainTypeCamara:        "camara",
+	DomainTypeJCE:           "jce",
+	DomainTypeSanctions:     "sanctions",
+	DomainTypeSIB:           "sib",
 	DomainTypeGoogleDorking: "docking",
 	DomainTypeSocialMedia:   "social_media",
 	DomainTypeXSocialMedia:  "x_social_media",
@@ -147,9 +250,19 @@ func IsValidDomainType(dt DomainType) bool {
 	return false
 }
 
+// Bounds of the attempts of a domain search failing on transient errors
+const (
+	DefaultSearchAttempts = 2
+	MaxSearchAttempts     = 5
+)
+
 // DomainSearchParams holds the search parameters for different domains
 type DomainSearchParams struct {
 	Query string
+	// Attempts is how many times a search failing on a network error or a
+	// 429/5xx answer is made, DefaultSearchAttempts when zero and at most
+	// MaxSearchAttempts
+	Attempts int
 }
 
 // DomainSearchResult represents the result of a domain search
@@ -162,4 +275,6 @@ type DomainSearchResult struct {
 	Output              any                          `json:"output"`
 	Success             bool                         `json:"success"`
 	Error               error                        `json:"error"`
+	// Attempts is how many times the search was made
+	Attempts int `json:"attempts"`
 }
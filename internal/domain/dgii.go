@@ -1,6 +1,18 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRNC is returned for contributor IDs that are not a well-formed RNC
+// or cédula
+var ErrInvalidRNC = errors.New("invalid RNC")
+
+// rncWeights are the weights DGII applies to the first eight digits of an RNC
+var rncWeights = [8]int{7, 9, 8, 6, 5, 4, 3, 2}
 
 type Register struct {
 	ID                    ID        `json:"id"`
@@ -15,4 +27,71 @@ type Register struct {
 	Estado                string    `json:"estado"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
+	Freshness
+}
+
+// NormalizeRNC strips the spaces and dashes an RNC or cédula is written with
+func NormalizeRNC(rnc string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(rnc))
+}
+
+// ValidateRNC checks that rnc, ignoring dashes, is a 9-digit RNC or an 11-digit
+// cédula whose check digit matches the one DGII computes. The returned error
+// wraps ErrInvalidRNC.
+func ValidateRNC(rnc string) error {
+	digits := NormalizeRNC(rnc)
+	if len(digits) != 9 && len(digits) != 11 {
+		return fmt.Errorf("%w %q: expected 9 or 11 digits, got %d characters", ErrInvalidRNC, rnc, len(digits))
+	}
+
+	values := make([]int, len(digits))
+	for i, r := range digits {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("%w %q: %q is not a digit", ErrInvalidRNC, rnc, r)
+		}
+		values[i] = int(r - '0')
+	}
+
+	var check int
+	if len(values) == 9 {
+		check = rncCheckDigit(values[:8])
+	} else {
+		check = cedulaCheckDigit(values[:10])
+	}
+	if values[len(values)-1] != check {
+		return fmt.Errorf("%w %q: check digit should be %d", ErrInvalidRNC, rnc, check)
+	}
+	return nil
+}
+
+// rncCheckDigit computes the modulo 11 check digit of an RNC
+func rncCheckDigit(digits []int) int {
+	sum := 0
+	for i, digit := range digits {
+		sum += digit * rncWeights[i]
+	}
+
+	switch remainder := sum % 11; remainder {
+	case 0:
+		return 2
+	case 1:
+		return 1
+	default:
+		return 11 - remainder
+	}
+}
+
+// cedulaCheckDigit computes the Luhn check digit of a cédula
+func cedulaCheckDigit(digits []int) int {
+	sum := 0
+	for i, digit := range digits {
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return (10 - sum%10) % 10
 }
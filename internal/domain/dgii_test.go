@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRNC(t *testing.T) {
+	for _, rnc := range []string{
+		"401506254",
+		"4-01-50625-4",
+		"130000001",
+		" 001-1234567-3 ",
+		"40212345678",
+	} {
+		if err := ValidateRNC(rnc); err != nil {
+			t.Errorf("ValidateRNC(%q) returned error: %v", rnc, err)
+		}
+	}
+}
+
+func TestValidateRNCRejectsMalformedIDs(t *testing.T) {
+	for name, rnc := range map[string]string{
+		"empty":              "",
+		"too short":          "40150625",
+		"ten digits":         "4015062541",
+		"letters":            "40150625A",
+		"wrong rnc digit":    "401506255",
+		"wrong cedula digit": "00112345678",
+	} {
+		err := ValidateRNC(rnc)
+		if err == nil {
+			t.Errorf("%s: expected ValidateRNC(%q) to fail", name, rnc)
+			continue
+		}
+		if !errors.Is(err, ErrInvalidRNC) {
+			t.Errorf("%s: expected ErrInvalidRNC, got %v", name, err)
+		}
+	}
+}
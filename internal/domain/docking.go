@@ -1,43 +1,6 @@
 package domain
 
-import (
-	"insightful-intel/internal/custom"
-	"time"
-)
-
-var FRAUD_KEYWORDS = []string{
-	"fraude",
-	"estafa",
-	"denuncia",
-	"engaño",
-	"irregular",
-	"contrato",
-	"pagos",
-	"retrasos",
-	"robo",
-	"acusa",
-	"acusacion",
-	"acusado",
-	"fraude inmobiliaria",
-	"fraude inmobiliaria en republica dominicana",
-	"terrenos irregulares",
-	"incumplimiento de contrato",
-}
-
-var SOCIAL_MEDIA_SITES_KEYWORDS = []string{
-	"facebook.com",
-	"twitter.com",
-	"instagram.com",
-	"linkedin.com",
-	"youtube.com",
-	"tiktok.com",
-	"linkedin.com/company",
-}
-
-var X_IN_URL_KEYWORDS = []string{
-	"status", // finds specific posts, threads, or tweet IDs
-	"lists",  //  finds public user lists
-}
+import "time"
 
 var ADDRESS_KEYWORDS = []string{
 	"direccion",
@@ -54,44 +17,54 @@ var ENTITY_NAME_KEYWORDS = []string{
 	"nombre y apellido",
 }
 
-var FILE_TYPE_KEYWORDS = []string{
-	"pdf",
-	"doc",
-	"docx",
-	"xls",
-	"xlsx",
-	"ppt",
-	"pptx",
-}
-
-// GoogleDorking represents a Google Docking string search connector
-type GoogleDorking struct {
-	Stuff    custom.Client
-	BasePath string
-	PathMap  custom.CustomPathMap
-}
+// DuckDuckGoHTMLURL is the DuckDuckGo HTML endpoint docking searches fall back
+// to without Google Custom Search
+const DuckDuckGoHTMLURL = "https://html.duckduckgo.com/html/"
 
-// NewGoogleDorkingDomain creates a new Google Docking domain instance
-func NewGoogleDorkingDomain() GoogleDorking {
-	return GoogleDorking{
-		BasePath: "https://html.duckduckgo.com/html/",
-		Stuff:    *custom.NewClient(),
-	}
-}
+// Docking search providers
+const (
+	DorkingProviderGoogle     = "google"
+	DorkingProviderDuckDuckGo = "duckduckgo"
+)
 
 // GoogleDorkingResult represents a search result from Google Docking
 type GoogleDorkingResult struct {
 	ID                   ID        `json:"id"`
 	DomainSearchResultID ID        `json:"domainSearchResultId"`
-	SearchParameter      string    `json:"searchParameter" omitempty`
+	SearchParameter      string    `json:"searchParameter,omitempty"`
 	URL                  string    `json:"link"`
 	Title                string    `json:"title"`
 	Description          string    `json:"snippet"`
-	Relevance            float64   `json:"relevance" omitempty`
-	Rank                 int       `json:"rank" omitempty`
-	Keywords             []string  `json:"keywords" omitempty`
-	CreatedAt            time.Time `json:"createdAt" omitempty`
-	UpdatedAt            time.Time `json:"updatedAt" omitempty`
+	Relevance            float64   `json:"relevance,omitempty"`
+	Rank                 int       `json:"rank,omitempty"`
+	Keywords             []string  `json:"keywords,omitempty"`
+	Provider             string    `json:"provider,omitempty"`
+	CreatedAt            time.Time `json:"createdAt,omitempty"`
+	UpdatedAt            time.Time `json:"updatedAt,omitempty"`
+	// Explanation breaks the relevance down into its components, set when the
+	// search asks for it
+	Explanation *RelevanceBreakdown `json:"explanation,omitempty"`
+	Freshness
+}
+
+// RelevanceBreakdown explains the relevance of a docking result. The text
+// score is the sum of the exact match, contains, position, frequency and fuzzy
+// components of the best matching field; the relevance blends it with the
+// keyword match when the result has keywords, capped at 1.
+type RelevanceBreakdown struct {
+	// MatchedField is the field the text score comes from, title or description
+	MatchedField    string  `json:"matchedField,omitempty"`
+	ExactMatchBonus float64 `json:"exactMatchBonus"`
+	ContainsMatch   float64 `json:"containsMatch"`
+	PositionBonus   float64 `json:"positionBonus"`
+	FrequencyBonus  float64 `json:"frequencyBonus"`
+	FuzzySimilarity float64 `json:"fuzzySimilarity"`
+	TextScore       float64 `json:"textScore"`
+	// KeywordMatch is the keyword score and KeywordWeight its share of the
+	// relevance
+	KeywordMatch  float64 `json:"keywordMatch"`
+	KeywordWeight float64 `json:"keywordWeight"`
+	Relevance     float64 `json:"relevance"`
 }
 
 // GoogleDorkingSearchParams holds parameters for Google Docking search
@@ -106,4 +79,8 @@ type GoogleDorkingSearchParams struct {
 	InURLKeywords    []string `json:"in_url_keywords"`
 	ExcludeKeywords  []string `json:"exclude_keywords"`
 	SitesKeywords    []string `json:"sites_keywords"`
+	// Explain attaches the relevance breakdown to each result
+	Explain bool `json:"explain"`
+	// Pages is how many result pages the provider is asked for, one when zero
+	Pages int `json:"pages"`
 }
@@ -1,17 +1,150 @@
 package domain
 
-import "time"
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
 
 // DynamicPipelineConfig holds configuration for the dynamic pipeline
 type DynamicPipelineConfig struct {
-	Query              string       `json:"query"`
-	MaxDepth           int          `json:"max_depth"`
-	MaxConcurrentSteps int          `json:"max_concurrent_steps"`
-	DelayBetweenSteps  int          `json:"delay_between_steps"`
-	SkipDuplicates     bool         `json:"skip_duplicates"`
-	AvailableDomains   []DomainType `json:"available_domains"`
+	Query              string `json:"query"`
+	MaxDepth           int    `json:"max_depth"`
+	MaxConcurrentSteps int    `json:"max_concurrent_steps"`
+	// DelayBetweenSteps is the pause after each step completes, read from
+	// JSON as seconds or a duration string. DelayJitter stretches each pause
+	// by a random fraction of up to DelayJitter times the delay, so concurrent
+	// steps do not fire in bursts; zero keeps the pauses fixed.
+	DelayBetweenSteps Seconds      `json:"delay_between_steps"`
+	DelayJitter       float64      `json:"delay_jitter,omitempty"`
+	SkipDuplicates    bool         `json:"skip_duplicates"`
+	AvailableDomains  []DomainType `json:"available_domains"`
+	// StopOnFirstHit turns the run into an existence check: authoritative
+	// domains are searched concurrently and the run ends on the first strong hit.
+	StopOnFirstHit bool `json:"stop_on_first_hit"`
+	// MaxRequests and MaxBytes cap the outbound network usage of the run. No
+	// new step is dispatched once either is reached; zero means unlimited.
+	MaxRequests int64 `json:"max_requests"`
+	MaxBytes    int64 `json:"max_bytes"`
+	// MaxTotalSteps and MaxDuration bound the work of the run, which fans out
+	// at every depth: no new step is dispatched once MaxTotalSteps searches
+	// were dispatched or MaxDuration has passed since the run started. The
+	// steps already running complete. Zero means unlimited.
+	MaxTotalSteps int           `json:"max_total_steps,omitempty"`
+	MaxDuration   time.Duration `json:"max_duration,omitempty"`
+	// SearchAttempts is how many times the search of a step is made when it
+	// fails on a transient error, DefaultSearchAttempts when zero
+	SearchAttempts int `json:"search_attempts,omitempty"`
+	// CanonicalizeCompanies merges the DGII and ONAPI names of the same company
+	// so each company is searched once per domain rather than once per alias.
+	// Names match at CompanySimilarity or above (DefaultCompanySimilarity when zero).
+	CanonicalizeCompanies bool    `json:"canonicalize_companies"`
+	CompanySimilarity     float64 `json:"company_similarity"`
+	// SkipUnavailableSources records the steps of sources marked down by the
+	// source health state as skipped instead of searching them
+	SkipUnavailableSources bool `json:"skip_unavailable_sources"`
+	// RateLimits overrides the outbound request rate of each domain. Domains
+	// missing from the map keep their current limit.
+	RateLimits map[DomainType]RateLimit `json:"rate_limits,omitempty"`
+	// SessionID links the pipeline to the investigation session it belongs to
+	SessionID string `json:"session_id,omitempty"`
+	// SampleRate, when between 0 and 1, keeps only that fraction of the steps
+	// generated from the keywords found. The choice is drawn from a generator
+	// seeded with Seed and the step itself, so the same seed explores the same
+	// subset whatever the order the steps complete in.
+	Seed       int64   `json:"seed,omitempty"`
+	SampleRate float64 `json:"sample_rate,omitempty"`
+	// MaxFanoutMultiplier caps the steps a single step may queue at that
+	// multiple of the steps already queued, sampling the ones kept and
+	// recording a fanout_capped notice. Zero leaves the fan-out unbounded.
+	MaxFanoutMultiplier float64 `json:"max_fanout_multiplier,omitempty"`
+	// ConfidenceWeights weighs the signals of the overall confidence of the
+	// result, DefaultConfidenceWeights when zero
+	ConfidenceWeights ConfidenceWeights `json:"confidence_weights,omitzero"`
+	// TraversalMode picks the next step to run: TraversalBFS runs the steps
+	// level by level, TraversalDFS expands the steps found by a step before
+	// its siblings. Empty or unknown modes run breadth-first.
+	TraversalMode TraversalMode `json:"traversal_mode,omitempty"`
+	// CategoryPriority ranks the steps searching the keywords of each category:
+	// among the steps at the same depth, higher priorities run first. Categories
+	// missing from the map keep their DefaultCategoryPriority.
+	CategoryPriority map[KeywordCategory]int `json:"category_priority,omitempty"`
+	// MaxQueuedSteps caps the steps queued in memory, DefaultMaxQueuedSteps when
+	// zero. The steps past the cap are spilled to the database and queued again
+	// as the queue drains.
+	MaxQueuedSteps int `json:"max_queued_steps,omitempty"`
+	// RecordEvents stores the lifecycle events of the run, from its start to
+	// its end, in the audit log of the pipeline
+	RecordEvents bool `json:"record_events,omitempty"`
+	// AdaptiveDepth tunes the depth of each branch to its yield. A branch whose
+	// last AdaptiveDepthWindow steps found no new entity stops before MaxDepth,
+	// and a branch still finding new entities goes up to AdaptiveDepthExtension
+	// levels past it. Zero window and extension use their defaults.
+	AdaptiveDepth          bool `json:"adaptive_depth,omitempty"`
+	AdaptiveDepthWindow    int  `json:"adaptive_depth_window,omitempty"`
+	AdaptiveDepthExtension int  `json:"adaptive_depth_extension,omitempty"`
+	// DryRun plans the steps of the run without searching any source: the
+	// initial steps, and the steps the cached results of their searches would
+	// lead to, are returned unexecuted and nothing is stored
+	DryRun bool `json:"dry_run,omitempty"`
 }
 
+// TraversalMode is the order a pipeline runs its steps in
+type TraversalMode string
+
+const (
+	TraversalBFS TraversalMode = "bfs"
+	TraversalDFS TraversalMode = "dfs"
+)
+
+// DepthFirst reports whether the pipeline expands each lead fully before the
+// next one
+func (c DynamicPipelineConfig) DepthFirst() bool {
+	return c.TraversalMode == TraversalDFS
+}
+
+// StepDelay returns the pause after a step, drawn within
+// [DelayBetweenSteps, DelayBetweenSteps*(1+DelayJitter)]
+func (c DynamicPipelineConfig) StepDelay() time.Duration {
+	delay := c.DelayBetweenSteps.Duration()
+	if c.DelayJitter <= 0 || delay <= 0 {
+		return delay
+	}
+	return delay + time.Duration(rand.Float64()*c.DelayJitter*float64(delay))
+}
+
+// SampleStep reports whether the step searching keyword in domainType is kept
+// by the sampling of the configuration. Every step is kept when SampleRate is
+// not between 0 and 1.
+func (c DynamicPipelineConfig) SampleStep(domainType DomainType, keyword string) bool {
+	if c.SampleRate <= 0 || c.SampleRate >= 1 {
+		return true
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(string(domainType) + "|" + NormalizeKeyword(keyword)))
+	rng := rand.New(rand.NewPCG(uint64(c.Seed), hash.Sum64()))
+
+	return rng.Float64() < c.SampleRate
+}
+
+// RateLimit is the token bucket applied to the requests sent to a domain
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// Reasons a pipeline stopped dispatching steps before running out of them
+const (
+	// StopReasonBudgetExhausted marks a pipeline whose outbound budget was spent
+	StopReasonBudgetExhausted = "budget_exhausted"
+	// StopReasonStepLimit marks a pipeline that dispatched MaxTotalSteps steps
+	StopReasonStepLimit = "step_limit_reached"
+	// StopReasonTimeLimit marks a pipeline that ran for MaxDuration
+	StopReasonTimeLimit = "time_limit_reached"
+)
+
 // DynamicPipelineStep represents a single step in the pipeline
 type DynamicPipelineStep struct {
 	ID                  ID                           `json:"id"`
@@ -25,8 +158,20 @@ type DynamicPipelineStep struct {
 	Output              any                          `json:"output"`
 	KeywordsPerCategory map[KeywordCategory][]string `json:"keywords_per_category"`
 	Depth               int                          `json:"depth"`
+	// SkipReason is set on steps that were recorded without being searched
+	SkipReason string `json:"skip_reason,omitempty"`
+	// Priority orders the step among the queued steps at its depth, set from
+	// its category when it is queued
+	Priority int `json:"priority,omitempty"`
+	// ParentStepID is the step that found the keyword this step searches, zero
+	// for the initial steps
+	ParentStepID ID `json:"parent_step_id,omitzero"`
 }
 
+// SkipReasonSourceUnavailable marks a step skipped because its source was
+// known to be down when the step was dispatched
+const SkipReasonSourceUnavailable = "source_unavailable"
+
 // DynamicPipelineResult represents the complete pipeline result
 type DynamicPipelineResult struct {
 	ID              ID                    `json:"id"`
@@ -36,6 +181,51 @@ type DynamicPipelineResult struct {
 	TotalSteps      int                   `json:"total_steps"`
 	SuccessfulSteps int                   `json:"successful_steps"`
 	FailedSteps     int                   `json:"failed_steps"`
+	SkippedSteps    int                   `json:"skipped_steps"`
 	MaxDepthReached int                   `json:"max_depth_reached"`
 	Config          DynamicPipelineConfig `json:"config"`
+	StopReason      string                `json:"stop_reason,omitempty"`
+	Companies       []CanonicalCompany    `json:"companies,omitempty"`
+	RequestsUsed    int64                 `json:"requests_used"`
+	BytesUsed       int64                 `json:"bytes_used"`
+	// VisitedKeywords counts the distinct keywords searched per domain
+	VisitedKeywords int `json:"visited_keywords"`
+	// SkippedRecords counts the records found but not stored, as their
+	// connector rejected them
+	SkippedRecords int `json:"skipped_records"`
+	// SessionID is the investigation session the pipeline belongs to, if any
+	SessionID string `json:"session_id,omitempty"`
+	// Notices lists what the pipeline did on its own to stay within bounds
+	Notices []PipelineNotice `json:"notices,omitempty"`
+	// Confidence aggregates the corroboration, alerts and source coverage of
+	// the steps into a headline signal
+	Confidence PipelineConfidence `json:"confidence"`
+}
+
+// BudgetLimited reports whether the pipeline stopped on one of its limits
+// rather than running out of steps
+func (r *DynamicPipelineResult) BudgetLimited() bool {
+	switch r.StopReason {
+	case StopReasonBudgetExhausted, StopReasonStepLimit, StopReasonTimeLimit:
+		return true
+	}
+	return false
+}
+
+// VisitedKeyword identifies a keyword searched in a domain. Pipelines never
+// search the same visited keyword twice, whatever the depth it surfaces at, so
+// companies whose keywords point at each other do not loop.
+type VisitedKeyword struct {
+	DomainType DomainType
+	Keyword    string
+}
+
+// NewVisitedKeyword creates the visited key of a keyword searched in a domain
+func NewVisitedKeyword(domainType DomainType, keyword string) VisitedKeyword {
+	return VisitedKeyword{DomainType: domainType, Keyword: NormalizeKeyword(keyword)}
+}
+
+// NormalizeKeyword trims and lowercases a keyword and collapses its whitespace
+func NormalizeKeyword(keyword string) string {
+	return strings.ToLower(strings.Join(strings.Fields(keyword), " "))
 }
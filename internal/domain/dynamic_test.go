@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepDelayStaysWithinJitterWindow(t *testing.T) {
+	config := DynamicPipelineConfig{DelayBetweenSteps: Seconds(100 * time.Millisecond)}
+	if delay := config.StepDelay(); delay != 100*time.Millisecond {
+		t.Errorf("expected the fixed delay without jitter, got %s", delay)
+	}
+
+	config.DelayJitter = 0.5
+	seen := map[time.Duration]bool{}
+	for range 1000 {
+		delay := config.StepDelay()
+		if delay < 100*time.Millisecond || delay > 150*time.Millisecond {
+			t.Fatalf("expected a delay within [100ms, 150ms], got %s", delay)
+		}
+		seen[delay] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected the jitter to vary the delays, got %v", seen)
+	}
+
+	// Without a delay there is nothing to stretch
+	config.DelayBetweenSteps = 0
+	if delay := config.StepDelay(); delay != 0 {
+		t.Errorf("expected no delay, got %s", delay)
+	}
+}
@@ -0,0 +1,42 @@
+package domain
+
+// EntityReference is the provenance of an alias of a resolved entity: the step
+// whose records named the entity by the alias
+type EntityReference struct {
+	Alias           string     `json:"alias"`
+	DomainType      DomainType `json:"domain_type"`
+	StepID          ID         `json:"step_id"`
+	SearchParameter string     `json:"search_parameter"`
+	// Manual tells the aliases an analyst entered from the scraped ones
+	Manual bool `json:"manual"`
+}
+
+// EntityCluster is a resolved entity with its aliases and the references they
+// were taken from, as exported for review outside the pipeline
+type EntityCluster struct {
+	Name    string       `json:"name"`
+	RNC     string       `json:"rnc,omitempty"`
+	Aliases []string     `json:"aliases"`
+	Sources []DomainType `json:"sources"`
+	// Confidence grows with the number of sources naming the entity, from 0 to
+	// 1, reaching one half at two sources
+	Confidence float64           `json:"confidence"`
+	References []EntityReference `json:"references"`
+}
+
+// Clusters returns the canonical companies found so far with the references of
+// the steps added with AddStep
+func (c *CompanyCanonicalizer) Clusters() []EntityCluster {
+	clusters := make([]EntityCluster, 0, len(c.companies))
+	for _, company := range c.companies {
+		clusters = append(clusters, EntityCluster{
+			Name:       company.Name,
+			RNC:        company.RNC,
+			Aliases:    company.Aliases,
+			Sources:    company.Sources,
+			Confidence: saturate(len(company.Sources)),
+			References: c.references[company],
+		})
+	}
+	return clusters
+}
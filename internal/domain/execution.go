@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// ExecutionStatus is the state of a pipeline execution run in the background
+type ExecutionStatus string
+
+const (
+	ExecutionStatusProcessing ExecutionStatus = "processing"
+	ExecutionStatusCompleted  ExecutionStatus = "completed"
+	ExecutionStatusFailed     ExecutionStatus = "failed"
+)
+
+// ExecutionState is the progress of a pipeline execution, as tracked while it
+// runs
+type ExecutionState struct {
+	ExecutionID     string          `json:"execution_id"`
+	Status          ExecutionStatus `json:"status"`
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      time.Time       `json:"finished_at,omitzero"`
+	TotalSteps      int             `json:"total_steps"`
+	SuccessfulSteps int             `json:"successful_steps"`
+	FailedSteps     int             `json:"failed_steps"`
+	SkippedSteps    int             `json:"skipped_steps"`
+	LastError       string          `json:"last_error,omitempty"`
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// DefaultMaxFanoutMultiplier is the fan-out multiplier applied to the pipelines
+// run with the default settings
+const DefaultMaxFanoutMultiplier = 10
+
+// NoticeFanoutCapped marks a step whose generated steps were capped because
+// they exceeded the fan-out limit of the pipeline
+const NoticeFanoutCapped = "fanout_capped"
+
+// PipelineNotice reports something the pipeline did on its own to keep the run
+// within bounds
+type PipelineNotice struct {
+	Type            string     `json:"type"`
+	StepID          ID         `json:"step_id"`
+	DomainType      DomainType `json:"domain_type"`
+	SearchParameter string     `json:"search_parameter"`
+	Depth           int        `json:"depth"`
+	// Projected is the number of steps the step generated and Kept the number
+	// that were queued
+	Projected int `json:"projected"`
+	Kept      int `json:"kept"`
+}
+
+// FanoutLimit returns the number of steps a single step may queue when queued
+// steps are waiting, or zero when the fan-out is not limited
+func (c DynamicPipelineConfig) FanoutLimit(queued int) int {
+	if c.MaxFanoutMultiplier <= 0 {
+		return 0
+	}
+	return max(int(math.Ceil(c.MaxFanoutMultiplier*float64(max(queued, 1)))), 1)
+}
+
+// CapFanout keeps limit of the steps, sampled with a generator seeded with
+// seed so the same steps are kept whatever order they were generated in. It
+// returns the kept and the dropped steps.
+func CapFanout(steps []DynamicPipelineStep, limit int, seed int64) ([]DynamicPipelineStep, []DynamicPipelineStep) {
+	if limit <= 0 || len(steps) <= limit {
+		return steps, nil
+	}
+
+	sorted := slices.Clone(steps)
+	slices.SortFunc(sorted, func(a, b DynamicPipelineStep) int {
+		return cmp.Or(
+			cmp.Compare(a.DomainType, b.DomainType),
+			cmp.Compare(a.Category, b.Category),
+			cmp.Compare(NormalizeKeyword(a.SearchParameter), NormalizeKeyword(b.SearchParameter)),
+		)
+	})
+
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(len(sorted))))
+	picked := rng.Perm(len(sorted))[:limit]
+	slices.Sort(picked)
+
+	kept := make([]DynamicPipelineStep, 0, limit)
+	dropped := make([]DynamicPipelineStep, 0, len(sorted)-limit)
+	next := 0
+	for i, step := range sorted {
+		if next < len(picked) && picked[next] == i {
+			kept = append(kept, step)
+			next++
+			continue
+		}
+		dropped = append(dropped, step)
+	}
+
+	return kept, dropped
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestCapFanoutIsOrderIndependent(t *testing.T) {
+	var steps []DynamicPipelineStep
+	for i := 0; i < 20; i++ {
+		steps = append(steps, DynamicPipelineStep{DomainType: DomainTypeONAPI, SearchParameter: fmt.Sprintf("empresa %02d", i)})
+	}
+	reversed := slices.Clone(steps)
+	slices.Reverse(reversed)
+
+	kept, dropped := CapFanout(steps, 5, 42)
+	keptReversed, _ := CapFanout(reversed, 5, 42)
+
+	if len(kept) != 5 || len(dropped) != 15 {
+		t.Fatalf("expected 5 kept and 15 dropped steps, got %d and %d", len(kept), len(dropped))
+	}
+	if !slices.EqualFunc(kept, keptReversed, func(a, b DynamicPipelineStep) bool { return a.SearchParameter == b.SearchParameter }) {
+		t.Errorf("expected the same steps kept whatever their order, got %v and %v", kept, keptReversed)
+	}
+
+	if kept, dropped := CapFanout(steps, 0, 42); len(kept) != len(steps) || dropped != nil {
+		t.Errorf("expected no cap without a limit, got %d kept", len(kept))
+	}
+}
+
+func TestFanoutLimit(t *testing.T) {
+	if limit := (DynamicPipelineConfig{}).FanoutLimit(10); limit != 0 {
+		t.Errorf("expected no limit without a multiplier, got %d", limit)
+	}
+	if limit := (DynamicPipelineConfig{MaxFanoutMultiplier: 2.5}).FanoutLimit(3); limit != 8 {
+		t.Errorf("expected a limit of 8, got %d", limit)
+	}
+	if limit := (DynamicPipelineConfig{MaxFanoutMultiplier: 2}).FanoutLimit(0); limit != 2 {
+		t.Errorf("expected an empty queue to count as one step, got %d", limit)
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// DefaultStaleAfter is the age past which a stored record is flagged stale
+const DefaultStaleAfter = 30 * 24 * time.Hour
+
+// Freshness tells how old the scrape behind a stored record is. The records
+// served by the API embed it, so API consumers see as_of and stale next to the
+// record's own fields. It is only filled in API responses, never stored.
+type Freshness struct {
+	AsOf  time.Time `json:"as_of,omitzero"`
+	Stale bool      `json:"stale,omitempty"`
+}
+
+// NewFreshness dates a record scraped at asOf, flagging it stale once it is
+// older than staleAfter. A record without a scrape date is never stale.
+func NewFreshness(asOf time.Time, staleAfter time.Duration, now time.Time) Freshness {
+	if asOf.IsZero() {
+		return Freshness{}
+	}
+	return Freshness{AsOf: asOf, Stale: staleAfter > 0 && now.Sub(asOf) > staleAfter}
+}
+
+// StoredRecord is a stored domain record whose freshness can be reported and
+// whose search can be run again to refresh it
+type StoredRecord interface {
+	ScrapedAt() time.Time
+	SearchResultID() ID
+	SetFreshness(Freshness)
+}
+
+var (
+	_ StoredRecord = &Entity{}
+	_ StoredRecord = &ScjCase{}
+	_ StoredRecord = &Register{}
+	_ StoredRecord = &PGRNews{}
+	_ StoredRecord = &GoogleDorkingResult{}
+)
+
+// SetFreshness sets the freshness reported with the record
+func (f *Freshness) SetFreshness(freshness Freshness) {
+	*f = freshness
+}
+
+// scrapedAt returns the last time a record was written
+func scrapedAt(createdAt, updatedAt time.Time) time.Time {
+	if updatedAt.After(createdAt) {
+		return updatedAt
+	}
+	return createdAt
+}
+
+func (e *Entity) ScrapedAt() time.Time { return scrapedAt(e.CreatedAt, e.UpdatedAt) }
+func (e *Entity) SearchResultID() ID   { return e.DomainSearchResultID }
+
+func (c *ScjCase) ScrapedAt() time.Time { return scrapedAt(c.CreatedAt, c.UpdatedAt) }
+func (c *ScjCase) SearchResultID() ID   { return c.DomainSearchResultID }
+
+func (r *Register) ScrapedAt() time.Time { return scrapedAt(r.CreatedAt, r.UpdatedAt) }
+func (r *Register) SearchResultID() ID   { return r.DomainSearchResultID }
+
+func (n *PGRNews) ScrapedAt() time.Time { return scrapedAt(n.CreatedAt, n.UpdatedAt) }
+func (n *PGRNews) SearchResultID() ID   { return n.DomainSearchResultID }
+
+func (g *GoogleDorkingResult) ScrapedAt() time.Time { return scrapedAt(g.CreatedAt, g.UpdatedAt) }
+func (g *GoogleDorkingResult) SearchResultID() ID   { return g.DomainSearchResultID }
@@ -0,0 +1,68 @@
+package domain
+
+// GraphNode is a keyword searched by the pipeline in a domain
+type GraphNode struct {
+	ID         string          `json:"id"`
+	Keyword    string          `json:"keyword"`
+	DomainType DomainType      `json:"domain_type"`
+	Category   KeywordCategory `json:"category"`
+	Depth      int             `json:"depth"`
+	Success    bool            `json:"success"`
+	SkipReason string          `json:"skip_reason,omitempty"`
+}
+
+// GraphEdge links the step that found a keyword to the step searching it
+type GraphEdge struct {
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Keyword  string          `json:"keyword"`
+	Category KeywordCategory `json:"category"`
+}
+
+// PipelineGraph is a pipeline result as the graph of the keywords it searched
+// and of the steps that led to them, for visualizing how entities connect
+type PipelineGraph struct {
+	PipelineID ID          `json:"pipeline_id"`
+	Nodes      []GraphNode `json:"nodes"`
+	Edges      []GraphEdge `json:"edges"`
+}
+
+// BuildGraph returns the graph of the steps of result. Each step is a node,
+// linked to the step that found its keyword; the initial steps and the steps
+// whose parent is not part of the result have no incoming edge.
+func BuildGraph(result *DynamicPipelineResult) PipelineGraph {
+	graph := PipelineGraph{
+		PipelineID: result.ID,
+		Nodes:      make([]GraphNode, 0, len(result.Steps)),
+		Edges:      make([]GraphEdge, 0, len(result.Steps)),
+	}
+
+	steps := make(map[ID]bool, len(result.Steps))
+	for _, step := range result.Steps {
+		steps[step.ID] = true
+	}
+
+	for _, step := range result.Steps {
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:         step.ID.String(),
+			Keyword:    step.SearchParameter,
+			DomainType: step.DomainType,
+			Category:   step.Category,
+			Depth:      step.Depth,
+			Success:    step.Success,
+			SkipReason: step.SkipReason,
+		})
+
+		if step.ParentStepID == (ID{}) || !steps[step.ParentStepID] {
+			continue
+		}
+		graph.Edges = append(graph.Edges, GraphEdge{
+			From:     step.ParentStepID.String(),
+			To:       step.ID.String(),
+			Keyword:  step.SearchParameter,
+			Category: step.Category,
+		})
+	}
+
+	return graph
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "testing"
+
+func TestBuildGraph(t *testing.T) {
+	onapi := DynamicPipelineStep{ID: NewID(), DomainType: DomainTypeONAPI, SearchParameter: "novasco", Category: KeywordCategoryCompanyName, Success: true}
+	dgii := DynamicPipelineStep{ID: NewID(), DomainType: DomainTypeDGII, SearchParameter: "novasco", Category: KeywordCategoryContributorID, Success: true}
+	person := DynamicPipelineStep{ID: NewID(), DomainType: DomainTypePGR, SearchParameter: "juan perez", Category: KeywordCategoryPersonName, Depth: 1, ParentStepID: onapi.ID}
+	rnc := DynamicPipelineStep{ID: NewID(), DomainType: DomainTypeSCJ, SearchParameter: "401506254", Category: KeywordCategoryContributorID, Depth: 1, ParentStepID: dgii.ID}
+	deeper := DynamicPipelineStep{ID: NewID(), DomainType: DomainTypeONAPI, SearchParameter: "novasco srl", Category: KeywordCategoryCompanyName, Depth: 2, ParentStepID: rnc.ID}
+	orphan := DynamicPipelineStep{ID: NewID(), DomainType: DomainTypeDGII, SearchParameter: "alpha", Category: KeywordCategoryCompanyName, Depth: 1, ParentStepID: NewID()}
+
+	result := &DynamicPipelineResult{ID: NewID(), Steps: []DynamicPipelineStep{onapi, dgii, person, rnc, deeper, orphan}}
+	graph := BuildGraph(result)
+
+	if graph.PipelineID != result.ID {
+		t.Errorf("expected the graph of pipeline %s, got %s", result.ID, graph.PipelineID)
+	}
+	if len(graph.Nodes) != 6 {
+		t.Errorf("expected a node per step, got %d", len(graph.Nodes))
+	}
+	if len(graph.Edges) != 3 {
+		t.Fatalf("expected 3 edges, got %d: %+v", len(graph.Edges), graph.Edges)
+	}
+
+	edge := graph.Edges[2]
+	if edge.From != rnc.ID.String() || edge.To != deeper.ID.String() || edge.Keyword != "novasco srl" || edge.Category != KeywordCategoryCompanyName {
+		t.Errorf("expected an edge from the contributor ID step to the step it led to, got %+v", edge)
+	}
+	if node := graph.Nodes[2]; node.Keyword != "juan perez" || node.DomainType != DomainTypePGR || node.Category != KeywordCategoryPersonName || node.Depth != 1 {
+		t.Errorf("unexpected node %+v", node)
+	}
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "strings"
+
+// JCEPerson is a citizen registered in the electoral roll (padrón) of the Junta
+// Central Electoral, identified by cédula
+type JCEPerson struct {
+	Cedula          string `json:"cedula"`
+	Nombres         string `json:"nombres"`
+	PrimerApellido  string `json:"primer_apellido"`
+	SegundoApellido string `json:"segundo_apellido"`
+}
+
+// FullName returns the canonical registered name: given names followed by
+// both surnames
+func (p JCEPerson) FullName() string {
+	return strings.Join(strings.Fields(strings.Join([]string{p.Nombres, p.PrimerApellido, p.SegundoApellido}, " ")), " ")
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// KeywordSets are the keywords docking searches build their dork queries from,
+// tunable per investigation
+type KeywordSets struct {
+	// Fraud are the terms docking and file type searches look for in the text
+	Fraud []string `json:"fraud"`
+	// SocialMediaSites are the sites social media searches are restricted to
+	SocialMediaSites []string `json:"social_media_sites"`
+	// FileTypes are the file types file type searches are restricted to
+	FileTypes []string `json:"file_types"`
+	// XInURL are the URL fragments of the X posts and lists X searches look for
+	XInURL []string `json:"x_in_url"`
+	// XSites are the sites X searches are restricted to
+	XSites []string `json:"x_sites"`
+}
+
+// DefaultKeywordSets returns the keyword sets docking searches use unless
+// configured otherwise
+func DefaultKeywordSets() KeywordSets {
+	return KeywordSets{
+		Fraud: []string{
+			"fraude",
+			"estafa",
+			"denuncia",
+			"engaño",
+			"irregular",
+			"contrato",
+			"pagos",
+			"retrasos",
+			"robo",
+			"acusa",
+			"acusacion",
+			"acusado",
+			"fraude inmobiliaria",
+			"fraude inmobiliaria en republica dominicana",
+			"terrenos irregulares",
+			"incumplimiento de contrato",
+		},
+		SocialMediaSites: []string{
+			"facebook.com",
+			"twitter.com",
+			"instagram.com",
+			"linkedin.com",
+			"youtube.com",
+			"tiktok.com",
+			"linkedin.com/company",
+		},
+		FileTypes: []string{
+			"pdf",
+			"doc",
+			"docx",
+			"xls",
+			"xlsx",
+			"ppt",
+			"pptx",
+		},
+		XInURL: []string{
+			"status", // finds specific posts, threads, or tweet IDs
+			"lists",  //  finds public user lists
+		},
+		XSites: []string{"x.com"},
+	}
+}
+
+// LoadKeywordSets parses JSON keyword sets. Sets the JSON leaves out or empty
+// keep their default keywords.
+func LoadKeywordSets(data []byte) (KeywordSets, error) {
+	var sets KeywordSets
+	if err := json.Unmarshal(data, &sets); err != nil {
+		return KeywordSets{}, fmt.Errorf("failed to unmarshal keyword sets: %w", err)
+	}
+	return sets.WithDefaults(), nil
+}
+
+// WithDefaults returns the sets with their blank keywords dropped and the empty
+// sets replaced by their defaults
+func (s KeywordSets) WithDefaults() KeywordSets {
+	defaults := DefaultKeywordSets()
+	return KeywordSets{
+		Fraud:            keywordsOrDefault(s.Fraud, defaults.Fraud),
+		SocialMediaSites: keywordsOrDefault(s.SocialMediaSites, defaults.SocialMediaSites),
+		FileTypes:        keywordsOrDefault(s.FileTypes, defaults.FileTypes),
+		XInURL:           keywordsOrDefault(s.XInURL, defaults.XInURL),
+		XSites:           keywordsOrDefault(s.XSites, defaults.XSites),
+	}
+}
+
+// keywordsOrDefault returns the trimmed non-blank keywords, or defaults when
+// there are none
+func keywordsOrDefault(keywords, defaults []string) []string {
+	var trimmed []string
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			trimmed = append(trimmed, keyword)
+		}
+	}
+	if len(trimmed) == 0 {
+		return slices.Clone(defaults)
+	}
+	return trimmed
+}
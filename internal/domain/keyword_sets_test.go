@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLoadKeywordSets(t *testing.T) {
+	sets, err := LoadKeywordSets([]byte(`{"fraud": ["scam", " ", "fraud"], "social_media_sites": ["facebook.com", "instagram.com"]}`))
+	if err != nil {
+		t.Fatalf("LoadKeywordSets returned error: %v", err)
+	}
+
+	if !slices.Equal(sets.Fraud, []string{"scam", "fraud"}) {
+		t.Errorf("expected the custom fraud terms without blanks, got %v", sets.Fraud)
+	}
+	if slices.Contains(sets.SocialMediaSites, "tiktok.com") {
+		t.Errorf("expected the custom sites to replace the defaults, got %v", sets.SocialMediaSites)
+	}
+
+	// The sets left out keep their defaults
+	defaults := DefaultKeywordSets()
+	if !slices.Equal(sets.FileTypes, defaults.FileTypes) || !slices.Equal(sets.XSites, defaults.XSites) {
+		t.Errorf("expected the default file types and X sites, got %v and %v", sets.FileTypes, sets.XSites)
+	}
+
+	if _, err := LoadKeywordSets([]byte(`{"fraud": "scam"}`)); err == nil {
+		t.Error("expected invalid keyword sets to be rejected")
+	}
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManualFindingCategories are the keyword categories a manual finding may have
+var ManualFindingCategories = []KeywordCategory{
+	KeywordCategoryCompanyName,
+	KeywordCategoryPersonName,
+	KeywordCategoryContributorID,
+	KeywordCategoryAddress,
+	KeywordCategorySocialMedia,
+}
+
+// ManualFinding is offline intel, such as a tip or a document, an analyst folds
+// into the entities of a pipeline
+type ManualFinding struct {
+	Name     string          `json:"name"`
+	Category KeywordCategory `json:"category"`
+	Note     string          `json:"note,omitempty"`
+	// Source describes where the analyst got the finding from
+	Source string `json:"source,omitempty"`
+	// Manual is always true, so the finding reads as analyst-entered wherever
+	// its output ends up
+	Manual    bool      `json:"manual"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate trims the fields of the finding and checks the required ones
+func (f *ManualFinding) Validate() error {
+	f.Name = strings.TrimSpace(f.Name)
+	f.Note = strings.TrimSpace(f.Note)
+	f.Source = strings.TrimSpace(f.Source)
+
+	if f.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !slices.Contains(ManualFindingCategories, f.Category) {
+		return fmt.Errorf("unsupported category %q", f.Category)
+	}
+	return nil
+}
+
+// Step returns the pipeline step recording the finding. The step is successful
+// and has the finding both as output and as its only keyword, so it takes part
+// in the keywords, entities and corroboration of the pipeline like a search.
+func (f ManualFinding) Step(pipelineID ID) DynamicPipelineStep {
+	f.Manual = true
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now()
+	}
+
+	return DynamicPipelineStep{
+		PipelineID:      pipelineID,
+		DomainType:      DomainTypeManual,
+		SearchParameter: f.Name,
+		Category:        f.Category,
+		Keywords:        []string{f.Name},
+		Success:         true,
+		Output:          []ManualFinding{f},
+		KeywordsPerCategory: map[KeywordCategory][]string{
+			f.Category: {f.Name},
+		},
+	}
+}
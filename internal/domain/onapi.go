@@ -35,6 +35,13 @@ type Entity struct {
 	ListaClases          []ListaClase `json:"lista_clases"`
 	CreatedAt            time.Time    `json:"created_at"`
 	UpdatedAt            time.Time    `json:"updated_at"`
+	Freshness
+}
+
+// MissingDetail reports whether the entity was stored without the details of
+// its file, which name the holder and the protected classes
+func (e Entity) MissingDetail() bool {
+	return e.Titular == "" && len(e.ListaClases) == 0
 }
 
 type ListaClase struct {
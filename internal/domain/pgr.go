@@ -5,10 +5,14 @@ import (
 )
 
 type PGRNews struct {
-	ID                   ID        `json:"id"`
-	DomainSearchResultID ID        `json:"domain_search_result_id"`
-	URL                  string    `json:"url"`
-	Title                string    `json:"title"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                   ID     `json:"id"`
+	DomainSearchResultID ID     `json:"domain_search_result_id"`
+	URL                  string `json:"url"`
+	Title                string `json:"title"`
+	// PublishedAt is the publication date of the article, zero when the page
+	// shows none
+	PublishedAt time.Time `json:"published_at,omitzero"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Freshness
 }
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// PipelineEventType is the lifecycle point a pipeline event records
+type PipelineEventType string
+
+const (
+	PipelineEventStarted         PipelineEventType = "started"
+	PipelineEventStepCompleted   PipelineEventType = "step_completed"
+	PipelineEventStepFailed      PipelineEventType = "step_failed"
+	PipelineEventSourceBlocked   PipelineEventType = "source_blocked"
+	PipelineEventFanoutCapped    PipelineEventType = "fanout_capped"
+	PipelineEventBudgetExhausted PipelineEventType = "budget_exhausted"
+	PipelineEventCompleted       PipelineEventType = "completed"
+	PipelineEventCancelled       PipelineEventType = "cancelled"
+	PipelineEventFailed          PipelineEventType = "failed"
+)
+
+// PipelineEvent is an entry of the audit log of a pipeline run. Data holds the
+// details of the event, such as the domain and depth of a step.
+type PipelineEvent struct {
+	Seq        int64             `json:"seq"`
+	PipelineID ID                `json:"pipeline_id"`
+	StepID     ID                `json:"step_id,omitzero"`
+	Type       PipelineEventType `json:"type"`
+	Message    string            `json:"message"`
+	Data       map[string]any    `json:"data,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
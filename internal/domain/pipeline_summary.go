@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Pipeline statuses reported in the pipeline listings
+const (
+	// PipelineStatusRunning is reported for a pipeline whose counters have not
+	// been stored yet, which happens when it ends
+	PipelineStatusRunning   = "running"
+	PipelineStatusCompleted = "completed"
+	// PipelineStatusFailed is reported when every step of the pipeline failed
+	PipelineStatusFailed = "failed"
+	// PipelineStatusStopped is reported when the pipeline stopped early, as
+	// told by its stop reason
+	PipelineStatusStopped = "stopped"
+)
+
+// PipelineSummary is the projection of a pipeline listed without its steps
+type PipelineSummary struct {
+	ID              ID        `json:"id"`
+	Target          string    `json:"target"`
+	SessionID       string    `json:"session_id,omitempty"`
+	Status          string    `json:"status"`
+	TotalSteps      int       `json:"total_steps"`
+	SuccessfulSteps int       `json:"successful_steps"`
+	FailedSteps     int       `json:"failed_steps"`
+	MaxDepthReached int       `json:"max_depth_reached"`
+	StopReason      string    `json:"stop_reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PipelineStatus derives the status of a pipeline from its stored counters
+func PipelineStatus(totalSteps, successfulSteps, failedSteps int, stopReason string) string {
+	switch {
+	case stopReason != "":
+		return PipelineStatusStopped
+	case totalSteps == 0:
+		return PipelineStatusRunning
+	case successfulSteps == 0 && failedSteps > 0:
+		return PipelineStatusFailed
+	default:
+		return PipelineStatusCompleted
+	}
+}
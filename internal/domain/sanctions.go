@@ -0,0 +1,22 @@
+package domain
+
+// SanctionEntry is a person or company listed in a sanctions or politically
+// exposed persons (PEP) list, such as the OFAC SDN or UN consolidated lists
+type SanctionEntry struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	// Type is "person" or "company"
+	Type    string `json:"type,omitempty"`
+	List    string `json:"list"`
+	Program string `json:"program,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// SanctionMatch is a listed entry whose name or alias matches a searched name,
+// with the similarity of the closest one as confidence
+type SanctionMatch struct {
+	SanctionEntry
+	Query       string  `json:"query"`
+	MatchedName string  `json:"matched_name"`
+	Confidence  float64 `json:"confidence"`
+}
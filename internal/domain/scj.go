@@ -4,6 +4,9 @@ package domain
 
 import (
 	"insightful-intel/internal/custom"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,23 +24,28 @@ func NewScjDomain() Scj {
 }
 
 type ScjCase struct {
-	ID                   ID        `json:"id"`
-	DomainSearchResultID ID        `json:"domain_search_result_id"`
-	Linea                int       `json:"linea"`
-	AgnoCabecera         int       `json:"agno_cabecera"`
-	MesCabecera          int       `json:"mes_cabecera"`
-	URLCabecera          string    `json:"url_cabecera"`
-	URLCuerpo            string    `json:"url_cuerpo"`
-	IDExpediente         int       `json:"id_expediente"`
-	NoExpediente         string    `json:"no_expediente"`
-	NoSentencia          string    `json:"no_sentencia"`
-	NoUnico              string    `json:"no_unico"`
-	NoInterno            string    `json:"no_interno"`
-	IDTribunal           string    `json:"id_tribunal"`
-	DescTribunal         string    `json:"desc_tribunal"`
-	IDMateria            string    `json:"id_materia"`
-	DescMateria          string    `json:"desc_materia"`
-	FechaFallo           string    `json:"fecha_fallo"`
+	ID                   ID     `json:"id"`
+	DomainSearchResultID ID     `json:"domain_search_result_id"`
+	Linea                int    `json:"linea"`
+	AgnoCabecera         int    `json:"agno_cabecera"`
+	MesCabecera          int    `json:"mes_cabecera"`
+	URLCabecera          string `json:"url_cabecera"`
+	URLCuerpo            string `json:"url_cuerpo"`
+	IDExpediente         int    `json:"id_expediente"`
+	NoExpediente         string `json:"no_expediente"`
+	NoSentencia          string `json:"no_sentencia"`
+	NoUnico              string `json:"no_unico"`
+	NoInterno            string `json:"no_interno"`
+	IDTribunal           string `json:"id_tribunal"`
+	DescTribunal         string `json:"desc_tribunal"`
+	TribunalCanonico     string `json:"tribunal_canonico"`
+	IDMateria            string `json:"id_materia"`
+	DescMateria          string `json:"desc_materia"`
+	MateriaCanonica      string `json:"materia_canonica"`
+	// FechaFallo is the ruling date parsed from FechaFalloRaw, zero when the
+	// raw date does not parse
+	FechaFallo           time.Time `json:"fecha_fallo,omitzero"`
+	FechaFalloRaw        string    `json:"fecha_fallo_raw"`
 	Involucrados         string    `json:"involucrados"`
 	GuidBlob             string    `json:"guid_blob"`
 	TipoDocumentoAdjunto string    `json:"tipo_documento_adjunto"`
@@ -48,4 +56,77 @@ type ScjCase struct {
 	Activo               bool      `json:"activo"`
 	CreatedAt            time.Time `json:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at"`
+	Freshness
+}
+
+// scjDateLayouts are the layouts of the ruling dates seen in SCJ records
+var scjDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.999999999",
+	time.DateTime,
+	time.DateOnly,
+	"20060102",
+}
+
+var (
+	// scjDotNetDate matches the dates serialized by ASP.NET, such as
+	// "/Date(1559102400000)/" or "/Date(1559102400000-0400)/"
+	scjDotNetDate = regexp.MustCompile(`^/Date\((-?\d+)([+-]\d{4})?\)/$`)
+	// scjNumericDate matches the day, month and year of "29/05/2019",
+	// "29-5-2019" or "5/29/2019 12:00:00 a. m.", ignoring the time
+	scjNumericDate = regexp.MustCompile(`^(\d{1,2})[/.-](\d{1,2})[/.-](\d{4})\b`)
+)
+
+// ParseScjDate parses a ruling date in any of the formats SCJ records use. Day
+// first dates are preferred over month first ones, which are only read when
+// the day cannot be a month. It returns the zero time when the date does not
+// parse, or when it is a placeholder such as "0001-01-01T00:00:00".
+func ParseScjDate(raw string) time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+
+	parsed, ok := parseScjDate(raw)
+	if !ok || parsed.Year() < 1900 || parsed.Year() > 2100 {
+		return time.Time{}
+	}
+	return parsed.UTC()
+}
+
+func parseScjDate(raw string) (time.Time, bool) {
+	for _, layout := range scjDateLayouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed, true
+		}
+	}
+
+	if match := scjDotNetDate.FindStringSubmatch(raw); match != nil {
+		milliseconds, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(milliseconds), true
+	}
+
+	if match := scjNumericDate.FindStringSubmatch(raw); match != nil {
+		day, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		year, _ := strconv.Atoi(match[3])
+		if month > 12 && day <= 12 {
+			day, month = month, day
+		}
+		if month < 1 || month > 12 || day < 1 || day > 31 {
+			return time.Time{}, false
+		}
+
+		parsed := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		// time.Date normalizes days past the end of the month, such as 31/02
+		if parsed.Day() != day {
+			return time.Time{}, false
+		}
+		return parsed, true
+	}
+
+	return time.Time{}, false
 }
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed scj_references.json
+var defaultScjReferences []byte
+
+// ScjReference is the canonical form of a tribunal or materia together with the
+// spellings that map to it
+type ScjReference struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// ScjReferenceTable maps the free-text tribunal and materia descriptions of the
+// SCJ to stable references
+type ScjReferenceTable struct {
+	Tribunales []ScjReference `json:"tribunales"`
+	Materias   []ScjReference `json:"materias"`
+}
+
+var (
+	defaultScjReferenceTable     *ScjReferenceTable
+	defaultScjReferenceTableOnce sync.Once
+)
+
+// DefaultScjReferenceTable returns the reference table shipped with the binary
+func DefaultScjReferenceTable() *ScjReferenceTable {
+	defaultScjReferenceTableOnce.Do(func() {
+		table, err := LoadScjReferenceTable(defaultScjReferences)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded scj reference table: %v", err))
+		}
+		defaultScjReferenceTable = table
+	})
+	return defaultScjReferenceTable
+}
+
+// LoadScjReferenceTable parses a JSON reference table
+func LoadScjReferenceTable(data []byte) (*ScjReferenceTable, error) {
+	var table ScjReferenceTable
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scj reference table: %w", err)
+	}
+
+	for _, references := range [][]ScjReference{table.Tribunales, table.Materias} {
+		for _, reference := range references {
+			if reference.ID == "" {
+				return nil, fmt.Errorf("scj reference %q has no id", reference.Name)
+			}
+		}
+	}
+
+	return &table, nil
+}
+
+// NormalizeTribunal maps a tribunal description to its canonical reference
+func (t *ScjReferenceTable) NormalizeTribunal(raw string) (ScjReference, bool) {
+	return matchScjReference(t.Tribunales, raw)
+}
+
+// NormalizeMateria maps a materia description to its canonical reference
+func (t *ScjReferenceTable) NormalizeMateria(raw string) (ScjReference, bool) {
+	return matchScjReference(t.Materias, raw)
+}
+
+// Normalize fills the canonical tribunal and materia of a case, leaving them
+// empty when the raw text does not match any reference
+func (t *ScjReferenceTable) Normalize(c ScjCase) ScjCase {
+	c.TribunalCanonico = ""
+	if reference, ok := t.NormalizeTribunal(c.DescTribunal); ok {
+		c.TribunalCanonico = reference.ID
+	}
+
+	c.MateriaCanonica = ""
+	if reference, ok := t.NormalizeMateria(c.DescMateria); ok {
+		c.MateriaCanonica = reference.ID
+	}
+
+	return c
+}
+
+// matchScjReference prefers an exact alias match and otherwise picks the longest
+// alias contained in the text as whole words
+func matchScjReference(references []ScjReference, raw string) (ScjReference, bool) {
+	text := normalizeScjText(raw)
+	if text == "" {
+		return ScjReference{}, false
+	}
+
+	var best ScjReference
+	bestLen := 0
+	for _, reference := range references {
+		candidates := append([]string{reference.ID, reference.Name}, reference.Aliases...)
+		for _, candidate := range candidates {
+			alias := normalizeScjText(candidate)
+			if alias == "" {
+				continue
+			}
+
+			if alias == text {
+				return reference, true
+			}
+
+			if strings.Contains(" "+text+" ", " "+alias+" ") && len(alias) > bestLen {
+				best = reference
+				bestLen = len(alias)
+			}
+		}
+	}
+
+	return best, bestLen > 0
+}
+
+// normalizeScjText lowercases, strips accents and punctuation and collapses spaces
+func normalizeScjText(value string) string {
+	value = FoldAccents(strings.ToLower(value))
+
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, value)
+
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,73 @@
+package domain
+
+import "testing"
+
+func TestNormalizeMateriaVariantsMapToSameReference(t *testing.T) {
+	table := DefaultScjReferenceTable()
+
+	variants := []string{
+		"Civil y Comercial",
+		"CIVIL Y COMERCIAL",
+		"civil-comercial",
+		"  Materia Civil ",
+		"Civil",
+	}
+
+	for _, variant := range variants {
+		reference, ok := table.NormalizeMateria(variant)
+		if !ok {
+			t.Errorf("expected %q to be normalized", variant)
+			continue
+		}
+		if reference.ID != "civil" {
+			t.Errorf("expected %q to map to civil, got %s", variant, reference.ID)
+		}
+	}
+}
+
+func TestNormalizeTribunalFoldsAccentsAndPunctuation(t *testing.T) {
+	table := DefaultScjReferenceTable()
+
+	for _, variant := range []string{"Primera Sala", "1ra. Sala", "PRIMERA SALA DE LA SUPREMA CORTE DE JUSTICIA"} {
+		reference, ok := table.NormalizeTribunal(variant)
+		if !ok || reference.ID != "scj-primera-sala" {
+			t.Errorf("expected %q to map to scj-primera-sala, got %+v", variant, reference)
+		}
+	}
+
+	reference, ok := table.NormalizeMateria("Contencioso-Administrativo")
+	if !ok || reference.ID != "contencioso-administrativo" {
+		t.Errorf("unexpected materia reference: %+v", reference)
+	}
+}
+
+func TestNormalizeKeepsRawTextWhenUnknown(t *testing.T) {
+	c := DefaultScjReferenceTable().Normalize(ScjCase{
+		DescTribunal: "Tribunal Desconocido",
+		DescMateria:  "Penal",
+	})
+
+	if c.TribunalCanonico != "" {
+		t.Errorf("expected no canonical tribunal, got %s", c.TribunalCanonico)
+	}
+	if c.DescTribunal != "Tribunal Desconocido" {
+		t.Errorf("expected raw tribunal to be kept, got %s", c.DescTribunal)
+	}
+	if c.MateriaCanonica != "penal" {
+		t.Errorf("expected penal, got %s", c.MateriaCanonica)
+	}
+}
+
+func TestLoadScjReferenceTableRequiresIDs(t *testing.T) {
+	if _, err := LoadScjReferenceTable([]byte(`{"materias": [{"name": "Civil"}]}`)); err == nil {
+		t.Fatal("expected an error for a reference without id")
+	}
+
+	table, err := LoadScjReferenceTable([]byte(`{"materias": [{"id": "familia", "name": "Familia", "aliases": ["niños niñas y adolescentes"]}]}`))
+	if err != nil {
+		t.Fatalf("LoadScjReferenceTable returned error: %v", err)
+	}
+	if reference, ok := table.NormalizeMateria("Niños, Niñas y Adolescentes"); !ok || reference.ID != "familia" {
+		t.Errorf("expected custom table to be used, got %+v", reference)
+	}
+}
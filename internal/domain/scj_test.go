@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScjDate(t *testing.T) {
+	ruling := time.Date(2019, time.May, 29, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"2019-05-29T00:00:00":        ruling,
+		"2019-05-29T00:00:00.000":    ruling,
+		"2019-05-29T00:00:00Z":       ruling,
+		"2019-05-29 00:00:00":        ruling,
+		"2019-05-29":                 ruling,
+		"20190529":                   ruling,
+		"/Date(1559088000000)/":      ruling,
+		"/Date(1559088000000-0400)/": ruling,
+		"29/05/2019":                 ruling,
+		" 29-5-2019 ":                ruling,
+		"29.05.2019":                 ruling,
+		"5/29/2019 12:00:00 a. m.":   ruling,
+		"03/04/2019":                 time.Date(2019, time.April, 3, 0, 0, 0, 0, time.UTC),
+		"":                           {},
+		"pendiente":                  {},
+		"31/02/2019":                 {},
+		"13/13/2019":                 {},
+		"0001-01-01T00:00:00":        {},
+	}
+
+	for raw, want := range tests {
+		if got := ParseScjDate(raw); !got.Equal(want) {
+			t.Errorf("ParseScjDate(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package domain
+
+// ScreeningResult is the footprint of a query across the screened domains
+type ScreeningResult struct {
+	Query   string                  `json:"query"`
+	Hit     bool                    `json:"hit"`
+	Domains []ScreeningDomainResult `json:"domains"`
+}
+
+// ScreeningDomainResult tells whether a domain holds records matching a query,
+// without the records themselves
+type ScreeningDomainResult struct {
+	DomainType DomainType `json:"domain_type"`
+	Hit        bool       `json:"hit"`
+	Records    int        `json:"records"`
+	Error      string     `json:"error,omitempty"`
+}
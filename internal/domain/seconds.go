@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultDelayBetweenSteps is the pause after each step of the pipelines
+// started without a delay of their own
+const DefaultDelayBetweenSteps = Seconds(2 * time.Second)
+
+// Seconds is a duration written to JSON as a number of seconds. It reads a
+// number of seconds, fractional for sub-second precision, or a duration
+// string like "250ms", so configurations stored in whole seconds keep their
+// meaning.
+type Seconds time.Duration
+
+// Duration returns s as a time.Duration
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s)
+}
+
+// MarshalJSON writes s as a number of seconds
+func (s Seconds) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(s).Seconds())
+}
+
+// UnmarshalJSON reads a number of seconds or a duration string
+func (s *Seconds) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*s = Seconds(math.Round(seconds * float64(time.Second)))
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expected a number of seconds or a duration string, got %s", data)
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*s = Seconds(duration)
+	return nil
+}
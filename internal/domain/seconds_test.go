@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSecondsUnmarshalJSON(t *testing.T) {
+	for data, want := range map[string]time.Duration{
+		`2`:       2 * time.Second,
+		`0.25`:    250 * time.Millisecond,
+		`0`:       0,
+		`"250ms"`: 250 * time.Millisecond,
+		`"1m30s"`: 90 * time.Second,
+	} {
+		var config DynamicPipelineConfig
+		if err := json.Unmarshal([]byte(`{"delay_between_steps": `+data+`}`), &config); err != nil {
+			t.Errorf("%s: unexpected error %v", data, err)
+			continue
+		}
+		if got := config.DelayBetweenSteps.Duration(); got != want {
+			t.Errorf("%s: expected %s, got %s", data, want, got)
+		}
+	}
+
+	for _, data := range []string{`"soon"`, `true`, `[1]`} {
+		var delay Seconds
+		if err := json.Unmarshal([]byte(data), &delay); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
+
+func TestSecondsMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(DynamicPipelineConfig{DelayBetweenSteps: Seconds(250 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+
+	var config map[string]any
+	json.Unmarshal(data, &config)
+	if config["delay_between_steps"] != 0.25 {
+		t.Errorf("expected the delay in seconds, got %v", config["delay_between_steps"])
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxSeedLength is the longest seed query, in characters, accepted when
+// no other limit is configured
+const DefaultMaxSeedLength = 200
+
+// ErrEmptySeed is returned for a seed query that is empty or only whitespace
+var ErrEmptySeed = errors.New("seed query is required")
+
+// ErrSeedTooLong is returned for a seed query longer than the limit
+var ErrSeedTooLong = errors.New("seed query is too long")
+
+// ValidateSeed trims the seed query a search or pipeline starts from and checks
+// it is neither empty nor longer than maxLength characters,
+// DefaultMaxSeedLength when maxLength is zero
+func ValidateSeed(query string, maxLength int) (string, error) {
+	if maxLength <= 0 {
+		maxLength = DefaultMaxSeedLength
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", ErrEmptySeed
+	}
+	if length := utf8.RuneCountInString(query); length > maxLength {
+		return "", fmt.Errorf("%w: %d characters, at most %d", ErrSeedTooLong, length, maxLength)
+	}
+	return query, nil
+}
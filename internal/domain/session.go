@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Session is an investigation workspace grouping the pipelines run over the
+// same case, possibly across several days
+type Session struct {
+	ID          ID        `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SessionEntity is a keyword found by the pipelines of a session, merged across
+// the runs and domains that found it
+type SessionEntity struct {
+	Category    KeywordCategory `json:"category"`
+	Keyword     string          `json:"keyword"`
+	DomainTypes []DomainType    `json:"domain_types"`
+	PipelineIDs []ID            `json:"pipeline_ids"`
+	Occurrences int             `json:"occurrences"`
+}
+
+// SessionFinding is the latest successful search of a keyword in a domain among
+// the pipelines of a session
+type SessionFinding struct {
+	PipelineID      ID              `json:"pipeline_id"`
+	StepID          ID              `json:"step_id"`
+	DomainType      DomainType      `json:"domain_type"`
+	SearchParameter string          `json:"search_parameter"`
+	Category        KeywordCategory `json:"category"`
+	Output          any             `json:"output"`
+	Depth           int             `json:"depth"`
+}
+
+// SessionView is a session with its pipelines and the entities and findings
+// merged across them
+type SessionView struct {
+	Session   Session                  `json:"session"`
+	Pipelines []*DynamicPipelineResult `json:"pipelines"`
+	Entities  []SessionEntity          `json:"entities"`
+	Findings  []SessionFinding         `json:"findings"`
+}
+
+// NewSessionView merges the pipelines of a session, given oldest first, so a
+// later run replaces the findings of an earlier one for the same search
+func NewSessionView(session Session, pipelines []*DynamicPipelineResult) *SessionView {
+	view := &SessionView{
+		Session:   session,
+		Pipelines: pipelines,
+		Entities:  []SessionEntity{},
+		Findings:  []SessionFinding{},
+	}
+
+	entities := make(map[string]int)
+	findings := make(map[VisitedKeyword]int)
+
+	for _, pipeline := range pipelines {
+		for _, step := range pipeline.Steps {
+			for category, keywords := range step.KeywordsPerCategory {
+				for _, keyword := range keywords {
+					normalized := NormalizeKeyword(keyword)
+					if normalized == "" {
+						continue
+					}
+
+					key := string(category) + "|" + normalized
+					i, ok := entities[key]
+					if !ok {
+						i = len(view.Entities)
+						entities[key] = i
+						view.Entities = append(view.Entities, SessionEntity{Category: category, Keyword: keyword})
+					}
+
+					entity := &view.Entities[i]
+					entity.Occurrences++
+					if !slices.Contains(entity.DomainTypes, step.DomainType) {
+						entity.DomainTypes = append(entity.DomainTypes, step.DomainType)
+					}
+					if !slices.Contains(entity.PipelineIDs, pipeline.ID) {
+						entity.PipelineIDs = append(entity.PipelineIDs, pipeline.ID)
+					}
+				}
+			}
+
+			if !step.Success || step.Output == nil {
+				continue
+			}
+
+			finding := SessionFinding{
+				PipelineID:      pipeline.ID,
+				StepID:          step.ID,
+				DomainType:      step.DomainType,
+				SearchParameter: step.SearchParameter,
+				Category:        step.Category,
+				Output:          step.Output,
+				Depth:           step.Depth,
+			}
+
+			key := NewVisitedKeyword(step.DomainType, step.SearchParameter)
+			if i, ok := findings[key]; ok {
+				view.Findings[i] = finding
+				continue
+			}
+			findings[key] = len(view.Findings)
+			view.Findings = append(view.Findings, finding)
+		}
+	}
+
+	slices.SortStableFunc(view.Entities, func(a, b SessionEntity) int {
+		if a.Category != b.Category {
+			if a.Category < b.Category {
+				return -1
+			}
+			return 1
+		}
+		return b.Occurrences - a.Occurrences
+	})
+
+	return view
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "testing"
+
+func TestNewSessionViewMergesPipelines(t *testing.T) {
+	first := &DynamicPipelineResult{
+		ID: NewID(),
+		Steps: []DynamicPipelineStep{
+			{
+				ID: NewID(), DomainType: DomainTypeONAPI, SearchParameter: "Novasco", Success: true, Output: []Entity{{Texto: "NOVASCO"}},
+				KeywordsPerCategory: map[KeywordCategory][]string{
+					KeywordCategoryPersonName:  {"Juan Perez"},
+					KeywordCategoryCompanyName: {"NOVASCO"},
+				},
+			},
+			{ID: NewID(), DomainType: DomainTypeDGII, SearchParameter: "Novasco", Success: false},
+		},
+	}
+	second := &DynamicPipelineResult{
+		ID: NewID(),
+		Steps: []DynamicPipelineStep{
+			{
+				ID: NewID(), DomainType: DomainTypeONAPI, SearchParameter: "novasco ", Success: true, Output: []Entity{},
+				KeywordsPerCategory: map[KeywordCategory][]string{
+					KeywordCategoryPersonName: {"JUAN  PEREZ"},
+				},
+			},
+			{
+				ID: NewID(), DomainType: DomainTypeCamara, SearchParameter: "Juan Perez", Success: true, Output: []CamaraRecord{},
+				KeywordsPerCategory: map[KeywordCategory][]string{
+					KeywordCategoryPersonName: {"Juan Perez"},
+				},
+			},
+		},
+	}
+
+	view := NewSessionView(Session{ID: NewID(), Name: "Caso Novasco"}, []*DynamicPipelineResult{first, second})
+
+	if len(view.Pipelines) != 2 {
+		t.Fatalf("expected 2 pipelines, got %d", len(view.Pipelines))
+	}
+
+	if len(view.Entities) != 2 {
+		t.Fatalf("expected a company and a person, got %+v", view.Entities)
+	}
+	person := view.Entities[1]
+	if person.Category != KeywordCategoryPersonName || person.Keyword != "Juan Perez" || person.Occurrences != 3 {
+		t.Errorf("unexpected merged person: %+v", person)
+	}
+	if len(person.DomainTypes) != 2 || len(person.PipelineIDs) != 2 {
+		t.Errorf("expected the person found in 2 domains by 2 pipelines, got %+v", person)
+	}
+
+	if len(view.Findings) != 2 {
+		t.Fatalf("expected the failed step dropped and the repeated search merged, got %+v", view.Findings)
+	}
+	if view.Findings[0].PipelineID != second.ID || view.Findings[0].StepID != second.Steps[0].ID {
+		t.Errorf("expected the later run to replace the earlier finding, got %+v", view.Findings[0])
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// SIBInstitution is a financial institution registered with the
+// Superintendencia de Bancos, the banking and financial regulator. A company
+// missing from the registry while presenting itself as a bank or lender is a
+// fraud signal.
+type SIBInstitution struct {
+	ID                   ID           `json:"id"`
+	DomainSearchResultID ID           `json:"domain_search_result_id"`
+	Nombre               string       `json:"nombre"`
+	RNC                  string       `json:"rnc"`
+	TipoEntidad          string       `json:"tipo_entidad"`
+	Licencia             string       `json:"licencia"`
+	Estado               string       `json:"estado"`
+	FechaAutorizacion    string       `json:"fecha_autorizacion"`
+	Direccion            string       `json:"direccion"`
+	Funcionarios         []SIBOfficer `json:"funcionarios"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+// SIBOfficer is a director or officer listed for a registered institution
+type SIBOfficer struct {
+	Nombre string `json:"nombre"`
+	Cargo  string `json:"cargo"`
+}
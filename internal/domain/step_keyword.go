@@ -0,0 +1,57 @@
+package domain
+
+// StepKeyword is a keyword found by a pipeline step, stored one row per keyword
+// so the keywords of a pipeline can be queried by category
+type StepKeyword struct {
+	StepID            ID              `json:"step_id"`
+	DomainType        DomainType      `json:"domain_type"`
+	Category          KeywordCategory `json:"category"`
+	Keyword           string          `json:"keyword"`
+	NormalizedKeyword string          `json:"normalized_keyword"`
+	// NewlySeen tells whether no earlier pipeline found the keyword. It is only
+	// set when the keywords are compared against the stored corpus.
+	NewlySeen *bool `json:"newly_seen,omitempty"`
+}
+
+// Key is the natural key of the keyword, shared by the same finding across
+// pipelines and domains
+func (k StepKeyword) Key() string {
+	return string(k.Category) + "|" + k.NormalizedKeyword
+}
+
+// NewlySeenFilter selects how the keywords of a pipeline are compared against
+// those stored by earlier pipelines
+type NewlySeenFilter string
+
+const (
+	// NewlySeenOff leaves the keywords as they are
+	NewlySeenOff NewlySeenFilter = ""
+	// NewlySeenMark flags every keyword as newly seen or not
+	NewlySeenMark NewlySeenFilter = "mark"
+	// NewlySeenOnly keeps only the keywords no earlier pipeline found
+	NewlySeenOnly NewlySeenFilter = "only"
+)
+
+// Valid reports whether the filter is a known one
+func (f NewlySeenFilter) Valid() bool {
+	return f == NewlySeenOff || f == NewlySeenMark || f == NewlySeenOnly
+}
+
+// ApplyNewlySeen flags the keywords whose key is not in seen as newly seen,
+// keeping only those when the filter is NewlySeenOnly
+func ApplyNewlySeen(keywords []StepKeyword, seen map[string]bool, filter NewlySeenFilter) []StepKeyword {
+	if filter == NewlySeenOff {
+		return keywords
+	}
+
+	filtered := make([]StepKeyword, 0, len(keywords))
+	for _, keyword := range keywords {
+		newlySeen := !seen[keyword.Key()]
+		if filter == NewlySeenOnly && !newlySeen {
+			continue
+		}
+		keyword.NewlySeen = &newlySeen
+		filtered = append(filtered, keyword)
+	}
+	return filtered
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"container/heap"
+)
+
+// DefaultCategoryPriority ranks the keyword categories by how strong a lead
+// their keywords are: an exact contributor ID is worth exploring before the
+// names and addresses that mostly widen the search
+var DefaultCategoryPriority = map[KeywordCategory]int{
+	KeywordCategoryContributorID: 50,
+	KeywordCategoryCompanyName:   40,
+	KeywordCategoryPersonName:    30,
+	KeywordCategoryAddress:       20,
+	KeywordCategorySocialMedia:   10,
+	KeywordCategoryXSocialMedia:  10,
+	KeywordCategoryFileType:      0,
+}
+
+// DefaultMaxQueuedSteps is the number of steps a pipeline keeps queued in
+// memory when its configuration sets no cap
+const DefaultMaxQueuedSteps = 10000
+
+// QueueLimit returns the number of steps the pipeline keeps queued in memory,
+// past which the steps that would run last are spilled to the database
+func (c DynamicPipelineConfig) QueueLimit() int {
+	if c.MaxQueuedSteps > 0 {
+		return c.MaxQueuedSteps
+	}
+	return DefaultMaxQueuedSteps
+}
+
+// StepPriority returns the priority of the steps searching a keyword of
+// category, from CategoryPriority or else DefaultCategoryPriority
+func (c DynamicPipelineConfig) StepPriority(category KeywordCategory) int {
+	if priority, ok := c.CategoryPriority[category]; ok {
+		return priority
+	}
+	return DefaultCategoryPriority[category]
+}
+
+// StepQueue holds the steps waiting to run. Breadth-first, the shallowest
+// step is dequeued first; depth-first, the deepest. Steps at the same depth
+// are dequeued by priority, then breadth-first in the order they were queued.
+// Depth-first, the steps of the latest Push come first, in the order they were
+// pushed in, like the stack of a depth-first search.
+type StepQueue struct {
+	config DynamicPipelineConfig
+	heap   stepHeap
+	pushes int
+}
+
+// NewStepQueue creates an empty queue ordered for config
+func NewStepQueue(config DynamicPipelineConfig) *StepQueue {
+	return &StepQueue{
+		config: config,
+		heap:   stepHeap{depthFirst: config.DepthFirst()},
+	}
+}
+
+// Push queues steps, setting the priority of the generated ones from their
+// category. The initial steps all search the query, so they keep the order of
+// their domains.
+func (q *StepQueue) Push(steps ...DynamicPipelineStep) {
+	for i, step := range steps {
+		if step.Depth > 0 {
+			step.Priority = q.config.StepPriority(step.Category)
+		}
+		heap.Push(&q.heap, queuedStep{step: step, push: q.pushes, index: i})
+	}
+	q.pushes++
+}
+
+// Pop dequeues the next step, reporting false when the queue is empty
+func (q *StepQueue) Pop() (DynamicPipelineStep, bool) {
+	if q.heap.Len() == 0 {
+		return DynamicPipelineStep{}, false
+	}
+	return heap.Pop(&q.heap).(queuedStep).step, true
+}
+
+// Overflow removes the steps past the first limit, the ones that would run
+// last, and returns them in the order they would have run in
+func (q *StepQueue) Overflow(limit int) []DynamicPipelineStep {
+	if limit <= 0 || q.heap.Len() <= limit {
+		return nil
+	}
+
+	kept := make([]queuedStep, 0, limit)
+	for len(kept) < limit {
+		kept = append(kept, heap.Pop(&q.heap).(queuedStep))
+	}
+	overflow := make([]DynamicPipelineStep, 0, q.heap.Len())
+	for q.heap.Len() > 0 {
+		overflow = append(overflow, heap.Pop(&q.heap).(queuedStep).step)
+	}
+
+	// The steps were popped in order, which keeps them a valid heap
+	q.heap.steps = kept
+	return overflow
+}
+
+// Len returns the number of queued steps
+func (q *StepQueue) Len() int {
+	return q.heap.Len()
+}
+
+type queuedStep struct {
+	step DynamicPipelineStep
+	// push is the Push call that queued the step and index its position in it
+	push  int
+	index int
+}
+
+// stepHeap implements heap.Interface over the queued steps
+type stepHeap struct {
+	steps      []queuedStep
+	depthFirst bool
+}
+
+func (h stepHeap) Len() int { return len(h.steps) }
+
+func (h stepHeap) Less(i, j int) bool {
+	a, b := h.steps[i], h.steps[j]
+	if a.step.Depth != b.step.Depth {
+		return (a.step.Depth < b.step.Depth) != h.depthFirst
+	}
+	if a.step.Priority != b.step.Priority {
+		return a.step.Priority > b.step.Priority
+	}
+	if a.push != b.push {
+		return (a.push < b.push) != h.depthFirst
+	}
+	return a.index < b.index
+}
+
+func (h stepHeap) Swap(i, j int) { h.steps[i], h.steps[j] = h.steps[j], h.steps[i] }
+
+func (h *stepHeap) Push(x any) { h.steps = append(h.steps, x.(queuedStep)) }
+
+func (h *stepHeap) Pop() any {
+	last := h.steps[len(h.steps)-1]
+	h.steps = h.steps[:len(h.steps)-1]
+	return last
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+// drain pops every step of the queue and returns their search parameters
+func drain(queue *StepQueue) []string {
+	var order []string
+	for {
+		step, ok := queue.Pop()
+		if !ok {
+			return order
+		}
+		order = append(order, step.SearchParameter)
+	}
+}
+
+func TestStepQueueDequeueOrder(t *testing.T) {
+	mixed := []DynamicPipelineStep{
+		{SearchParameter: "dork", Category: KeywordCategoryFileType, Depth: 1},
+		{SearchParameter: "deep rnc", Category: KeywordCategoryContributorID, Depth: 2},
+		{SearchParameter: "company", Category: KeywordCategoryCompanyName, Depth: 1},
+		{SearchParameter: "rnc", Category: KeywordCategoryContributorID, Depth: 1},
+		{SearchParameter: "seed", Category: KeywordCategoryCompanyName, Depth: 0},
+		{SearchParameter: "other company", Category: KeywordCategoryCompanyName, Depth: 1},
+	}
+
+	queue := NewStepQueue(DynamicPipelineConfig{})
+	queue.Push(mixed...)
+	want := []string{"seed", "rnc", "company", "other company", "dork", "deep rnc"}
+	if order := drain(queue); !slices.Equal(order, want) {
+		t.Errorf("expected dequeue order %v, got %v", want, order)
+	}
+
+	// A configured priority overrides the default of its category
+	queue = NewStepQueue(DynamicPipelineConfig{CategoryPriority: map[KeywordCategory]int{KeywordCategoryFileType: 100}})
+	queue.Push(mixed...)
+	want = []string{"seed", "dork", "rnc", "company", "other company", "deep rnc"}
+	if order := drain(queue); !slices.Equal(order, want) {
+		t.Errorf("expected dequeue order %v with a configured priority, got %v", want, order)
+	}
+}
+
+func TestStepQueueDepthFirst(t *testing.T) {
+	queue := NewStepQueue(DynamicPipelineConfig{TraversalMode: TraversalDFS})
+	queue.Push(
+		DynamicPipelineStep{SearchParameter: "onapi seed", Depth: 0},
+		DynamicPipelineStep{SearchParameter: "dgii seed", Depth: 0},
+	)
+	queue.Push(
+		DynamicPipelineStep{SearchParameter: "company", Category: KeywordCategoryCompanyName, Depth: 1},
+		DynamicPipelineStep{SearchParameter: "rnc", Category: KeywordCategoryContributorID, Depth: 1},
+	)
+	queue.Push(DynamicPipelineStep{SearchParameter: "deep", Category: KeywordCategoryFileType, Depth: 2})
+
+	want := []string{"deep", "rnc", "company", "onapi seed", "dgii seed"}
+	if order := drain(queue); !slices.Equal(order, want) {
+		t.Errorf("expected depth-first order %v, got %v", want, order)
+	}
+	if queue.Len() != 0 {
+		t.Errorf("expected an empty queue, got %d steps", queue.Len())
+	}
+}
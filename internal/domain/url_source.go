@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// URLSource records that a domain found a URL already stored by another domain,
+// linking it to the stored record instead of duplicating it
+type URLSource struct {
+	ID                   ID         `json:"id"`
+	URL                  string     `json:"url"`
+	RecordTable          string     `json:"record_table"`
+	RecordID             ID         `json:"record_id"`
+	DomainType           DomainType `json:"domain_type"`
+	DomainSearchResultID ID         `json:"domain_search_result_id"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
@@ -0,0 +1,91 @@
+package infra
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the time the pipelines measure their duration with
+type Clock interface {
+	Now() time.Time
+}
+
+// Sleeper waits the pauses of the pipelines. Sleep returns the error of ctx
+// when it is done before d has passed.
+type Sleeper interface {
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// SystemClock is the Clock of the wall time
+var SystemClock Clock = systemClock{}
+
+// SystemSleeper is the Sleeper waiting in real time
+var SystemSleeper Sleeper = systemSleeper{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type systemSleeper struct{}
+
+func (systemSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// FakeClock is a Clock and a Sleeper over virtual time, for tests. Sleeping
+// advances its time at once and records the pause instead of waiting.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewFakeClock creates a fake clock reading now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the virtual time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the virtual time forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleep records d and advances the virtual time by it, unless ctx is done
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// Sleeps returns the pauses slept so far, in order
+func (c *FakeClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
@@ -0,0 +1,52 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeClockSleepAdvancesVirtualTime(t *testing.T) {
+	start := time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	began := time.Now()
+	for range 3 {
+		if err := clock.Sleep(context.Background(), time.Hour); err != nil {
+			t.Fatalf("Sleep returned error: %v", err)
+		}
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("expected the fake sleeps not to wait, took %s", elapsed)
+	}
+
+	if got := clock.Now(); !got.Equal(start.Add(3 * time.Hour)) {
+		t.Errorf("expected the clock to read %s, got %s", start.Add(3*time.Hour), got)
+	}
+	if sleeps := clock.Sleeps(); len(sleeps) != 3 || sleeps[0] != time.Hour {
+		t.Errorf("expected three one-hour sleeps, got %v", sleeps)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := clock.Sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled sleep to fail, got %v", err)
+	}
+	if len(clock.Sleeps()) != 3 {
+		t.Errorf("expected the cancelled sleep not to be recorded")
+	}
+}
+
+func TestSystemSleeperStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	began := time.Now()
+	if err := SystemSleeper.Sleep(ctx, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the sleep to end with its context, got %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("expected the sleep to stop with its context, took %s", elapsed)
+	}
+}
@@ -0,0 +1,24 @@
+package infra
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// debugDumpEnabled is read once at startup from DEBUG_DUMP
+var debugDumpEnabled, _ = strconv.ParseBool(os.Getenv("DEBUG_DUMP"))
+
+// debugDumpOutput is where DebugDump writes
+var debugDumpOutput io.Writer = os.Stdout
+
+// DebugDump pretty prints values for local debugging when DEBUG_DUMP is set,
+// and does nothing otherwise
+func DebugDump(values ...any) {
+	if !debugDumpEnabled {
+		return
+	}
+	spew.Fdump(debugDumpOutput, values...)
+}
@@ -0,0 +1,38 @@
+package infra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func withDebugDump(t *testing.T, enabled bool) *bytes.Buffer {
+	t.Helper()
+
+	originalEnabled, originalOutput := debugDumpEnabled, debugDumpOutput
+	t.Cleanup(func() { debugDumpEnabled, debugDumpOutput = originalEnabled, originalOutput })
+
+	var buf bytes.Buffer
+	debugDumpEnabled, debugDumpOutput = enabled, &buf
+	return &buf
+}
+
+func TestDebugDumpDisabled(t *testing.T) {
+	buf := withDebugDump(t, false)
+
+	DebugDump("step", struct{ Query string }{Query: "novasco"})
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when DEBUG_DUMP is unset, got %q", buf.String())
+	}
+}
+
+func TestDebugDumpEnabled(t *testing.T) {
+	buf := withDebugDump(t, true)
+
+	DebugDump("step", struct{ Query string }{Query: "novasco"})
+
+	if !strings.Contains(buf.String(), "novasco") {
+		t.Errorf("expected the values to be dumped, got %q", buf.String())
+	}
+}
@@ -2,11 +2,14 @@ package infra
 
 import "context"
 
+// contextKey is the type of the context keys of the IDs every layer logs
+// with. They are only set and read through this package.
 type contextKey string
 
 const ExecutionIDKey contextKey = "execution_id"
 const PipelineIDKey contextKey = "pipeline_id"
 const StepIDKey contextKey = "step_id"
+const SessionIDKey contextKey = "session_id"
 
 func GetExecutionID(ctx context.Context) (string, bool) {
 	executionID, ok := ctx.Value(ExecutionIDKey).(string)
@@ -28,3 +31,21 @@ func GetPipelineID(ctx context.Context) (string, bool) {
 func SetPipelineID(ctx context.Context, pipelineID string) context.Context {
 	return context.WithValue(ctx, PipelineIDKey, pipelineID)
 }
+
+func GetStepID(ctx context.Context) (string, bool) {
+	stepID, ok := ctx.Value(StepIDKey).(string)
+	return stepID, ok
+}
+
+func SetStepID(ctx context.Context, stepID string) context.Context {
+	return context.WithValue(ctx, StepIDKey, stepID)
+}
+
+func GetSessionID(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(SessionIDKey).(string)
+	return sessionID, ok
+}
+
+func SetSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, SessionIDKey, sessionID)
+}
@@ -0,0 +1,66 @@
+package infra
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ParseLogLevel reads a level name (debug, info, warn or error), defaulting to
+// info when it is empty or unknown
+func ParseLogLevel(value string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewLogger creates a logger writing JSON records at the given level or above
+func NewLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// SetupLogger makes a JSON logger on stderr the default logger, at the level
+// set by LOG_LEVEL
+func SetupLogger() *slog.Logger {
+	logger := NewLogger(os.Stderr, ParseLogLevel(os.Getenv("LOG_LEVEL")))
+	slog.SetDefault(logger)
+	return logger
+}
+
+// Logger returns the default logger carrying the execution, session, pipeline
+// and step IDs found in ctx
+func Logger(ctx context.Context) *slog.Logger {
+	return LoggerFrom(ctx, slog.Default())
+}
+
+// LoggerFrom returns the logger carrying the execution, session, pipeline and
+// step IDs found in ctx
+func LoggerFrom(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	var attrs []any
+	if executionID, ok := GetExecutionID(ctx); ok && executionID != "" {
+		attrs = append(attrs, slog.String(string(ExecutionIDKey), executionID))
+	}
+	if sessionID, ok := GetSessionID(ctx); ok && sessionID != "" {
+		attrs = append(attrs, slog.String(string(SessionIDKey), sessionID))
+	}
+	if pipelineID, ok := GetPipelineID(ctx); ok && pipelineID != "" {
+		attrs = append(attrs, slog.String(string(PipelineIDKey), pipelineID))
+	}
+	if stepID, ok := GetStepID(ctx); ok && stepID != "" {
+		attrs = append(attrs, slog.String(string(StepIDKey), stepID))
+	}
+
+	if len(attrs) == 0 {
+		return logger
+	}
+	return logger.With(attrs...)
+}
@@ -0,0 +1,67 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggerFromCarriesContextIDs(t *testing.T) {
+	var buf bytes.Buffer
+	base := NewLogger(&buf, slog.LevelInfo)
+
+	ctx := SetExecutionID(context.Background(), "exec-1")
+	ctx = SetPipelineID(ctx, "pipeline-1")
+	ctx = SetStepID(ctx, "step-1")
+
+	LoggerFrom(ctx, base).Info("step completed", slog.String("domain_type", "ONAPI"))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+
+	for key, want := range map[string]string{
+		"msg":          "step completed",
+		"level":        "INFO",
+		"execution_id": "exec-1",
+		"pipeline_id":  "pipeline-1",
+		"step_id":      "step-1",
+		"domain_type":  "ONAPI",
+	} {
+		if record[key] != want {
+			t.Errorf("expected %s=%q, got %v", key, want, record[key])
+		}
+	}
+	if _, ok := record["session_id"]; ok {
+		t.Errorf("expected no session_id outside a session, got %v", record["session_id"])
+	}
+}
+
+func TestLoggerHonorsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, ParseLogLevel("warn"))
+
+	logger.Info("ignored")
+	if buf.Len() != 0 {
+		t.Errorf("expected info records to be dropped at warn level, got %q", buf.String())
+	}
+
+	logger.Warn("kept")
+	if buf.Len() == 0 {
+		t.Error("expected warn records to be written")
+	}
+
+	for value, want := range map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"DEBUG": slog.LevelDebug,
+		"error": slog.LevelError,
+		"bogus": slog.LevelInfo,
+	} {
+		if got := ParseLogLevel(value); got != want {
+			t.Errorf("ParseLogLevel(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
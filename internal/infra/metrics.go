@@ -0,0 +1,166 @@
+package infra
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the search
+// latency histogram
+var DefaultLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Metrics counts the domain searches run by the process, per domain, and the
+// lookups of the search cache, in a form rendered in the Prometheus text
+// exposition format
+type Metrics struct {
+	mu       sync.Mutex
+	buckets  []float64
+	searches map[string]*searchMetrics
+
+	cacheHits   uint64
+	cacheMisses uint64
+}
+
+type searchMetrics struct {
+	attempts  uint64
+	successes uint64
+	failures  uint64
+	// latencyCounts holds the observations per bucket, not cumulated, with
+	// the ones above the last bucket at the end
+	latencyCounts []uint64
+	latencySum    float64
+}
+
+// DefaultMetrics is the registry the domain searches are recorded in
+var DefaultMetrics = NewMetrics()
+
+// NewMetrics creates an empty registry using DefaultLatencyBuckets
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets:  DefaultLatencyBuckets,
+		searches: make(map[string]*searchMetrics),
+	}
+}
+
+// RecordSearch counts a search of domainType that took latency and succeeded
+// or failed
+func (m *Metrics) RecordSearch(domainType string, latency time.Duration, success bool) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	search, ok := m.searches[domainType]
+	if !ok {
+		search = &searchMetrics{latencyCounts: make([]uint64, len(m.buckets)+1)}
+		m.searches[domainType] = search
+	}
+
+	search.attempts++
+	if success {
+		search.successes++
+	} else {
+		search.failures++
+	}
+
+	seconds := latency.Seconds()
+	bucket, _ := slices.BinarySearch(m.buckets, seconds)
+	search.latencyCounts[bucket]++
+	search.latencySum += seconds
+}
+
+// RecordCacheLookup counts a search answered from the search cache when hit is
+// true, and one that missed it otherwise
+func (m *Metrics) RecordCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if hit {
+		m.cacheHits++
+	} else {
+		m.cacheMisses++
+	}
+}
+
+// WritePrometheus renders the metrics in the Prometheus text exposition format,
+// with the domains sorted so the output is stable
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	domainTypes := make([]string, 0, len(m.searches))
+	for domainType := range m.searches {
+		domainTypes = append(domainTypes, domainType)
+	}
+	slices.Sort(domainTypes)
+
+	counters := []struct {
+		name, help string
+		value      func(*searchMetrics) uint64
+	}{
+		{"insightful_search_attempts_total", "Domain searches attempted.", func(s *searchMetrics) uint64 { return s.attempts }},
+		{"insightful_search_successes_total", "Domain searches that succeeded.", func(s *searchMetrics) uint64 { return s.successes }},
+		{"insightful_search_failures_total", "Domain searches that failed.", func(s *searchMetrics) uint64 { return s.failures }},
+	}
+
+	for _, counter := range counters {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name); err != nil {
+			return err
+		}
+		for _, domainType := range domainTypes {
+			if _, err := fmt.Fprintf(w, "%s{domain_type=%q} %d\n", counter.name, domainType, counter.value(m.searches[domainType])); err != nil {
+				return err
+			}
+		}
+	}
+
+	cacheCounters := []struct {
+		name, help string
+		value      uint64
+	}{
+		{"insightful_search_cache_hits_total", "Domain searches answered from the search cache.", m.cacheHits},
+		{"insightful_search_cache_misses_total", "Domain searches that missed the search cache.", m.cacheMisses},
+	}
+	for _, counter := range cacheCounters {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", counter.name, counter.help, counter.name, counter.name, counter.value); err != nil {
+			return err
+		}
+	}
+
+	const histogram = "insightful_search_duration_seconds"
+	if _, err := fmt.Fprintf(w, "# HELP %s Latency of the domain searches.\n# TYPE %s histogram\n", histogram, histogram); err != nil {
+		return err
+	}
+	for _, domainType := range domainTypes {
+		search := m.searches[domainType]
+
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += search.latencyCounts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket{domain_type=%q,le=%q} %d\n", histogram, domainType, strconv.FormatFloat(bound, 'g', -1, 64), cumulative); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket{domain_type=%q,le=\"+Inf\"} %d\n", histogram, domainType, search.attempts); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum{domain_type=%q} %s\n", histogram, domainType, strconv.FormatFloat(search.latencySum, 'g', -1, 64)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_count{domain_type=%q} %d\n", histogram, domainType, search.attempts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
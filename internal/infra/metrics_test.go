@@ -0,0 +1,55 @@
+package infra
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsLatencyHistogram(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.RecordSearch("PGR", 50*time.Millisecond, true)
+	metrics.RecordSearch("PGR", time.Second, true)
+	metrics.RecordSearch("PGR", time.Minute, false)
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus returned error: %v", err)
+	}
+
+	for _, line := range []string{
+		"# TYPE insightful_search_duration_seconds histogram",
+		`insightful_search_duration_seconds_bucket{domain_type="PGR",le="0.1"} 1`,
+		`insightful_search_duration_seconds_bucket{domain_type="PGR",le="0.5"} 1`,
+		`insightful_search_duration_seconds_bucket{domain_type="PGR",le="1"} 2`,
+		`insightful_search_duration_seconds_bucket{domain_type="PGR",le="30"} 2`,
+		`insightful_search_duration_seconds_bucket{domain_type="PGR",le="+Inf"} 3`,
+		`insightful_search_duration_seconds_sum{domain_type="PGR"} 61.05`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in metrics:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestMetricsCacheLookups(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.RecordCacheLookup(true)
+	metrics.RecordCacheLookup(false)
+	metrics.RecordCacheLookup(false)
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus returned error: %v", err)
+	}
+
+	for _, line := range []string{
+		"# TYPE insightful_search_cache_hits_total counter",
+		"insightful_search_cache_hits_total 1",
+		"insightful_search_cache_misses_total 2",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in metrics:\n%s", line, out.String())
+		}
+	}
+}
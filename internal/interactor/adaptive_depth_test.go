@@ -0,0 +1,91 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExecuteDynamicPipelineAdaptiveDepth(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	// The DGII search of the query opens two branches. The PROD branch finds a
+	// new company at every step; the ECHO branch only finds ECHO again.
+	searched := make(map[string]bool)
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searched[string(domainType)+":"+params.Query] = true
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		if domainType == domain.DomainTypeDGII {
+			result.Output = []domain.Register{}
+		} else {
+			result.Output = []domain.Entity{}
+		}
+
+		var found []string
+		switch {
+		case params.Query == "novasco" && domainType == domain.DomainTypeDGII:
+			found = []string{"PROD 1", "ECHO"}
+		case params.Query == "ECHO":
+			found = []string{"ECHO"}
+		case strings.HasPrefix(params.Query, "PROD "):
+			var n int
+			fmt.Sscanf(params.Query, "PROD %d", &n)
+			found = []string{fmt.Sprintf("PROD %d", n+1)}
+		}
+		if len(found) > 0 {
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{domain.KeywordCategoryCompanyName: found}
+		}
+		return result, nil
+	})
+
+	// novasco in DGII and ONAPI, ECHO in ONAPI, then PROD 1 to PROD 5
+	// alternating between ONAPI and DGII
+	const wantSteps = 2 + 1 + 5
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < wantSteps; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	for i := 0; i < wantSteps-1; i++ {
+		mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:                  "novasco",
+		MaxDepth:               3,
+		MaxConcurrentSteps:     1,
+		AvailableDomains:       []domain.DomainType{domain.DomainTypeDGII, domain.DomainTypeONAPI},
+		AdaptiveDepth:          true,
+		AdaptiveDepthWindow:    1,
+		AdaptiveDepthExtension: 2,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	// Without adaptive depth, the ONAPI search finding ECHO again would have
+	// queued the DGII search of ECHO at depth 2, below MaxDepth
+	if !searched["ONAPI:ECHO"] || searched["DGII:ECHO"] {
+		t.Errorf("expected the ECHO branch to stop at depth 1, got searches %v", searched)
+	}
+	// PROD 5 is searched at depth 5, past MaxDepth, and the extension ends there
+	if !searched["ONAPI:PROD 5"] || searched["DGII:PROD 6"] || result.MaxDepthReached != 5 {
+		t.Errorf("expected the PROD branch to continue to depth 5, got depth %d and searches %v", result.MaxDepthReached, searched)
+	}
+	if result.TotalSteps != wantSteps {
+		t.Errorf("expected %d steps, got %d: %v", wantSteps, result.TotalSteps, searched)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package interactor
+
+import (
+	"insightful-intel/internal/domain"
+	"testing"
+)
+
+func TestGenerateNextStepsSkipsCanonicalCompanyAliases(t *testing.T) {
+	registers := []domain.Register{{
+		RNC:             "130000001",
+		RazonSocial:     "NOVASCO REAL ESTATE, S.R.L.",
+		NombreComercial: "Novasco Real Estate",
+	}}
+	completed := domain.DynamicPipelineStep{
+		DomainType: domain.DomainTypeDGII,
+		Success:    true,
+		Output:     registers,
+		KeywordsPerCategory: map[domain.KeywordCategory][]string{
+			domain.KeywordCategoryCompanyName: {"NOVASCO REAL ESTATE, S.R.L.", "Novasco Real Estate"},
+		},
+	}
+	availableDomains := []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII}
+
+	newSearched := func() map[domain.DomainType]map[string]bool {
+		return map[domain.DomainType]map[string]bool{
+			domain.DomainTypeONAPI: {"NOVASCO REAL ESTATE": true},
+			domain.DomainTypeDGII:  {},
+		}
+	}
+
+	// Both names canonicalize to the name ONAPI was searched with
+	steps := (&DynamicPipelineInteractor{}).generateNextSteps(completed, availableDomains, newSearched(), map[domain.VisitedKeyword]bool{}, nil, domain.DynamicPipelineConfig{})
+	if len(steps) != 0 {
+		t.Fatalf("expected the forms of a searched name not to be searched again, got %+v", steps)
+	}
+
+	// Unsearched, the forms of the name make a single ONAPI step
+	steps = (&DynamicPipelineInteractor{}).generateNextSteps(completed, availableDomains, map[domain.DomainType]map[string]bool{
+		domain.DomainTypeONAPI: {},
+		domain.DomainTypeDGII:  {},
+	}, map[domain.VisitedKeyword]bool{}, nil, domain.DynamicPipelineConfig{})
+	if len(steps) != 1 || steps[0].DomainType != domain.DomainTypeONAPI {
+		t.Fatalf("expected one ONAPI step for both forms of the name, got %+v", steps)
+	}
+
+	// ONAPI already found the company, so the DGII aliases add nothing
+	companies := domain.NewCompanyCanonicalizer(0)
+	searched := newSearched()
+	for _, company := range companies.AddOutput([]domain.Entity{{Texto: "NOVASCO REAL ESTATE"}}) {
+		searched[domain.DomainTypeONAPI][company.Key()] = true
+	}
+	companies.AddOutput(registers)
+
+	steps = (&DynamicPipelineInteractor{}).generateNextSteps(completed, availableDomains, searched, map[domain.VisitedKeyword]bool{}, companies, domain.DynamicPipelineConfig{})
+	if len(steps) != 0 {
+		t.Fatalf("expected the canonical company to prevent per-alias searches, got %+v", steps)
+	}
+
+	merged := companies.Companies()
+	if len(merged) != 1 || len(merged[0].Aliases) != 2 {
+		t.Errorf("expected one company with both names, got %+v", merged)
+	}
+}
@@ -0,0 +1,147 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"insightful-intel/internal/repositories"
+	"testing"
+	"time"
+)
+
+func TestExecuteDynamicPipelineWaitsDelayBetweenSteps(t *testing.T) {
+	searcher := SearcherFunc(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Entity{}}, nil
+	})
+
+	for _, delay := range []time.Duration{0, 150 * time.Millisecond} {
+		interactor := NewDynamicPipelineInteractorWithSearcher(repositories.NewMemoryRepositoryFactory(), searcher)
+
+		start := time.Now()
+		result, err := interactor.ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+			Query:             "novasco",
+			MaxDepth:          1,
+			DelayBetweenSteps: domain.Seconds(delay),
+			AvailableDomains:  []domain.DomainType{domain.DomainTypeONAPI},
+		})
+		elapsed := time.Since(start)
+		if err != nil {
+			t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+		}
+		if result.TotalSteps != 1 {
+			t.Fatalf("expected a single step, got %d", result.TotalSteps)
+		}
+
+		// A sub-second delay is waited as is, not rounded to whole seconds
+		if elapsed < delay || elapsed > delay+time.Second {
+			t.Errorf("expected the %s delay to take %s to %s, took %s", delay, delay, delay+time.Second, elapsed)
+		}
+	}
+}
+
+// companySearcher finds three companies for "novasco" and nothing for them,
+// so a run over ONAPI and DGII to depth 1 takes 8 steps
+var companySearcher = SearcherFunc(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+	result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Entity{}}
+	if domainType == domain.DomainTypeDGII {
+		result.Output = []domain.Register{}
+	}
+	if params.Query == "novasco" {
+		result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+			domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL", "NOVASCO TRADING SRL", "NOVASCO CAPITAL SRL"},
+		}
+	}
+	return result, nil
+})
+
+func TestExecuteDynamicPipelineSleepsOncePerStep(t *testing.T) {
+	interactor := NewDynamicPipelineInteractorWithSearcher(repositories.NewMemoryRepositoryFactory(), companySearcher)
+	clock := infra.NewFakeClock(time.Now())
+	interactor.clock, interactor.sleeper = clock, clock
+
+	// An hour between steps passes in virtual time only
+	began := time.Now()
+	result, err := interactor.ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:             "novasco",
+		MaxDepth:          1,
+		SkipDuplicates:    true,
+		DelayBetweenSteps: domain.Seconds(time.Hour),
+		AvailableDomains:  []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > 5*time.Second {
+		t.Errorf("expected the run not to wait its delays, took %s", elapsed)
+	}
+
+	sleeps := clock.Sleeps()
+	if result.TotalSteps != 8 || len(sleeps) != result.TotalSteps {
+		t.Fatalf("expected a pause after each of the 8 steps, got %d pauses for %d steps", len(sleeps), result.TotalSteps)
+	}
+	for _, d := range sleeps {
+		if d != time.Hour {
+			t.Errorf("expected one-hour pauses, got %s", d)
+		}
+	}
+}
+
+func TestExecuteDynamicPipelineStopsAtMaxDurationInVirtualTime(t *testing.T) {
+	interactor := NewDynamicPipelineInteractorWithSearcher(repositories.NewMemoryRepositoryFactory(), companySearcher)
+	clock := infra.NewFakeClock(time.Now())
+	interactor.clock, interactor.sleeper = clock, clock
+
+	// One step at a time, each followed by an hour: the fourth step would
+	// start past the duration of the run
+	result, err := interactor.ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:              "novasco",
+		MaxDepth:           1,
+		SkipDuplicates:     true,
+		MaxConcurrentSteps: 1,
+		MaxDuration:        150 * time.Minute,
+		DelayBetweenSteps:  domain.Seconds(time.Hour),
+		AvailableDomains:   []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+	if result.TotalSteps != 3 || result.StopReason != domain.StopReasonTimeLimit {
+		t.Errorf("expected 3 steps stopped by the time limit, got %d steps stopped by %q", result.TotalSteps, result.StopReason)
+	}
+}
+
+func TestExecuteDynamicPipelineJittersDelayBetweenSteps(t *testing.T) {
+	interactor := NewDynamicPipelineInteractorWithSearcher(repositories.NewMemoryRepositoryFactory(), companySearcher)
+	clock := infra.NewFakeClock(time.Now())
+	interactor.clock, interactor.sleeper = clock, clock
+
+	const delay = time.Second
+	for range 5 {
+		if _, err := interactor.ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+			Query:             "novasco",
+			MaxDepth:          1,
+			SkipDuplicates:    true,
+			DelayBetweenSteps: domain.Seconds(delay),
+			DelayJitter:       0.25,
+			AvailableDomains:  []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+		}); err != nil {
+			t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+		}
+	}
+
+	// Every step pauses, each for a duration of its own within the window
+	sleeps := clock.Sleeps()
+	if len(sleeps) != 5*8 {
+		t.Fatalf("expected a pause after each of the 40 steps, got %d", len(sleeps))
+	}
+	distinct := map[time.Duration]bool{}
+	for _, d := range sleeps {
+		if d < delay || d > delay+delay/4 {
+			t.Errorf("expected a pause within [%s, %s], got %s", delay, delay+delay/4, d)
+		}
+		distinct[d] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("expected the pauses to vary, got %v", sleeps)
+	}
+}
@@ -0,0 +1,47 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"testing"
+)
+
+func TestDryRunPlansWithoutSearchingOrStoring(t *testing.T) {
+	// Any query to the database is unexpected
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		t.Errorf("unexpected %s search for %q in a dry run", domainType, params.Query)
+		return &domain.DomainSearchResult{DomainType: domainType}, nil
+	})
+
+	budget := custom.NewBudget(0, 0)
+	ctx := custom.WithBudget(context.Background(), budget)
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(ctx, domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         2,
+		SkipDuplicates:   true,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+		DryRun:           true,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if result.TotalSteps != 2 || len(result.Steps) != 2 {
+		t.Fatalf("expected the 2 initial steps to be planned, got %+v", result.Steps)
+	}
+	for _, step := range result.Steps {
+		if step.Success || step.Output != nil {
+			t.Errorf("expected %s to be planned unexecuted, got %+v", step.DomainType, step)
+		}
+	}
+	if budget.Requests() != 0 {
+		t.Errorf("expected no outbound request, got %d", budget.Requests())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -2,114 +2,298 @@ package interactor
 
 import (
 	"context"
+	"fmt"
+	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
 	"insightful-intel/internal/module"
 	"insightful-intel/internal/repositories"
-	"log"
+	"log/slog"
+	"os"
 	"slices"
+	"strconv"
+	"sync"
 	"time"
-
-	"github.com/davecgh/go-spew/spew"
 )
 
 type DynamicPipelineInteractor struct {
-	repositories *repositories.RepositoryFactory
+	repositories repositories.Factory
+	searcher     Searcher
+	sourceHealth *module.SourceHealth
+	rateLimiter  *module.RateLimiter
+	executions   *ExecutionTracker
+	// clock measures the duration of the runs and sleeper waits the pause
+	// after each step, the system ones unless a test runs in virtual time
+	clock   infra.Clock
+	sleeper infra.Sleeper
 }
 
+// NewDynamicPipelineInteractor creates an interactor searching with
+// module.SearchDomain
 func NewDynamicPipelineInteractor(
-	repositoryFactory *repositories.RepositoryFactory,
+	repositoryFactory repositories.Factory,
+) *DynamicPipelineInteractor {
+	return NewDynamicPipelineInteractorWithSearcher(repositoryFactory, defaultSearcher)
+}
+
+// NewDynamicPipelineInteractorWithSearcher creates an interactor running its
+// domain searches with searcher
+func NewDynamicPipelineInteractorWithSearcher(
+	repositoryFactory repositories.Factory,
+	searcher Searcher,
 ) *DynamicPipelineInteractor {
 	return &DynamicPipelineInteractor{
 		repositories: repositoryFactory,
+		searcher:     searcher,
+		sourceHealth: module.DefaultSourceHealth,
+		rateLimiter:  module.DefaultRateLimiter,
+		executions:   DefaultExecutionTracker,
+		clock:        infra.SystemClock,
+		sleeper:      infra.SystemSleeper,
 	}
 }
 
-func (d *DynamicPipelineInteractor) ExecuteDynamicPipeline(ctx context.Context, query string, maxDepth int, skipDuplicates bool) (*domain.DynamicPipelineResult, error) {
+// WithClock returns a copy of the interactor measuring its runs with clock and
+// waiting the pause after each step with sleeper, for runs in virtual time
+func (d *DynamicPipelineInteractor) WithClock(clock infra.Clock, sleeper infra.Sleeper) *DynamicPipelineInteractor {
+	copied := *d
+	copied.clock = clock
+	copied.sleeper = sleeper
+	return &copied
+}
 
-	// Create a channel to receive pipeline steps
-	stepChan := make(chan domain.DynamicPipelineStep, 100)
-	done := make(chan bool)
+// Executions returns the tracker of the executions run by the interactor
+func (d *DynamicPipelineInteractor) Executions() *ExecutionTracker {
+	return d.executions
+}
+
+// ExecuteDynamicPipeline runs a pipeline over all domains with the default
+// settings and returns its result
+func (d *DynamicPipelineInteractor) ExecuteDynamicPipeline(ctx context.Context, query string, maxDepth int, skipDuplicates bool) (*domain.DynamicPipelineResult, error) {
+	return d.ExecuteDynamicPipelineWithConfig(ctx, DefaultPipelineConfig(ctx, query, maxDepth, skipDuplicates))
+}
 
-	// Available domains
-	availableDomains := domain.AllDomainTypes()
+// DefaultPipelineConfig returns the configuration ExecuteDynamicPipeline runs
+// a pipeline with, for callers that change some of its settings
+func DefaultPipelineConfig(ctx context.Context, query string, maxDepth int, skipDuplicates bool) domain.DynamicPipelineConfig {
+	// Runs started within a session are stored as part of it
+	sessionID, _ := infra.GetSessionID(ctx)
 
-	// Configure the dynamic pipeline
-	config := domain.DynamicPipelineConfig{
+	return domain.DynamicPipelineConfig{
 		Query:              query,
 		MaxDepth:           maxDepth,
 		MaxConcurrentSteps: 10,
-		DelayBetweenSteps:  2,
+		DelayBetweenSteps:  domain.DefaultDelayBetweenSteps,
 		SkipDuplicates:     skipDuplicates,
-		AvailableDomains:   availableDomains,
+		AvailableDomains:   module.AvailableDomainTypes(),
+		MaxRequests:        envInt64("PIPELINE_MAX_REQUESTS"),
+		MaxBytes:           envInt64("PIPELINE_MAX_BYTES"),
+		MaxTotalSteps:      int(envInt64("PIPELINE_MAX_TOTAL_STEPS")),
+		MaxDuration:        envDuration("PIPELINE_MAX_DURATION"),
+		SearchAttempts:     int(envInt64("PIPELINE_SEARCH_ATTEMPTS")),
+		// Search each company once per domain instead of once per name
+		CanonicalizeCompanies:  true,
+		SkipUnavailableSources: true,
+		SessionID:              sessionID,
+		MaxFanoutMultiplier:    domain.DefaultMaxFanoutMultiplier,
+		RecordEvents:           pipelineEventsFromEnv(),
+	}
+}
+
+// ExecuteDynamicPipelineWithConfig runs a pipeline with the given configuration,
+// draining the streamed steps, and returns the result or the error that stopped it
+func (d *DynamicPipelineInteractor) ExecuteDynamicPipelineWithConfig(ctx context.Context, config domain.DynamicPipelineConfig) (*domain.DynamicPipelineResult, error) {
+	seed, err := module.ValidateSeed(config.Query)
+	if err != nil {
+		return nil, err
+	}
+	config.Query = seed
+
+	// A dry run only plans the steps, so nothing is searched, stored or tracked
+	if config.DryRun {
+		return module.PlanDynamicPipeline(ctx, config.Query, config.AvailableDomains, config)
 	}
 
+	// Create a channel to receive pipeline steps
+	stepChan := make(chan domain.DynamicPipelineStep, 100)
+
+	// Written by the goroutine before it closes done, so they are safe to read
+	// once it is closed
+	var dynamicResult *domain.DynamicPipelineResult
+	var runErr error
+	done := make(chan struct{})
+
 	// Start pipeline execution in a goroutine
 	go func() {
-		defer close(stepChan)
 		defer close(done)
-
-		// Execute the dynamic pipeline with step callback
-		dynamicResult, err := d.executeDynamicPipelineWithCallback(ctx, query, availableDomains, config, stepChan)
-		if err != nil {
-			// Send error as a step
-			errorStep := domain.DynamicPipelineStep{
-				DomainType:      "ERROR",
-				SearchParameter: query,
-				Success:         false,
-				Error:           err,
-				Output:          nil,
-				Depth:           0,
-			}
-			stepChan <- errorStep
-			return
-		}
-
-		// Send final summary
-		summaryStep := domain.DynamicPipelineStep{
-			DomainType:      "SUMMARY",
-			SearchParameter: query,
-			Success:         true,
-			Error:           nil,
-			Output: map[string]interface{}{
-				"total_steps":       dynamicResult.TotalSteps,
-				"successful_steps":  dynamicResult.SuccessfulSteps,
-				"failed_steps":      dynamicResult.FailedSteps,
-				"max_depth_reached": dynamicResult.MaxDepthReached,
-				"query":             query,
-			},
-			Depth: dynamicResult.MaxDepthReached,
-		}
-		stepChan <- summaryStep
+		dynamicResult, runErr = d.ExecuteDynamicPipelineStream(ctx, config, stepChan)
 	}()
 
-	// Stream the steps as they come
-	stepCount := 0
-	for {
-		select {
-		case step, ok := <-stepChan:
-			if !ok {
-				return nil, nil
-			}
+	// Stream the steps as they come. The pipeline stops on its own when ctx is
+	// cancelled, so draining until the channel closes also collects the partial result
+	logger := infra.Logger(ctx)
+	for step := range stepChan {
+		infra.DebugDump("step", step)
+		logger.Debug("pipeline step",
+			slog.String("step_id", step.ID.String()),
+			slog.String("domain_type", string(step.DomainType)),
+			slog.String("search_parameter", step.SearchParameter),
+			slog.Bool("success", step.Success),
+			slog.Int("depth", step.Depth),
+		)
+	}
+	<-done
+
+	return dynamicResult, runErr
+}
 
-			stepCount++
+// ExecuteDynamicPipelineStream runs a pipeline with the given configuration,
+// sending each step on stepChan when it starts and when it completes, then a
+// SUMMARY step, or an ERROR step carrying the error that stopped the run.
+// stepChan is closed once the pipeline finishes. The completed steps are sent
+// in the order they are stored.
+func (d *DynamicPipelineInteractor) ExecuteDynamicPipelineStream(ctx context.Context, config domain.DynamicPipelineConfig, stepChan chan<- domain.DynamicPipelineStep) (*domain.DynamicPipelineResult, error) {
+	defer close(stepChan)
 
-			spew.Dump("step", step)
+	// Track the progress of executions started with an ID
+	executionID, _ := infra.GetExecutionID(ctx)
+	d.executions.Start(executionID)
 
-		case <-ctx.Done():
-			// Client disconnected
-			return nil, nil
+	var dynamicResult *domain.DynamicPipelineResult
+	seed, err := module.ValidateSeed(config.Query)
+	if err == nil {
+		config.Query = seed
+		dynamicResult, err = d.executeDynamicPipelineWithCallback(ctx, config.Query, config.AvailableDomains, config, stepChan)
+	}
+	d.executions.Finish(executionID, err)
+	if err != nil {
+		stepChan <- domain.DynamicPipelineStep{
+			DomainType:      "ERROR",
+			SearchParameter: config.Query,
+			Success:         false,
+			Error:           err,
 		}
+		return dynamicResult, err
 	}
 
-	return nil, nil
+	// Send final summary
+	stepChan <- domain.DynamicPipelineStep{
+		DomainType:      "SUMMARY",
+		SearchParameter: config.Query,
+		Success:         true,
+		Error:           nil,
+		Output: map[string]interface{}{
+			"total_steps":       dynamicResult.TotalSteps,
+			"successful_steps":  dynamicResult.SuccessfulSteps,
+			"failed_steps":      dynamicResult.FailedSteps,
+			"skipped_steps":     dynamicResult.SkippedSteps,
+			"max_depth_reached": dynamicResult.MaxDepthReached,
+			"stop_reason":       dynamicResult.StopReason,
+			"budget_limited":    dynamicResult.BudgetLimited(),
+			"requests_used":     dynamicResult.RequestsUsed,
+			"bytes_used":        dynamicResult.BytesUsed,
+			"visited_keywords":  dynamicResult.VisitedKeywords,
+			"notices":           dynamicResult.Notices,
+			"confidence":        dynamicResult.Confidence,
+			"query":             config.Query,
+		},
+		Depth: dynamicResult.MaxDepthReached,
+	}
+	return dynamicResult, nil
 }
 
 // executeDynamicPipelineWithCallback executes the dynamic pipeline and sends steps to a channel
 func (s *DynamicPipelineInteractor) executeDynamicPipelineWithCallback(ctx context.Context, query string, availableDomains []domain.DomainType, config domain.DynamicPipelineConfig, stepChan chan<- domain.DynamicPipelineStep) (*domain.DynamicPipelineResult, error) {
+	ctx, _ = s.withRunLimits(ctx, config)
+
+	if config.StopOnFirstHit {
+		return s.executeExistenceCheck(ctx, query, availableDomains, config, stepChan)
+	}
+
 	// Create a custom pipeline executor that streams steps
 	return s.executeStreamingPipeline(ctx, query, availableDomains, config, stepChan)
 }
 
+// withRunLimits returns ctx with the rate limits of config applied to this run
+// only and the budget its outbound requests are counted against
+func (d *DynamicPipelineInteractor) withRunLimits(ctx context.Context, config domain.DynamicPipelineConfig) (context.Context, *custom.Budget) {
+	if d.rateLimiter != nil {
+		ctx = module.WithRateLimiter(ctx, d.rateLimiter.WithLimits(config.RateLimits))
+	}
+	budget := custom.NewBudget(config.MaxRequests, config.MaxBytes)
+	return custom.WithBudget(ctx, budget), budget
+}
+
+// searchStep runs the search of a step, making it again on transient errors
+// as many times as config allows
+func (d *DynamicPipelineInteractor) searchStep(ctx context.Context, config domain.DynamicPipelineConfig, step domain.DynamicPipelineStep) (*domain.DomainSearchResult, error) {
+	return d.searcher.Search(ctx, step.DomainType, domain.DomainSearchParams{Query: step.SearchParameter, Attempts: config.SearchAttempts})
+}
+
+// executeExistenceCheck runs a pipeline as an existence check: the
+// authoritative domains are searched at once and the run ends on the first
+// strong hit, stored and sent as its only step
+func (d *DynamicPipelineInteractor) executeExistenceCheck(ctx context.Context, query string, availableDomains []domain.DomainType, config domain.DynamicPipelineConfig, stepChan chan<- domain.DynamicPipelineStep) (*domain.DynamicPipelineResult, error) {
+	pipeline, err := module.CreateDynamicPipeline(ctx, query, availableDomains, config)
+	if err != nil {
+		return nil, err
+	}
+	ctx = infra.SetPipelineID(ctx, pipeline.ID.String())
+
+	pipelineHeader := *pipeline
+	pipelineHeader.Steps = nil
+	if _, err := d.repositories.GetPipelineRepository().CreateDynamicPipelineResult(ctx, &pipelineHeader); err != nil {
+		return nil, err
+	}
+
+	hit, err := module.CheckExistenceWith(ctx, d.searcher.Search, query, module.AuthoritativeDomains(availableDomains))
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline.Steps = make([]domain.DynamicPipelineStep, 0, 1)
+	if hit != nil {
+		step := domain.DynamicPipelineStep{
+			PipelineID:          pipeline.ID,
+			DomainType:          hit.DomainType,
+			SearchParameter:     query,
+			Category:            domain.KeywordCategoryCompanyName,
+			Keywords:            []string{query},
+			Success:             true,
+			Output:              hit.Output,
+			KeywordsPerCategory: hit.KeywordsPerCategory,
+		}
+		err := d.repositories.WithTx(ctx, func(repos repositories.Factory) error {
+			if err := repos.GetPipelineRepository().CreateDynamicPipelineStep(ctx, &step); err != nil {
+				return err
+			}
+			hit.PipelineStepsID = step.ID
+			created, err := repos.GetPipelineRepository().CreateDomainSearchResult(ctx, hit)
+			if err != nil {
+				return err
+			}
+			_, err = d.persistDomainOutput(ctx, repos, created)
+			return err
+		})
+		if err != nil {
+			infra.Logger(ctx).Error("failed to store existence check hit", slog.String("domain_type", string(step.DomainType)), slog.Any("error", err))
+			return nil, err
+		}
+
+		stepChan <- step
+		pipeline.Steps = append(pipeline.Steps, step)
+		pipeline.SuccessfulSteps = 1
+	}
+	pipeline.TotalSteps = len(pipeline.Steps)
+	pipeline.Config = config
+
+	if err := d.repositories.GetPipelineRepository().UpdateDynamicPipelineResult(ctx, pipeline); err != nil {
+		infra.Logger(ctx).Error("failed to update pipeline result", slog.Any("error", err))
+		return nil, err
+	}
+	return pipeline, nil
+}
+
 // executeStreamingPipeline executes the pipeline with real-time streaming
 func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context, query string, availableDomains []domain.DomainType, config domain.DynamicPipelineConfig, stepChan chan<- domain.DynamicPipelineStep) (*domain.DynamicPipelineResult, error) {
 	// Create the initial pipeline steps
@@ -118,7 +302,13 @@ func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context
 		return nil, err
 	}
 
-	_, err = d.repositories.GetPipelineRepository().CreateDynamicPipelineResult(ctx, createdPipelineResult)
+	ctx = infra.SetPipelineID(ctx, createdPipelineResult.ID.String())
+	executionID, _ := infra.GetExecutionID(ctx)
+
+	// Store the pipeline without its initial steps, which are stored as they run
+	pipelineHeader := *createdPipelineResult
+	pipelineHeader.Steps = nil
+	_, err = d.repositories.GetPipelineRepository().CreateDynamicPipelineResult(ctx, &pipelineHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -126,9 +316,23 @@ func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context
 	// Get initial steps from the result
 	initialSteps := createdPipelineResult.Steps
 
+	d.recordEvent(ctx, config, domain.PipelineEvent{
+		PipelineID: createdPipelineResult.ID,
+		Type:       domain.PipelineEventStarted,
+		Message:    fmt.Sprintf("pipeline started for %q", query),
+		Data: map[string]any{
+			"query":         query,
+			"domains":       availableDomains,
+			"max_depth":     config.MaxDepth,
+			"initial_steps": len(initialSteps),
+		},
+	})
+
 	totalSteps := 0
 	successfulSteps := 0
 	failedSteps := 0
+	skippedSteps := 0
+	skippedRecords := 0
 	maxDepthReached := 0
 
 	// Track searched keywords per domain to avoid duplicates
@@ -137,18 +341,114 @@ func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context
 		searchedKeywordsPerDomain[domainType] = make(map[string]bool)
 	}
 
+	// Track every keyword searched in each domain, at any depth, so keyword
+	// graphs that point back at an earlier company do not loop
+	visited := make(map[domain.VisitedKeyword]bool)
+	for _, step := range initialSteps {
+		visited[domain.NewVisitedKeyword(step.DomainType, step.SearchParameter)] = true
+	}
+
 	// Process steps with streaming
 	processedSteps := make([]domain.DynamicPipelineStep, 0)
 
-	// Create a queue for steps to process
-	stepQueue := make([]domain.DynamicPipelineStep, len(initialSteps))
-	copy(stepQueue, initialSteps)
+	// Queue the steps to process, the strongest leads first
+	stepQueue := domain.NewStepQueue(config)
+	stepQueue.Push(initialSteps...)
+
+	// The outbound requests of the run are counted against the budget
+	// withRunLimits put in its context
+	budget := custom.BudgetFromContext(ctx)
+
+	// Bound the steps and the time of the run. dispatched counts the searches
+	// dispatched so far and is only touched by the dispatch loop.
+	started := d.clock.Now()
+	dispatched := 0
+
+	// limitReached returns the reason to stop dispatching steps when a limit
+	// of the run has been reached, with a message and the usage that reached it
+	limitReached := func() (string, string, map[string]any) {
+		switch {
+		case budget.Exhausted():
+			return domain.StopReasonBudgetExhausted, "pipeline stopped: outbound budget exhausted", map[string]any{
+				"requests": budget.Requests(),
+				"bytes":    budget.Bytes(),
+			}
+		case config.MaxTotalSteps > 0 && dispatched >= config.MaxTotalSteps:
+			return domain.StopReasonStepLimit, "pipeline stopped: step limit reached", map[string]any{
+				"steps":           dispatched,
+				"max_total_steps": config.MaxTotalSteps,
+			}
+		case config.MaxDuration > 0 && d.clock.Now().Sub(started) >= config.MaxDuration:
+			return domain.StopReasonTimeLimit, "pipeline stopped: time limit reached", map[string]any{
+				"elapsed":      d.clock.Now().Sub(started).String(),
+				"max_duration": config.MaxDuration.String(),
+			}
+		}
+		return "", "", nil
+	}
+
+	// Decide how deep each branch goes
+	branches := domain.NewBranchYield(config)
+
+	// Merge the names of the same company found across domains
+	var companies *domain.CompanyCanonicalizer
+	if config.CanonicalizeCompanies {
+		companies = domain.NewCompanyCanonicalizer(config.CompanySimilarity)
+	}
+
+	// summarize records the counters on the pipeline result, for the final result
+	// as well as for a partial one when the context is cancelled
+	summarize := func() {
+		createdPipelineResult.TotalSteps = totalSteps
+		createdPipelineResult.SuccessfulSteps = successfulSteps
+		createdPipelineResult.FailedSteps = failedSteps
+		createdPipelineResult.SkippedSteps = skippedSteps
+		createdPipelineResult.SkippedRecords = skippedRecords
+		createdPipelineResult.MaxDepthReached = maxDepthReached
+		createdPipelineResult.Config = config
+		createdPipelineResult.RequestsUsed = budget.Requests()
+		createdPipelineResult.BytesUsed = budget.Bytes()
+		createdPipelineResult.VisitedKeywords = len(visited)
+		createdPipelineResult.Confidence = domain.ScoreConfidence(processedSteps, config.ConfidenceWeights)
+		if companies != nil {
+			createdPipelineResult.Companies = companies.Companies()
+		}
+	}
+
+	// Up to MaxConcurrentSteps steps run at once. mu guards the queue, the
+	// counters, searchedKeywordsPerDomain, visited, branches and companies, which the workers update
+	// as their steps complete
+	sem := make(chan struct{}, max(config.MaxConcurrentSteps, 1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var stepErr error
 
-	for len(stepQueue) > 0 {
-		// Get next step from queue
-		step := stepQueue[0]
-		stepQueue = stepQueue[1:]
+	// orderMu keeps the completed steps sent in the order they are stored, so
+	// a client resuming a stream from the position of the last step it got
+	// finds the steps it missed past that position
+	var orderMu sync.Mutex
 
+	// batchSize is the number of steps of the batch being dispatched, which
+	// together with the queued steps bounds the fan-out of each step
+	batchSize := 0
+
+	// Past the queue limit, the steps that would run last are spilled to the
+	// database; spilled counts them until they are queued again
+	pipelineRepo := d.repositories.GetPipelineRepository()
+	queueLimit := config.QueueLimit()
+	spilled := 0
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stepErr == nil {
+			stepErr = err
+		}
+	}
+
+	// runStep executes a step, records it and queues the steps generated from
+	// its keywords
+	runStep := func(step domain.DynamicPipelineStep) {
 		step.PipelineID = createdPipelineResult.ID
 
 		// Send step start event
@@ -158,7 +458,7 @@ func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context
 		stepChan <- startStep
 
 		// Execute the step
-		result, err := module.SearchDomain(step.DomainType, domain.DomainSearchParams{Query: step.SearchParameter})
+		result, err := d.searchStep(ctx, config, step)
 
 		// Update step with results
 		step.Success = err == nil
@@ -166,92 +466,48 @@ func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context
 		if result != nil {
 			step.Output = result.Output
 			step.KeywordsPerCategory = result.KeywordsPerCategory
+		} else {
+			result = &domain.DomainSearchResult{DomainType: step.DomainType, SearchParameter: step.SearchParameter, Error: err}
 		}
 
-		err = d.repositories.GetPipelineRepository().CreateDynamicPipelineStep(ctx, &step)
-		if err != nil {
-			log.Println("Error creating pipeline step ----> ", err)
-			return nil, err
-		}
+		// The step, its search result and its records are stored together, so a
+		// failure midway leaves none of them behind
+		orderMu.Lock()
+		skipped := 0
+		err = d.repositories.WithTx(ctx, func(repos repositories.Factory) error {
+			if err := repos.GetPipelineRepository().CreateDynamicPipelineStep(ctx, &step); err != nil {
+				infra.Logger(ctx).Error("failed to create pipeline step", slog.String("domain_type", string(step.DomainType)), slog.Any("error", err))
+				return err
+			}
 
-		result.PipelineStepsID = step.ID
+			result.PipelineStepsID = step.ID
+
+			created, err := repos.GetPipelineRepository().CreateDomainSearchResult(ctx, result)
+			if err != nil {
+				infra.Logger(infra.SetStepID(ctx, step.ID.String())).Error("failed to create domain search result", slog.Any("error", err))
+				return err
+			}
 
-		created, err := d.repositories.GetPipelineRepository().CreateDomainSearchResult(ctx, result)
+			skipped, err = d.persistDomainOutput(ctx, repos, created)
+			return err
+		})
 		if err != nil {
-			log.Println("Error creating pipeline CreateDomainSearchResult ----> ", err)
-			return nil, err
+			orderMu.Unlock()
+			fail(err)
+			return
 		}
 
-		switch step.DomainType {
-		case domain.DomainTypeONAPI:
-			entities, ok := created.Output.([]domain.Entity)
-			if !ok {
-				log.Println("Error casting result output to []domain.Entity ----> ", err)
-				return nil, err
-			}
-			for _, entity := range entities {
-				entity.DomainSearchResultID = created.ID
-				if err := d.repositories.GetOnapiRepository().Create(ctx, entity); err != nil {
-					log.Println("Error creating onapi repository ----> ", err)
-					return nil, err
-				}
-			}
-		case domain.DomainTypeSCJ:
-			cases, ok := created.Output.([]domain.ScjCase)
-			if !ok {
-				log.Println("Error casting result output to []domain.ScjCase ", err)
-				return nil, err
-			}
-			for _, c := range cases {
-				c.DomainSearchResultID = created.ID
-				if err := d.repositories.GetScjRepository().Create(ctx, c); err != nil {
-					log.Println("Error creating scj repository ", err, c)
-					return nil, err
-				}
-			}
-		case domain.DomainTypeDGII:
-			results, ok := created.Output.([]domain.Register)
-			if !ok {
-				log.Println("Error casting result output to []domain.Register ", err)
-				return nil, err
-			}
-			for _, result := range results {
-				result.DomainSearchResultID = created.ID
-				if err := d.repositories.GetDgiiRepository().Create(ctx, result); err != nil {
-					log.Println("Error creating dgii repository ", err)
-					return nil, err
-				}
-			}
-		case domain.DomainTypePGR:
-			results, ok := created.Output.([]domain.PGRNews)
-			if !ok {
-				log.Println("Error casting result output to []domain.PGRNews ", err)
-				return nil, err
-			}
-			for _, result := range results {
-				result.DomainSearchResultID = created.ID
-				if err := d.repositories.GetPgrRepository().Create(ctx, result); err != nil {
-					log.Println("Error creating pgr repository ", err)
-					return nil, err
-				}
-			}
-		case domain.DomainTypeGoogleDorking, domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
-			results, ok := created.Output.([]domain.GoogleDorkingResult)
-			if !ok {
-				log.Println("Error casting result output to []domain.GoogleDorkingResult ", err)
-				return nil, err
-			}
-			for _, result := range results {
-				result.DomainSearchResultID = created.ID
-				if err := d.repositories.GetDockingRepository().Create(ctx, result); err != nil {
-					log.Println("Error creating docking repository ", err)
-					return nil, err
-				}
-			}
-		}
+		stepCtx := infra.SetStepID(ctx, step.ID.String())
+		infra.Logger(stepCtx).Debug("pipeline step searched",
+			slog.String("domain_type", string(step.DomainType)),
+			slog.Bool("success", step.Success),
+			slog.Int("depth", step.Depth),
+		)
 
 		// Update counters
+		mu.Lock()
 		totalSteps++
+		skippedRecords += skipped
 		if step.Success {
 			successfulSteps++
 		} else {
@@ -261,41 +517,356 @@ func (d *DynamicPipelineInteractor) executeStreamingPipeline(ctx context.Context
 		if step.Depth > maxDepthReached {
 			maxDepthReached = step.Depth
 		}
+		processedSteps = append(processedSteps, step)
+		stepNumber := totalSteps
+		mu.Unlock()
+
+		d.executions.RecordStep(executionID, step)
+		d.recordEvent(ctx, config, stepEvent(createdPipelineResult.ID, stepNumber, step))
 
 		// Send completed step
 		stepChan <- step
-		processedSteps = append(processedSteps, step)
+		orderMu.Unlock()
 
 		// Add delay between steps for better streaming experience
-		time.Sleep(time.Duration(config.DelayBetweenSteps) * time.Second)
+		if d.sleeper.Sleep(ctx, config.StepDelay()) != nil {
+			return
+		}
+
+		mu.Lock()
+
+		// The domain that found a company has already searched it under every alias
+		if companies != nil && step.Success {
+			for _, company := range companies.AddOutput(step.Output) {
+				searchedKeywordsPerDomain[step.DomainType][company.Key()] = true
+			}
+		}
+
+		// Generate new steps from keywords if the branch goes deeper
+		var notice *domain.PipelineNotice
+		if step.Success && step.Output != nil && branches.Expand(step) {
+			newSteps := d.generateNextSteps(step, availableDomains, searchedKeywordsPerDomain, visited, companies, config)
+			newSteps, notice = capFanout(step, newSteps, stepQueue.Len()+spilled+batchSize, config, searchedKeywordsPerDomain, visited)
+			if notice != nil {
+				createdPipelineResult.Notices = append(createdPipelineResult.Notices, *notice)
+			}
+			stepQueue.Push(newSteps...)
+		}
+		overflow := stepQueue.Overflow(queueLimit)
+		spilled += len(overflow)
+		mu.Unlock()
+
+		if err := pipelineRepo.SpillQueuedSteps(ctx, createdPipelineResult.ID, overflow); err != nil {
+			infra.Logger(stepCtx).Error("failed to spill queued steps", slog.Int("steps", len(overflow)), slog.Any("error", err))
+			fail(err)
+			return
+		}
 
-		// Generate new steps from keywords if not at max depth
-		if step.Depth < config.MaxDepth && step.Success && step.Output != nil {
-			newSteps := d.generateNextSteps(step, availableDomains, searchedKeywordsPerDomain, config)
-			stepQueue = append(stepQueue, newSteps...)
+		if notice != nil {
+			infra.Logger(stepCtx).Warn("pipeline step fan-out capped",
+				slog.Int("projected", notice.Projected),
+				slog.Int("kept", notice.Kept),
+			)
+			d.recordEvent(ctx, config, domain.PipelineEvent{
+				PipelineID: createdPipelineResult.ID,
+				StepID:     step.ID,
+				Type:       domain.PipelineEventFanoutCapped,
+				Message:    fmt.Sprintf("fan-out of %s search for %q capped to %d of %d steps", step.DomainType, step.SearchParameter, notice.Kept, notice.Projected),
+				Data: map[string]any{
+					"projected": notice.Projected,
+					"kept":      notice.Kept,
+				},
+			})
+			stepChan <- domain.DynamicPipelineStep{
+				DomainType:      "NOTICE",
+				SearchParameter: step.SearchParameter,
+				Success:         true,
+				Output:          *notice,
+				Depth:           step.Depth,
+			}
 		}
 	}
 
+	// Steps are dispatched level by level: the steps generated by a batch are
+	// queued for the next one, which starts once the whole batch has drained.
+	// Within a batch the steps run in priority order. Depth-first, a batch
+	// takes only the MaxConcurrentSteps deepest steps, so the steps they find
+	// run before their siblings.
+dispatch:
+	for {
+		// Queue again the spilled steps the queue has room for. No step is
+		// running, so the queue is not locked while they are read.
+		if room := queueLimit - stepQueue.Len(); spilled > 0 && room > 0 {
+			drained, err := pipelineRepo.DrainQueuedSteps(ctx, createdPipelineResult.ID, room, config.DepthFirst())
+			if err != nil {
+				fail(err)
+				break
+			}
+			spilled -= len(drained)
+			if len(drained) == 0 {
+				spilled = 0
+			}
+			stepQueue.Push(drained...)
+		}
+
+		mu.Lock()
+		size := stepQueue.Len()
+		if n := max(config.MaxConcurrentSteps, 1); config.DepthFirst() && size > n {
+			size = n
+		}
+		batch := make([]domain.DynamicPipelineStep, 0, size)
+		for range size {
+			step, _ := stepQueue.Pop()
+			batch = append(batch, step)
+		}
+		batchSize = len(batch)
+		mu.Unlock()
+
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, step := range batch {
+			if ctx.Err() != nil {
+				break dispatch
+			}
+
+			// Record the steps of sources known to be down without searching them
+			if config.SkipUnavailableSources && !d.sourceHealth.IsAvailable(step.DomainType) {
+				orderMu.Lock()
+				err := d.skipStep(ctx, createdPipelineResult.ID, step, domain.SkipReasonSourceUnavailable, stepChan)
+				orderMu.Unlock()
+				if err != nil {
+					fail(err)
+					break dispatch
+				}
+				mu.Lock()
+				skippedSteps++
+				mu.Unlock()
+				d.executions.RecordStep(executionID, domain.DynamicPipelineStep{DomainType: step.DomainType, SkipReason: domain.SkipReasonSourceUnavailable})
+				d.recordEvent(ctx, config, domain.PipelineEvent{
+					PipelineID: createdPipelineResult.ID,
+					Type:       domain.PipelineEventSourceBlocked,
+					Message:    fmt.Sprintf("source %s unavailable, %q not searched", step.DomainType, step.SearchParameter),
+					Data: map[string]any{
+						"domain_type":      step.DomainType,
+						"search_parameter": step.SearchParameter,
+						"depth":            step.Depth,
+					},
+				})
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case sem <- struct{}{}:
+			}
+
+			mu.Lock()
+			failed := stepErr != nil
+			mu.Unlock()
+			if failed {
+				<-sem
+				break dispatch
+			}
+
+			if reason, message, usage := limitReached(); reason != "" {
+				<-sem
+				infra.Logger(ctx).Warn(message, slog.Any("usage", usage))
+				createdPipelineResult.StopReason = reason
+				d.recordEvent(ctx, config, domain.PipelineEvent{
+					PipelineID: createdPipelineResult.ID,
+					Type:       domain.PipelineEventBudgetExhausted,
+					Message:    message,
+					Data:       usage,
+				})
+				break dispatch
+			}
+			dispatched++
+
+			wg.Add(1)
+			go func(step domain.DynamicPipelineStep) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				runStep(step)
+			}(step)
+		}
+
+		wg.Wait()
+	}
+
+	// Wait for the steps still running when dispatching stopped
+	wg.Wait()
+
+	// Drop the spilled steps a stopped pipeline will not run
+	if spilled > 0 {
+		if err := pipelineRepo.ClearQueuedSteps(context.WithoutCancel(ctx), createdPipelineResult.ID); err != nil {
+			infra.Logger(ctx).Error("failed to clear spilled steps", slog.Any("error", err))
+		}
+	}
+
+	if stepErr != nil {
+		d.recordEvent(ctx, config, domain.PipelineEvent{
+			PipelineID: createdPipelineResult.ID,
+			Type:       domain.PipelineEventFailed,
+			Message:    fmt.Sprintf("pipeline failed: %v", stepErr),
+			Data:       map[string]any{"error": stepErr.Error()},
+		})
+		return nil, stepErr
+	}
+
+	if ctx.Err() != nil {
+		summarize()
+		d.recordEvent(ctx, config, endEvent(createdPipelineResult, domain.PipelineEventCancelled, "pipeline cancelled"))
+		return d.cancelPipeline(ctx, createdPipelineResult)
+	}
+
 	// Create final result
-	createdPipelineResult.TotalSteps = totalSteps
-	createdPipelineResult.SuccessfulSteps = successfulSteps
-	createdPipelineResult.FailedSteps = failedSteps
-	createdPipelineResult.MaxDepthReached = maxDepthReached
-	createdPipelineResult.Config = config
+	summarize()
+	d.recordEvent(ctx, config, endEvent(createdPipelineResult, domain.PipelineEventCompleted, "pipeline completed"))
 
 	err = d.repositories.GetPipelineRepository().UpdateDynamicPipelineResult(ctx, createdPipelineResult)
 	if err != nil {
-		log.Println("Error creating pipeline result ----> ", err)
+		infra.Logger(ctx).Error("failed to update pipeline result", slog.Any("error", err))
 		return nil, err
 	}
 
 	return createdPipelineResult, nil
 }
 
+// skipStep records a step that is not searched, with the reason it was skipped
+func (d *DynamicPipelineInteractor) skipStep(ctx context.Context, pipelineID domain.ID, step domain.DynamicPipelineStep, reason string, stepChan chan<- domain.DynamicPipelineStep) error {
+	step.PipelineID = pipelineID
+	step.Success = false
+	step.SkipReason = reason
+
+	if err := d.repositories.GetPipelineRepository().CreateDynamicPipelineStep(ctx, &step); err != nil {
+		infra.Logger(ctx).Error("failed to create skipped pipeline step", slog.String("domain_type", string(step.DomainType)), slog.Any("error", err))
+		return err
+	}
+
+	stepChan <- step
+	return nil
+}
+
+// cancelPipeline stores the counters of a pipeline stopped by its context and
+// returns the partial result along with the context error
+func (d *DynamicPipelineInteractor) cancelPipeline(ctx context.Context, partial *domain.DynamicPipelineResult) (*domain.DynamicPipelineResult, error) {
+	// The request context is already done, so the update must not depend on it
+	err := d.repositories.GetPipelineRepository().UpdateDynamicPipelineResult(context.WithoutCancel(ctx), partial)
+	if err != nil {
+		infra.Logger(ctx).Error("failed to update cancelled pipeline result", slog.Any("error", err))
+	}
+
+	return partial, ctx.Err()
+}
+
+// persistDomainOutput stores the records of a domain search result in the
+// repository of its domain, taken from repos. Records their connector would
+// not store are left out, and their number returned.
+func (d *DynamicPipelineInteractor) persistDomainOutput(ctx context.Context, repos repositories.Factory, created *domain.DomainSearchResult) (int, error) {
+	if created.Output == nil {
+		return 0, nil
+	}
+
+	skipped := 0
+	switch created.DomainType {
+	case domain.DomainTypeONAPI:
+		entities, ok := created.Output.([]domain.Entity)
+		if !ok {
+			return 0, fmt.Errorf("unexpected output type %T, want []domain.Entity", created.Output)
+		}
+		entities, skipped = persistable(module.NewOnapiDomain(), entities)
+		for _, entity := range entities {
+			entity.DomainSearchResultID = created.ID
+			if err := repos.GetOnapiRepository().Upsert(ctx, entity); err != nil {
+				infra.Logger(ctx).Error("failed to store onapi entity", slog.Any("error", err))
+				return skipped, err
+			}
+		}
+	case domain.DomainTypeSCJ:
+		cases, ok := created.Output.([]domain.ScjCase)
+		if !ok {
+			return 0, fmt.Errorf("unexpected output type %T, want []domain.ScjCase", created.Output)
+		}
+		cases, skipped = persistable(module.NewScjDomain(), cases)
+		for _, c := range cases {
+			c.DomainSearchResultID = created.ID
+			if err := repos.GetScjRepository().Create(ctx, c); err != nil {
+				infra.Logger(ctx).Error("failed to store scj case", slog.Int("id_expediente", c.IDExpediente), slog.Any("error", err))
+				return skipped, err
+			}
+		}
+	case domain.DomainTypeDGII:
+		results, ok := created.Output.([]domain.Register)
+		if !ok {
+			return 0, fmt.Errorf("unexpected output type %T, want []domain.Register", created.Output)
+		}
+		results, skipped = persistable(module.NewDgiiDomain(), results)
+		for _, result := range results {
+			result.DomainSearchResultID = created.ID
+			if _, err := repos.GetDgiiRepository().Upsert(ctx, result); err != nil {
+				infra.Logger(ctx).Error("failed to store dgii register", slog.String("rnc", result.RNC), slog.Any("error", err))
+				return skipped, err
+			}
+		}
+	case domain.DomainTypePGR:
+		results, ok := created.Output.([]domain.PGRNews)
+		if !ok {
+			return 0, fmt.Errorf("unexpected output type %T, want []domain.PGRNews", created.Output)
+		}
+		results, skipped = persistable(module.NewPgrDomain(), results)
+		for _, result := range results {
+			result.DomainSearchResultID = created.ID
+			if err := repos.GetPgrRepository().Create(ctx, result); err != nil {
+				infra.Logger(ctx).Error("failed to store pgr news", slog.Any("error", err))
+				return skipped, err
+			}
+		}
+	case domain.DomainTypeGoogleDorking, domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
+		results, ok := created.Output.([]domain.GoogleDorkingResult)
+		if !ok {
+			return 0, fmt.Errorf("unexpected output type %T, want []domain.GoogleDorkingResult", created.Output)
+		}
+		dorking := module.NewGoogleDorkingDomain()
+		results, skipped = persistable[domain.GoogleDorkingResult](&dorking, results)
+		for _, result := range results {
+			result.DomainSearchResultID = created.ID
+			if err := repos.GetDockingRepository().Create(ctx, result); err != nil {
+				infra.Logger(ctx).Error("failed to store docking result", slog.Any("error", err))
+				return skipped, err
+			}
+		}
+	}
+
+	if skipped > 0 {
+		infra.Logger(ctx).Warn("skipped records rejected by their connector",
+			slog.String("domain_type", string(created.DomainType)),
+			slog.Int("skipped", skipped),
+		)
+	}
+	return skipped, nil
+}
+
+// persistable returns the records connector would store and how many it
+// would not
+func persistable[T any](connector domain.DomainConnector[T], records []T) ([]T, int) {
+	kept := make([]T, 0, len(records))
+	for _, record := range records {
+		if domain.ShouldPersist(connector, record) {
+			kept = append(kept, record)
+		}
+	}
+	return kept, len(records) - len(kept)
+}
+
 func (*DynamicPipelineInteractor) generateNextSteps(
 	completedStep domain.DynamicPipelineStep,
 	availableDomains []domain.DomainType, searchedKeywordsPerDomain map[domain.DomainType]map[string]bool,
-	_ domain.DynamicPipelineConfig,
+	visited map[domain.VisitedKeyword]bool,
+	companies *domain.CompanyCanonicalizer,
+	config domain.DynamicPipelineConfig,
 ) []domain.DynamicPipelineStep {
 	var newSteps []domain.DynamicPipelineStep
 
@@ -312,6 +883,7 @@ func (*DynamicPipelineInteractor) generateNextSteps(
 			if len(keyword) < 3 {
 				continue
 			}
+			key := searchKey(category, keyword)
 			// Generate steps for each available domain
 			for _, domainType := range availableDomains {
 				searchableCategories := GetSearchableKeywordCategories(domainType)
@@ -320,7 +892,7 @@ func (*DynamicPipelineInteractor) generateNextSteps(
 				}
 
 				// Skip if already searched this keyword for this domain
-				if searchedKeywordsPerDomain[domainType][keyword] {
+				if searchedKeywordsPerDomain[domainType][key] {
 					continue
 				}
 
@@ -329,8 +901,30 @@ func (*DynamicPipelineInteractor) generateNextSteps(
 					continue
 				}
 
+				// Skip if the keyword was searched in this domain at any depth
+				visitedKey := domain.NewVisitedKeyword(domainType, key)
+				if visited[visitedKey] {
+					continue
+				}
+
+				// Skip if the company was already searched under another alias
+				if category == domain.KeywordCategoryCompanyName && companies != nil {
+					if company := companies.Resolve(keyword); company != nil {
+						if searchedKeywordsPerDomain[domainType][company.Key()] {
+							continue
+						}
+						searchedKeywordsPerDomain[domainType][company.Key()] = true
+					}
+				}
+
+				// Skip the steps left out by the sampling of the run
+				if !config.SampleStep(domainType, keyword) {
+					continue
+				}
+
 				// Mark as searched
-				searchedKeywordsPerDomain[domainType][keyword] = true
+				searchedKeywordsPerDomain[domainType][key] = true
+				visited[visitedKey] = true
 
 				// Create new step
 				newStep := domain.DynamicPipelineStep{
@@ -343,6 +937,7 @@ func (*DynamicPipelineInteractor) generateNextSteps(
 					Output:              nil,
 					KeywordsPerCategory: nil,
 					Depth:               completedStep.Depth + 1,
+					ParentStepID:        completedStep.ID,
 				}
 
 				newSteps = append(newSteps, newStep)
@@ -353,6 +948,51 @@ func (*DynamicPipelineInteractor) generateNextSteps(
 	return newSteps
 }
 
+// searchKey is the form a keyword is recorded as searched in: the canonical
+// name of companies, so the forms of a company name found across domains make
+// a single step, and the keyword itself otherwise
+func searchKey(category domain.KeywordCategory, keyword string) string {
+	if category == domain.KeywordCategoryCompanyName {
+		return domain.CanonicalizeCompanyName(keyword)
+	}
+	return keyword
+}
+
+// capFanout applies the fan-out guard of the configuration to the steps
+// generated by a step when queued steps are waiting. The steps it drops are
+// unmarked as searched so they can still be reached from another step. It
+// returns the steps to queue and, when they were capped, the notice recording it.
+func capFanout(
+	step domain.DynamicPipelineStep,
+	newSteps []domain.DynamicPipelineStep,
+	queued int,
+	config domain.DynamicPipelineConfig,
+	searchedKeywordsPerDomain map[domain.DomainType]map[string]bool,
+	visited map[domain.VisitedKeyword]bool,
+) ([]domain.DynamicPipelineStep, *domain.PipelineNotice) {
+	limit := config.FanoutLimit(queued)
+	if limit == 0 || len(newSteps) <= limit {
+		return newSteps, nil
+	}
+
+	kept, dropped := domain.CapFanout(newSteps, limit, config.Seed)
+	for _, droppedStep := range dropped {
+		key := searchKey(droppedStep.Category, droppedStep.SearchParameter)
+		delete(searchedKeywordsPerDomain[droppedStep.DomainType], key)
+		delete(visited, domain.NewVisitedKeyword(droppedStep.DomainType, key))
+	}
+
+	return kept, &domain.PipelineNotice{
+		Type:            domain.NoticeFanoutCapped,
+		StepID:          step.ID,
+		DomainType:      step.DomainType,
+		SearchParameter: step.SearchParameter,
+		Depth:           step.Depth,
+		Projected:       len(newSteps),
+		Kept:            len(kept),
+	}
+}
+
 func GetSearchableKeywordCategories(domainEntities domain.DomainType) []domain.KeywordCategory {
 	switch domainEntities {
 	case domain.DomainTypeONAPI:
@@ -363,9 +1003,37 @@ func GetSearchableKeywordCategories(domainEntities domain.DomainType) []domain.K
 		return module.GetSearchableKeywordCategories(&module.Dgii{})
 	case domain.DomainTypePGR:
 		return module.GetSearchableKeywordCategories(&module.Pgr{})
+	case domain.DomainTypeCamara:
+		return module.GetSearchableKeywordCategories(&module.Camara{})
+	case domain.DomainTypeJCE:
+		return module.GetSearchableKeywordCategories(&module.Jce{})
+	case domain.DomainTypeSanctions:
+		return module.GetSearchableKeywordCategories(&module.Sanctions{})
+	case domain.DomainTypeSIB:
+		return module.GetSearchableKeywordCategories(&module.Sib{})
 	case domain.DomainTypeGoogleDorking, domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
 		return module.GetSearchableKeywordCategories(&module.GoogleDorking{})
 	default:
 		return []domain.KeywordCategory{}
 	}
 }
+
+// envInt64 reads a numeric setting from the environment, returning zero when it
+// is unset or invalid
+func envInt64(key string) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// envDuration reads a duration setting from the environment, returning zero
+// when it is unset or invalid
+func envDuration(key string) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,565 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/module"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func stubSearchDomain(t *testing.T, stub func(context.Context, domain.DomainType, domain.DomainSearchParams) (*domain.DomainSearchResult, error)) {
+	t.Helper()
+
+	original := defaultSearcher
+	t.Cleanup(func() { defaultSearcher = original })
+	defaultSearcher = SearcherFunc(stub)
+}
+
+func TestExecuteDynamicPipelineReturnsResult(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+			}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < 3; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		if i == 0 {
+			// Only the ONAPI step finds keywords
+			mock.ExpectExec("INSERT INTO step_keywords").
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "company_name", "NOVASCO HOLDING SRL", "novasco holding srl").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(3, 3, 0, 1, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		SkipDuplicates:   true,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if result == nil {
+		t.Fatal("expected a pipeline result")
+	}
+	if result.TotalSteps != 3 || result.SuccessfulSteps != 3 || result.FailedSteps != 0 {
+		t.Errorf("unexpected totals: total=%d successful=%d failed=%d", result.TotalSteps, result.SuccessfulSteps, result.FailedSteps)
+	}
+	if result.MaxDepthReached != 1 {
+		t.Errorf("expected max depth 1, got %d", result.MaxDepthReached)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineReturnsError(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		t.Fatal("no search expected when the pipeline cannot be created")
+		return nil, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnError(errors.New("database unavailable"))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI},
+	})
+	if err == nil {
+		t.Fatal("expected the pipeline error to be returned")
+	}
+	if result != nil {
+		t.Errorf("expected no result, got %+v", result)
+	}
+}
+
+func TestExecuteDynamicPipelineStopsOnCancelledContext(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	searches := 0
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches++
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output:          []domain.Entity{},
+		}, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(1, 1, 0, 0, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(ctx, domain.DynamicPipelineConfig{
+		Query:             "novasco",
+		MaxDepth:          1,
+		DelayBetweenSteps: domain.Seconds(5 * time.Second),
+		AvailableDomains:  []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the pipeline to stop during the delay, took %s", elapsed)
+	}
+	if searches != 1 {
+		t.Errorf("expected a single search before cancellation, got %d", searches)
+	}
+	if result == nil || result.TotalSteps != 1 || result.SuccessfulSteps != 1 {
+		t.Errorf("expected the partial result of the first step, got %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineStopsWhenBudgetExhausted(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	searches := 0
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches++
+		// Stand in for the shared client, which records every outbound request
+		custom.BudgetFromContext(ctx).Record(1, 512)
+		result := &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+			},
+		}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(2, 2, 0, 0, domain.StopReasonBudgetExhausted, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         3,
+		MaxRequests:      2,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if searches != 2 {
+		t.Errorf("expected the pipeline to halt after 2 searches, got %d", searches)
+	}
+	if result.StopReason != domain.StopReasonBudgetExhausted {
+		t.Errorf("expected stop reason %q, got %q", domain.StopReasonBudgetExhausted, result.StopReason)
+	}
+	if result.RequestsUsed != 2 || result.BytesUsed != 1024 {
+		t.Errorf("unexpected usage: requests=%d bytes=%d", result.RequestsUsed, result.BytesUsed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineRunsStepsConcurrently(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL", "NOVASCO INMOBILIARIA"},
+			},
+		}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		case domain.DomainTypeSCJ:
+			result.Output = []domain.ScjCase{}
+		case domain.DomainTypePGR:
+			result.Output = []domain.PGRNews{}
+		}
+		return result, nil
+	})
+
+	// 4 initial steps, then both company names in each of the 4 domains
+	const wantSteps = 12
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < wantSteps; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(wantSteps, wantSteps, 0, 1, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:              "novasco",
+		MaxDepth:           1,
+		MaxConcurrentSteps: 4,
+		SkipDuplicates:     true,
+		AvailableDomains: []domain.DomainType{
+			domain.DomainTypeONAPI, domain.DomainTypeDGII, domain.DomainTypeSCJ, domain.DomainTypePGR,
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if result.TotalSteps != wantSteps || result.SuccessfulSteps != wantSteps {
+		t.Errorf("expected %d successful steps, got total=%d successful=%d", wantSteps, result.TotalSteps, result.SuccessfulSteps)
+	}
+	if peak < 2 || peak > 4 {
+		t.Errorf("expected between 2 and 4 concurrent searches, got %d", peak)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineSkipsUnavailableSources(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	var mu sync.Mutex
+	searched := []domain.DomainType{}
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		searched = append(searched, domainType)
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+			}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+
+	// The initial DGII step and the DGII expansion of the ONAPI company name
+	// are recorded as skipped without a search result
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "ONAPI", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			true, "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "DGII", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				false, "", domain.SkipReasonSourceUnavailable, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	pipeline := NewDynamicPipelineInteractor(repos)
+	pipeline.sourceHealth = module.NewSourceHealth()
+	pipeline.sourceHealth.MarkDown(domain.DomainTypeDGII, "health check failed", 0)
+
+	result, err := pipeline.ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:                  "novasco",
+		MaxDepth:               1,
+		SkipDuplicates:         true,
+		SkipUnavailableSources: true,
+		AvailableDomains:       []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	for _, domainType := range searched {
+		if domainType == domain.DomainTypeDGII {
+			t.Fatalf("expected no search dispatched to the unavailable source, got %v", searched)
+		}
+	}
+	if result.SkippedSteps != 2 {
+		t.Errorf("expected 2 skipped steps, got %d", result.SkippedSteps)
+	}
+	if result.SuccessfulSteps != 1 {
+		t.Errorf("expected 1 successful step, got %d", result.SuccessfulSteps)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineStopsKeywordLoops(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	// Each company surfaces the other one, spelled differently each time
+	related := map[string]string{
+		"alpha corp": "BETA  CORP",
+		"beta corp":  " Alpha Corp ",
+	}
+
+	var mu sync.Mutex
+	searches := 0
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		searches++
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {related[domain.NormalizeKeyword(params.Query)]},
+			},
+		}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+
+	// Both companies are searched once in each domain
+	const wantSteps = 4
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < wantSteps; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(wantSteps, wantSteps, 0, 1, "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "alpha corp",
+		MaxDepth:         50,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if searches != wantSteps {
+		t.Errorf("expected %d searches, got %d", wantSteps, searches)
+	}
+	if result.VisitedKeywords != wantSteps {
+		t.Errorf("expected %d visited keywords, got %d", wantSteps, result.VisitedKeywords)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineStoresSessionID(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Register{}}, nil
+	})
+
+	sessionID := domain.NewID().String()
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").
+		WithArgs(sqlmock.AnyArg(), sessionID, 0, 0, 0, 0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		SkipDuplicates:   true,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeDGII},
+		SessionID:        sessionID,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+	if result.SessionID != sessionID {
+		t.Errorf("expected session %s, got %q", sessionID, result.SessionID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineRollsBackFailedStepWrites(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output:          []domain.Entity{{SerieExpediente: 2020, NumeroExpediente: 1, Texto: "NOVASCO"}},
+		}, nil
+	})
+
+	// The entity of the step fails to store after its step and search result
+	// were written, so the whole step is rolled back rather than committed
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM onapi_entities").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO onapi_entities").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI},
+	})
+	if err == nil {
+		t.Fatal("expected the failed step to fail the pipeline")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected the step writes to be rolled back without a commit: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineSkipsRecordsFailingValidation(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output: []domain.Entity{
+				{SerieExpediente: 2020, NumeroExpediente: 1, Texto: "NOVASCO"},
+				// Blank sign and missing expediente
+				{SerieExpediente: 2020, NumeroExpediente: 2, Texto: "   "},
+				{Texto: "NOVASCO REAL ESTATE"},
+			},
+		}, nil
+	})
+
+	// Only the valid entity is stored, while the step still counts
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM onapi_entities").
+		WithArgs(int32(2020), int32(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO onapi_entities").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         0,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if result.TotalSteps != 1 || result.SuccessfulSteps != 1 {
+		t.Errorf("expected the step to count, got total=%d successful=%d", result.TotalSteps, result.SuccessfulSteps)
+	}
+	if result.SkippedRecords != 2 {
+		t.Errorf("expected 2 skipped records, got %d", result.SkippedRecords)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
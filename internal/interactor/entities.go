@@ -0,0 +1,116 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"regexp"
+	"strings"
+)
+
+type EntityInteractor struct {
+	repositories repositories.Factory
+}
+
+func NewEntityInteractor(repositoryFactory repositories.Factory) *EntityInteractor {
+	return &EntityInteractor{
+		repositories: repositoryFactory,
+	}
+}
+
+// FindCompaniesByPerson returns the companies where the person appears as
+// titular or gestor of an ONAPI record, or as officer or shareholder of a
+// mercantile registration
+func (e *EntityInteractor) FindCompaniesByPerson(ctx context.Context, name string) ([]domain.CompanyLink, error) {
+	person := normalizePersonName(name)
+	if person == "" {
+		return nil, fmt.Errorf("person name is required")
+	}
+
+	links := []domain.CompanyLink{}
+	seen := make(map[string]bool)
+	addLink := func(link domain.CompanyLink) {
+		key := string(link.Source) + "|" + link.Reference + "|" + link.Role
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		links = append(links, link)
+	}
+
+	entities, err := e.repositories.GetOnapiRepository().FindByPerson(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		reference := fmt.Sprintf("%d-%d", entity.SerieExpediente, entity.NumeroExpediente)
+		for _, field := range []struct{ role, value string }{
+			{domain.CompanyRoleTitular, entity.Titular},
+			{domain.CompanyRoleGestor, entity.Gestor},
+		} {
+			if mentionsPerson(field.value, person) {
+				addLink(domain.CompanyLink{
+					CompanyName:          entity.Texto,
+					Role:                 field.role,
+					Source:               domain.DomainTypeONAPI,
+					Reference:            reference,
+					DomainSearchResultID: entity.DomainSearchResultID,
+				})
+			}
+		}
+	}
+
+	records, err := e.repositories.GetPipelineRepository().GetCamaraRecordsByPerson(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		companyName := record.RazonSocial
+		if companyName == "" {
+			companyName = record.NombreComercial
+		}
+
+		for _, group := range []struct {
+			role   string
+			people []domain.CamaraPerson
+		}{
+			{domain.CompanyRoleGerente, record.Gerentes},
+			{domain.CompanyRoleSocio, record.Socios},
+		} {
+			for _, p := range group.people {
+				if mentionsPerson(p.Nombre, person) {
+					addLink(domain.CompanyLink{
+						CompanyName:          companyName,
+						RNC:                  record.RNC,
+						Role:                 group.role,
+						Source:               domain.DomainTypeCamara,
+						Reference:            record.RegistroMercantil,
+						DomainSearchResultID: record.DomainSearchResultID,
+					})
+				}
+			}
+		}
+	}
+
+	return links, nil
+}
+
+var personSeparators = regexp.MustCompile(`(?i)\s*[,;/]\s*|\s+y\s+`)
+
+// mentionsPerson reports whether a titular/gestor style field, which may list
+// several people, names the given (normalized) person
+func mentionsPerson(value, person string) bool {
+	for _, candidate := range personSeparators.Split(value, -1) {
+		if normalizePersonName(candidate) == person {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePersonName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,119 @@
+package interactor
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type mockDatabase struct {
+	db *sql.DB
+}
+
+func (m *mockDatabase) Health() map[string]string { return map[string]string{"status": "up"} }
+func (m *mockDatabase) Close() error              { return m.db.Close() }
+func (m *mockDatabase) GetDB() *sql.DB            { return m.db }
+
+func newMockRepositories(t *testing.T) (*repositories.RepositoryFactory, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return repositories.NewRepositoryFactory(&mockDatabase{db: db}), mock
+}
+
+var onapiEntityColumns = []string{
+	"id", "domain_search_result_id", "serie_expediente", "numero_expediente", "certificado", "tipo", "subtipo",
+	"texto", "clases", "aplicado_a_proteger", "expedicion", "vencimiento", "en_tramite",
+	"titular", "gestor", "domicilio", "status", "tipo_signo", "imagenes", "lista_clases",
+	"created_at", "updated_at",
+}
+
+func onapiEntityRow(serie, numero int32, texto, titular, gestor string) []driver.Value {
+	return []driver.Value{
+		domain.NewID().String(), domain.NewID().String(), serie, numero, "", "", "",
+		texto, "", "", "", "", false,
+		titular, gestor, "", "", "", "[]", "[]",
+		nil, nil,
+	}
+}
+
+func TestFindCompaniesByPersonLinksSharedDirector(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	mock.ExpectQuery("FROM onapi_entities").
+		WithArgs("%Juan Perez%", "%Juan Perez%").
+		WillReturnRows(sqlmock.NewRows(onapiEntityColumns).
+			AddRow(onapiEntityRow(2020, 1, "NOVASCO", "Juan Perez, Maria Gomez", "")...).
+			AddRow(onapiEntityRow(2021, 7, "CONSTRUCTORA DEL ESTE", "Pedro Diaz", "juan  perez")...).
+			AddRow(onapiEntityRow(2022, 3, "JUAN PEREZ HIJOS", "Juan Perez Hijos SRL", "")...))
+
+	camaraOutput, _ := json.Marshal([]domain.CamaraRecord{
+		{
+			RegistroMercantil: "12345SD",
+			RNC:               "130000001",
+			RazonSocial:       "Novasco Real Estate SRL",
+			Gerentes:          []domain.CamaraPerson{{Nombre: "Juan Perez", Cargo: "Gerente"}},
+			Socios:            []domain.CamaraPerson{{Nombre: "Juan Perez"}, {Nombre: "Maria Gomez"}},
+		},
+		{
+			RegistroMercantil: "999SD",
+			RazonSocial:       "Otra Empresa SRL",
+			Socios:            []domain.CamaraPerson{{Nombre: "Maria Gomez"}},
+		},
+	})
+	searchResultID := domain.NewID()
+
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(string(domain.DomainTypeCamara), "%Juan Perez%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "output"}).AddRow(searchResultID.String(), camaraOutput))
+
+	links, err := NewEntityInteractor(repos).FindCompaniesByPerson(context.Background(), "Juan Perez")
+	if err != nil {
+		t.Fatalf("FindCompaniesByPerson returned error: %v", err)
+	}
+
+	want := []domain.CompanyLink{
+		{CompanyName: "NOVASCO", Role: domain.CompanyRoleTitular, Source: domain.DomainTypeONAPI, Reference: "2020-1"},
+		{CompanyName: "CONSTRUCTORA DEL ESTE", Role: domain.CompanyRoleGestor, Source: domain.DomainTypeONAPI, Reference: "2021-7"},
+		{CompanyName: "Novasco Real Estate SRL", RNC: "130000001", Role: domain.CompanyRoleGerente, Source: domain.DomainTypeCamara, Reference: "12345SD"},
+		{CompanyName: "Novasco Real Estate SRL", RNC: "130000001", Role: domain.CompanyRoleSocio, Source: domain.DomainTypeCamara, Reference: "12345SD"},
+	}
+
+	if len(links) != len(want) {
+		t.Fatalf("expected %d links, got %d: %+v", len(want), len(links), links)
+	}
+
+	for i, link := range links {
+		if link.CompanyName != want[i].CompanyName || link.Role != want[i].Role ||
+			link.Source != want[i].Source || link.Reference != want[i].Reference || link.RNC != want[i].RNC {
+			t.Errorf("link %d: expected %+v, got %+v", i, want[i], link)
+		}
+	}
+
+	if links[2].DomainSearchResultID != searchResultID {
+		t.Errorf("expected mercantile link to keep its search result ID, got %s", links[2].DomainSearchResultID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFindCompaniesByPersonRequiresName(t *testing.T) {
+	repos, _ := newMockRepositories(t)
+
+	if _, err := NewEntityInteractor(repos).FindCompaniesByPerson(context.Background(), "   "); err == nil {
+		t.Fatal("expected an error for an empty name")
+	}
+}
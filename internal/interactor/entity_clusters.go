@@ -0,0 +1,23 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+)
+
+// EntityClusters resolves the companies named by the steps of a stored
+// pipeline, manual findings included, and returns them with the step, domain
+// and search that contributed each of their aliases
+func (d *DynamicPipelineInteractor) EntityClusters(ctx context.Context, pipelineID string) ([]domain.EntityCluster, error) {
+	pipeline, err := d.repositories.GetPipelineRepository().GetPipelineByID(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	companies := domain.NewCompanyCanonicalizer(pipeline.Config.CompanySimilarity)
+	for _, step := range pipeline.Steps {
+		companies.AddStep(step, storedCompanyOutput(step))
+	}
+
+	return companies.Clusters(), nil
+}
@@ -0,0 +1,76 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestEntityClustersListContributingSourcesAndAliases(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	pipelineID := domain.NewID()
+	onapiStepID, dgiiStepID, manualStepID := domain.NewID(), domain.NewID(), domain.NewID()
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(pipelineID.String(), nil, 4, 4, 0, 1, "", `{"query":"novasco"}`, "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).
+			AddRow(onapiStepID.String(), "ONAPI", "novasco", "", `["novasco"]`, true, "", "",
+				`[{"texto":"NOVASCO REAL ESTATE"},{"texto":"NOVASCO REAL ESTATE"}]`, `{"company_name":["NOVASCO REAL ESTATE"]}`, 0, nil).
+			AddRow(dgiiStepID.String(), "DGII", "NOVASCO REAL ESTATE", "company_name", `["NOVASCO REAL ESTATE"]`, true, "", "",
+				`[{"rnc":"130000001","razon_social":"Novasco Real Estate, S.R.L.","nombre_comercial":"Novasco"}]`, `{"company_name":["Novasco Real Estate, S.R.L."]}`, 1, onapiStepID.String()).
+			AddRow(domain.NewID().String(), "SCJ", "novasco", "", `["novasco"]`, true, "", "", `[]`, "null", 0, nil).
+			AddRow(manualStepID.String(), "MANUAL", "130000001", "contributor_id", `["130000001"]`, true, "", "",
+				`[{"name":"130000001","category":"contributor_id","manual":true}]`, `{"contributor_id":["130000001"]}`, 0, nil))
+
+	clusters, err := NewDynamicPipelineInteractor(repos).EntityClusters(context.Background(), pipelineID.String())
+	if err != nil {
+		t.Fatalf("EntityClusters returned error: %v", err)
+	}
+
+	if len(clusters) != 1 {
+		t.Fatalf("expected a single cluster, got %+v", clusters)
+	}
+	cluster := clusters[0]
+	if cluster.RNC != "130000001" {
+		t.Errorf("expected the cluster to carry the RNC, got %q", cluster.RNC)
+	}
+	for _, source := range []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII, domain.DomainTypeManual} {
+		if !slices.Contains(cluster.Sources, source) {
+			t.Errorf("expected %s among the sources, got %v", source, cluster.Sources)
+		}
+	}
+	for _, alias := range []string{"NOVASCO REAL ESTATE", "Novasco Real Estate, S.R.L.", "Novasco"} {
+		if !slices.Contains(cluster.Aliases, alias) {
+			t.Errorf("expected alias %q, got %v", alias, cluster.Aliases)
+		}
+	}
+	if cluster.Confidence != 0.6 {
+		t.Errorf("expected a confidence of 0.6 for three sources, got %f", cluster.Confidence)
+	}
+
+	want := []domain.EntityReference{
+		{Alias: "NOVASCO REAL ESTATE", DomainType: domain.DomainTypeONAPI, StepID: onapiStepID, SearchParameter: "novasco"},
+		{Alias: "Novasco Real Estate, S.R.L.", DomainType: domain.DomainTypeDGII, StepID: dgiiStepID, SearchParameter: "NOVASCO REAL ESTATE"},
+		{Alias: "Novasco", DomainType: domain.DomainTypeDGII, StepID: dgiiStepID, SearchParameter: "NOVASCO REAL ESTATE"},
+		{Alias: "130000001", DomainType: domain.DomainTypeManual, StepID: manualStepID, SearchParameter: "130000001", Manual: true},
+	}
+	if !slices.Equal(cluster.References, want) {
+		t.Errorf("unexpected references\n got %+v\nwant %+v", cluster.References, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
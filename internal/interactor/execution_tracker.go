@@ -0,0 +1,125 @@
+package interactor
+
+import (
+	"insightful-intel/internal/domain"
+	"sync"
+	"time"
+)
+
+// ExecutionRetention is how long finished executions are kept by the tracker
+const ExecutionRetention = 24 * time.Hour
+
+// ExecutionTracker records the state of the pipeline executions run in the
+// background, so their progress can be checked by execution ID
+type ExecutionTracker struct {
+	mu         sync.RWMutex
+	executions map[string]*domain.ExecutionState
+	now        func() time.Time
+}
+
+// DefaultExecutionTracker is the tracker shared by the interactors
+var DefaultExecutionTracker = NewExecutionTracker()
+
+// NewExecutionTracker creates a tracker without executions
+func NewExecutionTracker() *ExecutionTracker {
+	return &ExecutionTracker{
+		executions: make(map[string]*domain.ExecutionState),
+		now:        time.Now,
+	}
+}
+
+// Start marks an execution as processing. Starting an execution already
+// processing keeps its progress. Executions finished longer than
+// ExecutionRetention ago are dropped.
+func (t *ExecutionTracker) Start(executionID string) {
+	if t == nil || executionID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for id, state := range t.executions {
+		if !state.FinishedAt.IsZero() && now.Sub(state.FinishedAt) > ExecutionRetention {
+			delete(t.executions, id)
+		}
+	}
+
+	if state, ok := t.executions[executionID]; ok && state.Status == domain.ExecutionStatusProcessing {
+		return
+	}
+	t.executions[executionID] = &domain.ExecutionState{
+		ExecutionID: executionID,
+		Status:      domain.ExecutionStatusProcessing,
+		StartedAt:   now,
+	}
+}
+
+// RecordStep counts a completed or skipped step of an execution, keeping the
+// error of a failed one as its last error
+func (t *ExecutionTracker) RecordStep(executionID string, step domain.DynamicPipelineStep) {
+	if t == nil || executionID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.executions[executionID]
+	if !ok {
+		return
+	}
+
+	switch {
+	case step.SkipReason != "":
+		state.SkippedSteps++
+	case step.Success:
+		state.TotalSteps++
+		state.SuccessfulSteps++
+	default:
+		state.TotalSteps++
+		state.FailedSteps++
+		if step.Error != nil {
+			state.LastError = step.Error.Error()
+		}
+	}
+}
+
+// Finish marks an execution as completed, or as failed when err is not nil
+func (t *ExecutionTracker) Finish(executionID string, err error) {
+	if t == nil || executionID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.executions[executionID]
+	if !ok {
+		return
+	}
+
+	state.FinishedAt = t.now()
+	state.Status = domain.ExecutionStatusCompleted
+	if err != nil {
+		state.Status = domain.ExecutionStatusFailed
+		state.LastError = err.Error()
+	}
+}
+
+// Get returns the state of an execution
+func (t *ExecutionTracker) Get(executionID string) (domain.ExecutionState, bool) {
+	if t == nil {
+		return domain.ExecutionState{}, false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	state, ok := t.executions[executionID]
+	if !ok {
+		return domain.ExecutionState{}, false
+	}
+	return *state, true
+}
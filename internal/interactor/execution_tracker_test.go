@@ -0,0 +1,99 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExecutionTrackerRecordsCompletedExecution(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output:          []domain.Entity{},
+		}, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	interactor := NewDynamicPipelineInteractor(repos)
+	interactor.executions = NewExecutionTracker()
+
+	executionID := domain.NewID().String()
+	ctx := infra.SetExecutionID(context.Background(), executionID)
+	if _, err := interactor.ExecuteDynamicPipelineWithConfig(ctx, domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI},
+	}); err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	state, ok := interactor.Executions().Get(executionID)
+	if !ok {
+		t.Fatal("expected the execution to be tracked")
+	}
+	if state.Status != domain.ExecutionStatusCompleted {
+		t.Errorf("expected status %q, got %q", domain.ExecutionStatusCompleted, state.Status)
+	}
+	if state.TotalSteps != 1 || state.SuccessfulSteps != 1 || state.FailedSteps != 0 {
+		t.Errorf("unexpected step counts: total=%d successful=%d failed=%d", state.TotalSteps, state.SuccessfulSteps, state.FailedSteps)
+	}
+	if state.StartedAt.IsZero() || state.FinishedAt.IsZero() {
+		t.Errorf("expected start and finish times, got %+v", state)
+	}
+	if state.LastError != "" {
+		t.Errorf("expected no error, got %q", state.LastError)
+	}
+}
+
+func TestExecutionTrackerRecordsFailedExecution(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		t.Fatal("no search expected when the pipeline cannot be created")
+		return nil, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnError(errors.New("database unavailable"))
+
+	interactor := NewDynamicPipelineInteractor(repos)
+	interactor.executions = NewExecutionTracker()
+
+	executionID := domain.NewID().String()
+	ctx := infra.SetExecutionID(context.Background(), executionID)
+	if _, err := interactor.ExecuteDynamicPipelineWithConfig(ctx, domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI},
+	}); err == nil {
+		t.Fatal("expected the pipeline error to be returned")
+	}
+
+	state, ok := interactor.Executions().Get(executionID)
+	if !ok {
+		t.Fatal("expected the execution to be tracked")
+	}
+	if state.Status != domain.ExecutionStatusFailed {
+		t.Errorf("expected status %q, got %q", domain.ExecutionStatusFailed, state.Status)
+	}
+	if state.LastError == "" {
+		t.Error("expected the pipeline error to be kept")
+	}
+	if state.FinishedAt.IsZero() {
+		t.Error("expected a finish time")
+	}
+}
@@ -0,0 +1,86 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExecuteDynamicPipelineCapsFanout(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	// The DGII search of the query finds 30 companies, each of which would be
+	// searched in ONAPI
+	var companies []string
+	for i := 0; i < 30; i++ {
+		companies = append(companies, fmt.Sprintf("NOVASCO FILIAL %02d", i))
+	}
+
+	var mu sync.Mutex
+	searches := 0
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		searches++
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: companies,
+			}
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+		}
+		return result, nil
+	})
+
+	// 2 initial steps queued, so the DGII step may queue at most 2x2 steps
+	const wantSteps = 2 + 4
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < wantSteps; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:               "novasco",
+		MaxDepth:            1,
+		MaxConcurrentSteps:  1,
+		MaxFanoutMultiplier: 2,
+		AvailableDomains:    []domain.DomainType{domain.DomainTypeDGII, domain.DomainTypeONAPI},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if result.TotalSteps != wantSteps || searches != wantSteps {
+		t.Errorf("expected the fan-out to be capped at %d steps, got total=%d searches=%d", wantSteps, result.TotalSteps, searches)
+	}
+
+	if len(result.Notices) != 1 {
+		t.Fatalf("expected 1 notice, got %+v", result.Notices)
+	}
+	notice := result.Notices[0]
+	if notice.Type != domain.NoticeFanoutCapped || notice.DomainType != domain.DomainTypeDGII {
+		t.Errorf("expected a fanout_capped notice for the DGII step, got %+v", notice)
+	}
+	if notice.Projected != len(companies) || notice.Kept != 4 {
+		t.Errorf("expected 30 projected and 4 kept steps, got %+v", notice)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/module"
+	"insightful-intel/internal/repositories"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// stubCompanySearch answers every search with the same company name, so each
+// step leads to more steps, after waiting delay
+func stubCompanySearch(t *testing.T, searches *atomic.Int32, delay time.Duration) {
+	t.Helper()
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches.Add(1)
+		time.Sleep(delay)
+		result := &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+			},
+		}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+}
+
+// expectStoredSteps expects the pipeline header and n searched steps with
+// keywords to be stored
+func expectStoredSteps(mock sqlmock.Sqlmock, n int) {
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < n; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+}
+
+func TestExecuteDynamicPipelineStopsAtMaxTotalSteps(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	var searches atomic.Int32
+	stubCompanySearch(t, &searches, 0)
+
+	// The 2 initial steps lead to 2 more, of which only 1 fits the limit
+	expectStoredSteps(mock, 3)
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(3, 3, 0, 1, domain.StopReasonStepLimit, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         3,
+		MaxTotalSteps:    3,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if searches.Load() != 3 {
+		t.Errorf("expected the pipeline to halt after 3 searches, got %d", searches.Load())
+	}
+	if result.StopReason != domain.StopReasonStepLimit || !result.BudgetLimited() {
+		t.Errorf("expected a budget-limited result stopped by the step limit, got %q", result.StopReason)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineStopsAtMaxDuration(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	// One step at a time, each outlasting the duration of the run
+	var searches atomic.Int32
+	stubCompanySearch(t, &searches, 50*time.Millisecond)
+
+	expectStoredSteps(mock, 1)
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(1, 1, 0, 0, domain.StopReasonTimeLimit, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	started := time.Now()
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:              "novasco",
+		MaxDepth:           3,
+		MaxConcurrentSteps: 1,
+		MaxDuration:        10 * time.Millisecond,
+		AvailableDomains:   []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if searches.Load() != 1 {
+		t.Errorf("expected only the step started within the duration to run, got %d searches", searches.Load())
+	}
+	if result.StopReason != domain.StopReasonTimeLimit || !result.BudgetLimited() {
+		t.Errorf("expected a budget-limited result stopped by the time limit, got %q", result.StopReason)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected the run to stop soon after its duration, took %s", elapsed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExecuteDynamicPipelineAppliesRateLimitsToTheRunOnly(t *testing.T) {
+	base := module.NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeONAPI: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+
+	var runLimiter atomic.Pointer[module.RateLimiter]
+	interactor := NewDynamicPipelineInteractorWithSearcher(repositories.NewMemoryRepositoryFactory(), SearcherFunc(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		runLimiter.Store(module.RateLimiterFromContext(ctx))
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Entity{}}, nil
+	}))
+	interactor.rateLimiter = base
+
+	_, err := interactor.ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI},
+		RateLimits: map[domain.DomainType]domain.RateLimit{
+			domain.DomainTypeONAPI: {RequestsPerSecond: 1000, Burst: 3},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+	if limiter := runLimiter.Load(); limiter == nil || limiter == base {
+		t.Fatal("expected the searches of the run to wait on a limiter of their own")
+	}
+
+	// The shared limiter keeps its ONAPI rate after the run
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := base.Wait(ctx, domain.DomainTypeONAPI, "example.test"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := base.Wait(ctx, domain.DomainTypeONAPI, "example.test"); err == nil {
+		t.Error("expected the run's rate limits not to change the shared limiter")
+	}
+}
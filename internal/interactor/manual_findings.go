@@ -0,0 +1,78 @@
+package interactor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"insightful-intel/internal/domain"
+)
+
+// AddManualFinding folds a finding entered by an analyst into a stored
+// pipeline. The finding is recorded as a manual step, so its name joins the
+// keywords of the pipeline, and the companies and confidence of the pipeline
+// are recomputed with it. It returns the updated pipeline and, for company
+// findings, the canonical company the finding merged into.
+func (d *DynamicPipelineInteractor) AddManualFinding(ctx context.Context, pipelineID string, finding domain.ManualFinding) (*domain.DynamicPipelineResult, *domain.CanonicalCompany, error) {
+	if err := finding.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	pipelineRepo := d.repositories.GetPipelineRepository()
+
+	pipeline, err := pipelineRepo.GetPipelineByID(ctx, pipelineID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	step := finding.Step(pipeline.ID)
+	if err := pipelineRepo.CreateDynamicPipelineStep(ctx, &step); err != nil {
+		return nil, nil, fmt.Errorf("error saving manual finding: %w", err)
+	}
+	pipeline.Steps = append(pipeline.Steps, step)
+
+	// Stored outputs are decoded as plain JSON, so the companies are rebuilt
+	// from the outputs decoded again as records
+	companies := domain.NewCompanyCanonicalizer(pipeline.Config.CompanySimilarity)
+	for _, stored := range pipeline.Steps[:len(pipeline.Steps)-1] {
+		companies.AddOutput(storedCompanyOutput(stored))
+	}
+	merged := companies.AddFinding(step.Output.([]domain.ManualFinding)[0])
+
+	pipeline.Companies = companies.Companies()
+	pipeline.Confidence = domain.ScoreConfidence(pipeline.Steps, pipeline.Config.ConfidenceWeights)
+
+	return pipeline, merged, nil
+}
+
+// storedCompanyOutput returns the output of a stored step as the records the
+// company canonicalizer reads, nil for the domains naming no companies
+func storedCompanyOutput(step domain.DynamicPipelineStep) any {
+	if !step.Success || step.Output == nil {
+		return nil
+	}
+
+	switch step.DomainType {
+	case domain.DomainTypeONAPI:
+		return decodeStoredOutput[domain.Entity](step.Output)
+	case domain.DomainTypeDGII:
+		return decodeStoredOutput[domain.Register](step.Output)
+	case domain.DomainTypeManual:
+		return decodeStoredOutput[domain.ManualFinding](step.Output)
+	}
+	return nil
+}
+
+// decodeStoredOutput decodes the JSON output of a stored step into its records,
+// none when it does not hold them
+func decodeStoredOutput[T any](output any) []T {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil
+	}
+
+	var records []T
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil
+	}
+	return records
+}
@@ -0,0 +1,98 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddManualFindingMergesIntoCompanyAndCorroborates(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	pipelineID := domain.NewID()
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(pipelineID.String(), nil, 3, 2, 1, 0, "", `{"query":"novasco"}`, "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).
+			AddRow(domain.NewID().String(), "ONAPI", "novasco", "", `["novasco"]`, true, "", "",
+				`[{"texto":"NOVASCO REAL ESTATE"}]`, `{"company_name":["NOVASCO REAL ESTATE"]}`, 0, nil).
+			AddRow(domain.NewID().String(), "DGII", "novasco", "", `["novasco"]`, true, "", "",
+				`[{"rnc":"130000001","razon_social":"Novasco Real Estate, S.R.L."}]`, `{"company_name":["Novasco Real Estate, S.R.L."]}`, 0, nil).
+			AddRow(domain.NewID().String(), "SCJ", "novasco", "", `["novasco"]`, false, "timeout", "", "null", "null", 0, nil))
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").
+		WithArgs(sqlmock.AnyArg(), pipelineID, "MANUAL", "Novasco Real Estate SRL", "company_name",
+			sqlmock.AnyArg(), true, "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), 0, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO step_keywords").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "company_name", "Novasco Real Estate SRL", "novasco real estate srl").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	pipeline, company, err := NewDynamicPipelineInteractor(repos).AddManualFinding(context.Background(), pipelineID.String(), domain.ManualFinding{
+		Name:     " Novasco Real Estate SRL ",
+		Category: domain.KeywordCategoryCompanyName,
+		Note:     "Named in a tip about the Bávaro project",
+		Source:   "anonymous tip",
+	})
+	if err != nil {
+		t.Fatalf("AddManualFinding returned error: %v", err)
+	}
+
+	if company == nil {
+		t.Fatal("expected the finding to merge into the scraped company")
+	}
+	for _, source := range []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII, domain.DomainTypeManual} {
+		if !slices.Contains(company.Sources, source) {
+			t.Errorf("expected the company to be found in %s, got %v", source, company.Sources)
+		}
+	}
+	if company.RNC != "130000001" || len(pipeline.Companies) != 1 {
+		t.Errorf("expected a single company with its RNC, got %+v", pipeline.Companies)
+	}
+
+	step := pipeline.Steps[len(pipeline.Steps)-1]
+	findings, ok := step.Output.([]domain.ManualFinding)
+	if step.DomainType != domain.DomainTypeManual || !ok || !findings[0].Manual || findings[0].Source != "anonymous tip" {
+		t.Errorf("expected the finding to be recorded as a manual step, got %+v", step)
+	}
+
+	scraped := domain.ScoreConfidence(pipeline.Steps[:len(pipeline.Steps)-1], domain.ConfidenceWeights{})
+	if pipeline.Confidence.CorroboratingSources != scraped.CorroboratingSources+1 {
+		t.Errorf("expected the finding to add a corroborating source to %d, got %d", scraped.CorroboratingSources, pipeline.Confidence.CorroboratingSources)
+	}
+	if pipeline.Confidence.OverallConfidence <= scraped.OverallConfidence {
+		t.Errorf("expected the finding to raise the confidence above %f, got %f", scraped.OverallConfidence, pipeline.Confidence.OverallConfidence)
+	}
+	if pipeline.Confidence.SourceCoverage != scraped.SourceCoverage {
+		t.Errorf("expected the finding to leave the coverage at %f, got %f", scraped.SourceCoverage, pipeline.Confidence.SourceCoverage)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddManualFindingValidatesFinding(t *testing.T) {
+	repos, _ := newMockRepositories(t)
+	interactor := NewDynamicPipelineInteractor(repos)
+
+	for _, finding := range []domain.ManualFinding{
+		{Name: "  ", Category: domain.KeywordCategoryCompanyName},
+		{Name: "Novasco", Category: "rumor"},
+	} {
+		if _, _, err := interactor.AddManualFinding(context.Background(), domain.NewID().String(), finding); err == nil {
+			t.Errorf("expected %+v to be rejected", finding)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"testing"
+)
+
+func TestExecuteDynamicPipelineAgainstMemoryRepositories(t *testing.T) {
+	repos := repositories.NewMemoryRepositoryFactory()
+	ctx := context.Background()
+
+	searcher := SearcherFunc(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+			if params.Query == "novasco" {
+				result.Output = []domain.Entity{{SerieExpediente: 2020, NumeroExpediente: 1, Texto: "NOVASCO HOLDING SRL", Titular: "NOVASCO HOLDING SRL"}}
+				result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+					domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+				}
+			}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+			if params.Query == "NOVASCO HOLDING SRL" {
+				result.Output = []domain.Register{{RNC: "131246753", RazonSocial: "NOVASCO HOLDING SRL", Estado: "ACTIVO"}}
+			}
+		}
+		return result, nil
+	})
+
+	result, err := NewDynamicPipelineInteractorWithSearcher(repos, searcher).ExecuteDynamicPipelineWithConfig(ctx, domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		SkipDuplicates:   true,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	stored, err := repos.GetPipelineRepository().GetPipelineByID(ctx, result.ID.String())
+	if err != nil {
+		t.Fatalf("GetPipelineByID returned error: %v", err)
+	}
+	if stored.TotalSteps != 3 || stored.SuccessfulSteps != 3 || len(stored.Steps) != 3 {
+		t.Errorf("expected the pipeline stored with its 3 steps, got total=%d successful=%d steps=%d", stored.TotalSteps, stored.SuccessfulSteps, len(stored.Steps))
+	}
+	if stored.Config.Query != "novasco" {
+		t.Errorf("expected the config of the pipeline to be stored, got %+v", stored.Config)
+	}
+
+	summaries, err := repos.GetPipelineRepository().ListSummariesWithOptions(ctx, repositories.ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListSummariesWithOptions returned error: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].Target != "novasco" || summaries[0].ID != result.ID {
+		t.Errorf("expected the pipeline to be listed, got %+v", summaries)
+	}
+
+	keywords, err := repos.GetPipelineRepository().GetStepKeywordsByPipelineID(ctx, result.ID.String(), domain.KeywordCategoryCompanyName)
+	if err != nil {
+		t.Fatalf("GetStepKeywordsByPipelineID returned error: %v", err)
+	}
+	if len(keywords) != 1 || keywords[0].Keyword != "NOVASCO HOLDING SRL" {
+		t.Errorf("expected the company found by ONAPI as a step keyword, got %+v", keywords)
+	}
+
+	entities, err := repos.GetOnapiRepository().SearchWithMode(ctx, "novasco holding", repositories.MatchAll, 0, 10)
+	if err != nil {
+		t.Fatalf("SearchWithMode returned error: %v", err)
+	}
+	if len(entities) != 1 || entities[0].DomainSearchResultID == (domain.ID{}) {
+		t.Errorf("expected the ONAPI entity to be stored with its search result, got %+v", entities)
+	}
+
+	register, err := repos.GetDgiiRepository().GetByRNC(ctx, "131246753")
+	if err != nil {
+		t.Fatalf("GetByRNC returned error: %v", err)
+	}
+	if register.RazonSocial != "NOVASCO HOLDING SRL" {
+		t.Errorf("expected the DGII register to be stored, got %+v", register)
+	}
+}
@@ -0,0 +1,78 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// pipelineEventsFromEnv reports whether pipelines record their lifecycle
+// events, as set by PIPELINE_EVENTS (enabled unless set to false)
+func pipelineEventsFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("PIPELINE_EVENTS"))
+	return err != nil || enabled
+}
+
+// recordEvent appends an event to the audit log of a pipeline run recording
+// its events. The log must not stop the run, so an event that cannot be stored
+// is only logged, and it is stored even once the run is cancelled.
+func (d *DynamicPipelineInteractor) recordEvent(ctx context.Context, config domain.DynamicPipelineConfig, event domain.PipelineEvent) {
+	if !config.RecordEvents {
+		return
+	}
+
+	if err := d.repositories.GetPipelineRepository().CreatePipelineEvent(context.WithoutCancel(ctx), &event); err != nil {
+		infra.Logger(ctx).Error("failed to record pipeline event",
+			slog.String("event_type", string(event.Type)),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// stepEvent is the event of a step completing, number counting the steps the
+// run has completed so far
+func stepEvent(pipelineID domain.ID, number int, step domain.DynamicPipelineStep) domain.PipelineEvent {
+	event := domain.PipelineEvent{
+		PipelineID: pipelineID,
+		StepID:     step.ID,
+		Type:       domain.PipelineEventStepCompleted,
+		Message:    fmt.Sprintf("step %d completed: %s search for %q", number, step.DomainType, step.SearchParameter),
+		Data: map[string]any{
+			"step":             number,
+			"domain_type":      step.DomainType,
+			"search_parameter": step.SearchParameter,
+			"depth":            step.Depth,
+		},
+	}
+	if step.Error != nil {
+		event.Type = domain.PipelineEventStepFailed
+		event.Message = fmt.Sprintf("step %d failed: %s search for %q: %v", number, step.DomainType, step.SearchParameter, step.Error)
+		event.Data["error"] = step.Error.Error()
+	}
+	return event
+}
+
+// endEvent is the event of a pipeline run ending, with its counters
+func endEvent(result *domain.DynamicPipelineResult, eventType domain.PipelineEventType, message string) domain.PipelineEvent {
+	data := map[string]any{
+		"total_steps":       result.TotalSteps,
+		"successful_steps":  result.SuccessfulSteps,
+		"failed_steps":      result.FailedSteps,
+		"skipped_steps":     result.SkippedSteps,
+		"max_depth_reached": result.MaxDepthReached,
+	}
+	if result.StopReason != "" {
+		data["stop_reason"] = result.StopReason
+	}
+
+	return domain.PipelineEvent{
+		PipelineID: result.ID,
+		Type:       eventType,
+		Message:    message,
+		Data:       data,
+	}
+}
@@ -0,0 +1,71 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func expectPipelineEvent(mock sqlmock.Sqlmock, eventType domain.PipelineEventType) {
+	mock.ExpectExec("INSERT INTO pipeline_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), string(eventType), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestExecuteDynamicPipelineRecordsLifecycleEvents(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		result := &domain.DomainSearchResult{DomainType: domainType, SearchParameter: params.Query}
+		if domainType == domain.DomainTypeDGII {
+			return result, errors.New("dgii unavailable")
+		}
+		result.Success = true
+		result.Output = []domain.Entity{}
+		return result, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPipelineEvent(mock, domain.PipelineEventStarted)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectPipelineEvent(mock, domain.PipelineEventStepCompleted)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectPipelineEvent(mock, domain.PipelineEventStepFailed)
+	expectPipelineEvent(mock, domain.PipelineEventCompleted)
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+		RecordEvents:     true,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPipelineEventsFromEnv(t *testing.T) {
+	t.Setenv("PIPELINE_EVENTS", "")
+	if !pipelineEventsFromEnv() {
+		t.Error("expected events to be recorded by default")
+	}
+
+	t.Setenv("PIPELINE_EVENTS", "false")
+	if pipelineEventsFromEnv() {
+		t.Error("expected PIPELINE_EVENTS=false to disable the events")
+	}
+}
@@ -0,0 +1,110 @@
+package interactor
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"insightful-intel/internal/domain"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// spilledStep matches the encoded step spilled for a keyword
+type spilledStep struct {
+	domainType domain.DomainType
+	keyword    string
+}
+
+func (s spilledStep) Match(v driver.Value) bool {
+	data, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var step domain.DynamicPipelineStep
+	if err := json.Unmarshal(data, &step); err != nil {
+		return false
+	}
+	return step.DomainType == s.domainType && step.SearchParameter == s.keyword
+}
+
+func TestExecuteDynamicPipelineSpillsQueueOverflow(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	var mu sync.Mutex
+	var order []string
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		order = append(order, string(domainType)+":"+params.Query)
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"alpha", "beta", "gamma"},
+			}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for range 5 {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	// Two steps fit in the queue, so the third company found is spilled and
+	// read back once the queue has drained
+	mock.ExpectExec("INSERT INTO pipeline_step_queue").
+		WithArgs(sqlmock.AnyArg(), 1, domain.DefaultCategoryPriority[domain.KeywordCategoryCompanyName], spilledStep{domain.DomainTypeDGII, "gamma"}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	gamma, _ := json.Marshal(domain.DynamicPipelineStep{
+		DomainType:      domain.DomainTypeDGII,
+		SearchParameter: "gamma",
+		Category:        domain.KeywordCategoryCompanyName,
+		Keywords:        []string{"gamma"},
+		Depth:           1,
+		Priority:        domain.DefaultCategoryPriority[domain.KeywordCategoryCompanyName],
+	})
+	mock.ExpectQuery("FROM pipeline_step_queue").
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "step"}).AddRow(int64(1), gamma))
+	mock.ExpectExec("DELETE FROM pipeline_step_queue WHERE seq IN").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:              "novasco",
+		MaxDepth:           1,
+		MaxConcurrentSteps: 1,
+		MaxQueuedSteps:     2,
+		SkipDuplicates:     true,
+		AvailableDomains:   []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	want := []string{"ONAPI:novasco", "DGII:novasco", "DGII:alpha", "DGII:beta", "DGII:gamma"}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected every step to run, the spilled one last, %v, got %v", want, order)
+	}
+	if result.TotalSteps != len(want) {
+		t.Errorf("expected %d steps, got %d", len(want), result.TotalSteps)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
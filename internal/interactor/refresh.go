@@ -0,0 +1,64 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"insightful-intel/internal/module"
+	"log/slog"
+)
+
+// RefreshSearchResults re-scrapes the domain searches behind stored records,
+// bypassing the search cache, and stores the new records under the same search
+// results. It returns the number of search results that were refreshed.
+func (d *DynamicPipelineInteractor) RefreshSearchResults(ctx context.Context, ids []domain.ID) (int, error) {
+	pipelineRepo := d.repositories.GetPipelineRepository()
+
+	refreshed := 0
+	seen := make(map[domain.ID]bool, len(ids))
+	for _, id := range ids {
+		if id == (domain.ID{}) || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if err := ctx.Err(); err != nil {
+			return refreshed, err
+		}
+
+		stored, err := pipelineRepo.GetByID(ctx, id.String())
+		if err != nil {
+			return refreshed, err
+		}
+		previous, ok := stored.(*domain.DomainSearchResult)
+		if !ok {
+			return refreshed, fmt.Errorf("%s is not a domain search result", id)
+		}
+
+		module.DefaultSearchCache.Forget(previous.DomainType, previous.SearchParameter)
+
+		result, err := d.searcher.Search(ctx, previous.DomainType, domain.DomainSearchParams{Query: previous.SearchParameter})
+		if err != nil || result == nil {
+			infra.Logger(ctx).Warn("refresh search failed",
+				slog.String("domain_type", string(previous.DomainType)),
+				slog.String("search_result_id", id.String()),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		result.ID = id
+		if err := pipelineRepo.Update(ctx, id.String(), result); err != nil {
+			return refreshed, fmt.Errorf("error updating refreshed search result: %w", err)
+		}
+
+		if _, err := d.persistDomainOutput(ctx, d.repositories, result); err != nil {
+			return refreshed, err
+		}
+
+		refreshed++
+	}
+
+	return refreshed, nil
+}
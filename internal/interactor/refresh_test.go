@@ -0,0 +1,57 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRefreshSearchResultsRescrapes(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	var searches []string
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches = append(searches, string(domainType)+":"+params.Query)
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output:          []domain.PGRNews{{URL: "https://pgr.gob.do/novasco", Title: "Novasco"}},
+		}, nil
+	})
+
+	resultID := domain.NewID()
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(resultID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"success", "error_message", "domain_type", "search_parameter", "keywords_per_category", "output",
+		}).AddRow(true, "", "PGR", "novasco", "{}", "[]"))
+	mock.ExpectExec("UPDATE domain_search_results").
+		WithArgs(true, "", "PGR", "novasco", sqlmock.AnyArg(), sqlmock.AnyArg(), resultID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM pgr_news").
+		WithArgs("https://pgr.gob.do/novasco").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO pgr_news").
+		WithArgs(sqlmock.AnyArg(), resultID, "https://pgr.gob.do/novasco", "Novasco", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	interactor := NewDynamicPipelineInteractor(repos)
+	refreshed, err := interactor.RefreshSearchResults(context.Background(), []domain.ID{resultID, resultID})
+	if err != nil {
+		t.Fatalf("RefreshSearchResults returned error: %v", err)
+	}
+
+	if refreshed != 1 {
+		t.Errorf("expected 1 refreshed search result, got %d", refreshed)
+	}
+	if len(searches) != 1 || searches[0] != "PGR:novasco" {
+		t.Errorf("expected the stored search to run once again, got %v", searches)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
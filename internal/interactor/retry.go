@@ -0,0 +1,101 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"insightful-intel/internal/repositories"
+	"log/slog"
+)
+
+// RetryFailedSteps re-executes the failed steps of a stored pipeline, merges the
+// ones that now succeed into it and recomputes its counters. It returns the
+// updated pipeline and the number of steps that were resolved.
+func (d *DynamicPipelineInteractor) RetryFailedSteps(ctx context.Context, pipelineID string) (*domain.DynamicPipelineResult, int, error) {
+	pipelineRepo := d.repositories.GetPipelineRepository()
+
+	pipeline, err := pipelineRepo.GetPipelineByID(ctx, pipelineID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The retries search like the run did, within its limits and attempts
+	ctx, _ = d.withRunLimits(ctx, pipeline.Config)
+
+	resolved := 0
+	for i := range pipeline.Steps {
+		step := &pipeline.Steps[i]
+		// Skipped steps never ran, so there is nothing to retry
+		if step.Success || step.SkipReason != "" {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, resolved, err
+		}
+
+		result, err := d.searchStep(ctx, pipeline.Config, *step)
+		if err != nil || result == nil {
+			infra.Logger(infra.SetStepID(ctx, step.ID.String())).Warn("retried step failed again",
+				slog.String("domain_type", string(step.DomainType)),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		retried := *step
+		retried.PipelineID = pipeline.ID
+		retried.Success = true
+		retried.Error = nil
+		retried.Output = result.Output
+		retried.KeywordsPerCategory = result.KeywordsPerCategory
+
+		// The step, its search result and its records are stored together, so a
+		// failure midway leaves the step failed as it was
+		err = d.repositories.WithTx(ctx, func(repos repositories.Factory) error {
+			if err := repos.GetPipelineRepository().UpdateDynamicPipelineStep(ctx, &retried); err != nil {
+				return err
+			}
+
+			result.PipelineStepsID = retried.ID
+			created, err := repos.GetPipelineRepository().CreateDomainSearchResult(ctx, result)
+			if err != nil {
+				return fmt.Errorf("error saving retried search result: %w", err)
+			}
+
+			_, err = d.persistDomainOutput(ctx, repos, created)
+			return err
+		})
+		if err != nil {
+			return nil, resolved, err
+		}
+
+		*step = retried
+		resolved++
+	}
+
+	// Count the steps the way the executor does, keeping the skipped ones apart
+	pipeline.TotalSteps = 0
+	pipeline.SuccessfulSteps = 0
+	pipeline.FailedSteps = 0
+	pipeline.SkippedSteps = 0
+	for _, step := range pipeline.Steps {
+		switch {
+		case step.SkipReason != "":
+			pipeline.SkippedSteps++
+			continue
+		case step.Success:
+			pipeline.SuccessfulSteps++
+		default:
+			pipeline.FailedSteps++
+		}
+		pipeline.TotalSteps++
+	}
+
+	if err := pipelineRepo.UpdateDynamicPipelineResult(ctx, pipeline); err != nil {
+		return nil, resolved, err
+	}
+
+	return pipeline, resolved, nil
+}
@@ -0,0 +1,191 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRetryFailedStepsMergesResolvedSteps(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		if domainType == domain.DomainTypeDGII {
+			return &domain.DomainSearchResult{DomainType: domainType, Success: false}, errors.New("dgii still down")
+		}
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output: []domain.Entity{
+				{SerieExpediente: 2020, NumeroExpediente: 1, Texto: "NOVASCO"},
+			},
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO"},
+			},
+		}, nil
+	})
+
+	pipelineID := domain.NewID()
+	onapiStepID := domain.NewID()
+
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(pipelineID.String(), nil, 3, 1, 2, 0, "", `{"query":"novasco"}`, "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+
+	stepColumns := []string{
+		"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+		"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+	}
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows(stepColumns).
+			AddRow(onapiStepID.String(), "ONAPI", "novasco", "company_name", `["novasco"]`, false, "timeout", "", "null", "null", 0, nil).
+			AddRow(domain.NewID().String(), "DGII", "novasco", "contributor_id", `["novasco"]`, false, "timeout", "", "null", "null", 0, nil).
+			AddRow(domain.NewID().String(), "SCJ", "novasco", "contributor_id", `["novasco"]`, true, "", "", "[]", "{}", 0, nil))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE dynamic_pipeline_steps").
+		WithArgs(true, "", sqlmock.AnyArg(), sqlmock.AnyArg(), onapiStepID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM step_keywords").
+		WithArgs(onapiStepID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO step_keywords").
+		WithArgs(sqlmock.AnyArg(), onapiStepID, "company_name", "NOVASCO", "novasco").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO domain_search_results").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM onapi_entities").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO onapi_entities").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").
+		WithArgs(3, 2, 1, 0, "", sqlmock.AnyArg(), pipelineID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	interactor := NewDynamicPipelineInteractor(repos)
+	pipeline, resolved, err := interactor.RetryFailedSteps(context.Background(), pipelineID.String())
+	if err != nil {
+		t.Fatalf("RetryFailedSteps returned error: %v", err)
+	}
+
+	if resolved != 1 {
+		t.Errorf("expected 1 resolved step, got %d", resolved)
+	}
+	if pipeline.TotalSteps != 3 || pipeline.SuccessfulSteps != 2 || pipeline.FailedSteps != 1 {
+		t.Errorf("unexpected counters: total=%d successful=%d failed=%d",
+			pipeline.TotalSteps, pipeline.SuccessfulSteps, pipeline.FailedSteps)
+	}
+
+	onapiStep := pipeline.Steps[0]
+	if !onapiStep.Success || onapiStep.Error != nil {
+		t.Errorf("expected ONAPI step to be resolved, got %+v", onapiStep)
+	}
+	if len(onapiStep.KeywordsPerCategory[domain.KeywordCategoryCompanyName]) != 1 {
+		t.Errorf("expected keywords to be merged, got %v", onapiStep.KeywordsPerCategory)
+	}
+	if pipeline.Steps[1].Success {
+		t.Error("expected DGII step to remain failed")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRetryFailedStepsLeavesSkippedStepsAlone(t *testing.T) {
+	repos := repositories.NewMemoryRepositoryFactory()
+	ctx := context.Background()
+
+	var searched []domain.DomainType
+	budgeted := true
+	searcher := SearcherFunc(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searched = append(searched, domainType)
+		if params.Attempts != 3 {
+			t.Errorf("expected the retry to search with the attempts of the run, got %d", params.Attempts)
+		}
+		budgeted = budgeted && custom.BudgetFromContext(ctx) != nil
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Entity{}}, nil
+	})
+
+	pipeline, err := repos.GetPipelineRepository().CreateDynamicPipelineResult(ctx, &domain.DynamicPipelineResult{
+		Steps: []domain.DynamicPipelineStep{
+			{DomainType: domain.DomainTypeONAPI, SearchParameter: "novasco", Category: domain.KeywordCategoryCompanyName, Error: errors.New("timeout")},
+			{DomainType: domain.DomainTypeDGII, SearchParameter: "novasco", Category: domain.KeywordCategoryCompanyName, SkipReason: domain.SkipReasonSourceUnavailable},
+		},
+		Config:       domain.DynamicPipelineConfig{Query: "novasco", SearchAttempts: 3},
+		TotalSteps:   1,
+		FailedSteps:  1,
+		SkippedSteps: 1,
+	})
+	if err != nil {
+		t.Fatalf("CreateDynamicPipelineResult returned error: %v", err)
+	}
+
+	retried, resolved, err := NewDynamicPipelineInteractorWithSearcher(repos, searcher).RetryFailedSteps(ctx, pipeline.ID.String())
+	if err != nil {
+		t.Fatalf("RetryFailedSteps returned error: %v", err)
+	}
+
+	if resolved != 1 || len(searched) != 1 || searched[0] != domain.DomainTypeONAPI {
+		t.Errorf("expected only the failed ONAPI step to be retried, resolved %d and searched %v", resolved, searched)
+	}
+	if !budgeted {
+		t.Error("expected the retry to count its requests against a budget")
+	}
+	if retried.TotalSteps != 1 || retried.SuccessfulSteps != 1 || retried.FailedSteps != 0 || retried.SkippedSteps != 1 {
+		t.Errorf("expected the skipped step counted apart, got total=%d successful=%d failed=%d skipped=%d",
+			retried.TotalSteps, retried.SuccessfulSteps, retried.FailedSteps, retried.SkippedSteps)
+	}
+}
+
+func TestRetryFailedStepsRollsBackFailedStepWrites(t *testing.T) {
+	repos, mock := newMockRepositories(t)
+
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Entity{}}, nil
+	})
+
+	pipelineID := domain.NewID()
+	stepID := domain.NewID()
+
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(pipelineID.String(), nil, 1, 0, 1, 0, "", `{"query":"novasco"}`, "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).AddRow(stepID.String(), "ONAPI", "novasco", "company_name", `["novasco"]`, false, "timeout", "", "null", "null", 0, nil))
+
+	// The search result fails to store, so the step update is rolled back
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM step_keywords").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO domain_search_results").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, resolved, err := NewDynamicPipelineInteractor(repos).RetryFailedSteps(context.Background(), pipelineID.String())
+	if err == nil {
+		t.Fatal("expected the failed write to be returned")
+	}
+	if resolved != 0 {
+		t.Errorf("expected no resolved step, got %d", resolved)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
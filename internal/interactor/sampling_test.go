@@ -0,0 +1,72 @@
+package interactor
+
+import (
+	"fmt"
+	"insightful-intel/internal/domain"
+	"slices"
+	"testing"
+)
+
+// exploreSampled expands a fixed keyword graph with generateNextSteps, walking
+// the frontier in the given order, and returns the steps it generated
+func exploreSampled(config domain.DynamicPipelineConfig, reverse bool) []string {
+	availableDomains := []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeSCJ, domain.DomainTypePGR}
+	searched := make(map[domain.DomainType]map[string]bool)
+	for _, domainType := range availableDomains {
+		searched[domainType] = make(map[string]bool)
+	}
+	visited := make(map[domain.VisitedKeyword]bool)
+
+	keywordsOf := func(step domain.DynamicPipelineStep) []string {
+		var keywords []string
+		for i := 0; i < 8; i++ {
+			keywords = append(keywords, fmt.Sprintf("%s empresa %d", step.SearchParameter, i))
+		}
+		return keywords
+	}
+
+	frontier := []domain.DynamicPipelineStep{{DomainType: domain.DomainTypeDGII, SearchParameter: "novasco"}}
+	var generated []string
+	for len(frontier) > 0 {
+		if reverse {
+			slices.Reverse(frontier)
+		}
+		var next []domain.DynamicPipelineStep
+		for _, step := range frontier {
+			if step.Depth >= 2 {
+				continue
+			}
+			step.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: keywordsOf(step),
+			}
+			for _, newStep := range (&DynamicPipelineInteractor{}).generateNextSteps(step, availableDomains, searched, visited, nil, config) {
+				generated = append(generated, string(newStep.DomainType)+"|"+newStep.SearchParameter)
+				next = append(next, newStep)
+			}
+		}
+		frontier = next
+	}
+
+	slices.Sort(generated)
+	return generated
+}
+
+func TestGenerateNextStepsSamplesDeterministically(t *testing.T) {
+	all := exploreSampled(domain.DynamicPipelineConfig{}, false)
+
+	config := domain.DynamicPipelineConfig{Seed: 42, SampleRate: 0.5}
+	first := exploreSampled(config, false)
+	second := exploreSampled(config, true)
+
+	if !slices.Equal(first, second) {
+		t.Fatalf("expected the same seed to explore the same steps, got %d and %d steps", len(first), len(second))
+	}
+	if len(first) == 0 || len(first) >= len(all) {
+		t.Errorf("expected a partial exploration, got %d of %d steps", len(first), len(all))
+	}
+
+	other := exploreSampled(domain.DynamicPipelineConfig{Seed: 7, SampleRate: 0.5}, false)
+	if slices.Equal(first, other) {
+		t.Error("expected different seeds to explore different steps")
+	}
+}
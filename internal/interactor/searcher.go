@@ -0,0 +1,24 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/module"
+)
+
+// Searcher performs the domain searches of the interactor
+type Searcher interface {
+	Search(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error)
+}
+
+// SearcherFunc adapts a search function to a Searcher
+type SearcherFunc func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error)
+
+// Search calls f
+func (f SearcherFunc) Search(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+	return f(ctx, domainType, params)
+}
+
+// defaultSearcher is the Searcher of the interactors created without one.
+// Tests replace it with stubs so no outbound request is made.
+var defaultSearcher Searcher = SearcherFunc(module.SearchDomain)
@@ -0,0 +1,163 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"sync"
+	"testing"
+)
+
+// fakeRepositories keeps in memory what a pipeline stores. The stores a test
+// does not expect to be used are nil, so using them panics.
+type fakeRepositories struct {
+	repositories.Factory
+
+	pipelines *fakePipelineStore
+	onapi     *fakeOnapiStore
+}
+
+func newFakeRepositories() *fakeRepositories {
+	return &fakeRepositories{
+		pipelines: &fakePipelineStore{results: map[domain.ID]domain.DynamicPipelineResult{}},
+		onapi:     &fakeOnapiStore{},
+	}
+}
+
+func (f *fakeRepositories) WithTx(ctx context.Context, fn func(repos repositories.Factory) error) error {
+	return fn(f)
+}
+
+func (f *fakeRepositories) GetPipelineRepository() repositories.PipelineStore {
+	return f.pipelines
+}
+
+func (f *fakeRepositories) GetOnapiRepository() repositories.OnapiStore {
+	return f.onapi
+}
+
+type fakePipelineStore struct {
+	repositories.PipelineStore
+
+	mu             sync.Mutex
+	results        map[domain.ID]domain.DynamicPipelineResult
+	steps          []domain.DynamicPipelineStep
+	searchResults  []domain.DomainSearchResult
+	updatedResults int
+}
+
+func (s *fakePipelineStore) CreateDynamicPipelineResult(ctx context.Context, result *domain.DynamicPipelineResult) (*domain.DynamicPipelineResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[result.ID] = *result
+	return result, nil
+}
+
+func (s *fakePipelineStore) UpdateDynamicPipelineResult(ctx context.Context, result *domain.DynamicPipelineResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[result.ID] = *result
+	s.updatedResults++
+	return nil
+}
+
+func (s *fakePipelineStore) CreateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if step.ID == (domain.ID{}) {
+		step.ID = domain.NewID()
+	}
+	s.steps = append(s.steps, *step)
+	return nil
+}
+
+func (s *fakePipelineStore) CreateDomainSearchResult(ctx context.Context, result *domain.DomainSearchResult) (*domain.DomainSearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result.ID = domain.NewID()
+	s.searchResults = append(s.searchResults, *result)
+	return result, nil
+}
+
+func (s *fakePipelineStore) SpillQueuedSteps(ctx context.Context, pipelineID domain.ID, steps []domain.DynamicPipelineStep) error {
+	if len(steps) > 0 {
+		panic("unexpected spilled steps")
+	}
+	return nil
+}
+
+type fakeOnapiStore struct {
+	repositories.OnapiStore
+
+	mu       sync.Mutex
+	entities []domain.Entity
+}
+
+func (s *fakeOnapiStore) Upsert(ctx context.Context, entity domain.Entity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entities = append(s.entities, entity)
+	return nil
+}
+
+func TestExecuteDynamicPipelineWithInjectedSearcher(t *testing.T) {
+	repos := newFakeRepositories()
+
+	var mu sync.Mutex
+	searched := map[domain.DomainType][]string{}
+	searcher := SearcherFunc(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		searched[domainType] = append(searched[domainType], params.Query)
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+			if params.Query == "novasco" {
+				result.Output = []domain.Entity{{SerieExpediente: 2020, NumeroExpediente: 1, Texto: "NOVASCO HOLDING SRL", Titular: "NOVASCO HOLDING SRL"}}
+				result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+					domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+				}
+			}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		return result, nil
+	})
+
+	result, err := NewDynamicPipelineInteractorWithSearcher(repos, searcher).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:            "novasco",
+		MaxDepth:         1,
+		SkipDuplicates:   true,
+		AvailableDomains: []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+
+	// The seed is searched in both domains, and the company ONAPI found in DGII
+	if len(searched[domain.DomainTypeONAPI]) != 1 || len(searched[domain.DomainTypeDGII]) != 2 {
+		t.Errorf("unexpected searches: %v", searched)
+	}
+	if result.TotalSteps != 3 || result.SuccessfulSteps != 3 || result.MaxDepthReached != 1 {
+		t.Errorf("unexpected totals: total=%d successful=%d depth=%d", result.TotalSteps, result.SuccessfulSteps, result.MaxDepthReached)
+	}
+
+	pipelines := repos.pipelines
+	if len(pipelines.steps) != 3 || len(pipelines.searchResults) != 3 {
+		t.Errorf("expected 3 stored steps and search results, got %d and %d", len(pipelines.steps), len(pipelines.searchResults))
+	}
+	stored, ok := pipelines.results[result.ID]
+	if !ok || pipelines.updatedResults != 1 || stored.TotalSteps != 3 {
+		t.Errorf("expected the pipeline to be stored and updated with its totals, got %+v", stored)
+	}
+	for _, searchResult := range pipelines.searchResults {
+		if searchResult.PipelineStepsID == (domain.ID{}) {
+			t.Errorf("expected the search result of %q to reference its step", searchResult.SearchParameter)
+		}
+	}
+	if len(repos.onapi.entities) != 1 || repos.onapi.entities[0].DomainSearchResultID == (domain.ID{}) {
+		t.Errorf("expected the ONAPI entity to be stored with its search result, got %+v", repos.onapi.entities)
+	}
+}
@@ -0,0 +1,53 @@
+package interactor
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"strings"
+)
+
+type SessionInteractor struct {
+	repositories repositories.Factory
+}
+
+func NewSessionInteractor(repositoryFactory repositories.Factory) *SessionInteractor {
+	return &SessionInteractor{
+		repositories: repositoryFactory,
+	}
+}
+
+// CreateSession opens a new investigation session
+func (s *SessionInteractor) CreateSession(ctx context.Context, name, description string) (*domain.Session, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("session name is required")
+	}
+
+	session := &domain.Session{
+		Name:        name,
+		Description: strings.TrimSpace(description),
+	}
+	if err := s.repositories.GetSessionRepository().Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetSessionView returns a session with its pipelines and the entities and
+// findings merged across them
+func (s *SessionInteractor) GetSessionView(ctx context.Context, sessionID string) (*domain.SessionView, error) {
+	session, err := s.repositories.GetSessionRepository().GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines, err := s.repositories.GetPipelineRepository().GetPipelinesBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewSessionView(*session, pipelines), nil
+}
@@ -0,0 +1,103 @@
+package interactor
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// runTraversal runs a pipeline over a small keyword graph and returns the steps
+// in the order they were searched
+func runTraversal(t *testing.T, mode domain.TraversalMode, maxDepth, wantSteps int) []string {
+	t.Helper()
+
+	repos, mock := newMockRepositories(t)
+	mock.MatchExpectationsInOrder(false)
+
+	// The companies found by each search, which the other domain searches next
+	graph := map[string][]string{
+		"ONAPI:novasco": {"alpha", "beta"},
+		"DGII:novasco":  {"gamma"},
+		"DGII:alpha":    {"delta"},
+	}
+
+	var mu sync.Mutex
+	var order []string
+	stubSearchDomain(t, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		key := string(domainType) + ":" + params.Query
+		mu.Lock()
+		order = append(order, key)
+		mu.Unlock()
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch domainType {
+		case domain.DomainTypeONAPI:
+			result.Output = []domain.Entity{}
+		case domain.DomainTypeDGII:
+			result.Output = []domain.Register{}
+		}
+		if companies, ok := graph[key]; ok {
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: companies,
+			}
+		}
+		return result, nil
+	})
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < wantSteps; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO domain_search_results").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	for range graph {
+		mock.ExpectExec("INSERT INTO step_keywords").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("UPDATE dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := NewDynamicPipelineInteractor(repos).ExecuteDynamicPipelineWithConfig(context.Background(), domain.DynamicPipelineConfig{
+		Query:              "novasco",
+		MaxDepth:           maxDepth,
+		MaxConcurrentSteps: 1,
+		SkipDuplicates:     true,
+		TraversalMode:      mode,
+		AvailableDomains:   []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipelineWithConfig returned error: %v", err)
+	}
+	if result.TotalSteps != wantSteps {
+		t.Errorf("expected %d steps, got %d: %v", wantSteps, result.TotalSteps, order)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	return order
+}
+
+func TestExecuteDynamicPipelineTraversalModes(t *testing.T) {
+	bfs := runTraversal(t, "", 2, 6)
+	wantBFS := []string{"ONAPI:novasco", "DGII:novasco", "DGII:alpha", "DGII:beta", "ONAPI:gamma", "ONAPI:delta"}
+	if !slices.Equal(bfs, wantBFS) {
+		t.Errorf("expected breadth-first order %v, got %v", wantBFS, bfs)
+	}
+
+	dfs := runTraversal(t, domain.TraversalDFS, 2, 6)
+	wantDFS := []string{"ONAPI:novasco", "DGII:alpha", "ONAPI:delta", "DGII:beta", "DGII:novasco", "ONAPI:gamma"}
+	if !slices.Equal(dfs, wantDFS) {
+		t.Errorf("expected depth-first order %v, got %v", wantDFS, dfs)
+	}
+}
+
+func TestExecuteDynamicPipelineDepthFirstRespectsMaxDepth(t *testing.T) {
+	order := runTraversal(t, domain.TraversalDFS, 1, 5)
+	if slices.Contains(order, "ONAPI:delta") {
+		t.Errorf("expected the step past the max depth not to run, got %v", order)
+	}
+}
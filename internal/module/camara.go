@@ -0,0 +1,244 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+var _ domain.DomainConnector[domain.CamaraRecord] = &Camara{}
+
+// Camara is the connector for the mercantile registry of the chambers of commerce
+type Camara struct {
+	Stuff    custom.Client
+	BaseParh string
+	PathMap  custom.CustomPathMap
+}
+
+type CamaraSearchResponse struct {
+	Data []CamaraRecordResponse `json:"data"`
+}
+
+type CamaraRecordResponse struct {
+	RegistroMercantil string                 `json:"registroMercantil"`
+	RNC               string                 `json:"rnc"`
+	RazonSocial       string                 `json:"razonSocial"`
+	NombreComercial   string                 `json:"nombreComercial"`
+	Camara            string                 `json:"camara"`
+	Estado            string                 `json:"estado"`
+	FechaConstitucion string                 `json:"fechaConstitucion"`
+	Domicilio         string                 `json:"domicilio"`
+	Gerentes          []CamaraPersonResponse `json:"gerentes"`
+	Socios            []CamaraPersonResponse `json:"socios"`
+}
+
+type CamaraPersonResponse struct {
+	Nombre    string `json:"nombre"`
+	Cedula    string `json:"cedula"`
+	Cargo     string `json:"cargo"`
+	Domicilio string `json:"domicilio"`
+}
+
+// NewCamaraDomain creates a new mercantile registry connector
+func NewCamaraDomain() domain.DomainConnector[domain.CamaraRecord] {
+	return &Camara{
+		BaseParh: "https://www.camarasantodomingo.do/api/registro-mercantil/",
+		Stuff:    *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: "https://www.camarasantodomingo.do/api/registro-mercantil/",
+			Paths: map[string]string{
+				"search": "buscar",
+			},
+		},
+	}
+}
+
+func (*Camara) GetDomainType() domain.DomainType {
+	return domain.DomainTypeCamara
+}
+
+// Search looks up registrations by RNC when the query is a contributor ID and
+// by company name otherwise
+func (c *Camara) Search(ctx context.Context, query string) ([]domain.CamaraRecord, error) {
+	params := map[string]string{
+		"razonSocial": query,
+		"pageSize":    "50",
+		"pageIdx":     "1",
+	}
+	if isContributorID(query) {
+		params = map[string]string{
+			"rnc":      strings.ReplaceAll(query, "-", ""),
+			"pageSize": "50",
+			"pageIdx":  "1",
+		}
+	}
+
+	response, err := c.Stuff.Get(ctx, c.PathMap.GetURLFrom("search"), params, map[string]string{
+		"Accept":     "application/json",
+		"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: response.StatusCode}
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var camaraResponse CamaraSearchResponse
+	if err := json.Unmarshal(body, &camaraResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+
+	records := []domain.CamaraRecord{}
+	for _, item := range camaraResponse.Data {
+		record, err := c.ProcessData(toCamaraRecord(item))
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (c *Camara) ProcessData(data domain.CamaraRecord) (domain.CamaraRecord, error) {
+	if err := c.ValidateData(data); err != nil {
+		return domain.CamaraRecord{}, err
+	}
+	return c.TransformData(data), nil
+}
+
+func (c *Camara) ValidateData(data domain.CamaraRecord) error {
+	if data.RegistroMercantil == "" {
+		return fmt.Errorf("RegistroMercantil is required")
+	}
+	if data.RazonSocial == "" {
+		return fmt.Errorf("RazonSocial is required")
+	}
+	return nil
+}
+
+func (c *Camara) TransformData(data domain.CamaraRecord) domain.CamaraRecord {
+	transformed := data
+	transformed.RegistroMercantil = strings.TrimSpace(data.RegistroMercantil)
+	transformed.RNC = strings.TrimSpace(data.RNC)
+	transformed.RazonSocial = strings.TrimSpace(data.RazonSocial)
+	transformed.NombreComercial = strings.TrimSpace(data.NombreComercial)
+	transformed.Domicilio = strings.TrimSpace(data.Domicilio)
+
+	transformed.Gerentes = make([]domain.CamaraPerson, 0, len(data.Gerentes))
+	for _, person := range data.Gerentes {
+		transformed.Gerentes = append(transformed.Gerentes, trimCamaraPerson(person))
+	}
+
+	transformed.Socios = make([]domain.CamaraPerson, 0, len(data.Socios))
+	for _, person := range data.Socios {
+		transformed.Socios = append(transformed.Socios, trimCamaraPerson(person))
+	}
+
+	return transformed
+}
+
+func (c *Camara) GetDataByCategory(data domain.CamaraRecord, category domain.KeywordCategory) []string {
+	result := []string{}
+
+	switch category {
+	case domain.KeywordCategoryPersonName:
+		for _, person := range data.Gerentes {
+			result = append(result, person.Nombre)
+		}
+		for _, person := range data.Socios {
+			result = append(result, person.Nombre)
+		}
+	case domain.KeywordCategoryAddress:
+		result = append(result, data.Domicilio)
+		for _, person := range data.Gerentes {
+			result = append(result, person.Domicilio)
+		}
+		for _, person := range data.Socios {
+			result = append(result, person.Domicilio)
+		}
+	}
+
+	// Filter out empty strings from result
+	nonEmpty := make([]string, 0, len(result))
+	for _, item := range result {
+		if item != "" {
+			nonEmpty = append(nonEmpty, item)
+		}
+	}
+	return nonEmpty
+}
+
+func (c *Camara) GetSearchableKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryCompanyName,
+		domain.KeywordCategoryContributorID,
+	}
+}
+
+func (c *Camara) GetFoundKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryPersonName,
+		domain.KeywordCategoryAddress,
+	}
+}
+
+func toCamaraRecord(item CamaraRecordResponse) domain.CamaraRecord {
+	record := domain.CamaraRecord{
+		RegistroMercantil: item.RegistroMercantil,
+		RNC:               item.RNC,
+		RazonSocial:       item.RazonSocial,
+		NombreComercial:   item.NombreComercial,
+		Camara:            item.Camara,
+		Estado:            item.Estado,
+		FechaConstitucion: item.FechaConstitucion,
+		Domicilio:         item.Domicilio,
+	}
+
+	for _, person := range item.Gerentes {
+		record.Gerentes = append(record.Gerentes, domain.CamaraPerson(person))
+	}
+	for _, person := range item.Socios {
+		record.Socios = append(record.Socios, domain.CamaraPerson(person))
+	}
+
+	return record
+}
+
+func trimCamaraPerson(person domain.CamaraPerson) domain.CamaraPerson {
+	return domain.CamaraPerson{
+		Nombre:    strings.TrimSpace(person.Nombre),
+		Cedula:    strings.TrimSpace(person.Cedula),
+		Cargo:     strings.TrimSpace(person.Cargo),
+		Domicilio: strings.TrimSpace(person.Domicilio),
+	}
+}
+
+// isContributorID reports whether the query looks like an RNC (9 digits) or a
+// cédula (11 digits), ignoring dashes
+func isContributorID(query string) bool {
+	digits := strings.ReplaceAll(strings.TrimSpace(query), "-", "")
+	if len(digits) != 9 && len(digits) != 11 {
+		return false
+	}
+	for _, r := range digits {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+const camaraFixture = `{
+	"data": [
+		{
+			"registroMercantil": "12345SD",
+			"rnc": "130000001",
+			"razonSocial": " Novasco Real Estate SRL ",
+			"nombreComercial": "Novasco",
+			"camara": "Santo Domingo",
+			"estado": "ACTIVA",
+			"domicilio": "Av. Winston Churchill 10, Santo Domingo",
+			"gerentes": [
+				{"nombre": " Juan Perez ", "cedula": "00112345678", "cargo": "Gerente", "domicilio": "Calle 1, Naco"}
+			],
+			"socios": [
+				{"nombre": "Maria Gomez", "cedula": "00198765432", "cargo": "Socia"}
+			]
+		},
+		{
+			"registroMercantil": "",
+			"razonSocial": "Registro incompleto"
+		}
+	]
+}`
+
+func newTestCamara(t *testing.T, queries *[]url.Values) *Camara {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(camaraFixture))
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Camara{
+		Stuff: *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: srv.URL + "/",
+			Paths: map[string]string{
+				"search": "buscar",
+			},
+		},
+	}
+}
+
+func TestCamaraSearch(t *testing.T) {
+	var queries []url.Values
+	camara := newTestCamara(t, &queries)
+
+	records, err := camara.Search(context.Background(), "Novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("expected invalid records to be dropped, got %d records", len(records))
+	}
+
+	record := records[0]
+	if record.RazonSocial != "Novasco Real Estate SRL" {
+		t.Errorf("expected trimmed razon social, got %q", record.RazonSocial)
+	}
+	if len(record.Gerentes) != 1 || record.Gerentes[0].Nombre != "Juan Perez" {
+		t.Errorf("unexpected gerentes: %+v", record.Gerentes)
+	}
+
+	keywords := domain.GetCategoryByKeywords(domain.DomainConnector[domain.CamaraRecord](camara), records)
+	people := keywords[domain.KeywordCategoryPersonName]
+	if !slices.Contains(people, "Juan Perez") || !slices.Contains(people, "Maria Gomez") {
+		t.Errorf("expected officers and shareholders as person names, got %v", people)
+	}
+	addresses := keywords[domain.KeywordCategoryAddress]
+	if !slices.Contains(addresses, "Av. Winston Churchill 10, Santo Domingo") || !slices.Contains(addresses, "Calle 1, Naco") {
+		t.Errorf("unexpected addresses: %v", addresses)
+	}
+
+	if len(queries) != 1 || queries[0].Get("razonSocial") != "Novasco" {
+		t.Errorf("expected a company name search, got %v", queries)
+	}
+}
+
+func TestCamaraSearchByContributorID(t *testing.T) {
+	var queries []url.Values
+	camara := newTestCamara(t, &queries)
+
+	if _, err := camara.Search(context.Background(), "1-30-00000-1"); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(queries) != 1 || queries[0].Get("rnc") != "130000001" {
+		t.Errorf("expected an RNC search, got %v", queries)
+	}
+}
+
+func TestCamaraSearchUnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	camara := &Camara{
+		Stuff: *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: srv.URL + "/",
+			Paths:   map[string]string{"search": "buscar"},
+		},
+	}
+
+	if _, err := camara.Search(context.Background(), "Novasco"); err == nil {
+		t.Fatal("expected an error for a non-200 response")
+	}
+}
+
+func TestCamaraSearchCancelledContext(t *testing.T) {
+	var queries []url.Values
+	camara := newTestCamara(t, &queries)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := camara.Search(ctx, "Novasco"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(queries) != 0 {
+		t.Errorf("expected no request to reach the server, got %v", queries)
+	}
+}
@@ -0,0 +1,50 @@
+package module
+
+import (
+	"insightful-intel/internal/custom"
+	"log/slog"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// onapiCSRF and dgiiCSRF are the CSRF sessions shared by the ONAPI and DGII
+// connectors, so the token and its cookies are reused across their searches.
+// They are nil unless the environment configures them.
+var (
+	onapiCSRF = sync.OnceValue(func() *custom.CSRFSession { return csrfSessionFromEnv("ONAPI") })
+	dgiiCSRF  = sync.OnceValue(func() *custom.CSRFSession { return csrfSessionFromEnv("DGII") })
+)
+
+// csrfSessionFromEnv builds the CSRF session of a domain from the
+// <prefix>_CSRF_* variables, returning nil when <prefix>_CSRF_PAGE_URL, the
+// page handing out the token, is not set. _INPUT names the input or meta tag
+// holding the token, or _PATTERN extracts it as its first submatch; _FIELD
+// and _HEADER are where it is sent, and _TTL is how long it is reused.
+func csrfSessionFromEnv(prefix string) *custom.CSRFSession {
+	pageURL := os.Getenv(prefix + "_CSRF_PAGE_URL")
+	if pageURL == "" {
+		return nil
+	}
+
+	config := custom.CSRFConfig{
+		PageURL:   pageURL,
+		InputName: os.Getenv(prefix + "_CSRF_INPUT"),
+		Field:     os.Getenv(prefix + "_CSRF_FIELD"),
+		Header:    os.Getenv(prefix + "_CSRF_HEADER"),
+	}
+	if pattern := os.Getenv(prefix + "_CSRF_PATTERN"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			slog.Warn("ignoring invalid CSRF token pattern", slog.String("variable", prefix+"_CSRF_PATTERN"), slog.Any("error", err))
+		} else {
+			config.Pattern = re
+		}
+	}
+	if ttl, err := time.ParseDuration(os.Getenv(prefix + "_CSRF_TTL")); err == nil {
+		config.TTL = ttl
+	}
+
+	return custom.NewCSRFSession(custom.NewClient(), config)
+}
@@ -0,0 +1,122 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCSRFSessionFromEnv(t *testing.T) {
+	if session := csrfSessionFromEnv("DGII"); session != nil {
+		t.Fatalf("expected no session without DGII_CSRF_PAGE_URL, got %+v", session.Config)
+	}
+
+	t.Setenv("DGII_CSRF_PAGE_URL", "https://example.test/form")
+	t.Setenv("DGII_CSRF_INPUT", "__RequestVerificationToken")
+	t.Setenv("DGII_CSRF_PATTERN", `name="token" value="([^"]+)"`)
+	t.Setenv("DGII_CSRF_HEADER", "X-CSRF-Token")
+	t.Setenv("DGII_CSRF_TTL", "5m")
+
+	session := csrfSessionFromEnv("DGII")
+	if session == nil {
+		t.Fatal("expected a session")
+	}
+	config := session.Config
+	if config.PageURL != "https://example.test/form" || config.InputName != "__RequestVerificationToken" || config.Header != "X-CSRF-Token" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if config.Pattern == nil || config.TTL != 5*time.Minute {
+		t.Errorf("expected the pattern and TTL to be set, got %+v", config)
+	}
+
+	t.Setenv("DGII_CSRF_PATTERN", "(")
+	if session := csrfSessionFromEnv("DGII"); session == nil || session.Config.Pattern != nil {
+		t.Error("expected an invalid pattern to be ignored")
+	}
+}
+
+func TestDgiiSearchesShareCSRFSession(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		sessions  int
+		tokenGets int
+		rejected  int
+	)
+	// The server binds the token and the view state of the form to the session
+	// cookie it hands out on the first request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		session := ""
+		if cookie, err := r.Cookie("session"); err == nil {
+			session = cookie.Value
+		} else {
+			sessions++
+			session = fmt.Sprintf("session-%d", sessions)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session})
+		}
+
+		switch {
+		case r.URL.Path == "/token":
+			tokenGets++
+			fmt.Fprintf(w, `<input type="hidden" name="__RequestVerificationToken" value="token-%s" />`, session)
+		case r.Method == http.MethodGet:
+			fmt.Fprintf(w, `<form><input type="hidden" name="__VIEWSTATE" value="%s" /></form>`, session)
+		default:
+			r.ParseForm()
+			if r.PostForm.Get("__RequestVerificationToken") != "token-"+session || r.PostForm.Get("__VIEWSTATE") != session {
+				rejected++
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(dgiiRegisterFixture))
+		}
+	}))
+	defer srv.Close()
+
+	session := custom.NewCSRFSession(custom.NewClient(), custom.CSRFConfig{
+		PageURL:   srv.URL + "/token",
+		InputName: "__RequestVerificationToken",
+	})
+	previous := dgiiCSRF
+	dgiiCSRF = func() *custom.CSRFSession { return session }
+	defer func() { dgiiCSRF = previous }()
+
+	for range 2 {
+		dgii := NewDgiiDomain().(*Dgii)
+		dgii.BaseParh = srv.URL + "/rnc"
+
+		registers, err := dgii.SearchByRNC(context.Background(), "401-50625-4")
+		if err != nil {
+			t.Fatalf("SearchByRNC returned error: %v", err)
+		}
+		if len(registers) != 1 {
+			t.Fatalf("expected 1 register, got %d", len(registers))
+		}
+	}
+
+	if sessions != 1 || tokenGets != 1 || rejected != 0 {
+		t.Errorf("expected one session and token reused by both searches, got %d sessions, %d token fetches and %d rejections", sessions, tokenGets, rejected)
+	}
+}
+
+func TestOnapiUsesSharedCSRFSession(t *testing.T) {
+	session := custom.NewCSRFSession(custom.NewClient(), custom.CSRFConfig{PageURL: "https://example.test/form"})
+	previous := onapiCSRF
+	onapiCSRF = func() *custom.CSRFSession { return session }
+	defer func() { onapiCSRF = previous }()
+
+	onapi := NewOnapiDomain().(*Onapi)
+	if onapi.CSRF != session {
+		t.Fatal("expected the connector to use the shared session")
+	}
+	if onapi.Stuff.Client != session.Client().Client {
+		t.Error("expected the connector to send its requests with the client of the session")
+	}
+}
@@ -2,10 +2,12 @@ package module
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -18,6 +20,9 @@ type Dgii struct {
 	Stuff    custom.Client
 	BaseParh string
 	PathMap  custom.CustomPathMap
+	// CSRF, when set, adds the anti-forgery token of the session to the
+	// search requests
+	CSRF *custom.CSRFSession
 }
 
 func (*Dgii) GetDomainType() domain.DomainType {
@@ -83,6 +88,7 @@ func (dgi *Dgii) GetSearchableKeywordCategories() []domain.KeywordCategory {
 	return []domain.KeywordCategory{
 		domain.KeywordCategoryCompanyName,
 		domain.KeywordCategoryPersonName,
+		domain.KeywordCategoryContributorID,
 	}
 }
 
@@ -94,19 +100,103 @@ func (dgi *Dgii) GetFoundKeywordCategories() []domain.KeywordCategory {
 }
 
 func NewDgiiDomain() domain.DomainConnector[domain.Register] {
-	return &Dgii{
+	dgi := &Dgii{
 		BaseParh: "https://dgii.gov.do/app/WebApps/ConsultasWeb2/ConsultasWeb/consultas/rnc.aspx",
 		Stuff:    *custom.NewClient(),
 	}
+	// The form is loaded with the client of the CSRF session, so it shares the
+	// cookies of the token
+	if session := dgiiCSRF(); session != nil {
+		dgi.Stuff = *session.Client()
+		dgi.CSRF = session
+	}
+	return dgi
 }
 
 // Search implements DomainConnector interface by wrapping GetRegister
-func (dgi *Dgii) Search(query string) ([]domain.Register, error) {
-	return dgi.GetRegister(query)
+func (dgi *Dgii) Search(ctx context.Context, query string) ([]domain.Register, error) {
+	return dgi.GetRegister(ctx, query)
 }
 
-func (dgi *Dgii) GetRegister(query string) ([]domain.Register, error) {
-	response, err := dgi.Stuff.Get(dgi.BaseParh, map[string]string{
+// SearchByRNC looks up the register of an exact RNC or cédula, which is
+// validated before DGII is queried
+func (dgi *Dgii) SearchByRNC(ctx context.Context, rnc string) ([]domain.Register, error) {
+	if err := domain.ValidateRNC(rnc); err != nil {
+		return nil, err
+	}
+
+	data, err := dgi.formFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data["ctl00$smMain"] = "ctl00$cphMain$upBusqueda|ctl00$cphMain$btnBuscarPorRNC"
+	data["ctl00$cphMain$txtRNCCedula"] = domain.NormalizeRNC(rnc)
+	data["ctl00$cphMain$txtRazonSocial"] = ""
+	data["ctl00$cphMain$btnBuscarPorRNC"] = "Buscar"
+	data["ctl00$cphMain$hidActiveTab"] = "rnc"
+	data["__ASYNCPOST"] = "true"
+
+	formData := url.Values{}
+	for key, value := range data {
+		formData.Set(key, value)
+	}
+
+	resp, err := dgi.postForm(ctx, formData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make post request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// The register is shown as a two-column table of labels and values
+	doc, _ := html.Parse(bytes.NewReader(body))
+	fields := make(map[string]string)
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			var cells []string
+			for cell := n.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "th" || cell.Data == "td") {
+					cells = append(cells, strings.TrimSpace(getInnerText(cell)))
+				}
+			}
+			if len(cells) == 2 {
+				fields[strings.ToLower(cells[0])] = cells[1]
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+
+	register, err := dgi.ProcessData(domain.Register{
+		RNC:                   fields["cédula/rnc"],
+		RazonSocial:           fields["nombre/razón social"],
+		NombreComercial:       fields["nombre comercial"],
+		Categoria:             fields["categoría"],
+		RegimenPagos:          fields["régimen de pagos"],
+		Estado:                fields["estado"],
+		FacturadorElectronico: fields["facturador electrónico"],
+		LicenciaComercial:     fields["licencias de comercialización de vhm"],
+	})
+	if err != nil {
+		// DGII shows no register for RNCs it does not know
+		return []domain.Register{}, nil
+	}
+
+	return []domain.Register{register}, nil
+}
+
+// formFields loads the search form and returns the values of its inputs, which
+// the ASP.NET page expects back on every search
+func (dgi *Dgii) formFields(ctx context.Context) (map[string]string, error) {
+	response, err := dgi.Stuff.Get(ctx, dgi.BaseParh, map[string]string{
 		"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
 	}, map[string]string{
 		"User-Agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
@@ -143,6 +233,28 @@ func (dgi *Dgii) GetRegister(query string) ([]domain.Register, error) {
 	}
 	f(doc)
 
+	return data, nil
+}
+
+// postForm posts a search form, with the anti-forgery token of the CSRF
+// session when there is one
+func (dgi *Dgii) postForm(ctx context.Context, form url.Values) (*http.Response, error) {
+	headers := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded",
+		"User-Agent":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
+	}
+	if dgi.CSRF != nil {
+		return dgi.CSRF.PostForm(ctx, dgi.BaseParh, form, headers)
+	}
+	return dgi.Stuff.Post(ctx, dgi.BaseParh, form.Encode(), headers)
+}
+
+func (dgi *Dgii) GetRegister(ctx context.Context, query string) ([]domain.Register, error) {
+	data, err := dgi.formFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data["ctl00$MainContent$txtName"] = "bank"
 	data["ctl00$MainContent$cbIncludeCeased"] = "on"
 	data["ctl00$smMain"] = "ctl00$cphMain$upBusqueda|ctl00$cphMain$btnBuscarPorRazonSocial"
@@ -160,23 +272,20 @@ func (dgi *Dgii) GetRegister(query string) ([]domain.Register, error) {
 		formData.Set(key, value)
 	}
 
-	resp, err := dgi.Stuff.Post(dgi.BaseParh, formData.Encode(), map[string]string{
-		"Content-Type": "application/x-www-form-urlencoded",
-		"User-Agent":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
-	},
-	)
+	resp, err := dgi.postForm(ctx, formData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make post request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err = io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	doc, _ = html.Parse(bytes.NewReader(body))
+	doc, _ := html.Parse(bytes.NewReader(body))
 	var rows []*html.Node
+	var f func(*html.Node)
 	f = func(n *html.Node) {
 		if n.Data == "tr" && n.Parent.Data == "tbody" && (len(n.Attr) == 1 && n.Attr[0].Val == "TbRow") {
 			rows = append(rows, n)
@@ -0,0 +1,100 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const dgiiFormFixture = `<html><body><form>
+	<input type="hidden" name="__VIEWSTATE" value="state" />
+	<input type="text" name="ctl00$cphMain$txtRNCCedula" />
+</form></body></html>`
+
+const dgiiRegisterFixture = `<table id="cphMain_dvDatosContribuyentes"><tbody>
+	<tr><td>Cédula/RNC</td><td>401-50625-4</td></tr>
+	<tr><td>Nombre/Razón Social</td><td> DIRECCION GENERAL DE IMPUESTOS INTERNOS </td></tr>
+	<tr><td>Nombre Comercial</td><td>DGII</td></tr>
+	<tr><td>Categoría</td><td></td></tr>
+	<tr><td>Régimen de pagos</td><td>NORMAL</td></tr>
+	<tr><td>Estado</td><td>ACTIVO</td></tr>
+	<tr><td>Facturador Electrónico</td><td>SI</td></tr>
+	<tr><td>Licencias de Comercialización de VHM</td><td>N/A</td></tr>
+</tbody></table>`
+
+func newTestDgii(t *testing.T, posts *[]url.Values) *Dgii {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(dgiiFormFixture))
+			return
+		}
+
+		r.ParseForm()
+		*posts = append(*posts, r.PostForm)
+		if r.PostForm.Get("ctl00$cphMain$txtRNCCedula") == "401506254" {
+			w.Write([]byte(dgiiRegisterFixture))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Dgii{
+		Stuff:    *custom.NewClient(),
+		BaseParh: srv.URL,
+	}
+}
+
+func TestDgiiSearchByRNC(t *testing.T) {
+	var posts []url.Values
+	dgii := newTestDgii(t, &posts)
+
+	registers, err := dgii.SearchByRNC(context.Background(), "401-50625-4")
+	if err != nil {
+		t.Fatalf("SearchByRNC returned error: %v", err)
+	}
+
+	if len(registers) != 1 {
+		t.Fatalf("expected 1 register, got %d", len(registers))
+	}
+	if registers[0].RazonSocial != "DIRECCION GENERAL DE IMPUESTOS INTERNOS" || registers[0].Estado != "ACTIVO" {
+		t.Errorf("unexpected register: %+v", registers[0])
+	}
+
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 lookup, got %d", len(posts))
+	}
+	if posts[0].Get("__VIEWSTATE") != "state" || posts[0].Get("ctl00$cphMain$btnBuscarPorRNC") == "" {
+		t.Errorf("expected an exact lookup with the form state, got %v", posts[0])
+	}
+}
+
+func TestDgiiSearchByRNCUnknownRNC(t *testing.T) {
+	var posts []url.Values
+	dgii := newTestDgii(t, &posts)
+
+	registers, err := dgii.SearchByRNC(context.Background(), "130000001")
+	if err != nil {
+		t.Fatalf("SearchByRNC returned error: %v", err)
+	}
+	if len(registers) != 0 {
+		t.Errorf("expected no register, got %+v", registers)
+	}
+}
+
+func TestDgiiSearchByRNCRejectsMalformedRNC(t *testing.T) {
+	var posts []url.Values
+	dgii := newTestDgii(t, &posts)
+
+	if _, err := dgii.SearchByRNC(context.Background(), "401506255"); !errors.Is(err, domain.ErrInvalidRNC) {
+		t.Errorf("expected ErrInvalidRNC, got %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("expected DGII not to be queried, got %d lookups", len(posts))
+	}
+}
@@ -1,42 +1,54 @@
 package module
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
-	"io"
-	"log"
+	"log/slog"
 	"math"
-	"net/url"
-	"os"
+	"slices"
+	"sort"
 	"strings"
 	"unicode"
 )
 
 var _ domain.DomainConnector[domain.GoogleDorkingResult] = &GoogleDorking{}
 
-// GoogleDorking represents a Google Docking string search connector
+// ErrGoogleQuotaExceeded is returned when Google Custom Search refuses a search
+// because the daily quota or the rate limit of the key is exhausted
+var ErrGoogleQuotaExceeded = errors.New("google custom search quota exceeded")
+
+// GoogleDorking represents a Google Docking string search connector. It builds
+// the dork query of a search and ranks the results Provider fetches for it.
 type GoogleDorking struct {
-	Stuff    custom.Client
-	BasePath string
+	Provider SearchProvider
 	PathMap  custom.CustomPathMap
 }
 
-// NewGoogleDorkingDomain creates a new Google Docking domain instance
+// NewGoogleDorkingDomain creates a new Google Docking domain instance, which
+// searches Google Custom Search with a DuckDuckGo fallback when GOOGLE_API_KEY
+// and GOOGLE_CX_KEY are set, and DuckDuckGo only otherwise
 func NewGoogleDorkingDomain() GoogleDorking {
-	googleApiKey := os.Getenv("GOOGLE_API_KEY")
-	googleSearchEngineId := os.Getenv("GOOGLE_CX_KEY")
-	if googleApiKey == "" || googleSearchEngineId == "" {
-		log.Fatal("GOOGLE_API_KEY and GOOGLE_CX_KEY are not set")
-	}
+	fallback := NewDuckDuckGoSearchProvider()
 
-	googleSearchUrl := fmt.Sprintf("https://www.googleapis.com/customsearch/v1?key=%s&cx=%s", googleApiKey, googleSearchEngineId)
+	google, err := NewGoogleSearchProvider()
+	if err != nil {
+		return GoogleDorking{Provider: fallback}
+	}
+	return GoogleDorking{Provider: &FallbackSearchProvider{Primary: google, Fallback: fallback}}
+}
 
-	return GoogleDorking{
-		BasePath: googleSearchUrl,
-		Stuff:    *custom.NewClient(),
+// NewGoogleDorkingWithProvider creates a Google Docking domain instance
+// searching the provider of the given name only, one of the DorkingProvider
+// names
+func NewGoogleDorkingWithProvider(name string) (GoogleDorking, error) {
+	provider, err := NewSearchProvider(name)
+	if err != nil {
+		return GoogleDorking{}, err
 	}
+	return GoogleDorking{Provider: provider}, nil
 }
 
 type GoogleDorkingSearchResponse struct {
@@ -130,16 +142,16 @@ func (b *GoogleDorkingBuilder) ExcludeKeywords(keywords ...string) *GoogleDorkin
 }
 
 // Build executes the search and returns results
-func (b *GoogleDorkingBuilder) Build() ([]domain.GoogleDorkingResult, error) {
+func (b *GoogleDorkingBuilder) Build(ctx context.Context) ([]domain.GoogleDorkingResult, error) {
 	if b.params.Query == "" {
 		return nil, fmt.Errorf("query is required")
 	}
-	return b.gd.SearchWithParams(b.params)
+	return b.gd.SearchWithParams(ctx, b.params)
 }
 
 // BuildWithStats executes the search and returns results with statistics
-func (b *GoogleDorkingBuilder) BuildWithStats() ([]domain.GoogleDorkingResult, map[string]interface{}, error) {
-	results, err := b.Build()
+func (b *GoogleDorkingBuilder) BuildWithStats(ctx context.Context) ([]domain.GoogleDorkingResult, map[string]interface{}, error) {
+	results, err := b.Build(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -154,7 +166,7 @@ func (*GoogleDorking) GetDomainType() domain.DomainType {
 }
 
 // Search performs a Google Docking string search
-func (gd *GoogleDorking) Search(query string) ([]domain.GoogleDorkingResult, error) {
+func (gd *GoogleDorking) Search(ctx context.Context, query string) ([]domain.GoogleDorkingResult, error) {
 	params := domain.GoogleDorkingSearchParams{
 		Query:         query,
 		MaxResults:    10,
@@ -162,11 +174,11 @@ func (gd *GoogleDorking) Search(query string) ([]domain.GoogleDorkingResult, err
 		ExactMatch:    false,
 		CaseSensitive: false,
 	}
-	return gd.SearchWithParams(params)
+	return gd.SearchWithParams(ctx, params)
 }
 
 // SearchWithParams performs a Google Docking search with custom parameters
-func (gd *GoogleDorking) SearchWithParams(params domain.GoogleDorkingSearchParams) ([]domain.GoogleDorkingResult, error) {
+func (gd *GoogleDorking) SearchWithParams(ctx context.Context, params domain.GoogleDorkingSearchParams) ([]domain.GoogleDorkingResult, error) {
 	if params.Query == "" {
 		return nil, fmt.Errorf("query cannot be empty")
 	}
@@ -220,29 +232,134 @@ func (gd *GoogleDorking) SearchWithParams(params domain.GoogleDorkingSearchParam
 
 	q = fmt.Sprintf("%s %s", params.Query, q)
 
-	resp, err := gd.Stuff.Get(fmt.Sprintf("%s&q=%s", gd.BasePath, url.QueryEscape(q)), map[string]string{}, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	if gd.Provider == nil {
+		return nil, fmt.Errorf("no search provider configured")
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	results, err := gd.fetchPages(ctx, q, params.Pages)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
-	var result GoogleDorkingSearchResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	return gd.rankResults(results, params), nil
+}
+
+// fetchPages fetches up to pages result pages of the query, one when zero and
+// at most maxDorkingPages, stopping at the first page that adds no new URL.
+// Results repeated across pages are kept once.
+func (gd *GoogleDorking) fetchPages(ctx context.Context, q string, pages int) ([]domain.GoogleDorkingResult, error) {
+	if pages < 1 {
+		pages = 1
+	}
+	if pages > maxDorkingPages {
+		pages = maxDorkingPages
 	}
 
-	return result.Items, nil
+	var results []domain.GoogleDorkingResult
+	seen := make(map[string]bool)
+	for page := 1; page <= pages; page++ {
+		fetched, err := gd.Provider.Fetch(ctx, q, page)
+		if err != nil {
+			// The pages already fetched are still worth ranking
+			if page > 1 && ctx.Err() == nil {
+				slog.Warn("Failed to fetch docking results page", "page", page, "error", err)
+				break
+			}
+			return nil, err
+		}
+
+		added := 0
+		for _, result := range fetched {
+			if seen[result.URL] {
+				continue
+			}
+			seen[result.URL] = true
+			results = append(results, result)
+			added++
+		}
+		if added == 0 {
+			break
+		}
+	}
+	return results, nil
 }
 
-// calculateStringMatch calculates how well a string matches the query
-func (gd *GoogleDorking) calculateStringMatch(text, query string, params domain.GoogleDorkingSearchParams) float64 {
+// rankResults scores each result against the query, drops the ones below
+// MinRelevance (or without an exact match when ExactMatch is set), sorts them by
+// relevance and keeps at most MaxResults
+func (gd *GoogleDorking) rankResults(results []domain.GoogleDorkingResult, params domain.GoogleDorkingSearchParams) []domain.GoogleDorkingResult {
+	query := params.Query
+	if !params.CaseSensitive {
+		query = strings.ToLower(query)
+	}
+
+	ranked := make([]domain.GoogleDorkingResult, 0, len(results))
+	for _, result := range results {
+		if params.ExactMatch && !gd.hasExactMatch(result, query, params) {
+			continue
+		}
+
+		breakdown := gd.explainRelevance(result, query, params)
+		result.Relevance = breakdown.Relevance
+		if result.Relevance < params.MinRelevance {
+			continue
+		}
+		if params.Explain {
+			result.Explanation = &breakdown
+		}
+
+		ranked = append(ranked, result)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Relevance > ranked[j].Relevance
+	})
+
+	if params.MaxResults > 0 && len(ranked) > params.MaxResults {
+		ranked = ranked[:params.MaxResults]
+	}
+
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+
+	return ranked
+}
+
+// keywordMatchWeight is the share of the keyword match in the relevance of the
+// results with keywords
+const keywordMatchWeight = 0.2
+
+// explainRelevance blends the title/description match with the keyword match
+// into a score between 0 and 1, broken down into its components
+func (gd *GoogleDorking) explainRelevance(result domain.GoogleDorkingResult, query string, params domain.GoogleDorkingSearchParams) domain.RelevanceBreakdown {
+	breakdown := gd.explainStringMatch(result.Title, query, params)
+	breakdown.MatchedField = "title"
+	if description := gd.explainStringMatch(result.Description, query, params); description.TextScore > breakdown.TextScore {
+		breakdown = description
+		breakdown.MatchedField = "description"
+	}
+	if breakdown.TextScore == 0 {
+		breakdown.MatchedField = ""
+	}
+
+	score := breakdown.TextScore
+	if len(result.Keywords) > 0 {
+		breakdown.KeywordMatch = math.Min(gd.calculateKeywordMatch(result.Keywords, query, params), 1.0)
+		breakdown.KeywordWeight = keywordMatchWeight
+		score = score*(1-keywordMatchWeight) + breakdown.KeywordMatch*keywordMatchWeight
+	}
+
+	breakdown.Relevance = math.Min(score, 1.0)
+	return breakdown
+}
+
+// explainStringMatch calculates how well a string matches the query, as the
+// text score components of a relevance breakdown
+func (gd *GoogleDorking) explainStringMatch(text, query string, params domain.GoogleDorkingSearchParams) domain.RelevanceBreakdown {
+	var breakdown domain.RelevanceBreakdown
 	if text == "" {
-		return 0.0
+		return breakdown
 	}
 
 	if !params.CaseSensitive {
@@ -251,7 +368,9 @@ func (gd *GoogleDorking) calculateStringMatch(text, query string, params domain.
 
 	// Exact match gets highest score
 	if text == query {
-		return 1.0
+		breakdown.ExactMatchBonus = 1.0
+		breakdown.TextScore = 1.0
+		return breakdown
 	}
 
 	// Contains match
@@ -267,7 +386,11 @@ func (gd *GoogleDorking) calculateStringMatch(text, query string, params domain.
 		// Frequency bonus (more occurrences is better, but with diminishing returns)
 		frequencyBonus := math.Log(frequency + 1)
 
-		return (0.7 + positionBonus*0.2 + frequencyBonus*0.1)
+		breakdown.ContainsMatch = 0.7
+		breakdown.PositionBonus = positionBonus * 0.2
+		breakdown.FrequencyBonus = frequencyBonus * 0.1
+		breakdown.TextScore = breakdown.ContainsMatch + breakdown.PositionBonus + breakdown.FrequencyBonus
+		return breakdown
 	}
 
 	// Fuzzy match using Levenshtein distance
@@ -275,17 +398,18 @@ func (gd *GoogleDorking) calculateStringMatch(text, query string, params domain.
 	maxLen := math.Max(float64(len(text)), float64(len(query)))
 
 	if maxLen == 0 {
-		return 0.0
+		return breakdown
 	}
 
 	similarity := 1.0 - (float64(distance) / maxLen)
 
 	// Only consider fuzzy matches above a threshold
 	if similarity > 0.6 {
-		return similarity * 0.5
+		breakdown.FuzzySimilarity = similarity * 0.5
+		breakdown.TextScore = breakdown.FuzzySimilarity
 	}
 
-	return 0.0
+	return breakdown
 }
 
 // calculateKeywordMatch calculates keyword matching score
@@ -428,12 +552,12 @@ func (gd *GoogleDorking) TransformData(data domain.GoogleDorkingResult) domain.G
 // GetDataByCategory extracts data by keyword category
 func (gd *GoogleDorking) GetDataByCategory(data domain.GoogleDorkingResult, category domain.KeywordCategory) []string {
 	switch category {
-	// case domain.KeywordCategoryCompanyName:
-	// 	return gd.extractCompanyNames(data)
-	// case domain.KeywordCategoryPersonName:
-	// 	return gd.extractPersonNames(data)
-	// case domain.KeywordCategoryAddress:
-	// 	return gd.extractAddresses(data)
+	case domain.KeywordCategoryCompanyName:
+		return gd.extractCompanyNames(data)
+	case domain.KeywordCategoryPersonName:
+		return gd.extractPersonNames(data)
+	case domain.KeywordCategoryAddress:
+		return gd.extractAddresses(data)
 	case domain.KeywordCategorySocialMedia:
 		return gd.extractSocialMedia(data)
 	default:
@@ -441,80 +565,160 @@ func (gd *GoogleDorking) GetDataByCategory(data domain.GoogleDorkingResult, cate
 	}
 }
 
-// extractCompanyNames extracts potential company names from the result
+// dorkingCompanySuffixes are the legal forms ending a company name, without
+// their dots and lowercased
+var dorkingCompanySuffixes = map[string]bool{
+	"inc": true, "corp": true, "llc": true, "ltd": true, "co": true, "company": true,
+	"srl": true, "sa": true, "sas": true, "eirl": true,
+}
+
+// dorkingPersonTitles are the titles introducing a person name
+var dorkingPersonTitles = map[string]bool{
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "dra.": true,
+	"sr.": true, "sra.": true, "lic.": true, "licda.": true, "ing.": true,
+}
+
+// dorkingAddressStarts are the words an address starts with
+var dorkingAddressStarts = map[string]bool{
+	"calle": true, "c/": true, "av.": true, "av": true, "avenida": true, "autopista": true, "carretera": true,
+}
+
+// resultTexts splits the title and the description of a result into words,
+// separately so no name spans both
+func resultTexts(data domain.GoogleDorkingResult) [][]string {
+	return [][]string{strings.Fields(data.Title), strings.Fields(data.Description)}
+}
+
+// trimWord drops the punctuation around a word, keeping the dots of
+// abbreviations such as S.R.L.
+func trimWord(word string) string {
+	return strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) && r != '.' && r != '&'
+	})
+}
+
+// isCapitalized reports whether a word starts with an upper case letter
+func isCapitalized(word string) bool {
+	for _, r := range word {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// appendUnique appends value to values unless it is already there
+func appendUnique(values []string, value string) []string {
+	if value == "" || slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// extractCompanyNames extracts the company names of the result: up to four
+// capitalized words followed by a legal form, such as "Novasco Real Estate SRL"
 func (gd *GoogleDorking) extractCompanyNames(data domain.GoogleDorkingResult) []string {
 	companies := []string{}
-	text := data.Title + " " + data.Description
-
-	// Simple heuristic: look for capitalized words that might be company names
-	words := strings.Fields(text)
-	for i, word := range words {
-		if len(word) > 2 && unicode.IsUpper(rune(word[0])) {
-			// Check if it's followed by common company suffixes
-			if i+1 < len(words) {
-				nextWord := strings.ToLower(words[i+1])
-				if nextWord == "inc" || nextWord == "corp" || nextWord == "llc" ||
-					nextWord == "ltd" || nextWord == "co" || nextWord == "company" {
-					companies = append(companies, word+" "+words[i+1])
+
+	for _, words := range resultTexts(data) {
+		for i, word := range words {
+			suffix := strings.ToLower(strings.ReplaceAll(trimWord(word), ".", ""))
+			if i == 0 || !dorkingCompanySuffixes[suffix] {
+				continue
+			}
+
+			start := i
+			for start > 0 && i-start < 4 && isCapitalized(trimWord(words[start-1])) {
+				start--
+				// A name ends at the punctuation before it
+				if start > 0 && strings.ContainsAny(words[start-1], ",;:|") {
+					break
 				}
 			}
-			// Add standalone capitalized words
-			companies = append(companies, word)
+			if start == i {
+				continue
+			}
+
+			name := make([]string, 0, i-start+1)
+			for _, w := range words[start : i+1] {
+				name = append(name, trimWord(w))
+			}
+			companies = appendUnique(companies, strings.TrimSuffix(strings.Join(name, " "), ","))
 		}
 	}
 
 	return companies
 }
 
-// extractPersonNames extracts potential person names from the result
+// extractPersonNames extracts the person names of the result: the two
+// capitalized words after a title such as "Dr." or "Lic.", or else pairs of
+// capitalized words that are not part of a company name
 func (gd *GoogleDorking) extractPersonNames(data domain.GoogleDorkingResult) []string {
 	names := []string{}
-	text := data.Title + " " + data.Description
-
-	// Simple heuristic: look for patterns like "First Last" or "Mr. Last"
-	words := strings.Fields(text)
-	for i, word := range words {
-		if len(word) > 1 && unicode.IsUpper(rune(word[0])) {
-			// Check for title prefixes
-			if word == "Mr." || word == "Mrs." || word == "Ms." || word == "Dr." {
-				if i+1 < len(words) {
-					names = append(names, word+" "+words[i+1])
+	companies := gd.extractCompanyNames(data)
+
+	for _, words := range resultTexts(data) {
+		for i := 0; i+1 < len(words); i++ {
+			first := trimWord(words[i])
+
+			if dorkingPersonTitles[strings.ToLower(first)] {
+				var name []string
+				for j := i + 1; j < len(words) && len(name) < 2 && isCapitalized(trimWord(words[j])); j++ {
+					name = append(name, trimWord(words[j]))
 				}
-			}
-			// Check for first name + last name pattern
-			if i+1 < len(words) {
-				nextWord := words[i+1]
-				if len(nextWord) > 1 && unicode.IsUpper(rune(nextWord[0])) {
-					names = append(names, word+" "+nextWord)
+				if len(name) > 0 {
+					names = appendUnique(names, strings.Join(name, " "))
 				}
+				continue
+			}
+
+			first = strings.TrimSuffix(first, ".")
+			last := strings.TrimSuffix(trimWord(words[i+1]), ".")
+			if len(first) < 2 || len(last) < 2 || !isCapitalized(first) || !isCapitalized(last) {
+				continue
 			}
+			// A name ends at the punctuation after its first word, and streets
+			// and titles are no names
+			if strings.ContainsAny(words[i], ",;:|.") || dorkingAddressStarts[strings.ToLower(words[i])] || dorkingPersonTitles[strings.ToLower(words[i+1])] {
+				continue
+			}
+
+			name := first + " " + last
+			if slices.ContainsFunc(companies, func(company string) bool { return strings.Contains(company, name) }) {
+				continue
+			}
+			names = appendUnique(names, name)
 		}
 	}
 
 	return names
 }
 
-// extractAddresses extracts potential addresses from the result
+// extractAddresses extracts the addresses of the result: the words from a
+// street word such as "Calle" or "Av.", or from a street number followed by a
+// capitalized word, up to the first comma or the fifth word
 func (gd *GoogleDorking) extractAddresses(data domain.GoogleDorkingResult) []string {
 	addresses := []string{}
-	text := data.Title + " " + data.Description
-
-	// Look for common address patterns
-	words := strings.Fields(text)
-	for i, word := range words {
-		// Look for street numbers
-		if len(word) > 0 && unicode.IsDigit(rune(word[0])) {
-			address := word
-			// Collect following words that might be part of the address
+
+	for _, words := range resultTexts(data) {
+		for i := 0; i < len(words); i++ {
+			word := words[i]
+			startsStreet := dorkingAddressStarts[strings.ToLower(word)]
+			startsNumber := unicode.IsDigit([]rune(word)[0]) && i+1 < len(words) && isCapitalized(words[i+1])
+			if !startsStreet && !startsNumber {
+				continue
+			}
+
+			address := []string{word}
 			for j := i + 1; j < len(words) && j < i+5; j++ {
-				nextWord := words[j]
-				if strings.Contains(nextWord, ",") || strings.Contains(nextWord, ".") {
-					address += " " + nextWord
+				address = append(address, words[j])
+				if strings.ContainsAny(words[j], ",;") {
 					break
 				}
-				address += " " + nextWord
 			}
-			addresses = append(addresses, address)
+			if len(address) > 1 {
+				addresses = appendUnique(addresses, strings.TrimRight(strings.Join(address, " "), ",;."))
+			}
+			// The words of the address start no other one
+			i += len(address) - 1
 		}
 	}
 
@@ -543,18 +747,23 @@ func (gd *GoogleDorking) GetSearchableKeywordCategories() []domain.KeywordCatego
 	return []domain.KeywordCategory{
 		domain.KeywordCategoryCompanyName,
 		domain.KeywordCategoryPersonName,
+		domain.KeywordCategoryAddress,
 	}
 }
 
 // GetFoundKeywordCategories returns the categories that can be found in results
 func (gd *GoogleDorking) GetFoundKeywordCategories() []domain.KeywordCategory {
-	return []domain.KeywordCategory{}
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryCompanyName,
+		domain.KeywordCategoryPersonName,
+		domain.KeywordCategoryAddress,
+	}
 }
 
 // Advanced search methods
 
 // SearchWithFilters performs a search with advanced filtering options
-func (gd *GoogleDorking) SearchWithFilters(query string, filters map[string]interface{}) ([]domain.GoogleDorkingResult, error) {
+func (gd *GoogleDorking) SearchWithFilters(ctx context.Context, query string, filters map[string]interface{}) ([]domain.GoogleDorkingResult, error) {
 	params := domain.GoogleDorkingSearchParams{
 		Query:        query,
 		MaxResults:   10,
@@ -581,7 +790,7 @@ func (gd *GoogleDorking) SearchWithFilters(query string, filters map[string]inte
 		params.ExcludeKeywords = excludeKeywords
 	}
 
-	return gd.SearchWithParams(params)
+	return gd.SearchWithParams(ctx, params)
 }
 
 // GetSearchSuggestions returns search suggestions based on the query
@@ -639,40 +848,108 @@ func (gd *GoogleDorking) GetSearchStatistics(results []domain.GoogleDorkingResul
 // Fluent API examples and helper functions
 
 // QuickSearch provides a simple one-liner search
-func QuickSearch(query string) ([]domain.GoogleDorkingResult, error) {
-	return NewGoogleDorkingBuilder().Query(query).Build()
+func QuickSearch(ctx context.Context, query string) ([]domain.GoogleDorkingResult, error) {
+	return NewGoogleDorkingBuilder().Query(query).Build(ctx)
 }
 
 // AdvancedSearch provides a more complex search with multiple parameters
-func AdvancedSearch(query string, maxResults int, minRelevance float64) ([]domain.GoogleDorkingResult, error) {
+func AdvancedSearch(ctx context.Context, query string, maxResults int, minRelevance float64) ([]domain.GoogleDorkingResult, error) {
 	return NewGoogleDorkingBuilder().
 		Query(query).
 		MaxResults(maxResults).
 		MinRelevance(minRelevance).
-		Build()
+		Build(ctx)
 }
 
 // ExactSearch performs an exact match search
-func ExactSearch(query string) ([]domain.GoogleDorkingResult, error) {
+func ExactSearch(ctx context.Context, query string) ([]domain.GoogleDorkingResult, error) {
 	return NewGoogleDorkingBuilder().
 		Query(query).
 		ExactMatch(true).
-		Build()
+		Build(ctx)
 }
 
 // CaseSensitiveSearch performs a case-sensitive search
-func CaseSensitiveSearch(query string) ([]domain.GoogleDorkingResult, error) {
+func CaseSensitiveSearch(ctx context.Context, query string) ([]domain.GoogleDorkingResult, error) {
 	return NewGoogleDorkingBuilder().
 		Query(query).
 		CaseSensitive(true).
-		Build()
+		Build(ctx)
 }
 
 // FilteredSearch performs a search with keyword filtering
-func FilteredSearch(query string, includeKeywords, excludeKeywords []string) ([]domain.GoogleDorkingResult, error) {
+func FilteredSearch(ctx context.Context, query string, includeKeywords, excludeKeywords []string) ([]domain.GoogleDorkingResult, error) {
 	return NewGoogleDorkingBuilder().
 		Query(query).
 		IncludeKeywords(includeKeywords...).
 		ExcludeKeywords(excludeKeywords...).
-		Build()
+		Build(ctx)
+}
+
+// DorkingDomainTypes are the domain types searched through Google Docking
+var DorkingDomainTypes = []domain.DomainType{
+	domain.DomainTypeGoogleDorking,
+	domain.DomainTypeSocialMedia,
+	domain.DomainTypeFileType,
+	domain.DomainTypeXSocialMedia,
+}
+
+// SearchDorkingWithParams runs a Google Docking search of a docking domain
+// type with full parameters. The keywords the domain type searches by default
+// fill the keyword lists left empty, and MaxResults defaults to 10.
+func SearchDorkingWithParams(ctx context.Context, domainType domain.DomainType, params domain.GoogleDorkingSearchParams) (*domain.DomainSearchResult, error) {
+	if !slices.Contains(DorkingDomainTypes, domainType) {
+		return nil, fmt.Errorf("unsupported docking domain type: %s", domainType)
+	}
+
+	if params.MaxResults <= 0 {
+		params.MaxResults = 10
+	}
+	params = withDorkingKeywords(domainType, params, ActiveKeywordSets())
+
+	ctx = withDomainRateLimit(ctx, domainType)
+
+	gd := NewGoogleDorkingDomain()
+	results, err := gd.SearchWithParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.DomainSearchResult{
+		DomainType:          domainType,
+		SearchParameter:     params.Query,
+		Success:             true,
+		Output:              results,
+		KeywordsPerCategory: domain.GetCategoryByKeywords(&gd, results),
+	}, nil
+}
+
+// withDorkingKeywords fills the keyword lists params leave empty with the
+// keywords of sets the docking domain type searches by
+func withDorkingKeywords(domainType domain.DomainType, params domain.GoogleDorkingSearchParams, sets domain.KeywordSets) domain.GoogleDorkingSearchParams {
+	switch domainType {
+	case domain.DomainTypeGoogleDorking:
+		if len(params.IncludeKeywords) == 0 {
+			params.IncludeKeywords = sets.Fraud
+		}
+	case domain.DomainTypeSocialMedia:
+		if len(params.SitesKeywords) == 0 {
+			params.SitesKeywords = sets.SocialMediaSites
+		}
+	case domain.DomainTypeFileType:
+		if len(params.IncludeKeywords) == 0 {
+			params.IncludeKeywords = sets.Fraud
+		}
+		if len(params.FileTypeKeywords) == 0 {
+			params.FileTypeKeywords = sets.FileTypes
+		}
+	case domain.DomainTypeXSocialMedia:
+		if len(params.InURLKeywords) == 0 {
+			params.InURLKeywords = sets.XInURL
+		}
+		if len(params.SitesKeywords) == 0 {
+			params.SitesKeywords = sets.XSites
+		}
+	}
+	return params
 }
@@ -0,0 +1,351 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestGoogleDorking(t *testing.T, items []domain.GoogleDorkingResult) *GoogleDorking {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GoogleDorkingSearchResponse{Items: items})
+	}))
+	t.Cleanup(srv.Close)
+
+	return &GoogleDorking{Provider: &GoogleSearchProvider{
+		BasePath: srv.URL + "/customsearch/v1?key=test&cx=test",
+		Stuff:    *custom.NewClient(),
+	}}
+}
+
+func cannedDorkingItems() []domain.GoogleDorkingResult {
+	return []domain.GoogleDorkingResult{
+		{URL: "https://example.com/unrelated", Title: "Clima en Santo Domingo", Description: "Pronostico del tiempo"},
+		{URL: "https://example.com/mention", Title: "Noticias", Description: "Una denuncia contra novasco fue presentada"},
+		{URL: "https://example.com/fraude", Title: "Novasco fraude inmobiliaria", Description: "Reportaje"},
+		{URL: "https://example.com/exact", Title: "Novasco", Description: "Sitio oficial"},
+	}
+}
+
+func TestSearchWithParamsRanksAndFilters(t *testing.T) {
+	gd := newTestGoogleDorking(t, cannedDorkingItems())
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{
+		Query:        "novasco",
+		MaxResults:   10,
+		MinRelevance: 0.1,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+
+	wantURLs := []string{
+		"https://example.com/exact",
+		"https://example.com/fraude",
+		"https://example.com/mention",
+	}
+	if len(results) != len(wantURLs) {
+		t.Fatalf("expected %d results, got %d: %+v", len(wantURLs), len(results), results)
+	}
+
+	for i, result := range results {
+		if result.URL != wantURLs[i] {
+			t.Errorf("result %d: expected %s, got %s", i, wantURLs[i], result.URL)
+		}
+		if result.Rank != i+1 {
+			t.Errorf("result %d: expected rank %d, got %d", i, i+1, result.Rank)
+		}
+		if result.Relevance < 0.1 || result.Relevance > 1 {
+			t.Errorf("result %d: relevance %f out of range", i, result.Relevance)
+		}
+		if i > 0 && result.Relevance > results[i-1].Relevance {
+			t.Errorf("result %d: not sorted by relevance", i)
+		}
+	}
+
+	if results[0].Relevance != 1.0 {
+		t.Errorf("expected exact title match to score 1.0, got %f", results[0].Relevance)
+	}
+}
+
+func TestSearchWithParamsTruncatesToMaxResults(t *testing.T) {
+	gd := newTestGoogleDorking(t, cannedDorkingItems())
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{
+		Query:        "novasco",
+		MaxResults:   2,
+		MinRelevance: 0.1,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].URL != "https://example.com/exact" || results[1].Rank != 2 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestSearchWithParamsExactMatch(t *testing.T) {
+	gd := newTestGoogleDorking(t, cannedDorkingItems())
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{
+		Query:      "Novasco",
+		MaxResults: 10,
+		ExactMatch: true,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].URL != "https://example.com/exact" {
+		t.Fatalf("expected only the exact match, got %+v", results)
+	}
+	if results[0].Rank != 1 {
+		t.Errorf("expected rank 1, got %d", results[0].Rank)
+	}
+}
+
+func TestSearchWithParamsExplainsRelevance(t *testing.T) {
+	items := append(cannedDorkingItems(), domain.GoogleDorkingResult{
+		URL: "https://example.com/keywords", Title: "Reportaje", Description: "Novasco", Keywords: []string{"novasco", "fraude"},
+	})
+	gd := newTestGoogleDorking(t, items)
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{
+		Query:        "novasco",
+		MaxResults:   10,
+		MinRelevance: 0.1,
+		Explain:      true,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d: %+v", len(results), results)
+	}
+
+	const epsilon = 1e-9
+	for _, result := range results {
+		explanation := result.Explanation
+		if explanation == nil {
+			t.Fatalf("%s: expected a relevance breakdown", result.URL)
+		}
+
+		components := explanation.ExactMatchBonus + explanation.ContainsMatch + explanation.PositionBonus +
+			explanation.FrequencyBonus + explanation.FuzzySimilarity
+		if math.Abs(components-explanation.TextScore) > epsilon {
+			t.Errorf("%s: expected the components to sum to the text score %f, got %f", result.URL, explanation.TextScore, components)
+		}
+
+		want := explanation.TextScore*(1-explanation.KeywordWeight) + explanation.KeywordMatch*explanation.KeywordWeight
+		if math.Abs(math.Min(want, 1)-result.Relevance) > epsilon || explanation.Relevance != result.Relevance {
+			t.Errorf("%s: expected the breakdown to yield the relevance %f, got %f", result.URL, result.Relevance, want)
+		}
+	}
+
+	byURL := make(map[string]*domain.RelevanceBreakdown)
+	for _, result := range results {
+		byURL[result.URL] = result.Explanation
+	}
+	if exact := byURL["https://example.com/exact"]; exact.ExactMatchBonus != 1 || exact.MatchedField != "title" {
+		t.Errorf("expected the exact title match to earn the exact match bonus, got %+v", exact)
+	}
+	if mention := byURL["https://example.com/mention"]; mention.ContainsMatch == 0 || mention.MatchedField != "description" {
+		t.Errorf("expected the description mention to score as contained, got %+v", mention)
+	}
+	if keywords := byURL["https://example.com/keywords"]; keywords.KeywordMatch == 0 || keywords.KeywordWeight != keywordMatchWeight {
+		t.Errorf("expected the keywords to weigh in the relevance, got %+v", keywords)
+	}
+
+	plain, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{Query: "novasco", MaxResults: 10, MinRelevance: 0.1})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+	for _, result := range plain {
+		if result.Explanation != nil {
+			t.Errorf("%s: expected no breakdown unless asked for", result.URL)
+		}
+	}
+}
+
+const duckDuckGoResultsPage = `<html><body>
+<div class="result results_links result--ad">
+	<h2 class="result__title"><a class="result__a" href="https://ads.example.com/">Anuncio Novasco</a></h2>
+</div>
+<div class="result results_links results_links_deep web-result">
+	<h2 class="result__title"><a class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2Fexact&amp;rut=abc">Novasco</a></h2>
+	<a class="result__snippet" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2Fexact">Sitio oficial</a>
+</div>
+<div class="result results_links results_links_deep web-result">
+	<h2 class="result__title"><a class="result__a" href="https://example.com/fraude">Novasco fraude inmobiliaria</a></h2>
+	<a class="result__snippet" href="https://example.com/fraude">Reportaje sobre <b>Novasco</b></a>
+</div>
+</body></html>`
+
+func newTestDuckDuckGo(t *testing.T) (string, *[]string) {
+	t.Helper()
+
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(duckDuckGoResultsPage))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL + "/html/", &queries
+}
+
+func assertDuckDuckGoResults(t *testing.T, results []domain.GoogleDorkingResult) {
+	t.Helper()
+
+	if len(results) != 2 {
+		t.Fatalf("expected the 2 organic results, got %+v", results)
+	}
+	if results[0].URL != "https://example.com/exact" || results[0].Title != "Novasco" || results[0].Description != "Sitio oficial" {
+		t.Errorf("expected the redirect to be unwrapped into the exact match, got %+v", results[0])
+	}
+	for _, result := range results {
+		if result.Provider != domain.DorkingProviderDuckDuckGo {
+			t.Errorf("expected %s to come from DuckDuckGo, got provider %q", result.URL, result.Provider)
+		}
+	}
+}
+
+func TestSearchFallsBackToDuckDuckGoWithoutGoogleKeys(t *testing.T) {
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GOOGLE_CX_KEY", "")
+
+	gd := NewGoogleDorkingDomain()
+	duckDuckGo, ok := gd.Provider.(*DuckDuckGoSearchProvider)
+	if !ok || duckDuckGo.BasePath != domain.DuckDuckGoHTMLURL {
+		t.Fatalf("expected only the DuckDuckGo fallback without keys, got %+v", gd.Provider)
+	}
+
+	fallback, queries := newTestDuckDuckGo(t)
+	duckDuckGo.BasePath = fallback
+
+	results, err := gd.Search(context.Background(), "novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(*queries) != 1 || (*queries)[0] != "novasco" {
+		t.Errorf("expected one DuckDuckGo search for the query, got %q", *queries)
+	}
+	assertDuckDuckGoResults(t, results)
+}
+
+func TestSearchFallsBackToDuckDuckGoOnGoogleQuotaError(t *testing.T) {
+	googleCalls := 0
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		googleCalls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error": {"code": 403, "message": "Quota exceeded for quota metric 'Queries'", "errors": [{"reason": "dailyLimitExceeded"}]}}`))
+	}))
+	t.Cleanup(google.Close)
+
+	fallback, queries := newTestDuckDuckGo(t)
+	provider := &FallbackSearchProvider{
+		Primary: &GoogleSearchProvider{
+			BasePath: google.URL + "/customsearch/v1?key=test&cx=test",
+			Stuff:    *custom.NewClient(),
+		},
+		Fallback: &DuckDuckGoSearchProvider{BasePath: fallback},
+	}
+	gd := &GoogleDorking{Provider: provider}
+
+	results, err := gd.Search(context.Background(), "novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if googleCalls != 1 || len(*queries) != 1 {
+		t.Errorf("expected Google then DuckDuckGo to be searched once, got %d and %d searches", googleCalls, len(*queries))
+	}
+	assertDuckDuckGoResults(t, results)
+
+	provider.Fallback = nil
+	if _, err := gd.Search(context.Background(), "novasco"); !errors.Is(err, ErrGoogleQuotaExceeded) {
+		t.Errorf("expected the quota error without a fallback, got %v", err)
+	}
+}
+
+func TestGoogleDorkingGetDataByCategory(t *testing.T) {
+	gd := &GoogleDorking{}
+	result := domain.GoogleDorkingResult{
+		URL:         "https://example.com/novasco",
+		Title:       "Denuncia contra Novasco Real Estate S.R.L. | Noticias",
+		Description: "El Lic. Juan Perez representa a la empresa, con oficinas en Av. Winston Churchill 1099, Santo Domingo.",
+	}
+
+	tests := map[domain.KeywordCategory][]string{
+		domain.KeywordCategoryCompanyName: {"Novasco Real Estate S.R.L."},
+		domain.KeywordCategoryPersonName:  {"Juan Perez", "Winston Churchill", "Santo Domingo"},
+		domain.KeywordCategoryAddress:     {"Av. Winston Churchill 1099"},
+	}
+	for category, want := range tests {
+		got := gd.GetDataByCategory(result, category)
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %v, got %v", category, want, got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %v, got %v", category, want, got)
+				break
+			}
+		}
+	}
+
+	// The extracted names become keywords of the docking step
+	keywords := domain.GetCategoryByKeywords[domain.GoogleDorkingResult](gd, []domain.GoogleDorkingResult{result})
+	if len(keywords[domain.KeywordCategoryCompanyName]) != 1 {
+		t.Errorf("expected the company name among the keywords, got %v", keywords)
+	}
+}
+
+func TestGoogleDorkingExtractsNothingFromPlainText(t *testing.T) {
+	gd := &GoogleDorking{}
+	result := domain.GoogleDorkingResult{Title: "pronostico del tiempo", Description: "lluvias durante 3 dias"}
+
+	for _, category := range gd.GetFoundKeywordCategories() {
+		if got := gd.GetDataByCategory(result, category); len(got) != 0 {
+			t.Errorf("%s: expected no keywords, got %v", category, got)
+		}
+	}
+}
+
+func TestCreateDomainConnectorUsesOneDorkingConnector(t *testing.T) {
+	for _, domainType := range []domain.DomainType{
+		domain.DomainTypeGoogleDorking,
+		domain.DomainTypeSocialMedia,
+		domain.DomainTypeFileType,
+		domain.DomainTypeXSocialMedia,
+	} {
+		connector, err := CreateDomainConnector(domainType)
+		if err != nil {
+			t.Fatalf("%s: CreateDomainConnector returned error: %v", domainType, err)
+		}
+		gd, ok := connector.(*GoogleDorking)
+		if !ok {
+			t.Fatalf("%s: expected a *GoogleDorking connector, got %T", domainType, connector)
+		}
+		if gd.Provider == nil {
+			t.Errorf("%s: expected the connector to have a search provider", domainType)
+		}
+	}
+}
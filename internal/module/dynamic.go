@@ -5,10 +5,32 @@ import (
 	"fmt"
 	"insightful-intel/internal/domain"
 	"insightful-intel/internal/infra"
+	"sync"
+	"time"
 )
 
-// SearchDomain performs a search using the specified domain type and parameters
-func SearchDomain(domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+// SearchDomain performs a search using the specified domain type and parameters,
+// reusing the results cached by DefaultSearchCache and making the search again
+// when it fails on a transient error
+func SearchDomain(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+	return instrumentSearch(infra.DefaultMetrics, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return DefaultSearchCache.Search(ctx, domainType, params, retrySearch(searchDomainUncached))
+	})(ctx, domainType, params)
+}
+
+// instrumentSearch wraps search to record the attempts, outcome and latency of
+// each search in metrics
+func instrumentSearch(metrics *infra.Metrics, search SearchFunc) SearchFunc {
+	return func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		start := time.Now()
+		result, err := search(ctx, domainType, params)
+		metrics.RecordSearch(string(domainType), time.Since(start), err == nil && result != nil && result.Success)
+		return result, err
+	}
+}
+
+// searchDomainUncached performs the outbound search of SearchDomain
+func searchDomainUncached(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
 	// Validate domain type
 	if !domain.IsValidDomainType(domainType) {
 		return &domain.DomainSearchResult{
@@ -18,6 +40,9 @@ func SearchDomain(domainType domain.DomainType, params domain.DomainSearchParams
 		}, fmt.Errorf("unsupported domain type: %s", domainType)
 	}
 
+	// Space the requests sent to the source's host
+	ctx = withDomainRateLimit(ctx, domainType)
+
 	// Perform the search based on domain type
 	var output any
 	var searchErr error
@@ -25,38 +50,41 @@ func SearchDomain(domainType domain.DomainType, params domain.DomainSearchParams
 	switch domainType {
 	case domain.DomainTypeONAPI:
 		onapi := NewOnapiDomain()
-		output, searchErr = onapi.Search(params.Query)
+		output, searchErr = onapi.Search(ctx, params.Query)
 	case domain.DomainTypeSCJ:
 		scj := NewScjDomain()
-		output, searchErr = scj.Search(params.Query)
+		output, searchErr = scj.Search(ctx, params.Query)
 	case domain.DomainTypeDGII:
-		dgii := NewDgiiDomain()
-		output, searchErr = dgii.Search(params.Query)
+		// Contributor IDs that pass validation are looked up exactly
+		dgii := NewDgiiDomain().(*Dgii)
+		if domain.ValidateRNC(params.Query) == nil {
+			output, searchErr = dgii.SearchByRNC(ctx, params.Query)
+		} else {
+			output, searchErr = dgii.Search(ctx, params.Query)
+		}
 	case domain.DomainTypePGR:
 		pgr := NewPgrDomain()
-		output, searchErr = pgr.Search(params.Query)
-	case domain.DomainTypeGoogleDorking:
-		output, searchErr = NewGoogleDorkingBuilder().
-			Query(params.Query).
-			IncludeKeywords(domain.FRAUD_KEYWORDS...).
-			Build()
-	case domain.DomainTypeSocialMedia:
-		output, searchErr = NewGoogleDorkingBuilder().
-			Query(params.Query).
-			SitesKeywords(domain.SOCIAL_MEDIA_SITES_KEYWORDS...).
-			Build()
-	case domain.DomainTypeFileType:
-		output, searchErr = NewGoogleDorkingBuilder().
-			Query(params.Query).
-			IncludeKeywords(domain.FRAUD_KEYWORDS...).
-			FileTypeKeywords(domain.FILE_TYPE_KEYWORDS...).
-			Build()
-	case domain.DomainTypeXSocialMedia:
-		output, searchErr = NewGoogleDorkingBuilder().
-			Query(params.Query).
-			InURLKeywords(domain.X_IN_URL_KEYWORDS...).
-			SitesKeywords([]string{"x.com"}...).
-			Build()
+		output, searchErr = pgr.Search(ctx, params.Query)
+	case domain.DomainTypeCamara:
+		camara := NewCamaraDomain()
+		output, searchErr = camara.Search(ctx, params.Query)
+	case domain.DomainTypeJCE:
+		jce := NewJceDomain()
+		output, searchErr = jce.Search(ctx, params.Query)
+	case domain.DomainTypeSanctions:
+		sanctions := NewSanctionsDomain()
+		output, searchErr = sanctions.Search(ctx, params.Query)
+	case domain.DomainTypeSIB:
+		sib := NewSibDomain()
+		output, searchErr = sib.Search(ctx, params.Query)
+	case domain.DomainTypeGoogleDorking, domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
+		// Each docking domain searches by its keywords of the active sets
+		gd := NewGoogleDorkingDomain()
+		output, searchErr = gd.SearchWithParams(ctx, withDorkingKeywords(domainType, domain.GoogleDorkingSearchParams{
+			Query:        params.Query,
+			MaxResults:   10,
+			MinRelevance: 0.1,
+		}, ActiveKeywordSets()))
 	default:
 		return &domain.DomainSearchResult{
 			Success:    false,
@@ -85,6 +113,22 @@ func SearchDomain(domainType domain.DomainType, params domain.DomainSearchParams
 			if registers, ok := output.([]domain.PGRNews); ok {
 				keywordsPerCategory = domain.GetCategoryByKeywords(NewPgrDomain(), registers)
 			}
+		case domain.DomainTypeCamara:
+			if records, ok := output.([]domain.CamaraRecord); ok {
+				keywordsPerCategory = domain.GetCategoryByKeywords(NewCamaraDomain(), records)
+			}
+		case domain.DomainTypeJCE:
+			if people, ok := output.([]domain.JCEPerson); ok {
+				keywordsPerCategory = domain.GetCategoryByKeywords(NewJceDomain(), people)
+			}
+		case domain.DomainTypeSanctions:
+			if matches, ok := output.([]domain.SanctionMatch); ok {
+				keywordsPerCategory = domain.GetCategoryByKeywords(NewSanctionsDomain(), matches)
+			}
+		case domain.DomainTypeSIB:
+			if institutions, ok := output.([]domain.SIBInstitution); ok {
+				keywordsPerCategory = domain.GetCategoryByKeywords(NewSibDomain(), institutions)
+			}
 		case domain.DomainTypeGoogleDorking, domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
 			if registers, ok := output.([]domain.GoogleDorkingResult); ok {
 				keywordsPerCategory = domain.GetCategoryByKeywords(&GoogleDorking{}, registers)
@@ -122,6 +166,18 @@ func CreateDomainConnector(domainType domain.DomainType) (any, error) {
 	case domain.DomainTypePGR:
 		pgr := NewPgrDomain()
 		return &pgr, nil
+	case domain.DomainTypeCamara:
+		camara := NewCamaraDomain()
+		return &camara, nil
+	case domain.DomainTypeJCE:
+		jce := NewJceDomain()
+		return &jce, nil
+	case domain.DomainTypeSanctions:
+		sanctions := NewSanctionsDomain()
+		return &sanctions, nil
+	case domain.DomainTypeSIB:
+		sib := NewSibDomain()
+		return &sib, nil
 	case domain.DomainTypeGoogleDorking, domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
 		docking := NewGoogleDorkingDomain()
 		return &docking, nil
@@ -130,12 +186,20 @@ func CreateDomainConnector(domainType domain.DomainType) (any, error) {
 	}
 }
 
-// SearchMultipleDomains performs searches across multiple domains
-func SearchMultipleDomains(domainTypes []domain.DomainType, params domain.DomainSearchParams) []*domain.DomainSearchResult {
+// SearchMultipleDomains performs searches across multiple domains. search
+// defaults to SearchDomain when nil.
+func SearchMultipleDomains(ctx context.Context, domainTypes []domain.DomainType, params domain.DomainSearchParams, search SearchFunc) []*domain.DomainSearchResult {
+	if search == nil {
+		search = SearchDomain
+	}
+
 	results := make([]*domain.DomainSearchResult, 0, len(domainTypes))
 
 	for _, domainType := range domainTypes {
-		result, err := SearchDomain(domainType, params)
+		result, err := search(ctx, domainType, params)
+		if result == nil {
+			result = &domain.DomainSearchResult{DomainType: domainType, SearchParameter: params.Query}
+		}
 		if err != nil {
 			result.Error = err
 			result.Success = false
@@ -146,12 +210,70 @@ func SearchMultipleDomains(domainTypes []domain.DomainType, params domain.Domain
 	return results
 }
 
+// DefaultBatchConcurrency is the number of queries a batch search runs at once
+// when none is given
+const DefaultBatchConcurrency = 4
+
+// SearchBatch runs SearchMultipleDomains for each query, at most concurrency
+// queries at once, and returns the results of each query keyed by the query.
+// Queries never started because the context ended are left out.
+func SearchBatch(ctx context.Context, queries []string, domainTypes []domain.DomainType, concurrency int, search SearchFunc) map[string][]*domain.DomainSearchResult {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make(map[string][]*domain.DomainSearchResult, len(queries))
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+dispatch:
+	for _, query := range queries {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			queryResults := SearchMultipleDomains(ctx, domainTypes, domain.DomainSearchParams{Query: query}, search)
+
+			mu.Lock()
+			results[query] = queryResults
+			mu.Unlock()
+		}(query)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// AvailableDomainTypes returns the domain types a pipeline may search, leaving
+// out the sources whose access is not enabled
+func AvailableDomainTypes() []domain.DomainType {
+	domainTypes := make([]domain.DomainType, 0, len(domain.AllDomainTypes()))
+	for _, domainType := range domain.AllDomainTypes() {
+		if domainType == domain.DomainTypeJCE && !JCEEnabled() {
+			continue
+		}
+		if domainType == domain.DomainTypeSanctions && !SanctionsEnabled() {
+			continue
+		}
+		domainTypes = append(domainTypes, domainType)
+	}
+	return domainTypes
+}
+
 // DefaultDynamicPipelineConfig returns a default configuration
 func DefaultDynamicPipelineConfig() domain.DynamicPipelineConfig {
 	return domain.DynamicPipelineConfig{
 		MaxDepth:           5,
 		MaxConcurrentSteps: 10,
-		DelayBetweenSteps:  2,
+		DelayBetweenSteps:  domain.DefaultDelayBetweenSteps,
 		SkipDuplicates:     false,
 	}
 }
@@ -173,9 +295,10 @@ func CreateDynamicPipeline(
 
 	// Initialize the pipeline
 	pipeline := &domain.DynamicPipelineResult{
-		ID:     pipelineID,
-		Steps:  make([]domain.DynamicPipelineStep, 0),
-		Config: config,
+		ID:        pipelineID,
+		Steps:     make([]domain.DynamicPipelineStep, 0),
+		Config:    config,
+		SessionID: config.SessionID,
 	}
 
 	// Track searched keywords per domain to avoid duplicates
@@ -191,6 +314,10 @@ func CreateDynamicPipeline(
 		domain.DomainTypeDGII:          domain.KeywordCategoryContributorID,
 		domain.DomainTypePGR:           domain.KeywordCategoryPersonName,
 		domain.DomainTypeSCJ:           domain.KeywordCategoryContributorID,
+		domain.DomainTypeCamara:        domain.KeywordCategoryCompanyName,
+		domain.DomainTypeJCE:           domain.KeywordCategoryContributorID,
+		domain.DomainTypeSanctions:     domain.KeywordCategoryPersonName,
+		domain.DomainTypeSIB:           domain.KeywordCategoryCompanyName,
 		domain.DomainTypeGoogleDorking: domain.KeywordCategoryCompanyName,
 		domain.DomainTypeSocialMedia:   domain.KeywordCategoryCompanyName,
 		domain.DomainTypeFileType:      domain.KeywordCategoryCompanyName,
@@ -198,6 +325,15 @@ func CreateDynamicPipeline(
 	}
 
 	for _, domainType := range availableDomains {
+		// A cédula seed is first resolved to the registered name, which then
+		// expands to the other domains; other seeds have nothing to resolve
+		if domainType == domain.DomainTypeJCE && !isCedula(initialQuery) {
+			continue
+		}
+		// Only companies are licensed by the financial regulator
+		if domainType == domain.DomainTypeSIB && isCedula(initialQuery) {
+			continue
+		}
 		if category, ok := initialDomainCategories[domainType]; ok {
 			pipeline.Steps = append(pipeline.Steps, domain.DynamicPipelineStep{
 				DomainType:      domainType,
@@ -212,6 +348,46 @@ func CreateDynamicPipeline(
 	return pipeline, nil
 }
 
+// PlanDynamicPipeline returns the steps a run of the pipeline would start
+// with, followed, when MaxDepth allows it, by the steps the cached results of
+// their searches lead to. Nothing is searched: every step is returned
+// unexecuted, with Success false.
+func PlanDynamicPipeline(
+	ctx context.Context,
+	initialQuery string,
+	availableDomains []domain.DomainType,
+	config domain.DynamicPipelineConfig,
+) (*domain.DynamicPipelineResult, error) {
+	pipeline, err := CreateDynamicPipeline(ctx, initialQuery, availableDomains, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxDepth > 0 {
+		searchedKeywordsPerDomain := make(map[domain.DomainType]map[string]bool)
+		for _, domainType := range availableDomains {
+			searchedKeywordsPerDomain[domainType] = map[string]bool{initialQuery: true}
+		}
+
+		initialSteps := pipeline.Steps
+		for _, step := range initialSteps {
+			cached, ok := DefaultSearchCache.Peek(step.DomainType, step.SearchParameter)
+			if !ok {
+				continue
+			}
+			pipeline.Steps = append(pipeline.Steps, generateStepsFromKeywords(cached.KeywordsPerCategory, availableDomains, searchedKeywordsPerDomain, 1, config)...)
+		}
+	}
+
+	for i := range pipeline.Steps {
+		pipeline.Steps[i].PipelineID = pipeline.ID
+		pipeline.MaxDepthReached = max(pipeline.MaxDepthReached, pipeline.Steps[i].Depth)
+	}
+	pipeline.TotalSteps = len(pipeline.Steps)
+
+	return pipeline, nil
+}
+
 // generateStepsFromKeywords creates new pipeline steps based on extracted keywords
 func generateStepsFromKeywords(
 	keywordsPerCategory map[domain.KeywordCategory][]string,
@@ -283,6 +459,14 @@ func GetSearchableKeywordCategories(connector any) []domain.KeywordCategory {
 		return c.GetSearchableKeywordCategories()
 	case *Pgr:
 		return c.GetSearchableKeywordCategories()
+	case *Camara:
+		return c.GetSearchableKeywordCategories()
+	case *Jce:
+		return c.GetSearchableKeywordCategories()
+	case *Sanctions:
+		return c.GetSearchableKeywordCategories()
+	case *Sib:
+		return c.GetSearchableKeywordCategories()
 	case *GoogleDorking:
 		return c.GetSearchableKeywordCategories()
 	default:
@@ -308,12 +492,20 @@ func ExecuteDynamicPipeline(
 	config domain.DynamicPipelineConfig,
 ) (*domain.DynamicPipelineResult, error) {
 
+	if config.DryRun {
+		return PlanDynamicPipeline(ctx, initialQuery, availableDomains, config)
+	}
+
 	// Create the pipeline structure
 	pipeline, err := CreateDynamicPipeline(ctx, initialQuery, availableDomains, config)
 	if err != nil {
 		return nil, err
 	}
 
+	if config.StopOnFirstHit {
+		return executeExistenceCheck(ctx, pipeline, initialQuery, availableDomains)
+	}
+
 	// Execute steps in parallel where possible
 	// This is a simplified version - in practice you might want more sophisticated parallel execution
 	for i := range pipeline.Steps {
@@ -322,7 +514,7 @@ func ExecuteDynamicPipeline(
 			continue // Already processed
 		}
 
-		result, err := SearchDomain(step.DomainType, domain.DomainSearchParams{Query: step.SearchParameter})
+		result, err := SearchDomain(ctx, step.DomainType, domain.DomainSearchParams{Query: step.SearchParameter, Attempts: config.SearchAttempts})
 		if err != nil {
 			step.Error = err
 			step.Success = false
@@ -335,3 +527,35 @@ func ExecuteDynamicPipeline(
 
 	return pipeline, nil
 }
+
+// executeExistenceCheck replaces the planned steps with a single existence check
+// over the authoritative domains, recording the winning search as the only step
+func executeExistenceCheck(
+	ctx context.Context,
+	pipeline *domain.DynamicPipelineResult,
+	initialQuery string,
+	availableDomains []domain.DomainType,
+) (*domain.DynamicPipelineResult, error) {
+	hit, err := CheckExistence(ctx, initialQuery, AuthoritativeDomains(availableDomains))
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline.Steps = make([]domain.DynamicPipelineStep, 0, 1)
+	if hit != nil {
+		pipeline.Steps = append(pipeline.Steps, domain.DynamicPipelineStep{
+			PipelineID:          pipeline.ID,
+			DomainType:          hit.DomainType,
+			SearchParameter:     initialQuery,
+			Category:            domain.KeywordCategoryCompanyName,
+			Keywords:            []string{initialQuery},
+			Success:             true,
+			Output:              hit.Output,
+			KeywordsPerCategory: hit.KeywordsPerCategory,
+		})
+		pipeline.SuccessfulSteps = 1
+	}
+	pipeline.TotalSteps = len(pipeline.Steps)
+
+	return pipeline, nil
+}
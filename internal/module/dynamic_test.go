@@ -0,0 +1,71 @@
+package module
+
+import (
+	"context"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"testing"
+)
+
+func TestExecuteDynamicPipelineDryRunMakesNoRequests(t *testing.T) {
+	// The ONAPI search of the seed is cached, the DGII one is not
+	cached := func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{
+			Success:    true,
+			DomainType: domainType,
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+			},
+		}, nil
+	}
+	if _, err := DefaultSearchCache.Search(context.Background(), domain.DomainTypeONAPI, domain.DomainSearchParams{Query: "novasco dry run"}, cached); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { DefaultSearchCache.Forget(domain.DomainTypeONAPI, "novasco dry run") })
+
+	budget := custom.NewBudget(0, 0)
+	ctx := custom.WithBudget(context.Background(), budget)
+	availableDomains := []domain.DomainType{domain.DomainTypeONAPI, domain.DomainTypeDGII}
+
+	pipeline, err := ExecuteDynamicPipeline(ctx, "novasco dry run", availableDomains, domain.DynamicPipelineConfig{
+		MaxDepth:       2,
+		SkipDuplicates: true,
+		DryRun:         true,
+	})
+	if err != nil {
+		t.Fatalf("ExecuteDynamicPipeline returned error: %v", err)
+	}
+
+	if budget.Requests() != 0 {
+		t.Errorf("expected no outbound request in a dry run, got %d", budget.Requests())
+	}
+
+	// The 2 initial steps, then the cached company name in both domains
+	if pipeline.TotalSteps != 4 || len(pipeline.Steps) != 4 {
+		t.Fatalf("expected 4 planned steps, got %+v", pipeline.Steps)
+	}
+	expanded := 0
+	for _, step := range pipeline.Steps {
+		if step.Success || step.Output != nil || step.Error != nil {
+			t.Errorf("expected %s %q to be planned unexecuted, got %+v", step.DomainType, step.SearchParameter, step)
+		}
+		if step.Depth == 1 {
+			expanded++
+			if step.SearchParameter != "NOVASCO HOLDING SRL" || step.Category != domain.KeywordCategoryCompanyName {
+				t.Errorf("unexpected expansion step %+v", step)
+			}
+		}
+	}
+	if expanded != 2 || pipeline.MaxDepthReached != 1 {
+		t.Errorf("expected 2 steps one level down, got %d at depth %d", expanded, pipeline.MaxDepthReached)
+	}
+
+	// Without depth to expand into, only the initial steps are planned
+	pipeline, err = PlanDynamicPipeline(ctx, "novasco dry run", availableDomains, domain.DynamicPipelineConfig{DryRun: true})
+	if err != nil {
+		t.Fatalf("PlanDynamicPipeline returned error: %v", err)
+	}
+	if len(pipeline.Steps) != 2 {
+		t.Errorf("expected only the initial steps, got %+v", pipeline.Steps)
+	}
+}
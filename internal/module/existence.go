@@ -0,0 +1,100 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"strings"
+)
+
+// searchDomainFunc performs the searches of the existence check. Tests replace it
+// with stubs so no outbound request is made.
+var searchDomainFunc = func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+	return SearchDomain(ctx, domainType, params)
+}
+
+// CheckExistence searches the given domains concurrently and returns the first
+// result that is a strong hit for the query, cancelling the searches still in
+// flight. It returns nil when none of the domains produced a strong hit.
+func CheckExistence(ctx context.Context, query string, domainTypes []domain.DomainType) (*domain.DomainSearchResult, error) {
+	return CheckExistenceWith(ctx, searchDomainFunc, query, domainTypes)
+}
+
+// CheckExistenceWith is CheckExistence running its searches with search
+func CheckExistenceWith(ctx context.Context, search SearchFunc, query string, domainTypes []domain.DomainType) (*domain.DomainSearchResult, error) {
+	if len(domainTypes) == 0 {
+		return nil, fmt.Errorf("no domains to check")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so the searches that lose the race never block on send
+	results := make(chan *domain.DomainSearchResult, len(domainTypes))
+	for _, domainType := range domainTypes {
+		go func(domainType domain.DomainType) {
+			result, err := search(ctx, domainType, domain.DomainSearchParams{Query: query})
+			if err != nil {
+				result = nil
+			}
+			results <- result
+		}(domainType)
+	}
+
+	for range domainTypes {
+		select {
+		case result := <-results:
+			if IsStrongHit(result, query) {
+				return result, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, nil
+}
+
+// IsStrongHit reports whether a search result holds a company name or
+// contributor ID matching the query, ignoring case and spacing
+func IsStrongHit(result *domain.DomainSearchResult, query string) bool {
+	if result == nil || !result.Success {
+		return false
+	}
+
+	want := normalizeHitValue(query)
+	if want == "" {
+		return false
+	}
+
+	for _, category := range []domain.KeywordCategory{
+		domain.KeywordCategoryCompanyName,
+		domain.KeywordCategoryContributorID,
+	} {
+		for _, value := range result.KeywordsPerCategory[category] {
+			if normalizeHitValue(value) == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AuthoritativeDomains keeps the authoritative domains that are also available
+func AuthoritativeDomains(availableDomains []domain.DomainType) []domain.DomainType {
+	var domainTypes []domain.DomainType
+	for _, domainType := range domain.AuthoritativeDomainTypes() {
+		for _, available := range availableDomains {
+			if domainType == available {
+				domainTypes = append(domainTypes, domainType)
+				break
+			}
+		}
+	}
+	return domainTypes
+}
+
+func normalizeHitValue(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
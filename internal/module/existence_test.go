@@ -0,0 +1,82 @@
+package module
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestCheckExistenceReturnsOnFirstStrongHit(t *testing.T) {
+	slowCancelled := make(chan struct{})
+
+	original := searchDomainFunc
+	defer func() { searchDomainFunc = original }()
+
+	searchDomainFunc = func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		switch domainType {
+		case domain.DomainTypeDGII:
+			return &domain.DomainSearchResult{
+				Success:         true,
+				DomainType:      domainType,
+				SearchParameter: params.Query,
+				KeywordsPerCategory: map[domain.KeywordCategory][]string{
+					domain.KeywordCategoryCompanyName: {"NOVASCO  Real Estate"},
+				},
+			}, nil
+		default:
+			select {
+			case <-ctx.Done():
+				close(slowCancelled)
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return &domain.DomainSearchResult{Success: true, DomainType: domainType}, nil
+			}
+		}
+	}
+
+	start := time.Now()
+	hit, err := CheckExistence(context.Background(), "novasco real estate", []domain.DomainType{
+		domain.DomainTypeONAPI,
+		domain.DomainTypeDGII,
+	})
+	if err != nil {
+		t.Fatalf("CheckExistence returned error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected early return, took %s", elapsed)
+	}
+	if hit == nil || hit.DomainType != domain.DomainTypeDGII {
+		t.Fatalf("expected DGII hit, got %+v", hit)
+	}
+
+	select {
+	case <-slowCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the slow domain search to be cancelled")
+	}
+}
+
+func TestCheckExistenceWithoutStrongHit(t *testing.T) {
+	original := searchDomainFunc
+	defer func() { searchDomainFunc = original }()
+
+	searchDomainFunc = func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{
+			Success:    true,
+			DomainType: domainType,
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"Another Company"},
+			},
+		}, nil
+	}
+
+	hit, err := CheckExistence(context.Background(), "novasco", domain.AuthoritativeDomainTypes())
+	if err != nil {
+		t.Fatalf("CheckExistence returned error: %v", err)
+	}
+	if hit != nil {
+		t.Fatalf("expected no hit, got %+v", hit)
+	}
+}
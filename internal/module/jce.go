@@ -0,0 +1,179 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var _ domain.DomainConnector[domain.JCEPerson] = &Jce{}
+
+// ErrJCEDisabled is returned when the electoral registry is searched without
+// being enabled. Personal data from the padrón may only be queried where the
+// deployment has the legal basis to do so, so access is opt-in.
+var ErrJCEDisabled = errors.New("electoral registry (JCE) access is disabled, set JCE_ENABLED to enable it")
+
+// Jce is the connector resolving a cédula to the name registered in the
+// electoral roll of the Junta Central Electoral
+type Jce struct {
+	Stuff    custom.Client
+	BaseParh string
+	PathMap  custom.CustomPathMap
+	Enabled  bool
+}
+
+type JcePersonResponse struct {
+	Cedula    string `json:"cedula"`
+	Nombres   string `json:"nombres"`
+	Apellido1 string `json:"apellido1"`
+	Apellido2 string `json:"apellido2"`
+}
+
+// NewJceDomain creates a new electoral registry connector, enabled by the
+// JCE_ENABLED setting
+func NewJceDomain() domain.DomainConnector[domain.JCEPerson] {
+	return &Jce{
+		BaseParh: "https://dataportal.jce.gob.do/api/padron/",
+		Stuff:    *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: "https://dataportal.jce.gob.do/api/padron/",
+			Paths: map[string]string{
+				"cedula": "consulta",
+			},
+		},
+		Enabled: JCEEnabled(),
+	}
+}
+
+// JCEEnabled reports whether the electoral registry may be queried
+func JCEEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("JCE_ENABLED"))
+	return enabled
+}
+
+func (*Jce) GetDomainType() domain.DomainType {
+	return domain.DomainTypeJCE
+}
+
+// Search resolves a cédula to its registered person. Queries that are not a
+// cédula return no records without reaching the registry.
+func (j *Jce) Search(ctx context.Context, query string) ([]domain.JCEPerson, error) {
+	if !j.Enabled {
+		return nil, ErrJCEDisabled
+	}
+
+	if !isCedula(query) {
+		return []domain.JCEPerson{}, nil
+	}
+
+	response, err := j.Stuff.Get(ctx, j.PathMap.GetURLFrom("cedula"), map[string]string{
+		"cedula": strings.ReplaceAll(strings.TrimSpace(query), "-", ""),
+	}, map[string]string{
+		"Accept": "application/json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return []domain.JCEPerson{}, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: response.StatusCode}
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var personResponse JcePersonResponse
+	if err := json.Unmarshal(body, &personResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+
+	person, err := j.ProcessData(domain.JCEPerson{
+		Cedula:          personResponse.Cedula,
+		Nombres:         personResponse.Nombres,
+		PrimerApellido:  personResponse.Apellido1,
+		SegundoApellido: personResponse.Apellido2,
+	})
+	if err != nil {
+		return []domain.JCEPerson{}, nil
+	}
+
+	return []domain.JCEPerson{person}, nil
+}
+
+func (j *Jce) ProcessData(data domain.JCEPerson) (domain.JCEPerson, error) {
+	if err := j.ValidateData(data); err != nil {
+		return domain.JCEPerson{}, err
+	}
+	return j.TransformData(data), nil
+}
+
+func (j *Jce) ValidateData(data domain.JCEPerson) error {
+	if data.Cedula == "" {
+		return fmt.Errorf("Cedula is required")
+	}
+	if strings.TrimSpace(data.Nombres) == "" {
+		return fmt.Errorf("Nombres is required")
+	}
+	return nil
+}
+
+func (j *Jce) TransformData(data domain.JCEPerson) domain.JCEPerson {
+	return domain.JCEPerson{
+		Cedula:          strings.ReplaceAll(strings.TrimSpace(data.Cedula), "-", ""),
+		Nombres:         strings.Join(strings.Fields(data.Nombres), " "),
+		PrimerApellido:  strings.TrimSpace(data.PrimerApellido),
+		SegundoApellido: strings.TrimSpace(data.SegundoApellido),
+	}
+}
+
+func (j *Jce) GetDataByCategory(data domain.JCEPerson, category domain.KeywordCategory) []string {
+	switch category {
+	case domain.KeywordCategoryPersonName:
+		if name := data.FullName(); name != "" {
+			return []string{name}
+		}
+	}
+	return []string{}
+}
+
+func (j *Jce) GetSearchableKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryContributorID,
+	}
+}
+
+func (j *Jce) GetFoundKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryPersonName,
+	}
+}
+
+// isCedula reports whether the query looks like a cédula (11 digits), ignoring
+// dashes
+func isCedula(query string) bool {
+	digits := strings.ReplaceAll(strings.TrimSpace(query), "-", "")
+	if len(digits) != 11 {
+		return false
+	}
+	for _, r := range digits {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
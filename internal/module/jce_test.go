@@ -0,0 +1,151 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const jceFixture = `{
+	"cedula": "001-1234567-8",
+	"nombres": " Juan  Carlos ",
+	"apellido1": "Perez",
+	"apellido2": "Gomez"
+}`
+
+func newTestJce(t *testing.T, queries *[]url.Values) *Jce {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.Query())
+		if r.URL.Query().Get("cedula") != "00112345678" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jceFixture))
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Jce{
+		Stuff: *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: srv.URL + "/",
+			Paths:   map[string]string{"cedula": "consulta"},
+		},
+		Enabled: true,
+	}
+}
+
+func TestJceResolvesCedulaToName(t *testing.T) {
+	var queries []url.Values
+	jce := newTestJce(t, &queries)
+
+	people, err := jce.Search(context.Background(), "001-1234567-8")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(people) != 1 {
+		t.Fatalf("expected 1 person, got %d", len(people))
+	}
+	if name := people[0].FullName(); name != "Juan Carlos Perez Gomez" {
+		t.Errorf("expected the canonical registered name, got %q", name)
+	}
+	if people[0].Cedula != "00112345678" {
+		t.Errorf("expected the cédula without dashes, got %q", people[0].Cedula)
+	}
+
+	keywords := domain.GetCategoryByKeywords(domain.DomainConnector[domain.JCEPerson](jce), people)
+	if names := keywords[domain.KeywordCategoryPersonName]; len(names) != 1 || names[0] != "Juan Carlos Perez Gomez" {
+		t.Errorf("expected the name as person keyword, got %v", names)
+	}
+
+	if len(queries) != 1 || queries[0].Get("cedula") != "00112345678" {
+		t.Errorf("expected a cédula lookup, got %v", queries)
+	}
+}
+
+func TestJceSearchUnknownCedula(t *testing.T) {
+	var queries []url.Values
+	jce := newTestJce(t, &queries)
+
+	people, err := jce.Search(context.Background(), "40212345678")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(people) != 0 {
+		t.Errorf("expected no person for an unknown cédula, got %+v", people)
+	}
+}
+
+func TestJceSearchSkipsOtherQueries(t *testing.T) {
+	var queries []url.Values
+	jce := newTestJce(t, &queries)
+
+	for _, query := range []string{"Novasco", "1-30-00000-1"} {
+		people, err := jce.Search(context.Background(), query)
+		if err != nil || len(people) != 0 {
+			t.Errorf("expected no records for %q, got %+v, %v", query, people, err)
+		}
+	}
+	if len(queries) != 0 {
+		t.Errorf("expected no request for queries that are not a cédula, got %v", queries)
+	}
+}
+
+func TestJceSearchDisabled(t *testing.T) {
+	var queries []url.Values
+	jce := newTestJce(t, &queries)
+	jce.Enabled = false
+
+	if _, err := jce.Search(context.Background(), "00112345678"); !errors.Is(err, ErrJCEDisabled) {
+		t.Fatalf("expected ErrJCEDisabled, got %v", err)
+	}
+	if len(queries) != 0 {
+		t.Errorf("expected no request while disabled, got %v", queries)
+	}
+}
+
+func TestCreateDynamicPipelineResolvesCedulaSeed(t *testing.T) {
+	domains := []domain.DomainType{domain.DomainTypeJCE, domain.DomainTypePGR}
+
+	pipeline, err := CreateDynamicPipeline(context.Background(), "001-1234567-8", domains, domain.DynamicPipelineConfig{})
+	if err != nil {
+		t.Fatalf("CreateDynamicPipeline returned error: %v", err)
+	}
+	if len(pipeline.Steps) != 2 || pipeline.Steps[0].DomainType != domain.DomainTypeJCE {
+		t.Errorf("expected a JCE step for a cédula seed, got %+v", pipeline.Steps)
+	}
+
+	pipeline, err = CreateDynamicPipeline(context.Background(), "Novasco", domains, domain.DynamicPipelineConfig{})
+	if err != nil {
+		t.Fatalf("CreateDynamicPipeline returned error: %v", err)
+	}
+	if len(pipeline.Steps) != 1 || pipeline.Steps[0].DomainType != domain.DomainTypePGR {
+		t.Errorf("expected no JCE step for a name seed, got %+v", pipeline.Steps)
+	}
+}
+
+func TestAvailableDomainTypesRespectsJceGate(t *testing.T) {
+	t.Setenv("JCE_ENABLED", "")
+	for _, domainType := range AvailableDomainTypes() {
+		if domainType == domain.DomainTypeJCE {
+			t.Fatal("expected JCE to be unavailable unless enabled")
+		}
+	}
+
+	t.Setenv("JCE_ENABLED", "true")
+	found := false
+	for _, domainType := range AvailableDomainTypes() {
+		found = found || domainType == domain.DomainTypeJCE
+	}
+	if !found {
+		t.Error("expected JCE to be available once enabled")
+	}
+}
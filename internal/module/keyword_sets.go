@@ -0,0 +1,70 @@
+package module
+
+import (
+	"insightful-intel/internal/domain"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	activeKeywordSets     domain.KeywordSets
+	activeKeywordSetsOnce sync.Once
+	activeKeywordSetsMu   sync.RWMutex
+)
+
+// ActiveKeywordSets returns the keyword sets docking searches use: the ones
+// last passed to SetKeywordSets, or else the ones configured in the
+// environment (see keywordSetsFromEnv)
+func ActiveKeywordSets() domain.KeywordSets {
+	activeKeywordSetsOnce.Do(func() {
+		activeKeywordSets = keywordSetsFromEnv()
+	})
+
+	activeKeywordSetsMu.RLock()
+	defer activeKeywordSetsMu.RUnlock()
+	return activeKeywordSets
+}
+
+// SetKeywordSets replaces the keyword sets of the docking searches started
+// from now on. Empty sets keep their defaults.
+func SetKeywordSets(sets domain.KeywordSets) {
+	activeKeywordSetsOnce.Do(func() {})
+
+	activeKeywordSetsMu.Lock()
+	defer activeKeywordSetsMu.Unlock()
+	activeKeywordSets = sets.WithDefaults()
+}
+
+// keywordSetsFromEnv reads the keyword sets from the JSON file at
+// KEYWORD_SETS_FILE, then overrides each set listed comma-separated in
+// FRAUD_KEYWORDS, SOCIAL_MEDIA_SITES_KEYWORDS, FILE_TYPE_KEYWORDS and
+// X_IN_URL_KEYWORDS. Unset or invalid configuration keeps the defaults.
+func keywordSetsFromEnv() domain.KeywordSets {
+	sets := domain.DefaultKeywordSets()
+
+	if path := os.Getenv("KEYWORD_SETS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("Could not read keyword sets file, using defaults", "path", path, "error", err)
+		} else if loaded, err := domain.LoadKeywordSets(data); err != nil {
+			slog.Warn("Could not load keyword sets file, using defaults", "path", path, "error", err)
+		} else {
+			sets = loaded
+		}
+	}
+
+	for env, set := range map[string]*[]string{
+		"FRAUD_KEYWORDS":              &sets.Fraud,
+		"SOCIAL_MEDIA_SITES_KEYWORDS": &sets.SocialMediaSites,
+		"FILE_TYPE_KEYWORDS":          &sets.FileTypes,
+		"X_IN_URL_KEYWORDS":           &sets.XInURL,
+	} {
+		if value := os.Getenv(env); value != "" {
+			*set = strings.Split(value, ",")
+		}
+	}
+
+	return sets.WithDefaults()
+}
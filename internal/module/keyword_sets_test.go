@@ -0,0 +1,83 @@
+package module
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetKeywordSetsReachesTheQueryBuilder(t *testing.T) {
+	previous := ActiveKeywordSets()
+	t.Cleanup(func() { SetKeywordSets(previous) })
+
+	SetKeywordSets(domain.KeywordSets{
+		Fraud:            []string{"scam", "lawsuit"},
+		SocialMediaSites: []string{"facebook.com", "instagram.com"},
+	})
+
+	tests := map[domain.DomainType][]string{
+		domain.DomainTypeGoogleDorking: {"intext:(scam OR lawsuit)"},
+		domain.DomainTypeSocialMedia:   {"site:facebook.com OR site:instagram.com"},
+		domain.DomainTypeFileType:      {"intext:(scam OR lawsuit)", "filetype:pdf"},
+		domain.DomainTypeXSocialMedia:  {"site:x.com", "inurl:status"},
+	}
+	for domainType, want := range tests {
+		provider := &fakeSearchProvider{}
+		gd := &GoogleDorking{Provider: provider}
+
+		params := withDorkingKeywords(domainType, domain.GoogleDorkingSearchParams{Query: "novasco", MaxResults: 10}, ActiveKeywordSets())
+		if _, err := gd.SearchWithParams(context.Background(), params); err != nil {
+			t.Fatalf("%s: SearchWithParams returned error: %v", domainType, err)
+		}
+
+		query := provider.queries[0]
+		for _, fragment := range want {
+			if !strings.Contains(query, fragment) {
+				t.Errorf("%s: expected %q in query %q", domainType, fragment, query)
+			}
+		}
+		if strings.Contains(query, "fraude") || strings.Contains(query, "tiktok.com") {
+			t.Errorf("%s: expected the default keywords to be replaced, got %q", domainType, query)
+		}
+	}
+
+	// Keywords set on the search win over the active sets
+	params := withDorkingKeywords(domain.DomainTypeGoogleDorking, domain.GoogleDorkingSearchParams{IncludeKeywords: []string{"estafa"}}, ActiveKeywordSets())
+	if !slices.Equal(params.IncludeKeywords, []string{"estafa"}) {
+		t.Errorf("expected the keywords of the search to be kept, got %v", params.IncludeKeywords)
+	}
+}
+
+func TestKeywordSetsFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keywords.json")
+	if err := os.WriteFile(path, []byte(`{"fraud": ["scam"], "file_types": ["pdf"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KEYWORD_SETS_FILE", path)
+	t.Setenv("FRAUD_KEYWORDS", "fraud, embezzlement")
+	t.Setenv("SOCIAL_MEDIA_SITES_KEYWORDS", "")
+	t.Setenv("FILE_TYPE_KEYWORDS", "")
+	t.Setenv("X_IN_URL_KEYWORDS", "")
+
+	sets := keywordSetsFromEnv()
+	// The environment overrides the file, which overrides the defaults
+	if !slices.Equal(sets.Fraud, []string{"fraud", "embezzlement"}) {
+		t.Errorf("expected the fraud terms of the environment, got %v", sets.Fraud)
+	}
+	if !slices.Equal(sets.FileTypes, []string{"pdf"}) {
+		t.Errorf("expected the file types of the file, got %v", sets.FileTypes)
+	}
+	if !slices.Equal(sets.SocialMediaSites, domain.DefaultKeywordSets().SocialMediaSites) {
+		t.Errorf("expected the default sites, got %v", sets.SocialMediaSites)
+	}
+
+	t.Setenv("KEYWORD_SETS_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("FRAUD_KEYWORDS", "")
+	if sets := keywordSetsFromEnv(); !slices.Equal(sets.Fraud, domain.DefaultKeywordSets().Fraud) {
+		t.Errorf("expected the defaults without a readable file, got %v", sets.Fraud)
+	}
+}
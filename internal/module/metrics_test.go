@@ -0,0 +1,45 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentSearchRecordsMetrics(t *testing.T) {
+	metrics := infra.NewMetrics()
+	search := instrumentSearch(metrics, func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		if params.Query == "broken" {
+			return nil, errors.New("source unavailable")
+		}
+		return &domain.DomainSearchResult{Success: params.Query != "empty", DomainType: domainType}, nil
+	})
+
+	for _, query := range []string{"novasco", "acme", "broken", "empty"} {
+		search(context.Background(), domain.DomainTypeONAPI, domain.DomainSearchParams{Query: query})
+	}
+	search(context.Background(), domain.DomainTypeDGII, domain.DomainSearchParams{Query: "novasco"})
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus returned error: %v", err)
+	}
+
+	for _, line := range []string{
+		`insightful_search_attempts_total{domain_type="DGII"} 1`,
+		`insightful_search_attempts_total{domain_type="ONAPI"} 4`,
+		`insightful_search_successes_total{domain_type="ONAPI"} 2`,
+		`insightful_search_failures_total{domain_type="ONAPI"} 2`,
+		`insightful_search_failures_total{domain_type="DGII"} 0`,
+		`insightful_search_duration_seconds_bucket{domain_type="ONAPI",le="0.1"} 4`,
+		`insightful_search_duration_seconds_bucket{domain_type="ONAPI",le="+Inf"} 4`,
+		`insightful_search_duration_seconds_count{domain_type="ONAPI"} 4`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in metrics:\n%s", line, out.String())
+		}
+	}
+}
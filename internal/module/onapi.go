@@ -1,11 +1,13 @@
 package module
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
 	"io"
+	"net/http"
 	"strings"
 	"time"
 )
@@ -16,6 +18,12 @@ type Onapi struct {
 	Stuff    custom.Client
 	BaseParh string
 	PathMap  custom.CustomPathMap
+	// ProductMentions extracts the brands and companies mentioned in the
+	// product descriptions of the trademark classes as company names
+	ProductMentions bool
+	// CSRF, when set, adds the anti-forgery token of the session to the
+	// search requests
+	CSRF *custom.CSRFSession
 }
 
 type OnapiEntityResponse struct {
@@ -48,7 +56,7 @@ func (*Onapi) GetDomainType() domain.DomainType {
 
 // onapi endpoint
 func NewOnapiDomain() domain.DomainConnector[domain.Entity] {
-	return &Onapi{
+	onapi := &Onapi{
 		BaseParh: "https://www.onapi.gob.do/busqapi/signos/",
 		Stuff:    *custom.NewClient(),
 		PathMap: custom.CustomPathMap{
@@ -58,7 +66,13 @@ func NewOnapiDomain() domain.DomainConnector[domain.Entity] {
 				"detail":    "byexp",
 			},
 		},
+		ProductMentions: onapiProductMentionsEnabled(),
 	}
+	if session := onapiCSRF(); session != nil {
+		onapi.Stuff = *session.Client()
+		onapi.CSRF = session
+	}
+	return onapi
 }
 
 // Implement DomainConnector[domain.Entity] for Onapi
@@ -81,6 +95,12 @@ func (o *Onapi) ValidateData(data domain.Entity) error {
 	return nil
 }
 
+// ShouldPersist keeps the valid entities naming a sign, leaving out the blank
+// ones ONAPI sometimes returns
+func (o *Onapi) ShouldPersist(data domain.Entity) bool {
+	return o.ValidateData(data) == nil && strings.TrimSpace(data.Texto) != ""
+}
+
 func (o *Onapi) TransformData(data domain.Entity) domain.Entity {
 	transformed := data
 	transformed.Texto = strings.TrimSpace(data.Texto)
@@ -96,6 +116,11 @@ func (o *Onapi) GetDataByCategory(data domain.Entity, category domain.KeywordCat
 	switch category {
 	case domain.KeywordCategoryCompanyName:
 		result = append(result, data.Texto)
+		if o.ProductMentions {
+			for _, clase := range data.ListaClases {
+				result = append(result, extractProductMentions(clase.Productos)...)
+			}
+		}
 	case domain.KeywordCategoryPersonName:
 		result = append(result, data.Titular, data.Gestor)
 	case domain.KeywordCategoryAddress:
@@ -120,12 +145,12 @@ func (o *Onapi) GetFoundKeywordCategories() []domain.KeywordCategory {
 }
 
 // Search implements DomainConnector interface by wrapping SearchComercialName
-func (o *Onapi) Search(query string) ([]domain.Entity, error) {
-	return o.SearchComercialName(query)
+func (o *Onapi) Search(ctx context.Context, query string) ([]domain.Entity, error) {
+	return o.SearchComercialName(ctx, query)
 }
 
-func (o *Onapi) SearchComercialName(query string) ([]domain.Entity, error) {
-	response, err := o.Stuff.Get(o.PathMap.GetURLFrom("firstpage"), map[string]string{
+func (o *Onapi) SearchComercialName(ctx context.Context, query string) ([]domain.Entity, error) {
+	response, err := o.get(ctx, o.PathMap.GetURLFrom("firstpage"), map[string]string{
 		"subtipo":  "",
 		"texto":    query,
 		"tipo":     "",
@@ -134,7 +159,6 @@ func (o *Onapi) SearchComercialName(query string) ([]domain.Entity, error) {
 		"pageIdx":  "1",
 	}, map[string]string{
 		"Content-Type":    "application/json",
-		"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
 		"Accept":          "application/json, text/plain, */*",
 		"Accept-Language": "en-US,en;q=0.9",
 		"Accept-Encoding": "gzip, deflate, br",
@@ -166,7 +190,7 @@ func (o *Onapi) SearchComercialName(query string) ([]domain.Entity, error) {
 	for _, onapiEntity := range onapiResponse {
 		domainEntity := toDomainEntity(onapiEntity)
 		if o.ValidateData(domainEntity) == nil {
-			details, err := o.GetDetails(domainEntity.NumeroExpediente, domainEntity.SerieExpediente)
+			details, err := o.GetDetails(ctx, domainEntity.NumeroExpediente, domainEntity.SerieExpediente)
 			if err != nil {
 				return nil, fmt.Errorf("failed to unmarsha Details JSON response: %w", err)
 			}
@@ -178,8 +202,8 @@ func (o *Onapi) SearchComercialName(query string) ([]domain.Entity, error) {
 	return domainEntities, nil
 }
 
-func (o *Onapi) GetDetails(numero int32, serie int32) (*domain.Entity, error) {
-	response, err := o.Stuff.Get(o.PathMap.GetURLFrom("detail"), map[string]string{
+func (o *Onapi) GetDetails(ctx context.Context, numero int32, serie int32) (*domain.Entity, error) {
+	response, err := o.get(ctx, o.PathMap.GetURLFrom("detail"), map[string]string{
 		"numero":    fmt.Sprintf("%d", numero),
 		"tipoExped": "E",
 		"serie":     fmt.Sprintf("%d", serie),
@@ -209,6 +233,15 @@ func (o *Onapi) GetDetails(numero int32, serie int32) (*domain.Entity, error) {
 	return &domainEntity, nil
 }
 
+// get sends a search request, with the anti-forgery token of the CSRF session
+// when there is one
+func (o *Onapi) get(ctx context.Context, endpoint string, params map[string]string, headers map[string]string) (*http.Response, error) {
+	if o.CSRF != nil {
+		return o.CSRF.Get(ctx, endpoint, params, headers)
+	}
+	return o.Stuff.Get(ctx, endpoint, params, headers)
+}
+
 func toDomainEntity(onapiEntity OnapiEntityResponse) domain.Entity {
 	return domain.Entity{
 		SerieExpediente:   onapiEntity.SerieExpediente,
@@ -0,0 +1,69 @@
+package module
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// productQuotedPattern matches names quoted in a product description
+	productQuotedPattern = regexp.MustCompile(`["“«]([^"”»]{3,60})["”»]`)
+	// productCompanyPattern matches capitalized names, which may be joined by
+	// lowercase connectives, followed by a legal form
+	productCompanyPattern = regexp.MustCompile(`([\p{Lu}\d][\p{L}\d&\-]*(?:\s+(?:(?:de|del|la|las|los|y|e)\s+)?[\p{Lu}\d][\p{L}\d&\-]*){0,4}),?\s+(S\.\s?R\.\s?L\.?|SRL|S\.\s?A\.\s?S\.?|SAS|S\.\s?A\.?|E\.\s?I\.\s?R\.\s?L\.?|EIRL)`)
+	// productUppercasePattern matches runs of uppercase words, which name brands
+	// in otherwise lowercase descriptions
+	productUppercasePattern = regexp.MustCompile(`[\p{Lu}\d][\p{Lu}\d&\-]+(?:\s+[\p{Lu}\d][\p{Lu}\d&\-]+)*`)
+)
+
+// extractProductMentions returns the brand and company names mentioned in the
+// product and service descriptions of a trademark's classes
+func extractProductMentions(productos string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	add := func(mention string) {
+		mention = strings.Trim(strings.Join(strings.Fields(mention), " "), " ,;:-")
+		if len([]rune(mention)) < 3 || !strings.ContainsFunc(mention, unicode.IsLetter) {
+			return
+		}
+		key := strings.ToLower(mention)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		mentions = append(mentions, mention)
+	}
+
+	for _, match := range productQuotedPattern.FindAllStringSubmatch(productos, -1) {
+		add(match[1])
+	}
+	for _, match := range productCompanyPattern.FindAllString(productos, -1) {
+		add(match)
+	}
+
+	// Descriptions written in uppercase give no hint of which words are names
+	if strings.ContainsFunc(productos, unicode.IsLower) {
+		for _, match := range productUppercasePattern.FindAllString(productos, -1) {
+			// Skip the parts of names already found with their legal form
+			if slices.ContainsFunc(mentions, func(mention string) bool {
+				return strings.Contains(strings.ToLower(mention), strings.ToLower(match))
+			}) {
+				continue
+			}
+			add(match)
+		}
+	}
+
+	return mentions
+}
+
+// onapiProductMentionsEnabled reports whether product descriptions are mined for
+// names, which ONAPI_PRODUCT_KEYWORDS=false turns off
+func onapiProductMentionsEnabled() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("ONAPI_PRODUCT_KEYWORDS"))
+	return err != nil || enabled
+}
@@ -0,0 +1,39 @@
+package module
+
+import (
+	"insightful-intel/internal/domain"
+	"slices"
+	"testing"
+)
+
+func TestOnapiExtractsProductMentions(t *testing.T) {
+	entity := domain.Entity{
+		SerieExpediente:  2020,
+		NumeroExpediente: 1,
+		Texto:            "NOVASCO",
+		ListaClases: []domain.ListaClase{
+			{Numero: 35, Productos: `Distribución y venta de bebidas de la marca "Agua Cristal"; importación de productos fabricados por Constructora del Este S.R.L.`},
+			{Numero: 43, Productos: "Servicios de restaurante bajo licencia de PIZZA BELLA y cafetería."},
+			{Numero: 9, Productos: "APARATOS E INSTRUMENTOS CIENTIFICOS, NAUTICOS Y GEODESICOS"},
+		},
+	}
+
+	onapi := &Onapi{ProductMentions: true}
+	names := onapi.GetDataByCategory(entity, domain.KeywordCategoryCompanyName)
+
+	for _, want := range []string{"NOVASCO", "Agua Cristal", "Constructora del Este S.R.L.", "PIZZA BELLA"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected %q among the company names, got %v", want, names)
+		}
+	}
+	for _, unwanted := range []string{"APARATOS E INSTRUMENTOS CIENTIFICOS", "Distribución"} {
+		if slices.ContainsFunc(names, func(name string) bool { return name == unwanted }) {
+			t.Errorf("expected %q not to be extracted, got %v", unwanted, names)
+		}
+	}
+
+	onapi.ProductMentions = false
+	if names := onapi.GetDataByCategory(entity, domain.KeywordCategoryCompanyName); !slices.Equal(names, []string{"NOVASCO"}) {
+		t.Errorf("expected only the mark text when product mentions are off, got %v", names)
+	}
+}
@@ -1,27 +1,71 @@
 package module
 
 import (
+	"context"
 	"fmt"
 	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
+	"net/http"
 	"net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gocolly/colly"
 )
 
 var _ domain.DomainConnector[domain.PGRNews] = &Pgr{}
 
+// DefaultPgrMaxPages is the number of search result pages visited when
+// PGR_MAX_PAGES is not set
+const DefaultPgrMaxPages = 5
+
+// DefaultPgrRetries and DefaultPgrRetryBackoff are the retries of a result page
+// failing with a transient error, and the wait before the first of them, when
+// PGR_RETRIES and PGR_RETRY_BACKOFF are not set. The wait doubles with each
+// retry.
+const (
+	DefaultPgrRetries      = 2
+	DefaultPgrRetryBackoff = 500 * time.Millisecond
+)
+
 type Pgr struct {
 	Stuff    custom.Client
 	BaseParh string
 	PathMap  custom.CustomPathMap
+	// MaxPages is the number of search result pages followed, one when zero
+	MaxPages int
+	// Retries is the number of times a result page failing with a network
+	// error, a 429 or a 5xx is fetched again, after RetryBackoff and then
+	// twice as long each time
+	Retries      int
+	RetryBackoff time.Duration
 }
 
 func NewPgrDomain() domain.DomainConnector[domain.PGRNews] {
+	maxPages, err := strconv.Atoi(os.Getenv("PGR_MAX_PAGES"))
+	if err != nil || maxPages <= 0 {
+		maxPages = DefaultPgrMaxPages
+	}
+
+	retries, err := strconv.Atoi(os.Getenv("PGR_RETRIES"))
+	if err != nil || retries < 0 {
+		retries = DefaultPgrRetries
+	}
+
+	backoff, err := time.ParseDuration(os.Getenv("PGR_RETRY_BACKOFF"))
+	if err != nil || backoff < 0 {
+		backoff = DefaultPgrRetryBackoff
+	}
+
 	return &Pgr{
-		BaseParh: "https://pgr.gob.do/",
-		Stuff:    *custom.NewClient(),
+		BaseParh:     "https://pgr.gob.do/",
+		Stuff:        *custom.NewClient(),
+		MaxPages:     maxPages,
+		Retries:      retries,
+		RetryBackoff: backoff,
 	}
 }
 
@@ -29,27 +73,134 @@ func (*Pgr) GetDomainType() domain.DomainType {
 	return domain.DomainTypePGR
 }
 
-func (p *Pgr) Search(query string) ([]domain.PGRNews, error) {
+// Search scrapes the news the site search returns for the query, following
+// the result pages up to MaxPages. It stops at the first page adding no news,
+// or missing past the first one, and news listed on several pages are kept
+// once. A search finding nothing returns no news, while a page that cannot be
+// fetched, even after the retries, fails the search.
+func (p *Pgr) Search(ctx context.Context, query string) ([]domain.PGRNews, error) {
+	base, err := url.Parse(p.BaseParh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PGR base URL: %w", err)
+	}
+
 	c := colly.NewCollector(
-		colly.AllowedDomains("pgr.gob.do"),
+		colly.AllowedDomains(base.Host),
 	)
+	c.WithTransport(custom.NewContextTransport(ctx))
+	// Retries fetch the same page again
+	c.AllowURLRevisit = true
 
-	var news []domain.PGRNews
+	news := []domain.PGRNews{}
+	seen := map[string]bool{}
+	added := 0
+	// failed is the response of the last request failing, nil when the page
+	// was fetched or the request was never sent
+	var failed *colly.Response
 
 	c.OnHTML("article", func(e *colly.HTMLElement) {
 		article := domain.PGRNews{}
 
 		article.URL = e.ChildAttr("h5 a", "href")
 		article.Title = e.ChildAttr("h5 a", "title")
+		article.PublishedAt = parsePgrDate(e.ChildAttr("time", "datetime"), e.ChildText("time"))
+
+		if seen[article.URL] {
+			return
+		}
+		seen[article.URL] = true
+		added++
 
 		news = append(news, article)
 
 	})
-	c.Visit(fmt.Sprintf("%s?s=%s", p.BaseParh, url.QueryEscape(query)))
+	c.OnError(func(r *colly.Response, err error) {
+		failed = r
+	})
+
+	for page := 1; page <= max(p.MaxPages, 1); page++ {
+		added = 0
+		for attempt := 0; ; attempt++ {
+			failed = nil
+			err = c.Visit(pgrSearchURL(p.BaseParh, query, page))
+			if err == nil || attempt >= p.Retries || failed == nil || !isTransientStatus(failed.StatusCode) || ctx.Err() != nil {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.RetryBackoff << attempt):
+			}
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			// WordPress answers the pages past the last one with a 404
+			if page > 1 && failed != nil && failed.StatusCode == http.StatusNotFound {
+				break
+			}
+			return nil, fmt.Errorf("failed to fetch PGR results page %d: %w", page, err)
+		}
+		if added == 0 {
+			break
+		}
+	}
 
 	return news, nil
 }
 
+// isTransientStatus reports whether a failed request, given the status it was
+// answered with or zero when it got no answer, may succeed when made again
+func isTransientStatus(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// pgrSearchURL returns the URL of a page of the WordPress search results
+func pgrSearchURL(basePath, query string, page int) string {
+	searchURL := fmt.Sprintf("%s?s=%s", basePath, url.QueryEscape(query))
+	if page > 1 {
+		searchURL += fmt.Sprintf("&paged=%d", page)
+	}
+	return searchURL
+}
+
+// pgrMonths maps the Spanish month names the site prints dates with
+var pgrMonths = map[string]time.Month{
+	"enero": time.January, "febrero": time.February, "marzo": time.March,
+	"abril": time.April, "mayo": time.May, "junio": time.June,
+	"julio": time.July, "agosto": time.August, "septiembre": time.September,
+	"setiembre": time.September, "octubre": time.October,
+	"noviembre": time.November, "diciembre": time.December,
+}
+
+var pgrTextDate = regexp.MustCompile(`(\d{1,2})\s+(?:de\s+)?([a-záéíóú]+)\s*(?:de\s+|,\s*)?(\d{4})`)
+
+// parsePgrDate parses the publication date of an article from the datetime
+// attribute of its time element, falling back to the Spanish date it shows,
+// such as "5 de marzo de 2024". It returns the zero time when neither parses.
+func parsePgrDate(datetime, text string) time.Time {
+	datetime = strings.TrimSpace(datetime)
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if parsed, err := time.Parse(layout, datetime); err == nil {
+			return parsed
+		}
+	}
+
+	match := pgrTextDate.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return time.Time{}
+	}
+	month, ok := pgrMonths[match[2]]
+	if !ok {
+		return time.Time{}
+	}
+	day, _ := strconv.Atoi(match[1])
+	year, _ := strconv.Atoi(match[3])
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
 func (p *Pgr) ProcessData(data domain.PGRNews) (domain.PGRNews, error) {
 	if err := p.ValidateData(data); err != nil {
 		return domain.PGRNews{}, err
@@ -0,0 +1,167 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const pgrArticle = `<article>
+	<h5><a href="%s" title="%s">%s</a></h5>
+	<time class="entry-date published" datetime="%s">%s</time>
+</article>`
+
+func pgrResultsPage(articles ...string) string {
+	page := "<html><body>"
+	for _, article := range articles {
+		page += article
+	}
+	return page + "</body></html>"
+}
+
+func TestPgrSearchFollowsResultPages(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Query().Get("paged"))
+		if r.URL.Query().Get("s") != "novasco" {
+			t.Errorf("unexpected search query %q", r.URL.Query().Get("s"))
+		}
+
+		switch r.URL.Query().Get("paged") {
+		case "":
+			fmt.Fprint(w, pgrResultsPage(
+				fmt.Sprintf(pgrArticle, "https://pgr.gob.do/noticias/novasco-1/", "Caso Novasco", "Caso Novasco", "2024-03-05T10:30:00-04:00", "5 marzo, 2024"),
+				fmt.Sprintf(pgrArticle, "https://pgr.gob.do/noticias/novasco-2/", "Novasco, segunda fase", "Novasco, segunda fase", "", "12 de febrero de 2024"),
+			))
+		case "2":
+			fmt.Fprint(w, pgrResultsPage(
+				// Listed on the first page too
+				fmt.Sprintf(pgrArticle, "https://pgr.gob.do/noticias/novasco-2/", "Novasco, segunda fase", "Novasco, segunda fase", "", "12 de febrero de 2024"),
+				fmt.Sprintf(pgrArticle, "https://pgr.gob.do/noticias/novasco-3/", "Arresto en caso Novasco", "Arresto en caso Novasco", "2023-11-20", "20 noviembre, 2023"),
+			))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	pgr := &Pgr{Stuff: *custom.NewClient(), BaseParh: server.URL + "/", MaxPages: 5}
+	news, err := pgr.Search(context.Background(), "novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(requested) != 3 {
+		t.Errorf("expected the search to stop at the missing third page, requested pages %q", requested)
+	}
+
+	expected := []struct {
+		url       string
+		published time.Time
+	}{
+		{url: "https://pgr.gob.do/noticias/novasco-1/", published: time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)},
+		{url: "https://pgr.gob.do/noticias/novasco-2/", published: time.Date(2024, time.February, 12, 0, 0, 0, 0, time.UTC)},
+		{url: "https://pgr.gob.do/noticias/novasco-3/", published: time.Date(2023, time.November, 20, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(news) != len(expected) {
+		t.Fatalf("expected %d news across both pages, got %+v", len(expected), news)
+	}
+	for i, want := range expected {
+		if news[i].URL != want.url {
+			t.Errorf("news %d: expected URL %q, got %q", i, want.url, news[i].URL)
+		}
+		if !news[i].PublishedAt.Equal(want.published) {
+			t.Errorf("news %d: expected published at %v, got %v", i, want.published, news[i].PublishedAt)
+		}
+	}
+}
+
+func TestPgrSearchStopsAtMaxPages(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, pgrResultsPage(
+			fmt.Sprintf(pgrArticle, "https://pgr.gob.do/noticias/"+r.URL.Query().Get("paged"), "Noticia", "Noticia", "", ""),
+		))
+	}))
+	defer server.Close()
+
+	pgr := &Pgr{Stuff: *custom.NewClient(), BaseParh: server.URL + "/", MaxPages: 2}
+	news, err := pgr.Search(context.Background(), "novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if requests != 2 || len(news) != 2 {
+		t.Errorf("expected 2 pages and 2 news, got %d pages and %d news", requests, len(news))
+	}
+	if !news[0].PublishedAt.IsZero() {
+		t.Errorf("expected no publication date without one in the markup, got %v", news[0].PublishedAt)
+	}
+}
+
+func TestPgrSearchRetriesTransientErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, pgrResultsPage(
+			fmt.Sprintf(pgrArticle, "https://pgr.gob.do/noticias/novasco-1/", "Caso Novasco", "Caso Novasco", "2024-03-05", ""),
+		))
+	}))
+	defer server.Close()
+
+	pgr := &Pgr{Stuff: *custom.NewClient(), BaseParh: server.URL + "/", Retries: 2, RetryBackoff: time.Millisecond}
+	news, err := pgr.Search(context.Background(), "novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected the page to be fetched again after the 503, got %d requests", requests)
+	}
+	if len(news) != 1 || news[0].URL != "https://pgr.gob.do/noticias/novasco-1/" {
+		t.Errorf("expected the news of the retried page, got %+v", news)
+	}
+}
+
+func TestPgrSearchReportsPersistentFailures(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	pgr := &Pgr{Stuff: *custom.NewClient(), BaseParh: server.URL + "/", Retries: 2, RetryBackoff: time.Millisecond}
+	news, err := pgr.Search(context.Background(), "novasco")
+	if err == nil {
+		t.Fatalf("expected the failing page to fail the search, got %+v", news)
+	}
+	if requests != 3 {
+		t.Errorf("expected the first attempt and 2 retries, got %d requests", requests)
+	}
+}
+
+func TestPgrSearchWithoutResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pgrResultsPage())
+	}))
+	defer server.Close()
+
+	pgr := &Pgr{Stuff: *custom.NewClient(), BaseParh: server.URL + "/", Retries: 2, RetryBackoff: time.Millisecond}
+	news, err := pgr.Search(context.Background(), "novasco")
+	if err != nil {
+		t.Fatalf("expected no error when nothing is found, got %v", err)
+	}
+	if news == nil || len(news) != 0 {
+		t.Errorf("expected an empty list of news, got %#v", news)
+	}
+}
@@ -0,0 +1,179 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultRateLimit is the rate applied to domains without a specific limit
+var DefaultRateLimit = domain.RateLimit{RequestsPerSecond: 1, Burst: 1}
+
+// DefaultRateLimits are the conservative per-domain rates that keep the
+// pipelines from getting the server IP banned by the sources
+var DefaultRateLimits = map[domain.DomainType]domain.RateLimit{
+	domain.DomainTypeONAPI:         {RequestsPerSecond: 1, Burst: 1},
+	domain.DomainTypeSCJ:           {RequestsPerSecond: 1, Burst: 1},
+	domain.DomainTypePGR:           {RequestsPerSecond: 1, Burst: 1},
+	domain.DomainTypeJCE:           {RequestsPerSecond: 1, Burst: 1},
+	domain.DomainTypeSanctions:     {RequestsPerSecond: 10, Burst: 10},
+	domain.DomainTypeSIB:           {RequestsPerSecond: 1, Burst: 1},
+	domain.DomainTypeDGII:          {RequestsPerSecond: 2, Burst: 2},
+	domain.DomainTypeCamara:        {RequestsPerSecond: 2, Burst: 2},
+	domain.DomainTypeGoogleDorking: {RequestsPerSecond: 1, Burst: 1},
+}
+
+// RateLimiter spaces the outbound requests of each host with a token bucket,
+// at the rate of the domain sending them. Domains that query the same host
+// share its bucket.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limits   map[domain.DomainType]domain.RateLimit
+	limiters map[rateLimitBucket]*rate.Limiter
+	// parent holds the buckets of the domains this limiter has no limit for
+	parent *RateLimiter
+}
+
+// rateLimitBucket identifies the bucket of the requests a domain sends to a host
+type rateLimitBucket struct {
+	source domain.DomainType
+	host   string
+}
+
+type rateLimiterKey struct{}
+
+// DefaultRateLimiter is the rate limiter the outbound requests of SearchDomain
+// wait on
+var DefaultRateLimiter = NewRateLimiter(DefaultRateLimits)
+
+// NewRateLimiter creates a rate limiter with the given per-domain limits, using
+// DefaultRateLimit for the other domains
+func NewRateLimiter(limits map[domain.DomainType]domain.RateLimit) *RateLimiter {
+	limiter := &RateLimiter{
+		limits:   make(map[domain.DomainType]domain.RateLimit),
+		limiters: make(map[rateLimitBucket]*rate.Limiter),
+	}
+	limiter.SetLimits(limits)
+	return limiter
+}
+
+// WithLimits returns a limiter giving the domains in limits their own buckets
+// and sharing the buckets of l for the other domains, so a run can override
+// the rates of some sources without changing them for the other runs
+func (l *RateLimiter) WithLimits(limits map[domain.DomainType]domain.RateLimit) *RateLimiter {
+	if len(limits) == 0 {
+		return l
+	}
+	limiter := NewRateLimiter(limits)
+	limiter.parent = l
+	return limiter
+}
+
+// WithRateLimiter returns a context whose searches wait on limiter
+func WithRateLimiter(ctx context.Context, limiter *RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, limiter)
+}
+
+// RateLimiterFromContext returns the rate limiter of the context, or
+// DefaultRateLimiter when it has none
+func RateLimiterFromContext(ctx context.Context) *RateLimiter {
+	if limiter, ok := ctx.Value(rateLimiterKey{}).(*RateLimiter); ok {
+		return limiter
+	}
+	return DefaultRateLimiter
+}
+
+// withDomainRateLimit returns a context whose outbound requests each take a
+// token from the bucket of domainType for their host in the rate limiter of ctx
+func withDomainRateLimit(ctx context.Context, domainType domain.DomainType) context.Context {
+	return custom.WithRequestLimiter(ctx, domainRequestLimiter{
+		limiter:    RateLimiterFromContext(ctx),
+		domainType: domainType,
+	})
+}
+
+// domainRequestLimiter spaces the requests a domain sends to each host
+type domainRequestLimiter struct {
+	limiter    *RateLimiter
+	domainType domain.DomainType
+}
+
+func (d domainRequestLimiter) Wait(ctx context.Context, host string) error {
+	return d.limiter.Wait(ctx, d.domainType, host)
+}
+
+// SetLimits changes the limits of the given domains, keeping the others
+func (l *RateLimiter) SetLimits(limits map[domain.DomainType]domain.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for domainType, limit := range limits {
+		limit = normalizeRateLimit(limit)
+		source := rateLimitHost(domainType)
+		l.limits[source] = limit
+		for bucket, limiter := range l.limiters {
+			if bucket.source == source {
+				limiter.SetLimit(rate.Limit(limit.RequestsPerSecond))
+				limiter.SetBurst(limit.Burst)
+			}
+		}
+	}
+}
+
+// Wait blocks until a request of the domain to host is allowed, or returns an
+// error when ctx is done first. A nil limiter never waits.
+func (l *RateLimiter) Wait(ctx context.Context, domainType domain.DomainType, host string) error {
+	if l == nil {
+		return nil
+	}
+
+	if err := l.limiter(domainType, host).Wait(ctx); err != nil {
+		return fmt.Errorf("rate limit wait for %s: %w", domainType, err)
+	}
+	return nil
+}
+
+func (l *RateLimiter) limiter(domainType domain.DomainType, host string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	source := rateLimitHost(domainType)
+	limit, ok := l.limits[source]
+	if !ok && l.parent != nil {
+		return l.parent.limiter(domainType, host)
+	}
+	if !ok {
+		limit = DefaultRateLimit
+	}
+
+	bucket := rateLimitBucket{source: source, host: host}
+	limiter, ok := l.limiters[bucket]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), limit.Burst)
+		l.limiters[bucket] = limiter
+	}
+	return limiter
+}
+
+// rateLimitHost maps the domains that search the same host to one bucket
+func rateLimitHost(domainType domain.DomainType) domain.DomainType {
+	switch domainType {
+	case domain.DomainTypeSocialMedia, domain.DomainTypeFileType, domain.DomainTypeXSocialMedia:
+		return domain.DomainTypeGoogleDorking
+	}
+	return domainType
+}
+
+func normalizeRateLimit(limit domain.RateLimit) domain.RateLimit {
+	if limit.RequestsPerSecond <= 0 {
+		limit.RequestsPerSecond = DefaultRateLimit.RequestsPerSecond
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+	return limit
+}
@@ -0,0 +1,180 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesRapidCalls(t *testing.T) {
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeONAPI: {RequestsPerSecond: 20, Burst: 1},
+	})
+
+	const calls = 5
+	start := time.Now()
+	for i := 0; i < calls; i++ {
+		if err := limiter.Wait(context.Background(), domain.DomainTypeONAPI, "example.test"); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// The first call spends the burst and every later one waits 50ms
+	minimum := time.Duration(calls-1) * 50 * time.Millisecond
+	if elapsed < minimum-10*time.Millisecond {
+		t.Errorf("expected %d calls to take at least %v, took %v", calls, minimum, elapsed)
+	}
+}
+
+func TestRateLimiterKeepsDomainsIndependent(t *testing.T) {
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeONAPI: {RequestsPerSecond: 0.1, Burst: 1},
+		domain.DomainTypeDGII:  {RequestsPerSecond: 0.1, Burst: 1},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, domain.DomainTypeONAPI, "example.test"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := limiter.Wait(ctx, domain.DomainTypeDGII, "example.test"); err != nil {
+		t.Fatalf("expected DGII to have its own bucket, got %v", err)
+	}
+}
+
+func TestRateLimiterSharesGoogleBucket(t *testing.T) {
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeGoogleDorking: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+
+	if err := limiter.Wait(context.Background(), domain.DomainTypeGoogleDorking, "example.test"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, domain.DomainTypeSocialMedia, "example.test"); err == nil {
+		t.Fatal("expected social media searches to share the Google bucket")
+	}
+}
+
+func TestRateLimiterHonorsCancellation(t *testing.T) {
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeSCJ: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+
+	if err := limiter.Wait(context.Background(), domain.DomainTypeSCJ, "example.test"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	err := limiter.Wait(ctx, domain.DomainTypeSCJ, "example.test")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to stop on cancellation, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterSetLimitsUpdatesBucket(t *testing.T) {
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypePGR: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+	limiter.SetLimits(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypePGR: {RequestsPerSecond: 1000, Burst: 3},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, domain.DomainTypePGR, "example.test"); err != nil {
+			t.Fatalf("Wait %d returned error: %v", i, err)
+		}
+	}
+}
+
+func TestRateLimiterWithLimitsKeepsParentLimits(t *testing.T) {
+	parent := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeJCE: {RequestsPerSecond: 0.1, Burst: 1},
+		domain.DomainTypeSIB: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+	limiter := parent.WithLimits(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeJCE: {RequestsPerSecond: 1000, Burst: 3},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, domain.DomainTypeJCE, "example.test"); err != nil {
+			t.Fatalf("Wait %d returned error: %v", i, err)
+		}
+	}
+
+	// The parent keeps its own JCE rate, and shares its SIB bucket
+	if err := parent.Wait(ctx, domain.DomainTypeJCE, "example.test"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := parent.Wait(ctx, domain.DomainTypeJCE, "example.test"); err == nil {
+		t.Error("expected the parent to keep its JCE rate")
+	}
+	if err := limiter.Wait(context.Background(), domain.DomainTypeSIB, "example.test"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := parent.Wait(ctx, domain.DomainTypeSIB, "example.test"); err == nil {
+		t.Error("expected the SIB bucket to be shared with the parent")
+	}
+}
+
+func TestRateLimiterKeepsHostsIndependent(t *testing.T) {
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypePGR: {RequestsPerSecond: 0.1, Burst: 1},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, domain.DomainTypePGR, "pgr.gob.do"); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if err := limiter.Wait(ctx, domain.DomainTypePGR, "cdn.pgr.gob.do"); err != nil {
+		t.Fatalf("expected each host to have its own bucket, got %v", err)
+	}
+}
+
+func TestSearchTakesATokenPerRequest(t *testing.T) {
+	var posts []url.Values
+	dgii := newTestDgii(t, &posts)
+
+	target, err := url.Parse(dgii.BaseParh)
+	if err != nil {
+		t.Fatalf("failed to parse the server URL: %v", err)
+	}
+
+	limiter := NewRateLimiter(map[domain.DomainType]domain.RateLimit{
+		domain.DomainTypeDGII: {RequestsPerSecond: 0.1, Burst: 2},
+	})
+	ctx := withDomainRateLimit(WithRateLimiter(context.Background(), limiter), domain.DomainTypeDGII)
+
+	// The lookup loads the form and posts it
+	if _, err := dgii.SearchByRNC(ctx, "401-50625-4"); err != nil {
+		t.Fatalf("SearchByRNC returned error: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(waitCtx, domain.DomainTypeDGII, target.Hostname()); err == nil {
+		t.Error("expected the form GET and the POST to take a token each")
+	}
+}
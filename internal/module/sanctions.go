@@ -0,0 +1,203 @@
+package module
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/textmatch"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var _ domain.DomainConnector[domain.SanctionMatch] = &Sanctions{}
+
+// DefaultSanctionsThreshold is the name similarity from which a listed name is
+// reported as a match
+const DefaultSanctionsThreshold = 0.88
+
+// ErrSanctionsDisabled is returned when the sanctions lists are searched
+// without a dataset configured
+var ErrSanctionsDisabled = errors.New("sanctions screening is disabled, set SANCTIONS_DATASET to a sanctions list file or URL")
+
+// sanctionsDatasets caches the entries of each dataset, which are loaded on
+// the first search and kept until the process restarts
+var sanctionsDatasets sync.Map
+
+// Sanctions is the connector matching names against sanctions and PEP lists.
+// The lists are read from Dataset, a local file or an http(s) URL holding a
+// JSON array of domain.SanctionEntry.
+type Sanctions struct {
+	Stuff     custom.Client
+	Dataset   string
+	Threshold float64
+}
+
+// NewSanctionsDomain creates a new sanctions connector over the dataset set by
+// SANCTIONS_DATASET, matching at SANCTIONS_MATCH_THRESHOLD
+func NewSanctionsDomain() domain.DomainConnector[domain.SanctionMatch] {
+	threshold, err := strconv.ParseFloat(os.Getenv("SANCTIONS_MATCH_THRESHOLD"), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		threshold = DefaultSanctionsThreshold
+	}
+
+	return &Sanctions{
+		Stuff:     *custom.NewClient(),
+		Dataset:   os.Getenv("SANCTIONS_DATASET"),
+		Threshold: threshold,
+	}
+}
+
+// SanctionsEnabled reports whether a sanctions dataset is configured
+func SanctionsEnabled() bool {
+	return os.Getenv("SANCTIONS_DATASET") != ""
+}
+
+func (*Sanctions) GetDomainType() domain.DomainType {
+	return domain.DomainTypeSanctions
+}
+
+// Search returns the listed entries whose name or an alias matches the query,
+// closest first. Contributor IDs are not names and match nothing.
+func (s *Sanctions) Search(ctx context.Context, query string) ([]domain.SanctionMatch, error) {
+	if s.Dataset == "" {
+		return nil, ErrSanctionsDisabled
+	}
+
+	entries, err := s.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []domain.SanctionMatch{}
+	if isContributorID(query) || textmatch.Normalize(query) == "" {
+		return matches, nil
+	}
+
+	threshold := s.Threshold
+	if threshold <= 0 {
+		threshold = DefaultSanctionsThreshold
+	}
+
+	for _, entry := range entries {
+		match := domain.SanctionMatch{SanctionEntry: entry, Query: query}
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if score := textmatch.NameSimilarity(query, name); score > match.Confidence {
+				match.MatchedName, match.Confidence = name, score
+			}
+		}
+		if match.Confidence < threshold {
+			continue
+		}
+
+		match, err := s.ProcessData(match)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, match)
+	}
+
+	slices.SortStableFunc(matches, func(a, b domain.SanctionMatch) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+
+	return matches, nil
+}
+
+// entries returns the entries of the dataset, loading it on first use
+func (s *Sanctions) entries(ctx context.Context) ([]domain.SanctionEntry, error) {
+	if cached, ok := sanctionsDatasets.Load(s.Dataset); ok {
+		return cached.([]domain.SanctionEntry), nil
+	}
+
+	data, err := s.readDataset(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []domain.SanctionEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sanctions dataset: %w", err)
+	}
+
+	sanctionsDatasets.Store(s.Dataset, entries)
+	return entries, nil
+}
+
+func (s *Sanctions) readDataset(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.Dataset, "http://") && !strings.HasPrefix(s.Dataset, "https://") {
+		data, err := os.ReadFile(s.Dataset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sanctions dataset: %w", err)
+		}
+		return data, nil
+	}
+
+	response, err := s.Stuff.Get(ctx, s.Dataset, nil, map[string]string{
+		"Accept": "application/json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: response.StatusCode}
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return data, nil
+}
+
+func (s *Sanctions) ProcessData(data domain.SanctionMatch) (domain.SanctionMatch, error) {
+	if err := s.ValidateData(data); err != nil {
+		return domain.SanctionMatch{}, err
+	}
+	return s.TransformData(data), nil
+}
+
+func (s *Sanctions) ValidateData(data domain.SanctionMatch) error {
+	if strings.TrimSpace(data.Name) == "" {
+		return fmt.Errorf("Name is required")
+	}
+	if strings.TrimSpace(data.List) == "" {
+		return fmt.Errorf("List is required")
+	}
+	return nil
+}
+
+func (s *Sanctions) TransformData(data domain.SanctionMatch) domain.SanctionMatch {
+	transformed := data
+	transformed.Name = strings.TrimSpace(data.Name)
+	transformed.List = strings.TrimSpace(data.List)
+	transformed.MatchedName = strings.TrimSpace(data.MatchedName)
+	return transformed
+}
+
+// GetDataByCategory returns no keywords: a listed name is a finding, not a
+// lead to expand the search with
+func (s *Sanctions) GetDataByCategory(data domain.SanctionMatch, category domain.KeywordCategory) []string {
+	return []string{}
+}
+
+func (s *Sanctions) GetSearchableKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryPersonName,
+		domain.KeywordCategoryCompanyName,
+	}
+}
+
+func (s *Sanctions) GetFoundKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{}
+}
@@ -0,0 +1,115 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sanctionsFixture = `[
+	{"name": "PÉREZ GÓMEZ, José Antonio", "type": "person", "list": "OFAC SDN", "program": "SDNTK", "country": "DO"},
+	{"name": "Inversiones Caribe Azul S.R.L.", "aliases": ["Caribe Azul Holdings"], "type": "company", "list": "UN", "country": "DO"},
+	{"name": "María Fernández", "type": "person", "list": "PEP"}
+]`
+
+func newTestSanctions(t *testing.T, threshold float64) *Sanctions {
+	t.Helper()
+
+	dataset := filepath.Join(t.TempDir(), "sanctions.json")
+	if err := os.WriteFile(dataset, []byte(sanctionsFixture), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	return &Sanctions{Stuff: *custom.NewClient(), Dataset: dataset, Threshold: threshold}
+}
+
+func TestSanctionsFuzzyMatches(t *testing.T) {
+	sanctions := newTestSanctions(t, DefaultSanctionsThreshold)
+
+	tests := []struct {
+		query string
+		list  string
+		name  string
+	}{
+		// Accents, casing, punctuation and word order differ
+		{query: "Jose Antonio Perez Gomez", list: "OFAC SDN", name: "PÉREZ GÓMEZ, José Antonio"},
+		// A typo still matches
+		{query: "Jose Antonio Peres Gomez", list: "OFAC SDN", name: "PÉREZ GÓMEZ, José Antonio"},
+		// Aliases match too
+		{query: "CARIBE AZUL HOLDINGS", list: "UN", name: "Caribe Azul Holdings"},
+	}
+
+	for _, tt := range tests {
+		matches, err := sanctions.Search(context.Background(), tt.query)
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", tt.query, err)
+		}
+		if len(matches) != 1 {
+			t.Fatalf("Search(%q): expected 1 match, got %+v", tt.query, matches)
+		}
+		if matches[0].List != tt.list || matches[0].MatchedName != tt.name {
+			t.Errorf("Search(%q): unexpected match %+v", tt.query, matches[0])
+		}
+		if matches[0].Confidence < DefaultSanctionsThreshold || matches[0].Confidence > 1 {
+			t.Errorf("Search(%q): unexpected confidence %v", tt.query, matches[0].Confidence)
+		}
+	}
+}
+
+func TestSanctionsNoMatchBelowThreshold(t *testing.T) {
+	sanctions := newTestSanctions(t, DefaultSanctionsThreshold)
+
+	for _, query := range []string{"Jose Antonio Martinez", "Maria Fernandez Rodriguez", "Inversiones Caribe", "130000001"} {
+		matches, err := sanctions.Search(context.Background(), query)
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", query, err)
+		}
+		if len(matches) != 0 {
+			t.Errorf("Search(%q): expected no match, got %+v", query, matches)
+		}
+	}
+}
+
+func TestSanctionsRemoteDataset(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(sanctionsFixture))
+	}))
+	defer srv.Close()
+
+	sanctions := &Sanctions{Stuff: *custom.NewClient(), Dataset: srv.URL + "/sanctions.json"}
+
+	for i := 0; i < 2; i++ {
+		matches, err := sanctions.Search(context.Background(), "Maria Fernandez")
+		if err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+		if len(matches) != 1 || matches[0].List != "PEP" {
+			t.Fatalf("expected a PEP match, got %+v", matches)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected the dataset to be downloaded once, got %d requests", requests)
+	}
+}
+
+func TestSanctionsDisabledWithoutDataset(t *testing.T) {
+	sanctions := &Sanctions{}
+
+	if _, err := sanctions.Search(context.Background(), "Maria Fernandez"); !errors.Is(err, ErrSanctionsDisabled) {
+		t.Fatalf("expected ErrSanctionsDisabled, got %v", err)
+	}
+
+	if (&Sanctions{}).GetDomainType() != domain.DomainTypeSanctions {
+		t.Error("unexpected domain type")
+	}
+}
@@ -3,13 +3,18 @@ package module
 // Sumprema corte de Justica
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
 	"io"
+	"log"
+	"log/slog"
 	"net/url"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -49,38 +54,128 @@ type ScjCaseResponse struct {
 }
 
 type Scj struct {
-	Stuff    custom.Client
-	BaseParh string
-	PathMap  custom.CustomPathMap
-}
-
-var REMOVE_WORDS = map[string]bool{
-	"S. A.":    true,
-	"S.R.L.":   true,
-	"S. R. L.": true,
-	"N. V.":    true,
-	"N.V.":     true,
-	"S.A.":     true,
+	Stuff      custom.Client
+	BaseParh   string
+	PathMap    custom.CustomPathMap
+	References *domain.ScjReferenceTable
 }
 
 func NewScjDomain() domain.DomainConnector[domain.ScjCase] {
 	return &Scj{
-		BaseParh: "https://consultasentenciascj.poderjudicial.gob.do/Home/GetExpedientes",
-		Stuff:    *custom.NewClient(),
+		BaseParh:   "https://consultasentenciascj.poderjudicial.gob.do/Home/GetExpedientes",
+		Stuff:      *custom.NewClient(),
+		References: loadScjReferences(),
+	}
+}
+
+// loadScjReferences reads the tribunal/materia table from SCJ_REFERENCES_FILE,
+// falling back to the embedded table when it is unset or invalid
+func loadScjReferences() *domain.ScjReferenceTable {
+	path := os.Getenv("SCJ_REFERENCES_FILE")
+	if path == "" {
+		return domain.DefaultScjReferenceTable()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Warning: could not read SCJ references file %s, using defaults: %v", path, err)
+		return domain.DefaultScjReferenceTable()
+	}
+
+	table, err := domain.LoadScjReferenceTable(data)
+	if err != nil {
+		log.Printf("Warning: could not load SCJ references file %s, using defaults: %v", path, err)
+		return domain.DefaultScjReferenceTable()
+	}
+
+	return table
+}
+
+// references returns the configured reference table or the embedded one
+func (p *Scj) references() *domain.ScjReferenceTable {
+	if p.References == nil {
+		return domain.DefaultScjReferenceTable()
+	}
+	return p.References
+}
+
+// scjPageLength is the number of cases requested per DataTables page, and
+// scjMaxPages caps the pages fetched for a single query
+const (
+	scjPageLength = 10
+	scjMaxPages   = 20
+)
+
+// Search retrieves every case matching the query, up to scjMaxPages pages
+func (p *Scj) Search(ctx context.Context, query string) ([]domain.ScjCase, error) {
+	return p.SearchWithLimit(ctx, query, 0)
+}
+
+// SearchWithLimit retrieves up to limit cases matching the query, paging
+// through the results with the DataTables start/length parameters. A limit of
+// zero or less retrieves every case up to scjMaxPages pages.
+func (p *Scj) SearchWithLimit(ctx context.Context, query string, limit int) ([]domain.ScjCase, error) {
+	var cases []domain.ScjCase
+	seen := make(map[int]bool)
+
+	// Cases ValidateData rejects, such as the ones without an id, are dropped
+	// rather than left to fail when stored
+	dropped := 0
+	defer func() {
+		if dropped > 0 {
+			slog.Warn("dropped invalid SCJ cases", "query", query, "dropped", dropped)
+		}
+	}()
+
+	for page := 0; page < scjMaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result, err := p.searchPage(ctx, query, page*scjPageLength, scjPageLength)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, item := range result.Data {
+			// Pages may overlap when new cases are indexed while paging
+			if seen[item.IDExpediente] {
+				continue
+			}
+			seen[item.IDExpediente] = true
+			added++
+
+			c, err := p.ProcessData(p.ToDomain(item))
+			if err != nil {
+				dropped++
+				continue
+			}
+			cases = append(cases, c)
+		}
+
+		if limit > 0 && len(cases) >= limit {
+			return cases[:limit], nil
+		}
+		if added == 0 || len(result.Data) < scjPageLength || (page+1)*scjPageLength >= result.RecordsFiltered {
+			break
+		}
 	}
+
+	return cases, nil
 }
 
-func (p *Scj) Search(query string) ([]domain.ScjCase, error) {
+// searchPage requests one DataTables page of the cases matching the query
+func (p *Scj) searchPage(ctx context.Context, query string, start, length int) (*ScjSearchResponse, error) {
 	form := url.Values{}
 	form.Add("search[value]", query)
 	form.Add("Contenido", query)
 	form.Add("search[regex]", "false")
-	form.Add("start", "0")
-	form.Add("length", "10")
+	form.Add("start", strconv.Itoa(start))
+	form.Add("length", strconv.Itoa(length))
 
-	resp, err := p.Stuff.Post(p.BaseParh, form.Encode(), map[string]string{
+	resp, err := p.Stuff.Post(ctx, p.BaseParh, form.Encode(), map[string]string{
 		"Content-Type": "application/x-www-form-urlencoded",
-		"User-Agent":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36",
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
@@ -97,15 +192,11 @@ func (p *Scj) Search(query string) ([]domain.ScjCase, error) {
 		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
 	}
 
-	var cases []domain.ScjCase
-	for _, item := range result.Data {
-		cases = append(cases, p.ToDomain(item))
-	}
-	return cases, nil
+	return &result, nil
 }
 
 func (p *Scj) ToDomain(response ScjCaseResponse) domain.ScjCase {
-	return domain.ScjCase{
+	return p.references().Normalize(domain.ScjCase{
 		Linea:                response.Linea,
 		AgnoCabecera:         response.AgnoCabecera,
 		MesCabecera:          response.MesCabecera,
@@ -120,7 +211,8 @@ func (p *Scj) ToDomain(response ScjCaseResponse) domain.ScjCase {
 		DescTribunal:         response.DescTribunal,
 		IDMateria:            response.IDMateria,
 		DescMateria:          response.DescMateria,
-		FechaFallo:           response.FechaFallo,
+		FechaFallo:           domain.ParseScjDate(response.FechaFallo),
+		FechaFalloRaw:        response.FechaFallo,
 		Involucrados:         response.Involucrados,
 		GuidBlob:             response.GuidBlob,
 		TipoDocumentoAdjunto: response.TipoDocumentoAdjunto,
@@ -129,7 +221,7 @@ func (p *Scj) ToDomain(response ScjCaseResponse) domain.ScjCase {
 		Extension:            response.Extension,
 		Origen:               response.Origen,
 		Activo:               response.Activo,
-	}
+	})
 }
 
 func (dgi *Scj) GetDomainType() domain.DomainType {
@@ -160,8 +252,10 @@ func (p *Scj) TransformData(data domain.ScjCase) domain.ScjCase {
 	transformed.Involucrados = strings.TrimSpace(data.Involucrados)
 	transformed.URLBlob = strings.TrimSpace(data.URLBlob)
 	transformed.DescMateria = strings.TrimSpace(data.DescMateria)
+	transformed.DescTribunal = strings.TrimSpace(data.DescTribunal)
+	transformed.FechaFallo = domain.ParseScjDate(data.FechaFalloRaw)
 
-	return transformed
+	return p.references().Normalize(transformed)
 }
 
 func (p *Scj) GetDataByCategory(data domain.ScjCase, category domain.KeywordCategory) []string {
@@ -177,7 +271,8 @@ func (p *Scj) GetDataByCategory(data domain.ScjCase, category domain.KeywordCate
 		names := re.Split(involucrados, -1)
 		for _, name := range names {
 			trimmed := strings.TrimSpace(name)
-			if _, ok := REMOVE_WORDS[trimmed]; ok || trimmed == "" {
+			// The legal form of a company is split off its name by the comma
+			if trimmed == "" || domain.IsCompanyLegalForm(trimmed) {
 				continue
 			}
 
@@ -0,0 +1,133 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"insightful-intel/internal/custom"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newTestScj serves recordsFiltered cases in pages that repeat the last case of
+// the previous page, recording the start of each request
+func newTestScj(t *testing.T, recordsFiltered int, starts *[]int) *Scj {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		start, _ := strconv.Atoi(r.PostForm.Get("start"))
+		length, _ := strconv.Atoi(r.PostForm.Get("length"))
+		*starts = append(*starts, start)
+
+		first := start + 1
+		if start > 0 {
+			first = start
+		}
+		response := ScjSearchResponse{RecordsFiltered: recordsFiltered, RecordsTotal: recordsFiltered}
+		for id := first; id <= recordsFiltered && len(response.Data) < length; id++ {
+			response.Data = append(response.Data, ScjCaseResponse{IDExpediente: id, Involucrados: "Novasco SRL"})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Scj{
+		BaseParh: srv.URL,
+		Stuff:    *custom.NewClient(),
+	}
+}
+
+func TestScjSearchPagesThroughResults(t *testing.T) {
+	var starts []int
+	scj := newTestScj(t, 13, &starts)
+
+	cases, err := scj.Search(context.Background(), "Novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(starts) != 2 || starts[0] != 0 || starts[1] != scjPageLength {
+		t.Errorf("expected two pages starting at 0 and %d, got %v", scjPageLength, starts)
+	}
+	if len(cases) != 13 {
+		t.Fatalf("expected 13 distinct cases, got %d", len(cases))
+	}
+
+	seen := make(map[int]bool)
+	for _, c := range cases {
+		if seen[c.IDExpediente] {
+			t.Errorf("case %d returned twice", c.IDExpediente)
+		}
+		seen[c.IDExpediente] = true
+	}
+}
+
+func TestScjSearchWithLimit(t *testing.T) {
+	var starts []int
+	scj := newTestScj(t, 13, &starts)
+
+	cases, err := scj.SearchWithLimit(context.Background(), "Novasco", 5)
+	if err != nil {
+		t.Fatalf("SearchWithLimit returned error: %v", err)
+	}
+
+	if len(cases) != 5 {
+		t.Errorf("expected 5 cases, got %d", len(cases))
+	}
+	if len(starts) != 1 {
+		t.Errorf("expected a single page, got %v", starts)
+	}
+}
+
+func TestScjSearchStopsAtPageCap(t *testing.T) {
+	var starts []int
+	scj := newTestScj(t, 10000, &starts)
+
+	cases, err := scj.Search(context.Background(), "Novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(starts) != scjMaxPages {
+		t.Errorf("expected %d pages, got %d", scjMaxPages, len(starts))
+	}
+	if len(cases) == 0 || len(cases) > scjMaxPages*scjPageLength {
+		t.Errorf("unexpected number of cases: %d", len(cases))
+	}
+}
+
+func TestScjSearchDropsInvalidCases(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := ScjSearchResponse{
+			RecordsFiltered: 3,
+			RecordsTotal:    3,
+			Data: []ScjCaseResponse{
+				{IDExpediente: 1, Involucrados: "  Novasco SRL  "},
+				{IDExpediente: 0, Involucrados: "Sin expediente"},
+				{IDExpediente: 2, Involucrados: "Novasco Real Estate"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer srv.Close()
+
+	scj := &Scj{BaseParh: srv.URL, Stuff: *custom.NewClient()}
+	cases, err := scj.Search(context.Background(), "Novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(cases) != 2 || cases[0].IDExpediente != 1 || cases[1].IDExpediente != 2 {
+		t.Fatalf("expected only the valid cases 1 and 2, got %+v", cases)
+	}
+	if cases[0].Involucrados != "Novasco SRL" {
+		t.Errorf("expected the cases to be transformed, got %q", cases[0].Involucrados)
+	}
+}
@@ -0,0 +1,116 @@
+package module
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"reflect"
+	"sync"
+)
+
+// DefaultScreeningConcurrency is the number of searches a screening runs at once
+// when none is given
+const DefaultScreeningConcurrency = 8
+
+// SearchFunc searches a domain for a query
+type SearchFunc func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error)
+
+// Screen searches every query in every domain, without expanding keywords, and
+// reports which domains hold records matching each query. At most concurrency
+// searches run at once; search defaults to SearchDomain when nil.
+func Screen(ctx context.Context, queries []string, domainTypes []domain.DomainType, concurrency int, search SearchFunc) []domain.ScreeningResult {
+	if search == nil {
+		search = SearchDomain
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultScreeningConcurrency
+	}
+
+	results := make([]domain.ScreeningResult, len(queries))
+	for i, query := range queries {
+		results[i] = domain.ScreeningResult{
+			Query:   query,
+			Domains: make([]domain.ScreeningDomainResult, len(domainTypes)),
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+dispatch:
+	for i, query := range queries {
+		for j, domainType := range domainTypes {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case sem <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func(i, j int, query string, domainType domain.DomainType) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				// Each goroutine writes its own slot, so no lock is needed
+				results[i].Domains[j] = screenDomain(ctx, search, query, domainType)
+			}(i, j, query, domainType)
+		}
+	}
+	wg.Wait()
+
+	for i := range results {
+		for j, domainResult := range results[i].Domains {
+			// Domains never dispatched because the context ended
+			if domainResult.DomainType == "" {
+				results[i].Domains[j] = domain.ScreeningDomainResult{DomainType: domainTypes[j], Error: context.Cause(ctx).Error()}
+				continue
+			}
+			results[i].Hit = results[i].Hit || domainResult.Hit
+		}
+	}
+
+	return results
+}
+
+func screenDomain(ctx context.Context, search SearchFunc, query string, domainType domain.DomainType) domain.ScreeningDomainResult {
+	screened := domain.ScreeningDomainResult{DomainType: domainType}
+
+	result, err := search(ctx, domainType, domain.DomainSearchParams{Query: query})
+	if err != nil {
+		screened.Error = err.Error()
+		return screened
+	}
+	if result == nil || !result.Success {
+		return screened
+	}
+
+	screened.Records = countRecords(result.Output)
+	screened.Hit = hasMatchingKeyword(result, query)
+	return screened
+}
+
+// hasMatchingKeyword reports whether any keyword found by the search matches the
+// query, ignoring case and spacing
+func hasMatchingKeyword(result *domain.DomainSearchResult, query string) bool {
+	want := normalizeHitValue(query)
+	if want == "" {
+		return false
+	}
+
+	for _, values := range result.KeywordsPerCategory {
+		for _, value := range values {
+			if normalizeHitValue(value) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// countRecords returns the number of records in a search output
+func countRecords(output any) int {
+	value := reflect.ValueOf(output)
+	if value.Kind() != reflect.Slice {
+		return 0
+	}
+	return value.Len()
+}
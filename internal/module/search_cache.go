@@ -0,0 +1,196 @@
+package module
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSearchCacheTTL is how long a search result is reused when no TTL is given
+const DefaultSearchCacheTTL = 10 * time.Minute
+
+// SearchCache reuses the successful results of domain searches, keyed by domain
+// and query, so a keyword fanned out many times reaches each source once
+type SearchCache struct {
+	entries  sync.Map
+	ttl      time.Duration
+	disabled bool
+	now      func() time.Time
+	metrics  *infra.Metrics
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	// nextSweep is when, in Unix nanoseconds, the expired entries are next
+	// dropped
+	nextSweep atomic.Int64
+}
+
+// SearchCacheOption configures a SearchCache
+type SearchCacheOption func(*SearchCache)
+
+// SearchCacheStats holds the counters of a SearchCache
+type SearchCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+type searchCacheKey struct {
+	domainType domain.DomainType
+	query      string
+}
+
+type searchCacheEntry struct {
+	result  domain.DomainSearchResult
+	expires time.Time
+}
+
+// WithSearchCacheTTL sets how long results are reused
+func WithSearchCacheTTL(ttl time.Duration) SearchCacheOption {
+	return func(c *SearchCache) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// WithSearchCacheMetrics records the hits and misses of the cache in metrics
+func WithSearchCacheMetrics(metrics *infra.Metrics) SearchCacheOption {
+	return func(c *SearchCache) {
+		c.metrics = metrics
+	}
+}
+
+// WithoutSearchCache disables the cache, so every search reaches its source
+func WithoutSearchCache() SearchCacheOption {
+	return func(c *SearchCache) {
+		c.disabled = true
+	}
+}
+
+// NewSearchCache creates a search cache, reusing results for DefaultSearchCacheTTL
+// unless configured otherwise
+func NewSearchCache(options ...SearchCacheOption) *SearchCache {
+	cache := &SearchCache{
+		ttl: DefaultSearchCacheTTL,
+		now: time.Now,
+	}
+	for _, option := range options {
+		option(cache)
+	}
+	return cache
+}
+
+// DefaultSearchCache is the cache consulted by SearchDomain. SEARCH_CACHE_TTL
+// sets its TTL as a duration, and a zero duration disables it.
+var DefaultSearchCache = newDefaultSearchCache()
+
+func newDefaultSearchCache() *SearchCache {
+	withMetrics := WithSearchCacheMetrics(infra.DefaultMetrics)
+
+	value := os.Getenv("SEARCH_CACHE_TTL")
+	if value == "" {
+		return NewSearchCache(withMetrics)
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return NewSearchCache(withMetrics)
+	}
+	if ttl <= 0 {
+		return NewSearchCache(WithoutSearchCache())
+	}
+	return NewSearchCache(WithSearchCacheTTL(ttl), withMetrics)
+}
+
+// Search returns the cached result of the domain search when there is one, and
+// runs search otherwise, caching its result when it succeeds
+func (c *SearchCache) Search(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams, search SearchFunc) (*domain.DomainSearchResult, error) {
+	if c == nil || c.disabled {
+		return search(ctx, domainType, params)
+	}
+
+	key := searchCacheKey{domainType: domainType, query: params.Query}
+	if value, ok := c.entries.Load(key); ok {
+		entry := value.(*searchCacheEntry)
+		if c.now().Before(entry.expires) {
+			c.hits.Add(1)
+			c.metrics.RecordCacheLookup(true)
+			// Callers set IDs on the result, so each gets its own copy
+			result := entry.result
+			return &result, nil
+		}
+		c.entries.CompareAndDelete(key, value)
+	}
+
+	c.misses.Add(1)
+	c.metrics.RecordCacheLookup(false)
+	result, err := search(ctx, domainType, params)
+	if err == nil && result != nil && result.Success {
+		now := c.now()
+		c.sweep(now)
+		c.entries.Store(key, &searchCacheEntry{result: *result, expires: now.Add(c.ttl)})
+	}
+
+	return result, err
+}
+
+// sweep drops the expired entries at most once per TTL, so the results of
+// queries that are never searched again do not stay in memory
+func (c *SearchCache) sweep(now time.Time) {
+	next := c.nextSweep.Load()
+	if now.UnixNano() < next || !c.nextSweep.CompareAndSwap(next, now.Add(c.ttl).UnixNano()) {
+		return
+	}
+
+	c.entries.Range(func(key, value any) bool {
+		if !now.Before(value.(*searchCacheEntry).expires) {
+			c.entries.CompareAndDelete(key, value)
+		}
+		return true
+	})
+}
+
+// Peek returns the cached result of a domain search, if there is one, without
+// searching or counting a hit or miss
+func (c *SearchCache) Peek(domainType domain.DomainType, query string) (*domain.DomainSearchResult, bool) {
+	if c == nil || c.disabled {
+		return nil, false
+	}
+
+	value, ok := c.entries.Load(searchCacheKey{domainType: domainType, query: query})
+	if !ok {
+		return nil, false
+	}
+	entry := value.(*searchCacheEntry)
+	if !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	result := entry.result
+	return &result, true
+}
+
+// Stats returns the hit and miss counters of the cache
+func (c *SearchCache) Stats() SearchCacheStats {
+	return SearchCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Forget drops the cached result of a domain search, so the next search for it
+// reaches the source
+func (c *SearchCache) Forget(domainType domain.DomainType, query string) {
+	if c == nil {
+		return
+	}
+	c.entries.Delete(searchCacheKey{domainType: domainType, query: query})
+}
+
+// Clear drops every cached result
+func (c *SearchCache) Clear() {
+	c.entries.Clear()
+}
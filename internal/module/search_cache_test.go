@@ -0,0 +1,147 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// camaraSearch searches the test mercantile registry as SearchDomain would
+func camaraSearch(camara *Camara) SearchFunc {
+	return func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		records, err := camara.Search(ctx, params.Query)
+		if err != nil {
+			return &domain.DomainSearchResult{DomainType: domainType, Error: err}, err
+		}
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: records}, nil
+	}
+}
+
+func TestSearchCacheReusesSuccessfulResults(t *testing.T) {
+	var queries []url.Values
+	search := camaraSearch(newTestCamara(t, &queries))
+	cache := NewSearchCache()
+
+	params := domain.DomainSearchParams{Query: "Novasco"}
+	first, err := cache.Search(context.Background(), domain.DomainTypeCamara, params, search)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	second, err := cache.Search(context.Background(), domain.DomainTypeCamara, params, search)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(queries) != 1 {
+		t.Fatalf("expected the second search to be served from the cache, got %d requests", len(queries))
+	}
+	if first == second {
+		t.Error("expected each caller to get its own copy of the result")
+	}
+	if records, ok := second.Output.([]domain.CamaraRecord); !ok || len(records) != 1 {
+		t.Errorf("unexpected cached output: %+v", second.Output)
+	}
+
+	// Another domain or query is a different entry
+	if _, err := cache.Search(context.Background(), domain.DomainTypeCamara, domain.DomainSearchParams{Query: "Acme"}, search); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(queries) != 2 {
+		t.Errorf("expected a new request for another query, got %d requests", len(queries))
+	}
+
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSearchCacheSkipsFailuresAndExpiredResults(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewSearchCache(WithSearchCacheTTL(time.Minute))
+	cache.now = func() time.Time { return now }
+
+	calls := 0
+	fail := true
+	search := func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		calls++
+		if fail {
+			return &domain.DomainSearchResult{DomainType: domainType}, errors.New("timeout")
+		}
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType}, nil
+	}
+
+	params := domain.DomainSearchParams{Query: "Novasco"}
+	cache.Search(context.Background(), domain.DomainTypeDGII, params, search)
+	fail = false
+	cache.Search(context.Background(), domain.DomainTypeDGII, params, search)
+	if calls != 2 {
+		t.Fatalf("expected a failed search not to be cached, got %d calls", calls)
+	}
+
+	cache.Search(context.Background(), domain.DomainTypeDGII, params, search)
+	if calls != 2 {
+		t.Fatalf("expected a cached result within the TTL, got %d calls", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	cache.Search(context.Background(), domain.DomainTypeDGII, params, search)
+	if calls != 3 {
+		t.Errorf("expected the result to expire after the TTL, got %d calls", calls)
+	}
+}
+
+func TestSearchCacheDisabled(t *testing.T) {
+	var queries []url.Values
+	search := camaraSearch(newTestCamara(t, &queries))
+	cache := NewSearchCache(WithoutSearchCache())
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Search(context.Background(), domain.DomainTypeCamara, domain.DomainSearchParams{Query: "Novasco"}, search); err != nil {
+			t.Fatalf("Search returned error: %v", err)
+		}
+	}
+	if len(queries) != 2 {
+		t.Errorf("expected every search to reach the source, got %d requests", len(queries))
+	}
+}
+
+func TestSearchCacheSweepsExpiredResults(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	metrics := infra.NewMetrics()
+	cache := NewSearchCache(WithSearchCacheTTL(time.Minute), WithSearchCacheMetrics(metrics))
+	cache.now = func() time.Time { return now }
+
+	search := func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType}, nil
+	}
+	cache.Search(context.Background(), domain.DomainTypeDGII, domain.DomainSearchParams{Query: "Novasco"}, search)
+	cache.Search(context.Background(), domain.DomainTypeDGII, domain.DomainSearchParams{Query: "Novasco"}, search)
+
+	// Storing another result past the TTL drops the expired one
+	now = now.Add(2 * time.Minute)
+	cache.Search(context.Background(), domain.DomainTypeDGII, domain.DomainSearchParams{Query: "Acme"}, search)
+
+	var queries []string
+	cache.entries.Range(func(key, value any) bool {
+		queries = append(queries, key.(searchCacheKey).query)
+		return true
+	})
+	if len(queries) != 1 || queries[0] != "Acme" {
+		t.Errorf("expected only the fresh result to be kept, got %v", queries)
+	}
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus returned error: %v", err)
+	}
+	for _, line := range []string{"insightful_search_cache_hits_total 1", "insightful_search_cache_misses_total 2"} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("expected %q in metrics:\n%s", line, out.String())
+		}
+	}
+}
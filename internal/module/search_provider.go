@@ -0,0 +1,239 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gocolly/colly"
+)
+
+// maxDorkingPages bounds the result pages a docking search fetches
+const maxDorkingPages = 5
+
+// googlePageSize is the number of results Google Custom Search answers per page
+const googlePageSize = 10
+
+// duckDuckGoPageSize is the number of results the DuckDuckGo HTML endpoint
+// answers per page
+const duckDuckGoPageSize = 30
+
+// SearchProvider fetches the results of a dork query from a search engine.
+// Pages start at 1.
+type SearchProvider interface {
+	Fetch(ctx context.Context, query string, page int) ([]domain.GoogleDorkingResult, error)
+}
+
+var (
+	_ SearchProvider = &GoogleSearchProvider{}
+	_ SearchProvider = &DuckDuckGoSearchProvider{}
+	_ SearchProvider = &FallbackSearchProvider{}
+)
+
+// NewSearchProvider creates the search provider of the given name, one of the
+// DorkingProvider names
+func NewSearchProvider(name string) (SearchProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case domain.DorkingProviderGoogle:
+		return NewGoogleSearchProvider()
+	case domain.DorkingProviderDuckDuckGo:
+		return NewDuckDuckGoSearchProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown search provider %q, expected %q or %q", name, domain.DorkingProviderGoogle, domain.DorkingProviderDuckDuckGo)
+}
+
+// GoogleSearchProvider searches Google Custom Search at BasePath, the search
+// URL with its key and engine ID
+type GoogleSearchProvider struct {
+	Stuff    custom.Client
+	BasePath string
+}
+
+// NewGoogleSearchProvider creates a Google Custom Search provider from
+// GOOGLE_API_KEY and GOOGLE_CX_KEY, failing when either is not set
+func NewGoogleSearchProvider() (*GoogleSearchProvider, error) {
+	googleApiKey := os.Getenv("GOOGLE_API_KEY")
+	googleSearchEngineId := os.Getenv("GOOGLE_CX_KEY")
+	if googleApiKey == "" || googleSearchEngineId == "" {
+		return nil, fmt.Errorf("GOOGLE_API_KEY and GOOGLE_CX_KEY are not set")
+	}
+
+	return &GoogleSearchProvider{
+		Stuff:    *custom.NewClient(),
+		BasePath: fmt.Sprintf("https://www.googleapis.com/customsearch/v1?key=%s&cx=%s", googleApiKey, googleSearchEngineId),
+	}, nil
+}
+
+// googleErrorResponse is the error body of Google Custom Search
+type googleErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Errors  []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+// googleQuotaReasons are the error reasons Google answers an exhausted quota
+// with
+var googleQuotaReasons = []string{"rateLimitExceeded", "dailyLimitExceeded", "userRateLimitExceeded", "quotaExceeded"}
+
+// isQuotaError reports whether a Google Custom Search error response means
+// the quota of the key is exhausted
+func isQuotaError(status int, body []byte) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+
+	var response googleErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return false
+	}
+	if response.Error.Status == "RESOURCE_EXHAUSTED" {
+		return true
+	}
+	for _, e := range response.Error.Errors {
+		if slices.Contains(googleQuotaReasons, e.Reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch runs the query against Google Custom Search
+func (p *GoogleSearchProvider) Fetch(ctx context.Context, q string, page int) ([]domain.GoogleDorkingResult, error) {
+	searchURL := fmt.Sprintf("%s&q=%s", p.BasePath, url.QueryEscape(q))
+	if page > 1 {
+		searchURL += "&start=" + strconv.Itoa((page-1)*googlePageSize+1)
+	}
+
+	resp, err := p.Stuff.Get(ctx, searchURL, map[string]string{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if isQuotaError(resp.StatusCode, body) {
+			return nil, fmt.Errorf("%w: status code %d", ErrGoogleQuotaExceeded, resp.StatusCode)
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var result GoogleDorkingSearchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+
+	for i := range result.Items {
+		result.Items[i].Provider = domain.DorkingProviderGoogle
+	}
+	return result.Items, nil
+}
+
+// DuckDuckGoSearchProvider scrapes the DuckDuckGo HTML endpoint at BasePath
+type DuckDuckGoSearchProvider struct {
+	BasePath string
+}
+
+// NewDuckDuckGoSearchProvider creates a DuckDuckGo provider scraping
+// DuckDuckGoHTMLURL
+func NewDuckDuckGoSearchProvider() *DuckDuckGoSearchProvider {
+	return &DuckDuckGoSearchProvider{BasePath: domain.DuckDuckGoHTMLURL}
+}
+
+// Fetch scrapes the results of the query from the DuckDuckGo HTML endpoint,
+// skipping the ads
+func (p *DuckDuckGoSearchProvider) Fetch(ctx context.Context, q string, page int) ([]domain.GoogleDorkingResult, error) {
+	c := colly.NewCollector()
+	c.WithTransport(custom.NewContextTransport(ctx))
+
+	results := []domain.GoogleDorkingResult{}
+	c.OnHTML("div.result", func(e *colly.HTMLElement) {
+		if strings.Contains(e.Attr("class"), "result--ad") {
+			return
+		}
+
+		link := duckDuckGoTarget(e.ChildAttr("a.result__a", "href"))
+		if link == "" {
+			return
+		}
+
+		results = append(results, domain.GoogleDorkingResult{
+			URL:         link,
+			Title:       strings.TrimSpace(e.ChildText("a.result__a")),
+			Description: strings.TrimSpace(e.ChildText(".result__snippet")),
+			Provider:    domain.DorkingProviderDuckDuckGo,
+		})
+	})
+
+	searchURL := fmt.Sprintf("%s?q=%s", p.BasePath, url.QueryEscape(strings.TrimSpace(q)))
+	if page > 1 {
+		searchURL += "&s=" + strconv.Itoa((page-1)*duckDuckGoPageSize)
+	}
+
+	if err := c.Visit(searchURL); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to search DuckDuckGo: %w", err)
+	}
+
+	return results, nil
+}
+
+// duckDuckGoTarget returns the URL a DuckDuckGo result links to, unwrapping the
+// redirect through duckduckgo.com/l/ its anchors usually go through
+func duckDuckGoTarget(href string) string {
+	if href == "" {
+		return ""
+	}
+	if strings.HasPrefix(href, "//") {
+		href = "https:" + href
+	}
+
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if target := parsed.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return href
+}
+
+// FallbackSearchProvider fetches from Primary and falls back to Fallback when
+// the quota of Primary is exhausted
+type FallbackSearchProvider struct {
+	Primary  SearchProvider
+	Fallback SearchProvider
+}
+
+// Fetch fetches the page from Primary, or from Fallback when Primary answers
+// ErrGoogleQuotaExceeded
+func (p *FallbackSearchProvider) Fetch(ctx context.Context, q string, page int) ([]domain.GoogleDorkingResult, error) {
+	results, err := p.Primary.Fetch(ctx, q, page)
+	if errors.Is(err, ErrGoogleQuotaExceeded) && p.Fallback != nil {
+		slog.Warn("Google Custom Search quota exceeded, falling back to DuckDuckGo", "query", q, "page", page)
+		return p.Fallback.Fetch(ctx, q, page)
+	}
+	return results, err
+}
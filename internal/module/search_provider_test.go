@@ -0,0 +1,214 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeSearchProvider answers canned pages and records what it was asked for
+type fakeSearchProvider struct {
+	pages   map[int][]domain.GoogleDorkingResult
+	err     error
+	queries []string
+	fetched []int
+}
+
+func (p *fakeSearchProvider) Fetch(ctx context.Context, query string, page int) ([]domain.GoogleDorkingResult, error) {
+	p.queries = append(p.queries, query)
+	p.fetched = append(p.fetched, page)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.pages[page], nil
+}
+
+func TestSearchWithParamsFetchesOnePageByDefault(t *testing.T) {
+	provider := &fakeSearchProvider{pages: map[int][]domain.GoogleDorkingResult{
+		1: {{URL: "https://example.com/exact", Title: "Novasco"}},
+		2: {{URL: "https://example.com/page-2", Title: "Novasco fraude"}},
+	}}
+	gd := &GoogleDorking{Provider: provider}
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{
+		Query:         "novasco",
+		MaxResults:    10,
+		SitesKeywords: []string{"facebook.com", "instagram.com"},
+	})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+
+	if len(provider.fetched) != 1 || provider.fetched[0] != 1 {
+		t.Errorf("expected only the first page to be fetched, got %v", provider.fetched)
+	}
+	// The dork query is built the same whatever the provider
+	if want := "novasco  site:facebook.com OR site:instagram.com"; provider.queries[0] != want {
+		t.Errorf("expected query %q, got %q", want, provider.queries[0])
+	}
+	if len(results) != 1 || results[0].URL != "https://example.com/exact" {
+		t.Errorf("expected the result of the first page, got %+v", results)
+	}
+}
+
+func TestSearchWithParamsFetchesPagesUntilNothingNew(t *testing.T) {
+	provider := &fakeSearchProvider{pages: map[int][]domain.GoogleDorkingResult{
+		1: {{URL: "https://example.com/exact", Title: "Novasco"}},
+		2: {
+			{URL: "https://example.com/exact", Title: "Novasco"},
+			{URL: "https://example.com/fraude", Title: "Novasco fraude inmobiliaria"},
+		},
+		3: {{URL: "https://example.com/fraude", Title: "Novasco fraude inmobiliaria"}},
+		4: {{URL: "https://example.com/never", Title: "Novasco"}},
+	}}
+	gd := &GoogleDorking{Provider: provider}
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{
+		Query:      "novasco",
+		MaxResults: 10,
+		Pages:      4,
+	})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+
+	// Page 3 repeats page 2, so page 4 is never asked for
+	if len(provider.fetched) != 3 {
+		t.Errorf("expected pages 1 to 3 to be fetched, got %v", provider.fetched)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected the 2 distinct results, got %+v", results)
+	}
+}
+
+func TestSearchWithParamsCapsPages(t *testing.T) {
+	pages := map[int][]domain.GoogleDorkingResult{}
+	for page := 1; page <= maxDorkingPages+2; page++ {
+		pages[page] = []domain.GoogleDorkingResult{{URL: "https://example.com/novasco/" + string(rune('a'+page)), Title: "Novasco"}}
+	}
+	provider := &fakeSearchProvider{pages: pages}
+	gd := &GoogleDorking{Provider: provider}
+
+	if _, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{Query: "novasco", MaxResults: 10, Pages: 100}); err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+	if len(provider.fetched) != maxDorkingPages {
+		t.Errorf("expected at most %d pages, got %v", maxDorkingPages, provider.fetched)
+	}
+}
+
+func TestSearchWithParamsKeepsPagesFetchedBeforeAnError(t *testing.T) {
+	provider := &failingAfterFirstPage{fakeSearchProvider{pages: map[int][]domain.GoogleDorkingResult{
+		1: {{URL: "https://example.com/exact", Title: "Novasco"}},
+	}}}
+	gd := &GoogleDorking{Provider: provider}
+
+	results, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{Query: "novasco", MaxResults: 10, Pages: 3})
+	if err != nil {
+		t.Fatalf("SearchWithParams returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected the result of the first page, got %+v", results)
+	}
+
+	// A failing first page fails the search
+	gd.Provider = &fakeSearchProvider{err: errors.New("boom")}
+	if _, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{Query: "novasco", Pages: 3}); err == nil {
+		t.Error("expected the error of the first page")
+	}
+
+	gd.Provider = nil
+	if _, err := gd.SearchWithParams(context.Background(), domain.GoogleDorkingSearchParams{Query: "novasco"}); err == nil {
+		t.Error("expected an error without a provider")
+	}
+}
+
+// failingAfterFirstPage fails every page but the first
+type failingAfterFirstPage struct {
+	fakeSearchProvider
+}
+
+func (p *failingAfterFirstPage) Fetch(ctx context.Context, query string, page int) ([]domain.GoogleDorkingResult, error) {
+	if page > 1 {
+		return nil, errors.New("page unavailable")
+	}
+	return p.fakeSearchProvider.Fetch(ctx, query, page)
+}
+
+func TestNewSearchProvider(t *testing.T) {
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GOOGLE_CX_KEY", "")
+
+	if _, err := NewSearchProvider(domain.DorkingProviderGoogle); err == nil {
+		t.Error("expected the Google provider to require its keys")
+	}
+	if _, err := NewSearchProvider("bing"); err == nil {
+		t.Error("expected an unknown provider to be rejected")
+	}
+
+	provider, err := NewSearchProvider("DuckDuckGo")
+	if err != nil {
+		t.Fatalf("NewSearchProvider returned error: %v", err)
+	}
+	if _, ok := provider.(*DuckDuckGoSearchProvider); !ok {
+		t.Errorf("expected a DuckDuckGo provider, got %T", provider)
+	}
+
+	t.Setenv("GOOGLE_API_KEY", "key")
+	t.Setenv("GOOGLE_CX_KEY", "cx")
+	gd, err := NewGoogleDorkingWithProvider(domain.DorkingProviderGoogle)
+	if err != nil {
+		t.Fatalf("NewGoogleDorkingWithProvider returned error: %v", err)
+	}
+	google, ok := gd.Provider.(*GoogleSearchProvider)
+	if !ok || !strings.Contains(google.BasePath, "key=key&cx=cx") {
+		t.Errorf("expected a Google provider with the keys, got %+v", gd.Provider)
+	}
+
+	// With keys, the default connector falls back to DuckDuckGo
+	if _, ok := NewGoogleDorkingDomain().Provider.(*FallbackSearchProvider); !ok {
+		t.Errorf("expected the default provider to fall back to DuckDuckGo")
+	}
+}
+
+func TestProvidersRequestPageOffsets(t *testing.T) {
+	var starts []string
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		starts = append(starts, r.URL.Query().Get("start"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GoogleDorkingSearchResponse{})
+	}))
+	t.Cleanup(google.Close)
+
+	var offsets []string
+	duckDuckGo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offsets = append(offsets, r.URL.Query().Get("s"))
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(duckDuckGoResultsPage))
+	}))
+	t.Cleanup(duckDuckGo.Close)
+
+	googleProvider := &GoogleSearchProvider{BasePath: google.URL + "/customsearch/v1?key=test&cx=test", Stuff: *custom.NewClient()}
+	duckDuckGoProvider := &DuckDuckGoSearchProvider{BasePath: duckDuckGo.URL + "/html/"}
+	for _, page := range []int{1, 3} {
+		if _, err := googleProvider.Fetch(context.Background(), "novasco", page); err != nil {
+			t.Fatalf("Google page %d: %v", page, err)
+		}
+		if _, err := duckDuckGoProvider.Fetch(context.Background(), "novasco", page); err != nil {
+			t.Fatalf("DuckDuckGo page %d: %v", page, err)
+		}
+	}
+
+	if len(starts) != 2 || starts[0] != "" || starts[1] != "21" {
+		t.Errorf("expected Google to start page 3 at result 21, got %q", starts)
+	}
+	if len(offsets) != 2 || offsets[0] != "" || offsets[1] != "60" {
+		t.Errorf("expected DuckDuckGo to skip 60 results for page 3, got %q", offsets)
+	}
+}
@@ -0,0 +1,87 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// searchRetryBackoff is the wait before the second attempt of a search, doubled
+// before each further attempt
+var searchRetryBackoff = 500 * time.Millisecond
+
+// StatusError is returned when a source answers a search with an unexpected
+// HTTP status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// IsTransientError reports whether a search failing with err may succeed when
+// made again: network errors and 429/5xx answers are transient, while parse
+// errors, other statuses, exhausted budgets and quotas and cancelled contexts
+// are not
+func IsTransientError(err error) bool {
+	if err == nil ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, custom.ErrBudgetExhausted) || errors.Is(err, ErrGoogleQuotaExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return isTransientStatus(statusErr.StatusCode)
+	}
+
+	// Only errors of the connection itself, as a system error such as a
+	// missing dataset file would fail again
+	var urlErr *url.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &urlErr) || errors.As(err, &opErr) || errors.As(err, &dnsErr) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retrySearch wraps search to make it again, up to the attempts of its
+// parameters, while it fails on a transient error. The result records the
+// attempts made.
+func retrySearch(search SearchFunc) SearchFunc {
+	return func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		attempts := params.Attempts
+		if attempts <= 0 {
+			attempts = domain.DefaultSearchAttempts
+		}
+		if attempts > domain.MaxSearchAttempts {
+			attempts = domain.MaxSearchAttempts
+		}
+
+		backoff := searchRetryBackoff
+		for attempt := 1; ; attempt++ {
+			result, err := search(ctx, domainType, params)
+			if result != nil {
+				result.Attempts = attempt
+			}
+			if err == nil || attempt >= attempts || !IsTransientError(err) {
+				return result, err
+			}
+
+			select {
+			case <-ctx.Done():
+				return result, err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
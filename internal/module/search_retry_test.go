@@ -0,0 +1,160 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+func withoutRetryBackoff(t *testing.T) {
+	t.Helper()
+
+	backoff := searchRetryBackoff
+	searchRetryBackoff = 0
+	t.Cleanup(func() { searchRetryBackoff = backoff })
+}
+
+func TestRetrySearchRetriesTransientErrors(t *testing.T) {
+	withoutRetryBackoff(t)
+
+	calls := 0
+	search := retrySearch(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		calls++
+		if calls == 1 {
+			err := &StatusError{StatusCode: http.StatusServiceUnavailable}
+			return &domain.DomainSearchResult{DomainType: domainType, Error: err}, err
+		}
+		return &domain.DomainSearchResult{DomainType: domainType, Success: true}, nil
+	})
+
+	result, err := search(context.Background(), domain.DomainTypeONAPI, domain.DomainSearchParams{Query: "novasco"})
+	if err != nil {
+		t.Fatalf("expected the second attempt to succeed, got %v", err)
+	}
+	if calls != 2 || result.Attempts != 2 || !result.Success {
+		t.Errorf("expected a successful result after 2 attempts, got %d calls and %+v", calls, result)
+	}
+}
+
+func TestRetrySearchStopsOnPermanentErrors(t *testing.T) {
+	withoutRetryBackoff(t)
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "parse error", err: fmt.Errorf("failed to unmarshal JSON response: %w", errors.New("invalid character"))},
+		{name: "not found", err: &StatusError{StatusCode: http.StatusNotFound}},
+		{name: "budget", err: custom.ErrBudgetExhausted},
+		{name: "cancelled", err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		search := retrySearch(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+			calls++
+			return &domain.DomainSearchResult{DomainType: domainType, Error: tt.err}, tt.err
+		})
+
+		result, err := search(context.Background(), domain.DomainTypeONAPI, domain.DomainSearchParams{Query: "novasco", Attempts: 3})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected the error to be returned, got %v", tt.name, err)
+		}
+		if calls != 1 || result.Attempts != 1 {
+			t.Errorf("%s: expected a single attempt, got %d", tt.name, calls)
+		}
+	}
+}
+
+func TestRetrySearchBoundsAttempts(t *testing.T) {
+	withoutRetryBackoff(t)
+
+	tests := []struct {
+		attempts int
+		want     int
+	}{
+		{attempts: 0, want: domain.DefaultSearchAttempts},
+		{attempts: 1, want: 1},
+		{attempts: 100, want: domain.MaxSearchAttempts},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		search := retrySearch(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+			calls++
+			return &domain.DomainSearchResult{DomainType: domainType}, fmt.Errorf("failed to make request: %w", syscall.ECONNRESET)
+		})
+
+		if _, err := search(context.Background(), domain.DomainTypeONAPI, domain.DomainSearchParams{Query: "novasco", Attempts: tt.attempts}); err == nil {
+			t.Fatal("expected the last error to be returned")
+		}
+		if calls != tt.want {
+			t.Errorf("Attempts %d: expected %d attempts, got %d", tt.attempts, tt.want, calls)
+		}
+	}
+}
+
+func TestSearchDomainRetriesServerErrors(t *testing.T) {
+	withoutRetryBackoff(t)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(sanctionsFixture))
+	}))
+	t.Cleanup(srv.Close)
+
+	dataset := srv.URL + "/retry-sanctions.json"
+	t.Setenv("SANCTIONS_DATASET", dataset)
+	t.Cleanup(func() {
+		sanctionsDatasets.Delete(dataset)
+		DefaultSearchCache.Forget(domain.DomainTypeSanctions, "Maria Fernandez")
+	})
+
+	result, err := SearchDomain(context.Background(), domain.DomainTypeSanctions, domain.DomainSearchParams{Query: "Maria Fernandez"})
+	if err != nil {
+		t.Fatalf("SearchDomain returned error: %v", err)
+	}
+	if requests != 2 || result.Attempts != 2 {
+		t.Errorf("expected the search to succeed on its second attempt, got %d requests and %d attempts", requests, result.Attempts)
+	}
+	if matches, ok := result.Output.([]domain.SanctionMatch); !ok || len(matches) != 1 {
+		t.Errorf("expected the PEP match, got %+v", result.Output)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: &StatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{err: &StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{err: &StatusError{StatusCode: http.StatusBadRequest}, want: false},
+		{err: fmt.Errorf("failed to make request: %w", &url.Error{Op: "Get", URL: "https://example.com", Err: syscall.ECONNREFUSED}), want: true},
+		{err: fmt.Errorf("failed to read response body: %w", io.ErrUnexpectedEOF), want: true},
+		{err: fmt.Errorf("failed to read sanctions dataset: %w", &fs.PathError{Op: "open", Path: "sanctions.json", Err: syscall.ENOENT}), want: false},
+		{err: fmt.Errorf("%w: status code 429", ErrGoogleQuotaExceeded), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransientError(tt.err); got != tt.want {
+			t.Errorf("IsTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
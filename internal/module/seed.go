@@ -0,0 +1,22 @@
+package module
+
+import (
+	"insightful-intel/internal/domain"
+	"os"
+	"strconv"
+)
+
+// ValidateSeed trims a seed query and checks it against the length limit set by
+// SEED_MAX_LENGTH, domain.DefaultMaxSeedLength when it is not set or not valid.
+// Every entry point starting a search or a pipeline validates its seed with it.
+func ValidateSeed(query string) (string, error) {
+	return domain.ValidateSeed(query, seedMaxLengthFromEnv())
+}
+
+func seedMaxLengthFromEnv() int {
+	maxLength, err := strconv.Atoi(os.Getenv("SEED_MAX_LENGTH"))
+	if err != nil || maxLength <= 0 {
+		return domain.DefaultMaxSeedLength
+	}
+	return maxLength
+}
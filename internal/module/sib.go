@@ -0,0 +1,202 @@
+package module
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var _ domain.DomainConnector[domain.SIBInstitution] = &Sib{}
+
+// DefaultSIBURL is the registry of supervised entities of the Superintendencia
+// de Bancos, used when SIB_API_URL is not set
+const DefaultSIBURL = "https://www.sb.gob.do/api/entidades-reguladas/"
+
+// Sib is the connector for the registry of financial institutions licensed by
+// the Superintendencia de Bancos
+type Sib struct {
+	Stuff    custom.Client
+	BaseParh string
+	PathMap  custom.CustomPathMap
+}
+
+type SibSearchResponse struct {
+	Data []SibInstitutionResponse `json:"data"`
+}
+
+type SibInstitutionResponse struct {
+	Nombre            string               `json:"nombre"`
+	RNC               string               `json:"rnc"`
+	TipoEntidad       string               `json:"tipoEntidad"`
+	Licencia          string               `json:"licencia"`
+	Estado            string               `json:"estado"`
+	FechaAutorizacion string               `json:"fechaAutorizacion"`
+	Direccion         string               `json:"direccion"`
+	Funcionarios      []SibOfficerResponse `json:"funcionarios"`
+}
+
+type SibOfficerResponse struct {
+	Nombre string `json:"nombre"`
+	Cargo  string `json:"cargo"`
+}
+
+// NewSibDomain creates a new financial regulator connector querying the
+// registry at SIB_API_URL
+func NewSibDomain() domain.DomainConnector[domain.SIBInstitution] {
+	baseURL := cmp.Or(os.Getenv("SIB_API_URL"), DefaultSIBURL)
+
+	return &Sib{
+		BaseParh: baseURL,
+		Stuff:    *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: baseURL,
+			Paths: map[string]string{
+				"search": "buscar",
+			},
+		},
+	}
+}
+
+func (*Sib) GetDomainType() domain.DomainType {
+	return domain.DomainTypeSIB
+}
+
+// Search looks up the licensed institutions by RNC when the query is a
+// contributor ID and by name otherwise. An entity that is not registered
+// returns no institutions.
+func (s *Sib) Search(ctx context.Context, query string) ([]domain.SIBInstitution, error) {
+	params := map[string]string{"nombre": query}
+	if isContributorID(query) {
+		params = map[string]string{"rnc": strings.ReplaceAll(query, "-", "")}
+	}
+
+	response, err := s.Stuff.Get(ctx, s.PathMap.GetURLFrom("search"), params, map[string]string{
+		"Accept": "application/json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: response.StatusCode}
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var sibResponse SibSearchResponse
+	if err := json.Unmarshal(body, &sibResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+
+	institutions := []domain.SIBInstitution{}
+	for _, item := range sibResponse.Data {
+		institution, err := s.ProcessData(toSIBInstitution(item))
+		if err != nil {
+			continue
+		}
+		institutions = append(institutions, institution)
+	}
+
+	return institutions, nil
+}
+
+func (s *Sib) ProcessData(data domain.SIBInstitution) (domain.SIBInstitution, error) {
+	if err := s.ValidateData(data); err != nil {
+		return domain.SIBInstitution{}, err
+	}
+	return s.TransformData(data), nil
+}
+
+func (s *Sib) ValidateData(data domain.SIBInstitution) error {
+	if data.Nombre == "" {
+		return fmt.Errorf("Nombre is required")
+	}
+	return nil
+}
+
+func (s *Sib) TransformData(data domain.SIBInstitution) domain.SIBInstitution {
+	transformed := data
+	transformed.Nombre = strings.TrimSpace(data.Nombre)
+	transformed.RNC = strings.TrimSpace(data.RNC)
+	transformed.TipoEntidad = strings.TrimSpace(data.TipoEntidad)
+	transformed.Licencia = strings.TrimSpace(data.Licencia)
+	transformed.Estado = strings.ToUpper(strings.TrimSpace(data.Estado))
+	transformed.Direccion = strings.TrimSpace(data.Direccion)
+
+	transformed.Funcionarios = make([]domain.SIBOfficer, 0, len(data.Funcionarios))
+	for _, officer := range data.Funcionarios {
+		transformed.Funcionarios = append(transformed.Funcionarios, domain.SIBOfficer{
+			Nombre: strings.TrimSpace(officer.Nombre),
+			Cargo:  strings.TrimSpace(officer.Cargo),
+		})
+	}
+
+	return transformed
+}
+
+func (s *Sib) GetDataByCategory(data domain.SIBInstitution, category domain.KeywordCategory) []string {
+	result := []string{}
+
+	switch category {
+	case domain.KeywordCategoryPersonName:
+		for _, officer := range data.Funcionarios {
+			result = append(result, officer.Nombre)
+		}
+	case domain.KeywordCategoryContributorID:
+		result = append(result, data.RNC)
+	case domain.KeywordCategoryAddress:
+		result = append(result, data.Direccion)
+	}
+
+	// Filter out empty strings from result
+	nonEmpty := make([]string, 0, len(result))
+	for _, item := range result {
+		if item != "" {
+			nonEmpty = append(nonEmpty, item)
+		}
+	}
+	return nonEmpty
+}
+
+func (s *Sib) GetSearchableKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryCompanyName,
+	}
+}
+
+func (s *Sib) GetFoundKeywordCategories() []domain.KeywordCategory {
+	return []domain.KeywordCategory{
+		domain.KeywordCategoryPersonName,
+		domain.KeywordCategoryContributorID,
+		domain.KeywordCategoryAddress,
+	}
+}
+
+func toSIBInstitution(item SibInstitutionResponse) domain.SIBInstitution {
+	institution := domain.SIBInstitution{
+		Nombre:            item.Nombre,
+		RNC:               item.RNC,
+		TipoEntidad:       item.TipoEntidad,
+		Licencia:          item.Licencia,
+		Estado:            item.Estado,
+		FechaAutorizacion: item.FechaAutorizacion,
+		Direccion:         item.Direccion,
+	}
+
+	for _, officer := range item.Funcionarios {
+		institution.Funcionarios = append(institution.Funcionarios, domain.SIBOfficer(officer))
+	}
+
+	return institution
+}
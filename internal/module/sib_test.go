@@ -0,0 +1,127 @@
+package module
+
+import (
+	"context"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+const sibRegisteredFixture = `{
+	"data": [
+		{
+			"nombre": " Banco Novasco de Ahorro y Crédito, S.A. ",
+			"rnc": "130000001",
+			"tipoEntidad": "Banco de Ahorro y Crédito",
+			"licencia": "BAC-042",
+			"estado": "vigente",
+			"fechaAutorizacion": "2015-06-01",
+			"direccion": "Av. 27 de Febrero 100, Santo Domingo",
+			"funcionarios": [
+				{"nombre": " Juan Perez ", "cargo": "Presidente"},
+				{"nombre": "Maria Gomez", "cargo": "Gerente General"}
+			]
+		},
+		{
+			"nombre": "",
+			"licencia": "BAC-000"
+		}
+	]
+}`
+
+const sibUnregisteredFixture = `{"data": []}`
+
+func newTestSib(t *testing.T, fixture string, queries *[]url.Values) *Sib {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*queries = append(*queries, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(fixture))
+	}))
+	t.Cleanup(srv.Close)
+
+	return &Sib{
+		Stuff: *custom.NewClient(),
+		PathMap: custom.CustomPathMap{
+			BaseURL: srv.URL + "/",
+			Paths:   map[string]string{"search": "buscar"},
+		},
+	}
+}
+
+func TestSibSearchRegisteredEntity(t *testing.T) {
+	var queries []url.Values
+	sib := newTestSib(t, sibRegisteredFixture, &queries)
+
+	institutions, err := sib.Search(context.Background(), "Banco Novasco")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if len(institutions) != 1 {
+		t.Fatalf("expected invalid institutions to be dropped, got %d institutions", len(institutions))
+	}
+
+	institution := institutions[0]
+	if institution.Nombre != "Banco Novasco de Ahorro y Crédito, S.A." {
+		t.Errorf("expected trimmed name, got %q", institution.Nombre)
+	}
+	if institution.Licencia != "BAC-042" || institution.Estado != "VIGENTE" {
+		t.Errorf("expected the license and its status, got %q and %q", institution.Licencia, institution.Estado)
+	}
+
+	keywords := domain.GetCategoryByKeywords(domain.DomainConnector[domain.SIBInstitution](sib), institutions)
+	people := keywords[domain.KeywordCategoryPersonName]
+	if !slices.Contains(people, "Juan Perez") || !slices.Contains(people, "Maria Gomez") {
+		t.Errorf("expected the officers as person names, got %v", people)
+	}
+	if ids := keywords[domain.KeywordCategoryContributorID]; !slices.Equal(ids, []string{"130000001"}) {
+		t.Errorf("expected the RNC of the institution, got %v", ids)
+	}
+
+	if len(queries) != 1 || queries[0].Get("nombre") != "Banco Novasco" {
+		t.Errorf("expected a name search, got %v", queries)
+	}
+}
+
+func TestSibSearchUnregisteredEntity(t *testing.T) {
+	var queries []url.Values
+	sib := newTestSib(t, sibUnregisteredFixture, &queries)
+
+	institutions, err := sib.Search(context.Background(), "1-30-00000-2")
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	if institutions == nil || len(institutions) != 0 {
+		t.Errorf("expected no institutions for an unregistered entity, got %#v", institutions)
+	}
+	if len(queries) != 1 || queries[0].Get("rnc") != "130000002" {
+		t.Errorf("expected an RNC search, got %v", queries)
+	}
+}
+
+func TestCreateDynamicPipelineSearchesSibForCompanySeeds(t *testing.T) {
+	domains := []domain.DomainType{domain.DomainTypeSIB, domain.DomainTypePGR}
+
+	pipeline, err := CreateDynamicPipeline(context.Background(), "Novasco", domains, domain.DynamicPipelineConfig{})
+	if err != nil {
+		t.Fatalf("CreateDynamicPipeline returned error: %v", err)
+	}
+	if len(pipeline.Steps) != 2 || pipeline.Steps[0].DomainType != domain.DomainTypeSIB || pipeline.Steps[0].Category != domain.KeywordCategoryCompanyName {
+		t.Errorf("expected a SIB company name step for a company seed, got %+v", pipeline.Steps)
+	}
+
+	pipeline, err = CreateDynamicPipeline(context.Background(), "001-1234567-8", domains, domain.DynamicPipelineConfig{})
+	if err != nil {
+		t.Fatalf("CreateDynamicPipeline returned error: %v", err)
+	}
+	if len(pipeline.Steps) != 1 || pipeline.Steps[0].DomainType != domain.DomainTypePGR {
+		t.Errorf("expected no SIB step for a cédula seed, got %+v", pipeline.Steps)
+	}
+}
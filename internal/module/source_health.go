@@ -0,0 +1,87 @@
+package module
+
+import (
+	"insightful-intel/internal/domain"
+	"sync"
+	"time"
+)
+
+// SourceHealth records the sources known to be down, as marked by health checks
+// or circuit breakers, so pipelines can skip them instead of searching them
+type SourceHealth struct {
+	mu      sync.RWMutex
+	outages map[domain.DomainType]SourceOutage
+	now     func() time.Time
+}
+
+// SourceOutage describes why and until when a source is down. A zero Until
+// keeps the source down until it is marked up again.
+type SourceOutage struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until,omitempty"`
+}
+
+// DefaultSourceHealth is the source health state shared by the pipelines
+var DefaultSourceHealth = NewSourceHealth()
+
+// NewSourceHealth creates a source health state with every source available
+func NewSourceHealth() *SourceHealth {
+	return &SourceHealth{
+		outages: make(map[domain.DomainType]SourceOutage),
+		now:     time.Now,
+	}
+}
+
+// MarkDown marks a source as down for the given duration, or until MarkUp when
+// the duration is zero
+func (h *SourceHealth) MarkDown(domainType domain.DomainType, reason string, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	outage := SourceOutage{Reason: reason, Since: h.now()}
+	if duration > 0 {
+		outage.Until = outage.Since.Add(duration)
+	}
+	h.outages[domainType] = outage
+}
+
+// MarkUp marks a source as available again
+func (h *SourceHealth) MarkUp(domainType domain.DomainType) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.outages, domainType)
+}
+
+// IsAvailable reports whether a source can be searched. A nil state treats
+// every source as available.
+func (h *SourceHealth) IsAvailable(domainType domain.DomainType) bool {
+	if h == nil {
+		return true
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	outage, down := h.outages[domainType]
+	if !down {
+		return true
+	}
+	return !outage.Until.IsZero() && h.now().After(outage.Until)
+}
+
+// Outages returns the sources currently down
+func (h *SourceHealth) Outages() map[domain.DomainType]SourceOutage {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := h.now()
+	outages := make(map[domain.DomainType]SourceOutage, len(h.outages))
+	for domainType, outage := range h.outages {
+		if outage.Until.IsZero() || !now.After(outage.Until) {
+			outages[domainType] = outage
+		}
+	}
+	return outages
+}
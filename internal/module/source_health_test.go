@@ -0,0 +1,39 @@
+package module
+
+import (
+	"insightful-intel/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestSourceHealthOutageExpires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	health := NewSourceHealth()
+	health.now = func() time.Time { return now }
+
+	health.MarkDown(domain.DomainTypePGR, "timeouts", time.Minute)
+	health.MarkDown(domain.DomainTypeSCJ, "maintenance", 0)
+
+	if health.IsAvailable(domain.DomainTypePGR) || health.IsAvailable(domain.DomainTypeSCJ) {
+		t.Fatal("expected the marked sources to be unavailable")
+	}
+	if !health.IsAvailable(domain.DomainTypeONAPI) {
+		t.Error("expected unmarked sources to be available")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !health.IsAvailable(domain.DomainTypePGR) {
+		t.Error("expected the outage to expire after its duration")
+	}
+	if health.IsAvailable(domain.DomainTypeSCJ) {
+		t.Error("expected an outage without duration to last until marked up")
+	}
+	if outages := health.Outages(); len(outages) != 1 {
+		t.Errorf("expected 1 current outage, got %v", outages)
+	}
+
+	health.MarkUp(domain.DomainTypeSCJ)
+	if !health.IsAvailable(domain.DomainTypeSCJ) {
+		t.Error("expected the source to be available once marked up")
+	}
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+)
+
+// DefaultCompactionMinBytes is the size from which a stored JSON blob is worth
+// compressing
+const DefaultCompactionMinBytes = 4096
+
+// gzipMagic starts every gzip stream, which no JSON document starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// CompactionStats reports what a compaction did
+type CompactionStats struct {
+	Rows        int   `json:"rows"`
+	BytesBefore int64 `json:"bytes_before"`
+	BytesAfter  int64 `json:"bytes_after"`
+}
+
+// compressedTables are the tables whose output and keywords_per_category
+// columns are compacted
+var compressedTables = []string{"domain_search_results", "dynamic_pipeline_steps"}
+
+// jsonColumn scans a JSON column that may have been compacted, decompressing
+// gzipped values so readers always get the JSON document. NULL leaves the
+// empty string.
+type jsonColumn struct {
+	s *string
+}
+
+func (c jsonColumn) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c.s = ""
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported JSON column type %T", value)
+	}
+
+	data, err := gunzipJSON(data)
+	if err != nil {
+		return err
+	}
+	*c.s = string(data)
+	return nil
+}
+
+// gzipJSON compresses a JSON document
+func gzipJSON(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipJSON returns data decompressed when it is gzipped, and as is otherwise
+func gunzipJSON(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress JSON column: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress JSON column: %w", err)
+	}
+	return decompressed, nil
+}
+
+// CompactOutputs gzips the output and keywords_per_category columns of up to
+// limit search results and pipeline steps whose JSON reaches minBytes. Rows are
+// only rewritten when compression makes them smaller, and keep their
+// updated_at so their freshness is unchanged.
+func (r *PipelineRepository) CompactOutputs(ctx context.Context, minBytes, limit int) (CompactionStats, error) {
+	if minBytes <= 0 {
+		minBytes = DefaultCompactionMinBytes
+	}
+
+	var stats CompactionStats
+	for _, table := range compressedTables {
+		if err := r.compactTable(ctx, table, minBytes, limit, &stats); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+type compactionRow struct {
+	id                  string
+	output              []byte
+	keywordsPerCategory []byte
+}
+
+func (r *PipelineRepository) compactTable(ctx context.Context, table string, minBytes, limit int, stats *CompactionStats) error {
+	query := fmt.Sprintf(`
+		SELECT id, output, keywords_per_category
+		FROM %s
+		WHERE compressed = FALSE
+		  AND COALESCE(LENGTH(output), 0) + COALESCE(LENGTH(keywords_per_category), 0) >= ?
+		LIMIT ?
+	`, table)
+
+	rows, err := r.db.QueryContext(ctx, query, minBytes, limit)
+	if err != nil {
+		return fmt.Errorf("error listing %s to compact: %w", table, err)
+	}
+
+	var candidates []compactionRow
+	for rows.Next() {
+		var row compactionRow
+		if err := rows.Scan(&row.id, &row.output, &row.keywordsPerCategory); err != nil {
+			rows.Close()
+			return err
+		}
+		candidates = append(candidates, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf(`
+		UPDATE %s SET
+			output = ?, keywords_per_category = ?, compressed = TRUE, updated_at = updated_at
+		WHERE id = ? AND compressed = FALSE
+	`, table)
+
+	for _, row := range candidates {
+		output, err := compactColumn(row.output)
+		if err != nil {
+			return err
+		}
+		keywordsPerCategory, err := compactColumn(row.keywordsPerCategory)
+		if err != nil {
+			return err
+		}
+
+		before := int64(len(row.output) + len(row.keywordsPerCategory))
+		after := int64(len(output) + len(keywordsPerCategory))
+		if after >= before {
+			continue
+		}
+
+		if _, err := r.db.ExecContext(ctx, update, output, keywordsPerCategory, row.id); err != nil {
+			return fmt.Errorf("error compacting %s %s: %w", table, row.id, err)
+		}
+
+		stats.Rows++
+		stats.BytesBefore += before
+		stats.BytesAfter += after
+	}
+
+	return nil
+}
+
+// compactColumn gzips a column value, leaving NULL and already compressed
+// values as they are
+func compactColumn(data []byte) ([]byte, error) {
+	if data == nil || bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	return gzipJSON(data)
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// capturedBytes matches any []byte argument and keeps it
+type capturedBytes struct {
+	value *[]byte
+}
+
+func (c capturedBytes) Match(v driver.Value) bool {
+	data, ok := v.([]byte)
+	if ok {
+		*c.value = data
+	}
+	return ok
+}
+
+func TestCompactOutputsRoundTrip(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	repo := repos.GetPipelineRepository().(*PipelineRepository)
+
+	var news []domain.PGRNews
+	for i := range 200 {
+		news = append(news, domain.PGRNews{
+			URL:   fmt.Sprintf("https://pgr.gob.do/noticias/%d", i),
+			Title: "Procuraduría somete a Novasco SRL por lavado de activos",
+		})
+	}
+	output, _ := json.Marshal(news)
+	keywords, _ := json.Marshal(map[domain.KeywordCategory][]string{
+		domain.KeywordCategoryCompanyName: {"Novasco SRL"},
+	})
+
+	resultColumns := []string{"success", "error_message", "domain_type", "search_parameter", "keywords_per_category", "output"}
+	id := domain.NewID().String()
+
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(resultColumns).AddRow(true, "", "PGR", "novasco", keywords, output))
+	original, err := repo.getDomainSearchResultByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("getDomainSearchResultByID returned error: %v", err)
+	}
+
+	var compactedOutput, compactedKeywords []byte
+	mock.ExpectQuery("FROM domain_search_results\\s+WHERE compressed = FALSE").
+		WithArgs(DefaultCompactionMinBytes, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "output", "keywords_per_category"}).AddRow(id, output, keywords))
+	mock.ExpectExec("UPDATE domain_search_results SET").
+		WithArgs(capturedBytes{&compactedOutput}, capturedBytes{&compactedKeywords}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM dynamic_pipeline_steps\\s+WHERE compressed = FALSE").
+		WithArgs(DefaultCompactionMinBytes, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "output", "keywords_per_category"}))
+
+	stats, err := repo.CompactOutputs(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatalf("CompactOutputs returned error: %v", err)
+	}
+
+	if stats.Rows != 1 {
+		t.Errorf("expected 1 compacted row, got %d", stats.Rows)
+	}
+	if len(compactedOutput) >= len(output) {
+		t.Errorf("expected the compacted output to be smaller than %d bytes, got %d", len(output), len(compactedOutput))
+	}
+	if stats.BytesAfter >= stats.BytesBefore {
+		t.Errorf("expected compaction to save bytes, got %d before and %d after", stats.BytesBefore, stats.BytesAfter)
+	}
+
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(resultColumns).AddRow(true, "", "PGR", "novasco", compactedKeywords, compactedOutput))
+	compacted, err := repo.getDomainSearchResultByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("getDomainSearchResultByID returned error after compaction: %v", err)
+	}
+
+	if !reflect.DeepEqual(compacted, original) {
+		t.Errorf("expected the compacted result to read back as stored, got %+v want %+v", compacted, original)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -3,29 +3,89 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"insightful-intel/internal/database"
 )
 
-// databaseAdapter adapts database.Service to DatabaseAccessor
+// databaseAdapter adapts database.Service to DatabaseAccessor, rewriting the
+// statements for the dialect of the database
 type databaseAdapter struct {
 	db database.Service
 }
 
+func (da *databaseAdapter) dialect() database.Dialect {
+	return database.DialectOf(da.db.GetDB())
+}
+
 func (da *databaseAdapter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args = da.dialect().Rewrite(query, args)
 	return da.db.GetDB().ExecContext(ctx, query, args...)
 }
 
 func (da *databaseAdapter) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args = da.dialect().Rewrite(query, args)
 	return da.db.GetDB().QueryContext(ctx, query, args...)
 }
 
 func (da *databaseAdapter) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args = da.dialect().Rewrite(query, args)
 	return da.db.GetDB().QueryRowContext(ctx, query, args...)
 }
 
+func (da *databaseAdapter) WithTx(ctx context.Context, fn func(tx DatabaseAccessor) error) (err error) {
+	tx, err := da.db.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(&txAccessor{tx: tx, dialect: da.dialect()}); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("error rolling back transaction: %w", rollbackErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// txAccessor runs the statements of a DatabaseAccessor in a transaction
+type txAccessor struct {
+	tx      *sql.Tx
+	dialect database.Dialect
+}
+
+func (ta *txAccessor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args = ta.dialect.Rewrite(query, args)
+	return ta.tx.ExecContext(ctx, query, args...)
+}
+
+func (ta *txAccessor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args = ta.dialect.Rewrite(query, args)
+	return ta.tx.QueryContext(ctx, query, args...)
+}
+
+func (ta *txAccessor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args = ta.dialect.Rewrite(query, args)
+	return ta.tx.QueryRowContext(ctx, query, args...)
+}
+
+// WithTx runs fn in the transaction already open
+func (ta *txAccessor) WithTx(ctx context.Context, fn func(tx DatabaseAccessor) error) error {
+	return fn(ta)
+}
+
 // NewDatabaseAdapter creates a new database adapter
 func NewDatabaseAdapter(db database.Service) DatabaseAccessor {
 	return &databaseAdapter{db: db}
 }
-
-
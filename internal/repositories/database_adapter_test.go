@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRepositoryFactoryWithTx(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	// A nested transaction joins the outer one, so both writes commit together
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM dgii_registers").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM onapi_entities").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repos.WithTx(context.Background(), func(tx Factory) error {
+		if err := tx.GetDgiiRepository().Delete(context.Background(), domain.NewID().String()); err != nil {
+			return err
+		}
+		return tx.WithTx(context.Background(), func(nested Factory) error {
+			return nested.GetOnapiRepository().Delete(context.Background(), domain.NewID().String())
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTx returned error: %v", err)
+	}
+
+	// An error rolls the transaction back and is returned
+	failure := errors.New("write failed")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	if err := repos.WithTx(context.Background(), func(tx Factory) error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
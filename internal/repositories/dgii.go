@@ -27,15 +27,22 @@ func (r *DgiiRepository) Create(ctx context.Context, entity domain.Register) err
 
 // CreateWithDomainSearchResultID inserts a new DGII register with a domain search result ID
 func (r *DgiiRepository) CreateWithDomainSearchResultID(ctx context.Context, entity domain.Register) error {
+	_, err := r.Upsert(ctx, entity)
+	return err
+}
+
+// Upsert stores a DGII register, updating the stored register with the same
+// RNC instead of inserting it again. It returns the ID of the stored register.
+func (r *DgiiRepository) Upsert(ctx context.Context, entity domain.Register) (domain.ID, error) {
 	// Check if RNC already exists
 	exists, existingID, err := r.RNCExists(ctx, entity.RNC)
 	if err != nil {
-		return fmt.Errorf("error checking RNC existence: %w", err)
+		return domain.ID{}, fmt.Errorf("error checking RNC existence: %w", err)
 	}
 
 	if exists {
 		// Update the existing entity instead of creating a new one
-		return r.Update(ctx, existingID.String(), entity)
+		return existingID, r.Update(ctx, existingID.String(), entity)
 	}
 
 	// Generate new ID for new entity
@@ -52,8 +59,11 @@ func (r *DgiiRepository) CreateWithDomainSearchResultID(ctx context.Context, ent
 		entity.ID, entity.DomainSearchResultID, entity.RNC, entity.RazonSocial, entity.NombreComercial, entity.Categoria,
 		entity.RegimenPagos, entity.FacturadorElectronico, entity.LicenciaComercial, entity.Estado,
 	)
+	if err != nil {
+		return domain.ID{}, err
+	}
 
-	return err
+	return entity.ID, nil
 }
 
 // RNCExists checks if an RNC already exists in the database
@@ -87,6 +97,7 @@ func (r *DgiiRepository) GetByRNC(ctx context.Context, rnc string) (domain.Regis
 	err := r.db.QueryRowContext(ctx, query, rnc).Scan(
 		&entity.ID, &entity.DomainSearchResultID, &entity.RNC, &entity.RazonSocial, &entity.NombreComercial, &entity.Categoria,
 		&entity.RegimenPagos, &entity.FacturadorElectronico, &entity.LicenciaComercial, &entity.Estado,
+		timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 	)
 
 	if err != nil {
@@ -110,6 +121,7 @@ func (r *DgiiRepository) GetByID(ctx context.Context, id string) (domain.Registe
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&entity.ID, &entity.DomainSearchResultID, &entity.RNC, &entity.RazonSocial, &entity.NombreComercial, &entity.Categoria,
 		&entity.RegimenPagos, &entity.FacturadorElectronico, &entity.LicenciaComercial, &entity.Estado,
+		timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 	)
 
 	if err != nil {
@@ -145,15 +157,29 @@ func (r *DgiiRepository) Delete(ctx context.Context, id string) error {
 
 // List retrieves multiple DGII registers with pagination
 func (r *DgiiRepository) List(ctx context.Context, offset, limit int) ([]domain.Register, error) {
+	return r.ListWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
+
+// dgiiListQuery lists the DGII registers
+var dgiiListQuery = listQuery{
+	sortable:     []string{"created_at", "updated_at", "rnc", "razon_social", "nombre_comercial", "estado"},
+	domainFilter: searchResultDomainFilter,
+}
+
+// ListWithOptions retrieves the DGII registers sorted and filtered by opts
+func (r *DgiiRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.Register, error) {
+	clauses, args, err := dgiiListQuery.clauses(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT id, domain_search_result_id, rnc, razon_social, nombre_comercial, categoria, regimen_pagos,
 			   facturador_electronico, licencia_comercial, estado, created_at, updated_at
 		FROM dgii_registers 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + clauses
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -174,8 +200,8 @@ func (r *DgiiRepository) List(ctx context.Context, offset, limit int) ([]domain.
 			&entity.FacturadorElectronico, // facturador_electronico
 			&entity.LicenciaComercial,     // licencia_comercial
 			&entity.Estado,                // estado
-			new(interface{}),              // created_at (ignored)
-			new(interface{}),              // updated_at (ignored)
+			timestamp{&entity.CreatedAt},  // created_at
+			timestamp{&entity.UpdatedAt},  // updated_at
 		)
 		if err != nil {
 			return nil, err
@@ -195,21 +221,29 @@ func (r *DgiiRepository) Count(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-// Search performs a search query on DGII registers
+// dgiiSearchColumns are the columns the words of a search are looked up in
+var dgiiSearchColumns = []string{"rnc", "razon_social", "nombre_comercial", "categoria", "estado"}
+
+// Search performs a search query on DGII registers, matching the registers
+// holding every word of the query
 func (r *DgiiRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.Register, error) {
+	return r.SearchWithMode(ctx, query, MatchAll, offset, limit)
+}
+
+// SearchWithMode searches DGII registers for the words of query, each word in
+// any of the searched columns, combined by mode
+func (r *DgiiRepository) SearchWithMode(ctx context.Context, query string, mode MatchMode, offset, limit int) ([]domain.Register, error) {
+	condition, args := tokenCondition(query, dgiiSearchColumns, mode)
 	searchQuery := `
 		SELECT id, domain_search_result_id, rnc, razon_social, nombre_comercial, categoria, regimen_pagos,
 			   facturador_electronico, licencia_comercial, estado, created_at, updated_at
 		FROM dgii_registers 
-		WHERE id LIKE ? OR domain_search_result_id LIKE ? OR rnc LIKE ? OR razon_social LIKE ? OR nombre_comercial LIKE ? 
-		   OR categoria LIKE ? OR estado LIKE ?
+		WHERE ` + condition + `
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
 	`
 
-	searchPattern := "%" + query + "%"
-	rows, err := r.db.QueryContext(ctx, searchQuery,
-		searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, limit, offset)
+	rows, err := r.db.QueryContext(ctx, searchQuery, append(args, limit, offset)...)
 	if err != nil {
 		return nil, err
 	}
@@ -222,6 +256,7 @@ func (r *DgiiRepository) Search(ctx context.Context, query string, offset, limit
 		err := rows.Scan(
 			&entity.ID, &entity.DomainSearchResultID, &entity.RNC, &entity.RazonSocial, &entity.NombreComercial, &entity.Categoria,
 			&entity.RegimenPagos, &entity.FacturadorElectronico, &entity.LicenciaComercial, &entity.Estado,
+			timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 		)
 		if err != nil {
 			return nil, err
@@ -230,7 +265,7 @@ func (r *DgiiRepository) Search(ctx context.Context, query string, offset, limit
 		entities = append(entities, entity)
 	}
 
-	return entities, nil
+	return entities, rows.Err()
 }
 
 // SearchByCategory performs a search within a specific keyword category
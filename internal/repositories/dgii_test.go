@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDgiiUpsertUpdatesRegisterWithSameRNC(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	dgiiRepo := repos.GetDgiiRepository()
+
+	register := domain.Register{RNC: "130000001", RazonSocial: "NOVASCO SRL", Estado: "ACTIVO", DomainSearchResultID: domain.NewID()}
+
+	mock.ExpectQuery("SELECT id FROM dgii_registers WHERE rnc = \\?").
+		WithArgs("130000001").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO dgii_registers").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	insertedID, err := dgiiRepo.Upsert(context.Background(), register)
+	if err != nil {
+		t.Fatalf("first Upsert returned error: %v", err)
+	}
+
+	// The second run finds the register stored by the first one, so its row is
+	// updated with the new fields and search result instead of duplicated
+	register.Estado = "SUSPENDIDO"
+	register.DomainSearchResultID = domain.NewID()
+	mock.ExpectQuery("SELECT id FROM dgii_registers WHERE rnc = \\?").
+		WithArgs("130000001").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(insertedID.String()))
+	mock.ExpectExec("UPDATE dgii_registers SET").
+		WithArgs(register.DomainSearchResultID, "NOVASCO SRL", "", "", "", "", "", "SUSPENDIDO", insertedID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	updatedID, err := dgiiRepo.Upsert(context.Background(), register)
+	if err != nil {
+		t.Fatalf("second Upsert returned error: %v", err)
+	}
+	if updatedID != insertedID {
+		t.Errorf("expected the update to resolve to the inserted register %s, got %s", insertedID, updatedID)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDgiiSearchMatchesEveryWord(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	mock.ExpectQuery(`FROM dgii_registers\s+WHERE \(\(rnc LIKE \? OR .*estado LIKE \?\) AND \(rnc LIKE \? OR .*estado LIKE \?\)\)\s+ORDER BY`).
+		WithArgs("%NOVASCO%", "%NOVASCO%", "%NOVASCO%", "%NOVASCO%", "%NOVASCO%",
+			"%ESTATE%", "%ESTATE%", "%ESTATE%", "%ESTATE%", "%ESTATE%", 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_search_result_id", "rnc", "razon_social", "nombre_comercial", "categoria", "regimen_pagos",
+			"facturador_electronico", "licencia_comercial", "estado", "created_at", "updated_at",
+		}).AddRow(domain.NewID().String(), domain.NewID().String(), "130000001", "NOVASCO REAL ESTATE SRL", "", "", "", "", "", "ACTIVO", nil, nil))
+
+	registers, err := repos.GetDgiiRepository().Search(context.Background(), "NOVASCO ESTATE", 0, 10)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(registers) != 1 || registers[0].RazonSocial != "NOVASCO REAL ESTATE SRL" {
+		t.Errorf("unexpected registers %+v", registers)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"insightful-intel/internal/database"
 	"insightful-intel/internal/domain"
+	"insightful-intel/internal/module"
 )
 
 // DockingRepository implements DomainRepository for Google Docking GoogleDorkingResult domain type
@@ -26,8 +27,24 @@ func (r *DockingRepository) Create(ctx context.Context, entity domain.GoogleDork
 	return r.CreateWithDomainSearchResultID(ctx, entity)
 }
 
-// CreateWithDomainSearchResultID inserts a new Google Docking result with a domain search result ID
+// CreateWithDomainSearchResultID inserts a new Google Docking result with a domain search result ID.
+// When the URL is already stored as PGR news, the result is linked to that record instead of duplicated
 func (r *DockingRepository) CreateWithDomainSearchResultID(ctx context.Context, entity domain.GoogleDorkingResult) error {
+	exists, pgrID, err := r.pgrNewsByURL(ctx, entity.URL)
+	if err != nil {
+		return fmt.Errorf("error checking pgr news url: %w", err)
+	}
+	if exists {
+		urlSources := &URLSourceRepository{db: r.db}
+		return urlSources.Link(ctx, domain.URLSource{
+			URL:                  entity.URL,
+			RecordTable:          "pgr_news",
+			RecordID:             pgrID,
+			DomainType:           domain.DomainTypeGoogleDorking,
+			DomainSearchResultID: entity.DomainSearchResultID,
+		})
+	}
+
 	entity.ID = domain.NewID()
 
 	query := `
@@ -38,13 +55,32 @@ func (r *DockingRepository) CreateWithDomainSearchResultID(ctx context.Context,
 
 	keywordsJSON, _ := json.Marshal(entity.Keywords)
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		entity.ID, entity.DomainSearchResultID, entity.SearchParameter, entity.URL, entity.Title, entity.Description, entity.Relevance, entity.Rank, keywordsJSON,
 	)
 
 	return err
 }
 
+// pgrNewsByURL looks up a stored PGR news item with the same URL, ignoring a trailing slash
+func (r *DockingRepository) pgrNewsByURL(ctx context.Context, url string) (bool, domain.ID, error) {
+	query := `SELECT id FROM pgr_news WHERE url = ? OR url = ? LIMIT 1`
+
+	withSlash, withoutSlash := urlVariants(url)
+
+	var id domain.ID
+	err := r.db.QueryRowContext(ctx, query, withoutSlash, withSlash).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return false, domain.ID{}, nil
+	}
+	if err != nil {
+		return false, domain.ID{}, err
+	}
+
+	return true, id, nil
+}
+
 // GetByID retrieves a Google Docking result by its URL
 func (r *DockingRepository) GetByID(ctx context.Context, id string) (domain.GoogleDorkingResult, error) {
 	query := `
@@ -58,6 +94,7 @@ func (r *DockingRepository) GetByID(ctx context.Context, id string) (domain.Goog
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&entity.ID, &entity.DomainSearchResultID, &entity.URL, &entity.Title, &entity.Description, &entity.Relevance, &entity.Rank, &keywordsJSON,
+		&entity.CreatedAt, &entity.UpdatedAt,
 	)
 
 	if err != nil {
@@ -96,14 +133,28 @@ func (r *DockingRepository) Delete(ctx context.Context, id string) error {
 
 // List retrieves multiple Google Docking results with pagination
 func (r *DockingRepository) List(ctx context.Context, offset, limit int) ([]domain.GoogleDorkingResult, error) {
+	return r.ListWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
+
+// dockingListQuery lists the Google Docking results
+var dockingListQuery = listQuery{
+	sortable:     []string{"relevance", "search_rank", "created_at", "updated_at", "title", "url"},
+	domainFilter: searchResultDomainFilter,
+}
+
+// ListWithOptions retrieves the Google Docking results sorted and filtered by opts
+func (r *DockingRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.GoogleDorkingResult, error) {
+	clauses, args, err := dockingListQuery.clauses(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT id, domain_search_result_id, url, title, description, relevance, search_rank, keywords, created_at, updated_at
 		FROM google_docking_results 
-		ORDER BY relevance DESC, created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + clauses
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -123,8 +174,8 @@ func (r *DockingRepository) List(ctx context.Context, offset, limit int) ([]doma
 			&entity.Relevance,            // relevance
 			&entity.Rank,                 // search_rank
 			&keywordsJSON,                // keywords
-			new(interface{}),             // created_at (ignored)
-			new(interface{}),             // updated_at (ignored)
+			timestamp{&entity.CreatedAt}, // created_at
+			timestamp{&entity.UpdatedAt}, // updated_at
 		)
 		if err != nil {
 			return nil, err
@@ -299,16 +350,16 @@ func (r *DockingRepository) GetBySearchParameter(ctx context.Context, searchPara
 
 // GetKeywordsByCategory retrieves keywords grouped by category for a Google Docking result
 func (r *DockingRepository) GetKeywordsByCategory(ctx context.Context, entityID string) (map[domain.KeywordCategory][]string, error) {
-	// entity, err := r.GetByID(ctx, entityID)
-	// if err != nil {
-	// 	return nil, err
-	// }
+	entity, err := r.GetByID(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
 
-	// docking := domain.NewGoogleDorkingDomain()
+	docking := module.NewGoogleDorkingDomain()
 	return map[domain.KeywordCategory][]string{
-		// domain.KeywordCategoryCompanyName: docking.GetDataByCategory(entity, domain.KeywordCategoryCompanyName),
-		// domain.KeywordCategoryPersonName:  docking.GetDataByCategory(entity, domain.KeywordCategoryPersonName),
-		// domain.KeywordCategoryAddress:     docking.GetDataByCategory(entity, domain.KeywordCategoryAddress),
-		// domain.KeywordCategorySocialMedia: docking.GetDataByCategory(entity, domain.KeywordCategorySocialMedia),
+		domain.KeywordCategoryCompanyName: docking.GetDataByCategory(entity, domain.KeywordCategoryCompanyName),
+		domain.KeywordCategoryPersonName:  docking.GetDataByCategory(entity, domain.KeywordCategoryPersonName),
+		domain.KeywordCategoryAddress:     docking.GetDataByCategory(entity, domain.KeywordCategoryAddress),
+		domain.KeywordCategorySocialMedia: docking.GetDataByCategory(entity, domain.KeywordCategorySocialMedia),
 	}, nil
 }
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"insightful-intel/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type mockDatabase struct {
+	db *sql.DB
+}
+
+func (m *mockDatabase) Health() map[string]string { return map[string]string{"status": "up"} }
+func (m *mockDatabase) Close() error              { return m.db.Close() }
+func (m *mockDatabase) GetDB() *sql.DB            { return m.db }
+
+func newMockFactory(t *testing.T) (*RepositoryFactory, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewRepositoryFactory(&mockDatabase{db: db}), mock
+}
+
+func TestDockingCreateLinksExistingPgrURL(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	pgrID := domain.NewID()
+	searchResultID := domain.NewID()
+
+	mock.ExpectQuery("SELECT id FROM pgr_news").
+		WithArgs("https://pgr.gob.do/noticias/novasco", "https://pgr.gob.do/noticias/novasco/").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(pgrID.String()))
+	mock.ExpectExec("INSERT INTO url_sources").
+		WithArgs(sqlmock.AnyArg(), "https://pgr.gob.do/noticias/novasco/", "pgr_news", pgrID, string(domain.DomainTypeGoogleDorking), searchResultID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repos.GetDockingRepository().CreateWithDomainSearchResultID(context.Background(), domain.GoogleDorkingResult{
+		DomainSearchResultID: searchResultID,
+		URL:                  "https://pgr.gob.do/noticias/novasco/",
+		Title:                "PGR somete a Novasco",
+	})
+	if err != nil {
+		t.Fatalf("CreateWithDomainSearchResultID returned error: %v", err)
+	}
+
+	// An INSERT into google_docking_results would be an unexpected call
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDockingCreateInsertsNewURL(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	mock.ExpectQuery("SELECT id FROM pgr_news").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO google_docking_results").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repos.GetDockingRepository().CreateWithDomainSearchResultID(context.Background(), domain.GoogleDorkingResult{
+		DomainSearchResultID: domain.NewID(),
+		URL:                  "https://example.com/novasco",
+	})
+	if err != nil {
+		t.Fatalf("CreateWithDomainSearchResultID returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDockingGetKeywordsByCategory(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	id := domain.NewID()
+	now := time.Now()
+	mock.ExpectQuery("FROM google_docking_results\\s+WHERE id = \\?").
+		WithArgs(id.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "domain_search_result_id", "url", "title", "description", "relevance", "search_rank", "keywords", "created_at", "updated_at"}).
+			AddRow(id.String(), domain.NewID().String(), "https://facebook.com/novasco", "Denuncia contra Novasco Real Estate S.R.L.",
+				"El Lic. Juan Perez representa a la empresa, con oficinas en Av. Winston Churchill 1099.", 0.8, 1, `["fraude"]`, now, now))
+
+	keywords, err := repos.GetDockingRepository().GetKeywordsByCategory(context.Background(), id.String())
+	if err != nil {
+		t.Fatalf("GetKeywordsByCategory returned error: %v", err)
+	}
+	if got := keywords[domain.KeywordCategoryCompanyName]; len(got) != 1 || got[0] != "Novasco Real Estate S.R.L." {
+		t.Errorf("unexpected company names %v", got)
+	}
+	if got := keywords[domain.KeywordCategoryAddress]; len(got) != 1 || got[0] != "Av. Winston Churchill 1099" {
+		t.Errorf("unexpected addresses %v", got)
+	}
+	if got := keywords[domain.KeywordCategorySocialMedia]; len(got) != 1 {
+		t.Errorf("expected the profile URL among the social media keywords, got %v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"insightful-intel/internal/database"
 	"insightful-intel/internal/domain"
 )
@@ -12,11 +13,11 @@ type DomainRepositoryUnion interface {
 
 // DomainRepositoryHandler provides a way to work with any domain repository
 type DomainRepositoryHandler struct {
-	GetOnapiRepository   func() *OnapiRepository
-	GetScjRepository     func() *ScjRepository
-	GetDgiiRepository    func() *DgiiRepository
-	GetPgrRepository     func() *PgrRepository
-	GetDockingRepository func() *DockingRepository
+	GetOnapiRepository   func() OnapiStore
+	GetScjRepository     func() ScjStore
+	GetDgiiRepository    func() DgiiStore
+	GetPgrRepository     func() PgrStore
+	GetDockingRepository func() DockingStore
 }
 
 func (h *DomainRepositoryHandler) GetDomainTypeRepo(domainType domain.DomainType) any {
@@ -35,46 +36,123 @@ func (h *DomainRepositoryHandler) GetDomainTypeRepo(domainType domain.DomainType
 	return nil
 }
 
+var _ Factory = &RepositoryFactory{}
+
 // RepositoryFactory provides a centralized way to create repository instances
 type RepositoryFactory struct {
 	db database.Service
+
+	// tx is the transaction the repositories run their statements in, if any
+	tx DatabaseAccessor
+
+	// memory holds the records instead of db when DB_DRIVER is memory
+	memory *memoryStore
 }
 
-// NewRepositoryFactory creates a new repository factory
+// NewRepositoryFactory creates a new repository factory. With DB_DRIVER set to
+// memory, its repositories keep their records in memory and db is not used.
 func NewRepositoryFactory(db database.Service) *RepositoryFactory {
+	if database.Driver() == database.DriverMemory {
+		return NewMemoryRepositoryFactory()
+	}
 	return &RepositoryFactory{
 		db: db,
 	}
 }
 
+// NewMemoryRepositoryFactory creates a repository factory whose repositories
+// keep their records in memory, shared by every repository it returns
+func NewMemoryRepositoryFactory() *RepositoryFactory {
+	return &RepositoryFactory{
+		memory: newMemoryStore(),
+	}
+}
+
+// WithTx runs fn with a factory whose repositories run their statements in a
+// single transaction, committed when fn returns nil and rolled back otherwise.
+// Called on a factory already in a transaction, fn joins it. In memory there
+// are no transactions: fn runs on the factory itself and nothing it stored is
+// rolled back.
+func (f *RepositoryFactory) WithTx(ctx context.Context, fn func(repos Factory) error) error {
+	if f.memory != nil {
+		return fn(f)
+	}
+	return f.accessor().WithTx(ctx, func(tx DatabaseAccessor) error {
+		return fn(&RepositoryFactory{db: f.db, tx: tx})
+	})
+}
+
+// accessor returns what the repositories run their statements on: the
+// transaction of the factory, or the database
+func (f *RepositoryFactory) accessor() DatabaseAccessor {
+	if f.tx != nil {
+		return f.tx
+	}
+	return NewDatabaseAdapter(f.db)
+}
+
 // GetOnapiRepository returns an ONAPI repository instance
-func (f *RepositoryFactory) GetOnapiRepository() *OnapiRepository {
-	return NewOnapiRepository(f.db)
+func (f *RepositoryFactory) GetOnapiRepository() OnapiStore {
+	if f.memory != nil {
+		return &memoryOnapiRepository{store: f.memory}
+	}
+	return &OnapiRepository{db: f.accessor()}
 }
 
 // GetScjRepository returns an SCJ repository instance
-func (f *RepositoryFactory) GetScjRepository() *ScjRepository {
-	return NewScjRepository(f.db)
+func (f *RepositoryFactory) GetScjRepository() ScjStore {
+	if f.memory != nil {
+		return &memoryScjRepository{store: f.memory}
+	}
+	return &ScjRepository{db: f.accessor()}
 }
 
 // GetDgiiRepository returns a DGII repository instance
-func (f *RepositoryFactory) GetDgiiRepository() *DgiiRepository {
-	return NewDgiiRepository(f.db)
+func (f *RepositoryFactory) GetDgiiRepository() DgiiStore {
+	if f.memory != nil {
+		return &memoryDgiiRepository{store: f.memory}
+	}
+	return &DgiiRepository{db: f.accessor()}
 }
 
 // GetPgrRepository returns a PGR repository instance
-func (f *RepositoryFactory) GetPgrRepository() *PgrRepository {
-	return NewPgrRepository(f.db)
+func (f *RepositoryFactory) GetPgrRepository() PgrStore {
+	if f.memory != nil {
+		return &memoryPgrRepository{store: f.memory}
+	}
+	return &PgrRepository{db: f.accessor()}
 }
 
 // GetDockingRepository returns a Google Docking repository instance
-func (f *RepositoryFactory) GetDockingRepository() *DockingRepository {
-	return NewDockingRepository(f.db)
+func (f *RepositoryFactory) GetDockingRepository() DockingStore {
+	if f.memory != nil {
+		return &memoryDockingRepository{store: f.memory}
+	}
+	return &DockingRepository{db: f.accessor()}
+}
+
+// GetURLSourceRepository returns a URL source repository instance
+func (f *RepositoryFactory) GetURLSourceRepository() URLSourceStore {
+	if f.memory != nil {
+		return &memoryURLSourceRepository{store: f.memory}
+	}
+	return &URLSourceRepository{db: f.accessor()}
+}
+
+// GetSessionRepository returns an investigation session repository instance
+func (f *RepositoryFactory) GetSessionRepository() SessionStore {
+	if f.memory != nil {
+		return &memorySessionRepository{store: f.memory}
+	}
+	return &SessionRepository{db: f.accessor()}
 }
 
 // GetPipelineRepository returns a pipeline repository instance
-func (f *RepositoryFactory) GetPipelineRepository() *PipelineRepository {
-	return NewPipelineRepository(f.db)
+func (f *RepositoryFactory) GetPipelineRepository() PipelineStore {
+	if f.memory != nil {
+		return &memoryPipelineRepository{store: f.memory}
+	}
+	return &PipelineRepository{db: f.accessor()}
 }
 
 // GetAllDomainRepositories returns all domain repositories
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"insightful-intel/internal/domain"
+	"time"
 )
 
 // DatabaseAccessor provides access to the underlying database connection
@@ -11,6 +12,11 @@ type DatabaseAccessor interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+
+	// WithTx runs fn with an accessor whose statements run in a transaction,
+	// committed when fn returns nil and rolled back otherwise. Inside a
+	// transaction, fn joins it.
+	WithTx(ctx context.Context, fn func(tx DatabaseAccessor) error) error
 }
 
 // BaseRepository defines common operations for all repositories
@@ -75,3 +81,126 @@ type PipelineResultRepository interface {
 	// GetKeywordsByCategory retrieves keywords grouped by category for a pipeline result
 	GetKeywordsByCategory(ctx context.Context, resultID string) (map[domain.KeywordCategory][]string, error)
 }
+
+// OnapiStore stores the ONAPI entities, implemented by OnapiRepository
+type OnapiStore interface {
+	Create(ctx context.Context, entity domain.Entity) error
+	Upsert(ctx context.Context, entity domain.Entity) error
+	GetByID(ctx context.Context, id string) (domain.Entity, error)
+	Update(ctx context.Context, id string, entity domain.Entity) error
+	Delete(ctx context.Context, id string) error
+	ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.Entity, error)
+	Count(ctx context.Context) (int64, error)
+	Search(ctx context.Context, query string, offset, limit int) ([]domain.Entity, error)
+	SearchWithMode(ctx context.Context, query string, mode MatchMode, offset, limit int) ([]domain.Entity, error)
+	FindByPerson(ctx context.Context, name string) ([]domain.Entity, error)
+}
+
+// ScjStore stores the SCJ cases, implemented by ScjRepository
+type ScjStore interface {
+	Create(ctx context.Context, entity domain.ScjCase) error
+	GetByID(ctx context.Context, id string) (domain.ScjCase, error)
+	ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.ScjCase, error)
+	ListByFechaFallo(ctx context.Context, from, to time.Time, offset, limit int) ([]domain.ScjCase, error)
+	Count(ctx context.Context) (int64, error)
+	Search(ctx context.Context, query string, offset, limit int) ([]domain.ScjCase, error)
+	GetKeywordsByCategory(ctx context.Context, entityID string) (map[domain.KeywordCategory][]string, error)
+}
+
+// DgiiStore stores the DGII registers, implemented by DgiiRepository
+type DgiiStore interface {
+	Create(ctx context.Context, entity domain.Register) error
+	Upsert(ctx context.Context, entity domain.Register) (domain.ID, error)
+	GetByID(ctx context.Context, id string) (domain.Register, error)
+	GetByRNC(ctx context.Context, rnc string) (domain.Register, error)
+	Delete(ctx context.Context, id string) error
+	ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.Register, error)
+	Count(ctx context.Context) (int64, error)
+	Search(ctx context.Context, query string, offset, limit int) ([]domain.Register, error)
+}
+
+// PgrStore stores the PGR news, implemented by PgrRepository
+type PgrStore interface {
+	Create(ctx context.Context, entity domain.PGRNews) error
+	GetByID(ctx context.Context, id string) (domain.PGRNews, error)
+	GetByURL(ctx context.Context, url string) (domain.PGRNews, error)
+	ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.PGRNews, error)
+	Count(ctx context.Context) (int64, error)
+	Search(ctx context.Context, query string, offset, limit int) ([]domain.PGRNews, error)
+}
+
+// DockingStore stores the docking results, implemented by DockingRepository
+type DockingStore interface {
+	Create(ctx context.Context, entity domain.GoogleDorkingResult) error
+	CreateWithDomainSearchResultID(ctx context.Context, entity domain.GoogleDorkingResult) error
+	GetByID(ctx context.Context, id string) (domain.GoogleDorkingResult, error)
+	ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.GoogleDorkingResult, error)
+	Count(ctx context.Context) (int64, error)
+	Search(ctx context.Context, query string, offset, limit int) ([]domain.GoogleDorkingResult, error)
+	GetKeywordsByCategory(ctx context.Context, entityID string) (map[domain.KeywordCategory][]string, error)
+}
+
+// PipelineStore stores the pipelines, their steps, search results, events and
+// spilled queues, implemented by PipelineRepository
+type PipelineStore interface {
+	CreateDynamicPipelineResult(ctx context.Context, result *domain.DynamicPipelineResult) (*domain.DynamicPipelineResult, error)
+	UpdateDynamicPipelineResult(ctx context.Context, result *domain.DynamicPipelineResult) error
+	GetPipelineByID(ctx context.Context, id string) (*domain.DynamicPipelineResult, error)
+	GetPipelinesBySessionID(ctx context.Context, sessionID string) ([]*domain.DynamicPipelineResult, error)
+	ListSummariesWithOptions(ctx context.Context, opts ListOptions) ([]domain.PipelineSummary, error)
+	CountPipelines(ctx context.Context) (int64, error)
+
+	CreateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error
+	UpdateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error
+	GetPipelineStepsByID(ctx context.Context, id string) ([]domain.DynamicPipelineStep, error)
+	GetStepKeywordsByPipelineID(ctx context.Context, pipelineID string, category domain.KeywordCategory) ([]domain.StepKeyword, error)
+	GetPreviouslySeenKeywords(ctx context.Context, pipelineID string) (map[string]bool, error)
+
+	CreateDomainSearchResult(ctx context.Context, result *domain.DomainSearchResult) (*domain.DomainSearchResult, error)
+	// GetByID retrieves a domain search result, or else a pipeline, by its ID
+	GetByID(ctx context.Context, id string) (any, error)
+	// Update modifies a domain search result or a pipeline
+	Update(ctx context.Context, id string, entity any) error
+	GetKeywordsByCategory(ctx context.Context, resultID string) (map[domain.KeywordCategory][]string, error)
+	GetCamaraRecordsByPerson(ctx context.Context, name string) ([]domain.CamaraRecord, error)
+
+	CreatePipelineEvent(ctx context.Context, event *domain.PipelineEvent) error
+	ListPipelineEvents(ctx context.Context, pipelineID string) ([]domain.PipelineEvent, error)
+
+	SpillQueuedSteps(ctx context.Context, pipelineID domain.ID, steps []domain.DynamicPipelineStep) error
+	DrainQueuedSteps(ctx context.Context, pipelineID domain.ID, limit int, depthFirst bool) ([]domain.DynamicPipelineStep, error)
+	ClearQueuedSteps(ctx context.Context, pipelineID domain.ID) error
+
+	CompactOutputs(ctx context.Context, minBytes, limit int) (CompactionStats, error)
+}
+
+// SessionStore stores the investigation sessions, implemented by
+// SessionRepository
+type SessionStore interface {
+	Create(ctx context.Context, session *domain.Session) error
+	GetByID(ctx context.Context, id string) (*domain.Session, error)
+}
+
+// URLSourceStore records the domains and pipelines each URL was found by,
+// implemented by URLSourceRepository
+type URLSourceStore interface {
+	Link(ctx context.Context, source domain.URLSource) error
+	GetByURL(ctx context.Context, url string) ([]domain.URLSource, error)
+}
+
+// Factory provides the repositories, implemented by RepositoryFactory. The
+// interactors depend on it so tests can supply their own repositories.
+type Factory interface {
+	// WithTx runs fn with repositories whose statements run in a single
+	// transaction, committed when fn returns nil and rolled back otherwise
+	WithTx(ctx context.Context, fn func(repos Factory) error) error
+
+	GetOnapiRepository() OnapiStore
+	GetScjRepository() ScjStore
+	GetDgiiRepository() DgiiStore
+	GetPgrRepository() PgrStore
+	GetDockingRepository() DockingStore
+	GetURLSourceRepository() URLSourceStore
+	GetSessionRepository() SessionStore
+	GetPipelineRepository() PipelineStore
+}
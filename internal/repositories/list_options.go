@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"insightful-intel/internal/domain"
+)
+
+// Sort orders of ListOptions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ErrInvalidListOptions is returned when ListOptions sort by a column the
+// repository does not allow, in an unknown order, or restrict the records to an
+// empty range
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// ListOptions page, sort and filter the records ListWithOptions returns
+type ListOptions struct {
+	Offset int
+	Limit  int
+	// Sort is the column to sort by, one of the sortable columns of the
+	// repository. Empty sorts by the default column of the repository.
+	Sort string
+	// Order is SortAsc or SortDesc, descending when empty
+	Order string
+	// Since and Until restrict the creation time of the records, both included
+	// and either unbounded when zero
+	Since time.Time
+	Until time.Time
+	// DomainType, when set, restricts the records to the ones found by searches
+	// of the domain
+	DomainType domain.DomainType
+}
+
+// searchResultDomainFilter matches the records whose search result is of a
+// domain type
+const searchResultDomainFilter = "domain_search_result_id IN (SELECT id FROM domain_search_results WHERE domain_type = ?)"
+
+// listQuery describes how ListOptions apply to a table
+type listQuery struct {
+	// sortable are the columns the records may be sorted by, the first being the
+	// default. Only these are ever written into a query.
+	sortable []string
+	// domainFilter is the condition matching the records of a domain type, with
+	// the domain type as its only placeholder
+	domainFilter string
+}
+
+// clauses returns the WHERE, ORDER BY, LIMIT and OFFSET clauses of a list
+// query with their arguments
+func (q listQuery) clauses(opts ListOptions) (string, []any, error) {
+	sort := opts.Sort
+	if sort == "" {
+		sort = q.sortable[0]
+	}
+	if !slices.Contains(q.sortable, sort) {
+		return "", nil, fmt.Errorf("%w: cannot sort by %q, sortable columns are %s", ErrInvalidListOptions, sort, strings.Join(q.sortable, ", "))
+	}
+
+	order := strings.ToLower(opts.Order)
+	switch order {
+	case "":
+		order = SortDesc
+	case SortAsc, SortDesc:
+	default:
+		return "", nil, fmt.Errorf("%w: unknown order %q", ErrInvalidListOptions, opts.Order)
+	}
+
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && opts.Since.After(opts.Until) {
+		return "", nil, fmt.Errorf("%w: since is after until", ErrInvalidListOptions)
+	}
+
+	var conditions []string
+	var args []any
+	if !opts.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, opts.Until)
+	}
+	if opts.DomainType != "" {
+		conditions = append(conditions, q.domainFilter)
+		args = append(args, string(opts.DomainType))
+	}
+
+	var clauses strings.Builder
+	if len(conditions) > 0 {
+		clauses.WriteString("WHERE " + strings.Join(conditions, " AND ") + "\n")
+	}
+	clauses.WriteString("ORDER BY " + sort + " " + strings.ToUpper(order))
+	// Records sorted by anything else are still listed newest first among equals
+	if sort != "created_at" {
+		clauses.WriteString(", created_at DESC")
+	}
+	clauses.WriteString("\nLIMIT ? OFFSET ?")
+
+	return clauses.String(), append(args, opts.Limit, opts.Offset), nil
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestListOptionsRejectUnknownSortColumns(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	for _, sort := range []string{"password", "created_at; DROP TABLE dgii_registers", "rnc DESC, (SELECT 1)"} {
+		_, err := repos.GetDgiiRepository().ListWithOptions(context.Background(), ListOptions{Sort: sort, Limit: 10})
+		if !errors.Is(err, ErrInvalidListOptions) {
+			t.Errorf("expected sorting by %q to be rejected, got %v", sort, err)
+		}
+	}
+
+	if _, err := repos.GetDgiiRepository().ListWithOptions(context.Background(), ListOptions{Order: "sideways", Limit: 10}); !errors.Is(err, ErrInvalidListOptions) {
+		t.Errorf("expected an unknown order to be rejected, got %v", err)
+	}
+
+	since := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := repos.GetDgiiRepository().ListWithOptions(context.Background(), ListOptions{Since: since, Until: since.AddDate(0, 0, -1), Limit: 10}); !errors.Is(err, ErrInvalidListOptions) {
+		t.Errorf("expected since after until to be rejected, got %v", err)
+	}
+
+	// Rejected options never reach the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListQueryClauses(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC)
+
+	clauses, args, err := dockingListQuery.clauses(ListOptions{
+		Sort:       "search_rank",
+		Order:      "ASC",
+		Since:      since,
+		Until:      until,
+		DomainType: domain.DomainTypeSocialMedia,
+		Offset:     20,
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatalf("clauses returned error: %v", err)
+	}
+
+	want := "WHERE created_at >= ? AND created_at <= ? AND " + searchResultDomainFilter + "\n" +
+		"ORDER BY search_rank ASC, created_at DESC\n" +
+		"LIMIT ? OFFSET ?"
+	if clauses != want {
+		t.Errorf("unexpected clauses:\n%s\nwant:\n%s", clauses, want)
+	}
+	wantArgs := []any{since, until, string(domain.DomainTypeSocialMedia), 10, 20}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("expected args %v, got %v", wantArgs, args)
+	}
+	for i := range args {
+		if args[i] != wantArgs[i] {
+			t.Errorf("arg %d: expected %v, got %v", i, wantArgs[i], args[i])
+		}
+	}
+
+	// Without options, docking results keep their relevance order
+	clauses, _, err = dockingListQuery.clauses(ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("clauses returned error: %v", err)
+	}
+	if !strings.HasPrefix(clauses, "ORDER BY relevance DESC, created_at DESC") {
+		t.Errorf("expected the default relevance order, got %q", clauses)
+	}
+}
+
+func TestPgrListWithOptions(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM pgr_news\s+WHERE created_at >= \?\s+ORDER BY published_at ASC, created_at DESC\s+LIMIT \? OFFSET \?`).
+		WithArgs(since, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "domain_search_result_id", "url", "title", "published_at", "created_at", "updated_at"}).
+			AddRow(domain.NewID().String(), domain.NewID().String(), "https://pgr.gob.do/noticia", "Noticia", since, since, since))
+
+	news, err := repos.GetPgrRepository().ListWithOptions(context.Background(), ListOptions{
+		Sort:  "published_at",
+		Order: SortAsc,
+		Since: since,
+		Limit: 5,
+	})
+	if err != nil {
+		t.Fatalf("ListWithOptions returned error: %v", err)
+	}
+	if len(news) != 1 {
+		t.Fatalf("expected 1 news item, got %d", len(news))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
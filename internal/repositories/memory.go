@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"cmp"
+	"encoding/json"
+	"insightful-intel/internal/domain"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore holds the records of the in-memory repositories, the storage of
+// DB_DRIVER=memory. Its records live as long as the process, and it is safe for
+// concurrent use.
+type memoryStore struct {
+	mu sync.RWMutex
+
+	onapi      *memoryTable[domain.Entity]
+	scj        *memoryTable[domain.ScjCase]
+	dgii       *memoryTable[domain.Register]
+	pgr        *memoryTable[domain.PGRNews]
+	docking    *memoryTable[domain.GoogleDorkingResult]
+	sessions   *memoryTable[domain.Session]
+	urlSources *memoryTable[domain.URLSource]
+
+	pipelines     *memoryTable[domain.DynamicPipelineResult]
+	steps         *memoryTable[memoryStep]
+	searchResults *memoryTable[domain.DomainSearchResult]
+	events        []domain.PipelineEvent
+	queue         []memoryQueuedStep
+
+	// seq numbers the pipeline events and queued steps like the
+	// AUTO_INCREMENT columns of their tables
+	seq int64
+	now func() time.Time
+}
+
+// memoryStep is a pipeline step as stored, with the pipeline it belongs to
+type memoryStep struct {
+	pipelineID domain.ID
+	step       domain.DynamicPipelineStep
+}
+
+// memoryQueuedStep is a step spilled by a pipeline
+type memoryQueuedStep struct {
+	seq        int64
+	pipelineID domain.ID
+	step       domain.DynamicPipelineStep
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		onapi:         newMemoryTable[domain.Entity](),
+		scj:           newMemoryTable[domain.ScjCase](),
+		dgii:          newMemoryTable[domain.Register](),
+		pgr:           newMemoryTable[domain.PGRNews](),
+		docking:       newMemoryTable[domain.GoogleDorkingResult](),
+		sessions:      newMemoryTable[domain.Session](),
+		urlSources:    newMemoryTable[domain.URLSource](),
+		pipelines:     newMemoryTable[domain.DynamicPipelineResult](),
+		steps:         newMemoryTable[memoryStep](),
+		searchResults: newMemoryTable[domain.DomainSearchResult](),
+		now:           time.Now,
+	}
+}
+
+// searchResultDomain returns the domain type of a stored search result, the
+// domain the records stored from it were found by
+func (s *memoryStore) searchResultDomain(id domain.ID) domain.DomainType {
+	result, ok := s.searchResults.get(id.String())
+	if !ok {
+		return ""
+	}
+	return result.DomainType
+}
+
+// memoryTable holds records by ID in the order they were inserted
+type memoryTable[T any] struct {
+	records map[domain.ID]T
+	order   []domain.ID
+}
+
+func newMemoryTable[T any]() *memoryTable[T] {
+	return &memoryTable[T]{records: map[domain.ID]T{}}
+}
+
+// put inserts the record, or replaces the record with the same ID in place
+func (t *memoryTable[T]) put(id domain.ID, record T) {
+	if _, ok := t.records[id]; !ok {
+		t.order = append(t.order, id)
+	}
+	t.records[id] = record
+}
+
+// get returns the record of an ID, which like in SQL may be any string
+func (t *memoryTable[T]) get(id string) (T, bool) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		var zero T
+		return zero, false
+	}
+	record, ok := t.records[parsed]
+	return record, ok
+}
+
+func (t *memoryTable[T]) delete(id string) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return
+	}
+	if _, ok := t.records[parsed]; !ok {
+		return
+	}
+	delete(t.records, parsed)
+	t.order = slices.DeleteFunc(t.order, func(stored domain.ID) bool { return stored == parsed })
+}
+
+// all returns the records oldest first
+func (t *memoryTable[T]) all() []T {
+	records := make([]T, 0, len(t.order))
+	for _, id := range t.order {
+		records = append(records, t.records[id])
+	}
+	return records
+}
+
+// newestFirst returns the records newest first, the order of the ORDER BY
+// created_at DESC queries
+func (t *memoryTable[T]) newestFirst() []T {
+	records := t.all()
+	slices.Reverse(records)
+	return records
+}
+
+func (t *memoryTable[T]) len() int {
+	return len(t.order)
+}
+
+// memoryColumn reads a sortable column of a record, as one of the types
+// compareColumns compares
+type memoryColumn[T any] func(record T, column string) any
+
+// listMemory applies opts to the records, given oldest first, like listQuery
+// applies them to a table. inDomain tells whether a record was found by a
+// domain.
+func listMemory[T any](records []T, q listQuery, opts ListOptions, column memoryColumn[T], inDomain func(T, domain.DomainType) bool) ([]T, error) {
+	if _, _, err := q.clauses(opts); err != nil {
+		return nil, err
+	}
+
+	sort := opts.Sort
+	if sort == "" {
+		sort = q.sortable[0]
+	}
+	descending := strings.ToLower(opts.Order) != SortAsc
+
+	var listed []T
+	for _, record := range records {
+		createdAt, _ := column(record, "created_at").(time.Time)
+		if !opts.Since.IsZero() && createdAt.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && createdAt.After(opts.Until) {
+			continue
+		}
+		if opts.DomainType != "" && !inDomain(record, opts.DomainType) {
+			continue
+		}
+		listed = append(listed, record)
+	}
+
+	// Records sorted by anything else are still listed newest first among equals
+	slices.Reverse(listed)
+	slices.SortStableFunc(listed, func(a, b T) int {
+		order := compareColumns(column(a, sort), column(b, sort))
+		if descending {
+			order = -order
+		}
+		if order == 0 && sort != "created_at" {
+			return -compareColumns(column(a, "created_at"), column(b, "created_at"))
+		}
+		return order
+	})
+
+	return page(listed, opts.Offset, opts.Limit), nil
+}
+
+// compareColumns compares two values of a column, strings regardless of case
+// like the collation of the database
+func compareColumns(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	case int:
+		b, _ := b.(int)
+		return cmp.Compare(a, b)
+	case int32:
+		b, _ := b.(int32)
+		return cmp.Compare(a, b)
+	case float64:
+		b, _ := b.(float64)
+		return cmp.Compare(a, b)
+	case time.Time:
+		b, _ := b.(time.Time)
+		return a.Compare(b)
+	}
+	return 0
+}
+
+// page returns the records of a LIMIT and OFFSET
+func page[T any](records []T, offset, limit int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(records) || limit <= 0 {
+		return nil
+	}
+	return records[offset:min(offset+limit, len(records))]
+}
+
+// foldForMatch folds text the way the database compares it, regardless of case
+// and accents
+func foldForMatch(text string) string {
+	return strings.ToLower(domain.FoldAccents(text))
+}
+
+// memoryContains reports whether query appears in any of fields, like a LIKE
+// '%query%' condition on them
+func memoryContains(query string, fields ...string) bool {
+	query = foldForMatch(query)
+	for _, field := range fields {
+		if strings.Contains(foldForMatch(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryTokensMatch reports whether the words of query appear in fields like
+// tokenCondition matches them: every word with MatchAll, one of them with
+// MatchAny, each in any of the fields
+func memoryTokensMatch(query string, mode MatchMode, fields ...string) bool {
+	tokens := strings.Fields(query)
+	if len(tokens) == 0 {
+		return true
+	}
+
+	for _, token := range tokens {
+		found := memoryContains(token, fields...)
+		if found && mode == MatchAny {
+			return true
+		}
+		if !found && mode != MatchAny {
+			return false
+		}
+	}
+	return mode != MatchAny
+}
+
+// stored returns a copy of value as it reads back from the JSON columns it is
+// stored in, so the memory repositories share nothing with their callers and
+// answer what the database would
+func stored[T any](value T) T {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded T
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
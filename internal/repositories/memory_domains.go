@@ -0,0 +1,618 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/module"
+	"slices"
+	"time"
+)
+
+var (
+	_ OnapiStore   = &memoryOnapiRepository{}
+	_ ScjStore     = &memoryScjRepository{}
+	_ DgiiStore    = &memoryDgiiRepository{}
+	_ PgrStore     = &memoryPgrRepository{}
+	_ DockingStore = &memoryDockingRepository{}
+)
+
+// memoryOnapiRepository stores the ONAPI entities in memory
+type memoryOnapiRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new ONAPI entity
+func (r *memoryOnapiRepository) Create(ctx context.Context, entity domain.Entity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.insert(entity)
+	return nil
+}
+
+func (r *memoryOnapiRepository) insert(entity domain.Entity) {
+	entity = stored(entity)
+	entity.ID = domain.NewID()
+	entity.CreatedAt = r.store.now()
+	entity.UpdatedAt = entity.CreatedAt
+	r.store.onapi.put(entity.ID, entity)
+}
+
+// Upsert stores an ONAPI entity, updating the stored entity with the same
+// serie and numero expediente instead of inserting it again
+func (r *memoryOnapiRepository) Upsert(ctx context.Context, entity domain.Entity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.onapi.all() {
+		if existing.SerieExpediente == entity.SerieExpediente && existing.NumeroExpediente == entity.NumeroExpediente {
+			r.update(existing, entity)
+			return nil
+		}
+	}
+
+	r.insert(entity)
+	return nil
+}
+
+// update replaces a stored entity, keeping its ID and creation time
+func (r *memoryOnapiRepository) update(existing, entity domain.Entity) {
+	entity = stored(entity)
+	entity.ID = existing.ID
+	entity.CreatedAt = existing.CreatedAt
+	entity.UpdatedAt = r.store.now()
+	r.store.onapi.put(entity.ID, entity)
+}
+
+// GetByID retrieves an ONAPI entity by its ID
+func (r *memoryOnapiRepository) GetByID(ctx context.Context, id string) (domain.Entity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entity, ok := r.store.onapi.get(id)
+	if !ok {
+		return domain.Entity{}, sql.ErrNoRows
+	}
+	return stored(entity), nil
+}
+
+// Update modifies an existing ONAPI entity
+func (r *memoryOnapiRepository) Update(ctx context.Context, id string, entity domain.Entity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if existing, ok := r.store.onapi.get(id); ok {
+		r.update(existing, entity)
+	}
+	return nil
+}
+
+// Delete removes an ONAPI entity by its ID
+func (r *memoryOnapiRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.onapi.delete(id)
+	return nil
+}
+
+// ListWithOptions retrieves the ONAPI entities sorted and filtered by opts
+func (r *memoryOnapiRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.Entity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entities, err := listMemory(r.store.onapi.all(), onapiListQuery, opts, func(entity domain.Entity, column string) any {
+		switch column {
+		case "created_at":
+			return entity.CreatedAt
+		case "updated_at":
+			return entity.UpdatedAt
+		case "serie_expediente":
+			return entity.SerieExpediente
+		case "numero_expediente":
+			return entity.NumeroExpediente
+		case "texto":
+			return entity.Texto
+		case "titular":
+			return entity.Titular
+		case "status":
+			return entity.Status
+		}
+		return nil
+	}, func(entity domain.Entity, domainType domain.DomainType) bool {
+		return r.store.searchResultDomain(entity.DomainSearchResultID) == domainType
+	})
+	return stored(entities), err
+}
+
+// Count returns the total number of ONAPI entities
+func (r *memoryOnapiRepository) Count(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(r.store.onapi.len()), nil
+}
+
+// Search matches the ONAPI entities holding every word of the query, accents
+// aside
+func (r *memoryOnapiRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.Entity, error) {
+	return r.SearchWithMode(ctx, query, MatchAll, offset, limit)
+}
+
+// SearchWithMode searches ONAPI entities for the words of query, each word
+// in any of the searched fields, combined by mode
+func (r *memoryOnapiRepository) SearchWithMode(ctx context.Context, query string, mode MatchMode, offset, limit int) ([]domain.Entity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entities []domain.Entity
+	for _, entity := range r.store.onapi.newestFirst() {
+		if memoryTokensMatch(query, mode, entity.Texto, entity.Titular, entity.Gestor, entity.Domicilio) {
+			entities = append(entities, entity)
+		}
+	}
+	return stored(page(entities, offset, limit)), nil
+}
+
+// FindByPerson retrieves the ONAPI entities whose titular or gestor mentions
+// the given name
+func (r *memoryOnapiRepository) FindByPerson(ctx context.Context, name string) ([]domain.Entity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entities []domain.Entity
+	for _, entity := range r.store.onapi.newestFirst() {
+		if memoryContains(name, entity.Titular, entity.Gestor) {
+			entities = append(entities, entity)
+		}
+	}
+	return stored(entities), nil
+}
+
+// memoryScjRepository stores the SCJ cases in memory
+type memoryScjRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new SCJ case
+func (r *memoryScjRepository) Create(ctx context.Context, entity domain.ScjCase) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entity = stored(entity)
+	entity.ID = domain.NewID()
+	entity.CreatedAt = r.store.now()
+	entity.UpdatedAt = entity.CreatedAt
+	r.store.scj.put(entity.ID, entity)
+	return nil
+}
+
+// GetByID retrieves an SCJ case by its ID
+func (r *memoryScjRepository) GetByID(ctx context.Context, id string) (domain.ScjCase, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entity, ok := r.store.scj.get(id)
+	if !ok {
+		return domain.ScjCase{}, sql.ErrNoRows
+	}
+	return stored(entity), nil
+}
+
+// ListWithOptions retrieves the SCJ cases sorted and filtered by opts
+func (r *memoryScjRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.ScjCase, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	cases, err := listMemory(r.store.scj.all(), scjListQuery, opts, func(entity domain.ScjCase, column string) any {
+		switch column {
+		case "created_at":
+			return entity.CreatedAt
+		case "updated_at":
+			return entity.UpdatedAt
+		case "fecha_fallo":
+			return entity.FechaFallo
+		case "id_expediente":
+			return entity.IDExpediente
+		case "desc_tribunal":
+			return entity.DescTribunal
+		case "desc_materia":
+			return entity.DescMateria
+		}
+		return nil
+	}, func(entity domain.ScjCase, domainType domain.DomainType) bool {
+		return r.store.searchResultDomain(entity.DomainSearchResultID) == domainType
+	})
+	return stored(cases), err
+}
+
+// ListByFechaFallo retrieves the SCJ cases ruled between from and to, either
+// unbounded when zero, latest ruling first
+func (r *memoryScjRepository) ListByFechaFallo(ctx context.Context, from, to time.Time, offset, limit int) ([]domain.ScjCase, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var cases []domain.ScjCase
+	for _, entity := range r.store.scj.newestFirst() {
+		if entity.FechaFallo.IsZero() ||
+			(!from.IsZero() && entity.FechaFallo.Before(from)) ||
+			(!to.IsZero() && entity.FechaFallo.After(to)) {
+			continue
+		}
+		cases = append(cases, entity)
+	}
+	slices.SortStableFunc(cases, func(a, b domain.ScjCase) int { return b.FechaFallo.Compare(a.FechaFallo) })
+	return stored(page(cases, offset, limit)), nil
+}
+
+// Count returns the total number of SCJ cases
+func (r *memoryScjRepository) Count(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(r.store.scj.len()), nil
+}
+
+// Search matches the SCJ cases whose numbers, parties, court or matter
+// contain the query, accents aside
+func (r *memoryScjRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.ScjCase, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var cases []domain.ScjCase
+	for _, entity := range r.store.scj.newestFirst() {
+		if memoryContains(query, entity.NoExpediente, entity.NoSentencia, entity.Involucrados, entity.DescTribunal, entity.DescMateria) {
+			cases = append(cases, entity)
+		}
+	}
+	return stored(page(cases, offset, limit)), nil
+}
+
+// GetKeywordsByCategory retrieves the keywords of an SCJ case
+func (r *memoryScjRepository) GetKeywordsByCategory(ctx context.Context, entityID string) (map[domain.KeywordCategory][]string, error) {
+	entity, err := r.GetByID(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[domain.KeywordCategory][]string{
+		domain.KeywordCategoryPersonName:  {entity.Involucrados},
+		domain.KeywordCategoryCompanyName: {entity.DescTribunal, entity.DescMateria},
+	}, nil
+}
+
+// memoryDgiiRepository stores the DGII registers in memory
+type memoryDgiiRepository struct {
+	store *memoryStore
+}
+
+// Create stores a DGII register like Upsert
+func (r *memoryDgiiRepository) Create(ctx context.Context, entity domain.Register) error {
+	_, err := r.Upsert(ctx, entity)
+	return err
+}
+
+// Upsert stores a DGII register, updating the stored register with the same
+// RNC instead of inserting it again. It returns the ID of the stored register.
+func (r *memoryDgiiRepository) Upsert(ctx context.Context, entity domain.Register) (domain.ID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entity = stored(entity)
+	entity.UpdatedAt = r.store.now()
+	for _, existing := range r.store.dgii.all() {
+		if existing.RNC == entity.RNC {
+			entity.ID = existing.ID
+			entity.CreatedAt = existing.CreatedAt
+			r.store.dgii.put(entity.ID, entity)
+			return entity.ID, nil
+		}
+	}
+
+	entity.ID = domain.NewID()
+	entity.CreatedAt = entity.UpdatedAt
+	r.store.dgii.put(entity.ID, entity)
+	return entity.ID, nil
+}
+
+// GetByID retrieves a DGII register by its ID
+func (r *memoryDgiiRepository) GetByID(ctx context.Context, id string) (domain.Register, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entity, ok := r.store.dgii.get(id)
+	if !ok {
+		return domain.Register{}, sql.ErrNoRows
+	}
+	return stored(entity), nil
+}
+
+// GetByRNC retrieves a DGII register by its RNC
+func (r *memoryDgiiRepository) GetByRNC(ctx context.Context, rnc string) (domain.Register, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, entity := range r.store.dgii.all() {
+		if entity.RNC == rnc {
+			return stored(entity), nil
+		}
+	}
+	return domain.Register{}, sql.ErrNoRows
+}
+
+// Delete removes a DGII register by its ID
+func (r *memoryDgiiRepository) Delete(ctx context.Context, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.dgii.delete(id)
+	return nil
+}
+
+// ListWithOptions retrieves the DGII registers sorted and filtered by opts
+func (r *memoryDgiiRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.Register, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	registers, err := listMemory(r.store.dgii.all(), dgiiListQuery, opts, func(entity domain.Register, column string) any {
+		switch column {
+		case "created_at":
+			return entity.CreatedAt
+		case "updated_at":
+			return entity.UpdatedAt
+		case "rnc":
+			return entity.RNC
+		case "razon_social":
+			return entity.RazonSocial
+		case "nombre_comercial":
+			return entity.NombreComercial
+		case "estado":
+			return entity.Estado
+		}
+		return nil
+	}, func(entity domain.Register, domainType domain.DomainType) bool {
+		return r.store.searchResultDomain(entity.DomainSearchResultID) == domainType
+	})
+	return stored(registers), err
+}
+
+// Count returns the total number of DGII registers
+func (r *memoryDgiiRepository) Count(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(r.store.dgii.len()), nil
+}
+
+// Search matches the DGII registers holding every word of the query
+func (r *memoryDgiiRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.Register, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var registers []domain.Register
+	for _, entity := range r.store.dgii.newestFirst() {
+		if memoryTokensMatch(query, MatchAll, entity.RNC, entity.RazonSocial, entity.NombreComercial, entity.Categoria, entity.Estado) {
+			registers = append(registers, entity)
+		}
+	}
+	return stored(page(registers, offset, limit)), nil
+}
+
+// memoryPgrRepository stores the PGR news in memory
+type memoryPgrRepository struct {
+	store *memoryStore
+}
+
+// Create stores a PGR news item, updating the stored item with the same URL
+// instead of inserting it again
+func (r *memoryPgrRepository) Create(ctx context.Context, entity domain.PGRNews) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entity = stored(entity)
+	entity.UpdatedAt = r.store.now()
+	entity.ID = domain.NewID()
+	entity.CreatedAt = entity.UpdatedAt
+	for _, existing := range r.store.pgr.all() {
+		if existing.URL == entity.URL {
+			entity.ID = existing.ID
+			entity.CreatedAt = existing.CreatedAt
+			break
+		}
+	}
+	r.store.pgr.put(entity.ID, entity)
+	return nil
+}
+
+// GetByID retrieves a PGR news item by its ID
+func (r *memoryPgrRepository) GetByID(ctx context.Context, id string) (domain.PGRNews, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entity, ok := r.store.pgr.get(id)
+	if !ok {
+		return domain.PGRNews{}, sql.ErrNoRows
+	}
+	return stored(entity), nil
+}
+
+// GetByURL retrieves a PGR news item by its URL
+func (r *memoryPgrRepository) GetByURL(ctx context.Context, url string) (domain.PGRNews, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, entity := range r.store.pgr.all() {
+		if entity.URL == url {
+			return stored(entity), nil
+		}
+	}
+	return domain.PGRNews{}, sql.ErrNoRows
+}
+
+// ListWithOptions retrieves the PGR news sorted and filtered by opts
+func (r *memoryPgrRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.PGRNews, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	news, err := listMemory(r.store.pgr.all(), pgrListQuery, opts, func(entity domain.PGRNews, column string) any {
+		switch column {
+		case "created_at":
+			return entity.CreatedAt
+		case "updated_at":
+			return entity.UpdatedAt
+		case "published_at":
+			return entity.PublishedAt
+		case "title":
+			return entity.Title
+		}
+		return nil
+	}, func(entity domain.PGRNews, domainType domain.DomainType) bool {
+		return r.store.searchResultDomain(entity.DomainSearchResultID) == domainType
+	})
+	return stored(news), err
+}
+
+// Count returns the total number of PGR news items
+func (r *memoryPgrRepository) Count(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(r.store.pgr.len()), nil
+}
+
+// Search matches the PGR news whose title or URL contain the query
+func (r *memoryPgrRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.PGRNews, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var news []domain.PGRNews
+	for _, entity := range r.store.pgr.newestFirst() {
+		if memoryContains(query, entity.Title, entity.URL) {
+			news = append(news, entity)
+		}
+	}
+	return stored(page(news, offset, limit)), nil
+}
+
+// memoryDockingRepository stores the docking results in memory
+type memoryDockingRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new docking result
+func (r *memoryDockingRepository) Create(ctx context.Context, entity domain.GoogleDorkingResult) error {
+	return r.CreateWithDomainSearchResultID(ctx, entity)
+}
+
+// CreateWithDomainSearchResultID inserts a new docking result. When the URL is
+// already stored as PGR news, the result is linked to that record instead of
+// duplicated.
+func (r *memoryDockingRepository) CreateWithDomainSearchResultID(ctx context.Context, entity domain.GoogleDorkingResult) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	withSlash, withoutSlash := urlVariants(entity.URL)
+	for _, news := range r.store.pgr.all() {
+		if news.URL == withSlash || news.URL == withoutSlash {
+			source := domain.URLSource{
+				ID:                   domain.NewID(),
+				URL:                  entity.URL,
+				RecordTable:          "pgr_news",
+				RecordID:             news.ID,
+				DomainType:           domain.DomainTypeGoogleDorking,
+				DomainSearchResultID: entity.DomainSearchResultID,
+				CreatedAt:            r.store.now(),
+			}
+			r.store.urlSources.put(source.ID, source)
+			return nil
+		}
+	}
+
+	entity = stored(entity)
+	entity.ID = domain.NewID()
+	entity.CreatedAt = r.store.now()
+	entity.UpdatedAt = entity.CreatedAt
+	r.store.docking.put(entity.ID, entity)
+	return nil
+}
+
+// GetByID retrieves a docking result by its ID
+func (r *memoryDockingRepository) GetByID(ctx context.Context, id string) (domain.GoogleDorkingResult, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	entity, ok := r.store.docking.get(id)
+	if !ok {
+		return domain.GoogleDorkingResult{}, sql.ErrNoRows
+	}
+	return stored(entity), nil
+}
+
+// ListWithOptions retrieves the docking results sorted and filtered by opts
+func (r *memoryDockingRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.GoogleDorkingResult, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	results, err := listMemory(r.store.docking.all(), dockingListQuery, opts, func(entity domain.GoogleDorkingResult, column string) any {
+		switch column {
+		case "relevance":
+			return entity.Relevance
+		case "search_rank":
+			return entity.Rank
+		case "created_at":
+			return entity.CreatedAt
+		case "updated_at":
+			return entity.UpdatedAt
+		case "title":
+			return entity.Title
+		case "url":
+			return entity.URL
+		}
+		return nil
+	}, func(entity domain.GoogleDorkingResult, domainType domain.DomainType) bool {
+		return r.store.searchResultDomain(entity.DomainSearchResultID) == domainType
+	})
+	return stored(results), err
+}
+
+// Count returns the total number of docking results
+func (r *memoryDockingRepository) Count(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(r.store.docking.len()), nil
+}
+
+// Search matches the docking results whose title, description or URL contain
+// the query, most relevant first
+func (r *memoryDockingRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.GoogleDorkingResult, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var results []domain.GoogleDorkingResult
+	for _, entity := range r.store.docking.newestFirst() {
+		if memoryContains(query, entity.ID.String(), entity.DomainSearchResultID.String(), entity.Title, entity.Description, entity.URL) {
+			results = append(results, entity)
+		}
+	}
+	slices.SortStableFunc(results, func(a, b domain.GoogleDorkingResult) int { return cmp.Compare(b.Relevance, a.Relevance) })
+	return stored(page(results, offset, limit)), nil
+}
+
+// GetKeywordsByCategory retrieves the keywords of a docking result
+func (r *memoryDockingRepository) GetKeywordsByCategory(ctx context.Context, entityID string) (map[domain.KeywordCategory][]string, error) {
+	entity, err := r.GetByID(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	docking := module.NewGoogleDorkingDomain()
+	return map[domain.KeywordCategory][]string{
+		domain.KeywordCategoryCompanyName: docking.GetDataByCategory(entity, domain.KeywordCategoryCompanyName),
+		domain.KeywordCategoryPersonName:  docking.GetDataByCategory(entity, domain.KeywordCategoryPersonName),
+		domain.KeywordCategoryAddress:     docking.GetDataByCategory(entity, domain.KeywordCategoryAddress),
+		domain.KeywordCategorySocialMedia: docking.GetDataByCategory(entity, domain.KeywordCategorySocialMedia),
+	}, nil
+}
@@ -0,0 +1,573 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	_ PipelineStore  = &memoryPipelineRepository{}
+	_ SessionStore   = &memorySessionRepository{}
+	_ URLSourceStore = &memoryURLSourceRepository{}
+)
+
+// memoryPipelineRepository stores the pipelines, their steps, events and queued
+// steps, and the domain search results in memory
+type memoryPipelineRepository struct {
+	store *memoryStore
+}
+
+// storedError returns the error as read back from its stored message
+func storedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(err.Error())
+}
+
+// storedStep returns a copy of a step as it reads back from the database
+func storedStep(step domain.DynamicPipelineStep) domain.DynamicPipelineStep {
+	err := step.Error
+	step.Error = nil
+	step = stored(step)
+	step.Error = storedError(err)
+	return step
+}
+
+// storedSearchResult returns a copy of a search result as it reads back from
+// the database
+func storedSearchResult(result domain.DomainSearchResult) domain.DomainSearchResult {
+	err := result.Error
+	result.Error = nil
+	result = stored(result)
+	result.Error = storedError(err)
+	return result
+}
+
+// storedPipeline returns the columns of a pipeline the database stores,
+// leaving out its steps and everything derived while it runs
+func storedPipeline(result *domain.DynamicPipelineResult) domain.DynamicPipelineResult {
+	return domain.DynamicPipelineResult{
+		ID:              result.ID,
+		SessionID:       result.SessionID,
+		TotalSteps:      result.TotalSteps,
+		SuccessfulSteps: result.SuccessfulSteps,
+		FailedSteps:     result.FailedSteps,
+		MaxDepthReached: result.MaxDepthReached,
+		StopReason:      result.StopReason,
+		Config:          stored(result.Config),
+		CreatedAt:       result.CreatedAt,
+		UpdatedAt:       result.UpdatedAt,
+	}
+}
+
+// CreateDomainSearchResult inserts a domain search result
+func (r *memoryPipelineRepository) CreateDomainSearchResult(ctx context.Context, result *domain.DomainSearchResult) (*domain.DomainSearchResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	result.ID = domain.NewID()
+	r.store.searchResults.put(result.ID, storedSearchResult(*result))
+	return result, nil
+}
+
+// CreateDynamicPipelineResult inserts a pipeline with its steps
+func (r *memoryPipelineRepository) CreateDynamicPipelineResult(ctx context.Context, result *domain.DynamicPipelineResult) (*domain.DynamicPipelineResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if result.ID == domain.ID(uuid.Nil) {
+		result.ID = domain.NewID()
+	}
+
+	header := storedPipeline(result)
+	header.CreatedAt = r.store.now()
+	header.UpdatedAt = header.CreatedAt
+	r.store.pipelines.put(header.ID, header)
+
+	for i := range result.Steps {
+		result.Steps[i].PipelineID = result.ID
+		r.insertStep(&result.Steps[i])
+	}
+
+	return result, nil
+}
+
+// insertStep stores a step, giving it an ID when it has none
+func (r *memoryPipelineRepository) insertStep(step *domain.DynamicPipelineStep) {
+	if step.ID == domain.ID(uuid.Nil) {
+		step.ID = domain.NewID()
+	}
+	r.store.steps.put(step.ID, memoryStep{
+		pipelineID: step.PipelineID,
+		step:       storedStep(*step),
+	})
+}
+
+// CreateDynamicPipelineStep inserts a pipeline step
+func (r *memoryPipelineRepository) CreateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.insertStep(step)
+	return nil
+}
+
+// UpdateDynamicPipelineStep updates the outcome of a pipeline step
+func (r *memoryPipelineRepository) UpdateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.steps.get(step.ID.String())
+	if !ok {
+		return nil
+	}
+
+	updated := storedStep(*step)
+	existing.step.Success = updated.Success
+	existing.step.Error = updated.Error
+	existing.step.Output = updated.Output
+	existing.step.KeywordsPerCategory = updated.KeywordsPerCategory
+	r.store.steps.put(step.ID, existing)
+	return nil
+}
+
+// pipelineSteps returns the stored steps of a pipeline in the order they were
+// inserted
+func (r *memoryPipelineRepository) pipelineSteps(pipelineID string) []domain.DynamicPipelineStep {
+	var steps []domain.DynamicPipelineStep
+	for _, stored := range r.store.steps.all() {
+		if stored.pipelineID.String() == pipelineID {
+			steps = append(steps, storedStep(stored.step))
+		}
+	}
+	return steps
+}
+
+// GetPipelineStepsByID retrieves the steps of a pipeline
+func (r *memoryPipelineRepository) GetPipelineStepsByID(ctx context.Context, id string) ([]domain.DynamicPipelineStep, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.pipelineSteps(id), nil
+}
+
+// pipelineKeywords returns the keywords found by the steps of a pipeline
+func (r *memoryPipelineRepository) pipelineKeywords(pipelineID string) []domain.StepKeyword {
+	var keywords []domain.StepKeyword
+	for _, step := range r.pipelineSteps(pipelineID) {
+		keywords = append(keywords, stepKeywords(&step)...)
+	}
+	return keywords
+}
+
+// GetStepKeywordsByPipelineID retrieves the keywords found by the steps of a
+// pipeline, optionally restricted to a category
+func (r *memoryPipelineRepository) GetStepKeywordsByPipelineID(ctx context.Context, pipelineID string, category domain.KeywordCategory) ([]domain.StepKeyword, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	keywords := []domain.StepKeyword{}
+	for _, keyword := range r.pipelineKeywords(pipelineID) {
+		if category == "" || keyword.Category == category {
+			keywords = append(keywords, keyword)
+		}
+	}
+	slices.SortStableFunc(keywords, func(a, b domain.StepKeyword) int {
+		return cmp.Or(cmp.Compare(a.Category, b.Category), cmp.Compare(a.NormalizedKeyword, b.NormalizedKeyword))
+	})
+	return keywords, nil
+}
+
+// GetPreviouslySeenKeywords returns the keys of the keywords of a pipeline that
+// the steps of a pipeline created before it had already found
+func (r *memoryPipelineRepository) GetPreviouslySeenKeywords(ctx context.Context, pipelineID string) (map[string]bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	current := make(map[string]bool)
+	for _, keyword := range r.pipelineKeywords(pipelineID) {
+		current[keyword.Key()] = true
+	}
+
+	seen := make(map[string]bool)
+	if _, ok := r.store.pipelines.get(pipelineID); !ok {
+		return seen, nil
+	}
+	for _, earlier := range r.store.pipelines.all() {
+		if earlier.ID.String() == pipelineID {
+			break
+		}
+		for _, keyword := range r.pipelineKeywords(earlier.ID.String()) {
+			if current[keyword.Key()] {
+				seen[keyword.Key()] = true
+			}
+		}
+	}
+	return seen, nil
+}
+
+// getPipeline returns a stored pipeline with its steps
+func (r *memoryPipelineRepository) getPipeline(id string) (*domain.DynamicPipelineResult, bool) {
+	header, ok := r.store.pipelines.get(id)
+	if !ok {
+		return nil, false
+	}
+	result := storedPipeline(&header)
+	result.Steps = r.pipelineSteps(id)
+	return &result, true
+}
+
+// GetPipelineByID retrieves a pipeline with its steps by its ID
+func (r *memoryPipelineRepository) GetPipelineByID(ctx context.Context, id string) (*domain.DynamicPipelineResult, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if result, ok := r.getPipeline(id); ok {
+		return result, nil
+	}
+	return nil, fmt.Errorf("%w with ID: %s", ErrPipelineNotFound, id)
+}
+
+// GetByID retrieves a domain search result, or else a pipeline, by its ID
+func (r *memoryPipelineRepository) GetByID(ctx context.Context, id string) (any, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if result, ok := r.store.searchResults.get(id); ok {
+		result = storedSearchResult(result)
+		return &result, nil
+	}
+	if result, ok := r.getPipeline(id); ok {
+		return result, nil
+	}
+	return nil, fmt.Errorf("pipeline result not found with ID: %s", id)
+}
+
+// Update modifies a domain search result or a pipeline
+func (r *memoryPipelineRepository) Update(ctx context.Context, id string, entity any) error {
+	switch v := entity.(type) {
+	case *domain.DomainSearchResult:
+		r.store.mu.Lock()
+		defer r.store.mu.Unlock()
+
+		if existing, ok := r.store.searchResults.get(v.ID.String()); ok {
+			updated := storedSearchResult(*v)
+			updated.PipelineStepsID = existing.PipelineStepsID
+			r.store.searchResults.put(v.ID, updated)
+		}
+		return nil
+	case *domain.DynamicPipelineResult:
+		return r.UpdateDynamicPipelineResult(ctx, v)
+	default:
+		return fmt.Errorf("unsupported pipeline result type: %T", entity)
+	}
+}
+
+// UpdateDynamicPipelineResult updates the counters, stop reason and config of
+// a pipeline
+func (r *memoryPipelineRepository) UpdateDynamicPipelineResult(ctx context.Context, result *domain.DynamicPipelineResult) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.pipelines.get(result.ID.String())
+	if !ok {
+		return nil
+	}
+
+	updated := storedPipeline(result)
+	updated.SessionID = existing.SessionID
+	updated.CreatedAt = existing.CreatedAt
+	updated.UpdatedAt = r.store.now()
+	r.store.pipelines.put(updated.ID, updated)
+	return nil
+}
+
+// ListSummariesWithOptions lists pipelines sorted and filtered by opts as
+// summaries
+func (r *memoryPipelineRepository) ListSummariesWithOptions(ctx context.Context, opts ListOptions) ([]domain.PipelineSummary, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	pipelines, err := listMemory(r.store.pipelines.all(), pipelineListQuery, opts, func(result domain.DynamicPipelineResult, column string) any {
+		switch column {
+		case "created_at":
+			return result.CreatedAt
+		case "updated_at":
+			return result.UpdatedAt
+		case "total_steps":
+			return result.TotalSteps
+		case "successful_steps":
+			return result.SuccessfulSteps
+		case "failed_steps":
+			return result.FailedSteps
+		case "max_depth_reached":
+			return result.MaxDepthReached
+		}
+		return nil
+	}, func(result domain.DynamicPipelineResult, domainType domain.DomainType) bool {
+		return slices.ContainsFunc(r.pipelineSteps(result.ID.String()), func(step domain.DynamicPipelineStep) bool {
+			return step.DomainType == domainType
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]domain.PipelineSummary, 0, len(pipelines))
+	for _, result := range pipelines {
+		summaries = append(summaries, domain.PipelineSummary{
+			ID:              result.ID,
+			Target:          result.Config.Query,
+			SessionID:       result.SessionID,
+			Status:          domain.PipelineStatus(result.TotalSteps, result.SuccessfulSteps, result.FailedSteps, result.StopReason),
+			TotalSteps:      result.TotalSteps,
+			SuccessfulSteps: result.SuccessfulSteps,
+			FailedSteps:     result.FailedSteps,
+			MaxDepthReached: result.MaxDepthReached,
+			StopReason:      result.StopReason,
+			CreatedAt:       result.CreatedAt,
+			UpdatedAt:       result.UpdatedAt,
+		})
+	}
+	return summaries, nil
+}
+
+// GetPipelinesBySessionID retrieves the pipelines of an investigation session
+// with their steps, oldest first
+func (r *memoryPipelineRepository) GetPipelinesBySessionID(ctx context.Context, sessionID string) ([]*domain.DynamicPipelineResult, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var results []*domain.DynamicPipelineResult
+	for _, header := range r.store.pipelines.all() {
+		if header.SessionID != sessionID {
+			continue
+		}
+		result, _ := r.getPipeline(header.ID.String())
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// CountPipelines returns the number of pipelines
+func (r *memoryPipelineRepository) CountPipelines(ctx context.Context) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return int64(r.store.pipelines.len()), nil
+}
+
+// GetKeywordsByCategory retrieves the keywords of a search result, or the
+// keywords of the steps of a pipeline each once per category
+func (r *memoryPipelineRepository) GetKeywordsByCategory(ctx context.Context, resultID string) (map[domain.KeywordCategory][]string, error) {
+	result, err := r.GetByID(ctx, resultID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := result.(type) {
+	case *domain.DomainSearchResult:
+		return v.KeywordsPerCategory, nil
+	case *domain.DynamicPipelineResult:
+		aggregated := domain.NewKeywordAggregator()
+		for _, step := range v.Steps {
+			aggregated.AddAll(step.KeywordsPerCategory)
+		}
+		return aggregated.KeywordsPerCategory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported result type: %T", result)
+	}
+}
+
+// GetCamaraRecordsByPerson retrieves the mercantile records stored in search
+// results whose output mentions the given name
+func (r *memoryPipelineRepository) GetCamaraRecordsByPerson(ctx context.Context, name string) ([]domain.CamaraRecord, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var records []domain.CamaraRecord
+	for _, result := range r.store.searchResults.newestFirst() {
+		if result.DomainType != domain.DomainTypeCamara || !result.Success {
+			continue
+		}
+
+		outputJSON, err := json.Marshal(result.Output)
+		if err != nil || !strings.Contains(strings.ToLower(string(outputJSON)), strings.ToLower(name)) {
+			continue
+		}
+
+		var output []domain.CamaraRecord
+		if err := json.Unmarshal(outputJSON, &output); err != nil {
+			continue
+		}
+		for _, record := range output {
+			record.DomainSearchResultID = result.ID
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// CreatePipelineEvent appends an event to the audit log of its pipeline
+func (r *memoryPipelineRepository) CreatePipelineEvent(ctx context.Context, event *domain.PipelineEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.seq++
+	event.Seq = r.store.seq
+	event.CreatedAt = r.store.now()
+	r.store.events = append(r.store.events, stored(*event))
+	return nil
+}
+
+// ListPipelineEvents returns the events of a pipeline in the order they were
+// recorded
+func (r *memoryPipelineRepository) ListPipelineEvents(ctx context.Context, pipelineID string) ([]domain.PipelineEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := []domain.PipelineEvent{}
+	for _, event := range r.store.events {
+		if event.PipelineID.String() == pipelineID {
+			events = append(events, stored(event))
+		}
+	}
+	return events, nil
+}
+
+// SpillQueuedSteps stores steps a running pipeline cannot keep queued in
+// memory, until DrainQueuedSteps queues them again
+func (r *memoryPipelineRepository) SpillQueuedSteps(ctx context.Context, pipelineID domain.ID, steps []domain.DynamicPipelineStep) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, step := range steps {
+		r.store.seq++
+		r.store.queue = append(r.store.queue, memoryQueuedStep{
+			seq:        r.store.seq,
+			pipelineID: pipelineID,
+			step:       storedStep(step),
+		})
+	}
+	return nil
+}
+
+// DrainQueuedSteps removes and returns up to limit of the steps spilled by a
+// pipeline, the ones to run first: the shallowest, or the deepest when
+// depthFirst, then the highest priorities
+func (r *memoryPipelineRepository) DrainQueuedSteps(ctx context.Context, pipelineID domain.ID, limit int, depthFirst bool) ([]domain.DynamicPipelineStep, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var queued []memoryQueuedStep
+	for _, spilled := range r.store.queue {
+		if spilled.pipelineID == pipelineID {
+			queued = append(queued, spilled)
+		}
+	}
+	slices.SortFunc(queued, func(a, b memoryQueuedStep) int {
+		if depthFirst {
+			return cmp.Or(cmp.Compare(b.step.Depth, a.step.Depth), cmp.Compare(b.step.Priority, a.step.Priority), cmp.Compare(b.seq, a.seq))
+		}
+		return cmp.Or(cmp.Compare(a.step.Depth, b.step.Depth), cmp.Compare(b.step.Priority, a.step.Priority), cmp.Compare(a.seq, b.seq))
+	})
+	queued = page(queued, 0, limit)
+	if len(queued) == 0 {
+		return nil, nil
+	}
+
+	drained := make(map[int64]bool, len(queued))
+	steps := make([]domain.DynamicPipelineStep, 0, len(queued))
+	for _, spilled := range queued {
+		drained[spilled.seq] = true
+		steps = append(steps, spilled.step)
+	}
+	r.store.queue = slices.DeleteFunc(r.store.queue, func(spilled memoryQueuedStep) bool { return drained[spilled.seq] })
+	return steps, nil
+}
+
+// ClearQueuedSteps drops the steps a pipeline left spilled when it stopped
+func (r *memoryPipelineRepository) ClearQueuedSteps(ctx context.Context, pipelineID domain.ID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.queue = slices.DeleteFunc(r.store.queue, func(spilled memoryQueuedStep) bool { return spilled.pipelineID == pipelineID })
+	return nil
+}
+
+// CompactOutputs has nothing to compact, the memory store keeping no
+// serialized outputs
+func (r *memoryPipelineRepository) CompactOutputs(ctx context.Context, minBytes, limit int) (CompactionStats, error) {
+	return CompactionStats{}, nil
+}
+
+// memorySessionRepository stores the investigation sessions in memory
+type memorySessionRepository struct {
+	store *memoryStore
+}
+
+// Create inserts a new session
+func (r *memorySessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if session.ID == domain.ID(uuid.Nil) {
+		session.ID = domain.NewID()
+	}
+	session.CreatedAt = r.store.now()
+	session.UpdatedAt = session.CreatedAt
+	r.store.sessions.put(session.ID, *session)
+	return nil
+}
+
+// GetByID retrieves a session by its ID
+func (r *memorySessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions.get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	return &session, nil
+}
+
+// memoryURLSourceRepository stores the URL sources in memory
+type memoryURLSourceRepository struct {
+	store *memoryStore
+}
+
+// Link records that a domain found the URL of an existing record
+func (r *memoryURLSourceRepository) Link(ctx context.Context, source domain.URLSource) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	source.ID = domain.NewID()
+	source.CreatedAt = r.store.now()
+	r.store.urlSources.put(source.ID, source)
+	return nil
+}
+
+// GetByURL retrieves the sources linked to a URL
+func (r *memoryURLSourceRepository) GetByURL(ctx context.Context, url string) ([]domain.URLSource, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	withSlash, withoutSlash := urlVariants(url)
+	var sources []domain.URLSource
+	for _, source := range r.store.urlSources.all() {
+		if source.URL == withSlash || source.URL == withoutSlash {
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
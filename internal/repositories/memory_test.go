@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryRepositoriesAreSelectedByDBDriver(t *testing.T) {
+	t.Setenv("DB_DRIVER", "memory")
+
+	repos := NewRepositoryFactory(nil)
+	if _, ok := repos.GetPipelineRepository().(*memoryPipelineRepository); !ok {
+		t.Fatalf("expected the memory pipeline repository, got %T", repos.GetPipelineRepository())
+	}
+
+	// Every repository of the factory shares its store
+	ctx := context.Background()
+	if err := repos.GetOnapiRepository().Create(ctx, domain.Entity{Texto: "NOVASCO"}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if count, _ := repos.GetOnapiRepository().Count(ctx); count != 1 {
+		t.Errorf("expected 1 stored entity, got %d", count)
+	}
+}
+
+func TestMemoryOnapiUpsertAndGet(t *testing.T) {
+	repo := NewMemoryRepositoryFactory().GetOnapiRepository()
+	ctx := context.Background()
+
+	entity := domain.Entity{SerieExpediente: 2020, NumeroExpediente: 1, Texto: "NOVASCO", Titular: "NOVASCO SRL"}
+	if err := repo.Upsert(ctx, entity); err != nil {
+		t.Fatalf("Upsert returned error: %v", err)
+	}
+	entity.Status = "Registrado"
+	if err := repo.Upsert(ctx, entity); err != nil {
+		t.Fatalf("Upsert returned error: %v", err)
+	}
+
+	entities, err := repo.ListWithOptions(ctx, ListOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListWithOptions returned error: %v", err)
+	}
+	if len(entities) != 1 || entities[0].Status != "Registrado" {
+		t.Fatalf("expected the upsert to update the stored entity, got %+v", entities)
+	}
+
+	got, err := repo.GetByID(ctx, entities[0].ID.String())
+	if err != nil || got.Titular != "NOVASCO SRL" {
+		t.Errorf("expected the entity by its ID, got %+v (%v)", got, err)
+	}
+	if _, err := repo.GetByID(ctx, domain.NewID().String()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows for a missing entity, got %v", err)
+	}
+
+	// Records read back share nothing with the store
+	got.Titular = "changed"
+	if again, _ := repo.GetByID(ctx, got.ID.String()); again.Titular != "NOVASCO SRL" {
+		t.Errorf("expected the stored entity to be unchanged, got %q", again.Titular)
+	}
+}
+
+func TestMemoryListWithOptions(t *testing.T) {
+	repos := NewMemoryRepositoryFactory()
+	repo := repos.GetDgiiRepository()
+	ctx := context.Background()
+
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repos.memory.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+
+	for _, name := range []string{"beta", "Alpha", "gamma"} {
+		if _, err := repo.Upsert(ctx, domain.Register{RNC: name, RazonSocial: name}); err != nil {
+			t.Fatalf("Upsert returned error: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"newest first by default", ListOptions{Limit: 10}, []string{"gamma", "Alpha", "beta"}},
+		{"sorted regardless of case", ListOptions{Sort: "razon_social", Order: SortAsc, Limit: 10}, []string{"Alpha", "beta", "gamma"}},
+		{"paged", ListOptions{Offset: 1, Limit: 1}, []string{"Alpha"}},
+		{"since", ListOptions{Since: clock.Add(-time.Minute), Limit: 10}, []string{"gamma", "Alpha"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registers, err := repo.ListWithOptions(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("ListWithOptions returned error: %v", err)
+			}
+			var got []string
+			for _, register := range registers {
+				got = append(got, register.RazonSocial)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := repo.ListWithOptions(ctx, ListOptions{Sort: "password", Limit: 10}); !errors.Is(err, ErrInvalidListOptions) {
+		t.Errorf("expected ErrInvalidListOptions for an unknown column, got %v", err)
+	}
+}
+
+func TestMemorySearch(t *testing.T) {
+	repos := NewMemoryRepositoryFactory()
+	ctx := context.Background()
+
+	onapi := repos.GetOnapiRepository()
+	onapi.Create(ctx, domain.Entity{Texto: "INVERSIONES NOVASCO", Titular: "José Pérez"})
+	onapi.Create(ctx, domain.Entity{Texto: "NOVASCO HOLDING", Titular: "Ana Gómez"})
+
+	entities, _ := onapi.SearchWithMode(ctx, "novasco jose", MatchAll, 0, 10)
+	if len(entities) != 1 || entities[0].Titular != "José Pérez" {
+		t.Errorf("expected every word to match regardless of accents, got %+v", entities)
+	}
+	entities, _ = onapi.SearchWithMode(ctx, "perez gomez", MatchAny, 0, 10)
+	if len(entities) != 2 {
+		t.Errorf("expected either word to match, got %+v", entities)
+	}
+
+	pgr := repos.GetPgrRepository()
+	pgr.Create(ctx, domain.PGRNews{URL: "https://pgr.gob.do/noticia", Title: "Arrestan estafadores"})
+	news, _ := pgr.Search(ctx, "estafa", 0, 10)
+	if len(news) != 1 {
+		t.Errorf("expected the news whose title contains the query, got %+v", news)
+	}
+
+	// Docking results already stored as PGR news are linked, not duplicated
+	docking := repos.GetDockingRepository()
+	docking.Create(ctx, domain.GoogleDorkingResult{URL: "https://pgr.gob.do/noticia/", Title: "Arrestan estafadores"})
+	if count, _ := docking.Count(ctx); count != 0 {
+		t.Errorf("expected the PGR news not to be duplicated, got %d docking results", count)
+	}
+	sources, _ := repos.GetURLSourceRepository().GetByURL(ctx, "https://pgr.gob.do/noticia")
+	if len(sources) != 1 || sources[0].RecordTable != "pgr_news" {
+		t.Errorf("expected the docking result to be linked to the PGR news, got %+v", sources)
+	}
+}
+
+func TestMemoryPipelineRoundTrip(t *testing.T) {
+	repo := NewMemoryRepositoryFactory().GetPipelineRepository()
+	ctx := context.Background()
+
+	result := &domain.DynamicPipelineResult{
+		Config: domain.DynamicPipelineConfig{Query: "novasco"},
+		Steps: []domain.DynamicPipelineStep{
+			{DomainType: domain.DomainTypeONAPI, SearchParameter: "novasco", Success: true, KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO SRL"},
+			}},
+			{DomainType: domain.DomainTypeDGII, SearchParameter: "novasco", Error: errors.New("unexpected status code: 502")},
+		},
+	}
+	if _, err := repo.CreateDynamicPipelineResult(ctx, result); err != nil {
+		t.Fatalf("CreateDynamicPipelineResult returned error: %v", err)
+	}
+
+	stored, err := repo.GetPipelineByID(ctx, result.ID.String())
+	if err != nil {
+		t.Fatalf("GetPipelineByID returned error: %v", err)
+	}
+	if len(stored.Steps) != 2 || stored.Steps[0].PipelineID != result.ID || stored.Config.Query != "novasco" {
+		t.Fatalf("expected the pipeline with its steps, got %+v", stored)
+	}
+	if stored.Steps[1].Error == nil || stored.Steps[1].Error.Error() != "unexpected status code: 502" {
+		t.Errorf("expected the step error message to round trip, got %v", stored.Steps[1].Error)
+	}
+
+	if _, err := repo.GetPipelineByID(ctx, domain.NewID().String()); !errors.Is(err, ErrPipelineNotFound) {
+		t.Errorf("expected ErrPipelineNotFound, got %v", err)
+	}
+
+	// A later pipeline finding the same company has seen it before
+	later := &domain.DynamicPipelineResult{Steps: []domain.DynamicPipelineStep{
+		{DomainType: domain.DomainTypeDGII, KeywordsPerCategory: map[domain.KeywordCategory][]string{
+			domain.KeywordCategoryCompanyName: {"Novasco SRL"},
+		}},
+	}}
+	repo.CreateDynamicPipelineResult(ctx, later)
+	seen, _ := repo.GetPreviouslySeenKeywords(ctx, later.ID.String())
+	if len(seen) != 1 {
+		t.Errorf("expected the company to have been seen before, got %v", seen)
+	}
+	if seen, _ := repo.GetPreviouslySeenKeywords(ctx, result.ID.String()); len(seen) != 0 {
+		t.Errorf("expected nothing seen before the first pipeline, got %v", seen)
+	}
+}
+
+func TestMemoryDrainQueuedStepsOrder(t *testing.T) {
+	repo := NewMemoryRepositoryFactory().GetPipelineRepository()
+	ctx := context.Background()
+
+	pipelineID := domain.NewID()
+	repo.SpillQueuedSteps(ctx, pipelineID, []domain.DynamicPipelineStep{
+		{SearchParameter: "deep", Depth: 2},
+		{SearchParameter: "low", Depth: 1, Priority: 10},
+		{SearchParameter: "high", Depth: 1, Priority: 50},
+	})
+	repo.SpillQueuedSteps(ctx, domain.NewID(), []domain.DynamicPipelineStep{{SearchParameter: "other"}})
+
+	drained, err := repo.DrainQueuedSteps(ctx, pipelineID, 2, false)
+	if err != nil {
+		t.Fatalf("DrainQueuedSteps returned error: %v", err)
+	}
+	if len(drained) != 2 || drained[0].SearchParameter != "high" || drained[1].SearchParameter != "low" {
+		t.Errorf("expected the shallowest steps by priority, got %+v", drained)
+	}
+
+	drained, _ = repo.DrainQueuedSteps(ctx, pipelineID, 10, false)
+	if len(drained) != 1 || drained[0].SearchParameter != "deep" {
+		t.Errorf("expected the drained steps to be removed, got %+v", drained)
+	}
+}
+
+func TestMemoryRepositoriesConcurrentUse(t *testing.T) {
+	repos := NewMemoryRepositoryFactory()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repos.GetScjRepository().Create(ctx, domain.ScjCase{NoExpediente: fmt.Sprint(i)})
+			repos.GetScjRepository().Search(ctx, "1", 0, 10)
+		}()
+	}
+	wg.Wait()
+
+	if count, _ := repos.GetScjRepository().Count(ctx); count != 50 {
+		t.Errorf("expected 50 stored cases, got %d", count)
+	}
+}
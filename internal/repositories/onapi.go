@@ -35,9 +35,9 @@ func (r *OnapiRepository) CreateWithDomainSearchResultID(ctx context.Context, en
 		INSERT INTO onapi_entities (
 			id, domain_search_result_id, serie_expediente, numero_expediente, certificado, tipo, subtipo,
 			texto, clases, aplicado_a_proteger, expedicion, vencimiento, en_tramite,
-			titular, gestor, domicilio, status, tipo_signo, imagenes, lista_clases,
+			titular, gestor, domicilio, search_ascii, status, tipo_signo, imagenes, lista_clases,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
 	imagenesJSON, _ := json.Marshal(entity.Imagenes)
@@ -47,13 +47,46 @@ func (r *OnapiRepository) CreateWithDomainSearchResultID(ctx context.Context, en
 		entity.ID, entity.DomainSearchResultID, entity.SerieExpediente, entity.NumeroExpediente, entity.Certificado,
 		entity.Tipo, entity.SubTipo, entity.Texto, entity.Clases, entity.AplicadoAProteger,
 		entity.Expedicion, entity.Vencimiento, entity.EnTramite, entity.Titular,
-		entity.Gestor, entity.Domicilio, entity.Status, entity.TipoSigno,
+		entity.Gestor, entity.Domicilio, onapiSearchASCII(entity), entity.Status, entity.TipoSigno,
 		imagenesJSON, listaClasesJSON,
 	)
 
 	return err
 }
 
+// Upsert stores an ONAPI entity, updating the stored entity with the same
+// serie and numero expediente instead of inserting it again
+func (r *OnapiRepository) Upsert(ctx context.Context, entity domain.Entity) error {
+	exists, existingID, err := r.ExpedienteExists(ctx, entity.SerieExpediente, entity.NumeroExpediente)
+	if err != nil {
+		return fmt.Errorf("error checking expediente existence: %w", err)
+	}
+
+	if exists {
+		return r.Update(ctx, existingID.String(), entity)
+	}
+
+	return r.CreateWithDomainSearchResultID(ctx, entity)
+}
+
+// ExpedienteExists checks if an entity with the serie and numero expediente
+// already exists in the database
+func (r *OnapiRepository) ExpedienteExists(ctx context.Context, serie, numero int32) (bool, domain.ID, error) {
+	query := `SELECT id FROM onapi_entities WHERE serie_expediente = ? AND numero_expediente = ? LIMIT 1`
+
+	var id domain.ID
+	err := r.db.QueryRowContext(ctx, query, serie, numero).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return false, domain.ID{}, nil
+	}
+	if err != nil {
+		return false, domain.ID{}, err
+	}
+
+	return true, id, nil
+}
+
 // GetByID retrieves an ONAPI entity by its ID
 func (r *OnapiRepository) GetByID(ctx context.Context, id string) (domain.Entity, error) {
 	query := `
@@ -111,7 +144,7 @@ func (r *OnapiRepository) Update(ctx context.Context, id string, entity domain.E
 		UPDATE onapi_entities SET
 			domain_search_result_id = ?, serie_expediente = ?, numero_expediente = ?, certificado = ?, tipo = ?, subtipo = ?,
 			texto = ?, clases = ?, aplicado_a_proteger = ?, expedicion = ?, vencimiento = ?, 
-			en_tramite = ?, titular = ?, gestor = ?, domicilio = ?, status = ?, tipo_signo = ?,
+			en_tramite = ?, titular = ?, gestor = ?, domicilio = ?, search_ascii = ?, status = ?, tipo_signo = ?,
 			imagenes = ?, lista_clases = ?, updated_at = NOW()
 		WHERE id = ?
 	`
@@ -123,7 +156,7 @@ func (r *OnapiRepository) Update(ctx context.Context, id string, entity domain.E
 		entity.DomainSearchResultID, entity.SerieExpediente, entity.NumeroExpediente, entity.Certificado,
 		entity.Tipo, entity.SubTipo, entity.Texto, entity.Clases, entity.AplicadoAProteger,
 		entity.Expedicion, entity.Vencimiento, entity.EnTramite, entity.Titular,
-		entity.Gestor, entity.Domicilio, entity.Status, entity.TipoSigno,
+		entity.Gestor, entity.Domicilio, onapiSearchASCII(entity), entity.Status, entity.TipoSigno,
 		imagenesJSON, listaClasesJSON, id,
 	)
 
@@ -139,17 +172,31 @@ func (r *OnapiRepository) Delete(ctx context.Context, id string) error {
 
 // List retrieves multiple ONAPI entities with pagination
 func (r *OnapiRepository) List(ctx context.Context, offset, limit int) ([]domain.Entity, error) {
+	return r.ListWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
+
+// onapiListQuery lists the ONAPI entities
+var onapiListQuery = listQuery{
+	sortable:     []string{"created_at", "updated_at", "serie_expediente", "numero_expediente", "texto", "titular", "status"},
+	domainFilter: searchResultDomainFilter,
+}
+
+// ListWithOptions retrieves the ONAPI entities sorted and filtered by opts
+func (r *OnapiRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.Entity, error) {
+	clauses, args, err := onapiListQuery.clauses(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT id, domain_search_result_id, serie_expediente, numero_expediente, certificado, tipo, subtipo,
 			   texto, clases, aplicado_a_proteger, expedicion, vencimiento, en_tramite,
 			   titular, gestor, domicilio, status, tipo_signo, imagenes, lista_clases,
 			   created_at, updated_at
 		FROM onapi_entities 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + clauses
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +206,6 @@ func (r *OnapiRepository) List(ctx context.Context, offset, limit int) ([]domain
 	for rows.Next() {
 		var entity domain.Entity
 		var imagenesJSON, listaClasesJSON string
-		var createdAt, updatedAt any // could be time.Time or string; check domain.Entity
 
 		// Ensure the order and number of columns in Scan matches the SELECT above
 		err := rows.Scan(
@@ -183,8 +229,8 @@ func (r *OnapiRepository) List(ctx context.Context, offset, limit int) ([]domain
 			&entity.TipoSigno,            // tipo_signo
 			&imagenesJSON,                // imagenes
 			&listaClasesJSON,             // lista_clases
-			&createdAt,                   // created_at
-			&updatedAt,                   // updated_at
+			timestamp{&entity.CreatedAt}, // created_at
+			timestamp{&entity.UpdatedAt}, // updated_at
 		)
 		if err != nil {
 			return nil, err
@@ -200,6 +246,52 @@ func (r *OnapiRepository) List(ctx context.Context, offset, limit int) ([]domain
 	return entities, nil
 }
 
+// FindByPerson retrieves the ONAPI entities whose titular or gestor mentions the given name
+func (r *OnapiRepository) FindByPerson(ctx context.Context, name string) ([]domain.Entity, error) {
+	query := `
+		SELECT id, domain_search_result_id, serie_expediente, numero_expediente, certificado, tipo, subtipo,
+			   texto, clases, aplicado_a_proteger, expedicion, vencimiento, en_tramite,
+			   titular, gestor, domicilio, status, tipo_signo, imagenes, lista_clases,
+			   created_at, updated_at
+		FROM onapi_entities 
+		WHERE titular LIKE ? OR gestor LIKE ?
+		ORDER BY created_at DESC
+	`
+
+	searchPattern := "%" + name + "%"
+	rows, err := r.db.QueryContext(ctx, query, searchPattern, searchPattern)
+	if err != nil {
+		return nil, fmt.Errorf("error finding onapi entities by person: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []domain.Entity
+	for rows.Next() {
+		var entity domain.Entity
+		var imagenesJSON, listaClasesJSON string
+		var createdAt, updatedAt any
+
+		err := rows.Scan(
+			&entity.ID, &entity.DomainSearchResultID, &entity.SerieExpediente, &entity.NumeroExpediente, &entity.Certificado,
+			&entity.Tipo, &entity.SubTipo, &entity.Texto, &entity.Clases, &entity.AplicadoAProteger,
+			&entity.Expedicion, &entity.Vencimiento, &entity.EnTramite, &entity.Titular,
+			&entity.Gestor, &entity.Domicilio, &entity.Status, &entity.TipoSigno,
+			&imagenesJSON, &listaClasesJSON, &createdAt, &updatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse JSON fields
+		json.Unmarshal([]byte(imagenesJSON), &entity.Imagenes)
+		json.Unmarshal([]byte(listaClasesJSON), &entity.ListaClases)
+
+		entities = append(entities, entity)
+	}
+
+	return entities, rows.Err()
+}
+
 // Count returns the total number of ONAPI entities
 func (r *OnapiRepository) Count(ctx context.Context) (int64, error) {
 	query := `SELECT COUNT(*) FROM onapi_entities`
@@ -208,22 +300,38 @@ func (r *OnapiRepository) Count(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-// Search performs a search query on ONAPI entities
+// onapiSearchColumns are the columns the words of a search are looked up in.
+// search_ascii holds them without accents, the others still match the entities
+// stored before it existed.
+var onapiSearchColumns = []string{"texto", "titular", "gestor", "domicilio", "search_ascii"}
+
+// onapiSearchASCII is the search_ascii column of an entity
+func onapiSearchASCII(entity domain.Entity) string {
+	return searchASCII(entity.Texto, entity.Titular, entity.Gestor, entity.Domicilio)
+}
+
+// Search performs a search query on ONAPI entities, matching the entities
+// holding every word of the query, accents aside
 func (r *OnapiRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.Entity, error) {
+	return r.SearchWithMode(ctx, query, MatchAll, offset, limit)
+}
+
+// SearchWithMode searches ONAPI entities for the words of query, each word
+// in any of the searched columns, combined by mode
+func (r *OnapiRepository) SearchWithMode(ctx context.Context, query string, mode MatchMode, offset, limit int) ([]domain.Entity, error) {
+	condition, args := tokenCondition(domain.FoldAccents(query), onapiSearchColumns, mode)
 	searchQuery := `
 		SELECT id, domain_search_result_id, serie_expediente, numero_expediente, certificado, tipo, subtipo,
 			   texto, clases, aplicado_a_proteger, expedicion, vencimiento, en_tramite,
 			   titular, gestor, domicilio, status, tipo_signo, imagenes, lista_clases,
 			   created_at, updated_at
 		FROM onapi_entities 
-		WHERE texto LIKE ? OR titular LIKE ? OR gestor LIKE ? OR domicilio LIKE ?
+		WHERE ` + condition + `
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
 	`
 
-	searchPattern := "%" + query + "%"
-	rows, err := r.db.QueryContext(ctx, searchQuery,
-		searchPattern, searchPattern, searchPattern, searchPattern, limit, offset)
+	rows, err := r.db.QueryContext(ctx, searchQuery, append(args, limit, offset)...)
 	if err != nil {
 		return nil, err
 	}
@@ -240,6 +348,7 @@ func (r *OnapiRepository) Search(ctx context.Context, query string, offset, limi
 			&entity.Expedicion, &entity.Vencimiento, &entity.EnTramite, &entity.Titular,
 			&entity.Gestor, &entity.Domicilio, &entity.Status, &entity.TipoSigno,
 			&imagenesJSON, &listaClasesJSON,
+			timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 		)
 		if err != nil {
 			return nil, err
@@ -252,7 +361,7 @@ func (r *OnapiRepository) Search(ctx context.Context, query string, offset, limi
 		entities = append(entities, entity)
 	}
 
-	return entities, nil
+	return entities, rows.Err()
 }
 
 // SearchByCategory performs a search within a specific keyword category
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestOnapiUpsertInsertsThenUpdates(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	onapiRepo := repos.GetOnapiRepository()
+
+	entity := domain.Entity{SerieExpediente: 2020, NumeroExpediente: 1234, Texto: "NOVASCO", Status: "En trámite"}
+
+	// The first time the expediente is not stored, so it is inserted
+	mock.ExpectQuery("SELECT id FROM onapi_entities WHERE serie_expediente = \\? AND numero_expediente = \\?").
+		WithArgs(int32(2020), int32(1234)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("INSERT INTO onapi_entities").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := onapiRepo.Upsert(context.Background(), entity); err != nil {
+		t.Fatalf("first Upsert returned error: %v", err)
+	}
+
+	// Storing it again updates the stored row instead of inserting a duplicate
+	existingID := domain.NewID()
+	entity.Status = "Registrada"
+	mock.ExpectQuery("SELECT id FROM onapi_entities WHERE serie_expediente = \\? AND numero_expediente = \\?").
+		WithArgs(int32(2020), int32(1234)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(existingID.String()))
+	mock.ExpectExec("UPDATE onapi_entities SET").
+		WithArgs(sqlmock.AnyArg(), int32(2020), int32(1234), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"NOVASCO", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "NOVASCO", "Registrada", sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), existingID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := onapiRepo.Upsert(context.Background(), entity); err != nil {
+		t.Fatalf("second Upsert returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOnapiSearchWithModeAny(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	mock.ExpectQuery(`FROM onapi_entities\s+WHERE \(\(texto LIKE \? OR titular LIKE \? OR gestor LIKE \? OR domicilio LIKE \? OR search_ascii LIKE \?\) OR \(texto LIKE \? OR titular LIKE \? OR gestor LIKE \? OR domicilio LIKE \? OR search_ascii LIKE \?\)\)`).
+		WithArgs("%NOVASCO%", "%NOVASCO%", "%NOVASCO%", "%NOVASCO%", "%NOVASCO%", "%PEREZ%", "%PEREZ%", "%PEREZ%", "%PEREZ%", "%PEREZ%", 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_search_result_id", "serie_expediente", "numero_expediente", "certificado", "tipo", "subtipo",
+			"texto", "clases", "aplicado_a_proteger", "expedicion", "vencimiento", "en_tramite",
+			"titular", "gestor", "domicilio", "status", "tipo_signo", "imagenes", "lista_clases",
+			"created_at", "updated_at",
+		}).AddRow(
+			domain.NewID().String(), domain.NewID().String(), 2020, 1, "", "", "",
+			"NOVASCO", "", "", "", "", false,
+			"Juan Perez", "", "", "", "", "[]", "[]",
+			nil, nil,
+		))
+
+	entities, err := repos.GetOnapiRepository().SearchWithMode(context.Background(), "NOVASCO PEREZ", MatchAny, 0, 10)
+	if err != nil {
+		t.Fatalf("SearchWithMode returned error: %v", err)
+	}
+	if len(entities) != 1 || entities[0].Texto != "NOVASCO" {
+		t.Errorf("unexpected entities %+v", entities)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOnapiSearchIgnoresAccents(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	onapiRepo := repos.GetOnapiRepository()
+
+	// The entity is stored with its searchable fields unaccented
+	mock.ExpectExec("INSERT INTO onapi_entities").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), int32(2020), int32(1), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"NOVASCO", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"José Peña", sqlmock.AnyArg(), "Calle Duarte", "NOVASCO Jose Pena Calle Duarte",
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := onapiRepo.Create(context.Background(), domain.Entity{
+		SerieExpediente:  2020,
+		NumeroExpediente: 1,
+		Texto:            "NOVASCO",
+		Titular:          "José Peña",
+		Domicilio:        "Calle Duarte",
+	}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	// so "Jose" finds "José", and "José" finds it as well
+	for _, query := range []string{"Jose", "José"} {
+		mock.ExpectQuery(`FROM onapi_entities\s+WHERE \(\(texto LIKE \? OR titular LIKE \? OR gestor LIKE \? OR domicilio LIKE \? OR search_ascii LIKE \?\)\)`).
+			WithArgs("%Jose%", "%Jose%", "%Jose%", "%Jose%", "%Jose%", 10, 0).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "domain_search_result_id", "serie_expediente", "numero_expediente", "certificado", "tipo", "subtipo",
+				"texto", "clases", "aplicado_a_proteger", "expedicion", "vencimiento", "en_tramite",
+				"titular", "gestor", "domicilio", "status", "tipo_signo", "imagenes", "lista_clases",
+				"created_at", "updated_at",
+			}).AddRow(
+				domain.NewID().String(), domain.NewID().String(), 2020, 1, "", "", "",
+				"NOVASCO", "", "", "", "", false,
+				"José Peña", "", "Calle Duarte", "", "", "[]", "[]",
+				nil, nil,
+			))
+
+		entities, err := onapiRepo.Search(context.Background(), query, 0, 10)
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", query, err)
+		}
+		if len(entities) != 1 || entities[0].Titular != "José Peña" {
+			t.Errorf("Search(%q) returned unexpected entities %+v", query, entities)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -44,12 +44,12 @@ func (r *PgrRepository) CreateWithDomainSearchResultID(ctx context.Context, enti
 
 	query := `
 		INSERT INTO pgr_news (
-			id, domain_search_result_id, url, title, created_at, updated_at
-		) VALUES (?, ?, ?, ?, NOW(), NOW())
+			id, domain_search_result_id, url, title, published_at, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
-		entity.ID, entity.DomainSearchResultID, entity.URL, entity.Title,
+		entity.ID, entity.DomainSearchResultID, entity.URL, entity.Title, nullTime(entity.PublishedAt),
 	)
 
 	return err
@@ -75,7 +75,7 @@ func (r *PgrRepository) URLExists(ctx context.Context, url string) (bool, domain
 // GetByURL retrieves a PGR news item by URL
 func (r *PgrRepository) GetByURL(ctx context.Context, url string) (domain.PGRNews, error) {
 	query := `
-		SELECT id, domain_search_result_id, url, title, created_at, updated_at
+		SELECT id, domain_search_result_id, url, title, published_at, created_at, updated_at
 		FROM pgr_news 
 		WHERE url = ?
 	`
@@ -84,6 +84,7 @@ func (r *PgrRepository) GetByURL(ctx context.Context, url string) (domain.PGRNew
 
 	err := r.db.QueryRowContext(ctx, query, url).Scan(
 		&entity.ID, &entity.DomainSearchResultID, &entity.URL, &entity.Title,
+		timestamp{&entity.PublishedAt}, timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 	)
 
 	if err != nil {
@@ -96,7 +97,7 @@ func (r *PgrRepository) GetByURL(ctx context.Context, url string) (domain.PGRNew
 // GetByID retrieves a PGR news item by its URL
 func (r *PgrRepository) GetByID(ctx context.Context, id string) (domain.PGRNews, error) {
 	query := `
-		SELECT id, domain_search_result_id, url, title, created_at, updated_at
+		SELECT id, domain_search_result_id, url, title, published_at, created_at, updated_at
 		FROM pgr_news 
 		WHERE id = ?
 	`
@@ -105,6 +106,7 @@ func (r *PgrRepository) GetByID(ctx context.Context, id string) (domain.PGRNews,
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&entity.ID, &entity.DomainSearchResultID, &entity.URL, &entity.Title,
+		timestamp{&entity.PublishedAt}, timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 	)
 
 	if err != nil {
@@ -118,12 +120,12 @@ func (r *PgrRepository) GetByID(ctx context.Context, id string) (domain.PGRNews,
 func (r *PgrRepository) Update(ctx context.Context, entity domain.PGRNews) error {
 	query := `
 		UPDATE pgr_news SET
-			domain_search_result_id = ?, url = ?, title = ?, updated_at = NOW()
+			domain_search_result_id = ?, url = ?, title = ?, published_at = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
-		entity.DomainSearchResultID, entity.URL, entity.Title, entity.ID,
+		entity.DomainSearchResultID, entity.URL, entity.Title, nullTime(entity.PublishedAt), entity.ID,
 	)
 
 	return err
@@ -138,14 +140,28 @@ func (r *PgrRepository) Delete(ctx context.Context, entity domain.PGRNews) error
 
 // List retrieves multiple PGR news items with pagination
 func (r *PgrRepository) List(ctx context.Context, offset, limit int) ([]domain.PGRNews, error) {
+	return r.ListWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
+
+// pgrListQuery lists the PGR news items
+var pgrListQuery = listQuery{
+	sortable:     []string{"created_at", "updated_at", "published_at", "title"},
+	domainFilter: searchResultDomainFilter,
+}
+
+// ListWithOptions retrieves the PGR news items sorted and filtered by opts
+func (r *PgrRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.PGRNews, error) {
+	clauses, args, err := pgrListQuery.clauses(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT id, domain_search_result_id, url, title, created_at, updated_at
+		SELECT id, domain_search_result_id, url, title, published_at, created_at, updated_at
 		FROM pgr_news 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + clauses
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -160,8 +176,9 @@ func (r *PgrRepository) List(ctx context.Context, offset, limit int) ([]domain.P
 			&entity.DomainSearchResultID,
 			&entity.URL,
 			&entity.Title,
-			new(interface{}), // created_at (ignored)
-			new(interface{}), // updated_at (ignored)
+			timestamp{&entity.PublishedAt}, // published_at
+			timestamp{&entity.CreatedAt},   // created_at
+			timestamp{&entity.UpdatedAt},   // updated_at
 		)
 		if err != nil {
 			return nil, err
@@ -184,7 +201,7 @@ func (r *PgrRepository) Count(ctx context.Context) (int64, error) {
 // Search performs a search query on PGR news items
 func (r *PgrRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.PGRNews, error) {
 	searchQuery := `
-		SELECT id, domain_search_result_id, url, title, created_at, updated_at
+		SELECT id, domain_search_result_id, url, title, published_at, created_at, updated_at
 		FROM pgr_news 
 		WHERE title LIKE ? OR url LIKE ?
 		ORDER BY created_at DESC
@@ -205,6 +222,7 @@ func (r *PgrRepository) Search(ctx context.Context, query string, offset, limit
 
 		err := rows.Scan(
 			&entity.ID, &entity.DomainSearchResultID, &entity.URL, &entity.Title,
+			timestamp{&entity.PublishedAt}, timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 		)
 		if err != nil {
 			return nil, err
@@ -224,7 +242,7 @@ func (r *PgrRepository) SearchByCategory(ctx context.Context, category domain.Ke
 	switch category {
 	case domain.KeywordCategoryCompanyName, domain.KeywordCategoryPersonName, domain.KeywordCategoryAddress:
 		searchQuery = `
-			SELECT id, domain_search_result_id, url, title, created_at, updated_at
+			SELECT id, domain_search_result_id, url, title, published_at, created_at, updated_at
 			FROM pgr_news 
 			WHERE title LIKE ?
 			ORDER BY created_at DESC
@@ -247,6 +265,7 @@ func (r *PgrRepository) SearchByCategory(ctx context.Context, category domain.Ke
 
 		err := rows.Scan(
 			&entity.ID, &entity.DomainSearchResultID, &entity.URL, &entity.Title,
+			timestamp{&entity.PublishedAt}, timestamp{&entity.CreatedAt}, timestamp{&entity.UpdatedAt},
 		)
 		if err != nil {
 			return nil, err
@@ -2,15 +2,22 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"insightful-intel/internal/database"
 	"insightful-intel/internal/domain"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrPipelineNotFound is returned when no pipeline result has the requested ID
+var ErrPipelineNotFound = errors.New("pipeline result not found")
+
 // PipelineRepository implements PipelineResultRepository for pipeline results
 type PipelineRepository struct {
 	db DatabaseAccessor
@@ -60,29 +67,83 @@ func (r *PipelineRepository) CreateDynamicPipelineResult(ctx context.Context, re
 
 	query := `
 		INSERT INTO dynamic_pipeline_results (
-			id, total_steps, successful_steps, failed_steps, max_depth_reached, config, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW())
+			id, session_id, total_steps, successful_steps, failed_steps, max_depth_reached, config, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
 	configJSON, _ := json.Marshal(result.Config)
 
 	_, err := r.db.ExecContext(ctx, query,
-		result.ID, result.TotalSteps, result.SuccessfulSteps, result.FailedSteps, result.MaxDepthReached, configJSON,
+		result.ID, nullableString(result.SessionID), result.TotalSteps, result.SuccessfulSteps, result.FailedSteps, result.MaxDepthReached, configJSON,
 	)
 	if err != nil {
 
 		return nil, err
 	}
 
-	// // Get the generated UUID
-	// err = r.CreateDynamicPipelineSteps(ctx, result.ID.String(), result.Steps)
-	// if err != nil {
-	// 	return nil, err
-	// }
+	err = r.CreateDynamicPipelineSteps(ctx, result.ID.String(), result.Steps)
+	if err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
 
+// CreateDynamicPipelineSteps inserts the steps of a pipeline in a single statement
+func (r *PipelineRepository) CreateDynamicPipelineSteps(ctx context.Context, pipelineID string, steps []domain.DynamicPipelineStep) error {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	parentID, err := uuid.Parse(pipelineID)
+	if err != nil {
+		return fmt.Errorf("invalid pipeline ID %q: %w", pipelineID, err)
+	}
+
+	placeholders := make([]string, 0, len(steps))
+	args := make([]any, 0, len(steps)*12)
+	for i := range steps {
+		step := &steps[i]
+		if step.ID == domain.ID(uuid.Nil) {
+			step.ID = domain.NewID()
+		}
+		step.PipelineID = parentID
+
+		keywordsJSON, _ := json.Marshal(step.Keywords)
+		outputJSON, _ := json.Marshal(step.Output)
+		keywordsPerCategoryJSON, _ := json.Marshal(step.KeywordsPerCategory)
+
+		var errorMessage string
+		if step.Error != nil {
+			errorMessage = step.Error.Error()
+		}
+
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())")
+		args = append(args,
+			step.ID, step.PipelineID, string(step.DomainType), step.SearchParameter, string(step.Category),
+			keywordsJSON, step.Success, errorMessage, step.SkipReason, outputJSON, keywordsPerCategoryJSON, step.Depth,
+			parentStepID(step),
+		)
+	}
+
+	query := `
+		INSERT INTO dynamic_pipeline_steps (
+			id, pipeline_id, domain_type, search_parameter, category, keywords, success, error_message, 
+			skip_reason, output, keywords_per_category, depth, parent_step_id, created_at, updated_at
+		) VALUES ` + strings.Join(placeholders, ", ")
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error creating pipeline steps: %w", err)
+	}
+
+	stepPointers := make([]*domain.DynamicPipelineStep, 0, len(steps))
+	for i := range steps {
+		stepPointers = append(stepPointers, &steps[i])
+	}
+
+	return r.createStepKeywords(ctx, stepPointers...)
+}
+
 // CreateDynamicPipelineSteps inserts individual pipeline steps
 func (r *PipelineRepository) CreateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error {
 	if step.ID == domain.ID(uuid.Nil) {
@@ -92,8 +153,8 @@ func (r *PipelineRepository) CreateDynamicPipelineStep(ctx context.Context, step
 	query := `
 		INSERT INTO dynamic_pipeline_steps (
 			id, pipeline_id, domain_type, search_parameter, category, keywords, success, error_message, 
-			output, keywords_per_category, depth, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+			skip_reason, output, keywords_per_category, depth, parent_step_id, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
 	keywordsJSON, _ := json.Marshal(step.Keywords)
@@ -107,15 +168,202 @@ func (r *PipelineRepository) CreateDynamicPipelineStep(ctx context.Context, step
 
 	_, err := r.db.ExecContext(ctx, query,
 		step.ID, step.PipelineID, string(step.DomainType), step.SearchParameter, string(step.Category),
-		keywordsJSON, step.Success, errorMessage, outputJSON, keywordsPerCategoryJSON, step.Depth,
+		keywordsJSON, step.Success, errorMessage, step.SkipReason, outputJSON, keywordsPerCategoryJSON, step.Depth,
+		parentStepID(step),
 	)
 	if err != nil {
 		return err
 	}
 
+	return r.createStepKeywords(ctx, step)
+}
+
+// parentStepID returns the parent step ID to store for step, NULL for the
+// initial steps
+func parentStepID(step *domain.DynamicPipelineStep) any {
+	if step.ParentStepID == domain.ID(uuid.Nil) {
+		return nil
+	}
+	return step.ParentStepID
+}
+
+// createStepKeywords stores the keywords found by steps one row per keyword
+func (r *PipelineRepository) createStepKeywords(ctx context.Context, steps ...*domain.DynamicPipelineStep) error {
+	var keywords []domain.StepKeyword
+	for _, step := range steps {
+		keywords = append(keywords, stepKeywords(step)...)
+	}
+	if len(keywords) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(keywords))
+	args := make([]any, 0, len(keywords)*5)
+	for _, keyword := range keywords {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, NOW())")
+		args = append(args, domain.NewID(), keyword.StepID, string(keyword.Category), keyword.Keyword, keyword.NormalizedKeyword)
+	}
+
+	query := `
+		INSERT INTO step_keywords (
+			id, step_id, category, keyword, normalized_keyword, created_at
+		) VALUES ` + strings.Join(placeholders, ", ")
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error creating step keywords: %w", err)
+	}
+
 	return nil
 }
 
+// stepKeywords lists the distinct keywords of a step per category
+func stepKeywords(step *domain.DynamicPipelineStep) []domain.StepKeyword {
+	var keywords []domain.StepKeyword
+	seen := make(map[string]bool)
+
+	categories := make([]domain.KeywordCategory, 0, len(step.KeywordsPerCategory))
+	for category := range step.KeywordsPerCategory {
+		categories = append(categories, category)
+	}
+	slices.Sort(categories)
+
+	for _, category := range categories {
+		for _, keyword := range step.KeywordsPerCategory[category] {
+			normalized := domain.NormalizeKeyword(keyword)
+			if normalized == "" || seen[string(category)+"|"+normalized] {
+				continue
+			}
+			seen[string(category)+"|"+normalized] = true
+
+			// normalized_keyword is indexed and capped at 255 characters
+			if runes := []rune(normalized); len(runes) > 255 {
+				normalized = string(runes[:255])
+			}
+
+			keywords = append(keywords, domain.StepKeyword{
+				StepID:            step.ID,
+				DomainType:        step.DomainType,
+				Category:          category,
+				Keyword:           strings.TrimSpace(keyword),
+				NormalizedKeyword: normalized,
+			})
+		}
+	}
+
+	return keywords
+}
+
+// GetStepKeywordsByPipelineID retrieves the keywords found by the steps of a
+// pipeline, optionally restricted to a category
+func (r *PipelineRepository) GetStepKeywordsByPipelineID(ctx context.Context, pipelineID string, category domain.KeywordCategory) ([]domain.StepKeyword, error) {
+	query := `
+		SELECT k.step_id, s.domain_type, k.category, k.keyword, k.normalized_keyword
+		FROM step_keywords k
+		JOIN dynamic_pipeline_steps s ON s.id = k.step_id
+		WHERE s.pipeline_id = ?
+	`
+	args := []any{pipelineID}
+	if category != "" {
+		query += " AND k.category = ?"
+		args = append(args, string(category))
+	}
+	query += " ORDER BY k.category, k.normalized_keyword"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding step keywords: %w", err)
+	}
+	defer rows.Close()
+
+	keywords := []domain.StepKeyword{}
+	for rows.Next() {
+		var keyword domain.StepKeyword
+		var domainType, keywordCategory string
+
+		if err := rows.Scan(&keyword.StepID, &domainType, &keywordCategory, &keyword.Keyword, &keyword.NormalizedKeyword); err != nil {
+			return nil, err
+		}
+
+		keyword.DomainType = domain.DomainType(domainType)
+		keyword.Category = domain.KeywordCategory(keywordCategory)
+		keywords = append(keywords, keyword)
+	}
+
+	return keywords, rows.Err()
+}
+
+// GetPreviouslySeenKeywords returns the keys of the keywords of a pipeline that
+// the steps of a pipeline created before it had already found
+func (r *PipelineRepository) GetPreviouslySeenKeywords(ctx context.Context, pipelineID string) (map[string]bool, error) {
+	query := `
+		SELECT DISTINCT k.category, k.normalized_keyword
+		FROM step_keywords k
+		JOIN dynamic_pipeline_steps s ON s.id = k.step_id
+		JOIN dynamic_pipeline_results p ON p.id = s.pipeline_id
+		JOIN dynamic_pipeline_results current ON current.id = ?
+		WHERE p.id <> current.id
+		  AND p.created_at < current.created_at
+		  AND EXISTS (
+			SELECT 1
+			FROM step_keywords ck
+			JOIN dynamic_pipeline_steps cs ON cs.id = ck.step_id
+			WHERE cs.pipeline_id = current.id
+			  AND ck.category = k.category
+			  AND ck.normalized_keyword = k.normalized_keyword
+		  )
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding previously seen keywords: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var keyword domain.StepKeyword
+		var category string
+		if err := rows.Scan(&category, &keyword.NormalizedKeyword); err != nil {
+			return nil, err
+		}
+		keyword.Category = domain.KeywordCategory(category)
+		seen[keyword.Key()] = true
+	}
+
+	return seen, rows.Err()
+}
+
+// UpdateDynamicPipelineStep updates the outcome of a pipeline step
+func (r *PipelineRepository) UpdateDynamicPipelineStep(ctx context.Context, step *domain.DynamicPipelineStep) error {
+	query := `
+		UPDATE dynamic_pipeline_steps SET
+			success = ?, error_message = ?, output = ?, keywords_per_category = ?, compressed = FALSE, updated_at = NOW()
+		WHERE id = ?
+	`
+
+	outputJSON, _ := json.Marshal(step.Output)
+	keywordsPerCategoryJSON, _ := json.Marshal(step.KeywordsPerCategory)
+
+	var errorMessage string
+	if step.Error != nil {
+		errorMessage = step.Error.Error()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		step.Success, errorMessage, outputJSON, keywordsPerCategoryJSON, step.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating pipeline step: %w", err)
+	}
+
+	// Replace the keywords of the previous attempt
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM step_keywords WHERE step_id = ?`, step.ID); err != nil {
+		return fmt.Errorf("error deleting step keywords: %w", err)
+	}
+
+	return r.createStepKeywords(ctx, step)
+}
+
 // GetByID retrieves a pipeline result by its ID
 func (r *PipelineRepository) GetPipelineByID(ctx context.Context, id string) (*domain.DynamicPipelineResult, error) {
 	dynamicResult, err := r.getDynamicPipelineResultByID(ctx, id)
@@ -123,7 +371,7 @@ func (r *PipelineRepository) GetPipelineByID(ctx context.Context, id string) (*d
 		return dynamicResult, nil
 	}
 
-	return nil, fmt.Errorf("pipeline result not found with ID: %s", id)
+	return nil, fmt.Errorf("%w with ID: %s", ErrPipelineNotFound, id)
 }
 
 // GetByID retrieves a pipeline result by its ID
@@ -146,7 +394,7 @@ func (r *PipelineRepository) GetByID(ctx context.Context, id string) (any, error
 // getDomainSearchResultByID retrieves a DomainSearchResult by ID
 func (r *PipelineRepository) getDomainSearchResultByID(ctx context.Context, id string) (*domain.DomainSearchResult, error) {
 	query := `
-		SELECT success, error_message, domain_type, search_parameter, keywords_per_category, output, created_at, updated_at
+		SELECT success, error_message, domain_type, search_parameter, keywords_per_category, output
 		FROM domain_search_results 
 		WHERE id = ?
 	`
@@ -155,7 +403,7 @@ func (r *PipelineRepository) getDomainSearchResultByID(ctx context.Context, id s
 	var errorMessage, domainType, keywordsJSON, outputJSON string
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&result.Success, &errorMessage, &domainType, &result.SearchParameter, &keywordsJSON, &outputJSON,
+		&result.Success, &errorMessage, &domainType, &result.SearchParameter, jsonColumn{&keywordsJSON}, jsonColumn{&outputJSON},
 	)
 
 	if err != nil {
@@ -163,7 +411,7 @@ func (r *PipelineRepository) getDomainSearchResultByID(ctx context.Context, id s
 	}
 
 	if errorMessage != "" {
-		result.Error = fmt.Errorf(errorMessage)
+		result.Error = errors.New(errorMessage)
 	}
 	result.DomainType = domain.DomainType(domainType)
 	json.Unmarshal([]byte(keywordsJSON), &result.KeywordsPerCategory)
@@ -172,23 +420,70 @@ func (r *PipelineRepository) getDomainSearchResultByID(ctx context.Context, id s
 	return &result, nil
 }
 
+// GetCamaraRecordsByPerson retrieves the mercantile records stored in search
+// results whose output mentions the given name
+func (r *PipelineRepository) GetCamaraRecordsByPerson(ctx context.Context, name string) ([]domain.CamaraRecord, error) {
+	// Compacted outputs cannot be matched in SQL, so they are matched once
+	// decompressed
+	query := `
+		SELECT id, output
+		FROM domain_search_results 
+		WHERE domain_type = ? AND success = TRUE
+		  AND (compressed = TRUE OR CONVERT(output USING utf8mb4) LIKE ?)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, string(domain.DomainTypeCamara), "%"+name+"%")
+	if err != nil {
+		return nil, fmt.Errorf("error finding camara records by person: %w", err)
+	}
+	defer rows.Close()
+
+	var records []domain.CamaraRecord
+	for rows.Next() {
+		var id domain.ID
+		var outputJSON string
+		if err := rows.Scan(&id, jsonColumn{&outputJSON}); err != nil {
+			return nil, err
+		}
+		if !strings.Contains(strings.ToLower(outputJSON), strings.ToLower(name)) {
+			continue
+		}
+
+		var output []domain.CamaraRecord
+		if err := json.Unmarshal([]byte(outputJSON), &output); err != nil {
+			continue
+		}
+
+		for _, record := range output {
+			record.DomainSearchResultID = id
+			records = append(records, record)
+		}
+	}
+
+	return records, rows.Err()
+}
+
 // getDynamicPipelineResultByID retrieves a DynamicPipelineResult by ID
 func (r *PipelineRepository) getDynamicPipelineResultByID(ctx context.Context, id string) (*domain.DynamicPipelineResult, error) {
 	query := `
-		SELECT id, total_steps, successful_steps, failed_steps, max_depth_reached, config, created_at, updated_at
+		SELECT id, session_id, total_steps, successful_steps, failed_steps, max_depth_reached, stop_reason, config, created_at, updated_at
 		FROM dynamic_pipeline_results 
 		WHERE id = ?
 	`
 
 	var result domain.DynamicPipelineResult
+	var sessionID sql.NullString
 	var configJSON string
 	var createdAt, updatedAt string
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&result.ID,
+		&sessionID,
 		&result.TotalSteps,
 		&result.SuccessfulSteps,
 		&result.FailedSteps,
 		&result.MaxDepthReached,
+		&result.StopReason,
 		&configJSON,
 		&createdAt,
 		&updatedAt,
@@ -198,6 +493,7 @@ func (r *PipelineRepository) getDynamicPipelineResultByID(ctx context.Context, i
 		return nil, err
 	}
 
+	result.SessionID = sessionID.String
 	json.Unmarshal([]byte(configJSON), &result.Config)
 	result.CreatedAt, _ = time.Parse(time.DateTime, createdAt)
 	result.UpdatedAt, _ = time.Parse(time.DateTime, updatedAt)
@@ -215,7 +511,7 @@ func (r *PipelineRepository) getDynamicPipelineResultByID(ctx context.Context, i
 func (r *PipelineRepository) GetPipelineStepsByID(ctx context.Context, id string) ([]domain.DynamicPipelineStep, error) {
 	query := `
 		SELECT id, domain_type, search_parameter, category, keywords, success, error_message, 
-			   output, keywords_per_category, depth
+			   skip_reason, output, keywords_per_category, depth, parent_step_id
 		FROM dynamic_pipeline_steps 
 		WHERE pipeline_id = ?
 		ORDER BY created_at ASC
@@ -231,27 +527,34 @@ func (r *PipelineRepository) GetPipelineStepsByID(ctx context.Context, id string
 	for rows.Next() {
 		var step domain.DynamicPipelineStep
 		var domainType, category, keywordsJSON, outputJSON, keywordsPerCategoryJSON, errorMessage string
+		var parentID sql.NullString
 
 		err := rows.Scan(
 			&step.ID,
-			&domainType,              // domain_type
-			&step.SearchParameter,    // search_parameter
-			&category,                // category
-			&keywordsJSON,            // keywords
-			&step.Success,            // success
-			&errorMessage,            // error_message
-			&outputJSON,              // output
-			&keywordsPerCategoryJSON, // keywords_per_category
-			&step.Depth,              // depth
+			&domainType,                          // domain_type
+			&step.SearchParameter,                // search_parameter
+			&category,                            // category
+			&keywordsJSON,                        // keywords
+			&step.Success,                        // success
+			&errorMessage,                        // error_message
+			&step.SkipReason,                     // skip_reason
+			jsonColumn{&outputJSON},              // output
+			jsonColumn{&keywordsPerCategoryJSON}, // keywords_per_category
+			&step.Depth,                          // depth
+			&parentID,                            // parent_step_id
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		if parentID.Valid {
+			step.ParentStepID, _ = uuid.Parse(parentID.String)
+		}
+
 		step.DomainType = domain.DomainType(domainType)
 		step.Category = domain.KeywordCategory(category)
 		if errorMessage != "" {
-			step.Error = fmt.Errorf(errorMessage)
+			step.Error = errors.New(errorMessage)
 		}
 		json.Unmarshal([]byte(keywordsJSON), &step.Keywords)
 		json.Unmarshal([]byte(outputJSON), &step.Output)
@@ -280,7 +583,7 @@ func (r *PipelineRepository) updateDomainSearchResult(ctx context.Context, resul
 	query := `
 		UPDATE domain_search_results SET
 			success = ?, error_message = ?, domain_type = ?, search_parameter = ?, 
-			keywords_per_category = ?, output = ?, updated_at = NOW()
+			keywords_per_category = ?, output = ?, compressed = FALSE, updated_at = NOW()
 		WHERE id = ?
 	`
 
@@ -305,7 +608,7 @@ func (r *PipelineRepository) UpdateDynamicPipelineResult(ctx context.Context, re
 	query := `
 		UPDATE dynamic_pipeline_results SET
 			total_steps = ?, successful_steps = ?, failed_steps = ?, max_depth_reached = ?, 
-			config = ?, updated_at = NOW()
+			stop_reason = ?, config = ?, updated_at = NOW()
 		WHERE id = ?
 	`
 
@@ -313,7 +616,7 @@ func (r *PipelineRepository) UpdateDynamicPipelineResult(ctx context.Context, re
 
 	_, err := r.db.ExecContext(ctx, query,
 		result.TotalSteps, result.SuccessfulSteps, result.FailedSteps, result.MaxDepthReached,
-		configJSON, result.ID,
+		result.StopReason, configJSON, result.ID,
 	)
 	if err != nil {
 		return err
@@ -343,32 +646,146 @@ func (r *PipelineRepository) Delete(ctx context.Context, id string) error {
 
 // List retrieves multiple pipeline results with pagination
 func (r *PipelineRepository) List(ctx context.Context, offset, limit int) ([]*domain.DynamicPipelineResult, error) {
+	return r.ListWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
+
+// pipelineListQuery lists the pipelines, a domain type matching the pipelines
+// with a step in the domain
+var pipelineListQuery = listQuery{
+	sortable:     []string{"created_at", "updated_at", "total_steps", "successful_steps", "failed_steps", "max_depth_reached"},
+	domainFilter: "id IN (SELECT pipeline_id FROM dynamic_pipeline_steps WHERE domain_type = ?)",
+}
+
+// ListWithOptions retrieves the pipeline results sorted and filtered by opts
+func (r *PipelineRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]*domain.DynamicPipelineResult, error) {
+	clauses, args, err := pipelineListQuery.clauses(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get DomainSearchResult and DynamicPipelineResult
 	domainQuery := `
-		SELECT id, total_steps, successful_steps, failed_steps, max_depth_reached, config, created_at, updated_at
+		SELECT id, session_id, total_steps, successful_steps, failed_steps, max_depth_reached, stop_reason, config, created_at, updated_at
 		FROM dynamic_pipeline_results 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + clauses
+
+	rows, err := r.db.QueryContext(ctx, domainQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDynamicPipelineResults(rows)
+}
+
+// ListSummaries lists pipelines newest first as summaries, reading neither
+// their steps nor more of their config than the query they ran
+func (r *PipelineRepository) ListSummaries(ctx context.Context, offset, limit int) ([]domain.PipelineSummary, error) {
+	return r.ListSummariesWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
 
-	rows, err := r.db.QueryContext(ctx, domainQuery, limit, offset)
+// ListSummariesWithOptions lists pipelines sorted and filtered by opts as
+// summaries, like ListSummaries
+func (r *PipelineRepository) ListSummariesWithOptions(ctx context.Context, opts ListOptions) ([]domain.PipelineSummary, error) {
+	clauses, args, err := pipelineListQuery.clauses(opts)
 	if err != nil {
 		return nil, err
 	}
+
+	query := `
+		SELECT id, session_id, total_steps, successful_steps, failed_steps, max_depth_reached, stop_reason, config, created_at, updated_at
+		FROM dynamic_pipeline_results 
+		` + clauses
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pipelines: %w", err)
+	}
 	defer rows.Close()
 
+	summaries := []domain.PipelineSummary{}
+	for rows.Next() {
+		var summary domain.PipelineSummary
+		var sessionID, stopReason, configJSON sql.NullString
+		err := rows.Scan(
+			&summary.ID,
+			&sessionID,
+			&summary.TotalSteps,
+			&summary.SuccessfulSteps,
+			&summary.FailedSteps,
+			&summary.MaxDepthReached,
+			&stopReason,
+			&configJSON,
+			timestamp{&summary.CreatedAt},
+			timestamp{&summary.UpdatedAt},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var config struct {
+			Query string `json:"query"`
+		}
+		json.Unmarshal([]byte(configJSON.String), &config)
+
+		summary.Target = config.Query
+		summary.SessionID = sessionID.String
+		summary.StopReason = stopReason.String
+		summary.Status = domain.PipelineStatus(summary.TotalSteps, summary.SuccessfulSteps, summary.FailedSteps, summary.StopReason)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetPipelinesBySessionID retrieves the pipelines of an investigation session
+// with their steps, oldest first
+func (r *PipelineRepository) GetPipelinesBySessionID(ctx context.Context, sessionID string) ([]*domain.DynamicPipelineResult, error) {
+	query := `
+		SELECT id, session_id, total_steps, successful_steps, failed_steps, max_depth_reached, stop_reason, config, created_at, updated_at
+		FROM dynamic_pipeline_results 
+		WHERE session_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding session pipelines: %w", err)
+	}
+	results, err := scanDynamicPipelineResults(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		steps, err := r.GetPipelineStepsByID(ctx, result.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		result.Steps = steps
+	}
+
+	return results, nil
+}
+
+// scanDynamicPipelineResults scans the pipeline rows selected without their steps
+func scanDynamicPipelineResults(rows *sql.Rows) ([]*domain.DynamicPipelineResult, error) {
 	var results []*domain.DynamicPipelineResult
 	for rows.Next() {
 		var result domain.DynamicPipelineResult
+		var sessionID sql.NullString
 		var configJSON string
 		var createdAt, updatedAt string
 		// Need to scan all the columns selected in the query
 		err := rows.Scan(
 			&result.ID,
+			&sessionID,
 			&result.TotalSteps,
 			&result.SuccessfulSteps,
 			&result.FailedSteps,
 			&result.MaxDepthReached,
+			&result.StopReason,
 			&configJSON, // config
 			&createdAt,  // created_at
 			&updatedAt,  // updated_at
@@ -377,13 +794,23 @@ func (r *PipelineRepository) List(ctx context.Context, offset, limit int) ([]*do
 			return nil, err
 		}
 
+		result.SessionID = sessionID.String
 		json.Unmarshal([]byte(configJSON), &result.Config)
 		result.CreatedAt, _ = time.Parse(time.DateTime, createdAt)
 		result.UpdatedAt, _ = time.Parse(time.DateTime, updatedAt)
 		results = append(results, &result)
 	}
 
-	return results, nil
+	return results, rows.Err()
+}
+
+// CountPipelines returns the number of dynamic pipelines, the records
+// ListSummaries lists
+func (r *PipelineRepository) CountPipelines(ctx context.Context) (int64, error) {
+	query := `SELECT COUNT(*) FROM dynamic_pipeline_results`
+	var count int64
+	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	return count, err
 }
 
 // Count returns the total number of pipeline results
@@ -402,7 +829,7 @@ func (r *PipelineRepository) Count(ctx context.Context) (int64, error) {
 // GetByDomainType retrieves pipeline results by domain type
 func (r *PipelineRepository) GetByDomainType(ctx context.Context, domainType domain.DomainType, offset, limit int) ([]any, error) {
 	query := `
-		SELECT id, success, error_message, domain_type, search_parameter, keywords_per_category, output, created_at, updated_at
+		SELECT id, success, error_message, domain_type, search_parameter, keywords_per_category, output
 		FROM domain_search_results 
 		WHERE domain_type = ?
 		ORDER BY created_at DESC
@@ -421,14 +848,14 @@ func (r *PipelineRepository) GetByDomainType(ctx context.Context, domainType dom
 		var id, errorMessage, domainTypeStr, keywordsJSON, outputJSON string
 
 		err := rows.Scan(
-			&id, &result.Success, &errorMessage, &domainTypeStr, &result.SearchParameter, &keywordsJSON, &outputJSON,
+			&id, &result.Success, &errorMessage, &domainTypeStr, &result.SearchParameter, jsonColumn{&keywordsJSON}, jsonColumn{&outputJSON},
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		if errorMessage != "" {
-			result.Error = fmt.Errorf(errorMessage)
+			result.Error = errors.New(errorMessage)
 		}
 		result.DomainType = domain.DomainType(domainTypeStr)
 		json.Unmarshal([]byte(keywordsJSON), &result.KeywordsPerCategory)
@@ -443,7 +870,7 @@ func (r *PipelineRepository) GetByDomainType(ctx context.Context, domainType dom
 // GetBySuccessStatus retrieves pipeline results by success status
 func (r *PipelineRepository) GetBySuccessStatus(ctx context.Context, success bool, offset, limit int) ([]any, error) {
 	query := `
-		SELECT id, success, error_message, domain_type, search_parameter, keywords_per_category, output, created_at, updated_at
+		SELECT id, success, error_message, domain_type, search_parameter, keywords_per_category, output
 		FROM domain_search_results 
 		WHERE success = ?
 		ORDER BY created_at DESC
@@ -462,14 +889,14 @@ func (r *PipelineRepository) GetBySuccessStatus(ctx context.Context, success boo
 		var id, errorMessage, domainType, keywordsJSON, outputJSON string
 
 		err := rows.Scan(
-			&id, &result.Success, &errorMessage, &domainType, &result.SearchParameter, &keywordsJSON, &outputJSON,
+			&id, &result.Success, &errorMessage, &domainType, &result.SearchParameter, jsonColumn{&keywordsJSON}, jsonColumn{&outputJSON},
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		if errorMessage != "" {
-			result.Error = fmt.Errorf(errorMessage)
+			result.Error = errors.New(errorMessage)
 		}
 		result.DomainType = domain.DomainType(domainType)
 		json.Unmarshal([]byte(keywordsJSON), &result.KeywordsPerCategory)
@@ -484,7 +911,7 @@ func (r *PipelineRepository) GetBySuccessStatus(ctx context.Context, success boo
 // GetBySearchParameter retrieves pipeline results by search parameter
 func (r *PipelineRepository) GetBySearchParameter(ctx context.Context, searchParam string, offset, limit int) ([]any, error) {
 	query := `
-		SELECT id, success, error_message, domain_type, search_parameter, keywords_per_category, output, created_at, updated_at
+		SELECT id, success, error_message, domain_type, search_parameter, keywords_per_category, output
 		FROM domain_search_results 
 		WHERE search_parameter LIKE ?
 		ORDER BY created_at DESC
@@ -504,14 +931,14 @@ func (r *PipelineRepository) GetBySearchParameter(ctx context.Context, searchPar
 		var id, errorMessage, domainType, keywordsJSON, outputJSON string
 
 		err := rows.Scan(
-			&id, &result.Success, &errorMessage, &domainType, &result.SearchParameter, &keywordsJSON, &outputJSON,
+			&id, &result.Success, &errorMessage, &domainType, &result.SearchParameter, jsonColumn{&keywordsJSON}, jsonColumn{&outputJSON},
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		if errorMessage != "" {
-			result.Error = fmt.Errorf(errorMessage)
+			result.Error = errors.New(errorMessage)
 		}
 		result.DomainType = domain.DomainType(domainType)
 		json.Unmarshal([]byte(keywordsJSON), &result.KeywordsPerCategory)
@@ -534,18 +961,21 @@ func (r *PipelineRepository) GetKeywordsByCategory(ctx context.Context, resultID
 	case *domain.DomainSearchResult:
 		return v.KeywordsPerCategory, nil
 	case *domain.DynamicPipelineResult:
-		// Aggregate keywords from all steps
-		aggregated := make(map[domain.KeywordCategory][]string)
+		// Aggregate keywords from all steps, each keyword once per category
+		aggregated := domain.NewKeywordAggregator()
 		for _, step := range v.Steps {
-			for category, keywords := range step.KeywordsPerCategory {
-				if aggregated[category] == nil {
-					aggregated[category] = []string{}
-				}
-				aggregated[category] = append(aggregated[category], keywords...)
-			}
+			aggregated.AddAll(step.KeywordsPerCategory)
 		}
-		return aggregated, nil
+		return aggregated.KeywordsPerCategory(), nil
 	default:
 		return nil, fmt.Errorf("unsupported result type: %T", result)
 	}
 }
+
+// nullableString stores empty strings as NULL
+func nullableString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreatePipelineEvent appends an event to the audit log of its pipeline
+func (r *PipelineRepository) CreatePipelineEvent(ctx context.Context, event *domain.PipelineEvent) error {
+	var data any
+	if len(event.Data) > 0 {
+		dataJSON, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("error encoding pipeline event data: %w", err)
+		}
+		data = dataJSON
+	}
+
+	var stepID any
+	if event.StepID != domain.ID(uuid.Nil) {
+		stepID = event.StepID
+	}
+
+	query := `
+		INSERT INTO pipeline_events (pipeline_id, step_id, type, message, data)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, event.PipelineID, stepID, string(event.Type), event.Message, data)
+	if err != nil {
+		return fmt.Errorf("error creating pipeline event: %w", err)
+	}
+
+	event.Seq, _ = result.LastInsertId()
+	event.CreatedAt = time.Now()
+	return nil
+}
+
+// ListPipelineEvents returns the events of a pipeline in the order they were
+// recorded
+func (r *PipelineRepository) ListPipelineEvents(ctx context.Context, pipelineID string) ([]domain.PipelineEvent, error) {
+	query := `
+		SELECT seq, pipeline_id, step_id, type, message, data, created_at
+		FROM pipeline_events
+		WHERE pipeline_id = ?
+		ORDER BY seq ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing pipeline events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []domain.PipelineEvent{}
+	for rows.Next() {
+		var event domain.PipelineEvent
+		var stepID, message sql.NullString
+		var data []byte
+		var eventType, createdAt string
+		if err := rows.Scan(&event.Seq, &event.PipelineID, &stepID, &eventType, &message, &data, &createdAt); err != nil {
+			return nil, err
+		}
+
+		if stepID.Valid {
+			event.StepID, _ = uuid.Parse(stepID.String)
+		}
+		event.Type = domain.PipelineEventType(eventType)
+		event.Message = message.String
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &event.Data); err != nil {
+				return nil, fmt.Errorf("error decoding pipeline event %d: %w", event.Seq, err)
+			}
+		}
+		event.CreatedAt, _ = time.Parse(time.DateTime, createdAt)
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"insightful-intel/internal/domain"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateDynamicPipelineStepStoresKeywords(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	step := &domain.DynamicPipelineStep{
+		ID:         domain.NewID(),
+		PipelineID: domain.NewID(),
+		DomainType: domain.DomainTypeONAPI,
+		Success:    true,
+		KeywordsPerCategory: map[domain.KeywordCategory][]string{
+			domain.KeywordCategoryPersonName:  {" Juan  Perez ", "JUAN PEREZ", ""},
+			domain.KeywordCategoryCompanyName: {"Novasco SRL"},
+		},
+	}
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_steps").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO step_keywords").
+		WithArgs(
+			sqlmock.AnyArg(), step.ID, "company_name", "Novasco SRL", "novasco srl",
+			sqlmock.AnyArg(), step.ID, "person_name", "Juan  Perez", "juan perez",
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := repos.GetPipelineRepository().CreateDynamicPipelineStep(context.Background(), step); err != nil {
+		t.Fatalf("CreateDynamicPipelineStep returned error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetStepKeywordsByPipelineID(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	pipelineID := domain.NewID().String()
+	stepID := domain.NewID()
+
+	mock.ExpectQuery("FROM step_keywords").
+		WithArgs(pipelineID, "person_name").
+		WillReturnRows(sqlmock.NewRows([]string{"step_id", "domain_type", "category", "keyword", "normalized_keyword"}).
+			AddRow(stepID.String(), "ONAPI", "person_name", "Juan Perez", "juan perez"))
+
+	keywords, err := repos.GetPipelineRepository().GetStepKeywordsByPipelineID(context.Background(), pipelineID, domain.KeywordCategoryPersonName)
+	if err != nil {
+		t.Fatalf("GetStepKeywordsByPipelineID returned error: %v", err)
+	}
+
+	if len(keywords) != 1 {
+		t.Fatalf("expected 1 keyword, got %d", len(keywords))
+	}
+	if keywords[0].StepID != stepID || keywords[0].DomainType != domain.DomainTypeONAPI || keywords[0].Keyword != "Juan Perez" {
+		t.Errorf("unexpected keyword: %+v", keywords[0])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreateDynamicPipelineResultStoresSteps(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	pipelineRepo := repos.GetPipelineRepository()
+
+	parentID := domain.NewID()
+	result := &domain.DynamicPipelineResult{
+		ID:         domain.NewID(),
+		TotalSteps: 2,
+		Steps: []domain.DynamicPipelineStep{
+			{ID: parentID, DomainType: domain.DomainTypeONAPI, SearchParameter: "novasco", Category: domain.KeywordCategoryCompanyName, Success: true},
+			{DomainType: domain.DomainTypeDGII, SearchParameter: "novasco", Category: domain.KeywordCategoryContributorID, Depth: 1, ParentStepID: parentID},
+		},
+	}
+
+	mock.ExpectExec("INSERT INTO dynamic_pipeline_results").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO dynamic_pipeline_steps .* VALUES \(.*NOW\(\), NOW\(\)\), \(.*NOW\(\), NOW\(\)\)`).
+		WithArgs(
+			parentID, result.ID, "ONAPI", "novasco", "company_name", sqlmock.AnyArg(), true, "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), 0, nil,
+			sqlmock.AnyArg(), result.ID, "DGII", "novasco", "contributor_id", sqlmock.AnyArg(), false, "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), 1, parentID,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if _, err := pipelineRepo.CreateDynamicPipelineResult(context.Background(), result); err != nil {
+		t.Fatalf("CreateDynamicPipelineResult returned error: %v", err)
+	}
+
+	for _, step := range result.Steps {
+		if step.PipelineID != result.ID {
+			t.Errorf("expected step pipeline ID %s, got %s", result.ID, step.PipelineID)
+		}
+	}
+
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(result.ID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(result.ID.String(), nil, 2, 1, 0, 1, "", "{}", "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(result.ID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).
+			AddRow(result.Steps[0].ID.String(), "ONAPI", "novasco", "company_name", "null", true, "", "", "null", "null", 0, nil).
+			AddRow(result.Steps[1].ID.String(), "DGII", "novasco", "contributor_id", "null", false, "", "", "null", "null", 1, parentID.String()))
+
+	stored, err := pipelineRepo.GetPipelineByID(context.Background(), result.ID.String())
+	if err != nil {
+		t.Fatalf("GetPipelineByID returned error: %v", err)
+	}
+	if len(stored.Steps) != 2 || stored.Steps[0].ID != result.Steps[0].ID || stored.Steps[1].DomainType != domain.DomainTypeDGII || stored.Steps[1].ParentStepID != parentID {
+		t.Errorf("unexpected stored steps: %+v", stored.Steps)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetKeywordsByCategoryDeduplicatesAcrossSteps(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	pipelineID := domain.NewID()
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(pipelineID.String()).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(pipelineID.String(), nil, 3, 3, 0, 1, "", "{}", "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).
+			AddRow(domain.NewID().String(), "ONAPI", "novasco", "company_name", "null", true, "", "", "null",
+				`{"company_name":["NOVASCO","NOVASCO REAL ESTATE"],"person_name":["Juan Perez"]}`, 0, nil).
+			AddRow(domain.NewID().String(), "DGII", "novasco", "company_name", "null", true, "", "", "null",
+				`{"company_name":["novasco ","NOVASCO INMOBILIARIA","NOVASCO"]}`, 1, nil).
+			AddRow(domain.NewID().String(), "SCJ", "novasco", "company_name", "null", true, "", "", "null",
+				`{"company_name":["Novasco Real Estate"],"person_name":[" JUAN PEREZ","Maria Gomez"]}`, 1, nil))
+
+	keywords, err := repos.GetPipelineRepository().GetKeywordsByCategory(context.Background(), pipelineID.String())
+	if err != nil {
+		t.Fatalf("GetKeywordsByCategory returned error: %v", err)
+	}
+
+	// Each keyword once, in the order the steps first found it
+	if got, want := keywords[domain.KeywordCategoryCompanyName], []string{"NOVASCO", "NOVASCO REAL ESTATE", "NOVASCO INMOBILIARIA"}; !slices.Equal(got, want) {
+		t.Errorf("expected company names %q, got %q", want, got)
+	}
+	if got, want := keywords[domain.KeywordCategoryPersonName], []string{"Juan Perez", "Maria Gomez"}; !slices.Equal(got, want) {
+		t.Errorf("expected person names %q, got %q", want, got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestStoredErrorMessagesRoundTrip(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	pipelineRepo := repos.GetPipelineRepository().(*PipelineRepository)
+
+	const message = "quota at 100% for %s (50%d used)"
+
+	pipelineID := domain.NewID().String()
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).AddRow(domain.NewID().String(), "DGII", "novasco", "company_name", "null", false, message, "", "null", "null", 0, nil))
+
+	steps, err := pipelineRepo.GetPipelineStepsByID(context.Background(), pipelineID)
+	if err != nil {
+		t.Fatalf("GetPipelineStepsByID returned error: %v", err)
+	}
+	if len(steps) != 1 || steps[0].Error == nil || steps[0].Error.Error() != message {
+		t.Errorf("expected step error %q, got %+v", message, steps)
+	}
+
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(string(domain.DomainTypeDGII), 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "success", "error_message", "domain_type", "search_parameter", "keywords_per_category", "output",
+		}).AddRow(domain.NewID().String(), false, message, "DGII", "novasco", "null", "null"))
+
+	results, err := pipelineRepo.GetByDomainType(context.Background(), domain.DomainTypeDGII, 0, 10)
+	if err != nil {
+		t.Fatalf("GetByDomainType returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if result := results[0].(*domain.DomainSearchResult); result.Error == nil || result.Error.Error() != message {
+		t.Errorf("expected result error %q, got %v", message, result.Error)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
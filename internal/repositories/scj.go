@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"insightful-intel/internal/database"
 	"insightful-intel/internal/domain"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // ScjRepository implements DomainRepository for SCJ ScjCase domain type
@@ -32,9 +35,9 @@ func (r *ScjRepository) CreateWithDomainSearchResultID(ctx context.Context, enti
 	query := `
 		INSERT INTO scj_cases 
 		(id, domain_search_result_id, linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo, id_expediente, 
-		no_expediente, no_sentencia, no_unico, no_interno, id_tribunal, desc_tribunal, id_materia, desc_materia, fecha_fallo, 
-		involucrados, guid_blob, tipo_documento_adjunto, total_filas, url_blob, extension, origen, activo, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		no_expediente, no_sentencia, no_unico, no_interno, id_tribunal, desc_tribunal, tribunal_canonico, id_materia, desc_materia, materia_canonica, fecha_fallo_raw, fecha_fallo, 
+		involucrados, search_ascii, guid_blob, tipo_documento_adjunto, total_filas, url_blob, extension, origen, activo, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -52,10 +55,14 @@ func (r *ScjRepository) CreateWithDomainSearchResultID(ctx context.Context, enti
 		entity.NoInterno,
 		entity.IDTribunal,
 		entity.DescTribunal,
+		entity.TribunalCanonico,
 		entity.IDMateria,
 		entity.DescMateria,
-		entity.FechaFallo,
+		entity.MateriaCanonica,
+		entity.FechaFalloRaw,
+		nullTime(domain.ParseScjDate(entity.FechaFalloRaw)),
 		entity.Involucrados,
+		scjSearchASCII(entity),
 		entity.GuidBlob,
 		entity.TipoDocumentoAdjunto,
 		entity.TotalFilas,
@@ -72,43 +79,89 @@ func (r *ScjRepository) CreateWithDomainSearchResultID(ctx context.Context, enti
 	return nil
 }
 
-// GetByID retrieves an SCJ case by its ID
-func (r *ScjRepository) GetByID(ctx context.Context, id int) (domain.ScjCase, error) {
-	query := `
-		SELECT linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo,
+// scjCaseColumns are the columns scanScjCase reads, in order
+const scjCaseColumns = `id, domain_search_result_id, linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo,
 			   id_expediente, no_expediente, no_sentencia, no_unico, no_interno,
-			   id_tribunal, desc_tribunal, id_materia, desc_materia, fecha_fallo,
+			   id_tribunal, desc_tribunal, tribunal_canonico, id_materia, desc_materia, materia_canonica, fecha_fallo_raw, fecha_fallo,
 			   involucrados, guid_blob, tipo_documento_adjunto, total_filas,
-			   url_blob, extension, origen, activo, created_at, updated_at
-		FROM scj_cases 
-		WHERE id_expediente = ?
-	`
+			   url_blob, extension, origen, activo, created_at, updated_at`
 
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanScjCase reads an SCJ case selected with scjCaseColumns
+func scanScjCase(row rowScanner) (domain.ScjCase, error) {
 	var entity domain.ScjCase
+	var tribunalCanonico, materiaCanonica sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
+		&entity.ID,
+		&entity.DomainSearchResultID,
 		&entity.Linea, &entity.AgnoCabecera, &entity.MesCabecera, &entity.URLCabecera, &entity.URLCuerpo,
 		&entity.IDExpediente, &entity.NoExpediente, &entity.NoSentencia, &entity.NoUnico, &entity.NoInterno,
-		&entity.IDTribunal, &entity.DescTribunal, &entity.IDMateria, &entity.DescMateria, &entity.FechaFallo,
+		&entity.IDTribunal, &entity.DescTribunal, &tribunalCanonico, &entity.IDMateria, &entity.DescMateria, &materiaCanonica, &entity.FechaFalloRaw, timestamp{&entity.FechaFallo},
 		&entity.Involucrados, &entity.GuidBlob, &entity.TipoDocumentoAdjunto, &entity.TotalFilas,
 		&entity.URLBlob, &entity.Extension, &entity.Origen, &entity.Activo,
+		timestamp{&entity.CreatedAt},
+		timestamp{&entity.UpdatedAt},
 	)
-
 	if err != nil {
 		return domain.ScjCase{}, err
 	}
 
+	entity.TribunalCanonico = tribunalCanonico.String
+	entity.MateriaCanonica = materiaCanonica.String
+
 	return entity, nil
 }
 
+// scanScjCases reads the SCJ cases selected with scjCaseColumns, closing rows
+func scanScjCases(rows *sql.Rows) ([]domain.ScjCase, error) {
+	defer rows.Close()
+
+	var entities []domain.ScjCase
+	for rows.Next() {
+		entity, err := scanScjCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+
+	return entities, rows.Err()
+}
+
+// GetByID retrieves an SCJ case by its ID. SCJ cases are also known by the
+// numeric id_expediente of the court, so a numeric id is looked up as one.
+func (r *ScjRepository) GetByID(ctx context.Context, id string) (domain.ScjCase, error) {
+	if idExpediente, err := strconv.Atoi(id); err == nil {
+		return r.GetByIDExpediente(ctx, idExpediente)
+	}
+	return r.GetByUUID(ctx, id)
+}
+
+// GetByUUID retrieves an SCJ case by the UUID it is stored under
+func (r *ScjRepository) GetByUUID(ctx context.Context, id string) (domain.ScjCase, error) {
+	query := `SELECT ` + scjCaseColumns + ` FROM scj_cases WHERE id = ?`
+	return scanScjCase(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetByIDExpediente retrieves an SCJ case by the id_expediente of the court
+func (r *ScjRepository) GetByIDExpediente(ctx context.Context, idExpediente int) (domain.ScjCase, error) {
+	query := `SELECT ` + scjCaseColumns + ` FROM scj_cases WHERE id_expediente = ?`
+	return scanScjCase(r.db.QueryRowContext(ctx, query, idExpediente))
+}
+
 // Update modifies an existing SCJ case
 func (r *ScjRepository) Update(ctx context.Context, idExpediente int, entity domain.ScjCase) error {
 	query := `
 		UPDATE scj_cases SET
 			linea = ?, agno_cabecera = ?, mes_cabecera = ?, url_cabecera = ?, url_cuerpo = ?,
 			no_expediente = ?, no_sentencia = ?, no_unico = ?, no_interno = ?,
-			id_tribunal = ?, desc_tribunal = ?, id_materia = ?, desc_materia = ?, fecha_fallo = ?,
-			involucrados = ?, guid_blob = ?, tipo_documento_adjunto = ?, total_filas = ?,
+			id_tribunal = ?, desc_tribunal = ?, tribunal_canonico = ?, id_materia = ?, desc_materia = ?, materia_canonica = ?, fecha_fallo_raw = ?, fecha_fallo = ?,
+			involucrados = ?, search_ascii = ?, guid_blob = ?, tipo_documento_adjunto = ?, total_filas = ?,
 			url_blob = ?, extension = ?, origen = ?, activo = ?, updated_at = NOW()
 		WHERE id_expediente = ?
 	`
@@ -116,63 +169,74 @@ func (r *ScjRepository) Update(ctx context.Context, idExpediente int, entity dom
 	_, err := r.db.ExecContext(ctx, query,
 		entity.Linea, entity.AgnoCabecera, entity.MesCabecera, entity.URLCabecera, entity.URLCuerpo,
 		entity.NoExpediente, entity.NoSentencia, entity.NoUnico, entity.NoInterno,
-		entity.IDTribunal, entity.DescTribunal, entity.IDMateria, entity.DescMateria, entity.FechaFallo,
-		entity.Involucrados, entity.GuidBlob, entity.TipoDocumentoAdjunto, entity.TotalFilas,
+		entity.IDTribunal, entity.DescTribunal, entity.TribunalCanonico, entity.IDMateria, entity.DescMateria, entity.MateriaCanonica, entity.FechaFalloRaw, nullTime(domain.ParseScjDate(entity.FechaFalloRaw)),
+		entity.Involucrados, scjSearchASCII(entity), entity.GuidBlob, entity.TipoDocumentoAdjunto, entity.TotalFilas,
 		entity.URLBlob, entity.Extension, entity.Origen, entity.Activo, idExpediente,
 	)
 
 	return err
 }
 
-// Delete removes an SCJ case by its ID
+// Delete removes an SCJ case by its ID, a numeric id being the id_expediente
+// of the court as in GetByID
 func (r *ScjRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM scj_cases WHERE id_expediente = ?`
-	_, err := r.db.ExecContext(ctx, query, id)
+	if idExpediente, err := strconv.Atoi(id); err == nil {
+		_, err := r.db.ExecContext(ctx, `DELETE FROM scj_cases WHERE id_expediente = ?`, idExpediente)
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM scj_cases WHERE id = ?`, id)
 	return err
 }
 
 // List retrieves multiple SCJ cases with pagination
 func (r *ScjRepository) List(ctx context.Context, offset, limit int) ([]domain.ScjCase, error) {
+	return r.ListWithOptions(ctx, ListOptions{Offset: offset, Limit: limit})
+}
+
+// scjListQuery lists the SCJ cases
+var scjListQuery = listQuery{
+	sortable:     []string{"created_at", "updated_at", "fecha_fallo", "id_expediente", "desc_tribunal", "desc_materia"},
+	domainFilter: searchResultDomainFilter,
+}
+
+// ListWithOptions retrieves the SCJ cases sorted and filtered by opts
+func (r *ScjRepository) ListWithOptions(ctx context.Context, opts ListOptions) ([]domain.ScjCase, error) {
+	clauses, args, err := scjListQuery.clauses(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT id, domain_search_result_id, linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo,
-			   id_expediente, no_expediente, no_sentencia, no_unico, no_interno,
-			   id_tribunal, desc_tribunal, id_materia, desc_materia, fecha_fallo,
-			   involucrados, guid_blob, tipo_documento_adjunto, total_filas,
-			   url_blob, extension, origen, activo, created_at, updated_at
+		SELECT ` + scjCaseColumns + `
 		FROM scj_cases 
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		` + clauses
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanScjCases(rows)
+}
 
-	var entities []domain.ScjCase
-	for rows.Next() {
-		var entity domain.ScjCase
-
-		err := rows.Scan(
-			&entity.ID,
-			&entity.DomainSearchResultID,
-			&entity.Linea, &entity.AgnoCabecera, &entity.MesCabecera, &entity.URLCabecera, &entity.URLCuerpo,
-			&entity.IDExpediente, &entity.NoExpediente, &entity.NoSentencia, &entity.NoUnico, &entity.NoInterno,
-			&entity.IDTribunal, &entity.DescTribunal, &entity.IDMateria, &entity.DescMateria, &entity.FechaFallo,
-			&entity.Involucrados, &entity.GuidBlob, &entity.TipoDocumentoAdjunto, &entity.TotalFilas,
-			&entity.URLBlob, &entity.Extension, &entity.Origen, &entity.Activo,
-			new(interface{}), // created_at (ignored)
-			new(interface{}),
-		)
-		if err != nil {
-			return nil, err
-		}
+// ListByFechaFallo retrieves the SCJ cases ruled between from and to, both
+// included and either unbounded when zero, the latest rulings first. Cases
+// whose ruling date did not parse are left out.
+func (r *ScjRepository) ListByFechaFallo(ctx context.Context, from, to time.Time, offset, limit int) ([]domain.ScjCase, error) {
+	query := `
+		SELECT ` + scjCaseColumns + `
+		FROM scj_cases
+		WHERE fecha_fallo IS NOT NULL
+		  AND (? IS NULL OR fecha_fallo >= ?)
+		  AND (? IS NULL OR fecha_fallo <= ?)
+		ORDER BY fecha_fallo DESC
+		LIMIT ? OFFSET ?
+	`
 
-		entities = append(entities, entity)
+	rows, err := r.db.QueryContext(ctx, query, nullTime(from), nullTime(from), nullTime(to), nullTime(to), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error listing scj cases by fecha_fallo: %w", err)
 	}
-
-	return entities, nil
+	return scanScjCases(rows)
 }
 
 // Count returns the total number of SCJ cases
@@ -183,119 +247,94 @@ func (r *ScjRepository) Count(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-// Search performs a search query on SCJ cases
-func (r *ScjRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.ScjCase, error) {
-	searchQuery := `
-		SELECT id, domain_search_result_id, linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo,
-			   id_expediente, no_expediente, no_sentencia, no_unico, no_interno,
-			   id_tribunal, desc_tribunal, id_materia, desc_materia, fecha_fallo,
-			   involucrados, guid_blob, tipo_documento_adjunto, total_filas,
-			   url_blob, extension, origen, activo, created_at, updated_at
-		FROM scj_cases 
-		WHERE no_expediente LIKE ? OR no_sentencia LIKE ? OR involucrados LIKE ? 
-		   OR desc_tribunal LIKE ? OR desc_materia LIKE ?
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+// CountByMateria returns the number of cases per canonical materia, grouping the
+// cases that could not be normalized under an empty key
+func (r *ScjRepository) CountByMateria(ctx context.Context) (map[string]int64, error) {
+	query := `SELECT COALESCE(materia_canonica, ''), COUNT(*) FROM scj_cases GROUP BY COALESCE(materia_canonica, '')`
 
-	searchPattern := "%" + query + "%"
-	rows, err := r.db.QueryContext(ctx, searchQuery,
-		searchPattern, searchPattern, searchPattern, searchPattern, searchPattern, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error counting scj cases by materia: %w", err)
 	}
 	defer rows.Close()
 
-	var entities []domain.ScjCase
+	counts := make(map[string]int64)
 	for rows.Next() {
-		var entity domain.ScjCase
-
-		err := rows.Scan(
-			&entity.Linea, &entity.AgnoCabecera, &entity.MesCabecera, &entity.URLCabecera, &entity.URLCuerpo,
-			&entity.IDExpediente, &entity.NoExpediente, &entity.NoSentencia, &entity.NoUnico, &entity.NoInterno,
-			&entity.IDTribunal, &entity.DescTribunal, &entity.IDMateria, &entity.DescMateria, &entity.FechaFallo,
-			&entity.Involucrados, &entity.GuidBlob, &entity.TipoDocumentoAdjunto, &entity.TotalFilas,
-			&entity.URLBlob, &entity.Extension, &entity.Origen, &entity.Activo,
-		)
-		if err != nil {
+		var materia string
+		var count int64
+		if err := rows.Scan(&materia, &count); err != nil {
 			return nil, err
 		}
+		counts[materia] = count
+	}
 
-		entities = append(entities, entity)
+	return counts, rows.Err()
+}
+
+// scjSearchColumns are the columns a search is looked up in. search_ascii holds
+// them without accents, the others still match the cases stored before it
+// existed.
+var scjSearchColumns = []string{"no_expediente", "no_sentencia", "involucrados", "desc_tribunal", "desc_materia", "search_ascii"}
+
+// scjSearchASCII is the search_ascii column of a case
+func scjSearchASCII(entity domain.ScjCase) string {
+	return searchASCII(entity.NoExpediente, entity.NoSentencia, entity.Involucrados, entity.DescTribunal, entity.DescMateria)
+}
+
+// Search performs a search query on SCJ cases, accents aside
+func (r *ScjRepository) Search(ctx context.Context, query string, offset, limit int) ([]domain.ScjCase, error) {
+	likes := make([]string, len(scjSearchColumns))
+	args := make([]any, 0, len(scjSearchColumns)+2)
+	searchPattern := "%" + domain.FoldAccents(query) + "%"
+	for i, column := range scjSearchColumns {
+		likes[i] = column + " LIKE ?"
+		args = append(args, searchPattern)
 	}
 
-	return entities, nil
+	searchQuery := `
+		SELECT ` + scjCaseColumns + `
+		FROM scj_cases 
+		WHERE ` + strings.Join(likes, " OR ") + `
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, searchQuery, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanScjCases(rows)
 }
 
 // SearchByCategory performs a search within a specific keyword category
 func (r *ScjRepository) SearchByCategory(ctx context.Context, category domain.KeywordCategory, query string, offset, limit int) ([]domain.ScjCase, error) {
-	var searchQuery string
+	var where string
 	searchPattern := "%" + query + "%"
+	args := []any{searchPattern}
 
 	switch category {
 	case domain.KeywordCategoryPersonName:
-		searchQuery = `
-			SELECT id, domain_search_result_id, linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo,
-				   id_expediente, no_expediente, no_sentencia, no_unico, no_interno,
-				   id_tribunal, desc_tribunal, id_materia, desc_materia, fecha_fallo,
-				   involucrados, guid_blob, tipo_documento_adjunto, total_filas,
-				   url_blob, extension, origen, activo, created_at, updated_at
-			FROM scj_cases 
-			WHERE involucrados LIKE ?
-			ORDER BY created_at DESC
-			LIMIT ? OFFSET ?
-		`
+		where = `involucrados LIKE ?`
 	case domain.KeywordCategoryCompanyName:
-		searchQuery = `
-			SELECT id, domain_search_result_id, linea, agno_cabecera, mes_cabecera, url_cabecera, url_cuerpo,
-				   id_expediente, no_expediente, no_sentencia, no_unico, no_interno,
-				   id_tribunal, desc_tribunal, id_materia, desc_materia, fecha_fallo,
-				   involucrados, guid_blob, tipo_documento_adjunto, total_filas,
-				   url_blob, extension, origen, activo, created_at, updated_at
-			FROM scj_cases 
-			WHERE desc_tribunal LIKE ? OR desc_materia LIKE ?
-			ORDER BY created_at DESC
-			LIMIT ? OFFSET ?
-		`
+		where = `desc_tribunal LIKE ? OR desc_materia LIKE ?`
+		args = append(args, searchPattern)
 	default:
 		return []domain.ScjCase{}, fmt.Errorf("unsupported category: %s", category)
 	}
 
-	var rows *sql.Rows
-	var err error
-
-	if category == domain.KeywordCategoryCompanyName {
-		rows, err = r.db.QueryContext(ctx, searchQuery,
-			searchPattern, searchPattern, limit, offset)
-	} else {
-		rows, err = r.db.QueryContext(ctx, searchQuery,
-			searchPattern, limit, offset)
-	}
+	searchQuery := `
+		SELECT ` + scjCaseColumns + `
+		FROM scj_cases 
+		WHERE ` + where + `
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`
 
+	rows, err := r.db.QueryContext(ctx, searchQuery, append(args, limit, offset)...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var entities []domain.ScjCase
-	for rows.Next() {
-		var entity domain.ScjCase
-
-		err := rows.Scan(
-			&entity.ID, &entity.DomainSearchResultID, &entity.Linea, &entity.AgnoCabecera, &entity.MesCabecera, &entity.URLCabecera, &entity.URLCuerpo,
-			&entity.IDExpediente, &entity.NoExpediente, &entity.NoSentencia, &entity.NoUnico, &entity.NoInterno,
-			&entity.IDTribunal, &entity.DescTribunal, &entity.IDMateria, &entity.DescMateria, &entity.FechaFallo,
-			&entity.Involucrados, &entity.GuidBlob, &entity.TipoDocumentoAdjunto, &entity.TotalFilas,
-			&entity.URLBlob, &entity.Extension, &entity.Origen, &entity.Activo,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		entities = append(entities, entity)
-	}
-
-	return entities, nil
+	return scanScjCases(rows)
 }
 
 // GetByDomainType retrieves SCJ cases by domain type
@@ -310,7 +349,7 @@ func (r *ScjRepository) GetBySearchParameter(ctx context.Context, searchParam st
 }
 
 // GetKeywordsByCategory retrieves keywords grouped by category for an SCJ case
-func (r *ScjRepository) GetKeywordsByCategory(ctx context.Context, entityID int) (map[domain.KeywordCategory][]string, error) {
+func (r *ScjRepository) GetKeywordsByCategory(ctx context.Context, entityID string) (map[domain.KeywordCategory][]string, error) {
 	entity, err := r.GetByID(ctx, entityID)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"insightful-intel/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var scjCaseColumnNames = []string{
+	"id", "domain_search_result_id", "linea", "agno_cabecera", "mes_cabecera", "url_cabecera", "url_cuerpo",
+	"id_expediente", "no_expediente", "no_sentencia", "no_unico", "no_interno",
+	"id_tribunal", "desc_tribunal", "tribunal_canonico", "id_materia", "desc_materia", "materia_canonica", "fecha_fallo_raw", "fecha_fallo",
+	"involucrados", "guid_blob", "tipo_documento_adjunto", "total_filas",
+	"url_blob", "extension", "origen", "activo", "created_at", "updated_at",
+}
+
+func scjCaseRow(id domain.ID, idExpediente int) []driver.Value {
+	return []driver.Value{
+		id.String(), domain.NewID().String(), 1, 2019, 5, "", "",
+		idExpediente, "2019-001", "", "", "",
+		"", "", "Primera Sala", "", "", nil, "29/05/2019", "2019-05-29 00:00:00",
+		"NOVASCO vs. ESTADO", "", "", 1,
+		"", "", 0, true, "2025-01-01 00:00:00", "2025-01-01 00:00:00",
+	}
+}
+
+func TestScjCreateNormalizesFechaFallo(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	tests := []struct {
+		raw  string
+		want driver.Value
+	}{
+		{"2019-05-29T00:00:00", time.Date(2019, time.May, 29, 0, 0, 0, 0, time.UTC)},
+		{"/Date(1559088000000)/", time.Date(2019, time.May, 29, 0, 0, 0, 0, time.UTC)},
+		{"29/05/2019", time.Date(2019, time.May, 29, 0, 0, 0, 0, time.UTC)},
+		{"fecha no disponible", nil},
+	}
+
+	for _, test := range tests {
+		args := make([]driver.Value, 29)
+		for i := range args {
+			args[i] = sqlmock.AnyArg()
+		}
+		// fecha_fallo_raw keeps the date as received, fecha_fallo its parse
+		args[18], args[19] = test.raw, test.want
+
+		mock.ExpectExec("INSERT INTO scj_cases").
+			WithArgs(args...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := repos.GetScjRepository().Create(context.Background(), domain.ScjCase{
+			IDExpediente:  1,
+			FechaFalloRaw: test.raw,
+		})
+		if err != nil {
+			t.Fatalf("Create(%q) returned error: %v", test.raw, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestScjListByFechaFallo(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	from := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM scj_cases").
+		WithArgs(from, from, nil, nil, 10, 0).
+		WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(
+			domain.NewID().String(), domain.NewID().String(), 1, 2019, 5, "", "",
+			1, "2019-001", "", "", "",
+			"", "", nil, "", "", nil, "29/05/2019", "2019-05-29 00:00:00",
+			"NOVASCO vs. ESTADO", "", "", 1,
+			"", "", 0, true, "2025-01-01 00:00:00", "2025-01-01 00:00:00",
+		))
+
+	cases, err := repos.GetScjRepository().ListByFechaFallo(context.Background(), from, time.Time{}, 0, 10)
+	if err != nil {
+		t.Fatalf("ListByFechaFallo returned error: %v", err)
+	}
+
+	if len(cases) != 1 || cases[0].FechaFalloRaw != "29/05/2019" || !cases[0].FechaFallo.Equal(time.Date(2019, time.May, 29, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected cases %+v", cases)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestScjGetByID(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	scjRepo := repos.GetScjRepository()
+
+	id := domain.NewID()
+
+	// A UUID is looked up in the id column
+	mock.ExpectQuery("FROM scj_cases WHERE id = \\?").
+		WithArgs(id.String()).
+		WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(scjCaseRow(id, 4521)...))
+	// A number is looked up as the id_expediente of the court
+	mock.ExpectQuery("FROM scj_cases WHERE id_expediente = \\?").
+		WithArgs(4521).
+		WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(scjCaseRow(id, 4521)...))
+
+	for _, lookup := range []string{id.String(), "4521"} {
+		entity, err := scjRepo.GetByID(context.Background(), lookup)
+		if err != nil {
+			t.Fatalf("GetByID(%q) returned error: %v", lookup, err)
+		}
+		if entity.ID != id || entity.IDExpediente != 4521 || entity.TribunalCanonico != "Primera Sala" || entity.CreatedAt.IsZero() {
+			t.Errorf("GetByID(%q) returned unexpected case %+v", lookup, entity)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestScjGetKeywordsByCategory(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	id := domain.NewID()
+	mock.ExpectQuery("FROM scj_cases WHERE id = \\?").
+		WithArgs(id.String()).
+		WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(scjCaseRow(id, 4521)...))
+
+	keywords, err := repos.GetScjRepository().GetKeywordsByCategory(context.Background(), id.String())
+	if err != nil {
+		t.Fatalf("GetKeywordsByCategory returned error: %v", err)
+	}
+	if got := keywords[domain.KeywordCategoryPersonName]; len(got) != 1 || got[0] != "NOVASCO vs. ESTADO" {
+		t.Errorf("unexpected person names %v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestScjSearchIgnoresAccents(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	scjRepo := repos.GetScjRepository()
+
+	// The case is stored with its searchable fields unaccented
+	args := make([]driver.Value, 29)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+	args[21] = "2019-001 Jose Pena vs. Estado"
+	mock.ExpectExec("INSERT INTO scj_cases").WithArgs(args...).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := scjRepo.Create(context.Background(), domain.ScjCase{
+		IDExpediente: 4521,
+		NoExpediente: "2019-001",
+		Involucrados: "José Peña vs. Estado",
+	}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	// so "Jose" finds "José", and "José" finds it as well
+	id := domain.NewID()
+	row := scjCaseRow(id, 4521)
+	row[20] = "José Peña vs. Estado"
+	for _, query := range []string{"Jose", "José"} {
+		mock.ExpectQuery(`FROM scj_cases\s+WHERE no_expediente LIKE \? OR no_sentencia LIKE \? OR involucrados LIKE \? OR desc_tribunal LIKE \? OR desc_materia LIKE \? OR search_ascii LIKE \?`).
+			WithArgs("%Jose%", "%Jose%", "%Jose%", "%Jose%", "%Jose%", "%Jose%", 10, 0).
+			WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(row...))
+
+		cases, err := scjRepo.Search(context.Background(), query, 0, 10)
+		if err != nil {
+			t.Fatalf("Search(%q) returned error: %v", query, err)
+		}
+		if len(cases) != 1 || cases[0].ID != id || cases[0].Involucrados != "José Peña vs. Estado" {
+			t.Errorf("Search(%q) returned unexpected cases %+v", query, cases)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestScjSearchByCategory(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	id := domain.NewID()
+	mock.ExpectQuery(`FROM scj_cases\s+WHERE involucrados LIKE \?`).
+		WithArgs("%NOVASCO%", 10, 0).
+		WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(scjCaseRow(id, 4521)...))
+	mock.ExpectQuery(`FROM scj_cases\s+WHERE desc_tribunal LIKE \? OR desc_materia LIKE \?`).
+		WithArgs("%NOVASCO%", "%NOVASCO%", 10, 0).
+		WillReturnRows(sqlmock.NewRows(scjCaseColumnNames).AddRow(scjCaseRow(id, 4521)...))
+
+	for _, category := range []domain.KeywordCategory{domain.KeywordCategoryPersonName, domain.KeywordCategoryCompanyName} {
+		cases, err := repos.GetScjRepository().(*ScjRepository).SearchByCategory(context.Background(), category, "NOVASCO", 0, 10)
+		if err != nil {
+			t.Fatalf("SearchByCategory(%s) returned error: %v", category, err)
+		}
+		if len(cases) != 1 || cases[0].ID != id || cases[0].TribunalCanonico != "Primera Sala" || cases[0].CreatedAt.IsZero() {
+			t.Errorf("SearchByCategory(%s) returned unexpected cases %+v", category, cases)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestScjDelete(t *testing.T) {
+	repos, mock := newMockFactory(t)
+
+	// Like GetByID, a UUID is the id column and a number the id_expediente
+	id := domain.NewID()
+	mock.ExpectExec("DELETE FROM scj_cases WHERE id = \\?").
+		WithArgs(id.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM scj_cases WHERE id_expediente = \\?").
+		WithArgs(4521).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	for _, lookup := range []string{id.String(), "4521"} {
+		if err := repos.GetScjRepository().(*ScjRepository).Delete(context.Background(), lookup); err != nil {
+			t.Fatalf("Delete(%q) returned error: %v", lookup, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
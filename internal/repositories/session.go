@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"insightful-intel/internal/database"
+	"insightful-intel/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSessionNotFound is returned when no session has the requested ID
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionRepository stores the investigation sessions that group pipelines
+type SessionRepository struct {
+	db DatabaseAccessor
+}
+
+// NewSessionRepository creates a new session repository instance
+func NewSessionRepository(db database.Service) *SessionRepository {
+	return &SessionRepository{
+		db: NewDatabaseAdapter(db),
+	}
+}
+
+// Create inserts a new session
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	if session.ID == domain.ID(uuid.Nil) {
+		session.ID = domain.NewID()
+	}
+
+	query := `
+		INSERT INTO sessions (
+			id, name, description, created_at, updated_at
+		) VALUES (?, ?, ?, NOW(), NOW())
+	`
+
+	_, err := r.db.ExecContext(ctx, query, session.ID, session.Name, session.Description)
+	if err != nil {
+		return fmt.Errorf("error creating session: %w", err)
+	}
+
+	now := time.Now()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	return nil
+}
+
+// GetByID retrieves a session by its ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM sessions
+		WHERE id = ?
+	`
+
+	var session domain.Session
+	var description sql.NullString
+	var createdAt, updatedAt string
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID, &session.Name, &description, &createdAt, &updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding session: %w", err)
+	}
+
+	session.Description = description.String
+	session.CreatedAt, _ = time.Parse(time.DateTime, createdAt)
+	session.UpdatedAt, _ = time.Parse(time.DateTime, updatedAt)
+
+	return &session, nil
+}
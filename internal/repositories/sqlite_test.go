@@ -0,0 +1,235 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"insightful-intel/internal/database"
+	"insightful-intel/internal/domain"
+	"testing"
+	"time"
+)
+
+// newSQLiteFactory returns a factory over a migrated in-memory SQLite database
+func newSQLiteFactory(t *testing.T) *RepositoryFactory {
+	t.Helper()
+
+	db, err := database.OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations := database.NewMigrationService(db)
+	if err := migrations.RunMigrations(migrations.Migrations()); err != nil {
+		t.Fatalf("failed to migrate SQLite: %v", err)
+	}
+
+	return NewRepositoryFactory(&mockDatabase{db: db})
+}
+
+// createSQLiteSearchResult stores a search result for the records of a test to
+// reference
+func createSQLiteSearchResult(t *testing.T, repos *RepositoryFactory, domainType domain.DomainType) domain.ID {
+	t.Helper()
+
+	ctx := context.Background()
+	pipeline := &domain.DynamicPipelineResult{Steps: []domain.DynamicPipelineStep{{DomainType: domainType}}}
+	if _, err := repos.GetPipelineRepository().CreateDynamicPipelineResult(ctx, pipeline); err != nil {
+		t.Fatalf("CreateDynamicPipelineResult returned error: %v", err)
+	}
+
+	result, err := repos.GetPipelineRepository().CreateDomainSearchResult(ctx, &domain.DomainSearchResult{
+		DomainType:      domainType,
+		Success:         true,
+		PipelineStepsID: pipeline.Steps[0].ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateDomainSearchResult returned error: %v", err)
+	}
+	return result.ID
+}
+
+func TestSQLiteOnapiUpsertAndSearch(t *testing.T) {
+	repos := newSQLiteFactory(t)
+	repo := repos.GetOnapiRepository()
+	ctx := context.Background()
+
+	entity := domain.Entity{
+		DomainSearchResultID: createSQLiteSearchResult(t, repos, domain.DomainTypeONAPI),
+		SerieExpediente:      2020,
+		NumeroExpediente:     1,
+		Texto:                "NOVASCO",
+		Titular:              "José Pérez",
+	}
+	if err := repo.Upsert(ctx, entity); err != nil {
+		t.Fatalf("Upsert returned error: %v", err)
+	}
+	entity.Status = "Registrado"
+	if err := repo.Upsert(ctx, entity); err != nil {
+		t.Fatalf("Upsert returned error: %v", err)
+	}
+
+	if count, _ := repo.Count(ctx); count != 1 {
+		t.Errorf("expected the upsert to update the stored entity, got %d entities", count)
+	}
+
+	entities, err := repo.SearchWithMode(ctx, "novasco jose", MatchAll, 0, 10)
+	if err != nil {
+		t.Fatalf("SearchWithMode returned error: %v", err)
+	}
+	if len(entities) != 1 || entities[0].Status != "Registrado" {
+		t.Fatalf("expected the entity regardless of accents, got %+v", entities)
+	}
+	if entities[0].CreatedAt.IsZero() {
+		t.Errorf("expected the creation time to be read back")
+	}
+}
+
+func TestSQLiteDgiiListWithOptions(t *testing.T) {
+	repos := newSQLiteFactory(t)
+	repo := repos.GetDgiiRepository()
+	ctx := context.Background()
+
+	searchResultID := createSQLiteSearchResult(t, repos, domain.DomainTypeDGII)
+	for _, name := range []string{"beta", "Alpha", "gamma"} {
+		if _, err := repo.Upsert(ctx, domain.Register{DomainSearchResultID: searchResultID, RNC: name, RazonSocial: name}); err != nil {
+			t.Fatalf("Upsert returned error: %v", err)
+		}
+	}
+
+	registers, err := repo.ListWithOptions(ctx, ListOptions{
+		Sort:       "rnc",
+		Order:      SortAsc,
+		Since:      time.Now().Add(-time.Hour),
+		DomainType: domain.DomainTypeDGII,
+		Limit:      2,
+	})
+	if err != nil {
+		t.Fatalf("ListWithOptions returned error: %v", err)
+	}
+	if len(registers) != 2 || registers[0].RNC != "Alpha" || registers[1].RNC != "beta" {
+		t.Errorf("expected the first two registers by RNC, got %+v", registers)
+	}
+
+	register, err := repo.GetByRNC(ctx, "gamma")
+	if err != nil || register.RazonSocial != "gamma" {
+		t.Errorf("expected the register by its RNC, got %+v (%v)", register, err)
+	}
+}
+
+func TestSQLitePgrPublishedAtRoundTrip(t *testing.T) {
+	repos := newSQLiteFactory(t)
+	repo := repos.GetPgrRepository()
+	ctx := context.Background()
+
+	publishedAt := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+	news := domain.PGRNews{
+		DomainSearchResultID: createSQLiteSearchResult(t, repos, domain.DomainTypePGR),
+		URL:                  "https://pgr.gob.do/noticia",
+		Title:                "Arrestan estafadores",
+		PublishedAt:          publishedAt,
+	}
+	if err := repo.Create(ctx, news); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	got, err := repo.GetByURL(ctx, news.URL)
+	if err != nil {
+		t.Fatalf("GetByURL returned error: %v", err)
+	}
+	if !got.PublishedAt.Equal(publishedAt) {
+		t.Errorf("expected the publication date %s, got %s", publishedAt, got.PublishedAt)
+	}
+}
+
+func TestSQLitePipelineRoundTrip(t *testing.T) {
+	repos := newSQLiteFactory(t)
+	repo := repos.GetPipelineRepository()
+	ctx := context.Background()
+
+	session := &domain.Session{Name: "Novasco"}
+	if err := repos.GetSessionRepository().Create(ctx, session); err != nil {
+		t.Fatalf("Create session returned error: %v", err)
+	}
+
+	result := &domain.DynamicPipelineResult{
+		SessionID: session.ID.String(),
+		Config:    domain.DynamicPipelineConfig{Query: "novasco"},
+		Steps: []domain.DynamicPipelineStep{
+			{DomainType: domain.DomainTypeONAPI, SearchParameter: "novasco", Success: true, KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO SRL"},
+			}},
+			{DomainType: domain.DomainTypeDGII, SearchParameter: "novasco", Error: errors.New("unexpected status code: 502")},
+		},
+	}
+	if _, err := repo.CreateDynamicPipelineResult(ctx, result); err != nil {
+		t.Fatalf("CreateDynamicPipelineResult returned error: %v", err)
+	}
+
+	result.TotalSteps = 2
+	result.StopReason = domain.StopReasonStepLimit
+	if err := repo.UpdateDynamicPipelineResult(ctx, result); err != nil {
+		t.Fatalf("UpdateDynamicPipelineResult returned error: %v", err)
+	}
+
+	stored, err := repo.GetPipelineByID(ctx, result.ID.String())
+	if err != nil {
+		t.Fatalf("GetPipelineByID returned error: %v", err)
+	}
+	if len(stored.Steps) != 2 || stored.TotalSteps != 2 || stored.StopReason != domain.StopReasonStepLimit || stored.SessionID != session.ID.String() {
+		t.Fatalf("expected the updated pipeline with its steps, got %+v", stored)
+	}
+	if stored.Steps[1].Error == nil || stored.Steps[1].Error.Error() != "unexpected status code: 502" {
+		t.Errorf("expected the step error message to round trip, got %v", stored.Steps[1].Error)
+	}
+
+	keywords, err := repo.GetStepKeywordsByPipelineID(ctx, result.ID.String(), "")
+	if err != nil || len(keywords) != 1 || keywords[0].Keyword != "NOVASCO SRL" {
+		t.Errorf("expected the step keyword, got %+v (%v)", keywords, err)
+	}
+
+	summaries, err := repo.ListSummariesWithOptions(ctx, ListOptions{DomainType: domain.DomainTypeDGII, Limit: 10})
+	if err != nil || len(summaries) != 1 || summaries[0].Target != "novasco" {
+		t.Errorf("expected the pipeline summary, got %+v (%v)", summaries, err)
+	}
+
+	if err := repo.CreatePipelineEvent(ctx, &domain.PipelineEvent{PipelineID: result.ID, Type: domain.PipelineEventStarted}); err != nil {
+		t.Fatalf("CreatePipelineEvent returned error: %v", err)
+	}
+	events, err := repo.ListPipelineEvents(ctx, result.ID.String())
+	if err != nil || len(events) != 1 || events[0].Seq != 1 {
+		t.Errorf("expected the pipeline event, got %+v (%v)", events, err)
+	}
+
+	if err := repo.SpillQueuedSteps(ctx, result.ID, []domain.DynamicPipelineStep{{SearchParameter: "queued", Depth: 1}}); err != nil {
+		t.Fatalf("SpillQueuedSteps returned error: %v", err)
+	}
+	drained, err := repo.DrainQueuedSteps(ctx, result.ID, 10, false)
+	if err != nil || len(drained) != 1 || drained[0].SearchParameter != "queued" {
+		t.Errorf("expected the spilled step back, got %+v (%v)", drained, err)
+	}
+}
+
+func TestSQLiteWithTxRollsBack(t *testing.T) {
+	repos := newSQLiteFactory(t)
+	ctx := context.Background()
+
+	failure := errors.New("failure")
+	err := repos.WithTx(ctx, func(tx Factory) error {
+		if err := tx.GetSessionRepository().Create(ctx, &domain.Session{Name: "rolled back"}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the failure of fn, got %v", err)
+	}
+
+	var count int
+	if err := repos.db.GetDB().QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&count); err != nil {
+		t.Fatalf("failed to count sessions: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected the session to be rolled back, got %d sessions", count)
+	}
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"insightful-intel/internal/domain"
+	"strings"
+)
+
+// SpillQueuedSteps stores steps a running pipeline cannot keep queued in
+// memory, until DrainQueuedSteps queues them again
+func (r *PipelineRepository) SpillQueuedSteps(ctx context.Context, pipelineID domain.ID, steps []domain.DynamicPipelineStep) error {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(steps))
+	args := make([]any, 0, len(steps)*4)
+	for _, step := range steps {
+		stepJSON, err := json.Marshal(step)
+		if err != nil {
+			return fmt.Errorf("error encoding queued step: %w", err)
+		}
+		placeholders = append(placeholders, "(?, ?, ?, ?)")
+		args = append(args, pipelineID, step.Depth, step.Priority, stepJSON)
+	}
+
+	query := `
+		INSERT INTO pipeline_step_queue (pipeline_id, depth, priority, step)
+		VALUES ` + strings.Join(placeholders, ", ")
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("error spilling queued steps: %w", err)
+	}
+	return nil
+}
+
+// DrainQueuedSteps removes and returns up to limit of the steps spilled by a
+// pipeline, the ones to run first: the shallowest, or the deepest when
+// depthFirst, then the highest priorities
+func (r *PipelineRepository) DrainQueuedSteps(ctx context.Context, pipelineID domain.ID, limit int, depthFirst bool) ([]domain.DynamicPipelineStep, error) {
+	order := "depth ASC, priority DESC, seq ASC"
+	if depthFirst {
+		order = "depth DESC, priority DESC, seq DESC"
+	}
+
+	query := `
+		SELECT seq, step
+		FROM pipeline_step_queue
+		WHERE pipeline_id = ?
+		ORDER BY ` + order + `
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pipelineID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error reading queued steps: %w", err)
+	}
+
+	var steps []domain.DynamicPipelineStep
+	var seqs []any
+	for rows.Next() {
+		var seq int64
+		var stepJSON []byte
+		if err := rows.Scan(&seq, &stepJSON); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		var step domain.DynamicPipelineStep
+		if err := json.Unmarshal(stepJSON, &step); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error decoding queued step %d: %w", seq, err)
+		}
+		steps = append(steps, step)
+		seqs = append(seqs, seq)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(seqs) == 0 {
+		return nil, nil
+	}
+
+	query = `DELETE FROM pipeline_step_queue WHERE seq IN (?` + strings.Repeat(", ?", len(seqs)-1) + `)`
+	if _, err := r.db.ExecContext(ctx, query, seqs...); err != nil {
+		return nil, fmt.Errorf("error removing queued steps: %w", err)
+	}
+
+	return steps, nil
+}
+
+// ClearQueuedSteps drops the steps a pipeline left spilled when it stopped
+func (r *PipelineRepository) ClearQueuedSteps(ctx context.Context, pipelineID domain.ID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM pipeline_step_queue WHERE pipeline_id = ?`, pipelineID)
+	return err
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSpilledStepsDrainUnchanged(t *testing.T) {
+	repos, mock := newMockFactory(t)
+	repo := repos.GetPipelineRepository()
+
+	pipelineID := domain.NewID()
+	steps := []domain.DynamicPipelineStep{
+		{DomainType: domain.DomainTypeDGII, SearchParameter: "NOVASCO SRL", Category: domain.KeywordCategoryCompanyName, Keywords: []string{"NOVASCO SRL"}, Depth: 2, Priority: 40, ParentStepID: domain.NewID()},
+		{DomainType: domain.DomainTypeSCJ, SearchParameter: "401506254", Category: domain.KeywordCategoryContributorID, Keywords: []string{"401506254"}, Depth: 1, Priority: 50, ParentStepID: domain.NewID()},
+	}
+
+	var first, second []byte
+	mock.ExpectExec("INSERT INTO pipeline_step_queue").
+		WithArgs(pipelineID, 2, 40, capturedBytes{&first}, pipelineID, 1, 50, capturedBytes{&second}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := repo.SpillQueuedSteps(context.Background(), pipelineID, steps); err != nil {
+		t.Fatalf("SpillQueuedSteps returned error: %v", err)
+	}
+
+	// The shallowest step is drained first
+	mock.ExpectQuery(`FROM pipeline_step_queue\s+WHERE pipeline_id = \?\s+ORDER BY depth ASC`).
+		WithArgs(pipelineID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "step"}).AddRow(int64(2), second).AddRow(int64(1), first))
+	mock.ExpectExec(`DELETE FROM pipeline_step_queue WHERE seq IN \(\?, \?\)`).
+		WithArgs(int64(2), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	drained, err := repo.DrainQueuedSteps(context.Background(), pipelineID, 10, false)
+	if err != nil {
+		t.Fatalf("DrainQueuedSteps returned error: %v", err)
+	}
+
+	want := []domain.DynamicPipelineStep{steps[1], steps[0]}
+	if !reflect.DeepEqual(drained, want) {
+		t.Errorf("expected the spilled steps back unchanged, got %+v want %+v", drained, want)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+)
+
+// timestamp scans a TIMESTAMP column into a time.Time, whether the driver
+// returns it parsed or as text. NULL leaves the zero time.
+type timestamp struct {
+	t *time.Time
+}
+
+func (ts timestamp) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*ts.t = time.Time{}
+	case time.Time:
+		*ts.t = v
+	case []byte:
+		return ts.parse(string(v))
+	case string:
+		return ts.parse(v)
+	default:
+		return fmt.Errorf("unsupported timestamp type %T", value)
+	}
+	return nil
+}
+
+func (ts timestamp) parse(value string) error {
+	parsed, err := time.Parse(time.DateTime, value)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+	*ts.t = parsed
+	return nil
+}
+
+// nullTime returns t for a nullable TIMESTAMP column, NULL for the zero time
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
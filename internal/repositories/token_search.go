@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"insightful-intel/internal/domain"
+)
+
+// MatchMode is how the words of a search query combine
+type MatchMode string
+
+const (
+	// MatchAll matches the records holding every word of the query
+	MatchAll MatchMode = "all"
+	// MatchAny matches the records holding at least one word of the query
+	MatchAny MatchMode = "any"
+)
+
+// ParseMatchMode parses a match mode, MatchAll when empty
+func ParseMatchMode(mode string) (MatchMode, error) {
+	switch MatchMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case "", MatchAll:
+		return MatchAll, nil
+	case MatchAny:
+		return MatchAny, nil
+	}
+	return "", fmt.Errorf("unknown match mode %q, expected %q or %q", mode, MatchAll, MatchAny)
+}
+
+// tokenCondition returns the condition matching the records in which the words
+// of query appear, each word in at least one of columns, and its arguments.
+// MatchAll requires every word, MatchAny one of them, so "NOVASCO REAL ESTATE"
+// matches rows naming the words apart. A query without words matches every
+// record.
+func tokenCondition(query string, columns []string, mode MatchMode) (string, []any) {
+	tokens := strings.Fields(query)
+	if len(tokens) == 0 {
+		return "1 = 1", nil
+	}
+
+	join := " AND "
+	if mode == MatchAny {
+		join = " OR "
+	}
+
+	likes := make([]string, len(columns))
+	for i, column := range columns {
+		likes[i] = column + " LIKE ?"
+	}
+	tokenClause := "(" + strings.Join(likes, " OR ") + ")"
+
+	clauses := make([]string, len(tokens))
+	args := make([]any, 0, len(tokens)*len(columns))
+	for i, token := range tokens {
+		clauses[i] = tokenClause
+		for range columns {
+			args = append(args, "%"+token+"%")
+		}
+	}
+	return "(" + strings.Join(clauses, join) + ")", args
+}
+
+// searchASCII is the value of the search_ascii column of a record: its
+// searchable fields without accents. Searches fold their query the same way
+// and match it, so "Jose" finds "José" whatever the collation of the table.
+func searchASCII(fields ...string) string {
+	var text []string
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			text = append(text, field)
+		}
+	}
+	return domain.FoldAccents(strings.Join(text, " "))
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenCondition(t *testing.T) {
+	columns := []string{"razon_social", "nombre_comercial"}
+
+	condition, args := tokenCondition("  NOVASCO   REAL ESTATE ", columns, MatchAll)
+	want := "((razon_social LIKE ? OR nombre_comercial LIKE ?) AND (razon_social LIKE ? OR nombre_comercial LIKE ?) AND (razon_social LIKE ? OR nombre_comercial LIKE ?))"
+	if condition != want {
+		t.Errorf("unexpected condition:\n%s\nwant:\n%s", condition, want)
+	}
+	wantArgs := []any{"%NOVASCO%", "%NOVASCO%", "%REAL%", "%REAL%", "%ESTATE%", "%ESTATE%"}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("expected args %v, got %v", wantArgs, args)
+	}
+	for i := range args {
+		if args[i] != wantArgs[i] {
+			t.Errorf("arg %d: expected %v, got %v", i, wantArgs[i], args[i])
+		}
+	}
+
+	condition, _ = tokenCondition("NOVASCO ESTATE", columns, MatchAny)
+	if strings.Count(condition, ") OR (") != 1 || strings.Contains(condition, " AND ") {
+		t.Errorf("expected the words joined by OR, got %s", condition)
+	}
+
+	if condition, args := tokenCondition("   ", columns, MatchAll); condition != "1 = 1" || len(args) != 0 {
+		t.Errorf("expected a query without words to match everything, got %s %v", condition, args)
+	}
+}
+
+func TestParseMatchMode(t *testing.T) {
+	for input, want := range map[string]MatchMode{"": MatchAll, "all": MatchAll, "ANY": MatchAny} {
+		if got, err := ParseMatchMode(input); err != nil || got != want {
+			t.Errorf("ParseMatchMode(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseMatchMode("some"); err == nil {
+		t.Error("expected an unknown match mode to be rejected")
+	}
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"insightful-intel/internal/database"
+	"insightful-intel/internal/domain"
+	"strings"
+)
+
+// URLSourceRepository stores the cross-domain links between a URL and the record
+// that already holds it
+type URLSourceRepository struct {
+	db DatabaseAccessor
+}
+
+// NewURLSourceRepository creates a new URL source repository instance
+func NewURLSourceRepository(db database.Service) *URLSourceRepository {
+	return &URLSourceRepository{
+		db: NewDatabaseAdapter(db),
+	}
+}
+
+// Link records that a domain found the URL of an existing record
+func (r *URLSourceRepository) Link(ctx context.Context, source domain.URLSource) error {
+	source.ID = domain.NewID()
+
+	query := `
+		INSERT INTO url_sources (
+			id, url, record_table, record_id, domain_type, domain_search_result_id, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, NOW())
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		source.ID, source.URL, source.RecordTable, source.RecordID, string(source.DomainType), source.DomainSearchResultID,
+	)
+	if err != nil {
+		return fmt.Errorf("error linking url source: %w", err)
+	}
+
+	return nil
+}
+
+// GetByURL retrieves the sources linked to a URL
+func (r *URLSourceRepository) GetByURL(ctx context.Context, url string) ([]domain.URLSource, error) {
+	query := `
+		SELECT id, url, record_table, record_id, domain_type, domain_search_result_id
+		FROM url_sources
+		WHERE url = ? OR url = ?
+		ORDER BY created_at ASC
+	`
+
+	withSlash, withoutSlash := urlVariants(url)
+	rows, err := r.db.QueryContext(ctx, query, withoutSlash, withSlash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []domain.URLSource
+	for rows.Next() {
+		var source domain.URLSource
+		var domainType string
+
+		if err := rows.Scan(
+			&source.ID, &source.URL, &source.RecordTable, &source.RecordID, &domainType, &source.DomainSearchResultID,
+		); err != nil {
+			return nil, err
+		}
+
+		source.DomainType = domain.DomainType(domainType)
+		sources = append(sources, source)
+	}
+
+	return sources, rows.Err()
+}
+
+// urlVariants returns the URL with and without its trailing slash so links match
+// regardless of how each source spelled it
+func urlVariants(url string) (string, string) {
+	withoutSlash := strings.TrimSuffix(strings.TrimSpace(url), "/")
+	return withoutSlash + "/", withoutSlash
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DefaultPublicPaths are the paths served without an API key
+var DefaultPublicPaths = []string{"/health"}
+
+// apiKeysFromEnv reads the comma-separated API_KEYS, none when it is not set
+func apiKeysFromEnv() []string {
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// authMiddleware rejects with 401 the requests without an
+// "Authorization: Bearer <key>" header naming one of the API keys, except on
+// the public paths. Requests pass unchecked when no key is configured.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.apiKeys) == 0 || slices.Contains(s.publicPaths, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !s.validAPIKey(strings.TrimSpace(token)) {
+			slog.Warn("rejected unauthenticated request", slog.String("path", r.URL.Path))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="insightful-intel"`)
+			http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validAPIKey reports whether token is one of the API keys, comparing in
+// constant time so the keys cannot be guessed from response times
+func (s *Server) validAPIKey(token string) bool {
+	valid := false
+	for _, key := range s.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return token != "" && valid
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"insightful-intel/internal/domain"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.db = &mockDatabase{}
+	s.apiKeys = []string{"first-key", "second-key"}
+	s.publicPaths = DefaultPublicPaths
+
+	// Only the authorized listing reaches the repository
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(10, 0).
+		WillReturnRows(pgrNewsRows(domain.NewID(), domain.NewID(), time.Now()))
+	expectCount(mock, "pgr_news", 2)
+
+	for _, test := range []struct {
+		name          string
+		method        string
+		target        string
+		authorization string
+		want          int
+	}{
+		{"authorized", http.MethodGet, "/api/pgr", "Bearer second-key", http.StatusOK},
+		{"missing header", http.MethodGet, "/api/pgr", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/api/pgr", "Bearer third-key", http.StatusUnauthorized},
+		{"not a bearer token", http.MethodGet, "/api/pgr", "Basic Zmlyc3Qta2V5", http.StatusUnauthorized},
+		{"empty bearer token", http.MethodGet, "/api/pipeline", "Bearer ", http.StatusUnauthorized},
+		{"public path", http.MethodGet, "/health", "", http.StatusOK},
+		{"preflight", http.MethodOptions, "/api/pgr", "", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(test.method, test.target, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+
+		rec := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(rec, req)
+		if rec.Code != test.want {
+			t.Errorf("%s: expected %d, got %d: %s", test.name, test.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAuthMiddlewareOpenWithoutKeys(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(10, 0).
+		WillReturnRows(pgrNewsRows(domain.NewID(), domain.NewID(), time.Now()))
+	expectCount(mock, "pgr_news", 2)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pgr", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 without API keys configured, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"insightful-intel/internal/repositories"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultCompactionBatch is the number of rows of each table compacted per
+// tick
+const DefaultCompactionBatch = 100
+
+// compactionIntervalFromEnv reads COMPACTION_INTERVAL, a duration such as 1h.
+// Compaction is disabled when it is not set or not valid.
+func compactionIntervalFromEnv() time.Duration {
+	value := os.Getenv("COMPACTION_INTERVAL")
+	if value == "" {
+		return 0
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		slog.Warn("invalid COMPACTION_INTERVAL environment variable, compaction disabled", slog.String("compaction_interval", value))
+		return 0
+	}
+	return interval
+}
+
+// compactionMinBytesFromEnv reads COMPACTION_MIN_BYTES, falling back to
+// repositories.DefaultCompactionMinBytes when it is not set or not valid
+func compactionMinBytesFromEnv() int {
+	value := os.Getenv("COMPACTION_MIN_BYTES")
+	if value == "" {
+		return repositories.DefaultCompactionMinBytes
+	}
+
+	minBytes, err := strconv.Atoi(value)
+	if err != nil || minBytes <= 0 {
+		slog.Warn("invalid COMPACTION_MIN_BYTES environment variable, using default", slog.String("compaction_min_bytes", value))
+		return repositories.DefaultCompactionMinBytes
+	}
+	return minBytes
+}
+
+// startCompaction compacts the stored outputs every compaction interval until
+// ctx is done, when an interval is configured
+func (s *Server) startCompaction(ctx context.Context) {
+	if s.compactionInterval <= 0 || s.repositories == nil {
+		return
+	}
+
+	s.goBackground(func() {
+		ticker := time.NewTicker(s.compactionInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.compactOutputs()
+			}
+		}
+	})
+}
+
+// compactOutputs runs a compaction batch and logs what it saved
+func (s *Server) compactOutputs() {
+	stats, err := s.repositories.GetPipelineRepository().CompactOutputs(s.backgroundContext(), s.compactionMinBytes, DefaultCompactionBatch)
+	if err != nil {
+		slog.Error("failed to compact stored outputs", slog.Any("error", err))
+		return
+	}
+	if stats.Rows == 0 {
+		return
+	}
+
+	slog.Info("compacted stored outputs",
+		slog.Int("rows", stats.Rows),
+		slog.Int64("bytes_before", stats.BytesBefore),
+		slog.Int64("bytes_after", stats.BytesAfter),
+	)
+}
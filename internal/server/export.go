@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+)
+
+// exportStored answers an export request with a page of the records list
+// returns, read with the same paging, sorting and filtering as the listing
+// handlers. CSV is the only format.
+func exportStored[T any, P interface {
+	*T
+	domain.StoredRecord
+}](s *Server, w http.ResponseWriter, r *http.Request, name string, list func(ctx context.Context, opts repositories.ListOptions) ([]T, error)) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, fmt.Sprintf("Unsupported export format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := s.listOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := listStored[T, P](s, r, func() ([]T, error) {
+		return list(r.Context(), opts)
+	})
+	if err != nil {
+		listError(w, name+" records", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	if err := writeCSV(w, records); err != nil {
+		slog.Error("failed to write CSV export", slog.String("export", name), slog.Any("error", err))
+	}
+}
+
+// writeCSV writes records as CSV under a header row of the JSON names of
+// their fields, the fields of embedded structs included
+func writeCSV[T any](w io.Writer, records []T) error {
+	columns := csvColumns(reflect.TypeFor[T]())
+
+	writer := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for _, record := range records {
+		value := reflect.ValueOf(record)
+		for i, column := range columns {
+			row[i] = csvValue(value.FieldByIndex(column.index))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvColumns lists the exported fields of t under their JSON names, skipping
+// the fields JSON skips
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// csvValue formats a field for a CSV cell: times as RFC 3339, string lists
+// joined by semicolons and nested values as JSON
+func csvValue(value reflect.Value) string {
+	if value.Type() == timeType {
+		t := value.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	if stringer, ok := value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Pointer, reflect.Interface, reflect.Map:
+		if value.IsNil() {
+			return ""
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.String {
+			return strings.Join(value.Interface().([]string), "; ")
+		}
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// onapiExportHandler exports the stored ONAPI entities
+func (s *Server) onapiExportHandler(w http.ResponseWriter, r *http.Request) {
+	exportStored(s, w, r, "onapi", s.GetRepositories().GetOnapiRepository().ListWithOptions)
+}
+
+// scjExportHandler exports the stored SCJ cases
+func (s *Server) scjExportHandler(w http.ResponseWriter, r *http.Request) {
+	exportStored(s, w, r, "scj", s.GetRepositories().GetScjRepository().ListWithOptions)
+}
+
+// dgiiExportHandler exports the stored DGII registers
+func (s *Server) dgiiExportHandler(w http.ResponseWriter, r *http.Request) {
+	exportStored(s, w, r, "dgii", s.GetRepositories().GetDgiiRepository().ListWithOptions)
+}
+
+// pgrExportHandler exports the stored PGR news
+func (s *Server) pgrExportHandler(w http.ResponseWriter, r *http.Request) {
+	exportStored(s, w, r, "pgr", s.GetRepositories().GetPgrRepository().ListWithOptions)
+}
+
+// dockingExportHandler exports the stored Google Docking results
+func (s *Server) dockingExportHandler(w http.ResponseWriter, r *http.Request) {
+	exportStored(s, w, r, "docking", s.GetRepositories().GetDockingRepository().ListWithOptions)
+}
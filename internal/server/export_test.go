@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"insightful-intel/internal/domain"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDgiiExportHandlerWritesCSV(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.staleAfter = 24 * time.Hour
+
+	id, resultID := domain.NewID(), domain.NewID()
+	scrapedAt := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("FROM dgii_registers").
+		WithArgs(5, 10).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_search_result_id", "rnc", "razon_social", "nombre_comercial", "categoria", "regimen_pagos",
+			"facturador_electronico", "licencia_comercial", "estado", "created_at", "updated_at",
+		}).AddRow(id.String(), resultID.String(), "401506254", "NOVASCO, S.R.L.", `El "Novasco"`, "", "NORMAL", "NO", "N/A", "ACTIVO", scrapedAt, scrapedAt))
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/dgii/export?format=csv&offset=10&limit=5", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `attachment; filename="dgii.csv"` {
+		t.Errorf("expected an attachment disposition, got %q", disposition)
+	}
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+		t.Errorf("expected a CSV content type, got %q", contentType)
+	}
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected a header and a row, got %d rows", len(rows))
+	}
+
+	wantHeader := []string{
+		"id", "domain_search_result_id", "rnc", "razon_social", "nombre_comercial", "categoria", "regimen_pagos",
+		"facturador_electronico", "licencia_comercial", "estado", "created_at", "updated_at", "as_of", "stale",
+	}
+	if !slices.Equal(rows[0], wantHeader) {
+		t.Errorf("expected header %v, got %v", wantHeader, rows[0])
+	}
+
+	wantRow := []string{
+		id.String(), resultID.String(), "401506254", "NOVASCO, S.R.L.", `El "Novasco"`, "", "NORMAL", "NO", "N/A", "ACTIVO",
+		"2025-01-02T15:04:05Z", "2025-01-02T15:04:05Z", "2025-01-02T15:04:05Z", "true",
+	}
+	if !slices.Equal(rows[1], wantRow) {
+		t.Errorf("expected row %v, got %v", wantRow, rows[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestExportHandlerRejectsUnknownFormat(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/onapi/export?format=xlsx", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rec.Code)
+	}
+}
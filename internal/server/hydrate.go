@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"insightful-intel/internal/module"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultHydrateConcurrency is the number of details fetched at once when a
+// listing is hydrated
+const DefaultHydrateConcurrency = 4
+
+// hydrateConcurrencyFromEnv reads HYDRATE_CONCURRENCY, falling back to
+// DefaultHydrateConcurrency when it is not set or not valid
+func hydrateConcurrencyFromEnv() int {
+	value := os.Getenv("HYDRATE_CONCURRENCY")
+	if value == "" {
+		return DefaultHydrateConcurrency
+	}
+
+	concurrency, err := strconv.Atoi(value)
+	if err != nil || concurrency <= 0 {
+		slog.Warn("invalid HYDRATE_CONCURRENCY environment variable, using default", slog.String("hydrate_concurrency", value))
+		return DefaultHydrateConcurrency
+	}
+	return concurrency
+}
+
+// hydrateStored fills in the detail of the records missing it, fetching at
+// most concurrency at once, and stores each hydrated record. Records whose
+// detail cannot be fetched or stored are left as listed.
+func hydrateStored[T any](
+	ctx context.Context,
+	records []T,
+	concurrency int,
+	missing func(T) bool,
+	fetch func(context.Context, T) (T, error),
+	store func(context.Context, T) error,
+) {
+	if concurrency <= 0 {
+		concurrency = DefaultHydrateConcurrency
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+dispatch:
+	for i := range records {
+		if !missing(records[i]) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			hydrated, err := fetch(ctx, records[i])
+			if err == nil {
+				err = store(ctx, hydrated)
+			}
+			if err != nil {
+				infra.Logger(ctx).Warn("failed to hydrate record", slog.Int("index", i), slog.Any("error", err))
+				return
+			}
+
+			// Each goroutine writes its own slot, so no lock is needed
+			records[i] = hydrated
+		}(i)
+	}
+	wg.Wait()
+}
+
+// hydrateOnapi fetches the details of the listed ONAPI entities stored without
+// them and updates their rows
+func (s *Server) hydrateOnapi(ctx context.Context, entities []domain.Entity) {
+	details := s.onapiDetails
+	if details == nil {
+		onapi := module.NewOnapiDomain().(*module.Onapi)
+		details = func(ctx context.Context, entity domain.Entity) (*domain.Entity, error) {
+			return onapi.GetDetails(ctx, entity.NumeroExpediente, entity.SerieExpediente)
+		}
+	}
+	onapiRepo := s.GetRepositories().GetOnapiRepository()
+
+	hydrateStored(ctx, entities, s.hydrateConcurrency,
+		func(entity domain.Entity) bool {
+			return entity.MissingDetail() && entity.NumeroExpediente != 0
+		},
+		func(ctx context.Context, entity domain.Entity) (domain.Entity, error) {
+			detail, err := details(ctx, entity)
+			if err != nil {
+				return entity, err
+			}
+
+			// The row keeps its identity and the search it was found by
+			hydrated := *detail
+			hydrated.ID = entity.ID
+			hydrated.DomainSearchResultID = entity.DomainSearchResultID
+			hydrated.CreatedAt = entity.CreatedAt
+			hydrated.UpdatedAt = entity.UpdatedAt
+			hydrated.Freshness = entity.Freshness
+			return hydrated, nil
+		},
+		func(ctx context.Context, entity domain.Entity) error {
+			return onapiRepo.Update(ctx, entity.ID.String(), entity)
+		},
+	)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"insightful-intel/internal/domain"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func onapiEntityRows(ids []domain.ID, titulares []string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "domain_search_result_id", "serie_expediente", "numero_expediente", "certificado", "tipo", "subtipo",
+		"texto", "clases", "aplicado_a_proteger", "expedicion", "vencimiento", "en_tramite",
+		"titular", "gestor", "domicilio", "status", "tipo_signo", "imagenes", "lista_clases",
+		"created_at", "updated_at",
+	})
+	for i, id := range ids {
+		rows.AddRow(
+			id.String(), domain.NewID().String(), 2020, i+1, "", "", "",
+			"NOVASCO", "", "", "", "", false,
+			titulares[i], "", "", "", "", "[]", "[]",
+			time.Now(), time.Now(),
+		)
+	}
+	return rows
+}
+
+func TestOnapiHandlerHydratesMissingDetails(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.hydrateConcurrency = 2
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var fetched []int32
+	s.onapiDetails = func(ctx context.Context, entity domain.Entity) (*domain.Entity, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		fetched = append(fetched, entity.NumeroExpediente)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		return &domain.Entity{
+			SerieExpediente:  entity.SerieExpediente,
+			NumeroExpediente: entity.NumeroExpediente,
+			Texto:            entity.Texto,
+			Titular:          "Novasco SRL",
+		}, nil
+	}
+
+	ids := []domain.ID{domain.NewID(), domain.NewID(), domain.NewID(), domain.NewID(), domain.NewID()}
+	mock.ExpectQuery("FROM onapi_entities").
+		WithArgs(10, 0).
+		WillReturnRows(onapiEntityRows(ids, []string{"", "", "Juan Perez", "", ""}))
+	mock.MatchExpectationsInOrder(false)
+	for _, i := range []int{0, 1, 3, 4} {
+		mock.ExpectExec("UPDATE onapi_entities").
+			WithArgs(sqlmock.AnyArg(), int32(2020), int32(i+1), "", "", "", "NOVASCO", "", "", "", "", false,
+				"Novasco SRL", "", "", "NOVASCO Novasco SRL", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), ids[i].String()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	expectCount(mock, "onapi_entities", 5)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/onapi?hydrate=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data []domain.Entity `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for i, entity := range body.Data {
+		if entity.ID != ids[i] {
+			t.Errorf("expected entity %d to keep its ID, got %s", i, entity.ID)
+		}
+		if entity.Titular == "" {
+			t.Errorf("expected entity %d to be hydrated", i)
+		}
+	}
+	if body.Data[2].Titular != "Juan Perez" {
+		t.Errorf("expected the entity with details to be left alone, got %q", body.Data[2].Titular)
+	}
+
+	if len(fetched) != 4 {
+		t.Errorf("expected 4 details fetched, got %v", fetched)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 details fetched at once, got %d", peak)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOnapiHandlerSkipsHydrationUnlessRequested(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.onapiDetails = func(ctx context.Context, entity domain.Entity) (*domain.Entity, error) {
+		t.Error("no details expected without hydrate=true")
+		return nil, nil
+	}
+
+	mock.ExpectQuery("FROM onapi_entities").
+		WithArgs(10, 0).
+		WillReturnRows(onapiEntityRows([]domain.ID{domain.NewID()}, []string{""}))
+	expectCount(mock, "onapi_entities", 1)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/onapi", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -1,12 +1,22 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"insightful-intel/internal/domain"
+	"insightful-intel/internal/interactor"
+	"insightful-intel/internal/repositories"
+
+	"github.com/google/uuid"
 )
 
 // onapiHandler handles ONAPI repository operations
@@ -17,24 +27,32 @@ func (s *Server) onapiHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// List ONAPI entities with pagination
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		if limit == 0 {
-			limit = 10
+		opts, err := s.listOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		entities, err := onapiRepo.List(r.Context(), offset, limit)
+		entities, err := listStored(s, r, func() ([]domain.Entity, error) {
+			return onapiRepo.ListWithOptions(r.Context(), opts)
+		})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to list entities: %v", err), http.StatusInternalServerError)
+			listError(w, "entities", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    entities,
-			"count":   len(entities),
-		})
+		// Entities stored without their details are completed on request
+		if r.URL.Query().Get("hydrate") == "true" {
+			s.hydrateOnapi(r.Context(), entities)
+		}
+
+		total, err := onapiRepo.Count(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count entities: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writePaginated(w, entities, opts.Offset, opts.Limit, total)
 
 	case http.MethodPost:
 		// Create new ONAPI entity
@@ -69,24 +87,52 @@ func (s *Server) scjHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// List SCJ cases with pagination
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		if limit == 0 {
-			limit = 10
+		opts, err := s.listOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// from and to, as YYYY-MM-DD, restrict the cases to a range of ruling
+		// dates, to included
+		var from, to time.Time
+		for _, param := range []struct {
+			name  string
+			value *time.Time
+		}{{"from", &from}, {"to", &to}} {
+			raw := r.URL.Query().Get(param.name)
+			if raw == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.DateOnly, raw)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Query parameter '%s' must be a YYYY-MM-DD date", param.name), http.StatusBadRequest)
+				return
+			}
+			*param.value = parsed
+		}
+		if !to.IsZero() {
+			to = to.AddDate(0, 0, 1).Add(-time.Second)
 		}
 
-		cases, err := scjRepo.List(r.Context(), offset, limit)
+		cases, err := listStored(s, r, func() ([]domain.ScjCase, error) {
+			if !from.IsZero() || !to.IsZero() {
+				return scjRepo.ListByFechaFallo(r.Context(), from, to, opts.Offset, opts.Limit)
+			}
+			return scjRepo.ListWithOptions(r.Context(), opts)
+		})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to list cases: %v", err), http.StatusInternalServerError)
+			listError(w, "cases", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    cases,
-			"count":   len(cases),
-		})
+		total, err := scjRepo.Count(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count cases: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writePaginated(w, cases, opts.Offset, opts.Limit, total)
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -101,24 +147,27 @@ func (s *Server) dgiiHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// List DGII registers with pagination
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		if limit == 0 {
-			limit = 10
+		opts, err := s.listOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		registers, err := dgiiRepo.List(r.Context(), offset, limit)
+		registers, err := listStored(s, r, func() ([]domain.Register, error) {
+			return dgiiRepo.ListWithOptions(r.Context(), opts)
+		})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to list registers: %v", err), http.StatusInternalServerError)
+			listError(w, "registers", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    registers,
-			"count":   len(registers),
-		})
+		total, err := dgiiRepo.Count(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count registers: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writePaginated(w, registers, opts.Offset, opts.Limit, total)
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -133,24 +182,27 @@ func (s *Server) pgrHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// List PGR news with pagination
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		if limit == 0 {
-			limit = 10
+		opts, err := s.listOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		news, err := pgrRepo.List(r.Context(), offset, limit)
+		news, err := listStored(s, r, func() ([]domain.PGRNews, error) {
+			return pgrRepo.ListWithOptions(r.Context(), opts)
+		})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to list news: %v", err), http.StatusInternalServerError)
+			listError(w, "news", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    news,
-			"count":   len(news),
-		})
+		total, err := pgrRepo.Count(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count news: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writePaginated(w, news, opts.Offset, opts.Limit, total)
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -165,24 +217,27 @@ func (s *Server) dockingHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		// List Google Docking results with pagination
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		if limit == 0 {
-			limit = 10
+		opts, err := s.listOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		results, err := dockingRepo.List(r.Context(), offset, limit)
+		results, err := listStored(s, r, func() ([]domain.GoogleDorkingResult, error) {
+			return dockingRepo.ListWithOptions(r.Context(), opts)
+		})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to list results: %v", err), http.StatusInternalServerError)
+			listError(w, "results", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    results,
-			"count":   len(results),
-		})
+		total, err := dockingRepo.Count(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count results: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writePaginated(w, results, opts.Offset, opts.Limit, total)
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -212,25 +267,32 @@ func (s *Server) pipelineHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// List pipeline results with pagination
-		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-		if limit == 0 {
-			limit = 10
+		// List pipeline summaries with pagination, leaving the steps to the
+		// single pipeline path
+		opts, err := s.listOptions(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		maxLimit := s.pipelineListMaxLimit
+		if maxLimit <= 0 {
+			maxLimit = DefaultPipelineListMaxLimit
 		}
+		opts.Limit = min(opts.Limit, maxLimit)
 
-		results, err := pipelineRepo.List(r.Context(), offset, limit)
+		results, err := pipelineRepo.ListSummariesWithOptions(r.Context(), opts)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to list pipeline results: %v", err), http.StatusInternalServerError)
+			listError(w, "pipeline results", err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    results,
-			"count":   len(results),
-		})
+		total, err := pipelineRepo.CountPipelines(r.Context())
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to count pipeline results: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		writePaginated(w, results, opts.Offset, opts.Limit, total)
 
 	case http.MethodPost:
 		// Save pipeline result to database
@@ -413,3 +475,492 @@ func (s *Server) pipelineStepsHandler(w http.ResponseWriter, r *http.Request) {
 		"count":       len(result),
 	})
 }
+
+// pipelineGraphHandler returns a stored pipeline result as the graph of the
+// keywords it searched
+func (s *Server) pipelineGraphHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Query parameter 'id' is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.GetRepositories().GetPipelineRepository().GetPipelineByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get pipeline result: %v", err), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    domain.BuildGraph(result),
+	})
+}
+
+// pipelineEventsHandler lists the lifecycle events recorded by a pipeline run,
+// oldest first
+func (s *Server) pipelineEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Query parameter 'id' is required", http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.GetRepositories().GetPipelineRepository().ListPipelineEvents(r.Context(), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get pipeline events: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    events,
+		"count":   len(events),
+	})
+}
+
+// pipelineKeywordsHandler lists the keywords found by the steps of a pipeline,
+// optionally restricted to a category. With newly_seen=mark each keyword is
+// flagged as found by an earlier pipeline or not, and newly_seen=only keeps
+// just the keywords no earlier pipeline found.
+func (s *Server) pipelineKeywordsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pipelineID := r.URL.Query().Get("pipeline_id")
+	if pipelineID == "" {
+		http.Error(w, "Query parameter 'pipeline_id' is required", http.StatusBadRequest)
+		return
+	}
+	category := domain.KeywordCategory(r.URL.Query().Get("category"))
+	newlySeen := domain.NewlySeenFilter(r.URL.Query().Get("newly_seen"))
+	if !newlySeen.Valid() {
+		http.Error(w, "Query parameter 'newly_seen' must be 'mark' or 'only'", http.StatusBadRequest)
+		return
+	}
+
+	pipelineRepo := s.GetRepositories().GetPipelineRepository()
+	keywords, err := pipelineRepo.GetStepKeywordsByPipelineID(r.Context(), pipelineID, category)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get pipeline keywords: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if newlySeen != domain.NewlySeenOff {
+		seen, err := pipelineRepo.GetPreviouslySeenKeywords(r.Context(), pipelineID)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to compare pipeline keywords: %v", err), http.StatusInternalServerError)
+			return
+		}
+		keywords = domain.ApplyNewlySeen(keywords, seen, newlySeen)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"pipeline_id": pipelineID,
+		"category":    category,
+		"newly_seen":  newlySeen,
+		"data":        keywords,
+		"count":       len(keywords),
+	})
+}
+
+// entityCompaniesHandler lists the companies linked to a person across the stored
+// ONAPI entities and mercantile records
+func (s *Server) entityCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.PathValue("name"))
+	if name == "" {
+		http.Error(w, "Path parameter 'name' is required", http.StatusBadRequest)
+		return
+	}
+
+	companies, err := interactor.NewEntityInteractor(s.GetRepositories()).FindCompaniesByPerson(r.Context(), name)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to find companies: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"person":  name,
+		"data":    companies,
+		"count":   len(companies),
+	})
+}
+
+// retryFailedStepsHandler re-executes the failed steps of a stored pipeline
+func (s *Server) retryFailedStepsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Query parameter 'id' is required", http.StatusBadRequest)
+		return
+	}
+
+	pipeline, resolved, err := s.interactor.RetryFailedSteps(r.Context(), id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to retry pipeline steps: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":        true,
+		"resolved_steps": resolved,
+		"data":           pipeline,
+	})
+}
+
+// ManualFindingRequest is the body of POST /api/pipeline/findings
+type ManualFindingRequest struct {
+	PipelineID string `json:"pipeline_id"`
+	domain.ManualFinding
+}
+
+// pipelineFindingsHandler folds a finding entered by an analyst into a stored
+// pipeline, tagged as manual
+func (s *Server) pipelineFindingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req ManualFindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(req.PipelineID); err != nil {
+		http.Error(w, "Field 'pipeline_id' must be a pipeline ID", http.StatusBadRequest)
+		return
+	}
+	if err := req.ManualFinding.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid finding: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	pipeline, company, err := interactor.NewDynamicPipelineInteractor(s.GetRepositories()).AddManualFinding(r.Context(), req.PipelineID, req.ManualFinding)
+	if errors.Is(err, repositories.ErrPipelineNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to add finding: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"company": company,
+		"data":    pipeline,
+	})
+}
+
+// entityClusterRow is a row of the CSV export of the entity clusters: a
+// reference of a cluster next to the cluster it belongs to
+type entityClusterRow struct {
+	Name       string  `json:"name"`
+	RNC        string  `json:"rnc"`
+	Sources    string  `json:"sources"`
+	Confidence float64 `json:"confidence"`
+	domain.EntityReference
+}
+
+// pipelineEntitiesHandler exports the entities resolved from a stored pipeline,
+// with the references of their aliases, as JSON or as a CSV row per reference
+func (s *Server) pipelineEntitiesHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Query parameter 'id' is required", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, fmt.Sprintf("Unsupported export format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	clusters, err := interactor.NewDynamicPipelineInteractor(s.GetRepositories()).EntityClusters(r.Context(), id)
+	if errors.Is(err, repositories.ErrPipelineNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to resolve pipeline entities: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "csv" {
+		var rows []entityClusterRow
+		for _, cluster := range clusters {
+			sources := make([]string, 0, len(cluster.Sources))
+			for _, source := range cluster.Sources {
+				sources = append(sources, string(source))
+			}
+			for _, reference := range cluster.References {
+				rows = append(rows, entityClusterRow{
+					Name:            cluster.Name,
+					RNC:             cluster.RNC,
+					Sources:         strings.Join(sources, "; "),
+					Confidence:      cluster.Confidence,
+					EntityReference: reference,
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="pipeline-%s-entities.csv"`, id))
+		if err := writeCSV(w, rows); err != nil {
+			slog.Error("failed to write CSV export", slog.String("export", "entities"), slog.Any("error", err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    clusters,
+		"count":   len(clusters),
+	})
+}
+
+// CreateSessionRequest is the body of POST /api/sessions
+type CreateSessionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// createSessionHandler opens a new investigation session
+func (s *Server) createSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Field 'name' is required", http.StatusBadRequest)
+		return
+	}
+
+	session, err := interactor.NewSessionInteractor(s.GetRepositories()).CreateSession(r.Context(), req.Name, req.Description)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    session,
+	})
+}
+
+// sessionHandler returns a session with its pipelines and the entities and
+// findings merged across them
+func (s *Server) sessionHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		http.Error(w, "Path parameter 'id' must be a session ID", http.StatusBadRequest)
+		return
+	}
+
+	view, err := interactor.NewSessionInteractor(s.GetRepositories()).GetSessionView(r.Context(), id)
+	if errors.Is(err, repositories.ErrSessionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    view,
+	})
+}
+
+// DefaultListLimit is the page size of the listings without a limit parameter
+const DefaultListLimit = 10
+
+// parsePagination reads the offset and limit of a listing request. A missing
+// offset is 0 and a missing or zero limit DefaultListLimit; a limit above the
+// maximum of the server is lowered to it. Non-numeric and negative values are
+// rejected.
+func (s *Server) parsePagination(r *http.Request) (offset, limit int, err error) {
+	query := r.URL.Query()
+
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"offset", &offset}, {"limit", &limit}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("query parameter '%s' must be a non-negative integer", param.name)
+		}
+		*param.value = parsed
+	}
+
+	maxLimit := s.listMaxLimit
+	if maxLimit <= 0 {
+		maxLimit = DefaultListMaxLimit
+	}
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	return offset, min(limit, maxLimit), nil
+}
+
+// listOptions reads the paging, sorting and filtering of a listing request:
+// offset and limit as parsePagination reads them, sort, order, domain, and
+// since and until, as RFC 3339 times or YYYY-MM-DD dates, until a date
+// included
+func (s *Server) listOptions(r *http.Request) (repositories.ListOptions, error) {
+	query := r.URL.Query()
+
+	opts := repositories.ListOptions{
+		Sort:       query.Get("sort"),
+		Order:      query.Get("order"),
+		DomainType: domain.DomainType(query.Get("domain")),
+	}
+	var err error
+	opts.Offset, opts.Limit, err = s.parsePagination(r)
+	if err != nil {
+		return opts, err
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"since", &opts.Since}, {"until", &opts.Until}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+			*param.value = parsed
+			continue
+		}
+		parsed, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return opts, fmt.Errorf("query parameter '%s' must be an RFC 3339 time or a YYYY-MM-DD date", param.name)
+		}
+		if param.name == "until" {
+			parsed = parsed.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		*param.value = parsed
+	}
+
+	return opts, nil
+}
+
+// writePaginated writes a page of a listing with its paging: the records of
+// the page and their count, the offset and limit of the page, the total of
+// stored records and whether records follow the page
+func writePaginated[T any](w http.ResponseWriter, data []T, offset, limit int, total int64) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"data":     data,
+		"count":    len(data),
+		"offset":   offset,
+		"limit":    limit,
+		"total":    total,
+		"has_more": int64(offset+len(data)) < total,
+	})
+}
+
+// listError answers a listing request whose list failed, with 400 when its
+// options were invalid
+func listError(w http.ResponseWriter, what string, err error) {
+	if errors.Is(err, repositories.ErrInvalidListOptions) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Failed to list %s: %v", what, err), http.StatusInternalServerError)
+}
+
+// listStored lists stored records dated with their freshness. With
+// refresh=true, the searches behind stale records are run again and the
+// records listed anew.
+func listStored[T any, P interface {
+	*T
+	domain.StoredRecord
+}](s *Server, r *http.Request, list func() ([]T, error)) ([]T, error) {
+	records, err := list()
+	if err != nil {
+		return nil, err
+	}
+
+	stale := s.markFreshness(len(records), func(i int) domain.StoredRecord { return P(&records[i]) })
+	if len(stale) == 0 || r.URL.Query().Get("refresh") != "true" {
+		return records, nil
+	}
+
+	if _, err := s.refreshSearchResults(r.Context(), stale); err != nil {
+		return nil, fmt.Errorf("refresh stale records: %w", err)
+	}
+
+	records, err = list()
+	if err != nil {
+		return nil, err
+	}
+	s.markFreshness(len(records), func(i int) domain.StoredRecord { return P(&records[i]) })
+
+	return records, nil
+}
+
+// markFreshness sets the freshness of n records and returns the search results
+// behind the stale ones
+func (s *Server) markFreshness(n int, record func(int) domain.StoredRecord) []domain.ID {
+	staleAfter := s.staleAfter
+	if staleAfter == 0 {
+		staleAfter = domain.DefaultStaleAfter
+	}
+	now := time.Now()
+
+	var stale []domain.ID
+	for i := range n {
+		stored := record(i)
+		freshness := domain.NewFreshness(stored.ScrapedAt(), staleAfter, now)
+		stored.SetFreshness(freshness)
+		if freshness.Stale && !slices.Contains(stale, stored.SearchResultID()) {
+			stale = append(stale, stored.SearchResultID())
+		}
+	}
+	return stale
+}
+
+// refreshSearchResults re-scrapes the given search results
+func (s *Server) refreshSearchResults(ctx context.Context, ids []domain.ID) (int, error) {
+	if s.refreshRecords != nil {
+		return s.refreshRecords(ctx, ids)
+	}
+	if s.interactor != nil {
+		return s.interactor.RefreshSearchResults(ctx, ids)
+	}
+	return interactor.NewDynamicPipelineInteractor(s.GetRepositories()).RefreshSearchResults(ctx, ids)
+}
@@ -0,0 +1,773 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/repositories"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+type mockDatabase struct {
+	db *sql.DB
+}
+
+func (m *mockDatabase) Health() map[string]string { return map[string]string{"status": "up"} }
+func (m *mockDatabase) Close() error              { return m.db.Close() }
+func (m *mockDatabase) GetDB() *sql.DB            { return m.db }
+
+func newMockServer(t *testing.T) (*Server, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &Server{
+		repositories: repositories.NewRepositoryFactory(&mockDatabase{db: db}),
+	}, mock
+}
+
+func TestEntityCompaniesHandler(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	mock.ExpectQuery("FROM onapi_entities").
+		WithArgs("%Juan Perez%", "%Juan Perez%").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_search_result_id", "serie_expediente", "numero_expediente", "certificado", "tipo", "subtipo",
+			"texto", "clases", "aplicado_a_proteger", "expedicion", "vencimiento", "en_tramite",
+			"titular", "gestor", "domicilio", "status", "tipo_signo", "imagenes", "lista_clases",
+			"created_at", "updated_at",
+		}).AddRow(
+			domain.NewID().String(), domain.NewID().String(), 2020, 1, "", "", "",
+			"NOVASCO", "", "", "", "", false,
+			"Juan Perez", "", "", "", "", "[]", "[]",
+			nil, nil,
+		))
+
+	camaraOutput, _ := json.Marshal([]domain.CamaraRecord{{
+		RegistroMercantil: "12345SD",
+		RazonSocial:       "Novasco Real Estate SRL",
+		Gerentes:          []domain.CamaraPerson{{Nombre: "Juan Perez"}},
+	}})
+	mock.ExpectQuery("FROM domain_search_results").
+		WithArgs(string(domain.DomainTypeCamara), "%Juan Perez%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "output"}).AddRow(domain.NewID().String(), camaraOutput))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/entities/Juan%20Perez/companies", nil)
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Success bool                 `json:"success"`
+		Person  string               `json:"person"`
+		Data    []domain.CompanyLink `json:"data"`
+		Count   int                  `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if body.Person != "Juan Perez" || body.Count != 2 {
+		t.Fatalf("unexpected response: %+v", body)
+	}
+	if body.Data[0].Source != domain.DomainTypeONAPI || body.Data[1].Source != domain.DomainTypeCamara {
+		t.Errorf("expected ONAPI and mercantile links, got %+v", body.Data)
+	}
+}
+
+func TestPipelineHandlerListsSummaries(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.pipelineListMaxLimit = 50
+
+	firstID, secondID := domain.NewID(), domain.NewID()
+	now := time.Now().UTC().Truncate(time.Second)
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).
+			AddRow(firstID.String(), nil, 12, 9, 3, 2, "", `{"query":"novasco","maxDepth":3}`, now, now).
+			AddRow(secondID.String(), "session-1", 4, 4, 0, 1, domain.StopReasonBudgetExhausted, `{"query":"Juan Perez"}`, now, now))
+	expectCount(mock, "dynamic_pipeline_results", 2)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline?limit=500", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data  []map[string]any `json:"data"`
+		Count int              `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Count != 2 {
+		t.Fatalf("expected 2 pipelines, got %+v", body)
+	}
+
+	first := body.Data[0]
+	if first["id"] != firstID.String() || first["target"] != "novasco" || first["status"] != domain.PipelineStatusCompleted {
+		t.Errorf("unexpected summary fields %+v", first)
+	}
+	if first["total_steps"] != float64(12) || first["successful_steps"] != float64(9) || first["failed_steps"] != float64(3) || first["created_at"] == nil {
+		t.Errorf("expected the step counts and creation date, got %+v", first)
+	}
+	for _, detail := range []string{"steps", "config", "companies", "confidence"} {
+		if _, ok := first[detail]; ok {
+			t.Errorf("expected the listing to leave %q out, got %+v", detail, first)
+		}
+	}
+
+	if second := body.Data[1]; second["status"] != domain.PipelineStatusStopped || second["session_id"] != "session-1" {
+		t.Errorf("expected the budget-exhausted pipeline to be stopped, got %+v", second)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRetryFailedStepsHandlerValidatesRequest(t *testing.T) {
+	s, _ := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/retry-failed?id=abc", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pipeline/retry-failed", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without id, got %d", rec.Code)
+	}
+}
+
+func TestPipelineFindingsHandlerValidatesRequest(t *testing.T) {
+	s, mock := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	pipelineID := domain.NewID().String()
+	for name, body := range map[string]string{
+		"malformed":        `{"pipeline_id":`,
+		"missing pipeline": `{"name": "Novasco", "category": "company_name"}`,
+		"missing name":     `{"pipeline_id": "` + pipelineID + `", "category": "company_name"}`,
+		"unknown category": `{"pipeline_id": "` + pipelineID + `", "name": "Novasco", "category": "rumor"}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pipeline/findings", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID).
+		WillReturnError(sql.ErrNoRows)
+
+	rec := httptest.NewRecorder()
+	body := `{"pipeline_id": "` + pipelineID + `", "name": "Novasco", "category": "company_name", "note": "tip", "source": "analyst"}`
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pipeline/findings", strings.NewReader(body)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown pipeline, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPipelineKeywordsHandler(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	pipelineID := domain.NewID().String()
+	mock.ExpectQuery("FROM step_keywords").
+		WithArgs(pipelineID, "person_name").
+		WillReturnRows(sqlmock.NewRows([]string{"step_id", "domain_type", "category", "keyword", "normalized_keyword"}).
+			AddRow(domain.NewID().String(), "ONAPI", "person_name", "Juan Perez", "juan perez").
+			AddRow(domain.NewID().String(), "CAMARA", "person_name", "Maria Gomez", "maria gomez"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/pipeline/keywords?pipeline_id="+pipelineID+"&category=person_name", nil)
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data  []domain.StepKeyword `json:"data"`
+		Count int                  `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Count != 2 || body.Data[1].Keyword != "Maria Gomez" {
+		t.Errorf("unexpected response: %+v", body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/keywords", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without pipeline_id, got %d", rec.Code)
+	}
+}
+
+func TestPipelineKeywordsHandlerNewlySeen(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	pipelineID := domain.NewID().String()
+	keywordRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"step_id", "domain_type", "category", "keyword", "normalized_keyword"}).
+			AddRow(domain.NewID().String(), "ONAPI", "company_name", "Novasco SRL", "novasco srl").
+			AddRow(domain.NewID().String(), "ONAPI", "person_name", "Juan Perez", "juan perez").
+			AddRow(domain.NewID().String(), "CAMARA", "person_name", "Maria Gomez", "maria gomez")
+	}
+	// An earlier investigation already found the company
+	seenRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"category", "normalized_keyword"}).
+			AddRow("company_name", "novasco srl")
+	}
+
+	mock.ExpectQuery("FROM step_keywords").
+		WithArgs(pipelineID).
+		WillReturnRows(keywordRows())
+	mock.ExpectQuery("p.created_at < current.created_at").
+		WithArgs(pipelineID).
+		WillReturnRows(seenRows())
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/keywords?pipeline_id="+pipelineID+"&newly_seen=only", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data  []domain.StepKeyword `json:"data"`
+		Count int                  `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Count != 2 || body.Data[0].Keyword != "Juan Perez" || body.Data[1].Keyword != "Maria Gomez" {
+		t.Fatalf("expected only the keywords no earlier pipeline found, got %+v", body.Data)
+	}
+	for _, keyword := range body.Data {
+		if keyword.NewlySeen == nil || !*keyword.NewlySeen {
+			t.Errorf("expected %q to be flagged as newly seen", keyword.Keyword)
+		}
+	}
+
+	mock.ExpectQuery("FROM step_keywords").
+		WithArgs(pipelineID).
+		WillReturnRows(keywordRows())
+	mock.ExpectQuery("p.created_at < current.created_at").
+		WithArgs(pipelineID).
+		WillReturnRows(seenRows())
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/keywords?pipeline_id="+pipelineID+"&newly_seen=mark", nil))
+	body.Data = nil
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Count != 3 || body.Data[0].NewlySeen == nil || *body.Data[0].NewlySeen {
+		t.Errorf("expected every keyword with the company flagged as already seen, got %+v", body.Data)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/keywords?pipeline_id="+pipelineID+"&newly_seen=yes", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown filter, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreateSessionHandler(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	mock.ExpectExec("INSERT INTO sessions").
+		WithArgs(sqlmock.AnyArg(), "Caso Novasco", "Red de empresas").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sessions", strings.NewReader(`{"name":" Caso Novasco ","description":"Red de empresas"}`))
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data domain.Session `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Data.ID == domain.ID(uuid.Nil) || body.Data.Name != "Caso Novasco" {
+		t.Errorf("unexpected session: %+v", body.Data)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/sessions", strings.NewReader(`{"name":" "}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without name, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSessionHandlerMergesPipelines(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	sessionID := domain.NewID().String()
+	firstID, secondID := domain.NewID().String(), domain.NewID().String()
+
+	mock.ExpectQuery("FROM sessions").
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_at", "updated_at"}).
+			AddRow(sessionID, "Caso Novasco", nil, "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+
+	pipelineColumns := []string{
+		"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+	}
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows(pipelineColumns).
+			AddRow(firstID, sessionID, 1, 1, 0, 0, "", `{"query":"novasco"}`, "2025-01-01 00:00:00", "2025-01-01 00:00:00").
+			AddRow(secondID, sessionID, 1, 1, 0, 0, "", `{"query":"juan perez"}`, "2025-01-02 00:00:00", "2025-01-02 00:00:00"))
+
+	stepColumns := []string{
+		"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+		"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+	}
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(firstID).
+		WillReturnRows(sqlmock.NewRows(stepColumns).
+			AddRow(domain.NewID().String(), "ONAPI", "novasco", "company_name", "null", true, "", "", `[{"texto":"NOVASCO"}]`, `{"person_name":["Juan Perez"]}`, 0, nil))
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(secondID).
+		WillReturnRows(sqlmock.NewRows(stepColumns).
+			AddRow(domain.NewID().String(), "CAMARA", "juan perez", "person_name", "null", true, "", "", `[]`, `{"person_name":["JUAN  PEREZ"]}`, 0, nil))
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/"+sessionID, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data domain.SessionView `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Data.Pipelines) != 2 || body.Data.Pipelines[1].SessionID != sessionID {
+		t.Fatalf("expected both pipelines of the session, got %+v", body.Data.Pipelines)
+	}
+	if len(body.Data.Entities) != 1 || body.Data.Entities[0].Occurrences != 2 || len(body.Data.Entities[0].PipelineIDs) != 2 {
+		t.Errorf("expected one person found by both pipelines, got %+v", body.Data.Entities)
+	}
+	if len(body.Data.Findings) != 2 {
+		t.Errorf("expected a finding per search, got %+v", body.Data.Findings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSessionHandlerNotFound(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	sessionID := domain.NewID().String()
+	mock.ExpectQuery("FROM sessions").
+		WithArgs(sessionID).
+		WillReturnError(sql.ErrNoRows)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/"+sessionID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown session, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid ID, got %d", rec.Code)
+	}
+}
+
+func pgrNewsRows(staleResultID, freshResultID domain.ID, staleAt time.Time) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{"id", "domain_search_result_id", "url", "title", "published_at", "created_at", "updated_at"}).
+		AddRow(domain.NewID().String(), freshResultID.String(), "https://pgr.gob.do/fresh", "Fresh", now, now, now).
+		AddRow(domain.NewID().String(), staleResultID.String(), "https://pgr.gob.do/stale", "Stale", nil, staleAt, staleAt)
+}
+
+func TestPgrHandlerFlagsStaleRecords(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.staleAfter = 24 * time.Hour
+	s.refreshRecords = func(ctx context.Context, ids []domain.ID) (int, error) {
+		t.Fatalf("expected no refresh without refresh=true, got %v", ids)
+		return 0, nil
+	}
+
+	staleResultID, freshResultID := domain.NewID(), domain.NewID()
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(10, 0).
+		WillReturnRows(pgrNewsRows(staleResultID, freshResultID, time.Now().Add(-48*time.Hour)))
+	expectCount(mock, "pgr_news", 2)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pgr", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data []domain.PGRNews `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Data) != 2 {
+		t.Fatalf("expected 2 news, got %d", len(body.Data))
+	}
+	if body.Data[0].Stale || body.Data[0].AsOf.IsZero() {
+		t.Errorf("expected the recent news to be fresh and dated, got %+v", body.Data[0].Freshness)
+	}
+	if !body.Data[1].Stale {
+		t.Errorf("expected the old news to be stale, got %+v", body.Data[1].Freshness)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPgrHandlerRefreshesStaleRecords(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.staleAfter = 24 * time.Hour
+
+	var refreshed []domain.ID
+	s.refreshRecords = func(ctx context.Context, ids []domain.ID) (int, error) {
+		refreshed = append(refreshed, ids...)
+		return len(ids), nil
+	}
+
+	staleResultID, freshResultID := domain.NewID(), domain.NewID()
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(10, 0).
+		WillReturnRows(pgrNewsRows(staleResultID, freshResultID, time.Now().Add(-48*time.Hour)))
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(10, 0).
+		WillReturnRows(pgrNewsRows(staleResultID, freshResultID, time.Now()))
+	expectCount(mock, "pgr_news", 2)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pgr?refresh=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if len(refreshed) != 1 || refreshed[0] != staleResultID {
+		t.Fatalf("expected only the stale search result to be re-scraped, got %v", refreshed)
+	}
+
+	var body struct {
+		Data []domain.PGRNews `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, news := range body.Data {
+		if news.Stale {
+			t.Errorf("expected refreshed news to be fresh, got %+v", news)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPipelineGraphHandler(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	pipelineID := domain.NewID().String()
+	parentID, childID := domain.NewID().String(), domain.NewID().String()
+
+	mock.ExpectQuery("FROM dynamic_pipeline_results").
+		WithArgs(pipelineID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "session_id", "total_steps", "successful_steps", "failed_steps", "max_depth_reached", "stop_reason", "config", "created_at", "updated_at",
+		}).AddRow(pipelineID, nil, 2, 2, 0, 1, "", `{"query":"novasco"}`, "2025-01-01 00:00:00", "2025-01-01 00:00:00"))
+	mock.ExpectQuery("FROM dynamic_pipeline_steps").
+		WithArgs(pipelineID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "domain_type", "search_parameter", "category", "keywords", "success", "error_message",
+			"skip_reason", "output", "keywords_per_category", "depth", "parent_step_id",
+		}).
+			AddRow(parentID, "ONAPI", "novasco", "company_name", "null", true, "", "", "[]", `{"person_name":["Juan Perez"]}`, 0, nil).
+			AddRow(childID, "PGR", "Juan Perez", "person_name", "null", true, "", "", "[]", "{}", 1, parentID))
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/graph?id="+pipelineID, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data domain.PipelineGraph `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Data.Nodes) != 2 || len(body.Data.Edges) != 1 {
+		t.Fatalf("expected 2 nodes and 1 edge, got %+v", body.Data)
+	}
+	if edge := body.Data.Edges[0]; edge.From != parentID || edge.To != childID || edge.Keyword != "Juan Perez" {
+		t.Errorf("unexpected edge %+v", edge)
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/graph", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without id, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPipelineEventsHandler(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	pipelineID := domain.NewID().String()
+	stepID := domain.NewID().String()
+
+	mock.ExpectQuery("FROM pipeline_events").
+		WithArgs(pipelineID).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "pipeline_id", "step_id", "type", "message", "data", "created_at"}).
+			AddRow(int64(1), pipelineID, nil, "started", `pipeline started for "novasco"`, []byte(`{"query":"novasco"}`), "2025-01-01 00:00:00").
+			AddRow(int64(2), pipelineID, stepID, "step_completed", `step 1 completed: ONAPI search for "novasco"`, []byte(`{"domain_type":"ONAPI","depth":0}`), "2025-01-01 00:00:01").
+			AddRow(int64(3), pipelineID, nil, "completed", "pipeline completed", nil, "2025-01-01 00:00:02"))
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/events?id="+pipelineID, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Data []domain.PipelineEvent `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Data) != 3 {
+		t.Fatalf("expected 3 events, got %+v", body.Data)
+	}
+	types := []domain.PipelineEventType{body.Data[0].Type, body.Data[1].Type, body.Data[2].Type}
+	if types[0] != domain.PipelineEventStarted || types[1] != domain.PipelineEventStepCompleted || types[2] != domain.PipelineEventCompleted {
+		t.Errorf("expected the events in the order they were recorded, got %v", types)
+	}
+	if body.Data[1].StepID.String() != stepID || body.Data[1].Data["domain_type"] != "ONAPI" {
+		t.Errorf("expected the step and details of the step event, got %+v", body.Data[1])
+	}
+
+	rec = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pipeline/events", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without id, got %d", rec.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestListHandlersSortAndFilter(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC)
+	mock.ExpectQuery(`FROM pgr_news\s+WHERE created_at >= \? AND created_at <= \?\s+ORDER BY title ASC, created_at DESC`).
+		WithArgs(since, until, 10, 0).
+		WillReturnRows(pgrNewsRows(domain.NewID(), domain.NewID(), time.Now()))
+	expectCount(mock, "pgr_news", 2)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pgr?sort=title&order=asc&since=2025-01-01&until=2025-01-31", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, target := range []string{
+		"/api/pgr?sort=password",
+		"/api/dgii?sort=rnc%3BDROP%20TABLE%20dgii_registers",
+		"/api/docking?order=random",
+		"/api/pipeline?sort=config",
+		"/api/onapi/export?sort=imagenes",
+		"/api/scj?since=yesterday",
+	} {
+		rec := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// expectCount expects the count of the records of a table, as listings total
+// them
+func expectCount(mock sqlmock.Sqlmock, table string, total int64) {
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM ` + table).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(total))
+}
+
+func TestListHandlersReturnPaginationMetadata(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(2, 4).
+		WillReturnRows(pgrNewsRows(domain.NewID(), domain.NewID(), time.Now()))
+	expectCount(mock, "pgr_news", 7)
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(2, 6).
+		WillReturnRows(pgrNewsRows(domain.NewID(), domain.NewID(), time.Now()))
+	expectCount(mock, "pgr_news", 8)
+
+	for _, test := range []struct {
+		target  string
+		offset  int
+		total   int64
+		hasMore bool
+	}{
+		// Records 5 and 6 of 7, so one follows
+		{"/api/pgr?offset=4&limit=2", 4, 7, true},
+		// Records 7 and 8 of 8, the last page
+		{"/api/pgr?offset=6&limit=2", 6, 8, false},
+	} {
+		rec := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", test.target, rec.Code, rec.Body.String())
+		}
+
+		var body struct {
+			Data    []domain.PGRNews `json:"data"`
+			Count   int              `json:"count"`
+			Offset  int              `json:"offset"`
+			Limit   int              `json:"limit"`
+			Total   int64            `json:"total"`
+			HasMore bool             `json:"has_more"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Count != 2 || body.Offset != test.offset || body.Limit != 2 || body.Total != test.total || body.HasMore != test.hasMore {
+			t.Errorf("%s: unexpected metadata count=%d offset=%d limit=%d total=%d has_more=%v",
+				test.target, body.Count, body.Offset, body.Limit, body.Total, body.HasMore)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	s := &Server{listMaxLimit: 50}
+
+	for _, test := range []struct {
+		query  string
+		offset int
+		limit  int
+	}{
+		{"", 0, DefaultListLimit},
+		{"offset=20&limit=5", 20, 5},
+		{"limit=0", 0, DefaultListLimit},
+		// Pages above the maximum are lowered to it
+		{"limit=999999999", 0, 50},
+	} {
+		offset, limit, err := s.parsePagination(httptest.NewRequest(http.MethodGet, "/api/pgr?"+test.query, nil))
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", test.query, err)
+			continue
+		}
+		if offset != test.offset || limit != test.limit {
+			t.Errorf("%q: expected offset %d and limit %d, got %d and %d", test.query, test.offset, test.limit, offset, limit)
+		}
+	}
+
+	for _, query := range []string{"offset=-1", "limit=-10", "limit=abc", "offset=1.5", "limit=10abc"} {
+		if _, _, err := s.parsePagination(httptest.NewRequest(http.MethodGet, "/api/pgr?"+query, nil)); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+func TestListHandlersRejectInvalidPagination(t *testing.T) {
+	s, mock := newMockServer(t)
+
+	for _, target := range []string{
+		"/api/onapi?limit=abc",
+		"/api/scj?offset=-5",
+		"/api/dgii?limit=-1",
+		"/api/pgr?offset=ten",
+		"/api/docking?limit=1e3",
+		"/api/pipeline?limit=abc",
+		"/api/dgii/export?offset=-1",
+	} {
+		rec := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+	}
+
+	// An oversized page is capped instead of scanning the table
+	mock.ExpectQuery("FROM pgr_news").
+		WithArgs(DefaultListMaxLimit, 0).
+		WillReturnRows(pgrNewsRows(domain.NewID(), domain.NewID(), time.Now()))
+	expectCount(mock, "pgr_news", 2)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pgr?limit=999999999", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+
+	"insightful-intel/internal/infra"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header carrying the ID of a request, accepted from
+// the caller and echoed in the response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from callers
+const maxRequestIDLength = 128
+
+// requestIDMiddleware sets the ID of the request as the execution ID of its
+// context, so everything it logs carries it, and echoes it in the response.
+// The caller's X-Request-ID is kept when it is a plain token, a new UUID is
+// generated otherwise.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(infra.SetExecutionID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID reports whether id is non-empty, short and made of letters,
+// digits and "-", "_", "." or ":", so it is safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
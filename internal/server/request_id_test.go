@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"insightful-intel/internal/infra"
+
+	"github.com/google/uuid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	s := &Server{}
+
+	var seen string
+	handler := s.requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = infra.GetExecutionID(r.Context())
+	}))
+
+	// The caller's ID round-trips and reaches the context
+	req := httptest.NewRequest(http.MethodGet, "/api/pgr", nil)
+	req.Header.Set(RequestIDHeader, "client-req_42")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "client-req_42" {
+		t.Errorf("expected the request ID to be echoed, got %q", got)
+	}
+	if seen != "client-req_42" {
+		t.Errorf("expected the context to carry the request ID, got %q", seen)
+	}
+
+	// Without one, or with one unsafe to log, a new ID is generated
+	for _, header := range []string{"", "bad id\nwith newline", string(make([]byte, maxRequestIDLength+1))} {
+		req := httptest.NewRequest(http.MethodGet, "/api/pgr", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(RequestIDHeader)
+		if _, err := uuid.Parse(got); err != nil {
+			t.Errorf("header %q: expected a generated UUID, got %q", header, got)
+		}
+		if seen != got {
+			t.Errorf("header %q: expected the context to carry %q, got %q", header, got, seen)
+		}
+	}
+}
+
+func TestRoutesEchoRequestID(t *testing.T) {
+	s, _ := newMockServer(t)
+	s.apiKeys = []string{"key"}
+
+	// Rejected requests carry their ID as well
+	req := httptest.NewRequest(http.MethodGet, "/api/pgr", nil)
+	req.Header.Set(RequestIDHeader, "rejected-1")
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get(RequestIDHeader) != "rejected-1" {
+		t.Errorf("expected a 401 carrying the request ID, got %d with %q", rec.Code, rec.Header().Get(RequestIDHeader))
+	}
+}
@@ -3,16 +3,22 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"insightful-intel/internal/custom"
 	"insightful-intel/internal/domain"
 	"insightful-intel/internal/infra"
+	"insightful-intel/internal/interactor"
 	"insightful-intel/internal/module"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
@@ -20,7 +26,15 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	// Register routes
 	mux.HandleFunc("/search", s.searchHandler)
+	mux.HandleFunc("POST /search", s.searchParamsHandler)
+	mux.HandleFunc("POST /search/batch", s.searchBatchHandler)
 	mux.HandleFunc("/dynamic", s.dynamicPipelineHandler)
+	mux.HandleFunc("/dynamic/status", s.executionStatusHandler)
+	mux.HandleFunc("GET /dynamic/ws", s.dynamicPipelineWebSocketHandler)
+	mux.HandleFunc("POST /dynamic/plan", s.dynamicPlanHandler)
+	mux.HandleFunc("POST /api/screen", s.screenHandler)
+	mux.HandleFunc("/metrics", s.metricsHandler)
+	mux.HandleFunc("GET /health", s.healthHandler)
 
 	// Repository-based routes
 	mux.HandleFunc("/api/onapi", s.onapiHandler)
@@ -28,12 +42,27 @@ func (s *Server) RegisterRoutes() http.Handler {
 	mux.HandleFunc("/api/dgii", s.dgiiHandler)
 	mux.HandleFunc("/api/pgr", s.pgrHandler)
 	mux.HandleFunc("/api/docking", s.dockingHandler)
+	mux.HandleFunc("GET /api/onapi/export", s.onapiExportHandler)
+	mux.HandleFunc("GET /api/scj/export", s.scjExportHandler)
+	mux.HandleFunc("GET /api/dgii/export", s.dgiiExportHandler)
+	mux.HandleFunc("GET /api/pgr/export", s.pgrExportHandler)
+	mux.HandleFunc("GET /api/docking/export", s.dockingExportHandler)
 	mux.HandleFunc("/api/pipeline", s.pipelineHandler)
 	mux.HandleFunc("/api/pipeline/steps", s.pipelineStepsHandler)
-	mux.HandleFunc("/api/pipeline/save", s.savePipelineHandler)
-
-	// Wrap the mux with CORS middleware
-	return s.corsMiddleware(mux)
+	mux.HandleFunc("/api/pipeline/keywords", s.pipelineKeywordsHandler)
+	mux.HandleFunc("/api/pipeline/graph", s.pipelineGraphHandler)
+	mux.HandleFunc("/api/pipeline/events", s.pipelineEventsHandler)
+	mux.HandleFunc("/api/pipeline/save", s.requireSignature(s.savePipelineHandler))
+	mux.HandleFunc("/api/pipeline/retry-failed", s.retryFailedStepsHandler)
+	mux.HandleFunc("POST /api/pipeline/findings", s.requireSignature(s.pipelineFindingsHandler))
+	mux.HandleFunc("GET /api/pipeline/entities", s.pipelineEntitiesHandler)
+	mux.HandleFunc("GET /api/entities/{name}/companies", s.entityCompaniesHandler)
+	mux.HandleFunc("POST /api/sessions", s.createSessionHandler)
+	mux.HandleFunc("GET /api/sessions/{id}", s.sessionHandler)
+
+	// Wrap the mux with the API key check, within CORS so preflight requests
+	// are answered without a key, and give every request an ID
+	return s.requestIDMiddleware(s.corsMiddleware(s.authMiddleware(mux)))
 }
 
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
@@ -41,7 +70,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*") // Replace "*" with specific origins if needed
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, "+RequestIDHeader+", "+custom.SignatureHeader)
+		w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
 		w.Header().Set("Access-Control-Allow-Credentials", "false") // Set to "true" if credentials are required
 
 		// Handle preflight OPTIONS requests
@@ -66,6 +96,7 @@ type ConnectorPipeline struct {
 
 // Step executes a single step in the pipeline for a specific domain connector
 func Step[T any](
+	ctx context.Context,
 	domainConnector domain.DomainConnector[T],
 	searchableCategory []domain.KeywordCategory,
 	category domain.KeywordCategory,
@@ -90,7 +121,7 @@ func Step[T any](
 			continue
 		}
 
-		result, err := module.SearchDomain(domainType, domain.DomainSearchParams{Query: keyword})
+		result, err := module.SearchDomain(ctx, domainType, domain.DomainSearchParams{Query: keyword})
 		if err != nil {
 			continue
 		}
@@ -135,20 +166,18 @@ const Seconds = 2
 // searchHandler demonstrates how to use the new domain search function
 func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
 	// Get query parameters
-	query := r.URL.Query().Get("q")
-	domainType := r.URL.Query().Get("domain")
-
-	if query == "" {
-		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+	query, err := module.ValidateSeed(r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid query parameter 'q': %v", err), http.StatusBadRequest)
 		return
 	}
+	domainType := r.URL.Query().Get("domain")
 
 	searchParams := domain.DomainSearchParams{
 		Query: query,
 	}
 
 	var result *domain.DomainSearchResult
-	var err error
 
 	// If specific domain is requested, search that domain
 	if domainType != "" {
@@ -162,7 +191,7 @@ func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("Invalid domain type. Use: %v", availableTypes), http.StatusBadRequest)
 			return
 		}
-		result, err = module.SearchDomain(dt, searchParams)
+		result, err = module.SearchDomain(r.Context(), dt, searchParams)
 
 		if err != nil {
 			http.Error(w, "Search failed", http.StatusInternalServerError)
@@ -188,7 +217,7 @@ func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
 	// If no specific domain, search default domains
 	domainTypes := domain.DefaultDomainTypes()
 
-	results := module.SearchMultipleDomains(domainTypes, searchParams)
+	results := module.SearchMultipleDomains(r.Context(), domainTypes, searchParams, s.searchDomain)
 
 	// Convert to ConnectorPipeline format
 	pipeline := make([]ConnectorPipeline, 0, len(results))
@@ -219,53 +248,291 @@ func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonResp)
 }
 
-// dynamicPipelineHandler demonstrates the new dynamic pipeline functionality
-func (s *Server) dynamicPipelineHandler(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+// SearchRequest is the body of POST /search: the Google Docking search
+// parameters and the domain to search, Google Docking when empty
+type SearchRequest struct {
+	domain.GoogleDorkingSearchParams
+	Domain string `json:"domain"`
+}
+
+// searchParamsHandler runs a single domain search with the full Google Docking
+// parameters. Other domains only take the query.
+func (s *Server) searchParamsHandler(w http.ResponseWriter, r *http.Request) {
+	var request SearchRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON body: %v", err), http.StatusBadRequest)
 		return
 	}
-	// User the dymanic interactor
 
-	// Get configuration parameters
-	maxDepth := 53
-	if depth := r.URL.Query().Get("depth"); depth != "" {
-		if d, err := strconv.Atoi(depth); err == nil && d > 0 && d <= 10 {
-			maxDepth = d
+	params := request.GoogleDorkingSearchParams
+	query, err := module.ValidateSeed(params.Query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid field 'query': %v", err), http.StatusBadRequest)
+		return
+	}
+	params.Query = query
+
+	if params.MaxResults < 0 {
+		http.Error(w, "Invalid field 'max_results': must not be negative", http.StatusBadRequest)
+		return
+	}
+	if params.MinRelevance < 0 || params.MinRelevance > 1 {
+		http.Error(w, "Invalid field 'min_relevance': must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+
+	domainType := domain.DomainTypeGoogleDorking
+	if request.Domain != "" {
+		domainType, err = domain.GetDomainTypeFromString(request.Domain)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid field 'domain': %v", err), http.StatusBadRequest)
+			return
 		}
 	}
 
-	skipDuplicates := true
-	if skip := r.URL.Query().Get("skip_duplicates"); skip == "false" {
-		skipDuplicates = false
+	var result *domain.DomainSearchResult
+	if slices.Contains(module.DorkingDomainTypes, domainType) {
+		search := s.searchDorking
+		if search == nil {
+			search = module.SearchDorkingWithParams
+		}
+		result, err = search(r.Context(), domainType, params)
+	} else {
+		if hasDorkingParams(params) {
+			http.Error(w, fmt.Sprintf("Invalid body: only 'query' applies to the %s domain, the other fields are Google Docking parameters", request.Domain), http.StatusBadRequest)
+			return
+		}
+		search := s.searchDomain
+		if search == nil {
+			search = module.SearchDomain
+		}
+		result, err = search(r.Context(), domainType, domain.DomainSearchParams{Query: query})
+	}
+	if err != nil || result == nil {
+		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ConnectorPipeline{
+		Success:             result.Success,
+		Error:               result.Error,
+		Name:                string(result.DomainType),
+		SearchParameter:     result.SearchParameter,
+		Output:              result.Output,
+		KeywordsPerCategory: result.KeywordsPerCategory,
+	})
+}
+
+// hasDorkingParams reports whether params set any field besides the query,
+// which only Google Docking searches take
+func hasDorkingParams(params domain.GoogleDorkingSearchParams) bool {
+	return params.MaxResults != 0 || params.MinRelevance != 0 ||
+		params.ExactMatch || params.CaseSensitive || params.Explain || params.Pages != 0 ||
+		len(params.IncludeKeywords) > 0 || len(params.ExcludeKeywords) > 0 ||
+		len(params.FileTypeKeywords) > 0 || len(params.SitesKeywords) > 0 || len(params.InURLKeywords) > 0
+}
+
+// maxBatchQueries bounds the queries of a batch search request
+const maxBatchQueries = 50
+
+// SearchBatchRequest lists the queries of a batch search and the domains to
+// search each of them in, the default domains when empty
+type SearchBatchRequest struct {
+	Queries []string `json:"queries"`
+	Domains []string `json:"domains"`
+}
+
+// searchBatchHandler runs the search of GET /search for several queries at
+// once and returns the connector results of each query keyed by the query
+func (s *Server) searchBatchHandler(w http.ResponseWriter, r *http.Request) {
+	var request SearchBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	if len(request.Queries) == 0 {
+		http.Error(w, "Field 'queries' is required", http.StatusBadRequest)
+		return
+	}
+	if len(request.Queries) > maxBatchQueries {
+		http.Error(w, fmt.Sprintf("At most %d queries can be searched at once", maxBatchQueries), http.StatusBadRequest)
+		return
+	}
+
+	queries := make([]string, 0, len(request.Queries))
+	for _, raw := range request.Queries {
+		query, err := module.ValidateSeed(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query %q: %v", raw, err), http.StatusBadRequest)
+			return
+		}
+		queries = append(queries, query)
+	}
+
+	domainTypes := domain.DefaultDomainTypes()
+	if len(request.Domains) > 0 {
+		domainTypes = make([]domain.DomainType, 0, len(request.Domains))
+		for _, name := range request.Domains {
+			dt, err := domain.GetDomainTypeFromString(name)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid domain type: %s", name), http.StatusBadRequest)
+				return
+			}
+			domainTypes = append(domainTypes, dt)
+		}
+	}
+
+	results := module.SearchBatch(r.Context(), queries, domainTypes, module.DefaultBatchConcurrency, s.searchDomain)
+
+	data := make(map[string][]ConnectorPipeline, len(results))
+	for query, queryResults := range results {
+		pipeline := make([]ConnectorPipeline, 0, len(queryResults))
+		for _, result := range queryResults {
+			pipeline = append(pipeline, ConnectorPipeline{
+				Success:             result.Success,
+				Error:               result.Error,
+				Name:                string(result.DomainType),
+				SearchParameter:     result.SearchParameter,
+				Output:              result.Output,
+				KeywordsPerCategory: result.KeywordsPerCategory,
+			})
+		}
+		data[query] = pipeline
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+		"count":   len(data),
+	})
+}
+
+// maxScreeningQueries and maxScreeningConcurrency bound a screening request
+const (
+	maxScreeningQueries     = 500
+	maxScreeningConcurrency = 32
+)
+
+// ScreenRequest lists the queries to screen and the domains to screen them in
+type ScreenRequest struct {
+	Queries     []string `json:"queries"`
+	Domains     []string `json:"domains"`
+	Concurrency int      `json:"concurrency"`
+}
+
+// screenHandler reports, for each query, which domains hold matching records.
+// Unlike a pipeline it only runs depth-0 searches, so lists of names can be
+// screened quickly.
+func (s *Server) screenHandler(w http.ResponseWriter, r *http.Request) {
+	var request ScreenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	queries := make([]string, 0, len(request.Queries))
+	for _, raw := range request.Queries {
+		query, err := module.ValidateSeed(raw)
+		if errors.Is(err, domain.ErrEmptySeed) {
+			continue
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid query %q: %v", raw, err), http.StatusBadRequest)
+			return
+		}
+		queries = append(queries, query)
+	}
+	if len(queries) == 0 {
+		http.Error(w, "Field 'queries' is required", http.StatusBadRequest)
+		return
+	}
+	if len(queries) > maxScreeningQueries {
+		http.Error(w, fmt.Sprintf("At most %d queries can be screened at once", maxScreeningQueries), http.StatusBadRequest)
+		return
+	}
+
+	domainTypes := domain.DefaultDomainTypes()
+	if len(request.Domains) > 0 {
+		domainTypes = make([]domain.DomainType, 0, len(request.Domains))
+		for _, name := range request.Domains {
+			dt, err := domain.GetDomainTypeFromString(name)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid domain type: %s", name), http.StatusBadRequest)
+				return
+			}
+			domainTypes = append(domainTypes, dt)
+		}
+	}
+
+	concurrency := min(request.Concurrency, maxScreeningConcurrency)
+	results := module.Screen(r.Context(), queries, domainTypes, concurrency, s.searchDomain)
+
+	hits := 0
+	for _, result := range results {
+		if result.Hit {
+			hits++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    results,
+		"count":   len(results),
+		"hits":    hits,
+	})
+}
+
+// dynamicPipelineHandler demonstrates the new dynamic pipeline functionality
+func (s *Server) dynamicPipelineHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := s.parsePipelineParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	executionID := r.URL.Query().Get("execution_id")
 	if executionID == "" {
 		executionID = domain.NewID().String()
+	} else if _, err := uuid.Parse(executionID); err != nil {
+		http.Error(w, "Query parameter 'execution_id' must be an execution ID", http.StatusBadRequest)
+		return
 	}
 
 	// Check if streaming is requested
 	stream := r.URL.Query().Get("stream") == "true"
 	if stream {
-		s.dynamicPipelineStreamHandler(w, r, query, maxDepth, skipDuplicates)
+		s.dynamicPipelineStreamHandler(w, r, r.URL.Query().Get("execution_id"), params)
 		return
 	}
 
-	ctx := infra.SetExecutionID(context.Background(), executionID)
-	// Start pipeline execution in the background
-	go func() {
-		log.Printf("[%s] Starting background pipeline execution with query: %s, max depth: %d, skip duplicates: %v",
-			executionID, query, maxDepth, skipDuplicates)
+	ctx := params.withSession(infra.SetExecutionID(s.backgroundContext(), executionID))
+	// The execution is tracked before it starts so its status can be polled
+	// as soon as the response is sent
+	s.executions().Start(executionID)
 
-		_, err := s.interactor.ExecuteDynamicPipeline(ctx, query, maxDepth, skipDuplicates)
+	// Start pipeline execution in the background
+	s.goBackground(func() {
+		logger := infra.Logger(ctx)
+		logger.Info("starting background pipeline execution",
+			slog.String("query", params.query),
+			slog.Int("max_depth", params.maxDepth),
+			slog.Bool("skip_duplicates", params.skipDuplicates),
+			slog.Duration("delay_between_steps", params.delay.Duration()),
+		)
+
+		_, err := s.interactor.ExecuteDynamicPipelineWithConfig(ctx, params.pipelineConfig(ctx))
 		if err != nil {
-			log.Printf("[%s] Background pipeline execution failed: %v", executionID, err)
+			logger.Error("background pipeline execution failed", slog.Any("error", err))
 		} else {
-			log.Printf("[%s] Background pipeline execution completed successfully", executionID)
+			logger.Info("background pipeline execution completed")
 		}
-	}()
+	})
 
 	// _, err := s.interactor.ExecuteDynamicPipeline(ctx, query, maxDepth, skipDuplicates)
 	// if err != nil {
@@ -289,304 +556,547 @@ func (s *Server) dynamicPipelineHandler(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
-// dynamicPipelineStreamHandler handles streaming pipeline results
-func (s *Server) dynamicPipelineStreamHandler(w http.ResponseWriter, r *http.Request, query string, maxDepth int, skipDuplicates bool) {
-	// Set headers for streaming
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control")
-
-	// Create a channel to receive pipeline steps
-	stepChan := make(chan domain.DynamicPipelineStep, 100)
-	done := make(chan bool)
+// PlanRequest describes the pipeline POST /dynamic/plan plans: its query, depth
+// (the default depth when zero), whether repeated keywords are skipped (true
+// when omitted) and the domains it searches, every available domain when empty
+type PlanRequest struct {
+	Query          string   `json:"query"`
+	Depth          int      `json:"depth"`
+	SkipDuplicates *bool    `json:"skip_duplicates"`
+	Domains        []string `json:"domains"`
+}
 
-	// Configure the dynamic pipeline
-	config := domain.DynamicPipelineConfig{
-		MaxDepth:           maxDepth,
-		MaxConcurrentSteps: 10,
-		DelayBetweenSteps:  2,
-		SkipDuplicates:     skipDuplicates,
+// dynamicPlanHandler returns the steps a pipeline would run for a query
+// without running them, so a run can be reviewed before spending quota on it
+func (s *Server) dynamicPlanHandler(w http.ResponseWriter, r *http.Request) {
+	var request PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
 	}
 
-	// Available domains
-	availableDomains := domain.AllDomainTypes()
+	query, err := module.ValidateSeed(request.Query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid field 'query': %v", err), http.StatusBadRequest)
+		return
+	}
 
-	// Start pipeline execution in a goroutine
-	go func() {
-		defer close(stepChan)
-		defer close(done)
+	depth := ""
+	if request.Depth != 0 {
+		depth = strconv.Itoa(request.Depth)
+	}
+	maxDepth, err := s.pipelineDepth(depth)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid field 'depth': %v", err), http.StatusBadRequest)
+		return
+	}
 
-		// Execute the dynamic pipeline with step callback
-		dynamicResult, err := s.executeDynamicPipelineWithCallback(r.Context(), query, availableDomains, config, stepChan)
-		if err != nil {
-			// Send error as a step
-			errorStep := domain.DynamicPipelineStep{
-				DomainType:      "ERROR",
-				SearchParameter: query,
-				Success:         false,
-				Error:           err,
-				Output:          nil,
-				Depth:           0,
+	domainTypes := module.AvailableDomainTypes()
+	if len(request.Domains) > 0 {
+		domainTypes = make([]domain.DomainType, 0, len(request.Domains))
+		for _, name := range request.Domains {
+			dt, err := domain.GetDomainTypeFromString(name)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid domain type: %s", name), http.StatusBadRequest)
+				return
 			}
-			stepChan <- errorStep
-			return
+			domainTypes = append(domainTypes, dt)
 		}
-
-		// Send final summary
-		summaryStep := domain.DynamicPipelineStep{
-			DomainType:      "SUMMARY",
-			SearchParameter: query,
-			Success:         true,
-			Error:           nil,
-			Output: map[string]interface{}{
-				"total_steps":       dynamicResult.TotalSteps,
-				"successful_steps":  dynamicResult.SuccessfulSteps,
-				"failed_steps":      dynamicResult.FailedSteps,
-				"max_depth_reached": dynamicResult.MaxDepthReached,
-			},
-			Depth: dynamicResult.MaxDepthReached,
-		}
-		stepChan <- summaryStep
-	}()
-	// Flush the response to ensure immediate delivery
-	flusher, ok := w.(http.Flusher)
-	if ok {
-		flusher.Flush()
 	}
-	if !ok {
-		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+
+	skipDuplicates := request.SkipDuplicates == nil || *request.SkipDuplicates
+	plan, err := module.PlanDynamicPipeline(r.Context(), query, domainTypes, domain.DynamicPipelineConfig{
+		Query:            query,
+		MaxDepth:         maxDepth,
+		SkipDuplicates:   skipDuplicates,
+		AvailableDomains: domainTypes,
+		DryRun:           true,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to plan pipeline: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Stream the steps as they come
-	stepCount := 0
-	for {
-		select {
-		case step, ok := <-stepChan:
-			if !ok {
-				// Channel closed, send completion event
-				s.writeSSEEvent(w, "complete", map[string]interface{}{
-					"message":     "Pipeline execution completed",
-					"total_steps": stepCount,
-				}, flusher)
-				return
-			}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    plan,
+		"count":   len(plan.Steps),
+	})
+}
 
-			stepCount++
+// pipelineParams are the settings of a pipeline started from /dynamic or
+// /dynamic/ws
+type pipelineParams struct {
+	query          string
+	maxDepth       int
+	skipDuplicates bool
+	delay          domain.Seconds
+	jitter         float64
+	traversal      domain.TraversalMode
+	adaptiveDepth  bool
+	stopOnFirstHit bool
+	// sessionID is the session the run is stored as part of, if any
+	sessionID string
+}
 
-			// Convert step to ConnectorPipeline format
-			pipelineStep := ConnectorPipeline{
-				Success:             step.Success,
-				Error:               step.Error,
-				Name:                string(step.DomainType),
-				SearchParameter:     step.SearchParameter,
-				Output:              step.Output,
-				KeywordsPerCategory: step.KeywordsPerCategory,
-			}
+// parsePipelineParams reads the settings of a pipeline from the query
+// parameters of r, returning an error naming the invalid parameter
+func (s *Server) parsePipelineParams(r *http.Request) (pipelineParams, error) {
+	query, err := module.ValidateSeed(r.URL.Query().Get("q"))
+	if err != nil {
+		return pipelineParams{}, fmt.Errorf("Invalid query parameter 'q': %v", err)
+	}
 
-			// Send step as SSE event
-			eventData := map[string]interface{}{
-				"step_number": stepCount,
-				"step":        pipelineStep,
-				"depth":       step.Depth,
-				"category":    string(step.Category),
-				"keywords":    step.Keywords,
-			}
+	// Get configuration parameters
+	maxDepth, err := s.pipelineDepth(r.URL.Query().Get("depth"))
+	if err != nil {
+		return pipelineParams{}, fmt.Errorf("Invalid query parameter 'depth': %v", err)
+	}
 
-			eventType := "step"
-			switch step.DomainType {
-			case "error":
-				eventType = "error"
-			case "SUMMARY":
-				eventType = "sumary"
-			}
+	skipDuplicates := true
+	if skip := r.URL.Query().Get("skip_duplicates"); skip == "false" {
+		skipDuplicates = false
+	}
 
-			s.writeSSEEvent(w, eventType, eventData, flusher)
+	delay, err := pipelineDelay(r.URL.Query().Get("delay_ms"))
+	if err != nil {
+		return pipelineParams{}, fmt.Errorf("Invalid query parameter 'delay_ms': %v", err)
+	}
+	jitter, err := pipelineJitter(r.URL.Query().Get("delay_jitter"))
+	if err != nil {
+		return pipelineParams{}, fmt.Errorf("Invalid query parameter 'delay_jitter': %v", err)
+	}
 
-		case <-r.Context().Done():
-			// Client disconnected
-			return
+	// Runs started within a session are stored as part of it
+	sessionID := r.URL.Query().Get("session_id")
+	if sessionID != "" {
+		if _, err := s.GetRepositories().GetSessionRepository().GetByID(r.Context(), sessionID); err != nil {
+			return pipelineParams{}, fmt.Errorf("Invalid session: %v", err)
 		}
 	}
+
+	return pipelineParams{
+		query:          query,
+		maxDepth:       maxDepth,
+		skipDuplicates: skipDuplicates,
+		delay:          delay,
+		jitter:         jitter,
+		traversal:      domain.TraversalMode(r.URL.Query().Get("traversal")),
+		adaptiveDepth:  r.URL.Query().Get("adaptive_depth") == "true",
+		stopOnFirstHit: r.URL.Query().Get("stop_on_first_hit") == "true",
+		sessionID:      sessionID,
+	}, nil
 }
 
-// executeDynamicPipelineWithCallback executes the dynamic pipeline and sends steps to a channel
-func (s *Server) executeDynamicPipelineWithCallback(ctx context.Context, query string, availableDomains []domain.DomainType, config domain.DynamicPipelineConfig, stepChan chan<- domain.DynamicPipelineStep) (*domain.DynamicPipelineResult, error) {
-	// Create a custom pipeline executor that streams steps
-	return s.executeStreamingPipeline(ctx, query, availableDomains, config, stepChan)
+// withSession returns ctx carrying the session of the run, when it has one
+func (p pipelineParams) withSession(ctx context.Context) context.Context {
+	if p.sessionID == "" {
+		return ctx
+	}
+	return infra.SetSessionID(ctx, p.sessionID)
 }
 
-// executeStreamingPipeline executes the pipeline with real-time streaming
-func (s *Server) executeStreamingPipeline(ctx context.Context, query string, availableDomains []domain.DomainType, config domain.DynamicPipelineConfig, stepChan chan<- domain.DynamicPipelineStep) (*domain.DynamicPipelineResult, error) {
-	// Create the initial pipeline steps
-	initialResult, err := module.CreateDynamicPipeline(ctx, query, availableDomains, config)
-	if err != nil {
-		return nil, err
+// pipelineConfig returns the configuration of a pipeline run with these
+// settings under ctx, the default one with the settings of the request
+func (p pipelineParams) pipelineConfig(ctx context.Context) domain.DynamicPipelineConfig {
+	config := interactor.DefaultPipelineConfig(ctx, p.query, p.maxDepth, p.skipDuplicates)
+	config.DelayBetweenSteps = p.delay
+	config.DelayJitter = p.jitter
+	config.TraversalMode = p.traversal
+	config.AdaptiveDepth = p.adaptiveDepth
+	config.StopOnFirstHit = p.stopOnFirstHit
+	return config
+}
+
+// pipelineDepth returns the depth of a pipeline started from /dynamic:
+// DefaultPipelineDepth when the depth parameter is empty, the parameter when it
+// is within the depth cap. Each level multiplies the steps by the domains
+// searched, so deeper runs are rejected rather than left to run for hours.
+func (s *Server) pipelineDepth(value string) (int, error) {
+	depthCap := s.pipelineMaxDepth
+	if depthCap <= 0 {
+		depthCap = DefaultPipelineMaxDepth
 	}
 
-	// Get initial steps from the result
-	initialSteps := initialResult.Steps
+	if value == "" {
+		return min(DefaultPipelineDepth, depthCap), nil
+	}
+
+	depth, err := strconv.Atoi(value)
+	if err != nil || depth <= 0 {
+		return 0, fmt.Errorf("must be a positive integer")
+	}
+	if depth > depthCap {
+		return 0, fmt.Errorf("must be at most %d", depthCap)
+	}
+	return depth, nil
+}
 
-	totalSteps := 0
-	successfulSteps := 0
-	failedSteps := 0
-	maxDepthReached := 0
+// pipelineDelay returns the pause after each step of a pipeline started from
+// /dynamic: DefaultDelayBetweenSteps when the delay_ms parameter is empty, the
+// parameter in milliseconds otherwise
+func pipelineDelay(value string) (domain.Seconds, error) {
+	if value == "" {
+		return domain.DefaultDelayBetweenSteps, nil
+	}
 
-	// Track searched keywords per domain to avoid duplicates
-	searchedKeywordsPerDomain := make(map[domain.DomainType]map[string]bool)
-	for _, domainType := range availableDomains {
-		searchedKeywordsPerDomain[domainType] = make(map[string]bool)
+	milliseconds, err := strconv.Atoi(value)
+	if err != nil || milliseconds < 0 {
+		return 0, fmt.Errorf("must be a non-negative integer")
 	}
+	return domain.Seconds(time.Duration(milliseconds) * time.Millisecond), nil
+}
 
-	// Process steps with streaming
-	processedSteps := make([]domain.DynamicPipelineStep, 0)
+// pipelineJitter returns the jitter of the pauses of a pipeline started from
+// /dynamic: none when the delay_jitter parameter is empty, the parameter as a
+// fraction of the delay otherwise
+func pipelineJitter(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
 
-	// Create a queue for steps to process
-	stepQueue := make([]domain.DynamicPipelineStep, len(initialSteps))
-	copy(stepQueue, initialSteps)
+	jitter, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(jitter) || math.IsInf(jitter, 0) || jitter < 0 {
+		return 0, fmt.Errorf("must be a non-negative number")
+	}
+	return jitter, nil
+}
+
+// executionStatusHandler returns the state of a pipeline execution started in
+// the background
+func (s *Server) executionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	executionID := r.URL.Query().Get("execution_id")
+	if executionID == "" {
+		http.Error(w, "Query parameter 'execution_id' is required", http.StatusBadRequest)
+		return
+	}
 
-	for len(stepQueue) > 0 {
-		// Get next step from queue
-		step := stepQueue[0]
-		stepQueue = stepQueue[1:]
+	state, ok := s.executions().Get(executionID)
+	if !ok {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
 
-		// Send step start event
-		startStep := step
-		startStep.Success = false
-		startStep.Output = nil
-		stepChan <- startStep
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    state,
+	})
+}
 
-		// Execute the step
-		result, err := module.SearchDomain(step.DomainType, domain.DomainSearchParams{Query: step.SearchParameter})
+// dynamicPipelineStreamHandler handles streaming pipeline results. Each
+// completed step is sent with the event ID of its position in the pipeline. A
+// pipeline started with an execution ID is stored under it and outlives its
+// connection for the resume window: a client reconnecting with Last-Event-ID
+// is sent the stored steps past that ID, then the live ones.
+func (s *Server) dynamicPipelineStreamHandler(w http.ResponseWriter, r *http.Request, executionID string, params pipelineParams) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
 
-		// Update step with results
-		step.Success = err == nil
-		step.Error = err
-		if result != nil {
-			step.Output = result.Output
-			step.KeywordsPerCategory = result.KeywordsPerCategory
+	resumable := executionID != ""
+	lastEventID := 0
+	if value := r.Header.Get("Last-Event-ID"); resumable && value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 0 {
+			http.Error(w, "Invalid header 'Last-Event-ID': must be a non-negative integer", http.StatusBadRequest)
+			return
 		}
+		lastEventID = id
+	}
 
-		// Update counters
-		totalSteps++
-		if step.Success {
-			successfulSteps++
+	// Set headers for streaming
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control, Last-Event-ID")
+
+	// A client reconnecting follows the pipeline still running under its
+	// execution ID; one reconnecting after it finished is only sent the steps
+	// it missed
+	var stream *pipelineStream
+	attached := false
+	if resumable {
+		stream = s.liveStream(executionID)
+		attached = stream != nil && stream.attach(r.Context())
+		if !attached {
+			stream = nil
+		}
+	}
+	if stream == nil && (!resumable || lastEventID == 0) {
+		stream = s.startPipelineStream(r.Context(), executionID, params)
+	}
+	if stream != nil {
+		if resumable {
+			defer func() {
+				if s.liveStream(executionID) == stream {
+					s.detachStream(executionID, stream)
+				} else {
+					<-stream.owner
+				}
+			}()
 		} else {
-			failedSteps++
+			defer stream.abandon()
 		}
+	}
 
-		if step.Depth > maxDepthReached {
-			maxDepthReached = step.Depth
+	// Flush the response to ensure immediate delivery
+	flusher.Flush()
+
+	// Send the stored steps past the last one the client received, all of
+	// them to a client following a pipeline another connection started. The
+	// live steps it is sent again are skipped.
+	stepCount := 0
+	replayed := make(map[domain.ID]bool)
+	if lastEventID > 0 || attached {
+		steps, err := s.GetRepositories().GetPipelineRepository().GetPipelineStepsByID(r.Context(), executionID)
+		if err != nil {
+			s.writeSSEEvent(w, 0, eventError, map[string]interface{}{
+				"message": fmt.Sprintf("failed to resume pipeline: %v", err),
+			}, flusher)
+			return
 		}
 
-		// Send completed step
-		stepChan <- step
-		processedSteps = append(processedSteps, step)
+		stepCount = min(lastEventID, len(steps))
+		for _, step := range steps[stepCount:] {
+			stepCount++
+			replayed[step.ID] = true
+			s.writeSSEEvent(w, stepCount, eventStep, stepEventData(stepCount, step), flusher)
+		}
+	}
+
+	if stream == nil {
+		s.writeStoredSummary(r.Context(), w, executionID, params.query, stepCount, flusher)
+		s.writeSSEEvent(w, 0, eventComplete, map[string]interface{}{
+			"message":     "Pipeline execution completed",
+			"total_steps": stepCount,
+		}, flusher)
+		return
+	}
+
+	// Keep the connection alive while a step is slow
+	keepaliveInterval := s.keepaliveInterval
+	if keepaliveInterval <= 0 {
+		keepaliveInterval = DefaultKeepaliveInterval
+	}
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+
+	// Stream the steps as they come. finished tells the summary or the error
+	// was sent, which a connection the pipeline finished before sends from the
+	// store.
+	finished := false
+	for {
+		select {
+		case step, ok := <-stream.steps:
+			if !ok {
+				if resumable {
+					s.removeStream(executionID, stream)
+					if !finished {
+						s.writeStoredSummary(r.Context(), w, executionID, params.query, stepCount, flusher)
+					}
+				}
+
+				// Channel closed, send completion event
+				s.writeSSEEvent(w, 0, eventComplete, map[string]interface{}{
+					"message":     "Pipeline execution completed",
+					"total_steps": stepCount,
+				}, flusher)
+				return
+			}
+
+			if replayed[step.ID] {
+				continue
+			}
+			infra.DebugDump("step", step)
+
+			// A completed step is the event a client resumes from
+			eventType := stepEventType(step)
+			id := 0
+			if eventType == eventStep && completedStep(step) {
+				stepCount++
+				id = stepCount
+			}
+			finished = finished || eventType == eventSummary || eventType == eventError
+
+			s.writeSSEEvent(w, id, eventType, stepEventData(stepCount, step), flusher)
 
-		// Add delay between steps for better streaming experience
-		time.Sleep(time.Duration(config.DelayBetweenSteps) * time.Second)
+		case <-keepalive.C:
+			// Comment lines are ignored by the EventSource clients
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
 
-		// Generate new steps from keywords if not at max depth
-		if step.Depth < config.MaxDepth && step.Success && step.Output != nil {
-			newSteps := s.generateNextSteps(step, availableDomains, searchedKeywordsPerDomain, config)
-			stepQueue = append(stepQueue, newSteps...)
+		case <-r.Context().Done():
+			// Client disconnected
+			return
 		}
 	}
+}
 
-	// Create final result
-	dynamicResult := &domain.DynamicPipelineResult{
-		Steps:           processedSteps,
-		TotalSteps:      totalSteps,
-		SuccessfulSteps: successfulSteps,
-		FailedSteps:     failedSteps,
-		MaxDepthReached: maxDepthReached,
-		Config:          config,
+// writeStoredSummary sends the summary of a stored pipeline that finished.
+// Its counters are only stored once it finishes.
+func (s *Server) writeStoredSummary(ctx context.Context, w http.ResponseWriter, executionID string, query string, stepCount int, flusher http.Flusher) {
+	pipeline, err := s.GetRepositories().GetPipelineRepository().GetPipelineByID(ctx, executionID)
+	if err != nil || pipeline.TotalSteps == 0 {
+		return
 	}
 
-	return dynamicResult, nil
+	s.writeSSEEvent(w, 0, eventSummary, stepEventData(stepCount, domain.DynamicPipelineStep{
+		DomainType:      "SUMMARY",
+		SearchParameter: query,
+		Success:         true,
+		Output: map[string]interface{}{
+			"total_steps":       pipeline.TotalSteps,
+			"successful_steps":  pipeline.SuccessfulSteps,
+			"failed_steps":      pipeline.FailedSteps,
+			"max_depth_reached": pipeline.MaxDepthReached,
+		},
+		Depth: pipeline.MaxDepthReached,
+	}), flusher)
 }
 
-// generateNextSteps generates new pipeline steps from a completed step
-func (s *Server) generateNextSteps(completedStep domain.DynamicPipelineStep, availableDomains []domain.DomainType, searchedKeywordsPerDomain map[domain.DomainType]map[string]bool, config domain.DynamicPipelineConfig) []domain.DynamicPipelineStep {
-	var newSteps []domain.DynamicPipelineStep
+// startPipelineStream starts a streamed pipeline. One started with an
+// execution ID runs under it, outside the request, and is registered for the
+// client to reconnect to; any other runs under an ID of its own and stops with
+// the request.
+func (s *Server) startPipelineStream(ctx context.Context, executionID string, params pipelineParams) *pipelineStream {
+	if executionID != "" {
+		ctx = infra.SetExecutionID(s.backgroundContext(), executionID)
+	} else {
+		ctx = infra.SetExecutionID(ctx, domain.NewID().String())
+	}
+	ctx, cancel := context.WithCancel(params.withSession(ctx))
+	stream := newPipelineStream(cancel)
 
-	// Extract keywords from the completed step
-	keywordsPerCategory := completedStep.KeywordsPerCategory
-	if keywordsPerCategory == nil {
-		return newSteps
+	if executionID != "" {
+		stream.owner <- struct{}{}
+		s.addStream(executionID, stream)
 	}
 
-	// Generate new steps for each keyword category
-	for category, keywords := range keywordsPerCategory {
-		for _, keyword := range keywords {
-			// Skip if already searched or if keyword is too short
-			if len(keyword) < 3 {
-				continue
-			}
+	// Start pipeline execution in a goroutine, which shutdown waits for when
+	// it outlives the request. The interactor sends the summary or the error
+	// last and closes the steps channel.
+	run := func() {
+		defer cancel()
+		s.interactor.ExecuteDynamicPipelineStream(ctx, params.pipelineConfig(ctx), stream.steps)
+	}
+	if executionID != "" {
+		s.goBackground(run)
+	} else {
+		go run()
+	}
 
-			// Generate steps for each available domain
-			for _, domainType := range availableDomains {
-				// Skip if already searched this keyword for this domain
-				if searchedKeywordsPerDomain[domainType][keyword] {
-					continue
-				}
+	return stream
+}
 
-				// Skip if same domain as current step
-				if domainType == completedStep.DomainType {
-					continue
-				}
+// stepEventType returns the type of the event of a streamed step
+func stepEventType(step domain.DynamicPipelineStep) string {
+	switch strings.ToUpper(string(step.DomainType)) {
+	case "ERROR":
+		return eventError
+	case "SUMMARY":
+		return eventSummary
+	case "NOTICE":
+		return eventNotice
+	}
+	return eventStep
+}
 
-				// Mark as searched
-				searchedKeywordsPerDomain[domainType][keyword] = true
-
-				// Create new step
-				newStep := domain.DynamicPipelineStep{
-					DomainType:          domainType,
-					SearchParameter:     keyword,
-					Category:            category,
-					Keywords:            []string{keyword},
-					Success:             false,
-					Error:               nil,
-					Output:              nil,
-					KeywordsPerCategory: nil,
-					Depth:               completedStep.Depth + 1,
-				}
+// completedStep tells a step sent once it completed, or once it was skipped,
+// from the same step announced when it started, neither successful nor failed
+func completedStep(step domain.DynamicPipelineStep) bool {
+	return step.Success || step.Error != nil || step.SkipReason != ""
+}
 
-				newSteps = append(newSteps, newStep)
-			}
-		}
+// stepEventData is the data of the event of a streamed step
+func stepEventData(stepNumber int, step domain.DynamicPipelineStep) map[string]interface{} {
+	// Convert step to ConnectorPipeline format
+	pipelineStep := ConnectorPipeline{
+		Success:             step.Success,
+		Error:               step.Error,
+		Name:                string(step.DomainType),
+		SearchParameter:     step.SearchParameter,
+		Output:              step.Output,
+		KeywordsPerCategory: step.KeywordsPerCategory,
 	}
 
-	return newSteps
+	return map[string]interface{}{
+		"step_number": stepNumber,
+		"step_id":     step.ID,
+		"step":        pipelineStep,
+		"depth":       step.Depth,
+		"category":    string(step.Category),
+		"keywords":    step.Keywords,
+	}
 }
 
-// writeSSEEvent writes a Server-Sent Event
-func (s *Server) writeSSEEvent(w http.ResponseWriter, eventType string, data interface{}, flusher http.Flusher) {
+// Event types of the streamed pipelines, which clients subscribe to by name
+const (
+	eventStep     = "step"
+	eventError    = "error"
+	eventSummary  = "summary"
+	eventNotice   = "notice"
+	eventComplete = "complete"
+)
+
+// writeSSEEvent writes a Server-Sent Event with the given ID
+func (s *Server) writeSSEEvent(w http.ResponseWriter, id int, eventType string, data interface{}, flusher http.Flusher) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Printf("Error marshaling SSE data: %v", err)
+		slog.Error("failed to marshal SSE data", slog.String("event", eventType), slog.Any("error", err))
 		return
 	}
 
-	// Write SSE format: event: type\ndata: json\n\n
+	// Write SSE format: id: n\nevent: type\ndata: json\n\n, without the ID
+	// when it is zero so the client keeps the last one
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
 	fmt.Fprintf(w, "event: %s\n", eventType)
 	fmt.Fprintf(w, "data: %s\n\n", string(jsonData))
 
 	flusher.Flush()
 }
 
+// metricsHandler exposes the domain search metrics in the Prometheus text
+// exposition format
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := infra.DefaultMetrics.WritePrometheus(w); err != nil {
+		slog.Error("failed to write metrics", slog.Any("error", err))
+	}
+}
+
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	resp, err := json.Marshal(s.db.Health())
+	// With DB_DRIVER=memory there is no database to check
+	health := map[string]string{"status": "up", "message": "Records are kept in memory"}
+	if s.db != nil {
+		health = s.db.Health()
+	}
+
+	resp, err := json.Marshal(health)
 	if err != nil {
 		http.Error(w, "Failed to marshal health check response", http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if _, err := w.Write(resp); err != nil {
-		log.Printf("Failed to write response: %v", err)
+		slog.Error("failed to write response", slog.Any("error", err))
 	}
 }
 
@@ -599,9 +1109,9 @@ func (s *Server) googleDorkingHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get query parameters
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+	query, err := module.ValidateSeed(r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid query parameter 'q': %v", err), http.StatusBadRequest)
 		return
 	}
 
@@ -622,6 +1132,7 @@ func (s *Server) googleDorkingHandler(w http.ResponseWriter, r *http.Request) {
 
 	exactMatch := r.URL.Query().Get("exact_match") == "true"
 	caseSensitive := r.URL.Query().Get("case_sensitive") == "true"
+	explain := r.URL.Query().Get("explain") == "true"
 
 	// Parse include/exclude keywords
 	var includeKeywords, excludeKeywords []string
@@ -644,10 +1155,11 @@ func (s *Server) googleDorkingHandler(w http.ResponseWriter, r *http.Request) {
 		CaseSensitive:   caseSensitive,
 		IncludeKeywords: includeKeywords,
 		ExcludeKeywords: excludeKeywords,
+		Explain:         explain,
 	}
 
 	// Perform search
-	results, err := googleDorking.SearchWithParams(params)
+	results, err := googleDorking.SearchWithParams(r.Context(), params)
 	if err != nil {
 		http.Error(w, "Search failed: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -669,6 +1181,7 @@ func (s *Server) googleDorkingHandler(w http.ResponseWriter, r *http.Request) {
 			"case_sensitive":   caseSensitive,
 			"include_keywords": includeKeywords,
 			"exclude_keywords": excludeKeywords,
+			"explain":          explain,
 		},
 	}
 
@@ -683,9 +1196,9 @@ func (s *Server) googleDorkingSuggestionsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		http.Error(w, "Query parameter 'q' is required", http.StatusBadRequest)
+	query, err := module.ValidateSeed(r.URL.Query().Get("q"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid query parameter 'q': %v", err), http.StatusBadRequest)
 		return
 	}
 
@@ -0,0 +1,719 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/interactor"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScreenHandler(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	s.searchDomain = func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if params.Query == "Broken" {
+			return nil, errors.New("source unavailable")
+		}
+
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		switch {
+		case domainType == domain.DomainTypeDGII && params.Query == "Novasco SRL":
+			result.Output = []domain.Register{{RNC: "130000001", RazonSocial: "NOVASCO SRL"}}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO  SRL"},
+			}
+		case domainType == domain.DomainTypeONAPI:
+			// ONAPI returns similar marks that do not match the query
+			result.Output = []domain.Entity{{Texto: "NOVA"}}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVA"},
+			}
+		}
+		return result, nil
+	}
+
+	body := `{"queries": ["Novasco SRL", "Acme", "Broken", "Globex", " "], "domains": ["onapi", "dgii"], "concurrency": 3}`
+	req := httptest.NewRequest(http.MethodPost, "/api/screen", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		Data  []domain.ScreeningResult `json:"data"`
+		Count int                      `json:"count"`
+		Hits  int                      `json:"hits"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Count != 4 || response.Hits != 1 {
+		t.Fatalf("expected 4 queries with 1 hit, got count=%d hits=%d", response.Count, response.Hits)
+	}
+
+	novasco := response.Data[0]
+	if !novasco.Hit || novasco.Domains[0].Hit || !novasco.Domains[1].Hit || novasco.Domains[1].Records != 1 {
+		t.Errorf("expected a DGII hit only for Novasco SRL, got %+v", novasco)
+	}
+	if acme := response.Data[1]; acme.Hit || acme.Domains[0].Records != 1 {
+		t.Errorf("expected ONAPI records without a hit for Acme, got %+v", acme)
+	}
+	if broken := response.Data[2]; broken.Hit || broken.Domains[0].Error == "" || broken.Domains[1].Error == "" {
+		t.Errorf("expected errors reported per domain, got %+v", broken)
+	}
+
+	if peak < 2 || peak > 3 {
+		t.Errorf("expected between 2 and 3 concurrent searches, got %d", peak)
+	}
+}
+
+func TestScreenHandlerValidatesRequest(t *testing.T) {
+	s, _ := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	for name, body := range map[string]string{
+		"invalid json":   `{`,
+		"no queries":     `{"queries": []}`,
+		"unknown domain": `{"queries": ["Novasco"], "domains": ["nowhere"]}`,
+		"too many":       `{"queries": [` + strings.Repeat(`"Novasco",`, maxScreeningQueries) + `"Novasco"]}`,
+		"blank queries":  `{"queries": ["  ", "\t"]}`,
+		"oversized":      `{"queries": ["Novasco", "` + strings.Repeat("a", domain.DefaultMaxSeedLength+1) + `"]}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/screen", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestSearchBatchHandler(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	var mu sync.Mutex
+	searched := map[string][]domain.DomainType{}
+	s.searchDomain = func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		searched[params.Query] = append(searched[params.Query], domainType)
+		mu.Unlock()
+
+		if domainType == domain.DomainTypeDGII && params.Query == "Acme" {
+			return nil, errors.New("dgii unavailable")
+		}
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query, Output: []domain.Entity{}}, nil
+	}
+
+	body := `{"queries": ["Novasco", "Acme"], "domains": ["onapi", "dgii"]}`
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/search/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		Data map[string][]struct {
+			Name    string `json:"name"`
+			Success bool   `json:"success"`
+		} `json:"data"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Count != 2 || len(response.Data) != 2 {
+		t.Fatalf("expected both queries in the response, got %+v", response.Data)
+	}
+	for _, query := range []string{"Novasco", "Acme"} {
+		if len(searched[query]) != 2 {
+			t.Errorf("expected %q to be searched in both domains, got %v", query, searched[query])
+		}
+		pipeline := response.Data[query]
+		if len(pipeline) != 2 || pipeline[0].Name != string(domain.DomainTypeONAPI) || pipeline[1].Name != string(domain.DomainTypeDGII) {
+			t.Errorf("expected the ONAPI and DGII results of %q, got %+v", query, pipeline)
+		}
+	}
+	if acme := response.Data["Acme"]; len(acme) == 2 && acme[1].Success {
+		t.Error("expected the failed DGII search of Acme to be reported")
+	}
+}
+
+func TestSearchBatchHandlerValidatesRequest(t *testing.T) {
+	s, _ := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	for name, body := range map[string]string{
+		"invalid json":   `{`,
+		"no queries":     `{"queries": []}`,
+		"unknown domain": `{"queries": ["Novasco"], "domains": ["nowhere"]}`,
+		"too many":       `{"queries": [` + strings.Repeat(`"Novasco",`, maxBatchQueries) + `"Novasco"]}`,
+		"blank query":    `{"queries": ["Novasco", " "]}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/search/batch", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestPipelineEntitiesHandlerValidatesRequest(t *testing.T) {
+	s, _ := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	for name, target := range map[string]string{
+		"no id":          "/api/pipeline/entities",
+		"unknown format": "/api/pipeline/entities?id=" + domain.NewID().String() + "&format=xml",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestSaveEndpointsRequireSignature(t *testing.T) {
+	s, _ := newMockServer(t)
+	s.signingSecret = []byte("shared-secret")
+	handler := s.RegisterRoutes()
+
+	body := `{"name": "Novasco", "category": "company_name"}`
+	post := func(signature string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/pipeline/findings", strings.NewReader(body))
+		if signature != "" {
+			req.Header.Set(custom.SignatureHeader, signature)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The signed body reaches the handler, which rejects the missing pipeline
+	if rec := post(custom.Sign(s.signingSecret, time.Now(), []byte(body)), body); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "pipeline_id") {
+		t.Errorf("expected a signed request to reach the handler, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"unsigned": post("", body),
+		"tampered": post(custom.Sign(s.signingSecret, time.Now(), []byte(body)), strings.Replace(body, "Novasco", "Acme", 1)),
+		"replayed": post(custom.Sign(s.signingSecret, time.Now().Add(-time.Hour), []byte(body)), body),
+	} {
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rec.Code)
+		}
+	}
+
+	// Without a secret the endpoints take unsigned requests
+	s.signingSecret = nil
+	if rec := post("", body); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected unsigned requests to pass without a secret, got %d", rec.Code)
+	}
+}
+
+func TestPreflightAllowsSignatureHeader(t *testing.T) {
+	s, _ := newMockServer(t)
+	s.signingSecret = []byte("shared-secret")
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/pipeline/findings", nil)
+	req.Header.Set("Access-Control-Request-Headers", custom.SignatureHeader)
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if allowed := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(allowed, custom.SignatureHeader) {
+		t.Errorf("expected browsers to be allowed to send %s, got %q", custom.SignatureHeader, allowed)
+	}
+}
+
+func TestHandlersValidateSeedQuery(t *testing.T) {
+	s, _ := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	seeds := map[string]string{
+		"missing":    "",
+		"empty":      "q=",
+		"whitespace": "q=%20%20%09",
+		"oversized":  "q=" + strings.Repeat("a", domain.DefaultMaxSeedLength+1),
+	}
+	for _, path := range []string{"/search", "/dynamic"} {
+		for name, query := range seeds {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?"+query, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s with a %s seed: expected 400, got %d", path, name, rec.Code)
+			}
+		}
+	}
+
+	t.Setenv("SEED_MAX_LENGTH", "5")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=Novasco", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too long") {
+		t.Errorf("expected SEED_MAX_LENGTH to reject a longer seed, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPipelineDepth(t *testing.T) {
+	s := &Server{}
+
+	if depth, err := s.pipelineDepth(""); err != nil || depth != DefaultPipelineDepth {
+		t.Errorf("expected the default depth %d, got %d (%v)", DefaultPipelineDepth, depth, err)
+	}
+	if depth, err := s.pipelineDepth(strconv.Itoa(DefaultPipelineMaxDepth)); err != nil || depth != DefaultPipelineMaxDepth {
+		t.Errorf("expected the cap itself to be accepted, got %d (%v)", depth, err)
+	}
+	for _, value := range []string{strconv.Itoa(DefaultPipelineMaxDepth + 1), "53", "0", "-1", "deep"} {
+		if _, err := s.pipelineDepth(value); err == nil {
+			t.Errorf("expected depth %q to be rejected", value)
+		}
+	}
+
+	// A lower cap rejects deeper runs and lowers the default
+	s.pipelineMaxDepth = 2
+	if depth, _ := s.pipelineDepth(""); depth != 2 {
+		t.Errorf("expected the default to be capped at 2, got %d", depth)
+	}
+	if _, err := s.pipelineDepth("3"); err == nil {
+		t.Error("expected a depth above the configured cap to be rejected")
+	}
+}
+
+func TestPipelineDelay(t *testing.T) {
+	if delay, err := pipelineDelay(""); err != nil || delay != domain.DefaultDelayBetweenSteps {
+		t.Errorf("expected the default delay %s, got %s (%v)", domain.DefaultDelayBetweenSteps.Duration(), delay.Duration(), err)
+	}
+	if delay, err := pipelineDelay("250"); err != nil || delay.Duration() != 250*time.Millisecond {
+		t.Errorf("expected a 250ms delay, got %s (%v)", delay.Duration(), err)
+	}
+	if delay, err := pipelineDelay("0"); err != nil || delay != 0 {
+		t.Errorf("expected no delay, got %s (%v)", delay.Duration(), err)
+	}
+	for _, value := range []string{"-1", "1.5", "2s"} {
+		if _, err := pipelineDelay(value); err == nil {
+			t.Errorf("expected delay %q to be rejected", value)
+		}
+	}
+
+	if jitter, err := pipelineJitter(""); err != nil || jitter != 0 {
+		t.Errorf("expected no jitter by default, got %v (%v)", jitter, err)
+	}
+	if jitter, err := pipelineJitter("0.25"); err != nil || jitter != 0.25 {
+		t.Errorf("expected a 0.25 jitter, got %v (%v)", jitter, err)
+	}
+	for _, value := range []string{"-0.5", "NaN", "Inf", "some"} {
+		if _, err := pipelineJitter(value); err == nil {
+			t.Errorf("expected jitter %q to be rejected", value)
+		}
+	}
+}
+
+func TestDynamicPipelineStreamSleepsOncePerStep(t *testing.T) {
+	s, clock := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+
+	// An hour between steps passes in virtual time only
+	began := time.Now()
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&stream=true&delay_ms=3600000", nil))
+	if elapsed := time.Since(began); elapsed > 5*time.Second {
+		t.Errorf("expected the run not to wait its delays, took %s", elapsed)
+	}
+
+	steps := 0
+	for _, event := range sseEvents(rec.Body.String()) {
+		if event == eventStep {
+			steps++
+		}
+	}
+
+	// Each step is sent when it starts and when it completes
+	sleeps := clock.Sleeps()
+	if steps == 0 || len(sleeps) != steps/2 {
+		t.Fatalf("expected a pause after each step, got %d pauses for %d step events", len(sleeps), steps)
+	}
+	for _, d := range sleeps {
+		if d != time.Hour {
+			t.Errorf("expected one-hour pauses, got %s", d)
+		}
+	}
+}
+
+func TestDynamicPipelineStreamSendsKeepalives(t *testing.T) {
+	// Only the first search is slow enough for keepalives to be sent
+	var searches sync.Once
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches.Do(func() { time.Sleep(100 * time.Millisecond) })
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+	s.keepaliveInterval = 10 * time.Millisecond
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&stream=true", nil))
+
+	body := rec.Body.String()
+	if !strings.Contains(body, ": keepalive\n\n") {
+		t.Fatalf("expected keepalive comments while the step was slow, got %q", body)
+	}
+	if !strings.Contains(body, "event: complete\n") {
+		t.Errorf("expected the stream to complete after the keepalives, got %q", body)
+	}
+}
+
+// sseEvents returns the types of the events of an SSE stream, in order
+func sseEvents(body string) []string {
+	var events []string
+	for _, line := range strings.Split(body, "\n") {
+		if eventType, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, eventType)
+		}
+	}
+	return events
+}
+
+func TestDynamicPipelineStreamEventTypes(t *testing.T) {
+	search := func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	}
+
+	// The steps, announced when they start and sent when they complete, are
+	// followed by the summary and the completion
+	s, _ := newPipelineServer(search)
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&stream=true&delay_ms=0", nil))
+
+	events := sseEvents(rec.Body.String())
+	if len(events) < 4 || events[len(events)-2] != eventSummary || events[len(events)-1] != eventComplete {
+		t.Fatalf("expected the steps then %s and %s, got %v", eventSummary, eventComplete, events)
+	}
+	for _, event := range events[:len(events)-2] {
+		if event != eventStep {
+			t.Errorf("expected only %s events before %s, got %v", eventStep, eventSummary, events)
+			break
+		}
+	}
+
+	// A pipeline that cannot be stored stops before its first step with the
+	// error and the completion
+	failing, _ := newMockServer(t)
+	failing.interactor = interactor.NewDynamicPipelineInteractorWithSearcher(failing.repositories, interactor.SearcherFunc(search))
+	rec = httptest.NewRecorder()
+	failing.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&stream=true&delay_ms=0", nil))
+
+	if events := sseEvents(rec.Body.String()); len(events) != 2 || events[0] != eventError || events[1] != eventComplete {
+		t.Errorf("expected %s then %s, got %v", eventError, eventComplete, events)
+	}
+}
+
+func TestDynamicPipelineHandlerRejectsDepthAboveCap(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=Novasco&depth=53", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "'depth'") {
+		t.Errorf("expected 400 for a depth above the cap, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDynamicPipelineHandlerConfiguresBackgroundRuns(t *testing.T) {
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&delay_ms=0&traversal=dfs&adaptive_depth=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		ExecutionID string `json:"execution_id"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	s.waitBackground(context.Background())
+
+	pipeline, err := s.GetRepositories().GetPipelineRepository().GetPipelineByID(context.Background(), response.ExecutionID)
+	if err != nil {
+		t.Fatalf("expected the background run to be stored: %v", err)
+	}
+	if pipeline.Config.TraversalMode != domain.TraversalDFS {
+		t.Errorf("expected the background run to traverse depth-first, got %+v", pipeline.Config)
+	}
+	if !pipeline.Config.AdaptiveDepth {
+		t.Errorf("expected the background run to adapt its depth, got %+v", pipeline.Config)
+	}
+}
+
+func TestDynamicPipelineHandlerStopsOnFirstHit(t *testing.T) {
+	var searches atomic.Int32
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches.Add(1)
+		if domainType != domain.DomainTypeDGII {
+			return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+		}
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output:          []domain.Register{},
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO"},
+			},
+		}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=3&delay_ms=0&stop_on_first_hit=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		ExecutionID string `json:"execution_id"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	s.waitBackground(context.Background())
+
+	// Only the authoritative domains are searched, and the hit is the only step
+	if got, want := int(searches.Load()), len(domain.AuthoritativeDomainTypes()); got > want {
+		t.Errorf("expected at most %d searches, got %d", want, got)
+	}
+	steps, err := s.GetRepositories().GetPipelineRepository().GetPipelineStepsByID(context.Background(), response.ExecutionID)
+	if err != nil {
+		t.Fatalf("failed to read the stored steps: %v", err)
+	}
+	if len(steps) != 1 || steps[0].DomainType != domain.DomainTypeDGII || !steps[0].Success {
+		t.Fatalf("expected the DGII hit as the only step, got %+v", steps)
+	}
+	pipeline, err := s.GetRepositories().GetPipelineRepository().GetPipelineByID(context.Background(), response.ExecutionID)
+	if err != nil {
+		t.Fatalf("expected the background run to be stored: %v", err)
+	}
+	if !pipeline.Config.StopOnFirstHit || pipeline.TotalSteps != 1 || pipeline.SuccessfulSteps != 1 {
+		t.Errorf("expected a stored existence check with one successful step, got %+v", pipeline)
+	}
+}
+
+func TestDynamicPlanHandler(t *testing.T) {
+	s, mock := newMockServer(t)
+	s.searchDomain = func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		t.Errorf("unexpected %s search for %q while planning", domainType, params.Query)
+		return &domain.DomainSearchResult{DomainType: domainType}, nil
+	}
+	handler := s.RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dynamic/plan", strings.NewReader(`{"query": "Novasco", "depth": 2, "domains": ["onapi", "dgii"]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		Success bool                         `json:"success"`
+		Count   int                          `json:"count"`
+		Data    domain.DynamicPipelineResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.Success || response.Count != 2 || len(response.Data.Steps) != 2 {
+		t.Fatalf("expected the 2 initial steps, got %+v", response)
+	}
+	for _, step := range response.Data.Steps {
+		if step.Success || step.SearchParameter != "Novasco" {
+			t.Errorf("expected an unexecuted step for the query, got %+v", step)
+		}
+	}
+	if !response.Data.Config.DryRun || response.Data.Config.MaxDepth != 2 {
+		t.Errorf("expected a dry run config of depth 2, got %+v", response.Data.Config)
+	}
+
+	for name, body := range map[string]string{
+		"invalid json":   `{`,
+		"blank query":    `{"query": " "}`,
+		"too deep":       `{"query": "Novasco", "depth": 53}`,
+		"unknown domain": `{"query": "Novasco", "domains": ["nowhere"]}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dynamic/plan", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+
+	// Planning never reaches the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSearchParamsHandler(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	var received domain.GoogleDorkingSearchParams
+	var receivedType domain.DomainType
+	s.searchDorking = func(ctx context.Context, domainType domain.DomainType, params domain.GoogleDorkingSearchParams) (*domain.DomainSearchResult, error) {
+		receivedType, received = domainType, params
+		return &domain.DomainSearchResult{
+			Success:         true,
+			DomainType:      domainType,
+			SearchParameter: params.Query,
+			Output:          []domain.GoogleDorkingResult{{Title: "Novasco estafa", URL: "https://example.com/novasco"}},
+			KeywordsPerCategory: map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO SRL"},
+			},
+		}, nil
+	}
+
+	body := `{
+		"query": " Novasco ",
+		"domain": "file_type",
+		"max_results": 5,
+		"min_relevance": 0.4,
+		"include_keywords": ["estafa"],
+		"file_type_keywords": ["pdf"],
+		"sites_keywords": ["gob.do"],
+		"in_url_keywords": ["noticias"],
+		"exclude_keywords": ["empleo"]
+	}`
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if receivedType != domain.DomainTypeFileType {
+		t.Errorf("expected a file type search, got %q", receivedType)
+	}
+	if received.Query != "Novasco" || received.MaxResults != 5 || received.MinRelevance != 0.4 ||
+		len(received.FileTypeKeywords) != 1 || len(received.SitesKeywords) != 1 || len(received.InURLKeywords) != 1 || len(received.ExcludeKeywords) != 1 {
+		t.Errorf("expected the body parameters to reach the search, got %+v", received)
+	}
+
+	var pipeline ConnectorPipeline
+	if err := json.NewDecoder(rec.Body).Decode(&pipeline); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !pipeline.Success || pipeline.Name != string(domain.DomainTypeFileType) || pipeline.SearchParameter != "Novasco" {
+		t.Errorf("unexpected pipeline %+v", pipeline)
+	}
+}
+
+func TestSearchParamsHandlerValidatesBody(t *testing.T) {
+	s, _ := newMockServer(t)
+	s.searchDorking = func(ctx context.Context, domainType domain.DomainType, params domain.GoogleDorkingSearchParams) (*domain.DomainSearchResult, error) {
+		t.Errorf("no search expected for an invalid body, got %+v", params)
+		return nil, nil
+	}
+	handler := s.RegisterRoutes()
+
+	for name, test := range map[string]struct {
+		body    string
+		message string
+	}{
+		"missing query":     {`{"include_keywords": ["estafa"]}`, "'query'"},
+		"blank query":       {`{"query": "  "}`, "'query'"},
+		"malformed":         {`{"query": "Novasco"`, "Invalid JSON"},
+		"unknown field":     {`{"query": "Novasco", "sites": ["x.com"]}`, "Invalid JSON"},
+		"unknown domain":    {`{"query": "Novasco", "domain": "nowhere"}`, "'domain'"},
+		"negative results":  {`{"query": "Novasco", "max_results": -1}`, "'max_results'"},
+		"relevance range":   {`{"query": "Novasco", "min_relevance": 1.5}`, "'min_relevance'"},
+		"docking parameter": {`{"query": "Novasco", "domain": "onapi", "sites_keywords": ["x.com"]}`, "only 'query'"},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(test.body)))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), test.message) {
+			t.Errorf("%s: expected 400 mentioning %s, got %d: %s", name, test.message, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestExecutionStatusHandler(t *testing.T) {
+	s, _ := newMockServer(t)
+	handler := s.RegisterRoutes()
+
+	executionID := domain.NewID().String()
+	interactor.DefaultExecutionTracker.Start(executionID)
+	interactor.DefaultExecutionTracker.RecordStep(executionID, domain.DynamicPipelineStep{Success: true})
+	interactor.DefaultExecutionTracker.Finish(executionID, errors.New("database unavailable"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic/status?execution_id="+executionID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var response struct {
+		Success bool                  `json:"success"`
+		Data    domain.ExecutionState `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.ExecutionID != executionID || response.Data.Status != domain.ExecutionStatusFailed {
+		t.Errorf("unexpected execution state: %+v", response.Data)
+	}
+	if response.Data.SuccessfulSteps != 1 || response.Data.LastError != "database unavailable" {
+		t.Errorf("unexpected execution progress: %+v", response.Data)
+	}
+
+	for query, code := range map[string]int{
+		"": http.StatusBadRequest,
+		"?execution_id=" + domain.NewID().String(): http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic/status"+query, nil))
+		if rec.Code != code {
+			t.Errorf("%q: expected %d, got %d", query, code, rec.Code)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("expected the Prometheus text format, got %q", contentType)
+	}
+	if !strings.Contains(rec.Body.String(), "# TYPE insightful_search_attempts_total counter") {
+		t.Errorf("expected the search counters, got:\n%s", rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "# TYPE insightful_search_cache_hits_total counter") {
+		t.Errorf("expected the search cache counters, got:\n%s", rec.Body.String())
+	}
+}
@@ -1,17 +1,25 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"insightful-intel/internal/custom"
 	"insightful-intel/internal/database"
+	"insightful-intel/internal/domain"
 	"insightful-intel/internal/interactor"
+	"insightful-intel/internal/module"
 	"insightful-intel/internal/repositories"
 )
 
@@ -21,9 +29,86 @@ type Server struct {
 	db           database.Service
 	repositories *repositories.RepositoryFactory
 	interactor   *interactor.DynamicPipelineInteractor
+
+	// searchDomain runs the domain searches of the screening endpoint and of
+	// the /search endpoints, module.SearchDomain when nil
+	searchDomain module.SearchFunc
+
+	// keepaliveInterval is how often a streamed pipeline waiting for its next
+	// step sends a keepalive comment, DefaultKeepaliveInterval when zero
+	keepaliveInterval time.Duration
+
+	// streams are the streamed pipelines started with an execution ID, by
+	// that ID, which a client reconnecting within streamResumeWindow
+	// (DefaultStreamResumeWindow when zero) picks up again
+	streamsMu          sync.Mutex
+	streams            map[string]*pipelineStream
+	streamResumeWindow time.Duration
+
+	// searchDorking runs the Google Docking searches of POST /search,
+	// module.SearchDorkingWithParams when nil
+	searchDorking func(ctx context.Context, domainType domain.DomainType, params domain.GoogleDorkingSearchParams) (*domain.DomainSearchResult, error)
+
+	// staleAfter is the age past which stored records are flagged stale,
+	// domain.DefaultStaleAfter when zero
+	staleAfter time.Duration
+
+	// refreshRecords re-scrapes stored search results, the interactor's
+	// RefreshSearchResults when nil
+	refreshRecords func(ctx context.Context, ids []domain.ID) (int, error)
+
+	// hydrateConcurrency bounds the details fetched at once when a listing is
+	// hydrated, DefaultHydrateConcurrency when zero
+	hydrateConcurrency int
+
+	// onapiDetails fetches the details of a stored ONAPI entity, the ONAPI
+	// connector's GetDetails when nil
+	onapiDetails func(ctx context.Context, entity domain.Entity) (*domain.Entity, error)
+
+	// compactionInterval is how often stored outputs are compacted, never when
+	// zero, and compactionMinBytes the size from which they are compacted
+	compactionInterval time.Duration
+	compactionMinBytes int
+
+	// listMaxLimit caps the page size of the listings, DefaultListMaxLimit
+	// when zero
+	listMaxLimit int
+
+	// pipelineListMaxLimit caps the page size of the pipeline listing,
+	// DefaultPipelineListMaxLimit when zero
+	pipelineListMaxLimit int
+
+	// pipelineMaxDepth caps the depth of the pipelines started from /dynamic,
+	// DefaultPipelineMaxDepth when zero
+	pipelineMaxDepth int
+
+	// signingSecret is the shared secret the bodies posted to the save
+	// endpoints must be signed with, unchecked when empty. signatureTolerance
+	// is how old a signature may be, custom.DefaultSignatureTolerance when zero.
+	signingSecret      []byte
+	signatureTolerance time.Duration
+
+	// apiKeys are the keys the requests must carry as bearer tokens,
+	// unchecked when empty, except on publicPaths
+	apiKeys     []string
+	publicPaths []string
+
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+
+	// background tracks the pipelines run in the background, which
+	// backgroundCtx cancels when shutdown stops waiting for them
+	background       sync.WaitGroup
+	backgroundCtx    context.Context
+	cancelBackground context.CancelFunc
 }
 
-func NewServer(repoFactory *repositories.RepositoryFactory, interactor *interactor.DynamicPipelineInteractor) *http.Server {
+// DefaultShutdownTimeout is how long Run waits for in-flight requests and
+// background pipelines before forcing them to stop
+const DefaultShutdownTimeout = 5 * time.Second
+
+// NewServer creates the API server on the port set by PORT, 8080 by default
+func NewServer(db database.Service, repoFactory *repositories.RepositoryFactory, interactor *interactor.DynamicPipelineInteractor) *Server {
 	// Get port from environment variable, default to 8080 if not set or invalid
 	portStr := os.Getenv("PORT")
 	port := 8080 // Default port
@@ -31,19 +116,33 @@ func NewServer(repoFactory *repositories.RepositoryFactory, interactor *interact
 		if parsedPort, err := strconv.Atoi(portStr); err == nil && parsedPort > 0 && parsedPort < 65536 {
 			port = parsedPort
 		} else {
-			log.Printf("Warning: Invalid PORT environment variable '%s', using default port 8080", portStr)
+			slog.Warn("invalid PORT environment variable, using default port 8080", slog.String("port", portStr))
 		}
 	} else {
-		log.Printf("PORT environment variable not set, using default port 8080")
+		slog.Info("PORT environment variable not set, using default port 8080")
 	}
 
 	// Declare Server config
 	srv := &Server{
-		Port:         port,
-		repositories: repoFactory,
-		interactor:   interactor,
+		Port:                 port,
+		db:                   db,
+		repositories:         repoFactory,
+		interactor:           interactor,
+		staleAfter:           staleAfterFromEnv(),
+		hydrateConcurrency:   hydrateConcurrencyFromEnv(),
+		compactionInterval:   compactionIntervalFromEnv(),
+		compactionMinBytes:   compactionMinBytesFromEnv(),
+		listMaxLimit:         listMaxLimitFromEnv(),
+		pipelineListMaxLimit: pipelineListMaxLimitFromEnv(),
+		pipelineMaxDepth:     pipelineMaxDepthFromEnv(),
+		signingSecret:        []byte(os.Getenv("REQUEST_SIGNING_SECRET")),
+		signatureTolerance:   signatureToleranceFromEnv(),
+		apiKeys:              apiKeysFromEnv(),
+		publicPaths:          DefaultPublicPaths,
+		shutdownTimeout:      DefaultShutdownTimeout,
 	}
-	server := &http.Server{
+	srv.backgroundCtx, srv.cancelBackground = context.WithCancel(context.Background())
+	srv.httpServer = &http.Server{
 		Addr:        fmt.Sprintf(":%d", srv.Port),
 		Handler:     srv.RegisterRoutes(),
 		IdleTimeout: time.Minute,
@@ -52,9 +151,233 @@ func NewServer(repoFactory *repositories.RepositoryFactory, interactor *interact
 		WriteTimeout: 5 * time.Minute,
 	}
 
-	log.Printf("Server configured to listen on port %d", port)
+	slog.Info("server configured", slog.Int("port", port))
 
-	return server
+	return srv
+}
+
+// Run serves the API until ctx is done, then shuts down gracefully: in-flight
+// requests and background pipelines get the shutdown timeout to finish before
+// they are stopped, and the database is closed last
+func (s *Server) Run(ctx context.Context) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+	s.startCompaction(ctx)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("http server error: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down gracefully")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		// Streams still open past the timeout are closed with their connections
+		slog.Error("server forced to shutdown", slog.Any("error", err))
+		s.httpServer.Close()
+	}
+
+	s.waitBackground(shutdownCtx)
+
+	if s.db != nil {
+		if closeErr := s.db.Close(); closeErr != nil {
+			slog.Error("failed to close database", slog.Any("error", closeErr))
+		}
+	}
+
+	slog.Info("server exiting")
+	return err
+}
+
+// backgroundContext returns the context of the work outliving its request,
+// cancelled when shutdown stops waiting for it
+func (s *Server) backgroundContext() context.Context {
+	if s.backgroundCtx == nil {
+		return context.Background()
+	}
+	return s.backgroundCtx
+}
+
+// goBackground runs fn in a goroutine tracked by the server, so shutdown waits
+// for it
+func (s *Server) goBackground(fn func()) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		fn()
+	}()
+}
+
+// waitBackground waits for the background pipelines until ctx is done, then
+// cancels the ones still running and waits for them to stop
+func (s *Server) waitBackground(ctx context.Context) {
+	finished := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return
+	case <-ctx.Done():
+	}
+
+	slog.Warn("cancelling background pipelines still running at shutdown")
+	if s.cancelBackground != nil {
+		s.cancelBackground()
+	}
+	<-finished
+}
+
+// staleAfterFromEnv reads STALE_AFTER, a duration such as 720h, falling back to
+// domain.DefaultStaleAfter when it is not set or not valid
+func staleAfterFromEnv() time.Duration {
+	value := os.Getenv("STALE_AFTER")
+	if value == "" {
+		return domain.DefaultStaleAfter
+	}
+
+	staleAfter, err := time.ParseDuration(value)
+	if err != nil || staleAfter <= 0 {
+		slog.Warn("invalid STALE_AFTER environment variable, using default", slog.String("stale_after", value))
+		return domain.DefaultStaleAfter
+	}
+	return staleAfter
+}
+
+// DefaultListMaxLimit is the largest page of the listings when LIST_MAX_LIMIT
+// is not set
+const DefaultListMaxLimit = 100
+
+// listMaxLimitFromEnv reads LIST_MAX_LIMIT, falling back to
+// DefaultListMaxLimit when it is not set or not valid
+func listMaxLimitFromEnv() int {
+	value := os.Getenv("LIST_MAX_LIMIT")
+	if value == "" {
+		return DefaultListMaxLimit
+	}
+
+	maxLimit, err := strconv.Atoi(value)
+	if err != nil || maxLimit <= 0 {
+		slog.Warn("invalid LIST_MAX_LIMIT environment variable, using default", slog.String("list_max_limit", value))
+		return DefaultListMaxLimit
+	}
+	return maxLimit
+}
+
+// DefaultPipelineListMaxLimit is the largest page of the pipeline listing when
+// PIPELINE_LIST_MAX_LIMIT is not set
+const DefaultPipelineListMaxLimit = 100
+
+// pipelineListMaxLimitFromEnv reads PIPELINE_LIST_MAX_LIMIT, falling back to
+// DefaultPipelineListMaxLimit when it is not set or not valid
+func pipelineListMaxLimitFromEnv() int {
+	value := os.Getenv("PIPELINE_LIST_MAX_LIMIT")
+	if value == "" {
+		return DefaultPipelineListMaxLimit
+	}
+
+	maxLimit, err := strconv.Atoi(value)
+	if err != nil || maxLimit <= 0 {
+		slog.Warn("invalid PIPELINE_LIST_MAX_LIMIT environment variable, using default", slog.String("pipeline_list_max_limit", value))
+		return DefaultPipelineListMaxLimit
+	}
+	return maxLimit
+}
+
+// DefaultPipelineDepth is the depth of the pipelines started from /dynamic
+// without a depth parameter
+const DefaultPipelineDepth = 3
+
+// DefaultKeepaliveInterval is how often a streamed pipeline sends a keepalive
+// comment while it waits for its next step, so proxies do not drop the idle
+// connection during a slow search
+const DefaultKeepaliveInterval = 15 * time.Second
+
+// DefaultPipelineMaxDepth is the deepest pipeline /dynamic starts when
+// PIPELINE_MAX_DEPTH is not set
+const DefaultPipelineMaxDepth = 10
+
+// pipelineMaxDepthFromEnv reads PIPELINE_MAX_DEPTH, falling back to
+// DefaultPipelineMaxDepth when it is not set or not valid
+func pipelineMaxDepthFromEnv() int {
+	value := os.Getenv("PIPELINE_MAX_DEPTH")
+	if value == "" {
+		return DefaultPipelineMaxDepth
+	}
+
+	maxDepth, err := strconv.Atoi(value)
+	if err != nil || maxDepth <= 0 {
+		slog.Warn("invalid PIPELINE_MAX_DEPTH environment variable, using default", slog.String("pipeline_max_depth", value))
+		return DefaultPipelineMaxDepth
+	}
+	return maxDepth
+}
+
+// signatureToleranceFromEnv reads REQUEST_SIGNATURE_TOLERANCE, falling back to
+// custom.DefaultSignatureTolerance when it is not set or not valid
+func signatureToleranceFromEnv() time.Duration {
+	value := os.Getenv("REQUEST_SIGNATURE_TOLERANCE")
+	if value == "" {
+		return custom.DefaultSignatureTolerance
+	}
+
+	tolerance, err := time.ParseDuration(value)
+	if err != nil || tolerance <= 0 {
+		slog.Warn("invalid REQUEST_SIGNATURE_TOLERANCE environment variable, using default", slog.String("request_signature_tolerance", value))
+		return custom.DefaultSignatureTolerance
+	}
+	return tolerance
+}
+
+// maxSignedBodyBytes bounds the body read to verify its signature
+const maxSignedBodyBytes = 32 << 20
+
+// requireSignature rejects the requests whose body is not signed with the
+// signing secret, or whose signature is stale, before calling next. Requests
+// pass unchecked when no secret is configured.
+func (s *Server) requireSignature(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.signingSecret) == 0 {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		err = custom.VerifySignature(s.signingSecret, r.Header.Get(custom.SignatureHeader), body, time.Now(), s.signatureTolerance)
+		if err != nil {
+			slog.Warn("rejected unsigned request", slog.String("path", r.URL.Path), slog.Any("error", err))
+			http.Error(w, fmt.Sprintf("Invalid request signature: %v", err), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
+
+// executions returns the tracker the interactor records executions in
+func (s *Server) executions() *interactor.ExecutionTracker {
+	if s.interactor == nil {
+		return interactor.DefaultExecutionTracker
+	}
+	return s.interactor.Executions()
 }
 
 // GetRepositories returns the repository factory
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"insightful-intel/internal/repositories"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRunShutsDownOnSignal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	mock.ExpectClose()
+
+	database := &mockDatabase{db: db}
+	s := NewServer(database, repositories.NewRepositoryFactory(database), nil)
+	s.httpServer.Addr = "127.0.0.1:0"
+	s.shutdownTimeout = 200 * time.Millisecond
+
+	// A pipeline that only stops once shutdown cancels it
+	pipelineStopped := make(chan struct{})
+	s.goBackground(func() {
+		<-s.backgroundContext().Done()
+		close(pipelineStopped)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to send SIGTERM: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Run to return within the shutdown timeout")
+	}
+
+	select {
+	case <-pipelineStopped:
+	default:
+		t.Error("expected the background pipeline to be stopped before Run returned")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expected the database to be closed: %v", err)
+	}
+}
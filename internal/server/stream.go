@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"sync"
+	"time"
+)
+
+// DefaultStreamResumeWindow is how long a streamed pipeline started with an
+// execution ID keeps running once its connection drops, waiting for the client
+// to reconnect with Last-Event-ID
+const DefaultStreamResumeWindow = time.Minute
+
+// pipelineStream is a streamed pipeline and the connection following it. The
+// pipeline sends its steps on steps, closed when it finishes; a connection
+// holds owner while it reads them, so a reconnecting client waits for the
+// dropped connection to let go.
+type pipelineStream struct {
+	steps  chan domain.DynamicPipelineStep
+	cancel context.CancelFunc
+	owner  chan struct{}
+
+	// expiry abandons the pipeline when no connection follows it within the
+	// resume window, and expired tells it was abandoned. Both are guarded by mu.
+	mu      sync.Mutex
+	expiry  *time.Timer
+	expired bool
+}
+
+func newPipelineStream(cancel context.CancelFunc) *pipelineStream {
+	return &pipelineStream{
+		steps:  make(chan domain.DynamicPipelineStep, 100),
+		cancel: cancel,
+		owner:  make(chan struct{}, 1),
+	}
+}
+
+// attach waits for the stream to be free and takes it over, returning false
+// when ctx is done first or the stream was abandoned
+func (p *pipelineStream) attach(ctx context.Context) bool {
+	select {
+	case p.owner <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.expired {
+		<-p.owner
+		return false
+	}
+	if p.expiry != nil {
+		p.expiry.Stop()
+		p.expiry = nil
+	}
+	return true
+}
+
+// detach lets go of the stream, abandoning it with expire unless another
+// connection takes it over within window
+func (p *pipelineStream) detach(window time.Duration, expire func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(window, func() {
+		p.mu.Lock()
+		if p.expiry != timer {
+			p.mu.Unlock()
+			return
+		}
+		p.expired = true
+		p.mu.Unlock()
+		expire()
+	})
+	p.expiry = timer
+	<-p.owner
+}
+
+// abandon stops the pipeline and drains the steps it still sends, so it does
+// not block on a stream no connection reads
+func (p *pipelineStream) abandon() {
+	p.cancel()
+	go func() {
+		for range p.steps {
+		}
+	}()
+}
+
+// liveStream returns the stream of the pipeline started with executionID,
+// nil when none is running
+func (s *Server) liveStream(executionID string) *pipelineStream {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	return s.streams[executionID]
+}
+
+// addStream registers the stream of the pipeline started with executionID
+func (s *Server) addStream(executionID string, stream *pipelineStream) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	if s.streams == nil {
+		s.streams = make(map[string]*pipelineStream)
+	}
+	s.streams[executionID] = stream
+}
+
+// removeStream unregisters the stream of the pipeline started with
+// executionID, unless it was replaced by another
+func (s *Server) removeStream(executionID string, stream *pipelineStream) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	if s.streams[executionID] == stream {
+		delete(s.streams, executionID)
+	}
+}
+
+// detachStream lets go of the stream of the pipeline started with
+// executionID, which is abandoned unless a client reconnects within the
+// resume window
+func (s *Server) detachStream(executionID string, stream *pipelineStream) {
+	window := s.streamResumeWindow
+	if window <= 0 {
+		window = DefaultStreamResumeWindow
+	}
+	stream.detach(window, func() {
+		s.removeStream(executionID, stream)
+		stream.abandon()
+	})
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"insightful-intel/internal/custom"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/infra"
+	"insightful-intel/internal/interactor"
+	"insightful-intel/internal/repositories"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPipelineServer creates a server storing its records in memory, whose
+// pipelines search with search and pause in the virtual time of the returned
+// clock
+func newPipelineServer(search interactor.SearcherFunc) (*Server, *infra.FakeClock) {
+	repos := repositories.NewMemoryRepositoryFactory()
+	clock := infra.NewFakeClock(time.Now())
+	return &Server{
+		repositories: repos,
+		interactor:   interactor.NewDynamicPipelineInteractorWithSearcher(repos, search).WithClock(clock, clock),
+	}, clock
+}
+
+// companySearch counts the searches in searches and answers the ONAPI ones
+// with a company name, so they lead to more steps
+func companySearch(searches *atomic.Int32) interactor.SearcherFunc {
+	return func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		searches.Add(1)
+		result := &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}
+		if domainType == domain.DomainTypeONAPI {
+			result.Output = []domain.Entity{}
+			result.KeywordsPerCategory = map[domain.KeywordCategory][]string{
+				domain.KeywordCategoryCompanyName: {"NOVASCO HOLDING SRL"},
+			}
+		}
+		return result, nil
+	}
+}
+
+// sseEvent is an event read from an SSE stream
+type sseEvent struct {
+	ID     int
+	Type   string
+	StepID string
+	Total  int
+}
+
+// readSSE opens the stream at url, resuming from lastEventID when it is set,
+// and reads its events until it has read ids events with an ID, or until it
+// completes when ids is zero. The connection is then dropped.
+func readSSE(t *testing.T, url string, lastEventID string, ids int) []sseEvent {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("failed to open the stream: %v", err)
+	}
+	defer response.Body.Close()
+
+	var events []sseEvent
+	var event sseEvent
+	seen := 0
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			event.ID, _ = strconv.Atoi(strings.TrimPrefix(line, "id: "))
+		case strings.HasPrefix(line, "event: "):
+			event.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			var data struct {
+				StepID string `json:"step_id"`
+				Step   struct {
+					Output struct {
+						TotalSteps int `json:"total_steps"`
+					} `json:"output"`
+				} `json:"step"`
+			}
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data)
+			event.StepID, event.Total = data.StepID, data.Step.Output.TotalSteps
+		case line == "" && event.Type != "":
+			events = append(events, event)
+			if event.ID > 0 {
+				seen++
+			}
+			if (ids > 0 && seen == ids) || event.Type == eventComplete {
+				return events
+			}
+			event = sseEvent{}
+		}
+	}
+	t.Fatalf("stream ended before its events were read: %v", events)
+	return nil
+}
+
+func TestDynamicPipelineStreamResumesFromLastEventID(t *testing.T) {
+	release := make(chan struct{})
+	var searches atomic.Int32
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		if searches.Add(1) > 2 {
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+
+	// The searches past the second wait for the client to reconnect
+	routes := s.RegisterRoutes()
+	var reconnected sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Last-Event-ID") != "" {
+			reconnected.Do(func() { close(release) })
+		}
+		routes.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	executionID := domain.NewID().String()
+	url := server.URL + "/dynamic?q=novasco&depth=1&stream=true&execution_id=" + executionID
+
+	first := readSSE(t, url, "", 2)
+
+	// The pipeline keeps running once the server notices the dropped
+	// connection
+	stream := s.liveStream(executionID)
+	if stream == nil {
+		t.Fatalf("expected the pipeline to outlive its connection")
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		stream.mu.Lock()
+		detached := stream.expiry != nil
+		stream.mu.Unlock()
+		if detached {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the dropped connection to let go of the pipeline")
+		}
+	}
+
+	// The client reconnects with the ID of the last step it received
+	second := readSSE(t, url, strconv.Itoa(first[len(first)-1].ID), 0)
+
+	stepIDs := make(map[string]int)
+	var ids []int
+	total := 0
+	for _, event := range append(first, second...) {
+		if event.ID > 0 {
+			stepIDs[event.StepID]++
+			ids = append(ids, event.ID)
+		}
+		if event.Type == eventSummary {
+			total = event.Total
+		}
+	}
+
+	// Every step is received once, in order
+	if total <= 2 {
+		t.Fatalf("expected the pipeline to run past the dropped connection, got %d steps", total)
+	}
+	if len(ids) != total || len(stepIDs) != total {
+		t.Errorf("expected each of the %d steps once, got %v", total, stepIDs)
+	}
+	for i, id := range ids {
+		if id != i+1 {
+			t.Errorf("expected the event IDs to count the steps, got %v", ids)
+			break
+		}
+	}
+}
+
+func TestDynamicPipelineStreamStopsWhenBudgetIsExhausted(t *testing.T) {
+	t.Setenv("PIPELINE_MAX_REQUESTS", "1")
+
+	// Each search spends a request
+	var searches atomic.Int32
+	search := companySearch(&searches)
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		custom.BudgetFromContext(ctx).Record(1, 0)
+		return search(ctx, domainType, params)
+	})
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=2&stream=true&delay_ms=0", nil))
+
+	if summary := sseSummary(t, rec.Body.String()); summary.StopReason != domain.StopReasonBudgetExhausted || !summary.BudgetLimited {
+		t.Errorf("expected the streamed run to stop on its budget, got %+v", summary)
+	}
+}
+
+func TestDynamicPipelineStreamStopsAtMaxTotalSteps(t *testing.T) {
+	t.Setenv("PIPELINE_MAX_TOTAL_STEPS", "3")
+
+	var searches atomic.Int32
+	s, _ := newPipelineServer(companySearch(&searches))
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=2&stream=true&delay_ms=0", nil))
+
+	if searches.Load() != 3 {
+		t.Errorf("expected the streamed run to halt after 3 searches, got %d", searches.Load())
+	}
+	if summary := sseSummary(t, rec.Body.String()); summary.StopReason != domain.StopReasonStepLimit || !summary.BudgetLimited {
+		t.Errorf("expected the streamed run to stop at the step limit, got %+v", summary)
+	}
+}
+
+func TestDynamicPipelineStreamStoresRunInSession(t *testing.T) {
+	var searches atomic.Int32
+	s, _ := newPipelineServer(companySearch(&searches))
+
+	ctx := context.Background()
+	session := &domain.Session{Name: "novasco"}
+	if err := s.GetRepositories().GetSessionRepository().Create(ctx, session); err != nil {
+		t.Fatalf("failed to create the session: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&stream=true&delay_ms=0&session_id="+session.ID.String(), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	pipelines, err := s.GetRepositories().GetPipelineRepository().GetPipelinesBySessionID(ctx, session.ID.String())
+	if err != nil {
+		t.Fatalf("failed to list the runs of the session: %v", err)
+	}
+	if len(pipelines) != 1 || pipelines[0].Config.Query != "novasco" {
+		t.Errorf("expected the streamed run to be stored in the session, got %+v", pipelines)
+	}
+}
+
+// streamSummary is the output of the summary of a streamed run
+type streamSummary struct {
+	TotalSteps    int    `json:"total_steps"`
+	StopReason    string `json:"stop_reason"`
+	BudgetLimited bool   `json:"budget_limited"`
+}
+
+// sseSummary returns the summary of an SSE stream
+func sseSummary(t *testing.T, body string) streamSummary {
+	t.Helper()
+
+	_, data, found := strings.Cut(body, "event: summary\ndata: ")
+	if !found {
+		t.Fatalf("expected a summary, got %q", body)
+	}
+	data, _, _ = strings.Cut(data, "\n")
+
+	var summary struct {
+		Step struct {
+			Output streamSummary `json:"output"`
+		} `json:"step"`
+	}
+	if err := json.Unmarshal([]byte(data), &summary); err != nil {
+		t.Fatalf("failed to decode the summary: %v", err)
+	}
+	return summary.Step.Output
+}
+
+func TestDynamicPipelineStreamRunsStepsConcurrently(t *testing.T) {
+	// Each search waits for another one to start, which a sequential run
+	// never does
+	var mu sync.Mutex
+	running, peak := 0, 0
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			mu.Lock()
+			overlapped := peak > 1
+			mu.Unlock()
+			if overlapped {
+				break
+			}
+		}
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+
+	rec := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dynamic?q=novasco&depth=1&stream=true&delay_ms=0", nil))
+
+	if peak < 2 {
+		t.Errorf("expected the streamed steps to run concurrently, got at most %d at once", peak)
+	}
+	if events := sseEvents(rec.Body.String()); len(events) == 0 || events[len(events)-1] != eventComplete {
+		t.Errorf("expected the stream to complete, got %v", events)
+	}
+}
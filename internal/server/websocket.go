@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"insightful-intel/internal/infra"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsMessage is a message sent to a client following a pipeline over a
+// WebSocket, carrying the type and data of the matching SSE event
+type wsMessage struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// wsAction is a message a client following a pipeline over a WebSocket sends
+type wsAction struct {
+	Action string `json:"action"`
+}
+
+// wsActionCancel asks for the pipeline to stop
+const wsActionCancel = "cancel"
+
+// wsWriteTimeout is how long a message may take to be written to the client
+const wsWriteTimeout = 10 * time.Second
+
+// wsUpgrader accepts any origin, like the SSE stream
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// dynamicPipelineWebSocketHandler streams a dynamic pipeline over a
+// WebSocket, sending the messages of the SSE stream as JSON. The client
+// stops the pipeline by sending {"action":"cancel"} or by disconnecting.
+func (s *Server) dynamicPipelineWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := s.parsePipelineParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The upgrader answers the requests that are not a WebSocket handshake
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s.streamPipelineWebSocket(r, conn, params)
+}
+
+// streamPipelineWebSocket runs a pipeline and sends its steps to conn until
+// it finishes, then closes the connection with a normal closure. The client
+// is pinged every keepalive interval and dropped when it misses two pongs.
+func (s *Server) streamPipelineWebSocket(r *http.Request, conn *websocket.Conn, params pipelineParams) {
+	stream := s.startPipelineStream(r.Context(), "", params)
+	defer stream.abandon()
+
+	keepaliveInterval := s.keepaliveInterval
+	if keepaliveInterval <= 0 {
+		keepaliveInterval = DefaultKeepaliveInterval
+	}
+	pongTimeout := 2 * keepaliveInterval
+
+	// Read the client messages, cancelling the pipeline when asked to or when
+	// the connection closes. Messages that are not actions are ignored.
+	conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	go func() {
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					slog.Warn("pipeline WebSocket closed", slog.String("query", params.query), slog.Any("error", err))
+				}
+				stream.cancel()
+				return
+			}
+
+			var action wsAction
+			if err := json.Unmarshal(message, &action); err == nil && action.Action == wsActionCancel {
+				slog.Info("pipeline cancelled by the client", slog.String("query", params.query))
+				stream.cancel()
+			}
+		}
+	}()
+
+	ping := time.NewTicker(keepaliveInterval)
+	defer ping.Stop()
+
+	// The error or the summary comes last, before the steps channel closes
+	stepCount := 0
+	for {
+		select {
+		case step, ok := <-stream.steps:
+			if !ok {
+				writeWebSocketJSON(conn, wsMessage{
+					Event: eventComplete,
+					Data: map[string]interface{}{
+						"message":     "Pipeline execution completed",
+						"total_steps": stepCount,
+					},
+				})
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteTimeout))
+				return
+			}
+			infra.DebugDump("step", step)
+
+			eventType := stepEventType(step)
+			if eventType == eventStep && completedStep(step) {
+				stepCount++
+			}
+			if err := writeWebSocketJSON(conn, wsMessage{Event: eventType, Data: stepEventData(stepCount, step)}); err != nil {
+				return
+			}
+
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeWebSocketJSON sends v to conn as a JSON text message
+func writeWebSocketJSON(conn *websocket.Conn, v interface{}) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(v)
+}
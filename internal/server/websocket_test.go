@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"insightful-intel/internal/domain"
+	"insightful-intel/internal/module"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialPipelineWebSocket opens a WebSocket following a pipeline of server
+// started with query, failing the test after 5 seconds
+func dialPipelineWebSocket(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/dynamic/ws?"+query, nil)
+	if err != nil {
+		t.Fatalf("failed to open the WebSocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestDynamicPipelineWebSocketStreamsStepsAndCancels(t *testing.T) {
+	// The searches past the first wait for the pipeline to be cancelled
+	var searches atomic.Int32
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		if searches.Add(1) > 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	conn := dialPipelineWebSocket(t, server, "q=novasco&depth=1")
+
+	type message struct {
+		Event string `json:"event"`
+		Data  struct {
+			StepNumber int `json:"step_number"`
+			Step       struct {
+				Success bool `json:"success"`
+			} `json:"step"`
+		} `json:"data"`
+	}
+	receive := func() message {
+		t.Helper()
+		var m message
+		if err := conn.ReadJSON(&m); err != nil {
+			t.Fatalf("failed to receive a message: %v", err)
+		}
+		return m
+	}
+
+	// The first step completes, then the client cancels the pipeline
+	for {
+		m := receive()
+		if m.Event != eventStep {
+			t.Fatalf("expected step messages before the cancellation, got %q", m.Event)
+		}
+		if m.Data.Step.Success {
+			if m.Data.StepNumber != 1 {
+				t.Errorf("expected the first completed step to be numbered 1, got %d", m.Data.StepNumber)
+			}
+			break
+		}
+	}
+	if err := conn.WriteJSON(map[string]string{"action": "cancel"}); err != nil {
+		t.Fatalf("failed to send the cancellation: %v", err)
+	}
+
+	var events []string
+	for {
+		m := receive()
+		events = append(events, m.Event)
+		if m.Event == eventComplete {
+			break
+		}
+	}
+	if len(events) < 2 || events[len(events)-2] != eventError {
+		t.Errorf("expected the cancelled pipeline to end with an error, got %v", events)
+	}
+	// The initial steps run at once, and none runs past the cancellation
+	if got, want := int(searches.Load()), len(module.AvailableDomainTypes()); got != want {
+		t.Errorf("expected only the %d initial searches, got %d", want, got)
+	}
+}
+
+func TestDynamicPipelineWebSocketClosesNormallyAfterCompletion(t *testing.T) {
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		return &domain.DomainSearchResult{Success: true, DomainType: domainType, SearchParameter: params.Query}, nil
+	})
+
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	conn := dialPipelineWebSocket(t, server, "q=novasco&depth=1")
+
+	var last wsMessage
+	for {
+		var m wsMessage
+		err := conn.ReadJSON(&m)
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Fatalf("expected a normal closure, got %v", err)
+			}
+			break
+		}
+		last = m
+	}
+	if last.Event != eventComplete {
+		t.Errorf("expected the complete message before the closure, got %q", last.Event)
+	}
+}
+
+func TestDynamicPipelineWebSocketDropsUnresponsiveClient(t *testing.T) {
+	// The searches wait for the pipeline to be cancelled
+	cancelled := make(chan struct{})
+	var once sync.Once
+	s, _ := newPipelineServer(func(ctx context.Context, domainType domain.DomainType, params domain.DomainSearchParams) (*domain.DomainSearchResult, error) {
+		<-ctx.Done()
+		once.Do(func() { close(cancelled) })
+		return nil, ctx.Err()
+	})
+	s.keepaliveInterval = 10 * time.Millisecond
+
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	// The client reads the pings without answering them
+	conn := dialPipelineWebSocket(t, server, "q=novasco&depth=1")
+	var pings atomic.Int32
+	conn.SetPingHandler(func(string) error {
+		pings.Add(1)
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the pipeline to be cancelled once the client stopped answering the pings")
+	}
+	if pings.Load() == 0 {
+		t.Error("expected the client to be pinged")
+	}
+}
+
+func TestDynamicPipelineWebSocketRejectsInvalidParameters(t *testing.T) {
+	s, _ := newMockServer(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/dynamic/ws?q=novasco&depth=abc", nil)
+	recorder := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), "'depth'") {
+		t.Errorf("expected the error to name the parameter, got %q", recorder.Body.String())
+	}
+}
@@ -0,0 +1,79 @@
+// Package textmatch compares names as they are written across sources, where
+// casing, accents, punctuation and word order vary.
+package textmatch
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u", "â", "a", "ê", "e", "ô", "o", "ç", "c",
+)
+
+// Normalize lowercases the text, folds its accents, replaces punctuation with
+// spaces and collapses them
+func Normalize(text string) string {
+	text = accentReplacer.Replace(strings.ToLower(text))
+
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, text)
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Similarity returns the edit distance similarity of two texts once
+// normalized, from 0 (different) to 1 (equal)
+func Similarity(a, b string) float64 {
+	return similarity(Normalize(a), Normalize(b))
+}
+
+// NameSimilarity is the Similarity of two names, ignoring the order of their
+// words so "PEREZ GOMEZ, JUAN" matches "Juan Perez Gomez"
+func NameSimilarity(a, b string) float64 {
+	a, b = Normalize(a), Normalize(b)
+	return max(similarity(a, b), similarity(sortWords(a), sortWords(b)))
+}
+
+func sortWords(text string) string {
+	words := strings.Fields(text)
+	slices.Sort(words)
+	return strings.Join(words, " ")
+}
+
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
@@ -0,0 +1,21 @@
+package textmatch
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize("  PÉREZ GÓMEZ, José-Antonio  "); got != "perez gomez jose antonio" {
+		t.Errorf("unexpected normalized text %q", got)
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if score := NameSimilarity("Jose Antonio Perez Gomez", "PÉREZ GÓMEZ, José Antonio"); score != 1 {
+		t.Errorf("expected reordered names to be equal, got %v", score)
+	}
+	if score := Similarity("Jose Antonio Perez Gomez", "PÉREZ GÓMEZ, José Antonio"); score >= 1 {
+		t.Errorf("expected Similarity to keep word order, got %v", score)
+	}
+	if score := NameSimilarity("Juan Perez", "Maria Fernandez"); score > 0.5 {
+		t.Errorf("expected different names to score low, got %v", score)
+	}
+}